@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	appsCmd.Flags().String("directory", "data/apps", "The directory of app definitions, one JSON file per app.")
+
+	generatorCmd.AddCommand(appsCmd)
+}
+
+var appsCmd = &cobra.Command{
+	Use:   "apps",
+	Short: "Generate the apps.json database from a directory of app definitions",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command, formatJSON)
+		if err != nil {
+			return err
+		}
+
+		directory, _ := command.Flags().GetString("directory")
+		apps, err := readApps(directory)
+		if err != nil {
+			return err
+		}
+
+		if format != formatJSON {
+			return printReport(format, appsReport(apps))
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		if indent, _ := command.Flags().GetBool("indent"); indent {
+			encoder.SetIndent("", "  ")
+		}
+		if err := encoder.Encode(apps); err != nil {
+			return errors.Wrap(err, "failed to encode apps result")
+		}
+
+		return nil
+	},
+}
+
+// appsReport lists the generated apps, for printing in formats other than the apps.json database.
+type appsReport []*model.App
+
+func (r appsReport) printTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tHOSTING\tMANIFEST URL")
+	for _, app := range r {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", app.ID, app.DisplayName, app.HostingType, app.ManifestURL)
+	}
+
+	return tw.Flush()
+}
+
+// readApps reads and validates the app definitions in the given directory, sorted by id.
+func readApps(directory string) ([]*model.App, error) {
+	paths, err := filepath.Glob(filepath.Join(directory, "*.json"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list apps in %s", directory)
+	}
+
+	apps := []*model.App{}
+	ids := map[string]string{}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", path)
+		}
+
+		app := &model.App{}
+		if err := json.Unmarshal(data, app); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", path)
+		}
+		if err := app.IsValid(); err != nil {
+			return nil, errors.Wrapf(err, "invalid app in %s", path)
+		}
+		if existing, ok := ids[app.ID]; ok {
+			return nil, errors.Errorf("app %s is defined by both %s and %s", app.ID, existing, path)
+		}
+		ids[app.ID] = path
+
+		logger.Debugf("read app %s from %s", app.ID, path)
+		apps = append(apps, app)
+	}
+
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].ID < apps[j].ID
+	})
+
+	return apps, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// assetPatterns select the plugin bundle and its signatures among the assets of a release by
+// name, using the syntax of path.Match. Either pattern, if empty, falls back to the default
+// heuristics: the release's .tar.gz asset, signed by its .sig and .asc assets, ignoring the
+// per-platform bundles naming a platform, e.g. -linux-arm64, and their signatures.
+type assetPatterns struct {
+	// Bundle matches the name of the plugin bundle, e.g. *-linux-amd64.tar.gz. At most one asset
+	// of each release may match.
+	Bundle string
+	// Signature matches the names of the bundle's signatures, e.g. *-linux-amd64.tar.gz.sig.
+	Signature string
+}
+
+// isValid verifies the patterns are well-formed.
+func (p assetPatterns) isValid() error {
+	for _, pattern := range []string{p.Bundle, p.Signature} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "invalid asset pattern %s", pattern)
+		}
+	}
+
+	return nil
+}
+
+// isOldStyleBundle identifies the per-platform assets ignored by the default heuristics.
+func isOldStyleBundle(assetName string) bool {
+	bundleName := strings.TrimSuffix(strings.TrimSuffix(assetName, ".sig"), ".asc")
+	return strings.Contains(assetName, "-amd64") || bundlePlatform(bundleName) != ""
+}
+
+// matchesBundle reports whether the named asset is the release's plugin bundle.
+func (p assetPatterns) matchesBundle(assetName string) (bool, error) {
+	if p.Bundle != "" {
+		return path.Match(p.Bundle, assetName)
+	}
+
+	return strings.HasSuffix(assetName, ".tar.gz") && !isOldStyleBundle(assetName), nil
+}
+
+// matchesSignature reports whether the named asset signs the release's plugin bundle.
+func (p assetPatterns) matchesSignature(assetName string) (bool, error) {
+	if p.Signature != "" {
+		return path.Match(p.Signature, assetName)
+	}
+
+	return (strings.HasSuffix(assetName, ".sig") || strings.HasSuffix(assetName, ".asc")) && !isOldStyleBundle(assetName), nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// The types identifying the attestations written by the generator.
+const (
+	inTotoStatementType   = "https://in-toto.io/Statement/v1"
+	slsaProvenanceType    = "https://slsa.dev/provenance/v1"
+	attestationBuildType  = "https://github.com/mattermost/mattermost-marketplace/generator/v1"
+	attestationBuilderID  = "https://github.com/mattermost/mattermost-marketplace/cmd/generator"
+	attestationFileSuffix = ".intoto.json"
+)
+
+func init() {
+	generatorCmd.Flags().String("attestations-output", "", "An optional directory to which to write an in-toto statement of SLSA provenance for each plugin version, describing how its entry was produced and verified.")
+}
+
+// inTotoStatement is an in-toto attestation statement, binding a predicate to its subjects.
+type inTotoStatement struct {
+	Type          string                `json:"_type"`
+	Subject       []*resourceDescriptor `json:"subject"`
+	PredicateType string                `json:"predicateType"`
+	Predicate     *slsaProvenance       `json:"predicate"`
+}
+
+// resourceDescriptor identifies an artifact by its digests.
+type resourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// slsaProvenance is a SLSA v1 provenance predicate.
+type slsaProvenance struct {
+	BuildDefinition *slsaBuildDefinition `json:"buildDefinition"`
+	RunDetails      *slsaRunDetails      `json:"runDetails"`
+}
+
+type slsaBuildDefinition struct {
+	BuildType            string                 `json:"buildType"`
+	ExternalParameters   *attestationParameters `json:"externalParameters"`
+	InternalParameters   *attestationChecks     `json:"internalParameters"`
+	ResolvedDependencies []*resourceDescriptor  `json:"resolvedDependencies,omitempty"`
+}
+
+// attestationParameters describe the release from which the generator recorded the plugin version.
+type attestationParameters struct {
+	PluginID         string `json:"pluginId"`
+	Version          string `json:"version"`
+	SourceRepository string `json:"sourceRepository,omitempty"`
+	ReleaseTag       string `json:"releaseTag,omitempty"`
+}
+
+// attestationChecks describe how the generator verified the plugin version.
+type attestationChecks struct {
+	DataCommit string `json:"dataCommit,omitempty"`
+	// ChecksumsReverified records that the bundle was downloaded again by this run and matched
+	// its recorded checksums.
+	ChecksumsReverified bool `json:"checksumsReverified"`
+	// SigningKeys are the public key hashes of the signatures recorded for the bundles.
+	SigningKeys []string `json:"signingKeys,omitempty"`
+}
+
+type slsaRunDetails struct {
+	Builder  *slsaBuilder  `json:"builder"`
+	Metadata *slsaMetadata `json:"metadata,omitempty"`
+}
+
+type slsaBuilder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type slsaMetadata struct {
+	StartedOn *time.Time `json:"startedOn,omitempty"`
+}
+
+// newAttestation describes how the given plugin version was produced and verified, or returns nil
+// if it records no checksums by which to identify its bundles.
+func newAttestation(plugin *model.Plugin, checksumsReverified bool) *inTotoStatement {
+	var subjects []*resourceDescriptor
+	signingKeys := map[string]bool{}
+	if plugin.DownloadURL != "" && plugin.Checksums != nil {
+		subjects = append(subjects, newBundleDescriptor(plugin.DownloadURL, plugin.Checksums))
+		for _, signature := range plugin.Signatures {
+			signingKeys[signature.PublicKeyHash] = true
+		}
+	}
+	platforms := make([]string, 0, len(plugin.Platforms))
+	for platform := range plugin.Platforms {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	for _, platform := range platforms {
+		bundle := plugin.Platforms[platform]
+		if bundle.Checksums == nil {
+			continue
+		}
+		subjects = append(subjects, newBundleDescriptor(bundle.DownloadURL, bundle.Checksums))
+		for _, signature := range bundle.Signatures {
+			signingKeys[signature.PublicKeyHash] = true
+		}
+	}
+	if len(subjects) == 0 {
+		return nil
+	}
+
+	parameters := &attestationParameters{
+		PluginID: plugin.Manifest.Id,
+		Version:  plugin.Manifest.Version,
+	}
+	checks := &attestationChecks{
+		ChecksumsReverified: checksumsReverified,
+	}
+	for publicKeyHash := range signingKeys {
+		checks.SigningKeys = append(checks.SigningKeys, publicKeyHash)
+	}
+	sort.Strings(checks.SigningKeys)
+	builder := &slsaBuilder{ID: attestationBuilderID}
+	var metadata *slsaMetadata
+
+	if provenance := plugin.Provenance; provenance != nil {
+		parameters.SourceRepository = provenance.SourceRepository
+		parameters.ReleaseTag = provenance.ReleaseTag
+		checks.DataCommit = provenance.DataCommit
+		if provenance.GeneratorVersion != "" {
+			builder.Version = map[string]string{"generator": provenance.GeneratorVersion}
+		}
+		if !provenance.GeneratedAt.IsZero() {
+			generatedAt := provenance.GeneratedAt.UTC()
+			metadata = &slsaMetadata{StartedOn: &generatedAt}
+		}
+	}
+
+	return &inTotoStatement{
+		Type:          inTotoStatementType,
+		Subject:       subjects,
+		PredicateType: slsaProvenanceType,
+		Predicate: &slsaProvenance{
+			BuildDefinition: &slsaBuildDefinition{
+				BuildType:            attestationBuildType,
+				ExternalParameters:   parameters,
+				InternalParameters:   checks,
+				ResolvedDependencies: subjects,
+			},
+			RunDetails: &slsaRunDetails{
+				Builder:  builder,
+				Metadata: metadata,
+			},
+		},
+	}
+}
+
+// newBundleDescriptor identifies the bundle at the given url by its recorded checksums.
+func newBundleDescriptor(downloadURL string, checksums *model.Checksums) *resourceDescriptor {
+	descriptor := &resourceDescriptor{URI: downloadURL, Digest: map[string]string{}}
+	if u, err := url.Parse(downloadURL); err == nil && path.Base(u.Path) != "." && path.Base(u.Path) != "/" {
+		descriptor.Name = path.Base(u.Path)
+	}
+	if checksums.SHA256 != "" {
+		descriptor.Digest["sha256"] = checksums.SHA256
+	}
+	if checksums.SHA512 != "" {
+		descriptor.Digest["sha512"] = checksums.SHA512
+	}
+
+	return descriptor
+}
+
+// writeAttestations writes an attestation of each of the given plugin versions to
+// <id>/<version>.intoto.json in the given directory, skipping those without checksums.
+func writeAttestations(directory string, plugins []*model.Plugin, checksumsReverified bool) error {
+	written := 0
+	for _, plugin := range plugins {
+		attestation := newAttestation(plugin, checksumsReverified)
+		if attestation == nil {
+			logger.Debugf("not attesting %s %s without checksums", plugin.Manifest.Id, plugin.Manifest.Version)
+			continue
+		}
+		if !isPathSegment(plugin.Manifest.Id) || !isPathSegment(plugin.Manifest.Version+attestationFileSuffix) {
+			return errors.Errorf("%s %s is not usable as a file name", plugin.Manifest.Id, plugin.Manifest.Version)
+		}
+
+		data, err := json.MarshalIndent(attestation, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode attestation of %s %s", plugin.Manifest.Id, plugin.Manifest.Version)
+		}
+
+		pluginDirectory := filepath.Join(directory, plugin.Manifest.Id)
+		if err := os.MkdirAll(pluginDirectory, 0755); err != nil {
+			return errors.Wrapf(err, "failed to create %s", pluginDirectory)
+		}
+		attestationPath := filepath.Join(pluginDirectory, plugin.Manifest.Version+attestationFileSuffix)
+		if err := ioutil.WriteFile(attestationPath, append(data, '\n'), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", attestationPath)
+		}
+		written++
+	}
+
+	logger.Infof("wrote %d attestations to %s", written, directory)
+
+	return nil
+}
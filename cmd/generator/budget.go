@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/pkg/errors"
+)
+
+// requestPriority ranks the GitHub API requests of a run competing for its rate limit.
+type requestPriority int
+
+const (
+	// priorityRequired marks requests needed to generate the database, e.g. listing releases.
+	priorityRequired requestPriority = iota
+	// priorityDeferrable marks requests only refreshing optional metadata, e.g. whether a
+	// repository is archived, which are deferred to a later run once the budget runs low.
+	priorityDeferrable
+)
+
+// errRequestDeferred reports a deferrable request skipped to preserve the rate limit.
+var errRequestDeferred = errors.New("request deferred to preserve the GitHub rate limit")
+
+// gitHubBudget tracks the remaining GitHub rate limit across every request of a run, including
+// concurrent ones, so that generation does not exhaust the token's quota midway.
+type gitHubBudget struct {
+	// reserve is the number of requests kept for required requests, below which deferrable
+	// requests are skipped.
+	reserve int
+	// maxWait is how long a required request may wait for the rate limit to reset once
+	// exhausted, rather than failing.
+	maxWait time.Duration
+	now     func() time.Time
+
+	lock sync.Mutex
+	// known is set once a response reported the rate limit of the current window.
+	known     bool
+	remaining int
+	reset     time.Time
+}
+
+// newGitHubBudget creates a budget keeping the given number of requests in reserve.
+func newGitHubBudget(reserve int, maxWait time.Duration) *gitHubBudget {
+	return &gitHubBudget{
+		reserve: reserve,
+		maxWait: maxWait,
+		now:     time.Now,
+	}
+}
+
+// request makes the given API request once the budget allows a request of the given priority,
+// then accounts for the rate limit reported by its response, if any.
+func (b *gitHubBudget) request(ctx context.Context, priority requestPriority, call func() (*github.Response, error)) error {
+	if err := b.acquire(ctx, priority); err != nil {
+		return err
+	}
+
+	resp, err := call()
+	if resp != nil {
+		b.update(resp.Rate)
+	}
+
+	return err
+}
+
+// acquire reserves a request of the given priority, waiting up to maxWait for the rate limit to
+// reset if it is exhausted.
+func (b *gitHubBudget) acquire(ctx context.Context, priority requestPriority) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.known {
+		return nil
+	}
+	if !b.now().Before(b.reset) {
+		// A new window began, whose limit the next response reports.
+		b.known = false
+		return nil
+	}
+
+	if priority == priorityDeferrable && b.remaining <= b.reserve {
+		return errRequestDeferred
+	}
+
+	if b.remaining <= 0 {
+		wait := b.reset.Sub(b.now())
+		if wait > b.maxWait {
+			return newExitError(exitCodeRateLimited, errors.Errorf("GitHub rate limit exhausted until %s", b.reset.UTC().Format(time.RFC3339)))
+		}
+
+		// Hold the lock while waiting, as every other request must wait as well.
+		logger.Warnf("GitHub rate limit exhausted, waiting %s for it to reset", wait.Round(time.Second))
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "failed to wait for GitHub rate limit")
+		case <-timer.C:
+		}
+
+		b.known = false
+		return nil
+	}
+
+	// Account for the request until its response reports the actual limit.
+	b.remaining--
+
+	return nil
+}
+
+// update records the rate limit reported by a response. Responses to concurrent requests may
+// arrive out of order, so the lowest remaining count of a window wins.
+func (b *gitHubBudget) update(rate github.Rate) {
+	if rate.Limit == 0 {
+		// The server reports no rate limit, as some GitHub Enterprise Servers do not.
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	reset := rate.Reset.Time
+	if b.known && reset.Equal(b.reset) && b.remaining < rate.Remaining {
+		return
+	}
+
+	b.known = true
+	b.remaining = rate.Remaining
+	b.reset = reset
+}
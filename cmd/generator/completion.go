@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	generatorCmd.AddCommand(completionCmd)
+}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion <bash|zsh|powershell>",
+	Short: "Generate a shell completion script",
+	Long: `Generate a completion script for the given shell. To load completions in bash:
+
+  source <(generator completion bash)`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "powershell"},
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		root := command.Root()
+		switch args[0] {
+		case "bash":
+			return root.GenBashCompletion(os.Stdout)
+		case "zsh":
+			return root.GenZshCompletion(os.Stdout)
+		case "powershell":
+			return root.GenPowerShellCompletion(os.Stdout)
+		default:
+			return configError(errors.Errorf("unsupported shell %s", args[0]))
+		}
+	},
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// The database formats to which the convert command converts.
+const (
+	databaseFormatV1      = "v1"
+	databaseFormatCompact = model.CompactDatabaseFormat
+	databaseFormatV2      = model.DatabaseV2Format
+)
+
+func init() {
+	convertCmd.Flags().String("database", "plugins.json", "The database to convert, in any format.")
+	convertCmd.Flags().String("output-file", "", "The file to which to write the converted database, defaulting to stdout.")
+	convertCmd.Flags().String("to", databaseFormatV1, "The format to which to convert, either v1, compact or v2.")
+
+	generatorCmd.AddCommand(convertCmd)
+}
+
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert a plugins.json database between the flat v1, compact and v2 formats",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command, "")
+		if err != nil {
+			return err
+		}
+		output, _ := command.Flags().GetString("output-file")
+		if format != "" && output == "" {
+			return stdoutReportError("output-file")
+		}
+
+		indent, _ := command.Flags().GetBool("indent")
+		options := model.PluginsWriterOptions{Indent: indent}
+		to, _ := command.Flags().GetString("to")
+		switch to {
+		case databaseFormatV1:
+		case databaseFormatCompact:
+			options.Compact = true
+		case databaseFormatV2:
+			options.V2 = true
+		default:
+			return configError(errors.Errorf("unsupported format %s", to))
+		}
+
+		database, _ := command.Flags().GetString("database")
+		data, err := ioutil.ReadFile(database)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", database)
+		}
+		plugins, err := model.PluginsFromReader(bytes.NewReader(data))
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse %s", database)
+		}
+
+		var converted bytes.Buffer
+		if err := model.PluginsToWriter(&converted, plugins, options); err != nil {
+			return err
+		}
+
+		if output == "" {
+			_, err = converted.WriteTo(os.Stdout)
+		} else {
+			err = ioutil.WriteFile(output, converted.Bytes(), 0644)
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to write converted database")
+		}
+
+		logger.Infof("converted %d plugin versions from %d to %d bytes", len(plugins), len(data), converted.Len())
+
+		if format != "" {
+			return printReport(format, newWriteReport(output, plugins))
+		}
+
+		return nil
+	},
+}
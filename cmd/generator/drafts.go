@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	generatorCmd.Flags().Bool("include-drafts", false, "Whether to also index draft releases into a separate staging database, previewing the catalog as it would be were the drafts published. Requires --github-token for repositories hosted on GitHub.")
+	generatorCmd.Flags().String("staging-output", "plugins-staging.json", "The file to which to write the staging database when --include-drafts is given.")
+}
+
+// assetDownloader downloads the assets of a repository's releases through its release provider.
+type assetDownloader struct {
+	ctx            context.Context
+	logger         logrus.FieldLogger
+	provider       ReleaseProvider
+	owner          string
+	repositoryName string
+}
+
+// open downloads the given asset of a release, draft or otherwise.
+func (d *assetDownloader) open(asset *ReleaseAsset, draft bool) (io.ReadCloser, error) {
+	return d.provider.OpenAsset(d.ctx, d.owner, d.repositoryName, asset, draft)
+}
+
+// writeStagingDatabase writes the staging plugins to the given path.
+func writeStagingDatabase(path string, plugins []*model.Plugin, options model.PluginsWriterOptions) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create staging database %s", path)
+	}
+	defer file.Close()
+
+	if err := model.PluginsToWriter(file, plugins, options); err != nil {
+		return errors.Wrap(err, "failed to encode staging plugins")
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/spf13/cobra"
+)
+
+// The exit codes of the generator, distinguishing the kinds of failure so that CI pipelines can
+// branch on them, e.g. retrying runs that were rate limited.
+const (
+	// exitCodeFailure reports an unclassified failure.
+	exitCodeFailure = 1
+	// exitCodeConfig reports invalid flags or configuration.
+	exitCodeConfig = 2
+	// exitCodeGitHubAuth reports GitHub rejecting the credentials of --github-token.
+	exitCodeGitHubAuth = 3
+	// exitCodeRateLimited reports GitHub rate limiting the run.
+	exitCodeRateLimited = 4
+	// exitCodeValidation reports the generated database failing verification, e.g. of its
+	// checksums, urls, quality or reproducibility.
+	exitCodeValidation = 5
+	// exitCodePartial reports repositories that failed to generate with --keep-going, after
+	// writing the database of the remaining repositories.
+	exitCodePartial = 6
+)
+
+func init() {
+	generatorCmd.SetFlagErrorFunc(func(command *cobra.Command, err error) error {
+		return newExitError(exitCodeConfig, err)
+	})
+}
+
+// exitError classifies the wrapped error by the exit code with which the generator reports it.
+type exitError struct {
+	code int
+	err  error
+}
+
+// newExitError classifies the given error by the given exit code.
+func newExitError(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &exitError{code: code, err: err}
+}
+
+// configError classifies the given error as invalid configuration.
+func configError(err error) error {
+	return newExitError(exitCodeConfig, err)
+}
+
+// validationError classifies the given error as a validation failure.
+func validationError(err error) error {
+	return newExitError(exitCodeValidation, err)
+}
+
+// Error returns the message of the wrapped error.
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+// Cause returns the wrapped error, for errors.Cause.
+func (e *exitError) Cause() error {
+	return e.err
+}
+
+// exitCode returns the exit code with which to report the given error, as classified by the
+// outermost exitError or else by the GitHub error causing it.
+func exitCode(err error) int {
+	for err != nil {
+		switch e := err.(type) {
+		case *exitError:
+			return e.code
+		case *github.RateLimitError, *github.AbuseRateLimitError:
+			return exitCodeRateLimited
+		case *github.ErrorResponse:
+			if e.Response != nil && (e.Response.StatusCode == http.StatusUnauthorized || e.Response.StatusCode == http.StatusForbidden) {
+				return exitCodeGitHubAuth
+			}
+		}
+
+		cause, ok := err.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		err = cause.Cause()
+	}
+
+	return exitCodeFailure
+}
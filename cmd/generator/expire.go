@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	expireCmd.Flags().String("database", "plugins.json", "The database from which to remove lapsed pre-releases, in any format.")
+	expireCmd.Flags().String("output-file", "", "The file to which to write the cleaned database, defaulting to stdout.")
+	expireCmd.Flags().Duration("pre-release-ttl", 0, "How long after their release beta and experimental versions not recording their own expires_at are kept, as configured for the server, or 0 to remove only versions past their expires_at.")
+
+	generatorCmd.AddCommand(expireCmd)
+}
+
+var expireCmd = &cobra.Command{
+	Use:   "expire",
+	Short: "Remove the beta and experimental versions that lapsed without being promoted from an existing database",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command, "")
+		if err != nil {
+			return err
+		}
+		outputPath, _ := command.Flags().GetString("output-file")
+		if format != "" && outputPath == "" {
+			return stdoutReportError("output-file")
+		}
+
+		preReleaseTTL, _ := command.Flags().GetDuration("pre-release-ttl")
+		if preReleaseTTL < 0 {
+			return configError(errors.New("--pre-release-ttl must not be negative"))
+		}
+
+		now, err := getSourceDate(command)
+		if err != nil {
+			return configError(err)
+		}
+		if now.IsZero() {
+			now = time.Now().UTC()
+		}
+
+		database, _ := command.Flags().GetString("database")
+		data, err := ioutil.ReadFile(database)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", database)
+		}
+		plugins, err := model.PluginsFromReader(bytes.NewReader(data))
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse %s", database)
+		}
+
+		plugins, expired := expirePlugins(plugins, preReleaseTTL, now)
+
+		var output bytes.Buffer
+		if err := model.PluginsToWriter(&output, plugins, getWriterOptions(command)); err != nil {
+			return err
+		}
+
+		if outputPath == "" {
+			_, err = output.WriteTo(os.Stdout)
+		} else {
+			err = ioutil.WriteFile(outputPath, output.Bytes(), 0644)
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to write cleaned database")
+		}
+
+		logger.Infof("removed %d lapsed pre-releases", expired)
+
+		if format != "" {
+			return printReport(format, newWriteReport(outputPath, plugins))
+		}
+
+		return nil
+	},
+}
+
+// expirePlugins returns the given plugins less the versions lapsed by the given time, logging each
+// removed, and the number of versions removed.
+func expirePlugins(plugins []*model.Plugin, preReleaseTTL time.Duration, now time.Time) ([]*model.Plugin, int) {
+	kept := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		if plugin.IsExpired(preReleaseTTL, now) {
+			logger.Infof("removed %s %s %s, lapsed at %s", plugin.ReleaseStage, plugin.Manifest.Id, plugin.Manifest.Version, plugin.Expiry(preReleaseTTL).UTC().Format(time.RFC3339))
+			continue
+		}
+		kept = append(kept, plugin)
+	}
+
+	return kept, len(plugins) - len(kept)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+func init() {
+	generatorCmd.PersistentFlags().String("github-token", "", "The optional GitHub token for API requests.")
+	generatorCmd.PersistentFlags().String("github-base-url", "", "The optional API url of a GitHub Enterprise Server hosting the repositories, e.g. https://github.example.com/api/v3/.")
+	generatorCmd.PersistentFlags().String("github-upload-url", "", "The optional upload url of the GitHub Enterprise Server given by --github-base-url, defaulting to the base url.")
+	generatorCmd.PersistentFlags().Int("github-reserve", 100, "The number of GitHub API requests to keep for generating the database, deferring optional requests, such as checking whether repositories are archived, once fewer remain.")
+	generatorCmd.PersistentFlags().Duration("github-max-wait", 0, "How long to wait for an exhausted GitHub rate limit to reset, rather than failing the run with exit code 4.")
+
+	registerReleaseProvider("github", newGitHubReleaseProvider)
+}
+
+// gitHubReleaseProvider lists the releases of repositories hosted on github.com, or on a GitHub
+// Enterprise Server.
+type gitHubReleaseProvider struct {
+	client *github.Client
+	// budget is shared by every request of the provider, so that a run stays within its rate
+	// limit.
+	budget *gitHubBudget
+}
+
+// newGitHubReleaseProvider creates a release provider configured by the --github-* flags.
+func newGitHubReleaseProvider(command *cobra.Command) (ReleaseProvider, error) {
+	githubToken, _ := command.Flags().GetString("github-token")
+
+	includeDrafts, _ := command.Flags().GetBool("include-drafts")
+	if includeDrafts && githubToken == "" {
+		return nil, errors.New("--include-drafts requires --github-token")
+	}
+
+	githubBaseURL, _ := command.Flags().GetString("github-base-url")
+	githubUploadURL, _ := command.Flags().GetString("github-upload-url")
+
+	return newGitHubReleaseProviderWithClient(command, githubToken, githubBaseURL, githubUploadURL)
+}
+
+// newGitHubReleaseProviderWithClient creates a release provider authenticating with the given
+// token to github.com or the given GitHub Enterprise Server, budgeting its rate limit as configured
+// by the --github-* flags.
+func newGitHubReleaseProviderWithClient(command *cobra.Command, githubToken, githubBaseURL, githubUploadURL string) (ReleaseProvider, error) {
+	client, err := newGitHubClient(githubToken, githubBaseURL, githubUploadURL)
+	if err != nil {
+		return nil, err
+	}
+
+	githubReserve, _ := command.Flags().GetInt("github-reserve")
+	if githubReserve < 0 {
+		return nil, errors.New("--github-reserve must not be negative")
+	}
+	githubMaxWait, _ := command.Flags().GetDuration("github-max-wait")
+
+	return &gitHubReleaseProvider{client: client, budget: newGitHubBudget(githubReserve, githubMaxWait)}, nil
+}
+
+// newGitHubClient creates a client to github.com, or to the GitHub Enterprise Server at the given
+// base url, authenticating with the given token if any.
+func newGitHubClient(githubToken, baseURL, uploadURL string) (*github.Client, error) {
+	var httpClient *http.Client
+	if githubToken != "" {
+		ctx := context.Background()
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: githubToken},
+		)
+		httpClient = oauth2.NewClient(ctx, ts)
+	}
+
+	if baseURL == "" {
+		if uploadURL != "" {
+			return nil, errors.New("--github-upload-url requires --github-base-url")
+		}
+		return github.NewClient(httpClient), nil
+	}
+	if uploadURL == "" {
+		uploadURL = baseURL
+	}
+
+	client, err := github.NewEnterpriseClient(baseURL, uploadURL, httpClient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create GitHub Enterprise client")
+	}
+
+	return client, nil
+}
+
+// RepositoryURL returns the web page of the given GitHub repository.
+func (p *gitHubReleaseProvider) RepositoryURL(ctx context.Context, owner, name string) (string, error) {
+	var repository *github.Repository
+	err := p.budget.request(ctx, priorityRequired, func() (*github.Response, error) {
+		var resp *github.Response
+		var err error
+		repository, resp, err = p.client.Repositories.Get(ctx, owner, name)
+		return resp, err
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get repository")
+	}
+
+	return repository.GetHTMLURL(), nil
+}
+
+// IsRepositoryArchived reports whether the given GitHub repository was archived. The check is
+// deferred with errRequestDeferred once the rate limit runs low.
+func (p *gitHubReleaseProvider) IsRepositoryArchived(ctx context.Context, owner, name string) (bool, error) {
+	var repository *github.Repository
+	err := p.budget.request(ctx, priorityDeferrable, func() (*github.Response, error) {
+		var resp *github.Response
+		var err error
+		repository, resp, err = p.client.Repositories.Get(ctx, owner, name)
+		return resp, err
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get repository")
+	}
+
+	return repository.GetArchived(), nil
+}
+
+// ListReleases returns all GitHub releases for the given repository. Draft releases are only
+// listed given a token with push access to the repository.
+func (p *gitHubReleaseProvider) ListReleases(ctx context.Context, owner, name string) ([]*Release, error) {
+	var result []*Release
+	options := &github.ListOptions{
+		Page:    0,
+		PerPage: 40,
+	}
+	for {
+		var releases []*github.RepositoryRelease
+		var resp *github.Response
+		err := p.budget.request(ctx, priorityRequired, func() (*github.Response, error) {
+			var err error
+			releases, resp, err = p.client.Repositories.ListReleases(ctx, owner, name, options)
+			return resp, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get releases for repository %s", name)
+		}
+
+		for _, release := range releases {
+			result = append(result, newGitHubRelease(release))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return result, nil
+}
+
+// newGitHubRelease converts the given GitHub release.
+func newGitHubRelease(release *github.RepositoryRelease) *Release {
+	publishedAt := release.GetPublishedAt()
+	if publishedAt.IsZero() {
+		publishedAt = release.GetCreatedAt()
+	}
+
+	result := &Release{
+		Name:        release.GetName(),
+		TagName:     release.GetTagName(),
+		HTMLURL:     release.GetHTMLURL(),
+		Body:        release.GetBody(),
+		Prerelease:  release.GetPrerelease(),
+		Draft:       release.GetDraft(),
+		PublishedAt: publishedAt.Time,
+	}
+	for _, asset := range release.Assets {
+		updatedAt := asset.GetUpdatedAt()
+		if updatedAt.IsZero() {
+			updatedAt = asset.GetCreatedAt()
+		}
+
+		result.Assets = append(result.Assets, &ReleaseAsset{
+			ID:          asset.GetID(),
+			Name:        asset.GetName(),
+			DownloadURL: asset.GetBrowserDownloadURL(),
+			UpdatedAt:   updatedAt.Time,
+		})
+	}
+
+	return result
+}
+
+// OpenAsset downloads the given asset. The assets of draft releases are not publicly downloadable,
+// and are instead fetched through the API with the client's credentials.
+func (p *gitHubReleaseProvider) OpenAsset(ctx context.Context, owner, name string, asset *ReleaseAsset, draft bool) (io.ReadCloser, error) {
+	downloadURL := asset.DownloadURL
+	if draft {
+		var body io.ReadCloser
+		var redirectURL string
+		// The download reports no rate limit, so it is only accounted for.
+		err := p.budget.request(ctx, priorityRequired, func() (*github.Response, error) {
+			var err error
+			body, redirectURL, err = p.client.Repositories.DownloadReleaseAsset(ctx, owner, name, asset.ID)
+			return nil, err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to download draft asset %s", asset.Name)
+		}
+		if body != nil {
+			return body, nil
+		}
+
+		downloadURL = redirectURL
+	}
+
+	return openURL(downloadURL)
+}
+
+// ReadRepositoryFile returns the contents of the given file of the repository at the given tag, or
+// nil if the repository has no such file.
+func (p *gitHubReleaseProvider) ReadRepositoryFile(ctx context.Context, owner, name, tag, path string) ([]byte, error) {
+	var file *github.RepositoryContent
+	var resp *github.Response
+	err := p.budget.request(ctx, priorityRequired, func() (*github.Response, error) {
+		var err error
+		file, _, resp, err = p.client.Repositories.GetContents(ctx, owner, name, path, &github.RepositoryContentGetOptions{Ref: tag})
+		return resp, err
+	})
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s at %s", path, tag)
+	}
+	if file == nil {
+		// The path names a directory.
+		return nil, nil
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s at %s", path, tag)
+	}
+
+	return []byte(content), nil
+}
+
+// IsReleaseAsset reports whether the given url is the browser download url of an asset of a
+// release of the given repository, on github.com or the GitHub Enterprise Server.
+func (p *gitHubReleaseProvider) IsReleaseAsset(owner, name, downloadURL string) bool {
+	webURL := "https://github.com/"
+	if baseURL := p.client.BaseURL; baseURL.Host != "api.github.com" {
+		webURL = baseURL.Scheme + "://" + baseURL.Host + "/"
+	}
+
+	// Owner and repository names are case-insensitive.
+	prefix := strings.ToLower(webURL + owner + "/" + name + "/releases/download/")
+	return strings.HasPrefix(strings.ToLower(downloadURL), prefix)
+}
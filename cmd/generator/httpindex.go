@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	generatorCmd.Flags().StringSlice("http-index", nil, "The https url of a JSON index, or a directory listing, of plugin bundles hosted outside a code-hosting platform, published as community plugins. May be repeated.")
+
+	registerReleaseProvider(httpIndexProvider, newHTTPIndexReleaseProvider)
+}
+
+// httpIndexProvider names the release provider reading static indexes of plugin bundles.
+const httpIndexProvider = "http-index"
+
+// httpIndex is the JSON index of the releases of a plugin served from an https server. Relative
+// asset urls are resolved against the url of the index.
+type httpIndex struct {
+	// HomepageURL is used as the homepage of plugins whose manifest has none, defaulting to the
+	// url of the index.
+	HomepageURL string              `json:"homepage_url"`
+	Releases    []*httpIndexRelease `json:"releases"`
+}
+
+// httpIndexRelease describes a release within an httpIndex.
+type httpIndexRelease struct {
+	Name        string            `json:"name"`
+	TagName     string            `json:"tag_name"`
+	HTMLURL     string            `json:"html_url"`
+	Body        string            `json:"body"`
+	Prerelease  bool              `json:"prerelease"`
+	PublishedAt time.Time         `json:"published_at"`
+	Assets      []*httpIndexAsset `json:"assets"`
+}
+
+// httpIndexAsset describes a bundle or signature of an httpIndexRelease.
+type httpIndexAsset struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// getHTTPIndexRepositories returns the repositories of the indexes given by --http-index. Each is
+// hosted by the http-index provider, with the index url split into the owner and name.
+func getHTTPIndexRepositories(command *cobra.Command) ([]repository, error) {
+	indexURLs, _ := command.Flags().GetStringSlice("http-index")
+
+	var repositories []repository
+	for _, indexURL := range indexURLs {
+		u, err := url.Parse(indexURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid index url %s", indexURL)
+		}
+		if u.Scheme != "https" || u.Host == "" {
+			return nil, errors.Errorf("index url %s must be an https url", indexURL)
+		}
+		if u.RawQuery != "" || u.Fragment != "" {
+			return nil, errors.Errorf("index url %s must not have a query or fragment", indexURL)
+		}
+
+		repositories = append(repositories, repository{
+			Provider:   httpIndexProvider,
+			Owner:      u.Host,
+			Name:       strings.TrimPrefix(u.EscapedPath(), "/"),
+			AuthorType: model.AuthorTypeCommunity,
+		})
+	}
+
+	return repositories, nil
+}
+
+// httpIndexReleaseProvider lists the releases of plugins served from an https server, either as
+// a JSON index or as a directory listing of bundles and their signatures.
+type httpIndexReleaseProvider struct {
+	client *http.Client
+	// indexes caches the releases read from each index url.
+	indexes map[string]*httpIndexReleases
+}
+
+// httpIndexReleases are the releases read from an index.
+type httpIndexReleases struct {
+	homepageURL string
+	releases    []*Release
+}
+
+// newHTTPIndexReleaseProvider creates a release provider reading static indexes.
+func newHTTPIndexReleaseProvider(command *cobra.Command) (ReleaseProvider, error) {
+	return &httpIndexReleaseProvider{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		indexes: map[string]*httpIndexReleases{},
+	}, nil
+}
+
+// RepositoryURL returns the homepage given by the index, or else the url of the index.
+func (p *httpIndexReleaseProvider) RepositoryURL(ctx context.Context, owner, name string) (string, error) {
+	index, err := p.getIndex(ctx, owner, name)
+	if err != nil {
+		return "", err
+	}
+
+	return index.homepageURL, nil
+}
+
+// ListReleases returns the releases of the index, newest first.
+func (p *httpIndexReleaseProvider) ListReleases(ctx context.Context, owner, name string) ([]*Release, error) {
+	index, err := p.getIndex(ctx, owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return index.releases, nil
+}
+
+// OpenAsset downloads the given asset. Indexes have no draft releases.
+func (p *httpIndexReleaseProvider) OpenAsset(ctx context.Context, owner, name string, asset *ReleaseAsset, draft bool) (io.ReadCloser, error) {
+	return openURL(asset.DownloadURL)
+}
+
+// IsReleaseAsset reports whether the given url is served from the directory of the index. Assets
+// the index links to elsewhere are not recognized.
+func (p *httpIndexReleaseProvider) IsReleaseAsset(owner, name, downloadURL string) bool {
+	indexDir := "https://" + owner + "/" + name
+	if !strings.HasSuffix(indexDir, "/") {
+		indexDir = indexDir[:strings.LastIndex(indexDir, "/")+1]
+	}
+
+	return strings.HasPrefix(downloadURL, indexDir)
+}
+
+// getIndex reads the index at https://<owner>/<name>, decoding it as JSON if served as such, or
+// else as a directory listing.
+func (p *httpIndexReleaseProvider) getIndex(ctx context.Context, owner, name string) (*httpIndexReleases, error) {
+	indexURL := "https://" + owner + "/" + name
+	if index, ok := p.indexes[indexURL]; ok {
+		return index, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, indexURL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request for %s", indexURL)
+	}
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get index %s", indexURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to get index %s: status %d", indexURL, resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read index %s", indexURL)
+	}
+
+	// Resolve relative urls against the url finally served, e.g. after redirecting to add a
+	// trailing slash to a directory.
+	baseURL := resp.Request.URL
+
+	var index *httpIndexReleases
+	if isJSONIndex(resp.Header.Get("Content-Type"), data) {
+		index, err = parseJSONIndex(baseURL, data)
+	} else {
+		index, err = p.parseDirectoryListing(ctx, baseURL, data)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse index %s", indexURL)
+	}
+	p.indexes[indexURL] = index
+
+	return index, nil
+}
+
+// isJSONIndex reports whether an index with the given content type and body is a JSON index.
+func isJSONIndex(contentType string, data []byte) bool {
+	if strings.HasPrefix(contentType, "application/json") {
+		return true
+	}
+
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// parseJSONIndex decodes the releases of a JSON index served from the given url.
+func parseJSONIndex(baseURL *url.URL, data []byte) (*httpIndexReleases, error) {
+	var index httpIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, errors.Wrap(err, "failed to decode JSON index")
+	}
+
+	result := &httpIndexReleases{homepageURL: baseURL.String()}
+	if index.HomepageURL != "" {
+		result.homepageURL = index.HomepageURL
+	}
+
+	for _, indexRelease := range index.Releases {
+		releaseNotesURL := baseURL.String()
+		if indexRelease.HTMLURL != "" {
+			releaseNotesURL = indexRelease.HTMLURL
+		}
+
+		release := &Release{
+			Name:        indexRelease.Name,
+			TagName:     indexRelease.TagName,
+			HTMLURL:     releaseNotesURL,
+			Body:        indexRelease.Body,
+			Prerelease:  indexRelease.Prerelease,
+			PublishedAt: indexRelease.PublishedAt,
+		}
+		for _, indexAsset := range indexRelease.Assets {
+			downloadURL, err := resolveAssetURL(baseURL, indexAsset.URL)
+			if err != nil {
+				return nil, err
+			}
+
+			name := indexAsset.Name
+			if name == "" {
+				name = assetNameFromURL(downloadURL)
+			}
+
+			release.Assets = append(release.Assets, &ReleaseAsset{
+				Name:        name,
+				DownloadURL: downloadURL,
+				UpdatedAt:   indexAsset.UpdatedAt,
+			})
+		}
+		result.releases = append(result.releases, release)
+	}
+
+	sortReleases(result.releases)
+
+	return result, nil
+}
+
+// hrefPattern matches the links of a directory listing.
+var hrefPattern = regexp.MustCompile(`(?i)href\s*=\s*"([^"]+)"`)
+
+// parseDirectoryListing reads the releases of a directory listing served from the given url,
+// making a release of each linked .tar.gz bundle along with the .sig and .asc files signing it.
+// Each release is dated by the Last-Modified header of its bundle.
+func (p *httpIndexReleaseProvider) parseDirectoryListing(ctx context.Context, baseURL *url.URL, data []byte) (*httpIndexReleases, error) {
+	assets := map[string]*ReleaseAsset{}
+	for _, match := range hrefPattern.FindAllSubmatch(data, -1) {
+		href := string(match[1])
+		if strings.HasPrefix(href, "?") || strings.HasPrefix(href, "#") || strings.HasSuffix(href, "/") {
+			continue
+		}
+
+		downloadURL, err := resolveAssetURL(baseURL, href)
+		if err != nil {
+			return nil, err
+		}
+		name := assetNameFromURL(downloadURL)
+		assets[name] = &ReleaseAsset{Name: name, DownloadURL: downloadURL}
+	}
+
+	result := &httpIndexReleases{homepageURL: baseURL.String()}
+	for name, bundle := range assets {
+		if !strings.HasSuffix(name, ".tar.gz") {
+			continue
+		}
+
+		lastModified, err := p.getLastModified(ctx, bundle.DownloadURL)
+		if err != nil {
+			return nil, err
+		}
+		bundle.UpdatedAt = lastModified
+
+		release := &Release{
+			TagName:     strings.TrimSuffix(name, ".tar.gz"),
+			HTMLURL:     baseURL.String(),
+			PublishedAt: lastModified,
+			Assets:      []*ReleaseAsset{bundle},
+		}
+		for _, suffix := range []string{".sig", ".asc"} {
+			if signature, ok := assets[name+suffix]; ok {
+				release.Assets = append(release.Assets, signature)
+			}
+		}
+		result.releases = append(result.releases, release)
+	}
+
+	sortReleases(result.releases)
+
+	return result, nil
+}
+
+// getLastModified returns the Last-Modified time of the given url, or the zero time if the server
+// gives none.
+func (p *httpIndexReleaseProvider) getLastModified(ctx context.Context, assetURL string) (time.Time, error) {
+	req, err := http.NewRequest(http.MethodHead, assetURL, nil)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to create request for %s", assetURL)
+	}
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to get %s", assetURL)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, errors.Errorf("failed to get %s: status %d", assetURL, resp.StatusCode)
+	}
+
+	lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}, nil
+	}
+
+	return lastModified, nil
+}
+
+// resolveAssetURL resolves the given, possibly relative, asset url against the url of its index,
+// requiring the result to be served over https.
+func resolveAssetURL(baseURL *url.URL, assetURL string) (string, error) {
+	u, err := url.Parse(assetURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid asset url %s", assetURL)
+	}
+
+	resolved := baseURL.ResolveReference(u)
+	if resolved.Scheme != "https" {
+		return "", errors.Errorf("asset url %s must be an https url", resolved)
+	}
+
+	return resolved.String(), nil
+}
+
+// assetNameFromURL returns the unescaped file name of the given asset url.
+func assetNameFromURL(assetURL string) string {
+	u, err := url.Parse(assetURL)
+	if err != nil {
+		return ""
+	}
+
+	return u.Path[strings.LastIndex(u.Path, "/")+1:]
+}
+
+// sortReleases sorts the given releases newest first, as other providers list them, breaking
+// ties by tag.
+func sortReleases(releases []*Release) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		if !releases[i].PublishedAt.Equal(releases[j].PublishedAt) {
+			return releases[i].PublishedAt.After(releases[j].PublishedAt)
+		}
+		return releases[i].TagName > releases[j].TagName
+	})
+}
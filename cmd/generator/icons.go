@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/icons"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	generatorCmd.Flags().String("icon-bucket", "", "An optional S3 bucket to which to upload plugin icons, recording their url in place of inlining them in the database.")
+	generatorCmd.Flags().String("icon-prefix", "icons/", "The prefix of the keys of icons uploaded to --icon-bucket.")
+	generatorCmd.Flags().String("icon-base-url", "", "The url serving --icon-bucket, such as a CDN, defaulting to the bucket's S3 endpoint.")
+}
+
+// newIconStore creates a store uploading icons to the bucket given by --icon-bucket, or returns
+// nil if none is given. AWS credentials and region are taken from the environment.
+func newIconStore(command *cobra.Command) (icons.Store, error) {
+	bucket, _ := command.Flags().GetString("icon-bucket")
+	if bucket == "" {
+		return nil, nil
+	}
+	prefix, _ := command.Flags().GetString("icon-prefix")
+	baseURL, _ := command.Flags().GetString("icon-base-url")
+
+	awsSession, err := session.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create aws session")
+	}
+
+	return &icons.S3Store{
+		Client:  s3.New(awsSession),
+		Bucket:  bucket,
+		Prefix:  prefix,
+		BaseURL: baseURL,
+	}, nil
+}
+
+// externalizeIcons uploads the inlined icon of each plugin to the given store, replacing it with
+// the url at which it is served.
+func externalizeIcons(store icons.Store, plugins []*model.Plugin) error {
+	// Most versions of a plugin share an icon, so upload each distinct icon only once.
+	iconURLs := map[string]string{}
+	for _, plugin := range plugins {
+		if plugin.IconData == "" {
+			continue
+		}
+
+		iconURL, ok := iconURLs[plugin.IconData]
+		if !ok {
+			mimeType, data, err := model.DecodeIconData(plugin.IconData)
+			if err != nil {
+				return errors.Wrapf(err, "failed to decode icon of %s %s", plugin.Manifest.Id, plugin.Manifest.Version)
+			}
+
+			iconURL, err = store.Put(mimeType, data)
+			if err != nil {
+				return errors.Wrapf(err, "failed to store icon of %s %s", plugin.Manifest.Id, plugin.Manifest.Version)
+			}
+			iconURLs[plugin.IconData] = iconURL
+		}
+
+		logger.Debugf("hosting icon of %s %s at %s", plugin.Manifest.Id, plugin.Manifest.Version, iconURL)
+		plugin.IconURL = iconURL
+		plugin.IconData = ""
+	}
+
+	return nil
+}
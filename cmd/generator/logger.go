@@ -1,13 +1,97 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
 )
 
+// repositoryLogField names the log field identifying the repository being generated, rendered as
+// a prefix of each message so that the logs of repositories remain distinguishable when
+// interleaved.
+const repositoryLogField = "repository"
+
+// organizationLogField names the log field identifying the configured organization owning the
+// repository being generated, if any.
+const organizationLogField = "organization"
+
 var logger *log.Logger
 
 func init() {
+	generatorCmd.PersistentFlags().String("log-file", "", "An optional file to which to also write the logs of the run, replacing any previous logs.")
+
 	logger = log.New()
+	logger.Formatter = &repositoryFormatter{Formatter: &log.TextFormatter{}}
+}
+
+// repositoryFormatter prefixes the message of each entry logged for a repository with its name.
+type repositoryFormatter struct {
+	log.Formatter
+}
+
+// Format formats the given entry, moving any repository field to the message prefix.
+func (f *repositoryFormatter) Format(entry *log.Entry) ([]byte, error) {
+	repository, ok := entry.Data[repositoryLogField]
+	if !ok {
+		return f.Formatter.Format(entry)
+	}
+
+	prefixed := *entry
+	prefixed.Message = fmt.Sprintf("[%v] %s", repository, entry.Message)
+	prefixed.Data = make(log.Fields, len(entry.Data)-1)
+	for key, value := range entry.Data {
+		if key != repositoryLogField {
+			prefixed.Data[key] = value
+		}
+	}
+
+	return f.Formatter.Format(&prefixed)
+}
+
+// logFileHook writes every entry to a log file. Like all hooks, it is fired with the logger
+// locked, so entries logged concurrently are written whole and in turn.
+type logFileHook struct {
+	file      *os.File
+	formatter log.Formatter
+}
+
+// Levels returns every level, leaving the logger's level to filter entries.
+func (h *logFileHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire writes the given entry to the log file.
+func (h *logFileHook) Fire(entry *log.Entry) error {
+	serialized, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.file.Write(serialized)
+	return err
+}
+
+// setupLogFile also writes the logs of the run to the file given by --log-file, if any.
+func setupLogFile(command *cobra.Command) error {
+	logFile, _ := command.Flags().GetString("log-file")
+	if logFile == "" {
+		return nil
+	}
+
+	file, err := os.Create(logFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create log file %s", logFile)
+	}
+
+	logger.AddHook(&logFileHook{
+		file:      file,
+		formatter: &repositoryFormatter{Formatter: &log.TextFormatter{DisableColors: true, FullTimestamp: true}},
+	})
+
+	return nil
 }
 
 type logrusWriter struct {
@@ -6,7 +6,6 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -15,32 +14,46 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/blang/semver"
-	"github.com/google/go-github/v28/github"
 	"github.com/h2non/filetype"
 	svg "github.com/h2non/go-is-svg"
 	mattermostModel "github.com/mattermost/mattermost-server/model"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"golang.org/x/oauth2"
 
+	"github.com/mattermost/mattermost-marketplace/internal/api"
 	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/reporting"
+	"github.com/mattermost/mattermost-marketplace/internal/signing"
 )
 
 func init() {
-	generatorCmd.PersistentFlags().String("github-token", "", "The optional GitHub token for API requests.")
 	generatorCmd.PersistentFlags().Bool("debug", false, "Whether to output debug logs.")
 	generatorCmd.PersistentFlags().Bool("include-pre-release", true, "Whether to include pre-release versions.")
-	generatorCmd.PersistentFlags().String("existing", "", "An existing plugins.json to help streamline incremental updates.")
+	generatorCmd.PersistentFlags().String("existing", "", "An existing plugins.json, or split database directory, to help streamline incremental updates.")
+	generatorCmd.PersistentFlags().Bool("indent", false, "Whether to indent the generated plugins.json.")
+	generatorCmd.PersistentFlags().Bool("compact", false, "Whether to generate plugins.json in the compact format, storing each distinct icon once rather than in every version of a plugin.")
+	generatorCmd.PersistentFlags().Bool("v2", false, "Whether to generate plugins.json in the v2 format, storing the name, description, homepage and icon shared by the versions of each plugin once. Takes precedence over --compact.")
+	generatorCmd.Flags().String("split-output", "", "An optional directory to which to write the database split into a file per plugin, along with an index, instead of writing plugins.json to stdout.")
+	generatorCmd.PersistentFlags().String("sentry-dsn", "", "The optional Sentry DSN to which to report a failed run.")
+	generatorCmd.PersistentFlags().String("submissions", "", "An optional submissions database whose approved repositories are also published.")
+	generatorCmd.Flags().Bool("keep-going", false, "Whether to continue past repositories that fail to generate, reusing their plugins from --existing, and exit with code 6 once the database is written.")
 }
 
+// reporter, if configured, receives the errors logged by the generator.
+var reporter reporting.Reporter
+
 func main() {
 	if err := generatorCmd.Execute(); err != nil {
-		logger.WithError(err).Error("command failed")
-		os.Exit(1)
+		code := exitCode(err)
+		logger.WithError(err).WithField("exit_code", code).Error("command failed")
+		if reporter != nil {
+			reporter.Flush(5 * time.Second)
+		}
+		os.Exit(code)
 	}
 }
 
@@ -49,6 +62,25 @@ var generatorCmd = &cobra.Command{
 	Short: "Generator is a tool to generate the plugins.json database",
 	// SilenceErrors allows us to explicitly log the error returned from generatorCmd below.
 	SilenceErrors: true,
+	PersistentPreRunE: func(command *cobra.Command, args []string) error {
+		if err := setupLogFile(command); err != nil {
+			return err
+		}
+
+		sentryDSN, _ := command.Flags().GetString("sentry-dsn")
+		if sentryDSN == "" {
+			return nil
+		}
+
+		sentryReporter, err := reporting.NewSentryReporter(sentryDSN)
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize error reporting")
+		}
+		logger.AddHook(reporting.NewHook(sentryReporter))
+		reporter = sentryReporter
+
+		return nil
+	},
 	RunE: func(command *cobra.Command, args []string) error {
 		command.SilenceUsage = true
 
@@ -57,151 +89,611 @@ var generatorCmd = &cobra.Command{
 			logger.SetLevel(logrus.DebugLevel)
 		}
 
-		includePreRelease, _ := command.Flags().GetBool("include-pre-release")
-		githubToken, _ := command.Flags().GetString("github-token")
-
-		var client *github.Client
-
-		if githubToken != "" {
-			ctx := context.Background()
-			ts := oauth2.StaticTokenSource(
-				&oauth2.Token{AccessToken: githubToken},
-			)
-			tc := oauth2.NewClient(ctx, ts)
+		format, err := getFormat(command, "")
+		if err != nil {
+			return err
+		}
+		splitOutput, _ := command.Flags().GetString("split-output")
+		if format != "" && splitOutput == "" {
+			return stdoutReportError("split-output")
+		}
 
-			client = github.NewClient(tc)
-		} else {
-			client = github.NewClient(nil)
+		verifyURLsMode, err := getVerifyURLsMode(command)
+		if err != nil {
+			return configError(err)
+		}
+		qualityConfig, err := newQualityConfig(command)
+		if err != nil {
+			return configError(err)
+		}
+		screeners, err := newScreeners(command)
+		if err != nil {
+			return configError(err)
 		}
 
+		includePreRelease, _ := command.Flags().GetBool("include-pre-release")
+		includeDrafts, _ := command.Flags().GetBool("include-drafts")
+		keepGoing, _ := command.Flags().GetBool("keep-going")
+		providers := newReleaseProviders(command)
+
 		var existingPlugins []*model.Plugin
 		existingDatabase, _ := command.Flags().GetString("existing")
 		if existingDatabase != "" {
-			file, err := os.Open(existingDatabase)
+			existingPlugins, err = readExistingDatabase(existingDatabase)
 			if err != nil {
-				return errors.Wrapf(err, "failed to open existing database %s", existingDatabase)
+				return configError(err)
 			}
-			defer file.Close()
+		}
 
-			existingPlugins, err = model.PluginsFromReader(file)
+		ctx := context.Background()
+
+		repositories := []repository{
+			{Name: "mattermost-plugin-github", IconPath: "data/icons/github.svg", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-autolink", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-zoom", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-jira", IconPath: "data/icons/jira.svg", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-welcomebot", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-jenkins", IconPath: "data/icons/jenkins.svg", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-antivirus", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-custom-attributes", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-aws-SNS", IconPath: "data/icons/aws-sns.svg", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-gitlab", IconPath: "data/icons/gitlab.svg", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-nps", AuthorType: model.AuthorTypeMattermost},
+			{Name: "mattermost-plugin-webex", IconPath: "data/icons/webex.svg", AuthorType: model.AuthorTypeMattermost},
+		}
+
+		submissionsDatabase, _ := command.Flags().GetString("submissions")
+		if submissionsDatabase != "" {
+			submittedRepositories, err := getSubmittedRepositories(submissionsDatabase)
 			if err != nil {
-				return errors.Wrapf(err, "failed to read existing database %s", existingDatabase)
+				return configError(err)
 			}
+
+			repositories = mergeRepositories(repositories, submittedRepositories)
 		}
 
-		ctx := context.Background()
+		httpIndexRepositories, err := getHTTPIndexRepositories(command)
+		if err != nil {
+			return configError(err)
+		}
+		repositories = mergeRepositories(repositories, httpIndexRepositories)
 
-		repositoryNames := []string{
-			"mattermost-plugin-github",
-			"mattermost-plugin-autolink",
-			"mattermost-plugin-zoom",
-			"mattermost-plugin-jira",
-			"mattermost-plugin-welcomebot",
-			"mattermost-plugin-jenkins",
-			"mattermost-plugin-antivirus",
-			"mattermost-plugin-custom-attributes",
-			"mattermost-plugin-aws-SNS",
-			"mattermost-plugin-gitlab",
-			"mattermost-plugin-nps",
-			"mattermost-plugin-webex",
-		}
-
-		iconPaths := map[string]string{
-			"mattermost-plugin-aws-SNS": "data/icons/aws-sns.svg",
-			"mattermost-plugin-github":  "data/icons/github.svg",
-			"mattermost-plugin-gitlab":  "data/icons/gitlab.svg",
-			"mattermost-plugin-jenkins": "data/icons/jenkins.svg",
-			"mattermost-plugin-jira":    "data/icons/jira.svg",
-			"mattermost-plugin-webex":   "data/icons/webex.svg",
+		organizationRepositories, err := getOrganizationRepositories(command, providers)
+		if err != nil {
+			return configError(err)
 		}
+		repositories = mergeRepositories(repositories, organizationRepositories)
 
-		plugins := []*model.Plugin{}
+		statePath, _ := command.Flags().GetString("state")
+		var state *generationState
+		if statePath != "" {
+			state, err = loadState(statePath)
+			if err != nil {
+				return err
+			}
+		}
 
-		for _, repositoryName := range repositoryNames {
-			logger.Debugf("querying repository %s", repositoryName)
+		// Repositories are generated concurrently by organization, and their results merged in
+		// the order of the repositories so that the database does not depend on timing.
+		results := make([]repositoryResult, len(repositories))
+		var stateLock sync.Mutex
+		err = generateConcurrently(ctx, repositories, func(ctx context.Context, logger logrus.FieldLogger, i int, repository repository) error {
+			result := &results[i]
+			repositoryName := repository.Name
+			if state != nil {
+				stateLock.Lock()
+				statePlugins, ok := state.Repositories[stateKey(repository)]
+				stateStagingPlugins := state.Staging[stateKey(repository)]
+				stateLock.Unlock()
+				if ok {
+					logger.Infof("resuming with %d recorded plugins", len(statePlugins))
+					result.plugins = statePlugins
+					result.stagingPlugins = stateStagingPlugins
+					return nil
+				}
+			}
+
+			logger.Debug("querying repository")
+
+			if err := repository.Assets.isValid(); err != nil {
+				return configError(errors.Wrapf(err, "invalid asset patterns for repository %s", repositoryName))
+			}
+
+			provider, err := providers.forRepository(repository)
+			if err != nil {
+				return configError(errors.Wrapf(err, "failed to query repository %s", repositoryName))
+			}
 
-			releasePlugins, err := getReleasePlugins(ctx, client, repositoryName, includePreRelease, existingPlugins)
+			repositoryPlugins, repositoryStagingPlugins, repositoryWarnings, err := generateRepository(ctx, logger, provider, repository, includePreRelease, includeDrafts, existingPlugins)
 			if err != nil {
-				return errors.Wrapf(err, "failed to release plugin for repository %s", repositoryName)
-			}
-
-			for _, plugin := range releasePlugins {
-				if len(plugin.IconData) == 0 {
-					if iconPath, ok := iconPaths[repositoryName]; ok {
-						icon, err := getIcon(ctx, iconPath)
-						if err != nil {
-							return errors.Wrapf(err, "failed to fetch icon for repository %s", repositoryName)
-						}
-						if svg.Is(icon) {
-							plugin.IconData = fmt.Sprintf("data:image/svg+xml;base64,%s", base64.StdEncoding.EncodeToString(icon))
-						} else {
-							kind, err := filetype.Image(icon)
-							if err != nil {
-								return errors.Wrapf(err, "failed to match icon at %s to image", iconPath)
-							}
-
-							plugin.IconData = fmt.Sprintf("data:%s;base64,%s", kind.MIME, base64.StdEncoding.EncodeToString(icon))
-						}
-					}
+				if !keepGoing {
+					return err
+				}
+
+				logger.WithError(err).WithField("exit_code", exitCode(err)).Error("skipping repository")
+				result.failed = true
+
+				existingRepositoryPlugins := getExistingRepositoryPlugins(provider, repository, existingPlugins)
+				logger.Warnf("reusing %d existing plugins", len(existingRepositoryPlugins))
+				result.plugins = existingRepositoryPlugins
+				if includeDrafts {
+					result.stagingPlugins = existingRepositoryPlugins
 				}
-				plugins = append(plugins, plugin)
+				return nil
+			}
+			result.plugins = repositoryPlugins
+			result.stagingPlugins = repositoryStagingPlugins
+			result.warnings = repositoryWarnings
+
+			if state != nil {
+				stateLock.Lock()
+				defer stateLock.Unlock()
+				state.Repositories[stateKey(repository)] = repositoryPlugins
+				if includeDrafts {
+					state.Staging[stateKey(repository)] = repositoryStagingPlugins
+				}
+				if err := state.save(statePath); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		plugins := []*model.Plugin{}
+		stagingPlugins := []*model.Plugin{}
+		var failedRepositories []string
+		var warnings []string
+		for i, result := range results {
+			plugins = append(plugins, result.plugins...)
+			stagingPlugins = append(stagingPlugins, result.stagingPlugins...)
+			warnings = append(warnings, result.warnings...)
+			if result.failed {
+				failedRepositories = append(failedRepositories, repositories[i].Name)
 			}
 		}
 
-		encoder := json.NewEncoder(os.Stdout)
-		err := encoder.Encode(plugins)
+		// Screen community plugins before anything else, so that rejected plugins are neither
+		// verified nor signed.
+		if len(screeners) > 0 {
+			screen := &pluginScreen{screeners: screeners, rejected: map[*model.Plugin]bool{}}
+			plugins, err = screen.filter(plugins)
+			if err != nil {
+				return err
+			}
+			stagingPlugins, err = screen.filter(stagingPlugins)
+			if err != nil {
+				return err
+			}
+		}
+
+		if verifyChecksums, _ := command.Flags().GetBool("verify-checksums"); verifyChecksums {
+			if err := verifyReusedChecksums(plugins, existingPlugins); err != nil {
+				return validationError(err)
+			}
+		}
+
+		if verifyURLsMode != "" {
+			if err := verifyPluginURLs(newURLVerifier(), plugins, existingPlugins, verifyURLsMode); err != nil {
+				return validationError(err)
+			}
+		}
+
+		signer, err := newSigner(command)
+		if err != nil {
+			return configError(errors.Wrap(err, "failed to initialize signing"))
+		}
+		keyringSigner, err := newKeyringSigner(command)
+		if err != nil {
+			return configError(errors.Wrap(err, "failed to initialize signing"))
+		}
+		databaseSignature, _ := command.Flags().GetString("database-signature")
+		databaseSigner := signer
+		if databaseSigner == nil {
+			databaseSigner = keyringSigner
+		}
+		if databaseSignature != "" && databaseSigner == nil {
+			return configError(errors.New("--database-signature requires --kms-key-id or --keyring"))
+		}
+
+		sourceDate, err := getSourceDate(command)
+		if err != nil {
+			return configError(err)
+		}
+		generatedAt := sourceDate
+		if generatedAt.IsZero() {
+			generatedAt = time.Now().UTC().Truncate(time.Second)
+		}
+		dataCommit, _ := command.Flags().GetString("data-commit")
+		recordProvenance(plugins, generatedAt, dataCommit)
+		recordProvenance(stagingPlugins, generatedAt, dataCommit)
+
+		verifyReproducible, _ := command.Flags().GetString("verify-reproducible")
+		for _, openPGPSigner := range []*signing.OpenPGPSigner{signer, keyringSigner} {
+			if openPGPSigner == nil {
+				continue
+			}
+			if !sourceDate.IsZero() {
+				openPGPSigner.SetSignatureTime(sourceDate)
+			} else if verifyReproducible != "" {
+				return configError(errors.New("--verify-reproducible requires --source-date-epoch or $SOURCE_DATE_EPOCH to reproduce signatures"))
+			}
+		}
+
+		// Sign the unsigned bundles first, so that the KMS key signs every bundle regardless.
+		if keyringSigner != nil {
+			if err := signPlugins(keyringSigner, plugins, true); err != nil {
+				return err
+			}
+		}
+		if signer != nil {
+			if err := signPlugins(signer, plugins, false); err != nil {
+				return err
+			}
+		}
+
+		if normalizeRules := newNormalizeRules(command); normalizeRules != nil {
+			normalizePlugins(normalizeRules, plugins)
+		}
+
+		// Check quality only once signed, so that signatures added by the generator count.
+		if qualityConfig != nil {
+			if err := checkQuality(qualityConfig, plugins); err != nil {
+				return validationError(err)
+			}
+		}
+
+		iconStore, err := newIconStore(command)
+		if err != nil {
+			return configError(errors.Wrap(err, "failed to initialize icon store"))
+		}
+		if iconStore != nil {
+			if err := externalizeIcons(iconStore, plugins); err != nil {
+				return err
+			}
+		}
+
+		var database bytes.Buffer
+		writerOptions := getWriterOptions(command)
+		err = model.PluginsToWriter(&database, plugins, writerOptions)
 		if err != nil {
 			return errors.Wrap(err, "failed to encode plugins result")
 		}
 
+		if verifyReproducible != "" {
+			return verifyReproducedDatabase(verifyReproducible, database.Bytes(), plugins, writerOptions)
+		}
+
+		if databaseSignature != "" {
+			if err := writeDatabaseSignature(databaseSigner, bytes.NewReader(database.Bytes()), databaseSignature); err != nil {
+				return err
+			}
+		}
+
+		if splitOutput != "" {
+			index, err := model.WriteSplitDatabase(splitOutput, plugins, writerOptions)
+			if err != nil {
+				return errors.Wrap(err, "failed to write split database")
+			}
+			logger.Infof("wrote %d plugins to %s", len(index.Plugins), splitOutput)
+
+			if format != "" {
+				report := newWriteReport(splitOutput, plugins)
+				report.Warnings = warnings
+				if err := printReport(format, report); err != nil {
+					return err
+				}
+			}
+		} else if _, err := database.WriteTo(os.Stdout); err != nil {
+			return errors.Wrap(err, "failed to write plugins result")
+		}
+
+		if attestationsOutput, _ := command.Flags().GetString("attestations-output"); attestationsOutput != "" {
+			verifyChecksums, _ := command.Flags().GetBool("verify-checksums")
+			if err := writeAttestations(attestationsOutput, plugins, verifyChecksums); err != nil {
+				return err
+			}
+		}
+
+		if includeDrafts {
+			stagingOutput, _ := command.Flags().GetString("staging-output")
+			if err := writeStagingDatabase(stagingOutput, stagingPlugins, writerOptions); err != nil {
+				return err
+			}
+		}
+
+		// Keep the state of a partial run, so that running again retries only the failed
+		// repositories.
+		if len(failedRepositories) > 0 {
+			return newExitError(exitCodePartial, errors.Errorf("failed to generate %d repositories: %s", len(failedRepositories), strings.Join(failedRepositories, ", ")))
+		}
+
+		if statePath != "" {
+			if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+				return errors.Wrapf(err, "failed to remove state %s", statePath)
+			}
+		}
+
 		return nil
 	},
 }
 
-// getReleasePlugins queries GitHub for all releases of the given plugin, sorting by plugin versioning descending.
-func getReleasePlugins(ctx context.Context, client *github.Client, repositoryName string, includePreRelease bool, existingPlugins []*model.Plugin) ([]*model.Plugin, error) {
-	logger := logger.WithField("repository", repositoryName)
+// getWriterOptions returns the format of the databases written, as given by --indent, --compact
+// and --v2.
+func getWriterOptions(command *cobra.Command) model.PluginsWriterOptions {
+	indent, _ := command.Flags().GetBool("indent")
+	compact, _ := command.Flags().GetBool("compact")
+	v2, _ := command.Flags().GetBool("v2")
 
-	repository, _, err := client.Repositories.Get(ctx, "mattermost", repositoryName)
+	return model.PluginsWriterOptions{Indent: indent, Compact: compact, V2: v2}
+}
+
+// readExistingDatabase reads the plugins of the given database file or split database directory.
+func readExistingDatabase(existingDatabase string) ([]*model.Plugin, error) {
+	if info, err := os.Stat(existingDatabase); err == nil && info.IsDir() {
+		plugins, err := model.ReadSplitDatabase(existingDatabase)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read existing database %s", existingDatabase)
+		}
+		return plugins, nil
+	}
+
+	file, err := os.Open(existingDatabase)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get repository")
+		return nil, errors.Wrapf(err, "failed to open existing database %s", existingDatabase)
 	}
+	defer file.Close()
 
-	releases, err := getReleases(ctx, client, repositoryName, includePreRelease)
+	plugins, err := model.PluginsFromReader(file)
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrapf(err, "failed to read existing database %s", existingDatabase)
+	}
+
+	return plugins, nil
+}
+
+// generateRepository returns the plugins released by the given repository, along with its staging
+// plugins if including drafts, and warnings describing any icons dropped from them.
+func generateRepository(ctx context.Context, logger logrus.FieldLogger, provider ReleaseProvider, repository repository, includePreRelease, includeDrafts bool, existingPlugins []*model.Plugin) ([]*model.Plugin, []*model.Plugin, []string, error) {
+	releasePlugins, releaseStagingPlugins, err := getReleasePlugins(ctx, provider, repository.owner(), repository.Name, repository.Assets, includePreRelease, includeDrafts, existingPlugins)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "failed to release plugin for repository %s", repository.Name)
+	}
+
+	repositoryPlugins, warnings := applyRepository(ctx, logger, repository, releasePlugins)
+	repositoryStagingPlugins, stagingWarnings := applyRepository(ctx, logger, repository, releaseStagingPlugins)
+
+	return repositoryPlugins, repositoryStagingPlugins, append(warnings, stagingWarnings...), nil
+}
+
+// getExistingRepositoryPlugins returns the plugins of the existing database released by the given
+// repository, standing in for those of a repository that failed to generate.
+func getExistingRepositoryPlugins(provider ReleaseProvider, repository repository, existingPlugins []*model.Plugin) []*model.Plugin {
+	var plugins []*model.Plugin
+	for _, plugin := range existingPlugins {
+		if provider.IsReleaseAsset(repository.owner(), repository.Name, plugin.DownloadURL) {
+			plugins = append(plugins, plugin)
+		}
+	}
+
+	return plugins
+}
+
+// repositoryResult holds the outcome of generating a repository.
+type repositoryResult struct {
+	plugins        []*model.Plugin
+	stagingPlugins []*model.Plugin
+	// warnings describes the icons dropped from the repository's plugins.
+	warnings []string
+	// failed records the repository failing to generate, its plugins reused from the existing
+	// database instead.
+	failed bool
+}
+
+// applyRepository sets the author type of the given plugins of the repository, falling back to the
+// repository's icon for those without one, and recording the repository's maintainers on those
+// whose manifest declares none. A repository icon failing to be fetched or decoded is dropped
+// rather than failing the repository, returning a warning for each plugin left without it.
+func applyRepository(ctx context.Context, logger logrus.FieldLogger, repository repository, releasePlugins []*model.Plugin) ([]*model.Plugin, []string) {
+	repositoryPlugins := []*model.Plugin{}
+	var warnings []string
+	for _, plugin := range releasePlugins {
+		plugin.AuthorType = repository.AuthorType
+		if len(repository.Maintainers) > 0 {
+			if declared, _ := model.MaintainersFromManifest(plugin.Manifest); declared == nil {
+				plugin.Maintainers = repository.Maintainers
+			}
+		}
+
+		if len(plugin.IconData) == 0 && plugin.IconURL == "" && repository.IconPath != "" {
+			iconData, err := getIconData(ctx, logger, repository.IconPath)
+			if err != nil {
+				warning := fmt.Sprintf("dropped icon for plugin %s %s: %s", plugin.Manifest.Id, plugin.Manifest.Version, err.Error())
+				logger.Warn(warning)
+				warnings = append(warnings, warning)
+			}
+			plugin.IconData = iconData
+		}
+		repositoryPlugins = append(repositoryPlugins, plugin)
+	}
+
+	return repositoryPlugins, warnings
+}
+
+// getIconData returns the icon at the given path encoded as a data uri.
+func getIconData(ctx context.Context, logger logrus.FieldLogger, iconPath string) (string, error) {
+	icon, err := getIcon(ctx, logger, iconPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to fetch icon %s", iconPath)
+	}
+	if svg.Is(icon) {
+		return fmt.Sprintf("data:image/svg+xml;base64,%s", base64.StdEncoding.EncodeToString(icon)), nil
+	}
+
+	kind, err := filetype.Image(icon)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to match icon at %s to image", iconPath)
+	}
+	if kind == filetype.Unknown {
+		return "", errors.Errorf("icon at %s is not a recognized image", iconPath)
+	}
+
+	return fmt.Sprintf("data:%s;base64,%s", kind.MIME, base64.StdEncoding.EncodeToString(icon)), nil
+}
+
+// defaultRepositoryOwner owns the repositories published in the marketplace unless otherwise
+// specified.
+const defaultRepositoryOwner = "mattermost"
+
+// repository describes a repository whose releases are published in the marketplace.
+type repository struct {
+	// Organization names the organization configured by --organizations from which the repository
+	// is generated, if any, querying the provider with the organization's own credentials.
+	Organization string
+	// Provider names the registered release provider hosting the repository, defaulting to
+	// github.
+	Provider string
+	// Owner is the user or organization owning the repository, defaulting to mattermost.
+	Owner string
+	Name  string
+	// IconPath is an optional path or URL to an icon, used when the plugin bundle has none.
+	IconPath string
+	// AuthorType is recorded on each plugin published from the repository.
+	AuthorType model.AuthorType
+	// Maintainers, if any, are recorded on each plugin published from the repository whose
+	// manifest declares none, in place of any recorded by hand.
+	Maintainers []*model.Maintainer
+	// Assets optionally selects the bundle and signatures among the assets of each release, for
+	// repositories naming them differently than the default heuristics expect.
+	Assets assetPatterns
+}
+
+// owner returns the owner of the repository, falling back to defaultRepositoryOwner.
+func (r repository) owner() string {
+	if r.Owner == "" {
+		return defaultRepositoryOwner
+	}
+
+	return r.Owner
+}
+
+// provider returns the name of the repository's release provider, falling back to
+// defaultReleaseProvider.
+func (r repository) provider() string {
+	if r.Provider == "" {
+		return defaultReleaseProvider
+	}
+
+	return r.Provider
+}
+
+// key identifies the repository among those of every provider, preserving the owner/name keys of
+// GitHub repositories.
+func (r repository) key() string {
+	key := strings.ToLower(r.owner() + "/" + r.Name)
+	if r.provider() != defaultReleaseProvider {
+		key = r.provider() + ":" + key
+	}
+
+	return key
+}
+
+// getReleasePlugins queries the given provider for all releases of the given plugin, sorting by plugin versioning descending.
+// If includeDrafts is set, it also returns the plugins as they would be were the repository's draft
+// releases published.
+func getReleasePlugins(ctx context.Context, provider ReleaseProvider, owner, repositoryName string, patterns assetPatterns, includePreRelease, includeDrafts bool, existingPlugins []*model.Plugin) ([]*model.Plugin, []*model.Plugin, error) {
+	logger := logger.WithField(repositoryLogField, repositoryName)
+
+	repositoryURL, err := provider.RepositoryURL(ctx, owner, repositoryName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	releases, err := getReleases(ctx, provider, owner, repositoryName, includePreRelease, includeDrafts)
+	if err != nil {
+		return nil, nil, err
 	}
 	if len(releases) == 0 {
 		logger.Warnf("no releases found for repository")
-		return nil, nil
+		return nil, nil, nil
 	}
 
-	var plugins []*model.Plugin
-	// Keep track of the latest plugin compatible with the given server version
-	minServerVersionsSeen := map[string]*model.Plugin{}
+	archiveChecker, checksArchived := provider.(RepositoryArchiveChecker)
+	var archived bool
+	if checksArchived {
+		archived, err = archiveChecker.IsRepositoryArchived(ctx, owner, repositoryName)
+		if errors.Cause(err) == errRequestDeferred {
+			logger.Warn("deferring archive check to preserve the GitHub rate limit, keeping any recorded archival")
+			checksArchived = false
+		} else if err != nil {
+			return nil, nil, err
+		} else if archived {
+			logger.Warnf("repository is archived, marking its plugins as unmaintained")
+		}
+	}
+
+	downloader := &assetDownloader{ctx: ctx, logger: logger, provider: provider, owner: owner, repositoryName: repositoryName}
+
+	var publishedPlugins, allPlugins []*model.Plugin
 	for _, release := range releases {
-		releasePlugin, err := getReleasePlugin(release, repository, existingPlugins)
+		releasePlugin, err := getReleasePlugin(logger, release, repositoryURL, patterns, downloader, existingPlugins)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to get release plugin for %s", release.GetName())
+			return nil, nil, errors.Wrapf(err, "failed to get release plugin for %s", release.Name)
 		}
 
 		if releasePlugin == nil {
-			logger.Warnf("no plugin found for release %s", release.GetName())
+			logger.Warnf("no plugin found for release %s", release.Name)
 			continue
 		}
+		// Preserve any archival recorded by hand, unless the provider reports it.
+		if checksArchived {
+			releasePlugin.RepositoryArchived = archived
+		}
 
+		allPlugins = append(allPlugins, releasePlugin)
+		if !release.Draft {
+			publishedPlugins = append(publishedPlugins, releasePlugin)
+		}
+	}
+
+	plugins, err := selectLatestPlugins(publishedPlugins)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !includeDrafts {
+		return plugins, nil, nil
+	}
+
+	stagingPlugins, err := selectLatestPlugins(allPlugins)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return plugins, stagingPlugins, nil
+}
+
+// selectLatestPlugins selects the latest of the given plugins for each minimum server version,
+// sorting by plugin version descending.
+func selectLatestPlugins(releasePlugins []*model.Plugin) ([]*model.Plugin, error) {
+	var plugins []*model.Plugin
+	// Keep track of the latest plugin compatible with the given server version
+	minServerVersionsSeen := map[string]*model.Plugin{}
+	for _, releasePlugin := range releasePlugins {
 		if minServerVersionsSeen[releasePlugin.Manifest.MinServerVersion] != nil {
 			if releasePlugin.Manifest.Version == "" {
 				return nil, errors.Errorf("version is empty for manifest.Id %s", releasePlugin.Manifest.Id)
 			}
 
 			lastSeenPlugin := minServerVersionsSeen[releasePlugin.Manifest.MinServerVersion]
-			lastSeenPluginVersion, err := semver.Parse(lastSeenPlugin.Manifest.Version)
+			lastSeenPluginVersion, err := model.ParseVersion(lastSeenPlugin.Manifest.Version)
 			if err != nil {
 				return nil, errors.Wrapf(err, "failed to parse version %s", lastSeenPlugin.Manifest.Version)
 			}
 
-			releasePluginVersion, err := semver.Parse(releasePlugin.Manifest.Version)
+			releasePluginVersion, err := model.ParseVersion(releasePlugin.Manifest.Version)
 			if err != nil {
 				return nil, errors.Wrapf(err, "failed to parse release plugin version %s", releasePlugin.Manifest.Version)
 			}
@@ -223,91 +715,98 @@ func getReleasePlugins(ctx context.Context, client *github.Client, repositoryNam
 	sort.SliceStable(
 		plugins,
 		func(i, j int) bool {
-			return semver.MustParse(plugins[i].Manifest.Version).GT(semver.MustParse(plugins[j].Manifest.Version))
+			iVersion, _ := model.ParseVersion(plugins[i].Manifest.Version)
+			jVersion, _ := model.ParseVersion(plugins[j].Manifest.Version)
+			return iVersion.GT(jVersion)
 		},
 	)
 
 	return plugins, nil
 }
 
-// getReleases returns all GitHub releases for the given repository.
-func getReleases(ctx context.Context, client *github.Client, repoName string, includePreRelease bool) ([]*github.RepositoryRelease, error) {
-	var result []*github.RepositoryRelease
-	options := &github.ListOptions{
-		Page:    0,
-		PerPage: 40,
+// getReleases returns all releases of the given repository, including drafts only if requested.
+func getReleases(ctx context.Context, provider ReleaseProvider, owner, repoName string, includePreRelease, includeDrafts bool) ([]*Release, error) {
+	releases, err := provider.ListReleases(ctx, owner, repoName)
+	if err != nil {
+		return nil, err
 	}
-	for {
-		releases, resp, err := client.Repositories.ListReleases(ctx, "mattermost", repoName, options)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to get releases for repository %s", repoName)
-		}
-
-		for _, release := range releases {
-			if release.GetDraft() {
-				continue
-			}
 
-			if release.GetPrerelease() && !includePreRelease {
-				continue
-			}
-
-			result = append(result, release)
+	var result []*Release
+	for _, release := range releases {
+		if release.Draft && !includeDrafts {
+			continue
 		}
 
-		if resp.NextPage == 0 {
-			break
+		if release.Prerelease && !includePreRelease {
+			continue
 		}
-		options.Page = resp.NextPage
+
+		result = append(result, release)
 	}
 
 	return result, nil
 }
 
-func getReleasePlugin(release *github.RepositoryRelease, repository *github.Repository, existingPlugins []*model.Plugin) (*model.Plugin, error) {
+func getReleasePlugin(logger logrus.FieldLogger, release *Release, repositoryURL string, patterns assetPatterns, downloader *assetDownloader, existingPlugins []*model.Plugin) (*model.Plugin, error) {
 	var releaseName string
-	if release.GetName() == "" {
-		releaseName = release.GetTagName()
+	if release.Name == "" {
+		releaseName = release.TagName
 	} else {
-		releaseName = fmt.Sprintf("%s (%s)", release.GetName(), release.GetTagName())
+		releaseName = fmt.Sprintf("%s (%s)", release.Name, release.TagName)
 	}
 	logger.Debugf("found latest release %s", releaseName)
 
 	downloadURL := ""
-	var signatureAsset *github.ReleaseAsset
-	releaseNotesURL := release.GetHTMLURL()
+	var signatureAssets []*ReleaseAsset
+	releaseNotesURL := release.HTMLURL
 	var updatedAt time.Time
+	var bundleAsset *ReleaseAsset
+	bundleAssets := 0
 	for _, releaseAsset := range release.Assets {
-		assetName := releaseAsset.GetName()
-		if strings.Contains(assetName, "-amd64") {
-			logger.Debugf("ignoring old style tar bundle %s, for release %s", assetName, releaseName)
-			continue
+		assetName := releaseAsset.Name
+		if patterns.Bundle == "" && isOldStyleBundle(assetName) {
+			logger.Debugf("ignoring old style tar bundle %s as the plugin bundle, for release %s", assetName, releaseName)
 		}
 
-		if strings.HasSuffix(assetName, ".tar.gz") {
-			downloadURL = releaseAsset.GetBrowserDownloadURL()
-			timestampUpdatedAt := releaseAsset.GetUpdatedAt()
-			if timestampUpdatedAt.IsZero() {
-				timestampUpdatedAt = releaseAsset.GetCreatedAt()
-			}
+		isBundle, err := patterns.matchesBundle(assetName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to match asset %s for release %s", assetName, releaseName)
+		}
+		if isBundle {
+			bundleAssets++
+			bundleAsset = releaseAsset
+			downloadURL = releaseAsset.DownloadURL
+			updatedAt = releaseAsset.UpdatedAt.In(time.UTC).Truncate(time.Second)
+		}
 
-			updatedAt = timestampUpdatedAt.In(time.UTC)
+		isSignature, err := patterns.matchesSignature(assetName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to match asset %s for release %s", assetName, releaseName)
 		}
-		if strings.HasSuffix(assetName, ".sig") || strings.HasSuffix(assetName, ".asc") {
-			if signatureAsset != nil {
-				return nil, errors.Errorf("found multiple signatures %s for release %s", assetName, releaseName)
-			}
-			signatureAsset = &releaseAsset
+		if isSignature {
+			signatureAssets = append(signatureAssets, releaseAsset)
 		}
 	}
+	if patterns.Bundle != "" && bundleAssets > 1 {
+		return nil, errors.Errorf("bundle pattern %s matches %d assets of release %s", patterns.Bundle, bundleAssets, releaseName)
+	}
 
-	var signature string
-	if signatureAsset != nil {
-		var err error
-		signature, err = downloadSignature(signatureAsset)
+	var signatures []*model.Signature
+	for _, signatureAsset := range signatureAssets {
+		signature, err := downloadSignature(downloader, signatureAsset, release.Draft)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to download signatures for release %s", releaseName)
 		}
+
+		publicKeyHash, err := api.SignatureKeyID(signature)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to identify signing key of %s for release %s", signatureAsset.Name, releaseName)
+		}
+
+		signatures = append(signatures, &model.Signature{
+			Signature:     signature,
+			PublicKeyHash: publicKeyHash,
+		})
 	}
 
 	if downloadURL == "" {
@@ -323,8 +822,14 @@ func getReleasePlugin(release *github.RepositoryRelease, repository *github.Repo
 		}
 	}
 
+	var existingPlatforms map[string]*model.PlatformBundle
+	if plugin != nil {
+		existingPlatforms = plugin.Platforms
+	}
+
 	// If no plugin in existing database or the updated timestamp has changed, attempt to download and inspect manifest.
-	if plugin == nil || updatedAt.IsZero() || plugin.UpdatedAt.Before(updatedAt) {
+	refresh := plugin == nil || updatedAt.IsZero() || plugin.UpdatedAt.Before(updatedAt)
+	if refresh {
 		if plugin == nil {
 			logger.Debug("no existing plugin")
 		} else if updatedAt.IsZero() {
@@ -339,13 +844,14 @@ func getReleasePlugin(release *github.RepositoryRelease, repository *github.Repo
 
 		plugin = &model.Plugin{}
 
-		resp, err := http.Get(downloadURL)
+		bundle, err := downloader.open(bundleAsset, release.Draft)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to download plugin bundle for release %s", releaseName)
 		}
-		defer resp.Body.Close()
+		defer bundle.Close()
 
-		gzBundleReader, err := gzip.NewReader(resp.Body)
+		checksumsWriter := model.NewChecksumsWriter()
+		gzBundleReader, err := gzip.NewReader(io.TeeReader(bundle, checksumsWriter))
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to read gzipped plugin bundle for release %s", releaseName)
 		}
@@ -355,6 +861,12 @@ func getReleasePlugin(release *github.RepositoryRelease, repository *github.Repo
 			return nil, errors.Wrapf(err, "failed to read plugin bundle for release %s", releaseName)
 		}
 
+		// Drain any trailing bytes so the checksum covers the bundle exactly as served.
+		if _, err = io.Copy(checksumsWriter, bundle); err != nil {
+			return nil, errors.Wrapf(err, "failed to checksum plugin bundle for release %s", releaseName)
+		}
+		plugin.Checksums = &model.Checksums{SHA256: checksumsWriter.Checksums().SHA256}
+
 		manifestData, err := getFromTarFile(tar.NewReader(bytes.NewReader(bundleData)), "plugin.json")
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to read manifest from plugin bundle for release %s", releaseName)
@@ -364,6 +876,10 @@ func getReleasePlugin(release *github.RepositoryRelease, repository *github.Repo
 			return nil, errors.Errorf("manifest nil after reading from plugin bundle for release %s", releaseName)
 		}
 
+		if err := verifyBundleContents(plugin.Manifest, tar.NewReader(bytes.NewReader(bundleData))); err != nil {
+			return nil, errors.Wrapf(err, "failed to verify plugin bundle for release %s", releaseName)
+		}
+
 		if plugin.Manifest.IconPath != "" {
 			iconData, err := getFromTarFile(tar.NewReader(bytes.NewReader(bundleData)), plugin.Manifest.IconPath)
 			if err != nil {
@@ -373,6 +889,14 @@ func getReleasePlugin(release *github.RepositoryRelease, repository *github.Repo
 			logger.Debugf("using icon specified in manifest as %s", plugin.Manifest.IconPath)
 			plugin.IconData = fmt.Sprintf("data:image/svg+xml;base64,%s", base64.StdEncoding.EncodeToString(iconData))
 		}
+
+		presentation, err := getPresentation(downloader, release, bundleData)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid presentation of plugin for release %s", releaseName)
+		}
+		if presentation != nil {
+			presentation.apply(plugin)
+		}
 	} else {
 		logger.Debugf("skipping download since found existing plugin")
 	}
@@ -380,21 +904,62 @@ func getReleasePlugin(release *github.RepositoryRelease, repository *github.Repo
 	if plugin.Manifest == nil {
 		return nil, fmt.Errorf("failed to find plugin manifest for release %s", releaseName)
 	}
+	// Some community plugins version their manifests as their tags, e.g. v1.2.3.
+	if model.NormalizeManifestVersions(plugin.Manifest) {
+		logger.Debugf("normalized manifest version of release %s to %s", releaseName, plugin.Manifest.Version)
+	}
 
 	// Reset fields, even if we found the existing plugin above.
 	if plugin.Manifest.HomepageURL != "" {
 		plugin.HomepageURL = plugin.Manifest.HomepageURL
 	} else {
-		plugin.HomepageURL = repository.GetHTMLURL()
+		plugin.HomepageURL = repositoryURL
 	}
 	plugin.DownloadURL = downloadURL
 	plugin.ReleaseNotesURL = releaseNotesURL
-	plugin.Signature = signature
+	plugin.ReleaseNotes = strings.TrimSpace(release.Body)
+	// Preserve any dependencies recorded by hand, unless the manifest declares its own.
+	dependencies, err := model.DependenciesFromManifest(plugin.Manifest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid dependencies in manifest for release %s", releaseName)
+	}
+	if dependencies != nil {
+		plugin.Dependencies = dependencies
+	}
+	// Likewise preserve any maintainers recorded by hand, unless the manifest declares its own.
+	maintainers, err := model.MaintainersFromManifest(plugin.Manifest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid maintainers in manifest for release %s", releaseName)
+	}
+	if maintainers != nil {
+		plugin.Maintainers = maintainers
+	}
+	plugin.Signatures = signatures
+	plugin.Platforms = getPlatformBundles(logger, release, releaseName, bundleAsset, plugin.Manifest, len(signatures) > 0, downloader, existingPlatforms, refresh)
+	// Older Mattermost servers only understand a single signature.
+	plugin.Signature = ""
+	if len(signatures) > 0 {
+		plugin.Signature = signatures[0].Signature
+	}
+	// Preserve any stage recorded by hand, e.g. experimental.
+	if plugin.ReleaseStage == "" {
+		plugin.ReleaseStage = model.ReleaseStageProduction
+		if release.Prerelease {
+			plugin.ReleaseStage = model.ReleaseStageBeta
+		}
+	}
 	plugin.UpdatedAt = updatedAt
+	plugin.ReleasedAt = getReleasedAt(release)
+	plugin.Provenance = releaseProvenance(plugin.Provenance, repositoryURL, release.TagName)
 
 	return plugin, nil
 }
 
+// getReleasedAt returns the time the release was published, falling back to when it was created.
+func getReleasedAt(release *Release) time.Time {
+	return release.PublishedAt.In(time.UTC).Truncate(time.Second)
+}
+
 func getFromTarFile(reader *tar.Reader, filepath string) ([]byte, error) {
 	for {
 		hdr, err := reader.Next()
@@ -424,60 +989,102 @@ func getFromTarFile(reader *tar.Reader, filepath string) ([]byte, error) {
 	return nil, errors.Errorf("failed to find %s in tar file", filepath)
 }
 
-func downloadSignature(asset *github.ReleaseAsset) (string, error) {
-	signature, err := getSignatureFromAsset(*asset)
-	if err != nil {
-		return "", errors.Wrap(err, "Can't get signature from the asset")
+// verifyBundleContents verifies the tar file contains the server executables declared by the
+// manifest for each platform, as well as the webapp bundle if one is declared, catching broken
+// packaging before servers fail to install the plugin.
+func verifyBundleContents(manifest *mattermostModel.Manifest, reader *tar.Reader) error {
+	type declaredFile struct {
+		description string
+		filepath    string
+	}
+	var declaredFiles []declaredFile
+	declare := func(description, filepath string) {
+		if filepath != "" {
+			declaredFiles = append(declaredFiles, declaredFile{description, filepath})
+		}
 	}
 
-	return signature, nil
-}
+	// Support the deprecated backend manifest field.
+	server := manifest.Server
+	if server == nil {
+		server = manifest.Backend
+	}
+	if server != nil {
+		if server.Executables != nil {
+			declare("server executable for linux-amd64", server.Executables.LinuxAmd64)
+			declare("server executable for darwin-amd64", server.Executables.DarwinAmd64)
+			declare("server executable for windows-amd64", server.Executables.WindowsAmd64)
+		}
+		declare("server executable", server.Executable)
+	}
+	if manifest.Webapp != nil {
+		declare("webapp bundle", manifest.Webapp.BundlePath)
+	}
 
-func getSignatureFromAsset(asset github.ReleaseAsset) (string, error) {
-	url := asset.GetBrowserDownloadURL()
-	logger.Debugf("fetching signature file from %s", url)
+	if len(declaredFiles) == 0 {
+		return nil
+	}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", errors.Wrapf(err, "failed to download signature file %s", asset.GetName())
+	bundledFiles := map[string]bool{}
+	for {
+		hdr, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read tar file")
+		}
+		if hdr.FileInfo().IsDir() {
+			continue
+		}
+
+		// Strip the leading folder matching the plugin id, relative to which the manifest
+		// declares its files.
+		parts := strings.SplitN(hdr.Name, "/", 2)
+		if len(parts) == 2 {
+			bundledFiles[path.Clean(parts[1])] = true
+		}
 	}
-	defer resp.Body.Close()
 
-	sigFile, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", errors.Wrapf(err, "failed to open downloaded signature file %s", asset.GetName())
+	var missingFiles []string
+	for _, declaredFile := range declaredFiles {
+		if !bundledFiles[path.Clean(declaredFile.filepath)] {
+			missingFiles = append(missingFiles, fmt.Sprintf("%s %s", declaredFile.description, declaredFile.filepath))
+		}
 	}
-	return base64.StdEncoding.EncodeToString(sigFile), nil
+	if len(missingFiles) > 0 {
+		return errors.Errorf("bundle is missing the declared %s", strings.Join(missingFiles, ", "))
+	}
+
+	return nil
 }
 
-func getLatestRelease(ctx context.Context, client *github.Client, repoName string, includePreRelease bool) (*github.RepositoryRelease, error) {
-	releases, _, err := client.Repositories.ListReleases(ctx, "mattermost", repoName, &github.ListOptions{
-		Page:    0,
-		PerPage: 10,
-	})
+func downloadSignature(downloader *assetDownloader, asset *ReleaseAsset, draft bool) (string, error) {
+	signature, err := getSignatureFromAsset(downloader, asset, draft)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get releases for repository %s", repoName)
+		return "", errors.Wrap(err, "Can't get signature from the asset")
 	}
 
-	var latestRelease *github.RepositoryRelease
-	for _, release := range releases {
-		if release.GetDraft() {
-			continue
-		}
+	return signature, nil
+}
 
-		if release.GetPrerelease() && !includePreRelease {
-			continue
-		}
+func getSignatureFromAsset(downloader *assetDownloader, asset *ReleaseAsset, draft bool) (string, error) {
+	downloader.logger.Debugf("fetching signature file from %s", asset.DownloadURL)
 
-		if latestRelease == nil || release.GetPublishedAt().After(latestRelease.GetPublishedAt().Time) {
-			latestRelease = release
-		}
+	signature, err := downloader.open(asset, draft)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to download signature file %s", asset.Name)
 	}
+	defer signature.Close()
 
-	return latestRelease, nil
+	sigFile, err := ioutil.ReadAll(signature)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open downloaded signature file %s", asset.Name)
+	}
+	return base64.StdEncoding.EncodeToString(sigFile), nil
 }
 
-func getIcon(ctx context.Context, icon string) ([]byte, error) {
+func getIcon(ctx context.Context, logger logrus.FieldLogger, icon string) ([]byte, error) {
 	if strings.HasPrefix(icon, "http") {
 		logger.Debugf("fetching icon from url %s", icon)
 
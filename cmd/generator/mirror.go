@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	mirrorCmd.Flags().String("database", "plugins.json", "The plugins.json database to mirror.")
+	mirrorCmd.Flags().String("directory", "mirror", "The directory in which to write the mirror.")
+	mirrorCmd.Flags().String("base-url", "", "The url at which the mirror directory will be served, e.g. https://marketplace.example.com.")
+
+	generatorCmd.AddCommand(mirrorCmd)
+}
+
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Download every asset referenced by a plugins.json database for serving without internet access",
+	Long: `Mirror downloads every plugin bundle and image referenced by the given database into a local
+directory, writing each bundle's signatures alongside it. The database itself is written to
+plugins.json in the same directory, with its urls rewritten relative to the given base url.
+
+Icons are embedded in the database as data URIs, and so need no mirroring. Files already present
+in the directory are reused if they match the recorded checksums, allowing a mirror to be
+refreshed incrementally.`,
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command, "")
+		if err != nil {
+			return err
+		}
+
+		baseURL, _ := command.Flags().GetString("base-url")
+		if baseURL == "" {
+			return errors.New("--base-url is required")
+		}
+		if u, err := url.Parse(baseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.Errorf("base url %s must use http or https", baseURL)
+		}
+
+		database, _ := command.Flags().GetString("database")
+		file, err := os.Open(database)
+		if err != nil {
+			return errors.Wrapf(err, "failed to open %s", database)
+		}
+		defer file.Close()
+
+		plugins, err := model.PluginsFromReader(file)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", database)
+		}
+
+		directory, _ := command.Flags().GetString("directory")
+		mirror := &mirror{
+			directory: directory,
+			baseURL:   strings.TrimSuffix(baseURL, "/"),
+			files:     map[string]string{},
+			paths:     map[string]bool{},
+		}
+
+		for _, plugin := range plugins {
+			if err := mirror.mirrorPlugin(plugin); err != nil {
+				return errors.Wrapf(err, "failed to mirror %s %s", plugin.Manifest.Id, plugin.Manifest.Version)
+			}
+		}
+
+		if err := os.MkdirAll(directory, 0755); err != nil {
+			return errors.Wrapf(err, "failed to create %s", directory)
+		}
+		databasePath := filepath.Join(directory, "plugins.json")
+		output, err := os.Create(databasePath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s", databasePath)
+		}
+		defer output.Close()
+
+		if err := model.PluginsToWriter(output, plugins, getWriterOptions(command)); err != nil {
+			return errors.Wrapf(err, "failed to write %s", databasePath)
+		}
+		if err := output.Close(); err != nil {
+			return errors.Wrapf(err, "failed to write %s", databasePath)
+		}
+
+		logger.Infof("mirrored %d plugins to %s", len(plugins), directory)
+
+		if format != "" {
+			return printReport(format, newWriteReport(directory, plugins))
+		}
+
+		return nil
+	},
+}
+
+// mirror downloads assets into a directory, tracking what was already mirrored.
+type mirror struct {
+	directory string
+	baseURL   string
+	// files maps each mirrored url to its path relative to the directory.
+	files map[string]string
+	// paths records the relative paths already claimed by a mirrored url.
+	paths map[string]bool
+}
+
+// mirrorPlugin mirrors the assets of the given plugin, rewriting its urls to reference the mirror.
+func (m *mirror) mirrorPlugin(plugin *model.Plugin) error {
+	for _, segment := range []string{plugin.Manifest.Id, plugin.Manifest.Version} {
+		if !isPathSegment(segment) {
+			return errors.Errorf("%s is not usable as a directory name", segment)
+		}
+	}
+	pluginDirectory := path.Join("plugins", plugin.Manifest.Id, plugin.Manifest.Version)
+
+	if plugin.DownloadURL != "" {
+		mirroredURL, err := m.mirrorBundle(plugin.DownloadURL, pluginDirectory, plugin.Checksums, plugin.AllSignatures())
+		if err != nil {
+			return err
+		}
+		plugin.DownloadURL = mirroredURL
+	}
+
+	for platform, bundle := range plugin.Platforms {
+		if !model.IsValidPlatform(platform) {
+			return errors.Errorf("invalid platform %s", platform)
+		}
+		mirroredURL, err := m.mirrorBundle(bundle.DownloadURL, path.Join(pluginDirectory, platform), bundle.Checksums, bundle.Signatures)
+		if err != nil {
+			return errors.Wrapf(err, "failed to mirror %s bundle", platform)
+		}
+		bundle.DownloadURL = mirroredURL
+	}
+
+	imageDirectory := path.Join("images", plugin.Manifest.Id, plugin.Manifest.Version)
+	for i, screenshot := range plugin.Screenshots {
+		mirroredURL, err := m.mirrorImage(screenshot, imageDirectory)
+		if err != nil {
+			return errors.Wrap(err, "failed to mirror screenshot")
+		}
+		plugin.Screenshots[i] = mirroredURL
+	}
+
+	mirroredURL, err := m.mirrorImage(plugin.BannerImageURL, imageDirectory)
+	if err != nil {
+		return errors.Wrap(err, "failed to mirror banner image")
+	}
+	plugin.BannerImageURL = mirroredURL
+
+	mirroredURL, err = m.mirrorImage(plugin.IconURL, imageDirectory)
+	if err != nil {
+		return errors.Wrap(err, "failed to mirror icon")
+	}
+	plugin.IconURL = mirroredURL
+
+	return nil
+}
+
+// isPathSegment reports whether the given value names a single file or directory, and so may
+// safely be joined to a path.
+func isPathSegment(value string) bool {
+	return value != "" && value != "." && value != ".." && !strings.ContainsAny(value, `/\`)
+}
+
+// mirrorBundle mirrors the bundle at the given url into the given directory along with its
+// decoded signatures, verifying it against the given checksums, if any.
+func (m *mirror) mirrorBundle(bundleURL, directory string, checksums *model.Checksums, signatures []*model.Signature) (string, error) {
+	relativePath, err := m.mirrorFile(bundleURL, directory, "bundle.tar.gz", checksums)
+	if err != nil {
+		return "", err
+	}
+
+	for i, signature := range signatures {
+		signatureData, err := base64.StdEncoding.DecodeString(signature.Signature)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to decode signature")
+		}
+
+		signaturePath := relativePath + ".sig"
+		if i > 0 {
+			signaturePath = fmt.Sprintf("%s.%d.sig", relativePath, i)
+		}
+		if err := ioutil.WriteFile(filepath.Join(m.directory, filepath.FromSlash(signaturePath)), signatureData, 0644); err != nil {
+			return "", errors.Wrapf(err, "failed to write %s", signaturePath)
+		}
+	}
+
+	return m.url(relativePath), nil
+}
+
+// mirrorImage mirrors the image at the given url into the given directory, leaving data URIs and
+// empty values untouched.
+func (m *mirror) mirrorImage(imageURL, directory string) (string, error) {
+	if imageURL == "" || strings.HasPrefix(imageURL, "data:") {
+		return imageURL, nil
+	}
+
+	relativePath, err := m.mirrorFile(imageURL, directory, "image", nil)
+	if err != nil {
+		return "", err
+	}
+
+	return m.url(relativePath), nil
+}
+
+// url returns the url at which the given relative path is served.
+func (m *mirror) url(relativePath string) string {
+	segments := strings.Split(relativePath, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return m.baseURL + "/" + strings.Join(segments, "/")
+}
+
+// mirrorFile downloads the given url into the given directory, named after the url's last path
+// segment, and returns its slash-separated path relative to the mirror directory.
+func (m *mirror) mirrorFile(fileURL, directory, defaultName string, checksums *model.Checksums) (string, error) {
+	if relativePath, ok := m.files[fileURL]; ok {
+		return relativePath, nil
+	}
+
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse %s", fileURL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.Errorf("%s must use http or https", fileURL)
+	}
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = defaultName
+	}
+	relativePath := path.Join(directory, name)
+	for i := 1; m.paths[relativePath]; i++ {
+		relativePath = path.Join(directory, fmt.Sprintf("%d-%s", i, name))
+	}
+
+	localPath := filepath.Join(m.directory, filepath.FromSlash(relativePath))
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return "", errors.Wrapf(err, "failed to create directory for %s", relativePath)
+	}
+
+	if checksums != nil && matchesChecksums(localPath, checksums) {
+		logger.Debugf("reusing %s for %s", relativePath, fileURL)
+	} else if err := downloadFile(fileURL, localPath, checksums); err != nil {
+		return "", err
+	}
+
+	m.files[fileURL] = relativePath
+	m.paths[relativePath] = true
+
+	return relativePath, nil
+}
+
+// matchesChecksums reports whether the given file exists and matches the given checksums.
+func matchesChecksums(localPath string, checksums *model.Checksums) bool {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	checksumsWriter := model.NewChecksumsWriter()
+	if _, err := io.Copy(checksumsWriter, file); err != nil {
+		return false
+	}
+
+	return checksums.Verify(checksumsWriter.Checksums()) == nil
+}
+
+// downloadFile downloads the given url to the given path, verifying it against the given
+// checksums, if any, before replacing anything already at that path.
+func downloadFile(fileURL, localPath string, checksums *model.Checksums) error {
+	logger.Debugf("downloading %s", fileURL)
+
+	resp, err := http.Get(fileURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %s", fileURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to download %s: status code %d", fileURL, resp.StatusCode)
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(localPath), filepath.Base(localPath)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(file.Name())
+
+	checksumsWriter := model.NewChecksumsWriter()
+	if _, err := io.Copy(io.MultiWriter(file, checksumsWriter), resp.Body); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to download %s", fileURL)
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", file.Name())
+	}
+
+	if checksums != nil {
+		if err := checksums.Verify(checksumsWriter.Checksums()); err != nil {
+			return errors.Wrapf(err, "failed to verify %s", fileURL)
+		}
+	}
+
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return errors.Wrapf(err, "failed to set permissions of %s", file.Name())
+	}
+	if err := os.Rename(file.Name(), localPath); err != nil {
+		return errors.Wrapf(err, "failed to replace %s", localPath)
+	}
+
+	return nil
+}
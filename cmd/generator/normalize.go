@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/normalize"
+)
+
+func init() {
+	generatorCmd.Flags().Bool("normalize", false, "Whether to trim and collapse the whitespace of plugin names and descriptions, subject to the --normalize-* rules.")
+	generatorCmd.Flags().Bool("normalize-strip-markup", true, "Whether normalizing strips HTML and markdown formatting from names and descriptions.")
+	generatorCmd.Flags().Int("normalize-max-name-length", 64, "The number of characters at which normalizing truncates names, or 0 to leave them uncapped.")
+	generatorCmd.Flags().Int("normalize-max-description-length", 500, "The number of characters at which normalizing truncates descriptions, or 0 to leave them uncapped.")
+}
+
+// newNormalizeRules creates the rules given by the --normalize-* flags, or returns nil unless
+// --normalize is given.
+func newNormalizeRules(command *cobra.Command) *normalize.Rules {
+	if enabled, _ := command.Flags().GetBool("normalize"); !enabled {
+		return nil
+	}
+
+	stripMarkup, _ := command.Flags().GetBool("normalize-strip-markup")
+	maxNameLength, _ := command.Flags().GetInt("normalize-max-name-length")
+	maxDescriptionLength, _ := command.Flags().GetInt("normalize-max-description-length")
+
+	return &normalize.Rules{
+		StripMarkup:          stripMarkup,
+		MaxNameLength:        maxNameLength,
+		MaxDescriptionLength: maxDescriptionLength,
+	}
+}
+
+// normalizePlugins applies the given rules to each plugin, logging those changed.
+func normalizePlugins(rules *normalize.Rules, plugins []*model.Plugin) {
+	for _, plugin := range plugins {
+		if rules.Apply(plugin) {
+			logger.Infof("normalized name and description of %s %s", plugin.Manifest.Id, plugin.Manifest.Version)
+		}
+	}
+}
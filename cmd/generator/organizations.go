@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	generatorCmd.Flags().String("organizations", "", "An optional YAML file configuring additional source organizations, each with its own provider and token, whose repositories are generated concurrently with the others and merged into the database.")
+}
+
+// organizationsFile configures the source organizations of a generation run.
+type organizationsFile struct {
+	Organizations []*organization `yaml:"organizations"`
+}
+
+// organization is a source of repositories, such as an internal GitHub organization, queried with
+// its own credentials.
+type organization struct {
+	// Name identifies the organization, and owns its repositories.
+	Name string `yaml:"name"`
+	// Provider names the registered release provider hosting the organization, defaulting to
+	// github.
+	Provider string `yaml:"provider"`
+	// TokenEnv names the environment variable holding the GitHub token with which to query the
+	// organization, keeping the token itself out of the file.
+	TokenEnv string `yaml:"token_env"`
+	// GitHubBaseURL and GitHubUploadURL optionally locate the GitHub Enterprise Server hosting the
+	// organization.
+	GitHubBaseURL   string `yaml:"github_base_url"`
+	GitHubUploadURL string `yaml:"github_upload_url"`
+	// AuthorType is recorded on each plugin published from the organization, defaulting to
+	// community.
+	AuthorType   model.AuthorType          `yaml:"author_type"`
+	Repositories []*organizationRepository `yaml:"repositories"`
+}
+
+// organizationRepository describes a repository of an organization.
+type organizationRepository struct {
+	Name     string `yaml:"name"`
+	IconPath string `yaml:"icon_path"`
+}
+
+// organizationsFromReader parses and validates the organizations configured by the given reader.
+func organizationsFromReader(reader io.Reader) ([]*organization, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read organizations")
+	}
+
+	var file organizationsFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, errors.Wrap(err, "failed to parse organizations")
+	}
+
+	names := map[string]bool{}
+	for _, org := range file.Organizations {
+		if org == nil || org.Name == "" {
+			return nil, errors.New("organization has no name")
+		}
+		if names[strings.ToLower(org.Name)] {
+			return nil, errors.Errorf("organization %s configured twice", org.Name)
+		}
+		names[strings.ToLower(org.Name)] = true
+
+		if org.Provider == "" {
+			org.Provider = defaultReleaseProvider
+		}
+		if _, ok := releaseProviderFactories[org.Provider]; !ok {
+			return nil, errors.Errorf("unknown release provider %s for organization %s", org.Provider, org.Name)
+		}
+		if org.Provider != defaultReleaseProvider && (org.TokenEnv != "" || org.GitHubBaseURL != "" || org.GitHubUploadURL != "") {
+			return nil, errors.Errorf("organization %s configures GitHub credentials for release provider %s", org.Name, org.Provider)
+		}
+		if org.GitHubUploadURL != "" && org.GitHubBaseURL == "" {
+			return nil, errors.Errorf("organization %s configures github_upload_url without github_base_url", org.Name)
+		}
+
+		if org.AuthorType == "" {
+			org.AuthorType = model.AuthorTypeCommunity
+		}
+		if !org.AuthorType.IsValid() {
+			return nil, errors.Errorf("invalid author_type %s for organization %s", org.AuthorType, org.Name)
+		}
+
+		if len(org.Repositories) == 0 {
+			return nil, errors.Errorf("organization %s has no repositories", org.Name)
+		}
+		for _, r := range org.Repositories {
+			if r == nil || r.Name == "" {
+				return nil, errors.Errorf("repository of organization %s has no name", org.Name)
+			}
+		}
+	}
+
+	return file.Organizations, nil
+}
+
+// getOrganizationRepositories returns the repositories of the organizations configured by
+// --organizations, registering the provider of each organization with the given providers.
+func getOrganizationRepositories(command *cobra.Command, providers *releaseProviders) ([]repository, error) {
+	path, _ := command.Flags().GetString("organizations")
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open organizations %s", path)
+	}
+	defer file.Close()
+
+	organizations, err := organizationsFromReader(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid organizations %s", path)
+	}
+
+	var repositories []repository
+	for _, org := range organizations {
+		provider, err := newOrganizationProvider(command, org)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize release provider for organization %s", org.Name)
+		}
+		providers.lock.Lock()
+		providers.organizations[org.Name] = provider
+		providers.lock.Unlock()
+
+		for _, r := range org.Repositories {
+			repositories = append(repositories, repository{
+				Organization: org.Name,
+				Provider:     org.Provider,
+				Owner:        org.Name,
+				Name:         r.Name,
+				IconPath:     r.IconPath,
+				AuthorType:   org.AuthorType,
+			})
+		}
+	}
+
+	return repositories, nil
+}
+
+// newOrganizationProvider creates a release provider dedicated to the given organization, so that
+// it queries with the organization's own token and within its own rate limit.
+func newOrganizationProvider(command *cobra.Command, org *organization) (ReleaseProvider, error) {
+	if org.Provider != defaultReleaseProvider {
+		return releaseProviderFactories[org.Provider](command)
+	}
+
+	var token string
+	if org.TokenEnv != "" {
+		token = os.Getenv(org.TokenEnv)
+		if token == "" {
+			return nil, errors.Errorf("environment variable %s is not set", org.TokenEnv)
+		}
+	}
+
+	includeDrafts, _ := command.Flags().GetBool("include-drafts")
+	if includeDrafts && token == "" {
+		return nil, errors.New("--include-drafts requires a token_env")
+	}
+
+	return newGitHubReleaseProviderWithClient(command, token, org.GitHubBaseURL, org.GitHubUploadURL)
+}
+
+// repositoryJob generates the repository at the given index of a generation run.
+type repositoryJob func(ctx context.Context, logger logrus.FieldLogger, index int, r repository) error
+
+// generateConcurrently runs the given job for each repository, running the repositories of each
+// organization, and those of no organization, concurrently with one another. The repositories of
+// an organization are generated in turn, to stay within the rate limit of its token. The first
+// error returned by a job stops the run, and is returned once every running job completes.
+func generateConcurrently(ctx context.Context, repositories []repository, job repositoryJob) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var sources []string
+	indexesBySource := map[string][]int{}
+	for i, r := range repositories {
+		if _, ok := indexesBySource[r.Organization]; !ok {
+			sources = append(sources, r.Organization)
+		}
+		indexesBySource[r.Organization] = append(indexesBySource[r.Organization], i)
+	}
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, source := range sources {
+		wg.Add(1)
+		go func(source string) {
+			defer wg.Done()
+
+			var sourceLogger logrus.FieldLogger = logger
+			if source != "" {
+				sourceLogger = logger.WithField(organizationLogField, source)
+			}
+			for _, i := range indexesBySource[source] {
+				if ctx.Err() != nil {
+					return
+				}
+				r := repositories[i]
+				if err := job(ctx, sourceLogger.WithField(repositoryLogField, r.Name), i, r); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+			}
+		}(source)
+	}
+	wg.Wait()
+
+	return firstErr
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+func init() {
+	generatorCmd.PersistentFlags().StringP("output", "o", "", "The output format of the command's report, one of table, json or yaml. Commands writing a database log their summary unless given.")
+}
+
+// getFormat returns the output format given by --output, or the given default format if none is.
+func getFormat(command *cobra.Command, defaultFormat string) (string, error) {
+	format, _ := command.Flags().GetString("output")
+	if format == "" {
+		format = defaultFormat
+	}
+
+	switch format {
+	case "", formatTable, formatJSON, formatYAML:
+		return format, nil
+	default:
+		return "", configError(errors.Errorf("unsupported output format %s", format))
+	}
+}
+
+// stdoutReportError rejects a report requested with --output for a command writing its database
+// to stdout, as the report would corrupt the database.
+func stdoutReportError(outputFlag string) error {
+	return configError(errors.Errorf("--output requires --%s, as the database is otherwise written to stdout", outputFlag))
+}
+
+// tableReport is a report that can be printed as a table.
+type tableReport interface {
+	printTable(w io.Writer) error
+}
+
+// printReport writes the given report to stdout in the given format.
+func printReport(format string, report tableReport) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return errors.Wrap(err, "failed to encode report")
+		}
+		return nil
+	case formatYAML:
+		if err := model.EncodeYAML(os.Stdout, report); err != nil {
+			return errors.Wrap(err, "failed to encode report")
+		}
+		return nil
+	default:
+		return report.printTable(os.Stdout)
+	}
+}
+
+// writeReport summarizes a database written by a command.
+type writeReport struct {
+	// Output is the file or directory written.
+	Output   string `json:"output"`
+	Plugins  int    `json:"plugins"`
+	Versions int    `json:"versions"`
+	// Warnings describes the icons dropped from plugins rather than failing the command.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// newWriteReport summarizes the given plugins written to the given file or directory.
+func newWriteReport(output string, plugins []*model.Plugin) *writeReport {
+	ids := map[string]bool{}
+	for _, plugin := range plugins {
+		ids[plugin.Manifest.Id] = true
+	}
+
+	return &writeReport{Output: output, Plugins: len(ids), Versions: len(plugins)}
+}
+
+func (r *writeReport) printTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OUTPUT\tPLUGINS\tVERSIONS")
+	fmt.Fprintf(tw, "%s\t%d\t%d\n", r.Output, r.Plugins, r.Versions)
+	if len(r.Warnings) > 0 {
+		fmt.Fprintln(tw, "\nWARNING")
+		for _, warning := range r.Warnings {
+			fmt.Fprintln(tw, warning)
+		}
+	}
+
+	return tw.Flush()
+}
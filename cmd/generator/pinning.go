@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	generatorCmd.Flags().Bool("verify-checksums", false, "Whether to verify that bundles recorded in --existing still match their recorded checksums, downloading those reused as is, and failing on any mismatch, e.g. after a release asset was silently replaced.")
+}
+
+// verifyReusedChecksums verifies that the bundles recorded in the existing database still match
+// their recorded checksums. Bundles reused as is are downloaded again to verify them, while those
+// downloaded afresh, such as after their release asset was updated, are compared to the checksums
+// recorded for the same url. Every mismatch is reported before failing.
+func verifyReusedChecksums(plugins, existingPlugins []*model.Plugin) error {
+	existingChecksums := map[string]*model.Checksums{}
+	for _, plugin := range existingPlugins {
+		if plugin.DownloadURL != "" {
+			existingChecksums[plugin.DownloadURL] = plugin.Checksums
+		}
+		for _, bundle := range plugin.Platforms {
+			existingChecksums[bundle.DownloadURL] = bundle.Checksums
+		}
+	}
+
+	failed := 0
+	verify := func(plugin *model.Plugin, downloadURL string, checksums *model.Checksums) {
+		recordedChecksums, ok := existingChecksums[downloadURL]
+		if !ok {
+			return
+		}
+
+		logger := logger.WithField("plugin", plugin.Manifest.Id).WithField("version", plugin.Manifest.Version)
+		if recordedChecksums == nil {
+			logger.Warnf("no checksums recorded for %s", downloadURL)
+			return
+		}
+
+		var err error
+		switch {
+		case checksums == recordedChecksums:
+			// The bundle was reused as is, so download it again to verify it.
+			err = verifyDownloadChecksums(downloadURL, recordedChecksums)
+		case checksums == nil:
+			err = errors.Errorf("no checksums computed for %s", downloadURL)
+		default:
+			// Bundles downloaded afresh are checksummed only by SHA256.
+			if err = (&model.Checksums{SHA256: recordedChecksums.SHA256}).Verify(checksums); err != nil {
+				err = errors.Wrapf(err, "failed to verify %s", downloadURL)
+			}
+		}
+		if err != nil {
+			logger.WithError(err).Error("bundle no longer matches its recorded checksums")
+			failed++
+		}
+	}
+
+	for _, plugin := range plugins {
+		if plugin.DownloadURL != "" {
+			verify(plugin, plugin.DownloadURL, plugin.Checksums)
+		}
+		for _, bundle := range plugin.Platforms {
+			verify(plugin, bundle.DownloadURL, bundle.Checksums)
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf("%d bundles no longer match their recorded checksums", failed)
+	}
+
+	return nil
+}
+
+// verifyDownloadChecksums downloads the given url, following any redirects, and verifies the
+// content against the given checksums.
+func verifyDownloadChecksums(downloadURL string, checksums *model.Checksums) error {
+	logger.Debugf("verifying checksums of %s", downloadURL)
+
+	resp, err := http.Get(downloadURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %s", downloadURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to download %s: status code %d", downloadURL, resp.StatusCode)
+	}
+
+	checksumsWriter := model.NewChecksumsWriter()
+	if _, err := io.Copy(checksumsWriter, resp.Body); err != nil {
+		return errors.Wrapf(err, "failed to download %s", downloadURL)
+	}
+
+	if err := checksums.Verify(checksumsWriter.Checksums()); err != nil {
+		return errors.Wrapf(err, "failed to verify %s", downloadURL)
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// platformBundleRegexp matches the name of a per-platform bundle, e.g.
+// demo-1.0.0-linux-arm64.tar.gz, capturing its platform.
+var platformBundleRegexp = regexp.MustCompile(`-([a-z0-9]+-[a-z0-9]+)\.tar\.gz$`)
+
+// bundlePlatform returns the platform of the named per-platform bundle, or the empty string if the
+// asset is not one.
+func bundlePlatform(assetName string) string {
+	matches := platformBundleRegexp.FindStringSubmatch(assetName)
+	if matches == nil || !model.IsValidPlatform(matches[1]) {
+		return ""
+	}
+
+	return matches[1]
+}
+
+// platformManifest holds the server executables declared by a manifest, keyed by platform.
+// Unlike mattermostModel.ManifestExecutables, it is not limited to the amd64 platforms.
+type platformManifest struct {
+	Server  *platformManifestServer `json:"server"`
+	Backend *platformManifestServer `json:"backend"`
+}
+
+type platformManifestServer struct {
+	Executables map[string]string `json:"executables"`
+	Executable  string            `json:"executable"`
+}
+
+// executable returns the server executable declared for the given platform, if any.
+func (m *platformManifest) executable(platform string) string {
+	// Support the deprecated backend manifest field.
+	server := m.Server
+	if server == nil {
+		server = m.Backend
+	}
+	if server == nil {
+		return ""
+	}
+
+	if executable := server.Executables[platform]; executable != "" {
+		return executable
+	}
+
+	return server.Executable
+}
+
+// getPlatformBundles validates the per-platform bundles among the assets of the given release,
+// other than its bundle, returning those that pass keyed by platform. Each platform bundle must
+// declare the same plugin and version as the given manifest of the release's bundle, contain the
+// server executable it declares for its platform, and be signed if the release's bundle is. A
+// platform bundle failing validation is logged and left out, rather than failing the release.
+//
+// The checksums of the given existing platform bundles are reused for bundles at the same url,
+// unless refresh is set.
+func getPlatformBundles(logger logrus.FieldLogger, release *Release, releaseName string, bundleAsset *ReleaseAsset, manifest *mattermostModel.Manifest, signed bool, downloader *assetDownloader, existing map[string]*model.PlatformBundle, refresh bool) map[string]*model.PlatformBundle {
+	signatureAssets := map[string][]*ReleaseAsset{}
+	for _, releaseAsset := range release.Assets {
+		for _, extension := range []string{".sig", ".asc"} {
+			if strings.HasSuffix(releaseAsset.Name, extension) {
+				bundleName := strings.TrimSuffix(releaseAsset.Name, extension)
+				signatureAssets[bundleName] = append(signatureAssets[bundleName], releaseAsset)
+			}
+		}
+	}
+
+	var platforms map[string]*model.PlatformBundle
+	for _, releaseAsset := range release.Assets {
+		platform := bundlePlatform(releaseAsset.Name)
+		if platform == "" || releaseAsset == bundleAsset {
+			continue
+		}
+
+		logger := logger.WithField("platform", platform)
+		if _, ok := platforms[platform]; ok {
+			logger.Warnf("ignoring duplicate platform bundle %s for release %s", releaseAsset.Name, releaseName)
+			continue
+		}
+
+		var checksums *model.Checksums
+		if bundle, ok := existing[platform]; ok && !refresh && bundle.DownloadURL == releaseAsset.DownloadURL {
+			checksums = bundle.Checksums
+		}
+
+		bundle, err := getPlatformBundle(downloader, release, releaseAsset, signatureAssets[releaseAsset.Name], platform, manifest, signed, checksums)
+		if err != nil {
+			logger.WithError(err).Warnf("skipping invalid platform bundle %s for release %s", releaseAsset.Name, releaseName)
+			continue
+		}
+
+		if platforms == nil {
+			platforms = map[string]*model.PlatformBundle{}
+		}
+		platforms[platform] = bundle
+	}
+
+	return platforms
+}
+
+// getPlatformBundle validates the given per-platform bundle against the manifest of the release's
+// bundle, downloading it unless its checksums are given.
+func getPlatformBundle(downloader *assetDownloader, release *Release, asset *ReleaseAsset, signatureAssets []*ReleaseAsset, platform string, manifest *mattermostModel.Manifest, signed bool, checksums *model.Checksums) (*model.PlatformBundle, error) {
+	var signatures []*model.Signature
+	for _, signatureAsset := range signatureAssets {
+		signature, err := downloadSignature(downloader, signatureAsset, release.Draft)
+		if err != nil {
+			return nil, err
+		}
+
+		publicKeyHash, err := api.SignatureKeyID(signature)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to identify signing key of %s", signatureAsset.Name)
+		}
+
+		signatures = append(signatures, &model.Signature{
+			Signature:     signature,
+			PublicKeyHash: publicKeyHash,
+		})
+	}
+	if signed && len(signatures) == 0 {
+		return nil, errors.New("bundle is unsigned, unlike the release's bundle")
+	}
+
+	if checksums == nil {
+		var err error
+		checksums, err = verifyPlatformBundle(downloader, release, asset, platform, manifest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &model.PlatformBundle{
+		DownloadURL: asset.DownloadURL,
+		Signatures:  signatures,
+		Checksums:   checksums,
+	}, nil
+}
+
+// verifyPlatformBundle downloads the given per-platform bundle, verifying its manifest matches the
+// given manifest and that it contains the server executable declared for its platform, and
+// returns its checksums.
+func verifyPlatformBundle(downloader *assetDownloader, release *Release, asset *ReleaseAsset, platform string, manifest *mattermostModel.Manifest) (*model.Checksums, error) {
+	bundle, err := downloader.open(asset, release.Draft)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download bundle")
+	}
+	defer bundle.Close()
+
+	checksumsWriter := model.NewChecksumsWriter()
+	gzBundleReader, err := gzip.NewReader(io.TeeReader(bundle, checksumsWriter))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read gzipped bundle")
+	}
+
+	bundleData, err := ioutil.ReadAll(gzBundleReader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read bundle")
+	}
+
+	// Drain any trailing bytes so the checksum covers the bundle exactly as served.
+	if _, err = io.Copy(checksumsWriter, bundle); err != nil {
+		return nil, errors.Wrap(err, "failed to checksum bundle")
+	}
+
+	manifestData, err := getFromTarFile(tar.NewReader(bytes.NewReader(bundleData)), "plugin.json")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest from bundle")
+	}
+	platformManifestData := mattermostModel.ManifestFromJson(bytes.NewReader(manifestData))
+	if platformManifestData == nil {
+		return nil, errors.New("manifest nil after reading from bundle")
+	}
+	if platformManifestData.Id != manifest.Id || platformManifestData.Version != manifest.Version {
+		return nil, errors.Errorf("manifest declares %s %s rather than %s %s", platformManifestData.Id, platformManifestData.Version, manifest.Id, manifest.Version)
+	}
+
+	executables := &platformManifest{}
+	if err := json.Unmarshal(manifestData, executables); err != nil {
+		return nil, errors.Wrap(err, "failed to parse manifest executables")
+	}
+	if executable := executables.executable(platform); executable != "" {
+		if strings.HasPrefix(platform, "windows-") && path.Ext(executable) != ".exe" {
+			return nil, errors.Errorf("server executable %s lacks the .exe extension required on windows", executable)
+		}
+		if _, err := getFromTarFile(tar.NewReader(bytes.NewReader(bundleData)), path.Clean(executable)); err != nil {
+			return nil, errors.Wrapf(err, "bundle is missing the declared server executable %s", executable)
+		}
+	} else if platformManifestData.HasServer() {
+		return nil, errors.Errorf("manifest declares no server executable for %s", platform)
+	}
+
+	return &model.Checksums{SHA256: checksumsWriter.Checksums().SHA256}, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// presentationFile is the file in which plugin authors describe how their plugin is presented in
+// the marketplace, read from the plugin bundle or else from the root of the repository.
+const presentationFile = ".marketplace.yml"
+
+// presentation describes how a plugin is presented in the marketplace, as given by its authors.
+type presentation struct {
+	Labels      []model.Label            `yaml:"labels"`
+	Screenshots []string                 `yaml:"screenshots"`
+	Description string                   `yaml:"description"`
+	Hosting     model.HostingRequirement `yaml:"hosting"`
+}
+
+// parsePresentation decodes and validates the given presentation file, rejecting unknown fields so
+// that typos are reported rather than ignored.
+func parsePresentation(data []byte) (*presentation, error) {
+	var result presentation
+	if err := yaml.UnmarshalStrict(data, &result); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", presentationFile)
+	}
+
+	for i := range result.Labels {
+		if err := result.Labels[i].IsValid(); err != nil {
+			return nil, errors.Wrapf(err, "invalid label in %s", presentationFile)
+		}
+	}
+	for _, screenshot := range result.Screenshots {
+		if err := model.ValidateImageReference(screenshot); err != nil {
+			return nil, errors.Wrapf(err, "invalid screenshot in %s", presentationFile)
+		}
+	}
+	if result.Hosting != "" && !result.Hosting.IsValid() {
+		return nil, errors.Errorf("invalid hosting %s in %s", result.Hosting, presentationFile)
+	}
+
+	return &result, nil
+}
+
+// getPresentation reads the presentation of the plugin published by the given release, preferring
+// the file in its bundle to the file at the root of the repository as of the release's tag. It
+// returns nil if neither has one.
+func getPresentation(downloader *assetDownloader, release *Release, bundleData []byte) (*presentation, error) {
+	data, err := getFromTarFile(tar.NewReader(bytes.NewReader(bundleData)), presentationFile)
+	if err != nil {
+		// The bundle has no presentation file.
+		data = nil
+	}
+
+	if data == nil {
+		reader, ok := downloader.provider.(RepositoryFileReader)
+		if !ok {
+			return nil, nil
+		}
+
+		data, err = reader.ReadRepositoryFile(downloader.ctx, downloader.owner, downloader.repositoryName, release.TagName, presentationFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s from repository", presentationFile)
+		}
+		if data == nil {
+			return nil, nil
+		}
+		downloader.logger.Debugf("using %s from repository at %s", presentationFile, release.TagName)
+	} else {
+		downloader.logger.Debugf("using %s from plugin bundle", presentationFile)
+	}
+
+	return parsePresentation(data)
+}
+
+// apply sets the fields of the given plugin given by the presentation.
+func (p *presentation) apply(plugin *model.Plugin) {
+	if len(p.Labels) > 0 {
+		plugin.Labels = p.Labels
+	}
+	if len(p.Screenshots) > 0 {
+		plugin.Screenshots = p.Screenshots
+	}
+	if p.Description != "" {
+		plugin.LongDescription = p.Description
+	}
+	if p.Hosting != "" {
+		plugin.HostingRequirement = p.Hosting
+	}
+}
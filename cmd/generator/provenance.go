@@ -0,0 +1,72 @@
+package main
+
+import (
+	"runtime/debug"
+	"time"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	generatorCmd.Flags().String("data-commit", "", "The commit of the data repository from which the database is generated, recorded in the provenance of each new plugin version.")
+}
+
+// releaseProvenance returns the provenance of a plugin version published by the given release of
+// the given repository, keeping the given provenance recorded by an earlier run if it concerns the
+// same release.
+func releaseProvenance(existing *model.Provenance, repositoryURL, releaseTag string) *model.Provenance {
+	if existing != nil && existing.SourceRepository == repositoryURL && existing.ReleaseTag == releaseTag {
+		return existing
+	}
+
+	return &model.Provenance{
+		SourceRepository: repositoryURL,
+		ReleaseTag:       releaseTag,
+	}
+}
+
+// recordProvenance completes the provenance of the given plugin versions first recorded by this
+// run, dating it with the given time so that generation remains reproducible.
+func recordProvenance(plugins []*model.Plugin, generatedAt time.Time, dataCommit string) {
+	version := generatorVersion()
+	for _, plugin := range plugins {
+		if plugin.Provenance == nil || !plugin.Provenance.GeneratedAt.IsZero() {
+			continue
+		}
+
+		plugin.Provenance.GeneratorVersion = version
+		plugin.Provenance.GeneratedAt = generatedAt
+		plugin.Provenance.DataCommit = dataCommit
+	}
+}
+
+// generatorVersion identifies the build of the generator by the commit it was built from, or else
+// by its module version, if known.
+func generatorVersion() string {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	revision, modified := "", false
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision != "" {
+		if modified {
+			return revision + "-dirty"
+		}
+		return revision
+	}
+
+	if buildInfo.Main.Version != "(devel)" {
+		return buildInfo.Main.Version
+	}
+
+	return ""
+}
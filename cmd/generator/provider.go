@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// defaultReleaseProvider hosts the repositories published in the marketplace unless otherwise
+// specified.
+const defaultReleaseProvider = "github"
+
+// Release describes a release of a repository, independent of the provider hosting it.
+type Release struct {
+	Name    string
+	TagName string
+	// HTMLURL is the web page of the release, published as its release notes.
+	HTMLURL string
+	// Body holds the notes published with the release, in Markdown.
+	Body       string
+	Prerelease bool
+	Draft      bool
+	// PublishedAt is when the release was published, or else created.
+	PublishedAt time.Time
+	Assets      []*ReleaseAsset
+}
+
+// ReleaseAsset describes a file attached to a release.
+type ReleaseAsset struct {
+	// ID optionally identifies the asset to its provider, e.g. to download draft assets.
+	ID   int64
+	Name string
+	// DownloadURL is the public url of the asset, published as the plugin's download url.
+	DownloadURL string
+	// UpdatedAt is when the asset was last uploaded, or else created.
+	UpdatedAt time.Time
+}
+
+// ReleaseProvider lists and downloads the releases of the repositories hosted by a forge, such as
+// GitHub, or by a plain index of releases.
+type ReleaseProvider interface {
+	// RepositoryURL returns the web page of the given repository, used as the homepage of plugins
+	// whose manifest has none.
+	RepositoryURL(ctx context.Context, owner, name string) (string, error)
+	// ListReleases returns every release of the given repository visible to the provider,
+	// including drafts if any, newest first.
+	ListReleases(ctx context.Context, owner, name string) ([]*Release, error)
+	// OpenAsset downloads the given asset of a release of the given repository. The assets of
+	// draft releases may require the provider's credentials.
+	OpenAsset(ctx context.Context, owner, name string, asset *ReleaseAsset, draft bool) (io.ReadCloser, error)
+	// IsReleaseAsset reports, without querying the provider, whether the given url downloads an
+	// asset of a release of the given repository, so that its plugins can be recognized among
+	// those of an existing database.
+	IsReleaseAsset(owner, name, downloadURL string) bool
+}
+
+// RepositoryArchiveChecker is optionally implemented by release providers able to tell whether a
+// repository was archived, marking its plugins as unmaintained.
+type RepositoryArchiveChecker interface {
+	IsRepositoryArchived(ctx context.Context, owner, name string) (bool, error)
+}
+
+// RepositoryFileReader is optionally implemented by release providers able to read the files of a
+// repository as of a given release.
+type RepositoryFileReader interface {
+	// ReadRepositoryFile returns the contents of the file at the given path of the given repository
+	// as of the given tag, or nil if there is no such file.
+	ReadRepositoryFile(ctx context.Context, owner, name, tag, path string) ([]byte, error)
+}
+
+// releaseProviderFactory creates a release provider configured by the flags of the given command.
+type releaseProviderFactory func(command *cobra.Command) (ReleaseProvider, error)
+
+// releaseProviderFactories maps the name of each registered release provider to its factory.
+var releaseProviderFactories = map[string]releaseProviderFactory{}
+
+// registerReleaseProvider makes the release provider created by the given factory available to
+// repositories under the given name. It is meant to be called from init, alongside the
+// registration of any flags the provider needs.
+func registerReleaseProvider(name string, factory releaseProviderFactory) {
+	if _, ok := releaseProviderFactories[name]; ok {
+		panic("release provider " + name + " registered twice")
+	}
+
+	releaseProviderFactories[name] = factory
+}
+
+// releaseProviders creates the release providers of a generation run on first use, so that only
+// the providers hosting the given repositories need be configured. It is safe for concurrent use.
+type releaseProviders struct {
+	command *cobra.Command
+
+	lock      sync.Mutex
+	providers map[string]ReleaseProvider
+	// organizations maps the name of each configured organization to the provider, with its own
+	// credentials, hosting its repositories.
+	organizations map[string]ReleaseProvider
+}
+
+// newReleaseProviders creates the release providers configured by the flags of the given command.
+func newReleaseProviders(command *cobra.Command) *releaseProviders {
+	return &releaseProviders{
+		command:       command,
+		providers:     map[string]ReleaseProvider{},
+		organizations: map[string]ReleaseProvider{},
+	}
+}
+
+// forRepository returns the release provider hosting the given repository: that of its
+// organization, if configured with one, or else the one registered under its provider's name.
+func (p *releaseProviders) forRepository(r repository) (ReleaseProvider, error) {
+	if r.Organization != "" {
+		p.lock.Lock()
+		provider, ok := p.organizations[r.Organization]
+		p.lock.Unlock()
+		if !ok {
+			return nil, errors.Errorf("unknown organization %s", r.Organization)
+		}
+		return provider, nil
+	}
+
+	return p.get(r.provider())
+}
+
+// get returns the release provider registered under the given name.
+func (p *releaseProviders) get(name string) (ReleaseProvider, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if provider, ok := p.providers[name]; ok {
+		return provider, nil
+	}
+
+	factory, ok := releaseProviderFactories[name]
+	if !ok {
+		var names []string
+		for name := range releaseProviderFactories {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return nil, errors.Errorf("unknown release provider %s, expected one of %s", name, strings.Join(names, ", "))
+	}
+
+	provider, err := factory(p.command)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to initialize release provider %s", name)
+	}
+	p.providers[name] = provider
+
+	return provider, nil
+}
+
+// openURL downloads the given public url, as release providers do for published assets.
+func openURL(downloadURL string) (io.ReadCloser, error) {
+	resp, err := http.Get(downloadURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s", downloadURL)
+	}
+
+	return resp.Body, nil
+}
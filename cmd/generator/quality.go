@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/quality"
+)
+
+func init() {
+	addQualityFlags(generatorCmd.Flags())
+	addQualityFlags(validateCmd.Flags())
+}
+
+// addQualityFlags registers the flags configuring the quality checks on the given flag set.
+func addQualityFlags(flags *pflag.FlagSet) {
+	flags.StringSlice("quality-check", nil, "Quality checks to apply to every plugin, as check=severity pairs with a severity of off, warn or block, e.g. icon=block. Checks are icon, description, release-notes, signature and min-server-version.")
+	flags.Int("quality-min-description-length", 20, "The number of characters a description must reach to pass the description check, unless set by --quality-rules.")
+	flags.String("quality-rules", "", "The optional YAML file of quality checks and custom rules to apply to every plugin, overridden by --quality-check.")
+}
+
+// newQualityConfig creates the quality checks and rules configured by the command's flags, or
+// returns nil if none are enabled.
+func newQualityConfig(command *cobra.Command) (*quality.Config, error) {
+	checks, _ := command.Flags().GetStringSlice("quality-check")
+	rulesPath, _ := command.Flags().GetString("quality-rules")
+	if len(checks) == 0 && rulesPath == "" {
+		return nil, nil
+	}
+
+	config := &quality.Config{Severities: map[quality.Check]quality.Severity{}}
+	if rulesPath != "" {
+		var err error
+		config, err = readQualityRules(rulesPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid --quality-rules")
+		}
+	}
+
+	severities, err := quality.ParseSeverities(checks)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid --quality-check")
+	}
+	for check, severity := range severities {
+		config.Severities[check] = severity
+	}
+	if config.MinDescriptionLength == 0 || command.Flags().Changed("quality-min-description-length") {
+		config.MinDescriptionLength, _ = command.Flags().GetInt("quality-min-description-length")
+	}
+
+	return config, nil
+}
+
+// readQualityRules reads the quality checks and rules of the given rules file.
+func readQualityRules(path string) (*quality.Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer file.Close()
+
+	return quality.ConfigFromReader(file)
+}
+
+// checkQuality evaluates the given plugins, logging every finding. Any blocking finding then fails
+// the check.
+func checkQuality(config *quality.Config, plugins []*model.Plugin) error {
+	blocked := 0
+	for _, plugin := range plugins {
+		for _, finding := range config.Evaluate(plugin) {
+			logger := logger.WithField("check", finding.Check)
+			if finding.Severity == quality.SeverityBlock {
+				logger.Error(finding.String())
+				blocked++
+			} else {
+				logger.Warn(finding.String())
+			}
+		}
+	}
+
+	if blocked > 0 {
+		return errors.Errorf("%d blocking quality checks failed", blocked)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	replayCmd.Flags().String("since", "", "The start of the window over which to replay release events, as an RFC 3339 time or a duration before now, e.g. 36h.")
+	replayCmd.Flags().String("until", "", "The optional end of the window over which to replay release events, as an RFC 3339 time or a duration before now. Defaults to now.")
+	replayCmd.Flags().StringSlice("repository", nil, "The repository, as owner/name, whose release events to replay. May be repeated.")
+	replayCmd.Flags().String("queue-file", "webhook-queue.json", "The queue of the webhook daemon, into which missed release events are queued.")
+	replayCmd.Flags().Duration("replay-window", 72*time.Hour, "How long the id of each delivery is remembered, as configured for the webhook daemon.")
+	replayCmd.Flags().String("run", "", "The optional command, split on whitespace, with which to regenerate the database for each queued release event before returning, as the webhook daemon would. Otherwise, the events are processed once the daemon next starts.")
+	replayCmd.Flags().Duration("run-timeout", time.Hour, "The time after which a run of --run is abandoned.")
+
+	webhookCmd.AddCommand(replayCmd)
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Queue the release events missed while the webhook daemon was down, as listed by GitHub",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		now := time.Now().UTC()
+		sinceValue, _ := command.Flags().GetString("since")
+		if sinceValue == "" {
+			return configError(errors.New("--since is required"))
+		}
+		since, err := parseReplayTime(sinceValue, now)
+		if err != nil {
+			return configError(errors.Wrap(err, "invalid --since"))
+		}
+		until := now
+		if untilValue, _ := command.Flags().GetString("until"); untilValue != "" {
+			until, err = parseReplayTime(untilValue, now)
+			if err != nil {
+				return configError(errors.Wrap(err, "invalid --until"))
+			}
+		}
+		if !since.Before(until) {
+			return configError(errors.New("--since must be before --until"))
+		}
+
+		repositories, _ := command.Flags().GetStringSlice("repository")
+		if len(repositories) == 0 {
+			return configError(errors.New("--repository is required"))
+		}
+		for _, fullName := range repositories {
+			if parts := strings.Split(fullName, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return configError(errors.Errorf("repository %s must be given as owner/name", fullName))
+			}
+		}
+
+		githubToken, _ := command.Flags().GetString("github-token")
+		githubBaseURL, _ := command.Flags().GetString("github-base-url")
+		githubUploadURL, _ := command.Flags().GetString("github-upload-url")
+		client, err := newGitHubClient(githubToken, githubBaseURL, githubUploadURL)
+		if err != nil {
+			return configError(err)
+		}
+
+		queueFile, _ := command.Flags().GetString("queue-file")
+		replayWindow, _ := command.Flags().GetDuration("replay-window")
+		queue, err := loadWebhookQueue(queueFile, replayWindow)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		for _, fullName := range repositories {
+			logger := logger.WithField("repository", fullName)
+
+			events, err := listReleaseEvents(ctx, client, fullName, since, until)
+			if err != nil {
+				return err
+			}
+
+			queued := 0
+			for _, event := range events {
+				accepted, err := queue.replay(event)
+				if err != nil {
+					return err
+				}
+				if accepted {
+					logger.Infof("queued missed %s release %s", event.Action, event.Tag)
+					queued++
+				}
+			}
+			logger.Infof("queued %d of %d release events", queued, len(events))
+		}
+
+		run, _ := command.Flags().GetString("run")
+		runArgs := strings.Fields(run)
+		if len(runArgs) == 0 {
+			return nil
+		}
+
+		runTimeout, _ := command.Flags().GetDuration("run-timeout")
+		runner := &webhookRunner{queue: queue, args: runArgs, timeout: runTimeout}
+
+		return runner.drain(ctx)
+	},
+}
+
+// parseReplayTime parses the given RFC 3339 time, or duration before the given time.
+func parseReplayTime(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%s is neither an RFC 3339 time nor a duration", value)
+	}
+
+	return t.UTC(), nil
+}
+
+// listReleaseEvents returns the release events of the given repository created within the given
+// window, oldest first, as listed by the GitHub events API. The API only lists the events of the
+// past 90 days, up to 300 of them.
+func listReleaseEvents(ctx context.Context, client *github.Client, fullName string, since, until time.Time) ([]*webhookEvent, error) {
+	parts := strings.SplitN(fullName, "/", 2)
+	owner, name := parts[0], parts[1]
+
+	var events []*webhookEvent
+	options := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Activity.ListRepositoryEvents(ctx, owner, name, options)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list events of repository %s", fullName)
+		}
+
+		reachedSince := false
+		for _, event := range page {
+			createdAt := event.GetCreatedAt()
+			if createdAt.Before(since) {
+				// Events are listed newest first.
+				reachedSince = true
+				break
+			}
+			if event.GetType() != "ReleaseEvent" || createdAt.After(until) {
+				continue
+			}
+
+			payload, err := event.ParsePayload()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse event %s of repository %s", event.GetID(), fullName)
+			}
+			releaseEvent, ok := payload.(*github.ReleaseEvent)
+			if !ok {
+				continue
+			}
+
+			events = append(events, &webhookEvent{
+				Delivery:   replayedDeliveryPrefix + event.GetID(),
+				Repository: fullName,
+				Tag:        releaseEvent.GetRelease().GetTagName(),
+				Action:     releaseEvent.GetAction(),
+				ReceivedAt: createdAt.UTC(),
+			})
+		}
+
+		if reachedSince || resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].ReceivedAt.Before(events[j].ReceivedAt)
+	})
+
+	return events, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	generatorCmd.PersistentFlags().Int64("source-date-epoch", 0, "The Unix time with which to date signatures and generated sites, defaulting to $SOURCE_DATE_EPOCH, or else the current time.")
+	generatorCmd.Flags().String("verify-reproducible", "", "An existing database file or split database directory to regenerate, failing unless the output is byte-identical to it. Nothing is written.")
+}
+
+// getSourceDate returns the time given by --source-date-epoch or $SOURCE_DATE_EPOCH, or the zero
+// time if neither is given.
+func getSourceDate(command *cobra.Command) (time.Time, error) {
+	epoch, _ := command.Flags().GetInt64("source-date-epoch")
+	if epoch == 0 {
+		if value := os.Getenv("SOURCE_DATE_EPOCH"); value != "" {
+			var err error
+			epoch, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return time.Time{}, errors.Wrapf(err, "invalid $SOURCE_DATE_EPOCH %s", value)
+			}
+		}
+	}
+	if epoch == 0 {
+		return time.Time{}, nil
+	}
+	if epoch < 0 {
+		return time.Time{}, errors.Errorf("invalid source date epoch %d", epoch)
+	}
+
+	return time.Unix(epoch, 0).UTC(), nil
+}
+
+// verifyReproducedDatabase compares the generated database, encoded as the given bytes or split
+// with the given options, against the expected database file or split database directory, logging
+// each differing plugin version.
+func verifyReproducedDatabase(expected string, database []byte, plugins []*model.Plugin, options model.PluginsWriterOptions) error {
+	info, err := os.Stat(expected)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", expected)
+	}
+
+	var identical bool
+	var expectedPlugins []*model.Plugin
+	if info.IsDir() {
+		identical, err = splitDatabaseMatches(expected, plugins, options)
+		if err != nil {
+			return err
+		}
+		if !identical {
+			expectedPlugins, err = model.ReadSplitDatabase(expected)
+		}
+	} else {
+		var data []byte
+		data, err = ioutil.ReadFile(expected)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", expected)
+		}
+		identical = bytes.Equal(data, database)
+		if !identical {
+			expectedPlugins, err = model.PluginsFromReader(bytes.NewReader(data))
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse %s", expected)
+	}
+
+	if identical {
+		logger.Infof("reproduced %s", expected)
+		return nil
+	}
+
+	diff := model.DiffPlugins(expectedPlugins, plugins)
+	if diff.IsEmpty() {
+		return validationError(errors.Errorf("generated database differs from %s only in its formatting, e.g. --indent, --compact or --v2", expected))
+	}
+	for _, change := range diff.CatalogChanges() {
+		logger.Warnf("plugin %s %s was %s", change.PluginID, change.Version, change.Type)
+	}
+
+	return validationError(errors.Errorf("generated database differs from %s: %d versions added, %d updated and %d removed", expected, len(diff.Added), len(diff.Changed), len(diff.Removed)))
+}
+
+// splitDatabaseMatches reports whether splitting the given plugins with the given options would
+// reproduce the index and plugin files of the split database in the given directory.
+func splitDatabaseMatches(dir string, plugins []*model.Plugin, options model.PluginsWriterOptions) (bool, error) {
+	tempDir, err := ioutil.TempDir("", "reproduced")
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tempDir)
+
+	index, err := model.WriteSplitDatabase(tempDir, plugins, options)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to split database")
+	}
+
+	files := []string{model.DatabaseIndexName}
+	for _, entry := range index.Plugins {
+		files = append(files, filepath.FromSlash(entry.File))
+	}
+
+	for _, file := range files {
+		reproduced, err := ioutil.ReadFile(filepath.Join(tempDir, file))
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to read %s", file)
+		}
+		existing, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if os.IsNotExist(err) {
+			return false, nil
+		} else if err != nil {
+			return false, errors.Wrapf(err, "failed to read %s", file)
+		}
+		if !bytes.Equal(reproduced, existing) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/signing"
+)
+
+func init() {
+	resignCmd.Flags().String("database", "plugins.json", "The database whose signatures to replace, in any format.")
+	resignCmd.Flags().String("output-file", "", "The file to which to write the re-signed database, defaulting to stdout.")
+	resignCmd.Flags().String("old-key-hash", "", "The public key hash of the retired key whose signatures to replace.")
+	resignCmd.Flags().String("keyring", "", "An armored OpenPGP keyring holding the new key, if not signing with --kms-key-id. An encrypted key is decrypted with the passphrase in $SIGNING_KEYRING_PASSPHRASE.")
+	resignCmd.Flags().String("keyring-key-id", "", "The id of the key to use from --keyring, if it holds more than one.")
+
+	generatorCmd.AddCommand(resignCmd)
+}
+
+var resignCmd = &cobra.Command{
+	Use:   "resign",
+	Short: "Replace the signatures of a retired key in an existing database with signatures by a new key",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command, "")
+		if err != nil {
+			return err
+		}
+		outputPath, _ := command.Flags().GetString("output-file")
+		if format != "" && outputPath == "" {
+			return stdoutReportError("output-file")
+		}
+
+		oldKeyHash, _ := command.Flags().GetString("old-key-hash")
+		if oldKeyHash == "" {
+			return configError(errors.New("--old-key-hash is required"))
+		}
+
+		signer, err := newSigner(command)
+		if err != nil {
+			return configError(errors.Wrap(err, "failed to initialize signing"))
+		}
+		keyringSigner, err := newKeyringSigner(command)
+		if err != nil {
+			return configError(errors.Wrap(err, "failed to initialize signing"))
+		}
+		if signer == nil && keyringSigner == nil {
+			return configError(errors.New("--kms-key-id or --keyring is required"))
+		}
+		if signer != nil && keyringSigner != nil {
+			return configError(errors.New("only one of --kms-key-id and --keyring may be given"))
+		}
+		if signer == nil {
+			signer = keyringSigner
+		}
+		if signer.PublicKeyHash() == oldKeyHash {
+			return configError(errors.Errorf("the new key is the retired key %s", oldKeyHash))
+		}
+
+		sourceDate, err := getSourceDate(command)
+		if err != nil {
+			return configError(err)
+		}
+		if !sourceDate.IsZero() {
+			signer.SetSignatureTime(sourceDate)
+		}
+
+		database, _ := command.Flags().GetString("database")
+		data, err := ioutil.ReadFile(database)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", database)
+		}
+		plugins, err := model.PluginsFromReader(bytes.NewReader(data))
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse %s", database)
+		}
+
+		resigned, err := resignPlugins(signer, plugins, oldKeyHash)
+		if err != nil {
+			return err
+		}
+
+		var output bytes.Buffer
+		if err := model.PluginsToWriter(&output, plugins, getWriterOptions(command)); err != nil {
+			return err
+		}
+
+		if outputPath == "" {
+			_, err = output.WriteTo(os.Stdout)
+		} else {
+			err = ioutil.WriteFile(outputPath, output.Bytes(), 0644)
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to write re-signed database")
+		}
+
+		logger.Infof("re-signed %d bundles signed by %s with %s", resigned, oldKeyHash, signer.PublicKeyHash())
+
+		if format != "" {
+			return printReport(format, newWriteReport(outputPath, plugins))
+		}
+
+		return nil
+	},
+}
+
+// resignPlugins replaces each signature by the retired key with the given old key hash with a
+// signature by the given signer, re-downloading the bundles concerned and verifying them against
+// their recorded checksums. It returns the number of bundles re-signed.
+//
+// The legacy signature of a plugin is replaced too if made by the retired key, so that older
+// servers verifying only it remain consistent with the signatures recorded alongside.
+func resignPlugins(signer *signing.OpenPGPSigner, plugins []*model.Plugin, oldKeyHash string) (int, error) {
+	resigned := 0
+	for _, plugin := range plugins {
+		legacySigned := false
+		if plugin.Signature != "" {
+			keyID, err := api.SignatureKeyID(plugin.Signature)
+			legacySigned = err == nil && keyID == oldKeyHash
+		}
+
+		if plugin.DownloadURL != "" && (legacySigned || hasSignatureBy(plugin.Signatures, oldKeyHash)) {
+			signature, err := resignBundle(signer, plugin.DownloadURL, plugin.Checksums)
+			if err != nil {
+				return resigned, errors.Wrapf(err, "failed to re-sign %s %s", plugin.Manifest.Id, plugin.Manifest.Version)
+			}
+			plugin.Signatures = replaceSignatures(plugin.Signatures, oldKeyHash, signature)
+			if legacySigned {
+				plugin.Signature = signature.Signature
+			}
+			resigned++
+		}
+
+		for platform, bundle := range plugin.Platforms {
+			if !hasSignatureBy(bundle.Signatures, oldKeyHash) {
+				continue
+			}
+
+			signature, err := resignBundle(signer, bundle.DownloadURL, bundle.Checksums)
+			if err != nil {
+				return resigned, errors.Wrapf(err, "failed to re-sign %s %s for %s", plugin.Manifest.Id, plugin.Manifest.Version, platform)
+			}
+			bundle.Signatures = replaceSignatures(bundle.Signatures, oldKeyHash, signature)
+			resigned++
+		}
+	}
+
+	return resigned, nil
+}
+
+// hasSignatureBy reports whether any of the given signatures was made by the given key.
+func hasSignatureBy(signatures []*model.Signature, publicKeyHash string) bool {
+	for _, signature := range signatures {
+		if signature.PublicKeyHash == publicKeyHash {
+			return true
+		}
+	}
+
+	return false
+}
+
+// replaceSignatures returns the given signatures without those by the retired key, or any
+// previous signature by the new key, and with the given new signature added.
+func replaceSignatures(signatures []*model.Signature, oldKeyHash string, signature *model.Signature) []*model.Signature {
+	var replaced []*model.Signature
+	for _, existing := range signatures {
+		if existing.PublicKeyHash != oldKeyHash && existing.PublicKeyHash != signature.PublicKeyHash {
+			replaced = append(replaced, existing)
+		}
+	}
+
+	return append(replaced, signature)
+}
+
+// resignBundle downloads and signs the bundle at the given url, failing if it no longer matches
+// the given checksums, if any.
+func resignBundle(signer *signing.OpenPGPSigner, downloadURL string, checksums *model.Checksums) (*model.Signature, error) {
+	logger.Debugf("re-signing %s", downloadURL)
+
+	resp, err := http.Get(downloadURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s", downloadURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to download %s: status code %d", downloadURL, resp.StatusCode)
+	}
+
+	checksumsWriter := model.NewChecksumsWriter()
+	signature, err := signer.Sign(io.TeeReader(resp.Body, checksumsWriter))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to sign %s", downloadURL)
+	}
+	if checksums != nil {
+		if err := checksums.Verify(checksumsWriter.Checksums()); err != nil {
+			return nil, errors.Wrapf(err, "refusing to re-sign %s", downloadURL)
+		}
+	}
+
+	return signature, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/screening"
+)
+
+func init() {
+	generatorCmd.Flags().String("screen-denylist", "", "An optional file of case-insensitive regular expressions, one per line, rejecting community plugins whose name or description matches any.")
+	generatorCmd.Flags().String("screen-command", "", "An optional command, split on whitespace, screening the name and description of each community plugin given as JSON on its stdin. Exiting with status 1 rejects the plugin, printing the reasons to stdout.")
+	generatorCmd.Flags().Duration("screen-command-timeout", 30*time.Second, "The time after which a run of --screen-command is abandoned, failing generation.")
+}
+
+// newScreeners creates the screeners given by the --screen-* flags.
+func newScreeners(command *cobra.Command) ([]screening.Screener, error) {
+	var screeners []screening.Screener
+
+	denylistPath, _ := command.Flags().GetString("screen-denylist")
+	if denylistPath != "" {
+		file, err := os.Open(denylistPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open denylist %s", denylistPath)
+		}
+		defer file.Close()
+
+		denylist, err := screening.ParseDenylist(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse denylist %s", denylistPath)
+		}
+		screeners = append(screeners, denylist)
+	}
+
+	screenCommand, _ := command.Flags().GetString("screen-command")
+	if fields := strings.Fields(screenCommand); len(fields) > 0 {
+		timeout, _ := command.Flags().GetDuration("screen-command-timeout")
+		screeners = append(screeners, &screening.Command{
+			Path:    fields[0],
+			Args:    fields[1:],
+			Timeout: timeout,
+		})
+	}
+
+	return screeners, nil
+}
+
+// pluginScreen screens community plugins, remembering the outcome for each plugin so that those
+// shared by the published and staging databases are screened once.
+type pluginScreen struct {
+	screeners []screening.Screener
+	rejected  map[*model.Plugin]bool
+}
+
+// filter returns the given plugins, less the community plugins rejected by any screener. Plugins
+// by Mattermost and partners are trusted as is.
+func (s *pluginScreen) filter(plugins []*model.Plugin) ([]*model.Plugin, error) {
+	accepted := []*model.Plugin{}
+	for _, plugin := range plugins {
+		rejected, ok := s.rejected[plugin]
+		if !ok && plugin.AuthorType == model.AuthorTypeCommunity {
+			var err error
+			rejected, err = s.screen(plugin)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to screen %s %s", plugin.Manifest.Id, plugin.Manifest.Version)
+			}
+			s.rejected[plugin] = rejected
+		}
+
+		if !rejected {
+			accepted = append(accepted, plugin)
+		}
+	}
+
+	return accepted, nil
+}
+
+// screen applies every screener to the given plugin, logging the reasons for rejecting it.
+func (s *pluginScreen) screen(plugin *model.Plugin) (bool, error) {
+	rejected := false
+	for _, screener := range s.screeners {
+		reasons, err := screener.Screen(plugin)
+		if err != nil {
+			return false, err
+		}
+
+		for _, reason := range reasons {
+			logger.WithField("plugin", plugin.Manifest.Id).Warnf("rejected %s: %s", plugin.Manifest.Version, reason)
+			rejected = true
+		}
+	}
+
+	return rejected, nil
+}
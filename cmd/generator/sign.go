@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/signing"
+)
+
+// signingKeyName identifies the OpenPGP key wrapping the KMS signing key.
+const signingKeyName = "Mattermost Plugin Marketplace"
+
+func init() {
+	generatorCmd.PersistentFlags().String("kms-key-id", "", "The optional AWS KMS key with which to sign plugin bundles and the database.")
+	generatorCmd.Flags().String("keyring", "", "An optional armored OpenPGP keyring holding a maintainer key with which to sign bundles publishing no signature of their own. An encrypted key is decrypted with the passphrase in $SIGNING_KEYRING_PASSPHRASE.")
+	generatorCmd.Flags().String("keyring-key-id", "", "The id of the key to use from --keyring, if it holds more than one.")
+	generatorCmd.Flags().String("database-signature", "", "The optional file in which to write a detached signature of the generated database, requiring --kms-key-id or --keyring.")
+
+	generatorCmd.AddCommand(signingKeyCmd)
+}
+
+var signingKeyCmd = &cobra.Command{
+	Use:   "signing-key",
+	Short: "Print the armored OpenPGP public key verifying signatures made with --kms-key-id",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command, "")
+		if err != nil {
+			return err
+		}
+
+		signer, err := newSigner(command)
+		if err != nil {
+			return err
+		}
+		if signer == nil {
+			return errors.New("--kms-key-id is required")
+		}
+
+		if format == "" {
+			return signer.WritePublicKey(os.Stdout)
+		}
+
+		var publicKey bytes.Buffer
+		if err := signer.WritePublicKey(&publicKey); err != nil {
+			return err
+		}
+
+		return printReport(format, &signingKeyReport{
+			PublicKeyHash: signer.PublicKeyHash(),
+			PublicKey:     publicKey.String(),
+		})
+	},
+}
+
+// signingKeyReport describes the public key verifying signatures made with --kms-key-id.
+type signingKeyReport struct {
+	PublicKeyHash string `json:"public_key_hash"`
+	// PublicKey is the armored OpenPGP public key.
+	PublicKey string `json:"public_key"`
+}
+
+func (r *signingKeyReport) printTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PUBLIC KEY HASH")
+	fmt.Fprintln(tw, r.PublicKeyHash)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%s", r.PublicKey)
+	return err
+}
+
+// newSigner creates a signer over the KMS key given by --kms-key-id, or returns nil if none is
+// given. AWS credentials and region are taken from the environment.
+func newSigner(command *cobra.Command) (*signing.OpenPGPSigner, error) {
+	keyID, _ := command.Flags().GetString("kms-key-id")
+	if keyID == "" {
+		return nil, nil
+	}
+
+	awsSession, err := session.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create aws session")
+	}
+
+	kmsSigner, err := signing.NewKMSSigner(kms.New(awsSession), keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return signing.NewOpenPGPSigner(kmsSigner, kmsSigner.CreationDate(), signingKeyName)
+}
+
+// newKeyringSigner creates a signer over the maintainer key read from --keyring, or returns nil
+// if none is given.
+func newKeyringSigner(command *cobra.Command) (*signing.OpenPGPSigner, error) {
+	keyringPath, _ := command.Flags().GetString("keyring")
+	if keyringPath == "" {
+		return nil, nil
+	}
+
+	keyring, err := os.Open(keyringPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", keyringPath)
+	}
+	defer keyring.Close()
+
+	keyID, _ := command.Flags().GetString("keyring-key-id")
+	signer, err := signing.NewKeyringSigner(keyring, keyID, []byte(os.Getenv("SIGNING_KEYRING_PASSPHRASE")))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load signing key from %s", keyringPath)
+	}
+
+	return signer, nil
+}
+
+// signPlugins adds a signature by the given signer to every bundle of the given plugins not
+// already signed by it. If onlyUnsigned is set, bundles already bearing any signature are left
+// as is.
+func signPlugins(signer *signing.OpenPGPSigner, plugins []*model.Plugin, onlyUnsigned bool) error {
+	for _, plugin := range plugins {
+		if plugin.DownloadURL != "" && (!onlyUnsigned || (len(plugin.Signatures) == 0 && plugin.Signature == "")) {
+			signatures, err := signBundle(signer, plugin.DownloadURL, plugin.Signatures)
+			if err != nil {
+				return errors.Wrapf(err, "failed to sign %s %s", plugin.Manifest.Id, plugin.Manifest.Version)
+			}
+			plugin.Signatures = signatures
+			// Older servers verify only the legacy signature, so populate it if nothing else did.
+			if plugin.Signature == "" {
+				plugin.Signature = signatures[0].Signature
+			}
+		}
+
+		for platform, bundle := range plugin.Platforms {
+			if onlyUnsigned && len(bundle.Signatures) > 0 {
+				continue
+			}
+
+			signatures, err := signBundle(signer, bundle.DownloadURL, bundle.Signatures)
+			if err != nil {
+				return errors.Wrapf(err, "failed to sign %s %s for %s", plugin.Manifest.Id, plugin.Manifest.Version, platform)
+			}
+			bundle.Signatures = signatures
+		}
+	}
+
+	return nil
+}
+
+// signBundle downloads and signs the bundle at the given url, returning its signatures with the
+// new signature added, unless the signer already signed it.
+func signBundle(signer *signing.OpenPGPSigner, downloadURL string, signatures []*model.Signature) ([]*model.Signature, error) {
+	for _, signature := range signatures {
+		if signature.PublicKeyHash == signer.PublicKeyHash() {
+			return signatures, nil
+		}
+	}
+
+	logger.Debugf("signing %s", downloadURL)
+
+	resp, err := http.Get(downloadURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s", downloadURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to download %s: status code %d", downloadURL, resp.StatusCode)
+	}
+
+	signature, err := signer.Sign(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to sign %s", downloadURL)
+	}
+
+	return append(signatures, signature), nil
+}
+
+// writeDatabaseSignature writes a detached binary signature of the given database to the given
+// path.
+func writeDatabaseSignature(signer *signing.OpenPGPSigner, database io.Reader, path string) error {
+	signature, err := signer.Sign(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to sign database")
+	}
+
+	signatureData, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode database signature")
+	}
+
+	if err := ioutil.WriteFile(path, signatureData, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+
+	return nil
+}
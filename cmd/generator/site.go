@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+func init() {
+	siteCmd.Flags().String("database", "plugins.json", "The plugins.json database to render.")
+	siteCmd.Flags().String("directory", "site", "The directory in which to write the site.")
+	siteCmd.Flags().String("title", "Mattermost Plugin Marketplace", "The title of the site.")
+
+	generatorCmd.AddCommand(siteCmd)
+}
+
+var siteCmd = &cobra.Command{
+	Use:   "site",
+	Short: "Render a plugins.json database as a static HTML site",
+	Long: `Site renders the catalog into a directory of static HTML suitable for publishing to GitHub
+Pages or S3: an index of the latest version of every plugin, and a page per plugin with its version
+history and install instructions. Links between pages are relative, so the site may be served
+from any path.`,
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command, "")
+		if err != nil {
+			return err
+		}
+
+		database, _ := command.Flags().GetString("database")
+		data, err := ioutil.ReadFile(database)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", database)
+		}
+
+		pluginStore, err := store.New(bytes.NewReader(data), logger)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load %s", database)
+		}
+
+		latestPlugins, err := pluginStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		if err != nil {
+			return errors.Wrap(err, "failed to query plugins")
+		}
+
+		title, _ := command.Flags().GetString("title")
+		directory, _ := command.Flags().GetString("directory")
+		generatedAt, err := getSourceDate(command)
+		if err != nil {
+			return err
+		}
+		if generatedAt.IsZero() {
+			generatedAt = time.Now().UTC()
+		}
+
+		if err := writeSiteFile(filepath.Join(directory, "style.css"), func(file *os.File) error {
+			_, err := file.WriteString(siteStyle)
+			return err
+		}); err != nil {
+			return err
+		}
+
+		if err := writeSiteFile(filepath.Join(directory, "index.html"), func(file *os.File) error {
+			return siteTemplates.ExecuteTemplate(file, "index", &sitePage{
+				Title:       title,
+				Root:        ".",
+				GeneratedAt: generatedAt,
+				Plugins:     latestPlugins,
+			})
+		}); err != nil {
+			return err
+		}
+
+		for _, plugin := range latestPlugins {
+			if !isPathSegment(plugin.Manifest.Id) {
+				return errors.Errorf("plugin id %s is not usable as a directory name", plugin.Manifest.Id)
+			}
+
+			versions, err := pluginStore.GetPluginVersions(plugin.Manifest.Id)
+			if err != nil {
+				return errors.Wrapf(err, "failed to query versions of %s", plugin.Manifest.Id)
+			}
+
+			pluginPath := filepath.Join(directory, "plugins", plugin.Manifest.Id, "index.html")
+			if err := writeSiteFile(pluginPath, func(file *os.File) error {
+				return siteTemplates.ExecuteTemplate(file, "plugin", &sitePage{
+					Title:       title,
+					Root:        "../..",
+					GeneratedAt: generatedAt,
+					Plugin:      plugin,
+					Versions:    versions,
+				})
+			}); err != nil {
+				return err
+			}
+		}
+
+		logger.Infof("rendered %d plugins to %s", len(latestPlugins), directory)
+
+		if format != "" {
+			return printReport(format, newWriteReport(directory, latestPlugins))
+		}
+
+		return nil
+	},
+}
+
+// sitePage holds the data rendered into a page of the static site.
+type sitePage struct {
+	Title string
+	// Root is the relative path from the page to the root of the site.
+	Root        string
+	GeneratedAt time.Time
+	// Plugins are the latest version of each plugin, listed on the index.
+	Plugins []*model.Plugin
+	// Plugin is the latest version of the plugin described by a plugin page.
+	Plugin *model.Plugin
+	// Versions are every version of the plugin described by a plugin page, newest first.
+	Versions []*model.Plugin
+}
+
+// writeSiteFile creates the given file, and any missing parent directories, writing it with the
+// given function.
+func writeSiteFile(filePath string, write func(file *os.File) error) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", filePath)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", filePath)
+	}
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return errors.Wrapf(err, "failed to write %s", filePath)
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", filePath)
+	}
+
+	return nil
+}
+
+var siteTemplates = template.Must(template.New("site").Funcs(template.FuncMap{
+	// iconURL trusts the plugin's icon, which the store validated as an image data URI or an
+	// http(s) URL.
+	"iconURL": func(plugin *model.Plugin) template.URL {
+		if plugin.IconURL != "" {
+			return template.URL(plugin.IconURL)
+		}
+		return template.URL(plugin.IconData)
+	},
+	"bundleName": func(downloadURL string) string {
+		if u, err := url.Parse(downloadURL); err == nil {
+			return path.Base(u.Path)
+		}
+		return path.Base(downloadURL)
+	},
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02")
+	},
+}).Parse(siteLayout))
+
+const siteLayout = `
+{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .Plugin}}{{.Plugin.Manifest.Name}} - {{end}}{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}/style.css">
+</head>
+<body>
+<header><a href="{{.Root}}/index.html">{{.Title}}</a></header>
+<main>
+{{end}}
+
+{{define "footer"}}</main>
+<footer>Generated {{date .GeneratedAt}}</footer>
+</body>
+</html>
+{{end}}
+
+{{define "icon"}}{{if or .IconData .IconURL}}<img class="icon" src="{{iconURL .}}" alt="">{{end}}{{end}}
+
+{{define "index"}}{{template "header" .}}
+<h1>Plugins</h1>
+<ul class="plugins">
+{{range .Plugins}}<li>
+{{template "icon" .}}
+<a href="plugins/{{.Manifest.Id}}/index.html">{{.Manifest.Name}}</a> <span class="version">{{.Manifest.Version}}</span>
+<p>{{.Manifest.Description}}</p>
+</li>
+{{else}}<li>No plugins are published yet.</li>
+{{end}}</ul>
+{{template "footer" .}}{{end}}
+
+{{define "plugin"}}{{template "header" .}}
+{{with .Plugin}}
+<h1>{{template "icon" .}}{{.Manifest.Name}}</h1>
+<p>{{.Manifest.Description}}</p>
+<dl>
+<dt>Latest version</dt><dd>{{.Manifest.Version}}</dd>
+{{if .Manifest.MinServerVersion}}<dt>Minimum server version</dt><dd>{{.Manifest.MinServerVersion}}</dd>{{end}}
+{{if .AuthorType}}<dt>Author</dt><dd>{{.AuthorType}}</dd>{{end}}
+{{if .HomepageURL}}<dt>Homepage</dt><dd><a href="{{.HomepageURL}}">{{.HomepageURL}}</a></dd>{{end}}
+</dl>
+
+<h2>Installing</h2>
+<p>From the Mattermost System Console, open <b>Plugins &gt; Plugin Marketplace</b> and install <b>{{.Manifest.Name}}</b>.</p>
+{{if .DownloadURL}}<p>Alternatively, download the <a href="{{.DownloadURL}}">plugin bundle</a> and upload it under <b>Plugins &gt; Plugin Management</b>, or run:</p>
+<pre>mattermost plugin add {{bundleName .DownloadURL}}</pre>{{end}}
+{{end}}
+
+<h2>Versions</h2>
+<table class="versions">
+<tr><th>Version</th><th>Released</th><th>Minimum server version</th><th>Stage</th><th></th></tr>
+{{range .Versions}}<tr>
+<td>{{.Manifest.Version}}</td>
+<td>{{date .ReleasedAt}}</td>
+<td>{{.Manifest.MinServerVersion}}</td>
+<td>{{.ReleaseStage}}</td>
+<td>{{if .DownloadURL}}<a href="{{.DownloadURL}}">Download</a>{{end}}{{if .ReleaseNotesURL}} <a href="{{.ReleaseNotesURL}}">Release notes</a>{{end}}</td>
+</tr>
+{{end}}</table>
+{{template "footer" .}}{{end}}
+`
+
+const siteStyle = `body { font-family: sans-serif; margin: 0; color: #3d3c40; }
+header, footer { background: #1e325c; color: #fff; padding: 1em 2em; }
+header a { color: #fff; font-weight: bold; text-decoration: none; }
+footer { font-size: small; }
+main { max-width: 60em; margin: 0 auto; padding: 1em 2em; }
+a { color: #166de0; }
+.plugins { list-style: none; padding: 0; }
+.plugins li { border-bottom: 1px solid #ddd; padding: 1em 0; }
+.icon { width: 2em; height: 2em; vertical-align: middle; margin-right: 0.5em; }
+.version { color: #888; }
+.versions { border-collapse: collapse; width: 100%; }
+.versions th, .versions td { border-bottom: 1px solid #ddd; padding: 0.5em; text-align: left; }
+pre { background: #f4f4f4; padding: 1em; overflow-x: auto; }
+`
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	generatorCmd.Flags().String("state", "", "An optional file recording the plugins of each repository as it completes, from which an interrupted run resumes. The file is removed once the run succeeds.")
+}
+
+// generationState records the progress of a generation run.
+type generationState struct {
+	// Repositories maps each completed repository, keyed as by repository.key, to its plugins.
+	Repositories map[string][]*model.Plugin `json:"repositories"`
+	// Staging maps each completed repository to its plugins including draft releases, when
+	// indexing drafts.
+	Staging map[string][]*model.Plugin `json:"staging,omitempty"`
+}
+
+// stateKey identifies the repository within the state.
+func stateKey(r repository) string {
+	return r.key()
+}
+
+// loadState reads the state at the given path, or starts afresh if there is none.
+func loadState(path string) (*generationState, error) {
+	state := &generationState{Repositories: map[string][]*model.Plugin{}, Staging: map[string][]*model.Plugin{}}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read state %s", path)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse state %s", path)
+	}
+	if state.Repositories == nil {
+		state.Repositories = map[string][]*model.Plugin{}
+	}
+	if state.Staging == nil {
+		state.Staging = map[string][]*model.Plugin{}
+	}
+
+	return state, nil
+}
+
+// save writes the state to the given path, replacing the previous state only once fully written
+// so that an interruption never leaves it truncated.
+func (s *generationState) save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode state")
+	}
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary state")
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return errors.Wrap(err, "failed to write temporary state")
+	}
+	if err := tempFile.Close(); err != nil {
+		return errors.Wrap(err, "failed to write temporary state")
+	}
+
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		return errors.Wrapf(err, "failed to replace state %s", path)
+	}
+
+	return nil
+}
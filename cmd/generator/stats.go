@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// largestIconsShown is the number of plugins listed by the size of their icon data.
+const largestIconsShown = 5
+
+func init() {
+	statsCmd.Flags().String("database", "plugins.json", "The plugins.json database to summarize.")
+	statsCmd.Flags().String("format", formatTable, "The output format, one of table, json or yaml.")
+	_ = statsCmd.Flags().MarkDeprecated("format", "use --output instead")
+	statsCmd.Flags().Duration("stale-after", 0, "The age of a plugin's latest release beyond which it is reported as unmaintained, e.g. 8760h, or 0 to report only plugins whose repository was archived.")
+
+	generatorCmd.AddCommand(statsCmd)
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Report statistics over a plugins.json database, e.g. to guide pruning",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		// The deprecated --format is honored unless --output is given.
+		defaultFormat, _ := command.Flags().GetString("format")
+		format, err := getFormat(command, defaultFormat)
+		if err != nil {
+			return err
+		}
+
+		database, _ := command.Flags().GetString("database")
+		file, err := os.Open(database)
+		if err != nil {
+			return errors.Wrapf(err, "failed to open %s", database)
+		}
+		defer file.Close()
+
+		plugins, err := model.PluginsFromReader(file)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", database)
+		}
+
+		staleAfter, _ := command.Flags().GetDuration("stale-after")
+		stats := getDatabaseStats(plugins, staleAfter, time.Now())
+
+		return printReport(format, stats)
+	},
+}
+
+// pluginCount counts something about a single plugin, across its versions.
+type pluginCount struct {
+	PluginID string `json:"plugin_id"`
+	Count    int    `json:"count"`
+}
+
+// versionCount counts the plugin versions requiring a minimum server version.
+type versionCount struct {
+	// MinServerVersion is empty for plugin versions declaring none.
+	MinServerVersion string `json:"min_server_version"`
+	Versions         int    `json:"versions"`
+}
+
+// unmaintainedPlugin describes a plugin considered unmaintained.
+type unmaintainedPlugin struct {
+	PluginID string                   `json:"plugin_id"`
+	Reason   model.UnmaintainedReason `json:"reason"`
+	// LastReleasedAt is omitted if no version records its release.
+	LastReleasedAt *time.Time `json:"last_released_at,omitempty"`
+}
+
+// databaseStats summarizes a plugins.json database.
+type databaseStats struct {
+	Plugins  int `json:"plugins"`
+	Versions int `json:"versions"`
+	// VersionsPerPlugin lists each plugin's number of versions, most first.
+	VersionsPerPlugin []pluginCount `json:"versions_per_plugin"`
+	// IconDataBytes is the size of the icons embedded in the database.
+	IconDataBytes int `json:"icon_data_bytes"`
+	// LargestIcons lists the plugins whose icons, across versions, take the most bytes.
+	LargestIcons          []pluginCount  `json:"largest_icons"`
+	SignedVersions        int            `json:"signed_versions"`
+	PlatformBundles       int            `json:"platform_bundles"`
+	SignedPlatformBundles int            `json:"signed_platform_bundles"`
+	MinServerVersions     []versionCount `json:"min_server_versions"`
+	// Unmaintained lists the plugins whose repository was archived or whose latest release is
+	// older than the given age, by plugin id.
+	Unmaintained []unmaintainedPlugin `json:"unmaintained"`
+}
+
+// getDatabaseStats summarizes the given plugins, reporting those without a release within
+// staleAfter of now as unmaintained if staleAfter is positive.
+func getDatabaseStats(plugins []*model.Plugin, staleAfter time.Duration, now time.Time) *databaseStats {
+	stats := &databaseStats{
+		Versions:          len(plugins),
+		VersionsPerPlugin: []pluginCount{},
+		LargestIcons:      []pluginCount{},
+		MinServerVersions: []versionCount{},
+		Unmaintained:      []unmaintainedPlugin{},
+	}
+
+	versions := map[string]int{}
+	pluginVersions := map[string][]*model.Plugin{}
+	iconBytes := map[string]int{}
+	minServerVersions := map[string]int{}
+	for _, plugin := range plugins {
+		versions[plugin.Manifest.Id]++
+		pluginVersions[plugin.Manifest.Id] = append(pluginVersions[plugin.Manifest.Id], plugin)
+		iconBytes[plugin.Manifest.Id] += len(plugin.IconData)
+		stats.IconDataBytes += len(plugin.IconData)
+		minServerVersions[plugin.Manifest.MinServerVersion]++
+
+		if len(plugin.AllSignatures()) > 0 {
+			stats.SignedVersions++
+		}
+		for _, bundle := range plugin.Platforms {
+			stats.PlatformBundles++
+			if len(bundle.Signatures) > 0 {
+				stats.SignedPlatformBundles++
+			}
+		}
+	}
+	stats.Plugins = len(versions)
+
+	stats.VersionsPerPlugin = sortedCounts(versions)
+	for _, count := range sortedCounts(iconBytes) {
+		if count.Count == 0 || len(stats.LargestIcons) == largestIconsShown {
+			break
+		}
+		stats.LargestIcons = append(stats.LargestIcons, count)
+	}
+
+	for minServerVersion, count := range minServerVersions {
+		stats.MinServerVersions = append(stats.MinServerVersions, versionCount{minServerVersion, count})
+	}
+	sort.Slice(stats.MinServerVersions, func(i, j int) bool {
+		return lessServerVersion(stats.MinServerVersions[i].MinServerVersion, stats.MinServerVersions[j].MinServerVersion)
+	})
+
+	for pluginID, versions := range pluginVersions {
+		maintenance := model.NewMaintenance(versions)
+		reason := maintenance.Unmaintained(staleAfter, now)
+		if reason == "" {
+			continue
+		}
+
+		unmaintained := unmaintainedPlugin{PluginID: pluginID, Reason: reason}
+		if !maintenance.LastReleasedAt.IsZero() {
+			lastReleasedAt := maintenance.LastReleasedAt.UTC()
+			unmaintained.LastReleasedAt = &lastReleasedAt
+		}
+		stats.Unmaintained = append(stats.Unmaintained, unmaintained)
+	}
+	sort.Slice(stats.Unmaintained, func(i, j int) bool {
+		return stats.Unmaintained[i].PluginID < stats.Unmaintained[j].PluginID
+	})
+
+	return stats
+}
+
+// sortedCounts returns the given counts, largest first and then by plugin id.
+func sortedCounts(counts map[string]int) []pluginCount {
+	sorted := make([]pluginCount, 0, len(counts))
+	for pluginID, count := range counts {
+		sorted = append(sorted, pluginCount{pluginID, count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].PluginID < sorted[j].PluginID
+	})
+
+	return sorted
+}
+
+// lessServerVersion orders server versions by semantic version, placing missing and unparseable
+// versions last.
+func lessServerVersion(a, b string) bool {
+	aVersion, aErr := semver.Parse(a)
+	bVersion, bErr := semver.Parse(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return aVersion.LT(bVersion)
+	case aErr == nil:
+		return true
+	case bErr == nil:
+		return false
+	default:
+		return a < b
+	}
+}
+
+// percentage formats the given part of the total as a percentage.
+func percentage(part, total int) string {
+	if total == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.0f%%", float64(part)*100/float64(total))
+}
+
+// printTable writes the stats as tables.
+func (stats *databaseStats) printTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Plugins:\t%d\n", stats.Plugins)
+	fmt.Fprintf(tw, "Versions:\t%d\n", stats.Versions)
+	fmt.Fprintf(tw, "Signed versions:\t%d (%s)\n", stats.SignedVersions, percentage(stats.SignedVersions, stats.Versions))
+	if stats.PlatformBundles > 0 {
+		fmt.Fprintf(tw, "Signed platform bundles:\t%d of %d (%s)\n", stats.SignedPlatformBundles, stats.PlatformBundles, percentage(stats.SignedPlatformBundles, stats.PlatformBundles))
+	}
+	fmt.Fprintf(tw, "Icon data:\t%d bytes\n", stats.IconDataBytes)
+
+	fmt.Fprintln(tw, "\nPLUGIN\tVERSIONS")
+	for _, count := range stats.VersionsPerPlugin {
+		fmt.Fprintf(tw, "%s\t%d\n", count.PluginID, count.Count)
+	}
+
+	if len(stats.LargestIcons) > 0 {
+		fmt.Fprintln(tw, "\nPLUGIN\tICON DATA BYTES")
+		for _, count := range stats.LargestIcons {
+			fmt.Fprintf(tw, "%s\t%d\n", count.PluginID, count.Count)
+		}
+	}
+
+	fmt.Fprintln(tw, "\nMIN SERVER VERSION\tVERSIONS")
+	for _, count := range stats.MinServerVersions {
+		minServerVersion := count.MinServerVersion
+		if minServerVersion == "" {
+			minServerVersion = "none"
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", minServerVersion, count.Versions)
+	}
+
+	if len(stats.Unmaintained) > 0 {
+		fmt.Fprintln(tw, "\nUNMAINTAINED PLUGIN\tREASON\tLAST RELEASED")
+		for _, unmaintained := range stats.Unmaintained {
+			lastReleasedAt := "unknown"
+			if unmaintained.LastReleasedAt != nil {
+				lastReleasedAt = unmaintained.LastReleasedAt.Format("2006-01-02")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", unmaintained.PluginID, unmaintained.Reason, lastReleasedAt)
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// getSubmittedRepositories reads the repositories of the approved submissions in the given
+// submissions database, as persisted by the marketplace server.
+func getSubmittedRepositories(path string) ([]repository, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open submissions database %s", path)
+	}
+	defer file.Close()
+
+	submissions, err := model.SubmissionsFromReader(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read submissions database %s", path)
+	}
+
+	var repositories []repository
+	for _, submission := range submissions {
+		if submission.Status != model.SubmissionStatusApproved {
+			continue
+		}
+
+		owner, name, err := model.ParseGitHubRepository(submission.RepositoryURL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid submission %s", submission.ID)
+		}
+
+		logger.Debugf("including approved submission %s of %s/%s", submission.ID, owner, name)
+		repositories = append(repositories, repository{
+			Owner:      owner,
+			Name:       name,
+			AuthorType: model.AuthorTypeCommunity,
+		})
+	}
+
+	return repositories, nil
+}
+
+// mergeRepositories appends the given additional repositories to the curated ones, skipping any
+// already listed.
+func mergeRepositories(repositories, additional []repository) []repository {
+	seen := map[string]bool{}
+	for _, r := range repositories {
+		seen[r.key()] = true
+	}
+
+	for _, r := range additional {
+		if seen[r.key()] {
+			continue
+		}
+		seen[r.key()] = true
+		repositories = append(repositories, r)
+	}
+
+	return repositories
+}
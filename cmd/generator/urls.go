@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+const (
+	// verifyURLsWarn reports dead links without failing generation.
+	verifyURLsWarn = "warn"
+	// verifyURLsFail fails generation after reporting every dead link.
+	verifyURLsFail = "fail"
+)
+
+func init() {
+	generatorCmd.Flags().String("verify-urls", "", "Whether to check the homepage and release notes urls of new plugins, reporting dead links as warnings (warn) or failing generation (fail).")
+	generatorCmd.Flags().Lookup("verify-urls").NoOptDefVal = verifyURLsWarn
+}
+
+// getVerifyURLsMode returns the requested --verify-urls mode, or the empty string if disabled.
+func getVerifyURLsMode(command *cobra.Command) (string, error) {
+	mode, _ := command.Flags().GetString("verify-urls")
+	switch mode {
+	case "", verifyURLsWarn, verifyURLsFail:
+		return mode, nil
+	default:
+		return "", errors.Errorf("--verify-urls must be %s or %s, not %s", verifyURLsWarn, verifyURLsFail, mode)
+	}
+}
+
+// urlVerifier checks that urls respond successfully, remembering the result for urls shared by
+// several plugins, such as a repository's homepage.
+type urlVerifier struct {
+	client  *http.Client
+	results map[string]error
+}
+
+func newURLVerifier() *urlVerifier {
+	return &urlVerifier{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		results: map[string]error{},
+	}
+}
+
+// verify requests the given url, falling back to GET for servers that do not support HEAD.
+func (v *urlVerifier) verify(u string) error {
+	if err, ok := v.results[u]; ok {
+		return err
+	}
+
+	logger.Debugf("verifying url %s", u)
+
+	err := v.request(http.MethodHead, u)
+	if statusErr, ok := err.(*urlStatusError); ok && (statusErr.statusCode == http.StatusMethodNotAllowed || statusErr.statusCode == http.StatusNotImplemented) {
+		err = v.request(http.MethodGet, u)
+	}
+	v.results[u] = err
+
+	return err
+}
+
+type urlStatusError struct {
+	statusCode int
+}
+
+func (e *urlStatusError) Error() string {
+	return fmt.Sprintf("responded with status code %d", e.statusCode)
+}
+
+func (v *urlVerifier) request(method, u string) error {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return &urlStatusError{statusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// verifyPluginURLs checks the homepage and release notes urls of each plugin not found in the
+// existing database, warning of every dead link. In the fail mode, any dead link then fails
+// generation.
+func verifyPluginURLs(verifier *urlVerifier, plugins, existingPlugins []*model.Plugin, mode string) error {
+	existingDownloadURLs := map[string]bool{}
+	for _, plugin := range existingPlugins {
+		existingDownloadURLs[plugin.DownloadURL] = true
+	}
+
+	var deadLinks []string
+	for _, plugin := range plugins {
+		if existingDownloadURLs[plugin.DownloadURL] {
+			continue
+		}
+
+		links := []struct {
+			name string
+			url  string
+		}{
+			{"homepage", plugin.HomepageURL},
+			{"release notes", plugin.ReleaseNotesURL},
+		}
+		for _, link := range links {
+			if link.url == "" {
+				continue
+			}
+
+			if err := verifier.verify(link.url); err != nil {
+				logger.WithError(err).Warnf("dead %s url %s for plugin %s %s", link.name, link.url, plugin.Manifest.Id, plugin.Manifest.Version)
+				deadLinks = append(deadLinks, link.url)
+			}
+		}
+	}
+
+	if mode == verifyURLsFail && len(deadLinks) > 0 {
+		return errors.Errorf("found %d dead urls: %s", len(deadLinks), strings.Join(deadLinks, ", "))
+	}
+
+	return nil
+}
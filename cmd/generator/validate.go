@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+func init() {
+	validateCmd.Flags().String("database", "plugins.json", "The plugins.json database to validate.")
+
+	generatorCmd.AddCommand(validateCmd)
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate a plugins.json database, e.g. after editing it by hand",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command, "")
+		if err != nil {
+			return err
+		}
+
+		database, _ := command.Flags().GetString("database")
+		report := &validationReport{Database: database, Valid: true, Problems: []string{}}
+		err = validateDatabase(command, database, report)
+		if format == "" {
+			for _, problem := range report.Problems {
+				logger.Error(problem)
+			}
+			if err == nil {
+				logger.Infof("%s is valid", database)
+			}
+			return err
+		}
+
+		if err != nil {
+			if exitCode(err) != exitCodeValidation {
+				return err
+			}
+			report.Valid = false
+			if len(report.Problems) == 0 {
+				report.Problems = append(report.Problems, err.Error())
+			}
+		}
+		if printErr := printReport(format, report); printErr != nil {
+			return printErr
+		}
+
+		return err
+	},
+}
+
+// validationReport describes the outcome of validating a database.
+type validationReport struct {
+	Database string `json:"database"`
+	Valid    bool   `json:"valid"`
+	// Problems lists each schema violation, or else the reason the database is invalid.
+	Problems []string `json:"problems"`
+	// Warnings describes the icons the marketplace would drop from plugins rather than reject.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (r *validationReport) printTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATABASE\tVALID")
+	fmt.Fprintf(tw, "%s\t%t\n", r.Database, r.Valid)
+	if len(r.Problems) > 0 {
+		fmt.Fprintln(tw, "\nPROBLEM")
+		for _, problem := range r.Problems {
+			fmt.Fprintln(tw, problem)
+		}
+	}
+	if len(r.Warnings) > 0 {
+		fmt.Fprintln(tw, "\nWARNING")
+		for _, warning := range r.Warnings {
+			fmt.Fprintln(tw, warning)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// validateDatabase validates the given database against the schema, the store and the quality
+// checks, recording each schema violation in the given report.
+func validateDatabase(command *cobra.Command, database string, report *validationReport) error {
+	data, err := ioutil.ReadFile(database)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", database)
+	}
+
+	if err := model.ValidateAgainstSchema(bytes.NewReader(data)); err != nil {
+		if schemaErr, ok := err.(*model.SchemaError); ok {
+			report.Problems = append(report.Problems, schemaErr.Errors...)
+			return validationError(errors.Errorf("%s does not match schema", database))
+		}
+
+		return errors.Wrapf(err, "failed to validate %s against schema", database)
+	}
+
+	validated, err := store.New(bytes.NewReader(data), logger)
+	if err != nil {
+		return validationError(errors.Wrapf(err, "failed to validate %s", database))
+	}
+	report.Warnings = validated.Warnings()
+
+	qualityConfig, err := newQualityConfig(command)
+	if err != nil {
+		return configError(err)
+	}
+	if qualityConfig != nil {
+		plugins, err := model.PluginsFromReader(bytes.NewReader(data))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", database)
+		}
+		if err := checkQuality(qualityConfig, plugins); err != nil {
+			return validationError(errors.Wrapf(err, "%s fails quality checks", database))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// maxWebhookPayloadSize is the largest payload GitHub delivers, beyond which a delivery is
+// rejected.
+const maxWebhookPayloadSize = 25 << 20
+
+func init() {
+	webhookCmd.Flags().String("listen", ":8087", "The interface and port on which to receive GitHub webhooks.")
+	webhookCmd.Flags().String("webhook-secret", "", "The secret configured for the webhook, verifying the signature of each delivery. Defaults to $GITHUB_WEBHOOK_SECRET.")
+	webhookCmd.Flags().String("queue-file", "webhook-queue.json", "The file in which release events are queued until processed, along with the recent delivery ids, so that restarts lose neither.")
+	webhookCmd.Flags().String("run", "", "The command, split on whitespace, regenerating the database for each queued release event, given the event as $MARKETPLACE_REPOSITORY, $MARKETPLACE_TAG and $MARKETPLACE_ACTION.")
+	webhookCmd.Flags().Duration("run-timeout", time.Hour, "The time after which a run of --run is abandoned and retried.")
+	webhookCmd.Flags().Duration("retry-interval", time.Minute, "The time to wait before retrying a failed run of --run.")
+	webhookCmd.Flags().Duration("replay-window", 72*time.Hour, "How long the id of each delivery is remembered, rejecting its replays. GitHub only redelivers within 3 days.")
+
+	generatorCmd.AddCommand(webhookCmd)
+}
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Regenerate the database as GitHub webhooks report new releases",
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		secret, _ := command.Flags().GetString("webhook-secret")
+		if secret == "" {
+			secret = os.Getenv("GITHUB_WEBHOOK_SECRET")
+		}
+		if secret == "" {
+			return configError(errors.New("--webhook-secret is required"))
+		}
+		run, _ := command.Flags().GetString("run")
+		runArgs := strings.Fields(run)
+		if len(runArgs) == 0 {
+			return configError(errors.New("--run is required"))
+		}
+
+		queueFile, _ := command.Flags().GetString("queue-file")
+		replayWindow, _ := command.Flags().GetDuration("replay-window")
+		queue, err := loadWebhookQueue(queueFile, replayWindow)
+		if err != nil {
+			return err
+		}
+
+		runTimeout, _ := command.Flags().GetDuration("run-timeout")
+		retryInterval, _ := command.Flags().GetDuration("retry-interval")
+		receiver := &webhookReceiver{secret: []byte(secret), queue: queue}
+		runner := &webhookRunner{queue: queue, args: runArgs, timeout: runTimeout, retryInterval: retryInterval}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			runner.run(ctx)
+		}()
+
+		listen, _ := command.Flags().GetString("listen")
+		srv := &http.Server{
+			Addr:         listen,
+			Handler:      receiver,
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+		go func() {
+			logger.WithField("addr", srv.Addr).Info("Receiving webhooks")
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.WithError(err).Error("Failed to listen and serve")
+			}
+		}()
+
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt)
+		<-c
+		logger.Info("Shutting down")
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer shutdownCancel()
+		srv.Shutdown(shutdownCtx)
+		// Abandon any run in progress, leaving its event queued for the next start.
+		cancel()
+		<-done
+
+		return nil
+	},
+}
+
+// webhookEvent is a release event queued until processed.
+type webhookEvent struct {
+	Delivery string `json:"delivery"`
+	// Repository is the full name of the repository, e.g. mattermost/mattermost-plugin-demo.
+	Repository string    `json:"repository"`
+	Tag        string    `json:"tag"`
+	Action     string    `json:"action"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// replayedDeliveryPrefix distinguishes the delivery ids given to events replayed from the GitHub
+// events API from those of webhook deliveries.
+const replayedDeliveryPrefix = "event-"
+
+// releaseKey identifies the release event among those received, whichever its delivery, so that
+// replaying a release event already delivered by webhook does not queue it again.
+func (e *webhookEvent) releaseKey() string {
+	return "release:" + strings.ToLower(e.Repository) + "@" + e.Tag + ":" + e.Action
+}
+
+// webhookQueue durably records the pending release events, and the delivery ids received within
+// the replay window.
+type webhookQueue struct {
+	path         string
+	replayWindow time.Duration
+	now          func() time.Time
+	// ready is signaled whenever an event is queued.
+	ready chan struct{}
+
+	lock    sync.Mutex
+	Pending []*webhookEvent `json:"pending"`
+	// Seen maps the id of each delivery received within the replay window to when it was, along
+	// with the releaseKey of each release event received.
+	Seen map[string]time.Time `json:"seen"`
+}
+
+// loadWebhookQueue reads the queue at the given path, or starts an empty queue if there is none.
+func loadWebhookQueue(path string, replayWindow time.Duration) (*webhookQueue, error) {
+	queue := &webhookQueue{
+		path:         path,
+		replayWindow: replayWindow,
+		now:          time.Now,
+		ready:        make(chan struct{}, 1),
+		Seen:         map[string]time.Time{},
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return queue, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read queue %s", path)
+	}
+
+	if err := json.Unmarshal(data, queue); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse queue %s", path)
+	}
+	if queue.Seen == nil {
+		queue.Seen = map[string]time.Time{}
+	}
+	if len(queue.Pending) > 0 {
+		logger.Infof("resuming with %d queued release events", len(queue.Pending))
+	}
+
+	return queue, nil
+}
+
+// push queues the given event, unless its delivery was already received within the replay
+// window. The queue is saved before returning, so that a queued event survives restarts.
+func (q *webhookQueue) push(event *webhookEvent) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.prune()
+	if _, ok := q.Seen[event.Delivery]; ok {
+		return false, nil
+	}
+
+	return q.enqueue(event, event.ReceivedAt)
+}
+
+// replay queues the given event replayed from the GitHub events API, unless the release event was
+// already received, whether delivered by webhook or replayed, within the replay window.
+func (q *webhookQueue) replay(event *webhookEvent) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.prune()
+	if _, ok := q.Seen[event.Delivery]; ok {
+		return false, nil
+	}
+	if _, ok := q.Seen[event.releaseKey()]; ok {
+		return false, nil
+	}
+
+	return q.enqueue(event, q.now().UTC())
+}
+
+// enqueue records the given event as received at the given time, and queues it. The lock must be
+// held.
+func (q *webhookQueue) enqueue(event *webhookEvent, receivedAt time.Time) (bool, error) {
+	releaseKey := event.releaseKey()
+	previous, releaseSeen := q.Seen[releaseKey]
+
+	q.Seen[event.Delivery] = receivedAt
+	q.Seen[releaseKey] = receivedAt
+	q.Pending = append(q.Pending, event)
+	if err := q.save(); err != nil {
+		delete(q.Seen, event.Delivery)
+		if releaseSeen {
+			q.Seen[releaseKey] = previous
+		} else {
+			delete(q.Seen, releaseKey)
+		}
+		q.Pending = q.Pending[:len(q.Pending)-1]
+		return false, err
+	}
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+
+	return true, nil
+}
+
+// seen records the delivery of an event that is not queued, rejecting its replays.
+func (q *webhookQueue) seen(delivery string, receivedAt time.Time) (bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.prune()
+	if _, ok := q.Seen[delivery]; ok {
+		return false, nil
+	}
+
+	q.Seen[delivery] = receivedAt
+	if err := q.save(); err != nil {
+		delete(q.Seen, delivery)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// peek returns the oldest pending event, or nil if none is.
+func (q *webhookQueue) peek() *webhookEvent {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.Pending) == 0 {
+		return nil
+	}
+
+	return q.Pending[0]
+}
+
+// remove dequeues the given processed event.
+func (q *webhookQueue) remove(event *webhookEvent) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for i, pending := range q.Pending {
+		if pending == event {
+			q.Pending = append(q.Pending[:i:i], q.Pending[i+1:]...)
+			break
+		}
+	}
+
+	return q.save()
+}
+
+// prune forgets the deliveries received before the replay window. The lock must be held.
+func (q *webhookQueue) prune() {
+	cutoff := q.now().Add(-q.replayWindow)
+	for delivery, receivedAt := range q.Seen {
+		if receivedAt.Before(cutoff) {
+			delete(q.Seen, delivery)
+		}
+	}
+}
+
+// save writes the queue to its path, replacing the previous queue only once fully written. The
+// lock must be held.
+func (q *webhookQueue) save() error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode queue")
+	}
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary queue")
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return errors.Wrap(err, "failed to write temporary queue")
+	}
+	if err := tempFile.Close(); err != nil {
+		return errors.Wrap(err, "failed to write temporary queue")
+	}
+
+	if err := os.Rename(tempFile.Name(), q.path); err != nil {
+		return errors.Wrapf(err, "failed to replace queue %s", q.path)
+	}
+
+	return nil
+}
+
+// webhookReceiver verifies GitHub webhook deliveries, queueing their release events.
+type webhookReceiver struct {
+	secret []byte
+	queue  *webhookQueue
+}
+
+// webhookPayload holds the fields of a release event used by the receiver.
+type webhookPayload struct {
+	Action  string `json:"action"`
+	Release struct {
+		TagName string `json:"tag_name"`
+	} `json:"release"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+func (h *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayloadSize))
+	if err != nil {
+		http.Error(w, "failed to read payload", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if !verifyWebhookSignature(h.secret, body, r.Header.Get("X-Hub-Signature-256")) {
+		logger.WithField("remote_addr", r.RemoteAddr).Warn("rejecting webhook with an invalid signature")
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	delivery := r.Header.Get("X-GitHub-Delivery")
+	if delivery == "" {
+		http.Error(w, "missing delivery id", http.StatusBadRequest)
+		return
+	}
+	eventType := r.Header.Get("X-GitHub-Event")
+	logger := logger.WithFields(logrus.Fields{"delivery": delivery, "event": eventType})
+	receivedAt := h.queue.now().UTC()
+
+	if eventType != "release" {
+		// Record the delivery all the same, so that replays of other signed events, such as
+		// pings, are also rejected.
+		accepted, err := h.queue.seen(delivery, receivedAt)
+		h.respond(w, logger, accepted, err)
+		return
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	logger = logger.WithField("repository", payload.Repository.FullName)
+	accepted, err := h.queue.push(&webhookEvent{
+		Delivery:   delivery,
+		Repository: payload.Repository.FullName,
+		Tag:        payload.Release.TagName,
+		Action:     payload.Action,
+		ReceivedAt: receivedAt,
+	})
+	if accepted {
+		logger.Infof("queued %s release %s", payload.Action, payload.Release.TagName)
+	}
+	h.respond(w, logger, accepted, err)
+}
+
+// respond acknowledges a delivery once recorded, or rejects it as a replay.
+func (h *webhookReceiver) respond(w http.ResponseWriter, logger logrus.FieldLogger, accepted bool, err error) {
+	if err != nil {
+		logger.WithError(err).Error("failed to queue webhook")
+		http.Error(w, "failed to queue webhook", http.StatusInternalServerError)
+		return
+	}
+	if !accepted {
+		logger.Warn("ignoring replayed webhook delivery")
+		http.Error(w, "duplicate delivery", http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verifyWebhookSignature reports whether the given X-Hub-Signature-256 header signs the given
+// payload with the given secret.
+func verifyWebhookSignature(secret, payload []byte, header string) bool {
+	if !strings.HasPrefix(header, "sha256=") {
+		return false
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(header, "sha256="))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// webhookRunner runs the regeneration command for each queued event in turn, retrying failed
+// runs until they succeed.
+type webhookRunner struct {
+	queue         *webhookQueue
+	args          []string
+	timeout       time.Duration
+	retryInterval time.Duration
+}
+
+// run processes queued events until the given context is done.
+func (r *webhookRunner) run(ctx context.Context) {
+	for {
+		event := r.queue.peek()
+		if event == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.queue.ready:
+				continue
+			}
+		}
+
+		logger := logger.WithFields(logrus.Fields{"delivery": event.Delivery, "repository": event.Repository})
+		if err := r.runEvent(ctx, event); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			logger.WithError(err).Errorf("failed to regenerate for release %s, retrying in %s", event.Tag, r.retryInterval)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.retryInterval):
+			}
+			continue
+		}
+
+		logger.Infof("regenerated for release %s", event.Tag)
+		if err := r.queue.remove(event); err != nil {
+			// The event remains queued on disk, and is processed again after a restart.
+			logger.WithError(err).Error("failed to dequeue release event")
+		}
+	}
+}
+
+// drain processes the queued events in turn until none remain, stopping at the first failed run,
+// whose event remains queued.
+func (r *webhookRunner) drain(ctx context.Context) error {
+	for event := r.queue.peek(); event != nil; event = r.queue.peek() {
+		if err := r.runEvent(ctx, event); err != nil {
+			return errors.Wrapf(err, "failed to regenerate for release %s of %s", event.Tag, event.Repository)
+		}
+
+		logger.WithFields(logrus.Fields{"delivery": event.Delivery, "repository": event.Repository}).Infof("regenerated for release %s", event.Tag)
+		if err := r.queue.remove(event); err != nil {
+			return errors.Wrap(err, "failed to dequeue release event")
+		}
+	}
+
+	return nil
+}
+
+// runEvent runs the regeneration command for the given event.
+func (r *webhookRunner) runEvent(ctx context.Context, event *webhookEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, r.args[0], r.args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"MARKETPLACE_REPOSITORY="+event.Repository,
+		"MARKETPLACE_TAG="+event.Tag,
+		"MARKETPLACE_ACTION="+event.Action,
+	)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "failed to run %s", r.args[0])
+	}
+
+	return nil
+}
@@ -1,15 +1,22 @@
 package main
 
 import (
+	"os"
+	"time"
+
 	"github.com/akrylysov/algnhsa"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
-	"github.com/rakyll/statik/fs"
 	"github.com/sirupsen/logrus"
 
-	_ "github.com/mattermost/mattermost-marketplace/data/statik"
-
 	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/embedded"
+	"github.com/mattermost/mattermost-marketplace/internal/reporting"
+	"github.com/mattermost/mattermost-marketplace/internal/stats"
 	"github.com/mattermost/mattermost-marketplace/internal/store"
 )
 
@@ -22,41 +29,151 @@ func main() {
 	}
 }
 
-func newStatikStore(statikPath string, logger logrus.FieldLogger) (*store.Store, error) {
-	statikFS, err := fs.New()
+// newS3Store loads the store from the given object in S3, allowing the database to be updated
+// without redeploying the function.
+func newS3Store(s3Client s3iface.S3API, bucket, key string, logger logrus.FieldLogger) (*store.Store, error) {
+	object, err := s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to open statik fileystem")
+		return nil, errors.Wrapf(err, "failed to get s3://%s/%s", bucket, key)
 	}
+	defer object.Body.Close()
 
-	database, err := statikFS.Open(statikPath)
+	s3Store, err := store.New(object.Body, logger)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to open %s", database)
+		return nil, errors.Wrap(err, "failed to initialize store")
 	}
-	defer database.Close()
 
-	statikStore, err := store.New(database, logger)
+	return s3Store, nil
+}
+
+// newStore loads the store from S3 if DATABASE_S3_BUCKET is configured, falling back to the
+// database compiled into the binary.
+func newStore(logger logrus.FieldLogger) (*store.Store, error) {
+	bucket := os.Getenv("DATABASE_S3_BUCKET")
+	if bucket == "" {
+		return embedded.NewStore(embedded.PluginsPath, logger, store.Options{})
+	}
+
+	key := os.Getenv("DATABASE_S3_KEY")
+	if key == "" {
+		key = "plugins.json"
+	}
+
+	awsSession, err := session.NewSession()
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to initialize store")
+		return nil, errors.Wrap(err, "failed to create aws session")
 	}
 
-	return statikStore, nil
+	logger.WithField("bucket", bucket).WithField("key", key).Info("Loading database from S3")
+
+	return newS3StoreWithFallback(s3.New(awsSession), bucket, key, os.Getenv("DATABASE_S3_APPS_KEY"), logger)
+}
+
+// newS3StoreWithFallback loads the store and, if appsKey is given, its apps from S3. Should either
+// be unavailable, it serves the database compiled into the binary rather than failing the cold
+// start, until a later instance loads from S3 again.
+func newS3StoreWithFallback(s3Client s3iface.S3API, bucket, key, appsKey string, logger logrus.FieldLogger) (*store.Store, error) {
+	s3Store, err := newS3Store(s3Client, bucket, key, logger)
+	if err == nil && appsKey != "" {
+		err = loadS3Apps(s3Client, bucket, appsKey, s3Store)
+	}
+	if err != nil {
+		logger.WithError(err).Error("Failed to load database from S3, serving the embedded database")
+		return embedded.NewStore(embedded.PluginsPath, logger, store.Options{})
+	}
+
+	return s3Store, nil
+}
+
+// loadS3Apps loads the apps served by the given store from the given object in S3.
+func loadS3Apps(s3Client s3iface.S3API, bucket, key string, s3Store *store.Store) error {
+	object, err := s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get s3://%s/%s", bucket, key)
+	}
+	defer object.Body.Close()
+
+	if err := s3Store.LoadApps(object.Body); err != nil {
+		return errors.Wrap(err, "failed to load apps")
+	}
+
+	return nil
+}
+
+// statsFlushInterval is how often download statistics are persisted to S3.
+const statsFlushInterval = 30 * time.Second
+
+// newS3Tracker creates a download statistics tracker persisting to the given object in S3.
+func newS3Tracker(bucket, key string) (*stats.Tracker, error) {
+	if key == "" {
+		key = "stats.json"
+	}
+
+	awsSession, err := session.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create aws session")
+	}
+
+	tracker, err := stats.NewTracker(&stats.S3Backend{
+		Client: s3.New(awsSession),
+		Bucket: bucket,
+		Key:    key,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize stats")
+	}
+
+	return tracker, nil
 }
 
 func listenAndServe() error {
 	logger = logrus.New()
 
-	statikStore, err := newStatikStore("/plugins.json", logger)
+	if sentryDSN := os.Getenv("SENTRY_DSN"); sentryDSN != "" {
+		reporter, err := reporting.NewSentryReporter(sentryDSN)
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize error reporting")
+		}
+		// Errors are sent in the background, so those reported just before the function is
+		// frozen are delivered when it next thaws.
+		logger.AddHook(reporting.NewHook(reporter))
+	}
+
+	pluginStore, err := newStore(logger)
 	if err != nil {
 		return err
 	}
 
-	router := mux.NewRouter()
-	api.Register(router, &api.Context{
-		Store:  statikStore,
+	apiContext := &api.Context{
+		Store:  pluginStore,
 		Logger: logger,
-	})
+	}
+
+	if statsBucket := os.Getenv("STATS_S3_BUCKET"); statsBucket != "" {
+		tracker, err := newS3Tracker(statsBucket, os.Getenv("STATS_S3_KEY"))
+		if err != nil {
+			return err
+		}
+		apiContext.Stats = tracker
+
+		// The function may be frozen between invocations, so counters not yet flushed when an
+		// instance is reclaimed are lost.
+		go tracker.Run(statsFlushInterval, nil, func(err error) {
+			logger.WithError(err).Error("failed to persist stats")
+		})
+	}
+
+	router := mux.NewRouter()
+	api.Register(router, apiContext)
 
-	algnhsa.ListenAndServe(router, &algnhsa.Options{
+	// API Gateway bounds headers itself, but bodies and urls are bounded here.
+	algnhsa.ListenAndServe(api.NewLimitHandler(router, api.DefaultLimits), &algnhsa.Options{
 		UseProxyPath: true,
 	})
 
@@ -1,12 +1,102 @@
 package main
 
 import (
+	"bytes"
+	"io/ioutil"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
 )
 
-func TestNewStatikStore(t *testing.T) {
-	_, err := newStatikStore("/plugins.json", logger)
-	require.NoError(t, err)
+type mockS3Client struct {
+	s3iface.S3API
+	objects map[string][]byte
+}
+
+func (m *mockS3Client) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	data, ok := m.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)
+	}
+
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func TestNewS3Store(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+	s3Client := &mockS3Client{objects: map[string][]byte{
+		"bucket/plugins.json": []byte(`[{"manifest":{"id":"demo","version":"0.1.0"}}]`),
+		"bucket/invalid.json": []byte(`[{"manifest":{"id":"demo","version":"invalid"}}]`),
+	}}
+
+	t.Run("valid", func(t *testing.T) {
+		s3Store, err := newS3Store(s3Client, "bucket", "plugins.json", logger)
+		require.NoError(t, err)
+
+		plugins, err := s3Store.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := newS3Store(s3Client, "bucket", "missing.json", logger)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get s3://bucket/missing.json")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := newS3Store(s3Client, "bucket", "invalid.json", logger)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to initialize store")
+	})
+
+	t.Run("apps", func(t *testing.T) {
+		s3Client.objects["bucket/apps.json"] = []byte(`[{"id":"demo","display_name":"Demo","manifest_url":"https://example.com/manifest.json","hosting_type":"http"}]`)
+
+		s3Store, err := newS3Store(s3Client, "bucket", "plugins.json", logger)
+		require.NoError(t, err)
+		require.NoError(t, loadS3Apps(s3Client, "bucket", "apps.json", s3Store))
+
+		apps, err := s3Store.GetApps(&model.AppFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Len(t, apps, 1)
+
+		require.Error(t, loadS3Apps(s3Client, "bucket", "missing.json", s3Store))
+	})
+}
+
+func TestNewS3StoreWithFallback(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+	s3Client := &mockS3Client{objects: map[string][]byte{
+		"bucket/plugins.json": []byte(`[{"manifest":{"id":"demo","version":"0.1.0"}}]`),
+	}}
+
+	t.Run("available", func(t *testing.T) {
+		s3Store, err := newS3StoreWithFallback(s3Client, "bucket", "plugins.json", "", logger)
+		require.NoError(t, err)
+
+		plugins, err := s3Store.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+		require.Equal(t, "demo", plugins[0].Manifest.Id)
+	})
+
+	t.Run("database unavailable", func(t *testing.T) {
+		fallbackStore, err := newS3StoreWithFallback(s3Client, "bucket", "missing.json", "", logger)
+		require.NoError(t, err)
+		require.NotNil(t, fallbackStore)
+	})
+
+	t.Run("apps unavailable", func(t *testing.T) {
+		fallbackStore, err := newS3StoreWithFallback(s3Client, "bucket", "plugins.json", "missing.json", logger)
+		require.NoError(t, err)
+		require.NotNil(t, fallbackStore)
+	})
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/audit"
+	"github.com/mattermost/mattermost-marketplace/internal/notify"
+)
+
+// newAuditor creates an auditor verifying signatures by the keys read from --audit-keyring, and
+// notifying drift with the given notifier, if any.
+func newAuditor(command *cobra.Command, notifier *notify.Notifier) (*audit.Auditor, error) {
+	sampleSize, _ := command.Flags().GetInt("audit-sample-size")
+	paths, _ := command.Flags().GetStringSlice("audit-keyring")
+
+	var readers []io.Reader
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", path)
+		}
+		readers = append(readers, bytes.NewReader(data))
+	}
+	keyring, err := api.ReadPublicKeys(readers...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := api.NewClient("")
+	client.UserAgent = "mattermost-marketplace"
+	options := audit.Options{
+		SampleSize: sampleSize,
+		Keyring:    keyring,
+		Client:     client,
+	}
+	if notifier != nil {
+		options.OnDrift = notifier.NotifyDrift
+	}
+
+	return audit.New(logger, options), nil
+}
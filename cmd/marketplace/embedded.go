@@ -0,0 +1,15 @@
+//go:build embedded
+// +build embedded
+
+package main
+
+import (
+	"github.com/mattermost/mattermost-marketplace/internal/embedded"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+func init() {
+	fallbackStore = func(options store.Options) (*store.Store, error) {
+		return embedded.NewStore(embedded.PluginsPath, logger, options)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/notify"
+)
+
+// newNotifier creates a notifier delivering to the sinks configured in the given file, or returns
+// nil if none is given.
+func newNotifier(path string, logger logrus.FieldLogger) (*notify.Notifier, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer file.Close()
+
+	config, err := notify.ConfigFromReader(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	return notify.NewFromConfig(config, logger), nil
+}
+
+// notifyingAdvisories notifies of each advisory published to the wrapped advisories.
+type notifyingAdvisories struct {
+	api.Advisories
+	notifier *notify.Notifier
+}
+
+// Publish publishes the given advisory, notifying of it once published.
+func (a *notifyingAdvisories) Publish(advisory *model.Advisory) (*model.Advisory, error) {
+	published, err := a.Advisories.Publish(advisory)
+	if err != nil {
+		return nil, err
+	}
+
+	a.notifier.NotifyAdvisory(published)
+
+	return published, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/tombstones"
+)
+
+// reloadDatabase checks the given databases every interval until done is closed, replacing the
+// catalog's store whenever either was modified, and recording the plugin versions removed in the
+// given tombstone registry, if any. A database failing to load leaves the catalog serving its
+// previous store.
+func reloadDatabase(database, appsDatabase string, options store.Options, pluginCatalog *catalog.Catalog, registry *tombstones.Registry, interval time.Duration, done <-chan struct{}) {
+	logger := logger.WithField("database", database)
+
+	lastModified := databaseModTime(database, appsDatabase)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+
+		modified := databaseModTime(database, appsDatabase)
+		if modified.Equal(lastModified) {
+			continue
+		}
+
+		reloadedStore, err := newFileStore(database, appsDatabase, options)
+		if err != nil {
+			logger.WithError(err).Error("Failed to reload database")
+			continue
+		}
+		lastModified = modified
+
+		changes := pluginCatalog.Replace(reloadedStore)
+		logger.WithField("changes", len(changes)).Info("Reloaded database")
+
+		if registry != nil {
+			if err := registry.Record(changes, model.TombstoneReasonReloaded); err != nil {
+				logger.WithError(err).Error("Failed to record tombstones of removed plugin versions")
+			}
+		}
+	}
+}
+
+// databaseModTime returns the latest modification time of the given databases, ignoring any that
+// cannot be read.
+func databaseModTime(databases ...string) time.Time {
+	var latest time.Time
+	for _, database := range databases {
+		if database == "" {
+			continue
+		}
+
+		info, err := os.Stat(database)
+		if err == nil && info.IsDir() {
+			// A split database is rewritten along with its index.
+			info, err = os.Stat(filepath.Join(database, model.DatabaseIndexName))
+		}
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest
+}
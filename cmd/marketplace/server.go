@@ -1,30 +1,145 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/advisories"
+	"github.com/mattermost/mattermost-marketplace/internal/analytics"
 	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/apikeys"
+	"github.com/mattermost/mattermost-marketplace/internal/audit"
+	"github.com/mattermost/mattermost-marketplace/internal/blocklist"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/failover"
+	"github.com/mattermost/mattermost-marketplace/internal/featured"
+	"github.com/mattermost/mattermost-marketplace/internal/grpcapi"
+	"github.com/mattermost/mattermost-marketplace/internal/metrics"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/oidc"
+	"github.com/mattermost/mattermost-marketplace/internal/presign"
+	"github.com/mattermost/mattermost-marketplace/internal/proxy"
+	"github.com/mattermost/mattermost-marketplace/internal/quality"
+	"github.com/mattermost/mattermost-marketplace/internal/ratings"
+	"github.com/mattermost/mattermost-marketplace/internal/rehost"
+	"github.com/mattermost/mattermost-marketplace/internal/reporting"
+	"github.com/mattermost/mattermost-marketplace/internal/signing"
+	"github.com/mattermost/mattermost-marketplace/internal/stats"
 	"github.com/mattermost/mattermost-marketplace/internal/store"
-	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-marketplace/internal/submissions"
+	"github.com/mattermost/mattermost-marketplace/internal/tombstones"
+	"github.com/mattermost/mattermost-marketplace/internal/tracing"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var instanceID string
 
+// fallbackStore, if set by building with the embedded tag, serves the database compiled into the
+// binary whenever the configured database cannot be loaded at startup.
+var fallbackStore func(options store.Options) (*store.Store, error)
+
 func init() {
-	instanceID = model.NewId()
+	instanceID = mattermostModel.NewId()
 
 	serverCmd.PersistentFlags().String("database", "plugins.json", "The read-only JSON file backing the server.")
+	serverCmd.PersistentFlags().String("apps-database", "", "The optional read-only JSON file listing apps served alongside plugins.")
+	serverCmd.PersistentFlags().String("secondary-database", "", "The optional read-only JSON file, such as an earlier snapshot of --database, serving queries whenever --database fails.")
+	serverCmd.PersistentFlags().Duration("failover-retry-interval", failover.DefaultRetryInterval, "How long queries are served by --secondary-database after --database fails, before it is tried again.")
+	serverCmd.PersistentFlags().String("upstream", "", "The optional url of an upstream marketplace whose plugins to serve in place of --database, e.g. https://api.integrations.mattermost.com.")
+	serverCmd.PersistentFlags().String("upstream-cache-file", "", "The optional JSON file persisting the catalog last fetched from --upstream, served marked stale while the upstream is unreachable.")
+	serverCmd.PersistentFlags().Duration("upstream-refresh-interval", proxy.DefaultRefreshInterval, "How long the catalog fetched from --upstream is served before it is fetched again.")
+	serverCmd.PersistentFlags().Duration("upstream-max-retry-interval", proxy.DefaultMaxRetryInterval, "The longest time the cached catalog is served after --upstream fails before it is tried again, the retry interval doubling with each consecutive failure.")
+	serverCmd.PersistentFlags().Duration("upstream-refresh-wait", proxy.DefaultRefreshWait, "How long a query waits on a refresh from --upstream before the current catalog is served.")
+	serverCmd.PersistentFlags().StringSlice("channel", nil, "Additional catalogs to serve, as name=database pairs, selected by the channel query parameter, e.g. beta=beta.json.")
+	serverCmd.PersistentFlags().StringSlice("channel-host", nil, "Hosts selecting a channel when requests are addressed to them, as host=channel pairs, e.g. beta.marketplace.example.com=beta.")
+	serverCmd.PersistentFlags().String("tenants-file", "", "The optional JSON file listing tenants served their own catalogs and configuration, selected by host or path prefix.")
 	serverCmd.PersistentFlags().String("listen", ":8085", "The interface and port on which to listen.")
 	serverCmd.PersistentFlags().Bool("debug", false, "Whether to output debug logs.")
+	serverCmd.PersistentFlags().String("sentry-dsn", "", "The optional Sentry DSN to which to report errors and panics.")
+	serverCmd.PersistentFlags().String("grpc-listen", "", "The optional interface and port on which to serve the gRPC API, e.g. :8087.")
+	serverCmd.PersistentFlags().Duration("reload-interval", 0, "How often to check the databases for changes, reloading them without a restart, or 0 to never reload.")
+	serverCmd.PersistentFlags().Int("snapshot-limit", catalog.DefaultSnapshotLimit, "The number of catalog snapshots, one per load of a database, retained for moderators to roll back to.")
+	serverCmd.PersistentFlags().Bool("catalog-import", false, "Whether moderators may replace catalogs with PUT /api/v1/admin/catalog, overwriting their databases.")
+	serverCmd.PersistentFlags().Duration("read-timeout", api.DefaultServerOptions.ReadTimeout, "The maximum time to read a request, including its body, or 0 for no limit.")
+	serverCmd.PersistentFlags().Duration("write-timeout", api.DefaultServerOptions.WriteTimeout, "The maximum time to write a response, or 0 for no limit.")
+	serverCmd.PersistentFlags().Duration("idle-timeout", api.DefaultServerOptions.IdleTimeout, "The maximum time a kept-alive connection waits for its next request, or 0 for --read-timeout.")
+	serverCmd.PersistentFlags().Bool("disable-keep-alives", false, "Whether to close each connection after a single request.")
+	serverCmd.PersistentFlags().Bool("disable-http2", false, "Whether to serve only HTTP/1.1 over TLS.")
+	serverCmd.PersistentFlags().Uint32("http2-max-concurrent-streams", 0, "The maximum number of concurrent requests per HTTP/2 connection, or 0 for the default of 250.")
+	serverCmd.PersistentFlags().String("tls-cert-file", "", "The optional PEM-encoded certificate chain with which to serve HTTPS and gRPC over TLS, given --tls-key-file.")
+	serverCmd.PersistentFlags().String("tls-key-file", "", "The PEM-encoded private key of --tls-cert-file.")
+	serverCmd.PersistentFlags().String("tls-min-version", "1.2", "The oldest TLS version accepted, one of 1.0, 1.1, 1.2 or 1.3.")
+	serverCmd.PersistentFlags().StringSlice("tls-cipher-suites", nil, "The cipher suites accepted for TLS 1.2 and older, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, or none for Go's defaults.")
+	serverCmd.PersistentFlags().String("tls-client-ca-file", "", "The optional PEM-encoded certificates of the authorities signing the client certificates accepted for mutual TLS.")
+	serverCmd.PersistentFlags().String("tls-client-auth", "", "Whether clients must present a certificate signed by --tls-client-ca-file: none, optional or required. Defaults to required given --tls-client-ca-file.")
+	serverCmd.PersistentFlags().String("admin-listen", "", "The optional interface and port on which to serve pprof and runtime diagnostics, e.g. localhost:8086.")
+	serverCmd.PersistentFlags().Int64("max-body-size", api.DefaultLimits.MaxBodySize, "The maximum size in bytes of a request body, or 0 for no limit.")
+	serverCmd.PersistentFlags().Int("max-url-length", api.DefaultLimits.MaxURLLength, "The maximum length of a request url, or 0 for no limit.")
+	serverCmd.PersistentFlags().Int("max-header-size", 1<<20, "The maximum size in bytes of the request headers.")
+	serverCmd.PersistentFlags().Int("max-icon-size", 0, "The maximum size in bytes of a plugin icon, or 0 for the default.")
+	serverCmd.PersistentFlags().Bool("lenient-icons", false, "Whether to strip invalid plugin icons instead of failing to start.")
+	serverCmd.PersistentFlags().String("strict-rules", "", "The optional YAML file of quality checks and rules enforced in strict mode, rejecting databases with plugins failing its blocking rules.")
+	serverCmd.PersistentFlags().Duration("stale-after", 0, "The age of a plugin's latest release beyond which it is labeled unmaintained in responses, e.g. 8760h, or 0 to label only plugins whose repository was archived.")
+	serverCmd.PersistentFlags().Duration("pre-release-ttl", 0, "How long after their release beta and experimental versions not recording their own expires_at are served, e.g. 720h, or 0 to serve them until promoted or removed.")
+	serverCmd.PersistentFlags().Int("icon-stripping-threshold", 0, "The size in bytes of a plugin listing beyond which icons are served by url rather than inline, or 0 to always inline icons.")
+	serverCmd.PersistentFlags().Int("response-cache-size", 0, "The number of plugin listing responses to cache in memory by their normalized query, or 0 to disable the cache.")
+	serverCmd.PersistentFlags().StringSlice("compatibility-server-versions", api.DefaultCompatibilityServerVersions, "The server versions for which /api/v1/plugins/{id}/compatibility reports the plugin version served, unless requested otherwise.")
+	serverCmd.PersistentFlags().Duration("audit-interval", 0, "How often to re-verify a sample of the plugin versions served, reporting drift at /metrics and to --notifications-file, or 0 to disable audits.")
+	serverCmd.PersistentFlags().Int("audit-sample-size", audit.DefaultSampleSize, "The number of plugin versions re-verified by each audit.")
+	serverCmd.PersistentFlags().StringSlice("audit-keyring", nil, "Optional armored or binary OpenPGP public keys trusted to sign plugin bundles, whose signatures audits verify.")
+	serverCmd.PersistentFlags().String("stats-file", "", "The optional JSON file in which to persist download statistics.")
+	serverCmd.PersistentFlags().Duration("stats-flush-interval", time.Minute, "How often to persist download statistics and query analytics.")
+	serverCmd.PersistentFlags().String("analytics-file", "", "The optional JSON file in which to persist anonymized query analytics, reported to --moderators at /api/v1/analytics.")
+	serverCmd.PersistentFlags().StringSlice("slo", nil, "Service level objectives reported by /metrics, as route=latency:target, e.g. /api/v1/plugins=250ms:0.99.")
+	serverCmd.PersistentFlags().String("ratings-file", "", "The optional JSON file in which to persist plugin ratings.")
+	serverCmd.PersistentFlags().String("auth-tokens-file", "", "The optional JSON file mapping bearer tokens to the users allowed to submit ratings and repositories.")
+	serverCmd.PersistentFlags().String("oidc-issuer", "", "The optional OpenID Connect issuer whose tokens also authenticate users, alongside --auth-tokens-file.")
+	serverCmd.PersistentFlags().String("oidc-audience", "", "The audience, such as the client id, required of tokens from --oidc-issuer.")
+	serverCmd.PersistentFlags().StringToString("oidc-required-claims", nil, "Claims required of tokens from --oidc-issuer, as claim=value, the value being included in list claims, e.g. groups=marketplace-admins.")
+	serverCmd.PersistentFlags().String("oidc-user-claim", oidc.DefaultUserClaim, "The claim of tokens from --oidc-issuer identifying the user, as named by --moderators.")
+	serverCmd.PersistentFlags().String("oidc-jwks-url", "", "The url serving the signing keys of --oidc-issuer, defaulting to that discovered from its OpenID configuration.")
+	serverCmd.PersistentFlags().String("submissions-file", "", "The optional JSON file in which to persist community plugin submissions.")
+	serverCmd.PersistentFlags().String("api-keys-file", "", "The optional JSON file in which to persist the API keys issued to partners and their usage.")
+	serverCmd.PersistentFlags().Duration("api-keys-flush-interval", time.Minute, "How often to persist API key usage.")
+	serverCmd.PersistentFlags().StringSlice("moderators", nil, "The ids of the users allowed to approve or reject submissions.")
+	serverCmd.PersistentFlags().StringSlice("write-allowed-cidrs", nil, "The optional CIDR ranges from which to accept ratings, submissions and moderation, in addition to authentication.")
+	serverCmd.PersistentFlags().Bool("trust-forwarded-for", false, "Whether to identify clients by the X-Forwarded-For header appended by a trusted load balancer when applying --write-allowed-cidrs, the blocklist and the burst limit, and telling apart install reports.")
+	serverCmd.PersistentFlags().String("blocklist-file", "", "The optional JSON file in which to persist the clients denied access, manageable by moderators.")
+	serverCmd.PersistentFlags().String("advisories-file", "", "The optional JSON file in which to persist the security advisories published by moderators.")
+	serverCmd.PersistentFlags().String("featured-file", "", "The optional JSON file in which to persist the plugins featured by moderators, overriding the featuring recorded in the database.")
+	serverCmd.PersistentFlags().String("rehost-directory", "", "The optional directory in which to re-host plugin bundles, served at /downloads in place of their original urls and fetched from them when first requested.")
+	serverCmd.PersistentFlags().Duration("download-url-ttl", 5*time.Minute, "The least time for which the pre-signed urls minted for the re-hosted bundles of private plugins remain valid, at most twice it. Urls are signed with the key in $DOWNLOAD_SIGNING_KEY, which requires --rehost-directory.")
+	serverCmd.PersistentFlags().String("tombstones-file", "", "The optional JSON file in which to persist a tombstone of each plugin version removed from the catalog, served at /api/v1/tombstones.")
+	serverCmd.PersistentFlags().String("notifications-file", "", "The optional YAML file configuring the sinks, such as Mattermost or Slack webhooks, email or http endpoints, notified of new plugins, new versions, delistings and advisories.")
+	serverCmd.PersistentFlags().Bool("admin-ui", false, "Whether to serve a web UI at /admin letting --moderators browse the catalog, delist plugin versions, publish advisories and review statistics, signing in with their bearer tokens.")
+	serverCmd.PersistentFlags().String("catalog-signing-keyring", "", "An optional armored OpenPGP keyring holding the key with which to sign the catalog digests served at /api/v1/catalog/digest. An encrypted key is decrypted with the passphrase in $CATALOG_SIGNING_KEYRING_PASSPHRASE.")
+	serverCmd.PersistentFlags().String("catalog-signing-key-id", "", "The id of the key to use from --catalog-signing-keyring, if it holds more than one.")
+	serverCmd.PersistentFlags().StringSlice("signing-public-keys", nil, "Optional armored or binary OpenPGP public keys to serve at /api/v1/signing-keys, such as those signing plugin bundles. The key of --catalog-signing-keyring is served too.")
+	serverCmd.PersistentFlags().Int("burst-limit", 0, "The maximum number of requests accepted from a client per --burst-window, or 0 for no limit.")
+	serverCmd.PersistentFlags().Duration("burst-window", api.DefaultAbuseOptions.BurstWindow, "The window over which --burst-limit applies.")
+	serverCmd.PersistentFlags().Duration("burst-block-duration", api.DefaultAbuseOptions.BlockDuration, "How long to block clients exceeding --burst-limit, given --blocklist-file.")
+	serverCmd.PersistentFlags().Int("max-filter-length", api.DefaultAbuseOptions.MaxFilterLength, "The maximum length of the filter query parameter, or 0 for no limit.")
+	serverCmd.PersistentFlags().Int("max-page", api.DefaultAbuseOptions.MaxPage, "The maximum value of the page query parameter, or 0 for no limit.")
+	serverCmd.PersistentFlags().String("otlp-endpoint", "", "The optional base url of an OpenTelemetry collector's OTLP/HTTP receiver to which to export request traces, e.g. http://localhost:4318.")
+	serverCmd.PersistentFlags().Float64("trace-sample-ratio", 1, "The fraction of traces started by the server to record, given --otlp-endpoint. Traces continued from callers follow their sampling decision.")
+	serverCmd.PersistentFlags().String("translations-file", "", "The optional JSON file mapping locales to the display text of plugin labels, release stages, author types and error messages, selected by the Accept-Language header.")
 }
 
 var serverCmd = &cobra.Command{
@@ -38,47 +153,595 @@ var serverCmd = &cobra.Command{
 			logger.SetLevel(logrus.DebugLevel)
 		}
 
+		sentryDSN, _ := command.Flags().GetString("sentry-dsn")
+		if sentryDSN != "" {
+			reporter, err := reporting.NewSentryReporter(sentryDSN)
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize error reporting")
+			}
+			logger.AddHook(reporting.NewHook(reporter))
+			defer reporter.Flush(5 * time.Second)
+		}
+
+		maxIconSize, _ := command.Flags().GetInt("max-icon-size")
+		lenientIcons, _ := command.Flags().GetBool("lenient-icons")
+		staleAfter, _ := command.Flags().GetDuration("stale-after")
+		preReleaseTTL, _ := command.Flags().GetDuration("pre-release-ttl")
+		storeOptions := store.Options{
+			MaxIconSize:   maxIconSize,
+			LenientIcons:  lenientIcons,
+			StaleAfter:    staleAfter,
+			PreReleaseTTL: preReleaseTTL,
+		}
+		var featuredRegistry *featured.Registry
+		featuredFile, _ := command.Flags().GetString("featured-file")
+		if featuredFile != "" {
+			var err error
+			featuredRegistry, err = featured.New(&featured.FileBackend{Path: featuredFile})
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize featured plugins")
+			}
+			storeOptions.Featuring = featuredRegistry
+		}
+		strictRules, _ := command.Flags().GetString("strict-rules")
+		if strictRules != "" {
+			rulesFile, err := os.Open(strictRules)
+			if err != nil {
+				return errors.Wrap(err, "failed to open strict rules")
+			}
+			storeOptions.Rules, err = quality.ConfigFromReader(rulesFile)
+			rulesFile.Close()
+			if err != nil {
+				return errors.Wrapf(err, "failed to read strict rules from %s", strictRules)
+			}
+		}
+
 		database, _ := command.Flags().GetString("database")
-		databaseFile, err := os.Open(database)
-		if err != nil {
-			return errors.Wrapf(err, "failed to open %s", database)
+		appsDatabase, _ := command.Flags().GetString("apps-database")
+		reloadInterval, _ := command.Flags().GetDuration("reload-interval")
+		reloadDone := make(chan struct{})
+		defer close(reloadDone)
+		snapshotLimit, _ := command.Flags().GetInt("snapshot-limit")
+		catalogImport, _ := command.Flags().GetBool("catalog-import")
+		catalogOptions := catalog.Options{
+			SnapshotLimit: snapshotLimit,
+			StoreOptions:  storeOptions,
+		}
+
+		var tombstoneRegistry *tombstones.Registry
+		tombstonesFile, _ := command.Flags().GetString("tombstones-file")
+		if tombstonesFile != "" {
+			var err error
+			tombstoneRegistry, err = tombstones.New(&tombstones.FileBackend{Path: tombstonesFile})
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize tombstones")
+			}
+		}
+
+		secondaryDatabase, _ := command.Flags().GetString("secondary-database")
+		failoverRetryInterval, _ := command.Flags().GetDuration("failover-retry-interval")
+		failoverOptions := failover.Options{RetryInterval: failoverRetryInterval}
+
+		var pluginCatalog *catalog.Catalog
+		var pluginStore api.Store
+		upstream, _ := command.Flags().GetString("upstream")
+		if upstream != "" {
+			proxyStore, err := newProxyStore(command, upstream, catalogOptions)
+			if err != nil {
+				return err
+			}
+			pluginCatalog = proxyStore.Catalog()
+			pluginStore = proxyStore
+		} else {
+			fileStore, err := newFileStore(database, appsDatabase, storeOptions)
+			if err != nil && fallbackStore != nil {
+				logger.WithError(err).Error("Failed to load database, serving the embedded database")
+				fileStore, err = fallbackStore(storeOptions)
+			}
+			if err != nil {
+				return err
+			}
+
+			pluginCatalog = newCatalog(fileStore, database, catalogOptions, catalogImport)
+			if reloadInterval > 0 {
+				go reloadDatabase(database, appsDatabase, storeOptions, pluginCatalog, tombstoneRegistry, reloadInterval, reloadDone)
+			}
+			pluginStore, err = withSecondaryDatabase(pluginCatalog, secondaryDatabase, appsDatabase, storeOptions, failoverOptions, reloadInterval, reloadDone)
+			if err != nil {
+				return err
+			}
 		}
-		defer databaseFile.Close()
 
-		fileStore, err := store.New(databaseFile, logger)
+		notificationsFile, _ := command.Flags().GetString("notifications-file")
+		notifier, err := newNotifier(notificationsFile, logger)
 		if err != nil {
-			return errors.Wrap(err, "failed to initialize store")
+			return errors.Wrap(err, "failed to initialize notifications")
+		}
+		if notifier != nil {
+			go notifier.Watch(pluginCatalog, reloadDone)
+			defer notifier.Wait()
+		}
+
+		channelStores := map[string]api.Store{}
+		channels, _ := command.Flags().GetStringSlice("channel")
+		for _, channel := range channels {
+			name, channelDatabase, err := splitAssignment(channel)
+			if err != nil {
+				return errors.Wrap(err, "invalid --channel")
+			}
+			if _, ok := channelStores[name]; ok {
+				return errors.Errorf("channel %s given more than once", name)
+			}
+
+			channelStore, err := newFileStore(channelDatabase, appsDatabase, storeOptions)
+			if err != nil {
+				return errors.Wrapf(err, "failed to load channel %s", name)
+			}
+			channelCatalog := newCatalog(channelStore, channelDatabase, catalogOptions, catalogImport)
+			if reloadInterval > 0 {
+				go reloadDatabase(channelDatabase, appsDatabase, storeOptions, channelCatalog, nil, reloadInterval, reloadDone)
+			}
+			channelStores[name] = channelCatalog
+		}
+
+		channelHosts := map[string]string{}
+		channelHostAssignments, _ := command.Flags().GetStringSlice("channel-host")
+		for _, channelHost := range channelHostAssignments {
+			host, name, err := splitAssignment(channelHost)
+			if err != nil {
+				return errors.Wrap(err, "invalid --channel-host")
+			}
+			if _, ok := channelStores[name]; !ok {
+				return errors.Errorf("host %s selects unknown channel %s", host, name)
+			}
+			channelHosts[strings.ToLower(host)] = name
 		}
 
 		logger := logger.WithField("instance", instanceID)
 		logger.Info("Starting Plugin Marketplace")
 
+		iconStrippingThreshold, _ := command.Flags().GetInt("icon-stripping-threshold")
+		compatibilityServerVersions, _ := command.Flags().GetStringSlice("compatibility-server-versions")
+		apiContext := &api.Context{
+			Store:                       pluginStore,
+			Channels:                    channelStores,
+			ChannelHosts:                channelHosts,
+			IconStrippingThreshold:      iconStrippingThreshold,
+			CompatibilityServerVersions: compatibilityServerVersions,
+			Logger:                      logger,
+		}
+
+		responseCacheSize, _ := command.Flags().GetInt("response-cache-size")
+		if responseCacheSize > 0 {
+			apiContext.ResponseCache = api.NewResponseCache(responseCacheSize)
+		}
+
+		adminUI, _ := command.Flags().GetBool("admin-ui")
+		apiContext.AdminUI = adminUI
+
+		otlpEndpoint, _ := command.Flags().GetString("otlp-endpoint")
+		if otlpEndpoint != "" {
+			traceSampleRatio, _ := command.Flags().GetFloat64("trace-sample-ratio")
+			tracerProvider := tracing.NewProvider(tracing.Options{
+				Endpoint:    otlpEndpoint,
+				ServiceName: "mattermost-marketplace",
+				SampleRatio: traceSampleRatio,
+			})
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := tracerProvider.Shutdown(ctx); err != nil {
+					logger.WithError(err).Error("Failed to flush traces")
+				}
+			}()
+			apiContext.Tracer = tracerProvider.Tracer(tracing.InstrumentationName)
+		}
+
+		objectiveFlags, _ := command.Flags().GetStringSlice("slo")
+		objectives := make([]metrics.Objective, 0, len(objectiveFlags))
+		for _, objectiveFlag := range objectiveFlags {
+			objective, err := metrics.ParseObjective(objectiveFlag)
+			if err != nil {
+				return errors.Wrap(err, "invalid --slo")
+			}
+			objectives = append(objectives, objective)
+		}
+		recorder, err := metrics.NewRecorder(nil, objectives)
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize metrics")
+		}
+		apiContext.Metrics = recorder
+
+		auditInterval, _ := command.Flags().GetDuration("audit-interval")
+		if auditInterval > 0 {
+			auditor, err := newAuditor(command, notifier)
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize audits")
+			}
+			apiContext.Audit = auditor
+			go auditor.Run(pluginCatalog, auditInterval, reloadDone)
+		}
+
+		statsFile, _ := command.Flags().GetString("stats-file")
+		statsDone := make(chan struct{})
+		statsStopped := make(chan struct{})
+		if statsFile != "" {
+			tracker, err := stats.NewTracker(&stats.FileBackend{Path: statsFile})
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize stats")
+			}
+			apiContext.Stats = tracker
+
+			statsFlushInterval, _ := command.Flags().GetDuration("stats-flush-interval")
+			go func() {
+				defer close(statsStopped)
+				tracker.Run(statsFlushInterval, statsDone, func(err error) {
+					logger.WithError(err).Error("Failed to persist stats")
+				})
+			}()
+		} else {
+			close(statsStopped)
+		}
+
+		analyticsFile, _ := command.Flags().GetString("analytics-file")
+		analyticsStopped := make(chan struct{})
+		if analyticsFile != "" {
+			queryRecorder, err := analytics.NewRecorder(&analytics.FileBackend{Path: analyticsFile})
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize analytics")
+			}
+			apiContext.Analytics = queryRecorder
+
+			statsFlushInterval, _ := command.Flags().GetDuration("stats-flush-interval")
+			go func() {
+				defer close(analyticsStopped)
+				queryRecorder.Run(statsFlushInterval, statsDone, func(err error) {
+					logger.WithError(err).Error("Failed to persist analytics")
+				})
+			}()
+		} else {
+			close(analyticsStopped)
+		}
+
+		ratingsFile, _ := command.Flags().GetString("ratings-file")
+		if ratingsFile != "" {
+			aggregator, err := ratings.NewAggregator(&ratings.FileBackend{Path: ratingsFile})
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize ratings")
+			}
+			apiContext.Ratings = aggregator
+		}
+
+		submissionsFile, _ := command.Flags().GetString("submissions-file")
+		if submissionsFile != "" {
+			queue, err := submissions.NewQueue(&submissions.FileBackend{Path: submissionsFile})
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize submissions")
+			}
+			apiContext.Submissions = queue
+		}
+
+		apiKeysFile, _ := command.Flags().GetString("api-keys-file")
+		apiKeysDone := make(chan struct{})
+		apiKeysStopped := make(chan struct{})
+		if apiKeysFile != "" {
+			registry, err := apikeys.NewRegistry(&apikeys.FileBackend{Path: apiKeysFile})
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize api keys")
+			}
+			apiContext.APIKeys = registry
+
+			apiKeysFlushInterval, _ := command.Flags().GetDuration("api-keys-flush-interval")
+			go func() {
+				defer close(apiKeysStopped)
+				registry.Run(apiKeysFlushInterval, apiKeysDone, func(err error) {
+					logger.WithError(err).Error("Failed to persist api key usage")
+				})
+			}()
+		} else {
+			close(apiKeysStopped)
+		}
+
+		moderators, _ := command.Flags().GetStringSlice("moderators")
+		apiContext.Moderators = map[string]bool{}
+		for _, moderator := range moderators {
+			apiContext.Moderators[moderator] = true
+		}
+
+		apiContext.TrustForwardedFor, _ = command.Flags().GetBool("trust-forwarded-for")
+		writeAllowedCIDRs, _ := command.Flags().GetStringSlice("write-allowed-cidrs")
+		if len(writeAllowedCIDRs) > 0 {
+			allowlist, err := api.ParseIPAllowlist(writeAllowedCIDRs)
+			if err != nil {
+				return errors.Wrap(err, "failed to parse write allowlist")
+			}
+			allowlist.TrustForwardedFor = apiContext.TrustForwardedFor
+			apiContext.WriteAllowlist = allowlist
+		}
+
+		authTokensFile, _ := command.Flags().GetString("auth-tokens-file")
+		if authTokensFile != "" {
+			tokensFile, err := os.Open(authTokensFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to open %s", authTokensFile)
+			}
+			defer tokensFile.Close()
+
+			authenticator, err := api.TokenAuthenticatorFromReader(tokensFile)
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize authentication")
+			}
+			apiContext.Authenticator = authenticator
+		}
+
+		oidcIssuer, _ := command.Flags().GetString("oidc-issuer")
+		if oidcIssuer != "" {
+			oidcAudience, _ := command.Flags().GetString("oidc-audience")
+			oidcRequiredClaims, _ := command.Flags().GetStringToString("oidc-required-claims")
+			oidcUserClaim, _ := command.Flags().GetString("oidc-user-claim")
+			oidcJWKSURL, _ := command.Flags().GetString("oidc-jwks-url")
+			oidcAuthenticator, err := oidc.New(logger, oidc.Options{
+				Issuer:         oidcIssuer,
+				Audience:       oidcAudience,
+				RequiredClaims: oidcRequiredClaims,
+				UserClaim:      oidcUserClaim,
+				JWKSURL:        oidcJWKSURL,
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize openid connect authentication")
+			}
+
+			if apiContext.Authenticator != nil {
+				apiContext.Authenticator = api.MultiAuthenticator{apiContext.Authenticator, oidcAuthenticator}
+			} else {
+				apiContext.Authenticator = oidcAuthenticator
+			}
+		}
+
+		translationsFile, _ := command.Flags().GetString("translations-file")
+		if translationsFile != "" {
+			translationsReader, err := os.Open(translationsFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to open %s", translationsFile)
+			}
+			defer translationsReader.Close()
+
+			translations, err := api.TranslationsFromReader(translationsReader)
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize translations")
+			}
+			apiContext.Translations = translations
+		}
+
+		trustForwardedFor, _ := command.Flags().GetBool("trust-forwarded-for")
+		abuseOptions := api.AbuseOptions{TrustForwardedFor: trustForwardedFor}
+		abuseOptions.BurstLimit, _ = command.Flags().GetInt("burst-limit")
+		abuseOptions.BurstWindow, _ = command.Flags().GetDuration("burst-window")
+		abuseOptions.BlockDuration, _ = command.Flags().GetDuration("burst-block-duration")
+		abuseOptions.MaxFilterLength, _ = command.Flags().GetInt("max-filter-length")
+		abuseOptions.MaxPage, _ = command.Flags().GetInt("max-page")
+		if abuseOptions.BurstLimit > 0 && abuseOptions.BurstWindow <= 0 {
+			return errors.New("--burst-window must be positive given --burst-limit")
+		}
+
+		blocklistFile, _ := command.Flags().GetString("blocklist-file")
+		if blocklistFile != "" {
+			clientBlocklist, err := blocklist.New(&blocklist.FileBackend{Path: blocklistFile})
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize blocklist")
+			}
+			apiContext.Blocklist = clientBlocklist
+			abuseOptions.Blocklist = clientBlocklist
+		}
+
+		advisoriesFile, _ := command.Flags().GetString("advisories-file")
+		if advisoriesFile != "" {
+			registry, err := advisories.New(&advisories.FileBackend{Path: advisoriesFile})
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize advisories")
+			}
+			apiContext.Advisories = registry
+			if notifier != nil {
+				apiContext.Advisories = &notifyingAdvisories{Advisories: registry, notifier: notifier}
+			}
+		}
+		if featuredRegistry != nil {
+			apiContext.Featuring = featuredRegistry
+		}
+		if tombstoneRegistry != nil {
+			apiContext.Tombstones = tombstoneRegistry
+		}
+
+		rehostDirectory, _ := command.Flags().GetString("rehost-directory")
+		if rehostDirectory != "" {
+			host, err := rehost.New(rehostDirectory)
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize bundle re-hosting")
+			}
+			apiContext.Bundles = host
+		}
+
+		if downloadSigningKey := os.Getenv("DOWNLOAD_SIGNING_KEY"); downloadSigningKey != "" {
+			if rehostDirectory == "" {
+				return errors.New("$DOWNLOAD_SIGNING_KEY requires --rehost-directory")
+			}
+			downloadURLTTL, _ := command.Flags().GetDuration("download-url-ttl")
+			downloadSigner, err := presign.New([]byte(downloadSigningKey), downloadURLTTL)
+			if err != nil {
+				return errors.Wrap(err, "failed to initialize download signing")
+			}
+			apiContext.DownloadSigner = downloadSigner
+		}
+
+		catalogSigner, err := newCatalogSigner(command)
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize catalog signing")
+		}
+		if catalogSigner != nil {
+			apiContext.CatalogSigner = catalogSigner
+		}
+
+		signingKeys, err := newSigningKeys(command, catalogSigner)
+		if err != nil {
+			return errors.Wrap(err, "failed to load signing public keys")
+		}
+		apiContext.SigningKeys = signingKeys
+
+		var tenantStatsStopped sync.WaitGroup
+		tenantsFile, _ := command.Flags().GetString("tenants-file")
+		var tenants []*api.Tenant
+		if tenantsFile != "" {
+			tenantConfigs, err := loadTenantConfigs(tenantsFile)
+			if err != nil {
+				return errors.Wrap(err, "failed to load tenants")
+			}
+
+			statsFlushInterval, _ := command.Flags().GetDuration("stats-flush-interval")
+			for _, tenantConfig := range tenantConfigs {
+				tenant, err := newTenant(tenantConfig, tenantOptions{
+					storeOptions:                storeOptions,
+					catalogOptions:              catalogOptions,
+					failoverOptions:             failoverOptions,
+					catalogImport:               catalogImport,
+					reloadInterval:              reloadInterval,
+					reloadDone:                  reloadDone,
+					statsFlushInterval:          statsFlushInterval,
+					statsDone:                   statsDone,
+					statsStopped:                &tenantStatsStopped,
+					trustForwardedFor:           trustForwardedFor,
+					metrics:                     recorder,
+					tracer:                      apiContext.Tracer,
+					compatibilityServerVersions: compatibilityServerVersions,
+					responseCacheSize:           responseCacheSize,
+					catalogSigner:               apiContext.CatalogSigner,
+					signingKeys:                 apiContext.SigningKeys,
+					adminUI:                     adminUI,
+				})
+				if err != nil {
+					return err
+				}
+				tenants = append(tenants, tenant)
+			}
+		}
+
 		router := mux.NewRouter()
 
-		api.Register(router, &api.Context{
-			Store:  fileStore,
-			Logger: logger,
-		})
+		if err := api.RegisterTenants(router, tenants); err != nil {
+			return errors.Wrap(err, "invalid tenants")
+		}
+		api.Register(router, apiContext)
+
+		maxBodySize, _ := command.Flags().GetInt64("max-body-size")
+		maxURLLength, _ := command.Flags().GetInt("max-url-length")
+		maxHeaderSize, _ := command.Flags().GetInt("max-header-size")
+
+		var tlsConfig *tls.Config
+		tlsOptions := api.TLSOptions{}
+		tlsOptions.CertFile, _ = command.Flags().GetString("tls-cert-file")
+		tlsOptions.KeyFile, _ = command.Flags().GetString("tls-key-file")
+		tlsOptions.MinVersion, _ = command.Flags().GetString("tls-min-version")
+		tlsOptions.CipherSuites, _ = command.Flags().GetStringSlice("tls-cipher-suites")
+		tlsOptions.ClientCAFile, _ = command.Flags().GetString("tls-client-ca-file")
+		tlsOptions.ClientAuth, _ = command.Flags().GetString("tls-client-auth")
+		if tlsOptions.CertFile != "" || tlsOptions.KeyFile != "" {
+			tlsConfig, err = api.NewTLSConfig(tlsOptions)
+			if err != nil {
+				return errors.Wrap(err, "failed to configure tls")
+			}
+		} else if len(tlsOptions.CipherSuites) > 0 || tlsOptions.ClientCAFile != "" || tlsOptions.ClientAuth != "" {
+			return errors.New("--tls-cert-file and --tls-key-file are required to configure tls")
+		}
 
 		listen, _ := command.Flags().GetString("listen")
 		srv := &http.Server{
-			Addr:           listen,
-			Handler:        router,
-			ReadTimeout:    10 * time.Second,
-			WriteTimeout:   10 * time.Second,
-			IdleTimeout:    time.Second * 60,
-			MaxHeaderBytes: 1 << 20,
+			Addr: listen,
+			Handler: api.NewLimitHandler(api.NewAbuseHandler(router, abuseOptions, logger), api.Limits{
+				MaxBodySize:  maxBodySize,
+				MaxURLLength: maxURLLength,
+			}),
+			MaxHeaderBytes: maxHeaderSize,
 			ErrorLog:       log.New(&logrusWriter{logger}, "", 0),
+			TLSConfig:      tlsConfig,
+		}
+
+		serverOptions := api.ServerOptions{}
+		serverOptions.ReadTimeout, _ = command.Flags().GetDuration("read-timeout")
+		serverOptions.WriteTimeout, _ = command.Flags().GetDuration("write-timeout")
+		serverOptions.IdleTimeout, _ = command.Flags().GetDuration("idle-timeout")
+		serverOptions.DisableKeepAlives, _ = command.Flags().GetBool("disable-keep-alives")
+		serverOptions.DisableHTTP2, _ = command.Flags().GetBool("disable-http2")
+		serverOptions.MaxConcurrentStreams, _ = command.Flags().GetUint32("http2-max-concurrent-streams")
+		if err := api.ConfigureServer(srv, serverOptions); err != nil {
+			return errors.Wrap(err, "failed to configure server")
 		}
 
 		go func() {
-			logger.WithField("addr", srv.Addr).Info("Listening")
-			err := srv.ListenAndServe()
+			logger.WithFields(logrus.Fields{"addr": srv.Addr, "tls": tlsConfig != nil}).Info("Listening")
+			var err error
+			if tlsConfig != nil {
+				// The certificates are already loaded into the TLS configuration.
+				err = srv.ListenAndServeTLS("", "")
+			} else {
+				err = srv.ListenAndServe()
+			}
 			if err != nil && err != http.ErrServerClosed {
 				logger.WithField("err", err).Error("Failed to listen and serve")
 			}
 		}()
 
+		var grpcServer *grpc.Server
+		grpcListen, _ := command.Flags().GetString("grpc-listen")
+		if grpcListen != "" {
+			grpcListener, err := net.Listen("tcp", grpcListen)
+			if err != nil {
+				return errors.Wrapf(err, "failed to listen on %s", grpcListen)
+			}
+
+			var grpcOptions []grpc.ServerOption
+			if tlsConfig != nil {
+				grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+			}
+			grpcServer = grpc.NewServer(grpcOptions...)
+			grpcapi.Register(grpcServer, &grpcapi.Server{
+				Store:   pluginStore,
+				Watcher: pluginCatalog,
+				Logger:  logger,
+			})
+
+			go func() {
+				logger.WithField("addr", grpcListen).Info("Serving gRPC")
+				if err := grpcServer.Serve(grpcListener); err != nil {
+					logger.WithField("err", err).Error("Failed to serve gRPC")
+				}
+			}()
+		}
+
+		var adminSrv *http.Server
+		adminListen, _ := command.Flags().GetString("admin-listen")
+		if adminListen != "" {
+			adminRouter := mux.NewRouter()
+			api.RegisterDiagnostics(adminRouter, instanceID)
+
+			adminSrv = &http.Server{
+				Addr:    adminListen,
+				Handler: adminRouter,
+				// CPU profiles and traces stream for as long as requested.
+				ReadTimeout:    10 * time.Second,
+				WriteTimeout:   5 * time.Minute,
+				IdleTimeout:    time.Second * 60,
+				MaxHeaderBytes: 1 << 20,
+				ErrorLog:       log.New(&logrusWriter{logger}, "", 0),
+			}
+
+			go func() {
+				logger.WithField("addr", adminSrv.Addr).Info("Serving diagnostics")
+				err := adminSrv.ListenAndServe()
+				if err != nil && err != http.ErrServerClosed {
+					logger.WithField("err", err).Error("Failed to serve diagnostics")
+				}
+			}()
+		}
+
 		c := make(chan os.Signal, 1)
 		// We'll accept graceful shutdowns when quit via SIGINT (Ctrl+C)
 		// SIGKILL, SIGQUIT or SIGTERM (Ctrl+/) will not be caught.
@@ -91,7 +754,196 @@ var serverCmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
 		srv.Shutdown(ctx)
+		if adminSrv != nil {
+			adminSrv.Shutdown(ctx)
+		}
+		if grpcServer != nil {
+			// Watch streams never complete, so stop rather than waiting for them.
+			grpcServer.Stop()
+		}
+
+		close(statsDone)
+		<-statsStopped
+		<-analyticsStopped
+		tenantStatsStopped.Wait()
+		close(apiKeysDone)
+		<-apiKeysStopped
 
 		return nil
 	},
 }
+
+// newFileStore loads a store from the given database, or from the directory of a split database,
+// along with the given apps database, if any.
+func newFileStore(database, appsDatabase string, options store.Options) (*store.Store, error) {
+	fileStore, err := loadDatabase(database, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if appsDatabase != "" {
+		appsDatabaseFile, err := os.Open(appsDatabase)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open %s", appsDatabase)
+		}
+		defer appsDatabaseFile.Close()
+
+		if err := fileStore.LoadApps(appsDatabaseFile); err != nil {
+			return nil, errors.Wrap(err, "failed to load apps")
+		}
+	}
+
+	return fileStore, nil
+}
+
+// loadDatabase loads a store from the given database file or split database directory.
+func loadDatabase(database string, options store.Options) (*store.Store, error) {
+	info, err := os.Stat(database)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", database)
+	}
+
+	if info.IsDir() {
+		plugins, err := model.ReadSplitDatabase(database)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", database)
+		}
+
+		fileStore, err := store.NewFromPlugins(plugins, logger, options)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initialize store")
+		}
+		return fileStore, nil
+	}
+
+	databaseFile, err := os.Open(database)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", database)
+	}
+	defer databaseFile.Close()
+
+	fileStore, err := store.NewWithOptions(databaseFile, logger, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize store")
+	}
+
+	return fileStore, nil
+}
+
+// newCatalog creates a catalog serving the given store loaded from the given database, persisting
+// imports to the database if they are allowed.
+func newCatalog(fileStore *store.Store, database string, options catalog.Options, allowImport bool) *catalog.Catalog {
+	if allowImport {
+		options.Backend = &catalog.FileBackend{Path: database}
+	}
+
+	return catalog.NewWithOptions(fileStore, options)
+}
+
+// withSecondaryDatabase returns a store serving the given catalog, failing over to the given
+// secondary database, if any, which is reloaded as the catalog's database is.
+func withSecondaryDatabase(pluginCatalog *catalog.Catalog, secondaryDatabase, appsDatabase string, storeOptions store.Options, failoverOptions failover.Options, reloadInterval time.Duration, reloadDone <-chan struct{}) (api.Store, error) {
+	if secondaryDatabase == "" {
+		return pluginCatalog, nil
+	}
+
+	secondaryStore, err := newFileStore(secondaryDatabase, appsDatabase, storeOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load secondary database")
+	}
+	secondaryCatalog := catalog.New(secondaryStore)
+	if reloadInterval > 0 {
+		go reloadDatabase(secondaryDatabase, appsDatabase, storeOptions, secondaryCatalog, nil, reloadInterval, reloadDone)
+	}
+
+	return failover.New(pluginCatalog, secondaryCatalog, logger.WithField("database", secondaryDatabase), failoverOptions), nil
+}
+
+// newProxyStore creates a store serving the plugins of the given upstream marketplace, cached in
+// --upstream-cache-file, if any.
+func newProxyStore(command *cobra.Command, upstream string, catalogOptions catalog.Options) (*proxy.Store, error) {
+	cacheFile, _ := command.Flags().GetString("upstream-cache-file")
+	refreshInterval, _ := command.Flags().GetDuration("upstream-refresh-interval")
+	maxRetryInterval, _ := command.Flags().GetDuration("upstream-max-retry-interval")
+	refreshWait, _ := command.Flags().GetDuration("upstream-refresh-wait")
+
+	client := api.NewClient(strings.TrimSuffix(upstream, "/"))
+	client.UserAgent = "mattermost-marketplace"
+	proxyStore, err := proxy.New(client, logger.WithField("upstream", upstream), proxy.Options{
+		CacheFile:        cacheFile,
+		RefreshInterval:  refreshInterval,
+		MaxRetryInterval: maxRetryInterval,
+		RefreshWait:      refreshWait,
+		CatalogOptions:   catalogOptions,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to proxy %s", upstream)
+	}
+
+	return proxyStore, nil
+}
+
+// newCatalogSigner creates a signer over the key read from --catalog-signing-keyring, or returns
+// nil if none is given.
+func newCatalogSigner(command *cobra.Command) (*signing.OpenPGPSigner, error) {
+	keyringPath, _ := command.Flags().GetString("catalog-signing-keyring")
+	if keyringPath == "" {
+		return nil, nil
+	}
+
+	keyring, err := os.Open(keyringPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", keyringPath)
+	}
+	defer keyring.Close()
+
+	keyID, _ := command.Flags().GetString("catalog-signing-key-id")
+	signer, err := signing.NewKeyringSigner(keyring, keyID, []byte(os.Getenv("CATALOG_SIGNING_KEYRING_PASSPHRASE")))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load signing key from %s", keyringPath)
+	}
+
+	return signer, nil
+}
+
+// newSigningKeys describes the public keys read from --signing-public-keys, along with that of
+// the given catalog signer, if any, or returns nil if there are none to serve.
+func newSigningKeys(command *cobra.Command, catalogSigner *signing.OpenPGPSigner) ([]*model.PublicKey, error) {
+	paths, _ := command.Flags().GetStringSlice("signing-public-keys")
+
+	var readers []io.Reader
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", path)
+		}
+		readers = append(readers, bytes.NewReader(data))
+	}
+	if catalogSigner != nil {
+		var publicKey bytes.Buffer
+		if err := catalogSigner.WritePublicKey(&publicKey); err != nil {
+			return nil, errors.Wrap(err, "failed to write catalog signing key")
+		}
+		readers = append(readers, &publicKey)
+	}
+	if len(readers) == 0 {
+		return nil, nil
+	}
+
+	keyring, err := api.ReadPublicKeys(readers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.DescribePublicKeys(keyring)
+}
+
+// splitAssignment splits a key=value flag value into its non-empty key and value.
+func splitAssignment(assignment string) (string, string, error) {
+	parts := strings.SplitN(assignment, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.Errorf("%s is not of the form key=value", assignment)
+	}
+
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-marketplace/internal/advisories"
+	"github.com/mattermost/mattermost-marketplace/internal/analytics"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/failover"
+	"github.com/mattermost/mattermost-marketplace/internal/featured"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/ratings"
+	"github.com/mattermost/mattermost-marketplace/internal/stats"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/submissions"
+	"github.com/mattermost/mattermost-marketplace/internal/tombstones"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tenantConfig configures a tenant served alongside the default catalog, mirroring the server
+// flags of the same names.
+type tenantConfig struct {
+	Name string `json:"name"`
+	// Hosts select the tenant by the host to which requests are addressed.
+	Hosts []string `json:"hosts,omitempty"`
+	// PathPrefix selects the tenant by the path under which requests are made, e.g. /tenants/acme.
+	PathPrefix   string `json:"path_prefix,omitempty"`
+	Database     string `json:"database"`
+	AppsDatabase string `json:"apps_database,omitempty"`
+	// SecondaryDatabase serves the tenant's default catalog whenever its database fails.
+	SecondaryDatabase string `json:"secondary_database,omitempty"`
+	// Channels maps the name of each additional catalog served to the tenant to its database.
+	Channels          map[string]string `json:"channels,omitempty"`
+	StatsFile         string            `json:"stats_file,omitempty"`
+	AnalyticsFile     string            `json:"analytics_file,omitempty"`
+	RatingsFile       string            `json:"ratings_file,omitempty"`
+	SubmissionsFile   string            `json:"submissions_file,omitempty"`
+	AuthTokensFile    string            `json:"auth_tokens_file,omitempty"`
+	AdvisoriesFile    string            `json:"advisories_file,omitempty"`
+	FeaturedFile      string            `json:"featured_file,omitempty"`
+	TombstonesFile    string            `json:"tombstones_file,omitempty"`
+	NotificationsFile string            `json:"notifications_file,omitempty"`
+	Moderators        []string          `json:"moderators,omitempty"`
+	// WriteAllowedCIDRs honor --trust-forwarded-for, as for the default catalog.
+	WriteAllowedCIDRs      []string `json:"write_allowed_cidrs,omitempty"`
+	TranslationsFile       string   `json:"translations_file,omitempty"`
+	IconStrippingThreshold int      `json:"icon_stripping_threshold,omitempty"`
+}
+
+// tenantOptions holds the server configuration shared by every tenant.
+type tenantOptions struct {
+	storeOptions                store.Options
+	catalogOptions              catalog.Options
+	failoverOptions             failover.Options
+	catalogImport               bool
+	reloadInterval              time.Duration
+	reloadDone                  <-chan struct{}
+	statsFlushInterval          time.Duration
+	statsDone                   <-chan struct{}
+	statsStopped                *sync.WaitGroup
+	trustForwardedFor           bool
+	metrics                     api.Metrics
+	tracer                      trace.Tracer
+	compatibilityServerVersions []string
+	catalogSigner               api.CatalogSigner
+	signingKeys                 []*model.PublicKey
+	responseCacheSize           int
+	adminUI                     bool
+}
+
+// loadTenantConfigs decodes the json-encoded list of tenants in the given file.
+func loadTenantConfigs(path string) ([]*tenantConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer file.Close()
+
+	var configs []*tenantConfig
+	if err := json.NewDecoder(file).Decode(&configs); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+
+	return configs, nil
+}
+
+// newTenant loads the catalogs and initializes the features configured for the given tenant.
+func newTenant(config *tenantConfig, options tenantOptions) (*api.Tenant, error) {
+	if config.Database == "" {
+		return nil, errors.Errorf("tenant %s has no database", config.Name)
+	}
+
+	logger := logger.WithField("tenant", config.Name)
+
+	// Moderators feature plugins for each tenant apart, never for the default catalog.
+	var featuredRegistry *featured.Registry
+	options.storeOptions.Featuring = nil
+	options.catalogOptions.StoreOptions.Featuring = nil
+	if config.FeaturedFile != "" {
+		var err error
+		featuredRegistry, err = featured.New(&featured.FileBackend{Path: config.FeaturedFile})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize featured plugins of tenant %s", config.Name)
+		}
+		options.storeOptions.Featuring = featuredRegistry
+		options.catalogOptions.StoreOptions.Featuring = featuredRegistry
+	}
+
+	fileStore, err := newFileStore(config.Database, config.AppsDatabase, options.storeOptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load tenant %s", config.Name)
+	}
+	var tombstoneRegistry *tombstones.Registry
+	if config.TombstonesFile != "" {
+		tombstoneRegistry, err = tombstones.New(&tombstones.FileBackend{Path: config.TombstonesFile})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize tombstones of tenant %s", config.Name)
+		}
+	}
+
+	pluginCatalog := newCatalog(fileStore, config.Database, options.catalogOptions, options.catalogImport)
+	if options.reloadInterval > 0 {
+		go reloadDatabase(config.Database, config.AppsDatabase, options.storeOptions, pluginCatalog, tombstoneRegistry, options.reloadInterval, options.reloadDone)
+	}
+	notifier, err := newNotifier(config.NotificationsFile, logger)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to initialize notifications of tenant %s", config.Name)
+	}
+	if notifier != nil {
+		go notifier.Watch(pluginCatalog, options.reloadDone)
+	}
+	pluginStore, err := withSecondaryDatabase(pluginCatalog, config.SecondaryDatabase, config.AppsDatabase, options.storeOptions, options.failoverOptions, options.reloadInterval, options.reloadDone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load tenant %s", config.Name)
+	}
+
+	channelStores := map[string]api.Store{}
+	for name, channelDatabase := range config.Channels {
+		channelStore, err := newFileStore(channelDatabase, config.AppsDatabase, options.storeOptions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load channel %s of tenant %s", name, config.Name)
+		}
+		channelCatalog := newCatalog(channelStore, channelDatabase, options.catalogOptions, options.catalogImport)
+		if options.reloadInterval > 0 {
+			go reloadDatabase(channelDatabase, config.AppsDatabase, options.storeOptions, channelCatalog, nil, options.reloadInterval, options.reloadDone)
+		}
+		channelStores[name] = channelCatalog
+	}
+
+	tenantContext := &api.Context{
+		Store:                       pluginStore,
+		Channels:                    channelStores,
+		Metrics:                     options.metrics,
+		Tracer:                      options.tracer,
+		Moderators:                  map[string]bool{},
+		IconStrippingThreshold:      config.IconStrippingThreshold,
+		CompatibilityServerVersions: options.compatibilityServerVersions,
+		CatalogSigner:               options.catalogSigner,
+		SigningKeys:                 options.signingKeys,
+		AdminUI:                     options.adminUI,
+		Logger:                      logger,
+	}
+	for _, moderator := range config.Moderators {
+		tenantContext.Moderators[moderator] = true
+	}
+	if options.responseCacheSize > 0 {
+		tenantContext.ResponseCache = api.NewResponseCache(options.responseCacheSize)
+	}
+
+	if config.StatsFile != "" {
+		tracker, err := stats.NewTracker(&stats.FileBackend{Path: config.StatsFile})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize stats of tenant %s", config.Name)
+		}
+		tenantContext.Stats = tracker
+
+		options.statsStopped.Add(1)
+		go func() {
+			defer options.statsStopped.Done()
+			tracker.Run(options.statsFlushInterval, options.statsDone, func(err error) {
+				logger.WithError(err).Error("Failed to persist stats")
+			})
+		}()
+	}
+
+	if config.AnalyticsFile != "" {
+		queryRecorder, err := analytics.NewRecorder(&analytics.FileBackend{Path: config.AnalyticsFile})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize analytics of tenant %s", config.Name)
+		}
+		tenantContext.Analytics = queryRecorder
+
+		options.statsStopped.Add(1)
+		go func() {
+			defer options.statsStopped.Done()
+			queryRecorder.Run(options.statsFlushInterval, options.statsDone, func(err error) {
+				logger.WithError(err).Error("Failed to persist analytics")
+			})
+		}()
+	}
+
+	if config.RatingsFile != "" {
+		aggregator, err := ratings.NewAggregator(&ratings.FileBackend{Path: config.RatingsFile})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize ratings of tenant %s", config.Name)
+		}
+		tenantContext.Ratings = aggregator
+	}
+
+	if config.SubmissionsFile != "" {
+		queue, err := submissions.NewQueue(&submissions.FileBackend{Path: config.SubmissionsFile})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize submissions of tenant %s", config.Name)
+		}
+		tenantContext.Submissions = queue
+	}
+
+	if featuredRegistry != nil {
+		tenantContext.Featuring = featuredRegistry
+	}
+	if config.AdvisoriesFile != "" {
+		registry, err := advisories.New(&advisories.FileBackend{Path: config.AdvisoriesFile})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize advisories of tenant %s", config.Name)
+		}
+		tenantContext.Advisories = registry
+		if notifier != nil {
+			tenantContext.Advisories = &notifyingAdvisories{Advisories: registry, notifier: notifier}
+		}
+	}
+
+	if tombstoneRegistry != nil {
+		tenantContext.Tombstones = tombstoneRegistry
+	}
+
+	tenantContext.TrustForwardedFor = options.trustForwardedFor
+	if len(config.WriteAllowedCIDRs) > 0 {
+		allowlist, err := api.ParseIPAllowlist(config.WriteAllowedCIDRs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse write allowlist of tenant %s", config.Name)
+		}
+		allowlist.TrustForwardedFor = options.trustForwardedFor
+		tenantContext.WriteAllowlist = allowlist
+	}
+
+	if config.AuthTokensFile != "" {
+		tokensFile, err := os.Open(config.AuthTokensFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open %s", config.AuthTokensFile)
+		}
+		defer tokensFile.Close()
+
+		authenticator, err := api.TokenAuthenticatorFromReader(tokensFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize authentication of tenant %s", config.Name)
+		}
+		tenantContext.Authenticator = authenticator
+	}
+
+	if config.TranslationsFile != "" {
+		translationsReader, err := os.Open(config.TranslationsFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open %s", config.TranslationsFile)
+		}
+		defer translationsReader.Close()
+
+		translations, err := api.TranslationsFromReader(translationsReader)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize translations of tenant %s", config.Name)
+		}
+		tenantContext.Translations = translations
+	}
+
+	hosts := make([]string, 0, len(config.Hosts))
+	for _, host := range config.Hosts {
+		hosts = append(hosts, strings.ToLower(host))
+	}
+
+	return &api.Tenant{
+		Name:       config.Name,
+		Hosts:      hosts,
+		PathPrefix: config.PathPrefix,
+		Context:    tenantContext,
+	}, nil
+}
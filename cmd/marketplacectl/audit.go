@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/audit"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	auditCmd.Flags().Int("sample-size", audit.DefaultSampleSize, "The number of plugin versions to re-verify, chosen at random.")
+	auditCmd.Flags().StringSlice("keyring", nil, "A public key file trusted to sign plugin bundles, whose signatures are verified. May be repeated.")
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Re-verify a sample of the plugin versions served, reporting those whose bundles drifted.",
+	Args:  cobra.NoArgs,
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command)
+		if err != nil {
+			return err
+		}
+
+		keyringPaths, _ := command.Flags().GetStringSlice("keyring")
+		keyring, err := readPublicKeys(keyringPaths)
+		if err != nil {
+			return err
+		}
+
+		client := newClient(command)
+		latest, err := client.PluginsPager(&api.GetPluginsRequest{}).All()
+		if err != nil {
+			return errors.Wrap(err, "failed to get plugins")
+		}
+		var plugins []*model.Plugin
+		for _, plugin := range latest {
+			versions, err := client.GetPluginVersions(plugin.Manifest.Id)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get versions of %s", plugin.Manifest.Id)
+			}
+			plugins = append(plugins, versions...)
+		}
+
+		sampleSize, _ := command.Flags().GetInt("sample-size")
+		auditor := audit.New(logger, audit.Options{
+			SampleSize: sampleSize,
+			Keyring:    keyring,
+			Client:     client,
+		})
+		report := auditor.Audit(plugins)
+
+		if err := printAuditReport(os.Stdout, format, report); err != nil {
+			return err
+		}
+		if len(report.Findings) > 0 {
+			return errors.Errorf("%d of %d plugin versions checked drifted", countDrifted(report), report.Checked)
+		}
+
+		return nil
+	},
+}
+
+// countDrifted counts the plugin versions with findings in the given report.
+func countDrifted(report *model.AuditReport) int {
+	drifted := map[string]bool{}
+	for _, finding := range report.Findings {
+		drifted[finding.PluginID+"@"+finding.Version] = true
+	}
+
+	return len(drifted)
+}
+
+// printAuditReport writes the findings of the given report in the given format, one per row in a
+// table.
+func printAuditReport(w io.Writer, format string, report *model.AuditReport) error {
+	if format != formatTable {
+		return printData(w, format, report)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tVERSION\tCHECK\tERROR")
+	for _, finding := range report.Findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", finding.PluginID, finding.Version, finding.Check, finding.Error)
+	}
+
+	return tw.Flush()
+}
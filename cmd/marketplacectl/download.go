@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+)
+
+func init() {
+	downloadCmd.Flags().String("version", "", "The version to download, defaulting to the latest.")
+	downloadCmd.Flags().String("output-file", "", "The file to write the bundle to, defaulting to the name in its download url.")
+	downloadCmd.Flags().StringSlice("public-key", nil, "A public key file used to verify the bundle signature. May be repeated.")
+}
+
+// downloadResult describes a downloaded bundle, as printed in the machine-readable formats.
+type downloadResult struct {
+	PluginID string `json:"plugin_id"`
+	Version  string `json:"version"`
+	Path     string `json:"path"`
+}
+
+var downloadCmd = &cobra.Command{
+	Use:   "download <id>",
+	Short: "Download the bundle of a plugin.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command)
+		if err != nil {
+			return err
+		}
+
+		client := newClient(command)
+
+		publicKeyPaths, _ := command.Flags().GetStringSlice("public-key")
+		if len(publicKeyPaths) > 0 {
+			keyring, err := readPublicKeys(publicKeyPaths)
+			if err != nil {
+				return err
+			}
+			client.PublicKeys = keyring
+		}
+
+		version, _ := command.Flags().GetString("version")
+		plugin, err := api.FindPlugin(client, args[0], version)
+		if err != nil {
+			return err
+		}
+
+		if plugin.DownloadURL == "" {
+			return errors.Errorf("plugin %s has no download url", plugin.Manifest.Id)
+		}
+
+		output, _ := command.Flags().GetString("output-file")
+		if output == "" {
+			downloadURL, err := url.Parse(plugin.DownloadURL)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse download url %s", plugin.DownloadURL)
+			}
+			output = path.Base(downloadURL.Path)
+		}
+
+		file, err := os.Create(output)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s", output)
+		}
+
+		err = client.DownloadPlugin(plugin, file)
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = errors.Wrapf(closeErr, "failed to write %s", output)
+		}
+		if err != nil {
+			// A bundle failing verification must not be left behind for installation.
+			os.Remove(output)
+			return errors.Wrapf(err, "failed to download plugin %s", plugin.Manifest.Id)
+		}
+
+		if format != formatTable {
+			return printData(os.Stdout, format, &downloadResult{
+				PluginID: plugin.Manifest.Id,
+				Version:  plugin.Manifest.Version,
+				Path:     output,
+			})
+		}
+
+		fmt.Println(output)
+
+		return nil
+	},
+}
+
+// readPublicKeys reads the public keys at the given paths into a single keyring.
+func readPublicKeys(paths []string) (openpgp.EntityList, error) {
+	var files []*os.File
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	var readers []io.Reader
+	for _, keyPath := range paths {
+		file, err := os.Open(keyPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open public key %s", keyPath)
+		}
+		files = append(files, file)
+		readers = append(readers, file)
+	}
+
+	return api.ReadPublicKeys(readers...)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	installCmd.Flags().String("mattermost-url", "", "The site url of the Mattermost server on which to install the plugin.")
+	installCmd.Flags().String("mattermost-token", "", "The access token of a system admin of the Mattermost server, defaulting to $MATTERMOST_TOKEN.")
+	installCmd.Flags().String("version", "", "The version to install, defaulting to the latest compatible with the Mattermost server.")
+	installCmd.Flags().String("platform", "", "The platform of the bundle to install, e.g. linux-amd64, defaulting to the bundle for every platform.")
+	installCmd.Flags().StringSlice("public-key", nil, "A public key file used to verify the bundle signature before installing it. May be repeated.")
+	installCmd.Flags().Bool("force", false, "Whether to reinstall the plugin even if the version is already installed.")
+	installCmd.Flags().Bool("no-enable", false, "Whether to leave the installed plugin disabled.")
+	_ = installCmd.MarkFlagRequired("mattermost-url")
+}
+
+const (
+	installActionUnchanged   = "unchanged"
+	installActionReinstalled = "reinstalled"
+	installActionUpgraded    = "upgraded"
+	installActionInstalled   = "installed"
+)
+
+// installResult describes the outcome of installing a plugin, as printed in the machine-readable
+// formats.
+type installResult struct {
+	PluginID        string `json:"plugin_id"`
+	Version         string `json:"version"`
+	PreviousVersion string `json:"previous_version,omitempty"`
+	Action          string `json:"action"`
+}
+
+var installCmd = &cobra.Command{
+	Use:   "install <id>",
+	Short: "Install or upgrade a plugin on a Mattermost server.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command)
+		if err != nil {
+			return err
+		}
+
+		mattermostURL, _ := command.Flags().GetString("mattermost-url")
+		token, _ := command.Flags().GetString("mattermost-token")
+		if token == "" {
+			token = os.Getenv("MATTERMOST_TOKEN")
+		}
+		if token == "" {
+			return errors.New("--mattermost-token or $MATTERMOST_TOKEN is required")
+		}
+
+		mattermostClient := mattermostModel.NewAPIv4Client(strings.TrimSuffix(mattermostURL, "/"))
+		mattermostClient.SetToken(token)
+
+		installed, resp := mattermostClient.GetPlugins()
+		if resp.Error != nil {
+			return errors.Wrapf(resp.Error, "failed to list the plugins of %s", mattermostURL)
+		}
+
+		client := newClient(command)
+		publicKeyPaths, _ := command.Flags().GetStringSlice("public-key")
+		if len(publicKeyPaths) > 0 {
+			keyring, err := readPublicKeys(publicKeyPaths)
+			if err != nil {
+				return err
+			}
+			client.PublicKeys = keyring
+		}
+
+		version, _ := command.Flags().GetString("version")
+		plugin, err := findInstallablePlugin(client, args[0], version, serverVersion(resp.ServerVersion))
+		if err != nil {
+			return err
+		}
+
+		platform, _ := command.Flags().GetString("platform")
+		if platform != "" {
+			plugin = plugin.ForPlatform(platform)
+		}
+
+		id := plugin.Manifest.Id
+		previousVersion := installedVersion(installed, id)
+		force, _ := command.Flags().GetBool("force")
+		result := &installResult{
+			PluginID:        id,
+			Version:         plugin.Manifest.Version,
+			PreviousVersion: previousVersion,
+		}
+		if previousVersion == plugin.Manifest.Version && !force {
+			result.Action = installActionUnchanged
+			return printInstallResult(format, result)
+		}
+
+		var bundle bytes.Buffer
+		if err := client.DownloadPlugin(plugin, &bundle); err != nil {
+			return errors.Wrapf(err, "failed to download plugin %s", id)
+		}
+
+		// Replacing an installed version requires forcing the upload.
+		if _, resp := mattermostClient.UploadPluginForced(&bundle); resp.Error != nil {
+			return errors.Wrapf(resp.Error, "failed to install plugin %s on %s", id, mattermostURL)
+		}
+
+		noEnable, _ := command.Flags().GetBool("no-enable")
+		if !noEnable {
+			if _, resp := mattermostClient.EnablePlugin(id); resp.Error != nil {
+				return errors.Wrapf(resp.Error, "failed to enable plugin %s on %s", id, mattermostURL)
+			}
+		}
+
+		if previousVersion == plugin.Manifest.Version {
+			result.Action = installActionReinstalled
+			return printInstallResult(format, result)
+		}
+
+		event := &model.InstallEvent{
+			Event:    model.InstallEventInstall,
+			PluginID: id,
+			Version:  plugin.Manifest.Version,
+		}
+		if previousVersion != "" {
+			event.Event = model.InstallEventUpgrade
+		}
+		if err := client.ReportInstall(event); err != nil && err != api.ErrNotFound {
+			logger.WithError(err).Warn("failed to report installation to the marketplace")
+		}
+
+		result.Action = installActionInstalled
+		if previousVersion != "" {
+			result.Action = installActionUpgraded
+		}
+
+		return printInstallResult(format, result)
+	},
+}
+
+// printInstallResult prints the outcome of installing a plugin in the given format.
+func printInstallResult(format string, result *installResult) error {
+	if format != formatTable {
+		return printData(os.Stdout, format, result)
+	}
+
+	switch result.Action {
+	case installActionUnchanged:
+		fmt.Printf("%s %s is already installed\n", result.PluginID, result.Version)
+	case installActionReinstalled:
+		fmt.Printf("reinstalled %s %s\n", result.PluginID, result.Version)
+	case installActionUpgraded:
+		fmt.Printf("upgraded %s from %s to %s\n", result.PluginID, result.PreviousVersion, result.Version)
+	default:
+		fmt.Printf("installed %s %s\n", result.PluginID, result.Version)
+	}
+
+	return nil
+}
+
+// findInstallablePlugin resolves the plugin with the given id and, if non-empty, version, or else
+// the latest version compatible with the given server version, if known.
+func findInstallablePlugin(client *api.Client, id, version, serverVersion string) (*model.Plugin, error) {
+	if version != "" || serverVersion == "" {
+		return api.FindPlugin(client, id, version)
+	}
+
+	plugins, err := client.GetPlugins(&api.GetPluginsRequest{
+		Filter:        id,
+		ServerVersion: serverVersion,
+		PerPage:       model.AllPerPage,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get plugin %s", id)
+	}
+
+	for _, plugin := range plugins {
+		if plugin.Manifest != nil && plugin.HasID(id) {
+			return plugin, nil
+		}
+	}
+
+	return nil, errors.Wrapf(api.ErrNotFound, "plugin %s compatible with server version %s", id, serverVersion)
+}
+
+// serverVersion extracts the Mattermost version from the given X-Version-Id header, which
+// prefixes the version to build details, e.g. 5.18.0.5.18.0.abcdef.true.
+func serverVersion(versionID string) string {
+	parts := strings.SplitN(versionID, ".", 4)
+	if len(parts) < 3 {
+		return ""
+	}
+
+	return strings.Join(parts[:3], ".")
+}
+
+// installedVersion returns the version of the plugin with the given id installed on the server,
+// or the empty string if it is not installed.
+func installedVersion(installed *mattermostModel.PluginsResponse, id string) string {
+	for _, infos := range [][]*mattermostModel.PluginInfo{installed.Active, installed.Inactive} {
+		for _, info := range infos {
+			if info.Id == id {
+				return info.Version
+			}
+		}
+	}
+
+	return ""
+}
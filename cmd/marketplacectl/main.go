@@ -0,0 +1,55 @@
+// Package main is the entry point to marketplacectl, a command line client to the Plugin
+// Marketplace.
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+)
+
+// defaultAddress is the marketplace queried by Mattermost servers by default.
+const defaultAddress = "https://api.integrations.mattermost.com"
+
+var rootCmd = &cobra.Command{
+	Use:   "marketplacectl",
+	Short: "Marketplacectl queries a Plugin Marketplace.",
+	// SilenceErrors allows us to explicitly log the error returned from rootCmd below.
+	SilenceErrors: true,
+}
+
+func init() {
+	rootCmd.PersistentFlags().String("address", defaultAddress, "The address of the marketplace server.")
+	rootCmd.PersistentFlags().StringP("output", "o", formatTable, "The output format, one of table, json or yaml.")
+	rootCmd.PersistentFlags().String("format", formatTable, "The output format, one of table, json or yaml.")
+	_ = rootCmd.PersistentFlags().MarkDeprecated("format", "use --output instead")
+
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(versionsCmd)
+	rootCmd.AddCommand(downloadCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(completionCmd)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		logger.WithError(err).Error("command failed")
+		os.Exit(1)
+	}
+}
+
+// newClient creates a client to the marketplace configured for the given command.
+func newClient(command *cobra.Command) *api.Client {
+	address, _ := command.Flags().GetString("address")
+
+	client := api.NewClient(address)
+	client.UserAgent = "marketplacectl"
+
+	return client
+}
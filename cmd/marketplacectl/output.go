@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// getFormat returns the output format requested for the given command by --output, or by the
+// deprecated --format.
+func getFormat(command *cobra.Command) (string, error) {
+	format, _ := command.Flags().GetString("output")
+	if command.Flags().Changed("format") && !command.Flags().Changed("output") {
+		format, _ = command.Flags().GetString("format")
+	}
+
+	switch format {
+	case formatTable, formatJSON, formatYAML:
+		return format, nil
+	default:
+		return "", errors.Errorf("unsupported output format %s", format)
+	}
+}
+
+// printData writes the given value in the given machine-readable format, either indented JSON or
+// YAML.
+func printData(w io.Writer, format string, value interface{}) error {
+	if format == formatYAML {
+		if err := model.EncodeYAML(w, value); err != nil {
+			return errors.Wrap(err, "failed to encode output")
+		}
+		return nil
+	}
+
+	return printJSON(w, value)
+}
+
+// printJSON writes the given value as indented JSON.
+func printJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return errors.Wrap(err, "failed to encode output")
+	}
+
+	return nil
+}
+
+// printPlugins writes the given plugins in the given format, one per row in a table.
+func printPlugins(w io.Writer, format string, plugins []*model.Plugin) error {
+	if format != formatTable {
+		if plugins == nil {
+			plugins = []*model.Plugin{}
+		}
+		return printData(w, format, plugins)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tVERSION\tMIN SERVER VERSION\tRELEASE STAGE")
+	for _, plugin := range plugins {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			plugin.Manifest.Id,
+			plugin.Manifest.Name,
+			plugin.Manifest.Version,
+			plugin.Manifest.MinServerVersion,
+			plugin.ReleaseStage,
+		)
+	}
+
+	return tw.Flush()
+}
+
+// printPlugin writes the details of the given plugin in the given format.
+func printPlugin(w io.Writer, format string, plugin *model.Plugin) error {
+	if format != formatTable {
+		return printData(w, format, plugin)
+	}
+
+	labels := make([]string, 0, len(plugin.Labels))
+	for _, label := range plugin.Labels {
+		labels = append(labels, label.Name)
+	}
+
+	dependencies := make([]string, 0, len(plugin.Dependencies))
+	for _, dependency := range plugin.Dependencies {
+		if dependency.MinVersion != "" {
+			dependencies = append(dependencies, fmt.Sprintf("%s >= %s", dependency.PluginID, dependency.MinVersion))
+		} else {
+			dependencies = append(dependencies, dependency.PluginID)
+		}
+	}
+
+	maintainers := make([]string, 0, len(plugin.Maintainers))
+	for _, maintainer := range plugin.Maintainers {
+		contact := maintainer.Name
+		if maintainer.Email != "" {
+			contact += fmt.Sprintf(" <%s>", maintainer.Email)
+		}
+		if maintainer.URL != "" {
+			contact += fmt.Sprintf(" (%s)", maintainer.URL)
+		}
+		maintainers = append(maintainers, contact)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range [][2]string{
+		{"ID", plugin.Manifest.Id},
+		{"Name", plugin.Manifest.Name},
+		{"Description", plugin.Manifest.Description},
+		{"Version", plugin.Manifest.Version},
+		{"Min Server Version", plugin.Manifest.MinServerVersion},
+		{"Server Version Range", plugin.ServerVersionRange},
+		{"Release Stage", string(plugin.ReleaseStage)},
+		{"Author Type", string(plugin.AuthorType)},
+		{"Maintainers", strings.Join(maintainers, ", ")},
+		{"Hosting", string(plugin.HostingRequirement)},
+		{"Labels", strings.Join(labels, ", ")},
+		{"Dependencies", strings.Join(dependencies, ", ")},
+		{"Supersedes", strings.Join(plugin.Supersedes, ", ")},
+		{"Superseded By", plugin.SupersededBy},
+		{"Conflicts With", strings.Join(plugin.ConflictsWith, ", ")},
+		{"Unmaintained", string(plugin.Unmaintained)},
+		{"Homepage", plugin.HomepageURL},
+		{"Release Notes", plugin.ReleaseNotesURL},
+		{"Download", plugin.DownloadURL},
+	} {
+		if row[1] == "" {
+			continue
+		}
+		fmt.Fprintf(tw, "%s:\t%s\n", row[0], row[1])
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func init() {
+	for _, command := range []*cobra.Command{listCmd, searchCmd} {
+		command.Flags().String("server-version", "", "Only include plugins compatible with the given Mattermost server version.")
+		command.Flags().String("author-type", "", "Only include plugins by the given author type, one of mattermost, partner or community.")
+		command.Flags().String("hosting", "", "Only include plugins supporting the given hosting, one of on-prem or cloud.")
+		command.Flags().String("platform", "", "Only include plugins installable on the given platform, e.g. linux-amd64.")
+		command.Flags().StringSlice("label", nil, "Only include plugins carrying the given label. May be repeated.")
+		command.Flags().String("sort", "", "Order plugins by name or released, rather than featured plugins first.")
+	}
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the latest version of all plugins.",
+	Args:  cobra.NoArgs,
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		return listPlugins(command, "")
+	},
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search <terms>",
+	Short: "List the latest version of plugins matching the given terms.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		return listPlugins(command, args[0])
+	},
+}
+
+// listPlugins prints every plugin matching the given filter and the command's flags.
+func listPlugins(command *cobra.Command, filter string) error {
+	format, err := getFormat(command)
+	if err != nil {
+		return err
+	}
+
+	serverVersion, _ := command.Flags().GetString("server-version")
+	authorType, _ := command.Flags().GetString("author-type")
+	hosting, _ := command.Flags().GetString("hosting")
+	platform, _ := command.Flags().GetString("platform")
+	labels, _ := command.Flags().GetStringSlice("label")
+	sort, _ := command.Flags().GetString("sort")
+
+	plugins, err := newClient(command).PluginsPager(&api.GetPluginsRequest{
+		Filter:        filter,
+		ServerVersion: serverVersion,
+		AuthorType:    model.AuthorType(authorType),
+		Hosting:       model.HostingRequirement(hosting),
+		Platform:      platform,
+		Labels:        labels,
+		Sort:          model.PluginSort(sort),
+	}).All()
+	if err != nil {
+		return errors.Wrap(err, "failed to get plugins")
+	}
+
+	return printPlugins(os.Stdout, format, plugins)
+}
+
+func init() {
+	showCmd.Flags().String("version", "", "The version to show, defaulting to the latest.")
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the details of a plugin.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command)
+		if err != nil {
+			return err
+		}
+
+		version, _ := command.Flags().GetString("version")
+		plugin, err := api.FindPlugin(newClient(command), args[0], version)
+		if err != nil {
+			return err
+		}
+
+		return printPlugin(os.Stdout, format, plugin)
+	},
+}
+
+var versionsCmd = &cobra.Command{
+	Use:   "versions <id>",
+	Short: "List every version of a plugin.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command)
+		if err != nil {
+			return err
+		}
+
+		plugins, err := newClient(command).GetPluginVersions(args[0])
+		if err != nil {
+			return errors.Wrapf(err, "failed to get versions of plugin %s", args[0])
+		}
+
+		return printPlugins(os.Stdout, format, plugins)
+	},
+}
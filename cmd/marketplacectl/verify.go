@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+)
+
+func init() {
+	verifyCmd.Flags().String("version", "", "The version to verify, defaulting to the latest.")
+	verifyCmd.Flags().String("platform", "", "The platform of the bundle to verify, e.g. linux-amd64, defaulting to the bundle for every platform.")
+	verifyCmd.Flags().StringSlice("keyring", nil, "A public key file trusted to sign plugin bundles. May be repeated.")
+	_ = verifyCmd.MarkFlagRequired("keyring")
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <id>",
+	Short: "Download the bundle of a plugin, verifying its checksums and signatures.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(command *cobra.Command, args []string) error {
+		command.SilenceUsage = true
+
+		format, err := getFormat(command)
+		if err != nil {
+			return err
+		}
+
+		keyringPaths, _ := command.Flags().GetStringSlice("keyring")
+		keyring, err := readPublicKeys(keyringPaths)
+		if err != nil {
+			return err
+		}
+
+		client := newClient(command)
+		version, _ := command.Flags().GetString("version")
+		plugin, err := api.FindPlugin(client, args[0], version)
+		if err != nil {
+			return err
+		}
+
+		platform, _ := command.Flags().GetString("platform")
+		if platform != "" {
+			plugin = plugin.ForPlatform(platform)
+		}
+
+		verification, err := client.VerifyPlugin(plugin, keyring)
+		if err != nil {
+			return errors.Wrapf(err, "failed to verify plugin %s", plugin.Manifest.Id)
+		}
+
+		if err := printVerification(os.Stdout, format, verification); err != nil {
+			return err
+		}
+		if !verification.Verified() {
+			return errors.Errorf("plugin %s %s failed verification", verification.PluginID, verification.Version)
+		}
+
+		return nil
+	},
+}
+
+// printVerification writes the checks of the given verification in the given format, one per row
+// in a table.
+func printVerification(w io.Writer, format string, verification *api.PluginVerification) error {
+	if format != formatTable {
+		return printData(w, format, verification)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tRESULT\tDETAIL")
+
+	if verification.ChecksumError != "" {
+		fmt.Fprintf(tw, "checksums\tfail\t%s\n", verification.ChecksumError)
+	} else {
+		fmt.Fprintf(tw, "checksums\tpass\tsha256 %s\n", verification.Checksums.SHA256)
+	}
+
+	if len(verification.Signatures) == 0 {
+		fmt.Fprintln(tw, "signature\tfail\tplugin has no signature")
+	}
+	for _, signature := range verification.Signatures {
+		result, detail := "pass", "key "+signature.KeyID
+		switch {
+		case signature.Error != "":
+			result, detail = "fail", signature.Error
+		case !signature.Trusted:
+			result, detail = "skip", "untrusted key "+signature.KeyID
+		}
+		fmt.Fprintf(tw, "signature\t%s\t%s\n", result, detail)
+	}
+
+	return tw.Flush()
+}
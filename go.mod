@@ -5,7 +5,10 @@ go 1.12
 require (
 	github.com/akrylysov/algnhsa v0.0.0-20190319020909-05b3d192e9a7
 	github.com/aws/aws-lambda-go v1.13.0 // indirect
+	github.com/aws/aws-sdk-go v1.25.43
 	github.com/blang/semver v3.5.1+incompatible
+	github.com/getsentry/sentry-go v0.3.1
+	github.com/golang/protobuf v1.3.4
 	github.com/google/go-github/v28 v28.0.0
 	github.com/gorilla/mux v1.7.3
 	github.com/h2non/filetype v1.0.10
@@ -15,6 +18,17 @@ require (
 	github.com/rakyll/statik v0.1.6
 	github.com/sirupsen/logrus v1.4.2
 	github.com/spf13/cobra v0.0.5
-	github.com/stretchr/testify v1.4.0
+	github.com/spf13/pflag v1.0.5
+	github.com/stretchr/testify v1.8.4
+	github.com/vmihailenco/msgpack/v4 v4.3.13
+	github.com/xeipuuv/gojsonschema v1.2.0
+	go.opentelemetry.io/otel v1.21.0
+	go.opentelemetry.io/otel/sdk v1.21.0
+	go.opentelemetry.io/otel/trace v1.21.0
+	golang.org/x/crypto v0.0.0-20191001170739-f9e2070545dc
+	golang.org/x/net v0.0.0-20200301022130-244492dfa37a
 	golang.org/x/oauth2 v0.0.0-20190319182350-c85d3e98c914
+	google.golang.org/grpc v1.25.1
+	gopkg.in/square/go-jose.v2 v2.6.0
+	gopkg.in/yaml.v2 v2.2.4
 )
@@ -0,0 +1,140 @@
+// Package advisories tracks the security advisories published for plugins, persisting them to a
+// pluggable backend.
+package advisories
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// ErrNotFound is returned when the requested advisory does not exist.
+var ErrNotFound = errors.New("advisory not found")
+
+// Backend persists advisories.
+type Backend interface {
+	// Load returns all persisted advisories, or none if nothing was persisted yet.
+	Load() ([]*model.Advisory, error)
+	// Save replaces the persisted advisories with the given advisories.
+	Save(advisories []*model.Advisory) error
+}
+
+// Registry holds every published advisory, ordered by publication.
+type Registry struct {
+	backend Backend
+	now     func() time.Time
+	newID   func() string
+
+	lock       sync.RWMutex
+	advisories []*model.Advisory
+}
+
+// New creates a registry initialized with the advisories persisted to the given backend.
+func New(backend Backend) (*Registry, error) {
+	advisories, err := backend.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load advisories")
+	}
+
+	for _, advisory := range advisories {
+		if err := advisory.IsValid(); err != nil {
+			return nil, errors.Wrapf(err, "invalid advisory %s", advisory.ID)
+		}
+	}
+
+	return &Registry{
+		backend:    backend,
+		now:        time.Now,
+		newID:      mattermostModel.NewId,
+		advisories: advisories,
+	}, nil
+}
+
+// Publish adds the given advisory, returning it with its id and publication time.
+func (r *Registry) Publish(advisory *model.Advisory) (*model.Advisory, error) {
+	published := *advisory
+	published.ID = r.newID()
+	published.PublishedAt = r.now().UTC()
+	if err := published.IsValid(); err != nil {
+		return nil, errors.Wrap(err, "invalid advisory")
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	advisories := append(append([]*model.Advisory{}, r.advisories...), &published)
+	if err := r.save(advisories); err != nil {
+		return nil, err
+	}
+
+	result := published
+	return &result, nil
+}
+
+// Withdraw removes the given advisory, such as one published in error.
+func (r *Registry) Withdraw(id string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	advisories := []*model.Advisory{}
+	for _, existing := range r.advisories {
+		if existing.ID != id {
+			advisories = append(advisories, existing)
+		}
+	}
+	if len(advisories) == len(r.advisories) {
+		return ErrNotFound
+	}
+
+	return r.save(advisories)
+}
+
+// save persists the given advisories before adopting them. The lock must be held.
+func (r *Registry) save(advisories []*model.Advisory) error {
+	if err := r.backend.Save(advisories); err != nil {
+		return errors.Wrap(err, "failed to save advisories")
+	}
+
+	r.advisories = advisories
+
+	return nil
+}
+
+// GetAdvisories returns the advisories published for the given plugin, or for every plugin if
+// none is given, most recent first.
+func (r *Registry) GetAdvisories(pluginID string) []*model.Advisory {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	result := []*model.Advisory{}
+	for _, advisory := range r.advisories {
+		if pluginID != "" && advisory.PluginID != pluginID {
+			continue
+		}
+
+		copied := *advisory
+		result = append(result, &copied)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].PublishedAt.After(result[j].PublishedAt)
+	})
+
+	return result
+}
+
+// AdvisoriesByPlugin maps the id of each plugin with published advisories to its advisories, most
+// recent first.
+func (r *Registry) AdvisoriesByPlugin() map[string][]*model.Advisory {
+	result := map[string][]*model.Advisory{}
+	for _, advisory := range r.GetAdvisories("") {
+		result[advisory.PluginID] = append(result[advisory.PluginID], advisory)
+	}
+
+	return result
+}
@@ -0,0 +1,93 @@
+package advisories
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "advisories")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "advisories.json")
+
+	now := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+	nextID := 0
+	newRegistry := func(t *testing.T) *Registry {
+		registry, err := New(&FileBackend{Path: path})
+		require.NoError(t, err)
+		registry.now = func() time.Time { return now }
+		registry.newID = func() string {
+			nextID++
+			return fmt.Sprintf("advisory%d", nextID)
+		}
+
+		return registry
+	}
+
+	registry := newRegistry(t)
+
+	t.Run("no advisories", func(t *testing.T) {
+		require.Empty(t, registry.GetAdvisories(""))
+		require.Empty(t, registry.AdvisoriesByPlugin())
+	})
+
+	t.Run("invalid advisory", func(t *testing.T) {
+		_, err := registry.Publish(&model.Advisory{PluginID: "jira", Severity: "severe", Description: "Leaks secrets."})
+		require.Error(t, err)
+	})
+
+	jiraAdvisory, err := registry.Publish(&model.Advisory{
+		PluginID:    "jira",
+		Severity:    model.AdvisorySeverityHigh,
+		Description: "Webhook secrets are logged.",
+		FixedIn:     "3.0.1",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "advisory2", jiraAdvisory.ID)
+	require.Equal(t, now, jiraAdvisory.PublishedAt)
+
+	now = now.Add(time.Hour)
+	demoAdvisory, err := registry.Publish(&model.Advisory{
+		PluginID:    "demo",
+		Severity:    model.AdvisorySeverityLow,
+		Description: "Debug endpoints are exposed.",
+	})
+	require.NoError(t, err)
+
+	t.Run("most recent first", func(t *testing.T) {
+		require.Equal(t, []*model.Advisory{demoAdvisory, jiraAdvisory}, registry.GetAdvisories(""))
+		require.Equal(t, []*model.Advisory{jiraAdvisory}, registry.GetAdvisories("jira"))
+		require.Equal(t, map[string][]*model.Advisory{
+			"demo": {demoAdvisory},
+			"jira": {jiraAdvisory},
+		}, registry.AdvisoriesByPlugin())
+	})
+
+	t.Run("persists across restarts", func(t *testing.T) {
+		require.Equal(t, []*model.Advisory{demoAdvisory, jiraAdvisory}, newRegistry(t).GetAdvisories(""))
+	})
+
+	t.Run("withdraw", func(t *testing.T) {
+		require.Equal(t, ErrNotFound, registry.Withdraw("unknown"))
+
+		require.NoError(t, registry.Withdraw(demoAdvisory.ID))
+		require.Equal(t, []*model.Advisory{jiraAdvisory}, registry.GetAdvisories(""))
+		require.Equal(t, []*model.Advisory{jiraAdvisory}, newRegistry(t).GetAdvisories(""))
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(`[{"id": "advisory"}]`), 0600))
+
+		_, err := New(&FileBackend{Path: path})
+		require.Error(t, err)
+	})
+}
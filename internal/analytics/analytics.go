@@ -0,0 +1,312 @@
+// Package analytics aggregates anonymized patterns in the queries made of the marketplace, such
+// as the server versions listing plugins, per UTC day, persisting them to a pluggable backend.
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+const (
+	// dateFormat is the layout of the UTC day each query is aggregated under.
+	dateFormat = "2006-01-02"
+
+	// RetentionDays bounds the days of queries retained, and so reported.
+	RetentionDays = 90
+
+	// MinFilterCount is the number of times a search filter must be queried before it is reported,
+	// so that the report never reveals the searches of an individual.
+	MinFilterCount = 3
+
+	// maxFilterLength bounds the characters of each search filter recorded.
+	maxFilterLength = 64
+
+	// unknownServerVersion counts the queries giving no valid server version.
+	unknownServerVersion = "unknown"
+)
+
+// Dimension identifies the aspect of a query a record counts.
+type Dimension string
+
+const (
+	// DimensionServerVersion counts the plugin listings queried by a major and minor server version.
+	DimensionServerVersion Dimension = "server_version"
+	// DimensionFilter counts the plugin listings queried with a search filter.
+	DimensionFilter Dimension = "filter"
+	// DimensionPlugin counts the requests for a single plugin.
+	DimensionPlugin Dimension = "plugin"
+)
+
+// Record counts the queries sharing a single value of a dimension on a single UTC day.
+type Record struct {
+	Date      string    `json:"date"`
+	Dimension Dimension `json:"dimension"`
+	Value     string    `json:"value"`
+	Count     int64     `json:"count"`
+}
+
+// Backend persists query records.
+type Backend interface {
+	// Load returns all persisted records, or none if nothing was persisted yet.
+	Load() ([]*Record, error)
+	// Save replaces the persisted records with the given records.
+	Save(records []*Record) error
+}
+
+type key struct {
+	date      string
+	dimension Dimension
+	value     string
+}
+
+// Recorder counts queries in memory, periodically merging them into a backend and discarding
+// those older than RetentionDays.
+//
+// Only the aggregated dimensions of each query are recorded, never who made it.
+type Recorder struct {
+	backend Backend
+	now     func() time.Time
+
+	lock    sync.Mutex
+	counts  map[key]int64
+	pending map[key]int64
+}
+
+// NewRecorder creates a recorder initialized with the records persisted to the given backend.
+func NewRecorder(backend Backend) (*Recorder, error) {
+	records, err := backend.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load query records")
+	}
+
+	return &Recorder{
+		backend: backend,
+		now:     time.Now,
+		counts:  countsFromRecords(records),
+		pending: map[key]int64{},
+	}, nil
+}
+
+func countsFromRecords(records []*Record) map[key]int64 {
+	counts := map[key]int64{}
+	for _, record := range records {
+		counts[key{record.Date, record.Dimension, record.Value}] += record.Count
+	}
+
+	return counts
+}
+
+// record counts a query with the given values today.
+func (r *Recorder) record(values map[Dimension]string) {
+	date := r.now().UTC().Format(dateFormat)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for dimension, value := range values {
+		k := key{date, dimension, value}
+		r.counts[k]++
+		r.pending[k]++
+	}
+}
+
+// RecordQuery counts a plugin listing queried by the given server version with the given search
+// filter, either of which may be empty.
+func (r *Recorder) RecordQuery(serverVersion, filter string) {
+	values := map[Dimension]string{
+		DimensionServerVersion: normalizeServerVersion(serverVersion),
+	}
+	if filter := normalizeFilter(filter); filter != "" {
+		values[DimensionFilter] = filter
+	}
+
+	r.record(values)
+}
+
+// RecordPluginRequest counts a request for the given plugin.
+func (r *Recorder) RecordPluginRequest(pluginID string) {
+	r.record(map[Dimension]string{DimensionPlugin: strings.ToLower(pluginID)})
+}
+
+// normalizeServerVersion reduces the given server version to its major and minor version, which
+// suffices to judge support while revealing less about the server.
+func normalizeServerVersion(serverVersion string) string {
+	if serverVersion == "" {
+		return unknownServerVersion
+	}
+
+	version, err := semver.ParseTolerant(serverVersion)
+	if err != nil {
+		return unknownServerVersion
+	}
+
+	return fmt.Sprintf("%d.%d", version.Major, version.Minor)
+}
+
+// normalizeFilter lowercases the given search filter, collapsing its whitespace and truncating it
+// to maxFilterLength characters.
+func normalizeFilter(filter string) string {
+	filter = strings.ToLower(strings.Join(strings.Fields(filter), " "))
+	if utf8.RuneCountInString(filter) > maxFilterLength {
+		filter = string([]rune(filter)[:maxFilterLength])
+	}
+
+	return filter
+}
+
+// Flush merges the queries recorded since the last flush into the backend, discarding the records
+// older than RetentionDays.
+func (r *Recorder) Flush() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	records, err := r.backend.Load()
+	if err != nil {
+		return errors.Wrap(err, "failed to load query records")
+	}
+
+	counts := countsFromRecords(records)
+	for k, pending := range r.pending {
+		counts[k] += pending
+	}
+
+	oldest := r.firstRetainedDate()
+	for k := range counts {
+		if k.date < oldest {
+			delete(counts, k)
+		}
+	}
+
+	if err := r.backend.Save(recordsFromCounts(counts)); err != nil {
+		return errors.Wrap(err, "failed to save query records")
+	}
+
+	r.counts = counts
+	r.pending = map[key]int64{}
+
+	return nil
+}
+
+// firstRetainedDate returns the earliest day whose queries are retained.
+func (r *Recorder) firstRetainedDate() string {
+	return r.now().UTC().AddDate(0, 0, 1-RetentionDays).Format(dateFormat)
+}
+
+// Run flushes the recorder at the given interval until done is closed, flushing a final time
+// before returning.
+func (r *Recorder) Run(interval time.Duration, done <-chan struct{}, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Flush(); err != nil {
+				onError(err)
+			}
+		case <-done:
+			if err := r.Flush(); err != nil {
+				onError(err)
+			}
+			return
+		}
+	}
+}
+
+func recordsFromCounts(counts map[key]int64) []*Record {
+	records := make([]*Record, 0, len(counts))
+	for k, count := range counts {
+		records = append(records, &Record{k.date, k.dimension, k.value, count})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+		return a.Value < b.Value
+	})
+
+	return records
+}
+
+// Report summarizes the queries of the given number of days up to and including today, bounded by
+// RetentionDays, listing at most limit values of each dimension.
+func (r *Recorder) Report(days, limit int) *model.AnalyticsReport {
+	if days < 1 {
+		days = 1
+	} else if days > RetentionDays {
+		days = RetentionDays
+	}
+	today := r.now().UTC()
+	since := today.AddDate(0, 0, 1-days).Format(dateFormat)
+	until := today.Format(dateFormat)
+
+	totals := map[Dimension]map[string]int64{
+		DimensionServerVersion: {},
+		DimensionFilter:        {},
+		DimensionPlugin:        {},
+	}
+
+	r.lock.Lock()
+	for k, count := range r.counts {
+		if k.date < since || k.date > until || totals[k.dimension] == nil {
+			continue
+		}
+		totals[k.dimension][k.value] += count
+	}
+	r.lock.Unlock()
+
+	report := &model.AnalyticsReport{
+		Since:          since,
+		Until:          until,
+		ServerVersions: topCounts(totals[DimensionServerVersion], 1, limit),
+		Filters:        topCounts(totals[DimensionFilter], MinFilterCount, limit),
+		Plugins:        topCounts(totals[DimensionPlugin], 1, limit),
+	}
+	for _, count := range totals[DimensionServerVersion] {
+		report.TotalQueries += count
+	}
+
+	return report
+}
+
+// topCounts returns at most limit of the given values counted at least minCount times, most
+// counted first.
+func topCounts(totals map[string]int64, minCount int64, limit int) []model.AnalyticsCount {
+	result := make([]model.AnalyticsCount, 0, len(totals))
+	for value, count := range totals {
+		if count < minCount {
+			continue
+		}
+		result = append(result, model.AnalyticsCount{Value: value, Count: count})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Value < result[j].Value
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
@@ -0,0 +1,121 @@
+package analytics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func newFileRecorder(t *testing.T, path string, now *time.Time) *Recorder {
+	recorder, err := NewRecorder(&FileBackend{Path: path})
+	require.NoError(t, err)
+	recorder.now = func() time.Time {
+		return *now
+	}
+
+	return recorder
+}
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "analytics")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "analytics.json")
+
+	now := time.Date(2019, 11, 1, 23, 0, 0, 0, time.UTC)
+	recorder := newFileRecorder(t, path, &now)
+
+	t.Run("no queries", func(t *testing.T) {
+		require.Equal(t, &model.AnalyticsReport{
+			Since:          "2019-10-03",
+			Until:          "2019-11-01",
+			ServerVersions: []model.AnalyticsCount{},
+			Filters:        []model.AnalyticsCount{},
+			Plugins:        []model.AnalyticsCount{},
+		}, recorder.Report(30, 10))
+
+		// Nothing is written until something is recorded.
+		require.NoError(t, recorder.Flush())
+		_, err := os.Stat(path)
+		require.True(t, os.IsNotExist(err))
+	})
+
+	recorder.RecordQuery("5.18.1", "  Jira ")
+	recorder.RecordQuery("v5.18.0", "jira")
+	recorder.RecordQuery("5.20.0", "")
+	now = now.Add(2 * time.Hour)
+	recorder.RecordQuery("", "JIRA")
+	recorder.RecordQuery("invalid", "github")
+	recorder.RecordPluginRequest("Jira")
+	recorder.RecordPluginRequest("jira")
+	recorder.RecordPluginRequest("github")
+
+	expectedReport := &model.AnalyticsReport{
+		Since:        "2019-10-04",
+		Until:        "2019-11-02",
+		TotalQueries: 5,
+		ServerVersions: []model.AnalyticsCount{
+			{Value: "5.18", Count: 2},
+			{Value: "unknown", Count: 2},
+			{Value: "5.20", Count: 1},
+		},
+		// The github filter is too rare to report.
+		Filters: []model.AnalyticsCount{
+			{Value: "jira", Count: 3},
+		},
+		Plugins: []model.AnalyticsCount{
+			{Value: "jira", Count: 2},
+			{Value: "github", Count: 1},
+		},
+	}
+
+	t.Run("aggregates anonymized queries", func(t *testing.T) {
+		require.Equal(t, expectedReport, recorder.Report(30, 10))
+	})
+
+	t.Run("limits values", func(t *testing.T) {
+		report := recorder.Report(30, 1)
+		require.Equal(t, []model.AnalyticsCount{{Value: "5.18", Count: 2}}, report.ServerVersions)
+		require.Equal(t, []model.AnalyticsCount{{Value: "jira", Count: 2}}, report.Plugins)
+	})
+
+	t.Run("reports only the given days", func(t *testing.T) {
+		report := recorder.Report(1, 10)
+		require.Equal(t, "2019-11-02", report.Since)
+		require.EqualValues(t, 2, report.TotalQueries)
+		require.Empty(t, report.Filters)
+	})
+
+	t.Run("persists across restarts", func(t *testing.T) {
+		require.NoError(t, recorder.Flush())
+
+		restarted := newFileRecorder(t, path, &now)
+		require.Equal(t, expectedReport, restarted.Report(30, 10))
+	})
+
+	t.Run("discards expired queries", func(t *testing.T) {
+		now = now.AddDate(0, 0, RetentionDays-1)
+		recorder.RecordQuery("5.20.0", "")
+		require.NoError(t, recorder.Flush())
+
+		report := recorder.Report(RetentionDays, 10)
+		require.EqualValues(t, 3, report.TotalQueries)
+		require.Equal(t, []model.AnalyticsCount{{Value: "unknown", Count: 2}, {Value: "5.20", Count: 1}}, report.ServerVersions)
+
+		restarted := newFileRecorder(t, path, &now)
+		require.Equal(t, report, restarted.Report(RetentionDays, 10))
+	})
+}
+
+func TestNormalizeFilter(t *testing.T) {
+	require.Equal(t, "", normalizeFilter("   "))
+	require.Equal(t, "jira server", normalizeFilter(" Jira\tServer "))
+	require.Equal(t, strings.Repeat("é", maxFilterLength), normalizeFilter(strings.Repeat("É", maxFilterLength+10)))
+}
@@ -0,0 +1,60 @@
+package analytics
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// FileBackend persists query records as JSON to a local file.
+type FileBackend struct {
+	Path string
+}
+
+// Load reads the records from the file, returning none if it does not yet exist.
+func (b *FileBackend) Load() ([]*Record, error) {
+	data, err := ioutil.ReadFile(b.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", b.Path)
+	}
+
+	var records []*Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", b.Path)
+	}
+
+	return records, nil
+}
+
+// Save atomically replaces the file with the given records.
+func (b *FileBackend) Save(records []*Record) error {
+	data, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal records")
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(b.Path), filepath.Base(b.Path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to write %s", file.Name())
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", file.Name())
+	}
+
+	if err := os.Rename(file.Name(), b.Path); err != nil {
+		return errors.Wrapf(err, "failed to replace %s", b.Path)
+	}
+
+	return nil
+}
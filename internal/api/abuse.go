@@ -0,0 +1,170 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// pathologicalRequestCost is the number of requests a pathological query counts as towards the
+// client's burst limit.
+const pathologicalRequestCost = 10
+
+// AbuseOptions configures the detection of abusive clients.
+type AbuseOptions struct {
+	// Blocklist, if set, denies access to the clients it blocks, and records the clients
+	// exceeding the burst limit.
+	Blocklist Blocklist
+	// BurstLimit is the maximum number of requests accepted from a client per BurstWindow, or 0
+	// for no limit.
+	BurstLimit  int
+	BurstWindow time.Duration
+	// BlockDuration is how long clients exceeding the burst limit are blocked for, or 0 to only
+	// reject their requests until the window ends.
+	BlockDuration time.Duration
+	// MaxFilterLength is the maximum length of the filter query parameter, or 0 for no limit.
+	MaxFilterLength int
+	// MaxPage is the maximum value of the page query parameter, or 0 for no limit.
+	MaxPage int
+	// TrustForwardedFor identifies the client by the last address in the X-Forwarded-For header,
+	// as appended by a trusted load balancer, instead of by the connection's remote address.
+	TrustForwardedFor bool
+}
+
+// DefaultAbuseOptions reject queries no legitimate client makes, without limiting request rates.
+var DefaultAbuseOptions = AbuseOptions{
+	BurstWindow:     time.Minute,
+	BlockDuration:   10 * time.Minute,
+	MaxFilterLength: 256,
+	MaxPage:         1000,
+}
+
+// abuseHandler rejects requests from blocked clients, pathological queries and bursts of requests
+// before passing the remaining requests to the next handler.
+type abuseHandler struct {
+	next    http.Handler
+	options AbuseOptions
+	logger  logrus.FieldLogger
+	now     func() time.Time
+
+	lock        sync.Mutex
+	windowStart time.Time
+	requests    map[string]int
+}
+
+// NewAbuseHandler wraps the given handler, rejecting requests from abusive clients.
+//
+// Requests from blocked clients are answered with a 403, and pathological queries with a 400.
+// Clients exceeding the burst limit are answered with a 429, and are blocked for BlockDuration if
+// a Blocklist is given.
+func NewAbuseHandler(next http.Handler, options AbuseOptions, logger logrus.FieldLogger) http.Handler {
+	return &abuseHandler{
+		next:     next,
+		options:  options,
+		logger:   logger,
+		now:      time.Now,
+		requests: map[string]int{},
+	}
+}
+
+func (h *abuseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ip := clientIP(r, h.options.TrustForwardedFor)
+
+	if h.options.Blocklist != nil {
+		if entry, blocked := h.options.Blocklist.Blocks(ip, r.UserAgent()); blocked {
+			h.logger.WithFields(logrus.Fields{
+				"remote_addr":     r.RemoteAddr,
+				"blocklist_entry": entry.ID,
+			}).Debug("Rejected request from blocked client")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+
+	pathological := h.isPathological(r)
+	cost := 1
+	if pathological {
+		cost = pathologicalRequestCost
+	}
+
+	if h.options.BurstLimit > 0 && ip != nil {
+		if exceeded, retryAfter := h.countRequests(ip, cost); exceeded {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	if pathological {
+		h.logger.WithField("remote_addr", r.RemoteAddr).Debugf("Rejected pathological query %s", r.URL.RawQuery)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	h.next.ServeHTTP(w, r)
+}
+
+// isPathological reports whether the request's query is beyond what any legitimate client sends.
+func (h *abuseHandler) isPathological(r *http.Request) bool {
+	query := r.URL.Query()
+
+	if h.options.MaxFilterLength > 0 && len(query.Get("filter")) > h.options.MaxFilterLength {
+		return true
+	}
+
+	if h.options.MaxPage > 0 {
+		if page, err := strconv.Atoi(query.Get("page")); err == nil && page > h.options.MaxPage {
+			return true
+		}
+	}
+
+	return false
+}
+
+// countRequests counts the given number of requests towards the client's burst limit, reporting
+// whether the limit is exceeded and, if so, how long the client should wait before retrying. The
+// first time a client exceeds the limit within a window, it is added to the blocklist.
+func (h *abuseHandler) countRequests(ip net.IP, cost int) (bool, time.Duration) {
+	now := h.now()
+	client := ip.String()
+
+	h.lock.Lock()
+	if now.Sub(h.windowStart) >= h.options.BurstWindow {
+		h.windowStart = now
+		h.requests = map[string]int{}
+	}
+	previous := h.requests[client]
+	h.requests[client] = previous + cost
+	retryAfter := h.windowStart.Add(h.options.BurstWindow).Sub(now)
+	h.lock.Unlock()
+
+	if previous+cost <= h.options.BurstLimit {
+		return false, 0
+	}
+	if previous > h.options.BurstLimit || h.options.Blocklist == nil || h.options.BlockDuration <= 0 {
+		return true, retryAfter
+	}
+
+	expiresAt := now.Add(h.options.BlockDuration).UTC()
+	entry, err := h.options.Blocklist.Block(&model.BlocklistEntry{
+		IP:        client,
+		Reason:    fmt.Sprintf("exceeded %d requests per %s", h.options.BurstLimit, h.options.BurstWindow),
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("failed to block abusive client")
+		return true, retryAfter
+	}
+	h.logger.WithFields(logrus.Fields{
+		"client":          client,
+		"blocklist_entry": entry.ID,
+	}).Warn("Blocked client exceeding burst limit")
+
+	return true, h.options.BlockDuration
+}
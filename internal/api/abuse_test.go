@@ -0,0 +1,94 @@
+package api_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/blocklist"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+)
+
+func TestAbuseHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	get := func(t *testing.T, url, forwardedFor, userAgent string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp
+	}
+
+	t.Run("pathological queries", func(t *testing.T) {
+		ts := httptest.NewServer(api.NewAbuseHandler(next, api.DefaultAbuseOptions, testlib.MakeLogger(t)))
+		defer ts.Close()
+
+		require.Equal(t, http.StatusOK, get(t, ts.URL+"/api/v1/plugins?filter=jira&page=2", "", "").StatusCode)
+		require.Equal(t, http.StatusBadRequest, get(t, ts.URL+"/api/v1/plugins?filter="+strings.Repeat("a", 257), "", "").StatusCode)
+		require.Equal(t, http.StatusBadRequest, get(t, ts.URL+"/api/v1/plugins?page=1001", "", "").StatusCode)
+	})
+
+	t.Run("blocklist and bursts", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "blocklist")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		clientBlocklist, err := blocklist.New(&blocklist.FileBackend{Path: filepath.Join(dir, "blocklist.json")})
+		require.NoError(t, err)
+
+		_, err = clientBlocklist.Block(&model.BlocklistEntry{UserAgent: "scraper"})
+		require.NoError(t, err)
+
+		options := api.DefaultAbuseOptions
+		options.Blocklist = clientBlocklist
+		options.BurstLimit = 12
+		options.BurstWindow = time.Hour
+		options.TrustForwardedFor = true
+		ts := httptest.NewServer(api.NewAbuseHandler(next, options, testlib.MakeLogger(t)))
+		defer ts.Close()
+
+		t.Run("blocked user agent", func(t *testing.T) {
+			require.Equal(t, http.StatusForbidden, get(t, ts.URL, "192.0.2.1", "Scraper/1.0").StatusCode)
+		})
+
+		t.Run("burst", func(t *testing.T) {
+			for i := 0; i < 2; i++ {
+				require.Equal(t, http.StatusOK, get(t, ts.URL, "192.0.2.2", "").StatusCode)
+			}
+			// A pathological query counts as many requests, exhausting the burst limit.
+			require.Equal(t, http.StatusBadRequest, get(t, ts.URL+"?page=1001", "192.0.2.2", "").StatusCode)
+
+			resp := get(t, ts.URL, "192.0.2.2", "")
+			require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+			require.Equal(t, "600", resp.Header.Get("Retry-After"))
+
+			entries := clientBlocklist.GetEntries()
+			require.Len(t, entries, 2)
+			require.Equal(t, "192.0.2.2", entries[0].IP)
+			require.NotNil(t, entries[0].ExpiresAt)
+
+			require.Equal(t, http.StatusForbidden, get(t, ts.URL, "192.0.2.2", "").StatusCode)
+		})
+
+		t.Run("other clients are unaffected", func(t *testing.T) {
+			require.Equal(t, http.StatusOK, get(t, ts.URL, "192.0.2.3", "").StatusCode)
+		})
+	})
+}
@@ -0,0 +1,532 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// adminTokenCookie holds the bearer token of the moderator signed in to the admin UI.
+	adminTokenCookie = "marketplace_admin_token"
+	// adminCSRFField names the form field carrying the token guarding the admin UI's forms
+	// against cross-site requests.
+	adminCSRFField = "csrf"
+	// maxAdminFormSize bounds the body of the admin UI's forms.
+	maxAdminFormSize = 64 * 1024
+)
+
+// adminTemplates renders the pages of the admin UI.
+var adminTemplates = template.Must(template.New("admin").Funcs(template.FuncMap{
+	"date": func(t interface{ Format(string) string }) string {
+		return t.Format("2006-01-02")
+	},
+}).Parse(adminTemplatesText))
+
+// adminPage holds the data rendered by an admin UI page.
+type adminPage struct {
+	// Base is the path under which the admin UI is served.
+	Base    string
+	Title   string
+	UserID  string
+	CSRF    string
+	Channel string
+	// Query carries the selected channel, if any, to the links and forms of the page.
+	Query string
+	Error string
+
+	Filter  string
+	Plugins []*adminPluginRow
+
+	Plugin               *model.Plugin
+	Versions             []*adminVersionRow
+	Advisories           []*model.Advisory
+	Severities           []model.AdvisorySeverity
+	CanDelist            bool
+	CanPublishAdvisories bool
+
+	HasStats bool
+	Stats    *model.MarketplaceStats
+	Top      []*adminPluginRow
+}
+
+// adminPluginRow summarizes a plugin in the admin UI's listings.
+type adminPluginRow struct {
+	Plugin    *model.Plugin
+	ID        string
+	Downloads int64
+}
+
+// adminVersionRow summarizes a plugin version on the admin UI's plugin page.
+type adminVersionRow struct {
+	Plugin     *model.Plugin
+	Downloads  int64
+	Advisories []*model.Advisory
+}
+
+// adminUI serves the admin UI under its base path.
+type adminUI struct {
+	base string
+}
+
+// initAdmin registers the admin UI on the given router.
+func initAdmin(rootRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	adminRoute := rootRouter.PathPrefix("/admin")
+	// The base path includes the prefix of any tenant serving the admin UI.
+	base, err := adminRoute.GetPathTemplate()
+	if err != nil {
+		base = "/admin"
+	}
+	a := &adminUI{base: base}
+
+	adminRouter := adminRoute.Subrouter()
+	adminRouter.Handle("", addContext(a.handleAdminIndex)).Methods("GET")
+	adminRouter.Handle("/", addContext(a.handleAdminIndex)).Methods("GET")
+	adminRouter.Handle("/login", addContext(a.handleAdminLoginForm)).Methods("GET")
+	adminRouter.Handle("/login", addContext(a.handleAdminLogin)).Methods("POST")
+	adminRouter.Handle("/logout", addContext(a.handleAdminLogout)).Methods("POST")
+	adminRouter.Handle("/plugins", addContext(a.handleAdminPlugins)).Methods("GET")
+	adminRouter.Handle("/plugins/{id}", addContext(a.handleAdminPlugin)).Methods("GET")
+	adminRouter.Handle("/plugins/{id}/versions/{version}/delist", addContext(restrictWrites(a.handleAdminDelist))).Methods("POST")
+	adminRouter.Handle("/plugins/{id}/advisories", addContext(restrictWrites(a.handleAdminPublishAdvisory))).Methods("POST")
+	adminRouter.Handle("/stats", addContext(a.handleAdminStats)).Methods("GET")
+}
+
+// newAdminPage creates a page of the admin UI for the given request context.
+func (a *adminUI) newAdminPage(c *Context) *adminPage {
+	return &adminPage{
+		Base:    a.base,
+		Channel: c.Channel,
+		Query:   adminQuery(c),
+	}
+}
+
+// adminQuery returns the query string selecting the request's channel, if any.
+func adminQuery(c *Context) string {
+	if c.Channel == "" {
+		return ""
+	}
+
+	return "?" + url.Values{channelParameter: {c.Channel}}.Encode()
+}
+
+// adminCSRFToken derives the token guarding the forms of the moderator signed in with the given
+// bearer token, which a cross-site request cannot know.
+func adminCSRFToken(token string) string {
+	sum := sha256.Sum256([]byte("marketplace-admin-csrf:" + token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticateAdmin identifies the moderator signed in to the admin UI, redirecting to the sign in
+// page if there is none, and responding as forbidden if the user is not a moderator. Forms posted
+// must also carry the moderator's CSRF token.
+func (a *adminUI) authenticateAdmin(c *Context, w http.ResponseWriter, r *http.Request) (*adminPage, bool) {
+	if !c.AdminUI {
+		w.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+
+	cookie, err := r.Cookie(adminTokenCookie)
+	if err != nil || cookie.Value == "" || c.Authenticator == nil {
+		http.Redirect(w, r, a.base+"/login"+adminQuery(c), http.StatusSeeOther)
+		return nil, false
+	}
+
+	// The authenticator identifies users by the bearer token the cookie holds.
+	r.Header.Set("Authorization", bearerPrefix+cookie.Value)
+	userID, ok := c.Authenticator.Authenticate(r)
+	if !ok {
+		a.clearAdminCookie(w, r)
+		http.Redirect(w, r, a.base+"/login"+adminQuery(c), http.StatusSeeOther)
+		return nil, false
+	}
+	c.Logger = c.Logger.WithField("user", userID)
+	if !c.Moderators[userID] {
+		page := a.newAdminPage(c)
+		page.UserID = userID
+		renderAdminError(c, w, http.StatusForbidden, page, "Only moderators may manage the marketplace.")
+		return nil, false
+	}
+
+	page := a.newAdminPage(c)
+	page.UserID = userID
+	page.CSRF = adminCSRFToken(cookie.Value)
+
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, maxAdminFormSize)
+		if subtle.ConstantTimeCompare([]byte(r.PostFormValue(adminCSRFField)), []byte(page.CSRF)) != 1 {
+			c.Logger.Warn("Rejected admin form without a valid csrf token")
+			renderAdminError(c, w, http.StatusForbidden, page, "The form has expired. Please try again.")
+			return nil, false
+		}
+	}
+
+	return page, true
+}
+
+// setAdminCookie signs the moderator bearing the given token in to the admin UI.
+func (a *adminUI) setAdminCookie(w http.ResponseWriter, r *http.Request, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     adminTokenCookie,
+		Value:    token,
+		Path:     a.base,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// clearAdminCookie signs the moderator out of the admin UI.
+func (a *adminUI) clearAdminCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     adminTokenCookie,
+		Path:     a.base,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// renderAdminPage renders the named template of the admin UI with the given page.
+func renderAdminPage(c *Context, w http.ResponseWriter, statusCode int, name string, page *adminPage) {
+	var body bytes.Buffer
+	if err := adminTemplates.ExecuteTemplate(&body, name, page); err != nil {
+		c.Logger.WithError(err).Error("failed to render admin page")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(statusCode)
+	_, _ = body.WriteTo(w)
+}
+
+// renderAdminError renders the given message in place of the requested admin UI page.
+func renderAdminError(c *Context, w http.ResponseWriter, statusCode int, page *adminPage, message string) {
+	page.Title = http.StatusText(statusCode)
+	page.Error = message
+	renderAdminPage(c, w, statusCode, "error", page)
+}
+
+// handleAdminIndex responds to GET /admin, redirecting to the catalog.
+func (a *adminUI) handleAdminIndex(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !c.AdminUI {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, a.base+"/plugins"+adminQuery(c), http.StatusSeeOther)
+}
+
+// handleAdminLoginForm responds to GET /admin/login, rendering the sign in form.
+func (a *adminUI) handleAdminLoginForm(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !c.AdminUI {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	page := a.newAdminPage(c)
+	page.Title = "Sign in"
+	renderAdminPage(c, w, http.StatusOK, "login", page)
+}
+
+// handleAdminLogin responds to POST /admin/login, signing in the moderator bearing the token
+// given in the form.
+func (a *adminUI) handleAdminLogin(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !c.AdminUI {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAdminFormSize)
+	token := strings.TrimSpace(r.PostFormValue("token"))
+	page := a.newAdminPage(c)
+	page.Title = "Sign in"
+
+	authRequest, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to build authentication request")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	authRequest.Header.Set("Authorization", bearerPrefix+token)
+
+	var userID string
+	ok := false
+	if token != "" && c.Authenticator != nil {
+		userID, ok = c.Authenticator.Authenticate(authRequest)
+	}
+	if !ok || !c.Moderators[userID] {
+		c.Logger.Warn("Rejected admin sign in")
+		page.Error = "The token does not identify a moderator."
+		renderAdminPage(c, w, http.StatusUnauthorized, "login", page)
+		return
+	}
+	c.Logger.WithField("user", userID).Info("Signed in to admin UI")
+
+	a.setAdminCookie(w, r, token)
+	http.Redirect(w, r, a.base+"/plugins"+adminQuery(c), http.StatusSeeOther)
+}
+
+// handleAdminLogout responds to POST /admin/logout, signing the moderator out.
+func (a *adminUI) handleAdminLogout(c *Context, w http.ResponseWriter, r *http.Request) {
+	if _, ok := a.authenticateAdmin(c, w, r); !ok {
+		return
+	}
+
+	a.clearAdminCookie(w, r)
+	http.Redirect(w, r, a.base+"/login"+adminQuery(c), http.StatusSeeOther)
+}
+
+// handleAdminPlugins responds to GET /admin/plugins, listing the latest version of every plugin
+// matching the filter query parameter, regardless of compatibility.
+func (a *adminUI) handleAdminPlugins(c *Context, w http.ResponseWriter, r *http.Request) {
+	page, ok := a.authenticateAdmin(c, w, r)
+	if !ok {
+		return
+	}
+
+	page.Title = "Plugins"
+	page.Filter = r.URL.Query().Get("filter")
+	plugins, err := c.Store.GetPlugins(&model.PluginFilter{
+		PerPage:             model.AllPerPage,
+		Filter:              page.Filter,
+		IncludeIncompatible: true,
+	})
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to query plugins")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var downloads map[string]int64
+	if c.Stats != nil {
+		page.HasStats = true
+		downloads = c.Stats.MarketplaceStats().Plugins
+	}
+	for _, plugin := range plugins {
+		page.Plugins = append(page.Plugins, &adminPluginRow{
+			Plugin:    plugin,
+			ID:        plugin.Manifest.Id,
+			Downloads: downloads[plugin.Manifest.Id],
+		})
+	}
+
+	renderAdminPage(c, w, http.StatusOK, "plugins", page)
+}
+
+// handleAdminPlugin responds to GET /admin/plugins/{id}, detailing every version of the given
+// plugin along with its advisories and downloads.
+func (a *adminUI) handleAdminPlugin(c *Context, w http.ResponseWriter, r *http.Request) {
+	page, ok := a.authenticateAdmin(c, w, r)
+	if !ok {
+		return
+	}
+
+	a.renderAdminPlugin(c, w, r, http.StatusOK, page)
+}
+
+// renderAdminPlugin renders the page of the plugin given by the request.
+func (a *adminUI) renderAdminPlugin(c *Context, w http.ResponseWriter, r *http.Request, statusCode int, page *adminPage) {
+	versions, err := c.Store.GetPluginVersions(mux.Vars(r)["id"])
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to query plugin versions")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(versions) == 0 {
+		renderAdminError(c, w, http.StatusNotFound, page, "No such plugin.")
+		return
+	}
+
+	id := versions[0].Manifest.Id
+	page.Title = versions[0].Manifest.Name
+	page.Plugin = versions[0]
+	page.CanPublishAdvisories = c.Advisories != nil
+	page.Severities = []model.AdvisorySeverity{
+		model.AdvisorySeverityLow,
+		model.AdvisorySeverityMedium,
+		model.AdvisorySeverityHigh,
+		model.AdvisorySeverityCritical,
+	}
+	if _, ok := unwrapStore(c.Store).(Enumerator); ok {
+		_, page.CanDelist = unwrapStore(c.Store).(Importer)
+	}
+	if c.Advisories != nil {
+		page.Advisories = c.Advisories.GetAdvisories(id)
+	}
+
+	var downloads map[string]int64
+	if c.Stats != nil {
+		page.HasStats = true
+		downloads = c.Stats.PluginStats(id).Versions
+	}
+	for _, version := range versions {
+		row := &adminVersionRow{Plugin: version, Downloads: downloads[version.Manifest.Version]}
+		for _, advisory := range page.Advisories {
+			if advisory.Affects(version.Manifest.Version) {
+				row.Advisories = append(row.Advisories, advisory)
+			}
+		}
+		page.Versions = append(page.Versions, row)
+	}
+
+	renderAdminPage(c, w, statusCode, "plugin", page)
+}
+
+// handleAdminDelist responds to POST /admin/plugins/{id}/versions/{version}/delist, importing the
+// catalog without the given plugin version so that it is no longer served, and recording its
+// tombstone with the reason given, if any.
+func (a *adminUI) handleAdminDelist(c *Context, w http.ResponseWriter, r *http.Request) {
+	page, ok := a.authenticateAdmin(c, w, r)
+	if !ok {
+		return
+	}
+
+	enumerator, ok := unwrapStore(c.Store).(Enumerator)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	importer, ok := unwrapStore(c.Store).(Importer)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, version := vars["id"], vars["version"]
+	var remaining []*model.Plugin
+	delisted := false
+	for _, plugin := range enumerator.AllPlugins() {
+		if plugin.Manifest.Id == id && plugin.Manifest.Version == version {
+			delisted = true
+			continue
+		}
+		remaining = append(remaining, plugin)
+	}
+	if !delisted {
+		renderAdminError(c, w, http.StatusNotFound, page, "No such plugin version.")
+		return
+	}
+
+	var database bytes.Buffer
+	if err := model.PluginsToWriter(&database, remaining, model.PluginsWriterOptions{}); err != nil {
+		c.Logger.WithError(err).Error("failed to encode catalog")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	snapshot, changes, err := importer.Import(&database, c.Logger)
+	if errors.Cause(err) == catalog.ErrImportDisabled {
+		page.Error = "Delisting requires the server to allow catalog imports."
+		a.renderAdminPlugin(c, w, r, http.StatusConflict, page)
+		return
+	} else if validationErr, ok := errors.Cause(err).(*catalog.ValidationError); ok {
+		c.Logger.WithError(err).Warn("Rejected invalid delisting")
+		page.Error = "The catalog would be invalid without this version: " + strings.Join(validationErr.Problems, "; ")
+		a.renderAdminPlugin(c, w, r, http.StatusBadRequest, page)
+		return
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to delist plugin version")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	reason := strings.TrimSpace(r.PostFormValue("reason"))
+	if reason == "" {
+		reason = model.TombstoneReasonDelisted
+	}
+	recordTombstones(c, changes, reason)
+	c.Logger.WithFields(logrus.Fields{
+		"plugin_id": id,
+		"version":   version,
+		"snapshot":  snapshot.ID,
+	}).Info("Delisted plugin version")
+
+	http.Redirect(w, r, a.base+"/plugins/"+url.PathEscape(id)+adminQuery(c), http.StatusSeeOther)
+}
+
+// handleAdminPublishAdvisory responds to POST /admin/plugins/{id}/advisories, publishing an
+// advisory for the given plugin.
+func (a *adminUI) handleAdminPublishAdvisory(c *Context, w http.ResponseWriter, r *http.Request) {
+	page, ok := a.authenticateAdmin(c, w, r)
+	if !ok {
+		return
+	}
+	if c.Advisories == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	advisory, err := newAdvisory(&PublishAdvisoryRequest{
+		PluginID:    mux.Vars(r)["id"],
+		Severity:    model.AdvisorySeverity(r.PostFormValue("severity")),
+		Description: r.PostFormValue("description"),
+		FixedIn:     r.PostFormValue("fixed_in"),
+	}, page.UserID)
+	if err != nil {
+		page.Error = err.Error()
+		a.renderAdminPlugin(c, w, r, http.StatusBadRequest, page)
+		return
+	}
+
+	advisory.PluginID, ok = resolvePlugin(c, w, r, advisory.PluginID)
+	if !ok {
+		return
+	}
+
+	advisory, err = c.Advisories.Publish(advisory)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to publish advisory")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	c.Logger.WithField("advisory", advisory.ID).Info("Published advisory")
+	purgeResponseCache(c)
+
+	http.Redirect(w, r, a.base+"/plugins/"+url.PathEscape(advisory.PluginID)+adminQuery(c), http.StatusSeeOther)
+}
+
+// handleAdminStats responds to GET /admin/stats, summarizing downloads across the marketplace.
+func (a *adminUI) handleAdminStats(c *Context, w http.ResponseWriter, r *http.Request) {
+	page, ok := a.authenticateAdmin(c, w, r)
+	if !ok {
+		return
+	}
+	page.Title = "Statistics"
+
+	if c.Stats != nil {
+		page.HasStats = true
+		page.Stats = c.Stats.MarketplaceStats()
+		for id, downloads := range page.Stats.Plugins {
+			page.Top = append(page.Top, &adminPluginRow{ID: id, Downloads: downloads})
+		}
+		sort.Slice(page.Top, func(i, j int) bool {
+			if page.Top[i].Downloads != page.Top[j].Downloads {
+				return page.Top[i].Downloads > page.Top[j].Downloads
+			}
+			return page.Top[i].ID < page.Top[j].ID
+		})
+	}
+
+	renderAdminPage(c, w, http.StatusOK, "stats", page)
+}
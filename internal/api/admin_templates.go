@@ -0,0 +1,161 @@
+package api
+
+// adminTemplatesText defines the pages of the admin UI. Each page is a named template rendering a
+// complete document from an adminPage.
+const adminTemplatesText = `
+{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - Marketplace Admin</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #3d3c40; }
+header { background: #1e325c; color: #fff; padding: 0.75em 1.5em; display: flex; align-items: center; gap: 1.5em; }
+header a { color: #fff; }
+header form { margin-left: auto; }
+main { padding: 1.5em; max-width: 72em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+th, td { text-align: left; padding: 0.4em 0.6em; border-bottom: 1px solid #ddd; vertical-align: top; }
+.error { background: #fdecea; border: 1px solid #d24b4e; padding: 0.75em; margin-bottom: 1em; }
+.advisory { color: #d24b4e; }
+label { display: block; margin: 0.5em 0 0.2em; }
+textarea { width: 100%; max-width: 40em; }
+</style>
+</head>
+<body>
+<header>
+<strong>Marketplace Admin{{if .Channel}} ({{.Channel}}){{end}}</strong>
+{{if .UserID}}
+<a href="{{.Base}}/plugins{{.Query}}">Plugins</a>
+<a href="{{.Base}}/stats{{.Query}}">Statistics</a>
+<form method="post" action="{{.Base}}/logout{{.Query}}">
+<input type="hidden" name="csrf" value="{{.CSRF}}">
+{{.UserID}} <button type="submit">Sign out</button>
+</form>
+{{end}}
+</header>
+<main>
+<h1>{{.Title}}</h1>
+{{if .Error}}<div class="error">{{.Error}}</div>{{end}}
+{{end}}
+
+{{define "footer"}}</main>
+</body>
+</html>
+{{end}}
+
+{{define "error"}}{{template "header" .}}{{template "footer" .}}{{end}}
+
+{{define "login"}}{{template "header" .}}
+<form method="post" action="{{.Base}}/login{{.Query}}">
+<label for="token">Moderator token</label>
+<input type="password" id="token" name="token" autocomplete="off" required>
+<button type="submit">Sign in</button>
+</form>
+{{template "footer" .}}{{end}}
+
+{{define "plugins"}}{{template "header" .}}
+<form method="get" action="{{.Base}}/plugins">
+{{if .Channel}}<input type="hidden" name="channel" value="{{.Channel}}">{{end}}
+<input type="search" name="filter" value="{{.Filter}}" placeholder="Filter plugins">
+<button type="submit">Filter</button>
+</form>
+<table>
+<thead><tr><th>Plugin</th><th>Id</th><th>Latest version</th><th>Author</th><th>Released</th>{{if .HasStats}}<th>Downloads</th>{{end}}</tr></thead>
+<tbody>
+{{range .Plugins}}
+<tr>
+<td><a href="{{$.Base}}/plugins/{{.ID}}{{$.Query}}">{{.Plugin.Manifest.Name}}</a></td>
+<td>{{.ID}}</td>
+<td>{{.Plugin.Manifest.Version}}</td>
+<td>{{.Plugin.AuthorType}}</td>
+<td>{{date .Plugin.ReleasedAt}}</td>
+{{if $.HasStats}}<td>{{.Downloads}}</td>{{end}}
+</tr>
+{{else}}
+<tr><td colspan="6">No plugins found.</td></tr>
+{{end}}
+</tbody>
+</table>
+{{template "footer" .}}{{end}}
+
+{{define "plugin"}}{{template "header" .}}
+<p>{{.Plugin.Manifest.Description}}</p>
+<h2>Versions</h2>
+<table>
+<thead><tr><th>Version</th><th>Release stage</th><th>Min server version</th><th>Released</th>{{if .HasStats}}<th>Downloads</th>{{end}}<th>Advisories</th>{{if .CanDelist}}<th></th>{{end}}</tr></thead>
+<tbody>
+{{range .Versions}}
+<tr>
+<td><a href="{{.Plugin.DownloadURL}}">{{.Plugin.Manifest.Version}}</a></td>
+<td>{{.Plugin.ReleaseStage}}</td>
+<td>{{.Plugin.Manifest.MinServerVersion}}</td>
+<td>{{date .Plugin.ReleasedAt}}</td>
+{{if $.HasStats}}<td>{{.Downloads}}</td>{{end}}
+<td>{{range .Advisories}}<span class="advisory">{{.Severity}}</span> {{end}}</td>
+{{if $.CanDelist}}
+<td>
+<form method="post" action="{{$.Base}}/plugins/{{.Plugin.Manifest.Id}}/versions/{{.Plugin.Manifest.Version}}/delist{{$.Query}}" onsubmit="return confirm('Delist {{.Plugin.Manifest.Id}} {{.Plugin.Manifest.Version}}?')">
+<input type="hidden" name="csrf" value="{{$.CSRF}}">
+<input type="text" name="reason" placeholder="Reason (optional)" maxlength="200">
+<button type="submit">Delist</button>
+</form>
+</td>
+{{end}}
+</tr>
+{{end}}
+</tbody>
+</table>
+
+<h2>Advisories</h2>
+<table>
+<thead><tr><th>Severity</th><th>Description</th><th>Fixed in</th><th>Published</th></tr></thead>
+<tbody>
+{{range .Advisories}}
+<tr><td class="advisory">{{.Severity}}</td><td>{{.Description}}</td><td>{{.FixedIn}}</td><td>{{date .PublishedAt}} by {{.PublisherID}}</td></tr>
+{{else}}
+<tr><td colspan="4">No advisories published.</td></tr>
+{{end}}
+</tbody>
+</table>
+
+{{if .CanPublishAdvisories}}
+<h2>Publish an advisory</h2>
+<form method="post" action="{{.Base}}/plugins/{{.Plugin.Manifest.Id}}/advisories{{.Query}}">
+<input type="hidden" name="csrf" value="{{.CSRF}}">
+<label for="severity">Severity</label>
+<select id="severity" name="severity">
+{{range .Severities}}<option value="{{.}}">{{.}}</option>{{end}}
+</select>
+<label for="description">Description</label>
+<textarea id="description" name="description" rows="4" required></textarea>
+<label for="fixed_in">Fixed in version, if any</label>
+<input type="text" id="fixed_in" name="fixed_in">
+<p><button type="submit">Publish</button></p>
+</form>
+{{end}}
+{{template "footer" .}}{{end}}
+
+{{define "stats"}}{{template "header" .}}
+{{if .HasStats}}
+<p>{{.Stats.TotalDownloads}} downloads, {{.Stats.TotalInstalls}} installs and {{.Stats.TotalUpgrades}} upgrades reported.</p>
+<h2>Downloads by plugin</h2>
+<table>
+<thead><tr><th>Plugin</th><th>Downloads</th></tr></thead>
+<tbody>
+{{range .Top}}<tr><td><a href="{{$.Base}}/plugins/{{.ID}}{{$.Query}}">{{.ID}}</a></td><td>{{.Downloads}}</td></tr>{{end}}
+</tbody>
+</table>
+<h2>Downloads by day</h2>
+<table>
+<thead><tr><th>Date</th><th>Downloads</th></tr></thead>
+<tbody>
+{{range .Stats.Daily}}<tr><td>{{.Date}}</td><td>{{.Downloads}}</td></tr>{{end}}
+</tbody>
+</table>
+{{else}}
+<p>The server does not record download statistics.</p>
+{{end}}
+{{template "footer" .}}{{end}}
+`
@@ -0,0 +1,288 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/advisories"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+var adminCSRFPattern = regexp.MustCompile(`name="csrf" value="([0-9a-f]+)"`)
+
+func TestAdminUI(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	pluginV1 := &model.Plugin{
+		DownloadURL:  "https://example.com/jira-3.0.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "jira", Name: "Jira", Version: "3.0.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	pluginV2 := &model.Plugin{
+		DownloadURL:  "https://example.com/jira-3.0.1.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "jira", Name: "Jira", Version: "3.0.1"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	demoPlugin := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.9.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.9.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+
+	dir, err := ioutil.TempDir("", "admin")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	data, err := json.Marshal([]*model.Plugin{pluginV1, pluginV2, demoPlugin})
+	require.NoError(t, err)
+	database := filepath.Join(dir, "plugins.json")
+	require.NoError(t, ioutil.WriteFile(database, data, 0600))
+	initialStore, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+	pluginCatalog := catalog.NewWithOptions(initialStore, catalog.Options{
+		Backend: &catalog.FileBackend{Path: database},
+	})
+
+	registry, err := advisories.New(&advisories.FileBackend{Path: filepath.Join(dir, "advisories.json")})
+	require.NoError(t, err)
+
+	serve := func(t *testing.T, context *api.Context) (*httptest.Server, *http.Client) {
+		router := mux.NewRouter()
+		api.Register(router, context)
+		ts := httptest.NewServer(router)
+
+		jar, err := cookiejar.New(nil)
+		require.NoError(t, err)
+		client := &http.Client{
+			Jar: jar,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+
+		return ts, client
+	}
+
+	setUp := func(t *testing.T, adminUI bool) (*httptest.Server, *http.Client) {
+		return serve(t, &api.Context{
+			Store:      pluginCatalog,
+			Advisories: registry,
+			Authenticator: api.TokenAuthenticator{
+				"alice-token":     "alice",
+				"moderator-token": "moderator",
+			},
+			Moderators: map[string]bool{"moderator": true},
+			AdminUI:    adminUI,
+			Logger:     logger,
+		})
+	}
+
+	get := func(t *testing.T, client *http.Client, u string) (*http.Response, string) {
+		resp, err := client.Get(u)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, string(body)
+	}
+
+	post := func(t *testing.T, client *http.Client, u string, form url.Values) (*http.Response, string) {
+		resp, err := client.PostForm(u, form)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, string(body)
+	}
+
+	csrfToken := func(t *testing.T, body string) string {
+		match := adminCSRFPattern.FindStringSubmatch(body)
+		require.NotNil(t, match, "page has no csrf token")
+		return match[1]
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		ts, client := setUp(t, false)
+		defer ts.Close()
+
+		resp, _ := get(t, client, ts.URL+"/admin/login")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp, _ = get(t, client, ts.URL+"/admin/plugins")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("signed out", func(t *testing.T) {
+		ts, client := setUp(t, true)
+		defer ts.Close()
+
+		resp, _ := get(t, client, ts.URL+"/admin/plugins")
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		require.Equal(t, "/admin/login", resp.Header.Get("Location"))
+
+		resp, body := get(t, client, ts.URL+"/admin/login")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, `name="token"`)
+	})
+
+	t.Run("not a moderator", func(t *testing.T) {
+		ts, client := setUp(t, true)
+		defer ts.Close()
+
+		resp, body := post(t, client, ts.URL+"/admin/login", url.Values{"token": {"alice-token"}})
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		require.Contains(t, body, "does not identify a moderator")
+
+		resp, _ = post(t, client, ts.URL+"/admin/login", url.Values{"token": {"unknown-token"}})
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("moderator", func(t *testing.T) {
+		ts, client := setUp(t, true)
+		defer ts.Close()
+
+		resp, _ := post(t, client, ts.URL+"/admin/login", url.Values{"token": {"moderator-token"}})
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		require.Equal(t, "/admin/plugins", resp.Header.Get("Location"))
+		cookies := resp.Cookies()
+		require.Len(t, cookies, 1)
+		require.True(t, cookies[0].HttpOnly)
+
+		t.Run("list plugins", func(t *testing.T) {
+			resp, body := get(t, client, ts.URL+"/admin/plugins")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+			require.Contains(t, body, "Jira")
+			require.Contains(t, body, "3.0.1")
+			require.Contains(t, body, "Demo")
+
+			resp, body = get(t, client, ts.URL+"/admin/plugins?filter=demo")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Contains(t, body, "Demo")
+			require.NotContains(t, body, "Jira")
+		})
+
+		t.Run("unknown plugin", func(t *testing.T) {
+			resp, _ := get(t, client, ts.URL+"/admin/plugins/unknown")
+			require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		})
+
+		resp, body := get(t, client, ts.URL+"/admin/plugins/jira")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, body, "3.0.0")
+		require.Contains(t, body, "3.0.1")
+		csrf := csrfToken(t, body)
+
+		t.Run("without csrf token", func(t *testing.T) {
+			resp, _ := post(t, client, ts.URL+"/admin/plugins/jira/versions/3.0.0/delist", url.Values{})
+			require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+			resp, _ = post(t, client, ts.URL+"/admin/plugins/jira/advisories", url.Values{
+				"csrf":        {"invalid"},
+				"severity":    {string(model.AdvisorySeverityHigh)},
+				"description": {"Webhook secrets are logged."},
+			})
+			require.Equal(t, http.StatusForbidden, resp.StatusCode)
+			require.Empty(t, registry.GetAdvisories("jira"))
+		})
+
+		t.Run("publish advisory", func(t *testing.T) {
+			resp, body := post(t, client, ts.URL+"/admin/plugins/jira/advisories", url.Values{
+				"csrf":        {csrf},
+				"severity":    {"severe"},
+				"description": {"Webhook secrets are logged."},
+			})
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			require.Contains(t, body, "severity")
+
+			resp, _ = post(t, client, ts.URL+"/admin/plugins/jira/advisories", url.Values{
+				"csrf":        {csrf},
+				"severity":    {string(model.AdvisorySeverityHigh)},
+				"description": {"Webhook secrets are logged."},
+				"fixed_in":    {"3.0.1"},
+			})
+			require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+			require.Equal(t, "/admin/plugins/jira", resp.Header.Get("Location"))
+
+			published := registry.GetAdvisories("jira")
+			require.Len(t, published, 1)
+			require.Equal(t, "moderator", published[0].PublisherID)
+			require.Equal(t, model.AdvisorySeverityHigh, published[0].Severity)
+
+			_, body = get(t, client, ts.URL+"/admin/plugins/jira")
+			require.Contains(t, body, "Webhook secrets are logged.")
+		})
+
+		t.Run("delist version", func(t *testing.T) {
+			resp, _ := post(t, client, ts.URL+"/admin/plugins/jira/versions/2.0.0/delist", url.Values{"csrf": {csrf}})
+			require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+			resp, _ = post(t, client, ts.URL+"/admin/plugins/jira/versions/3.0.0/delist", url.Values{"csrf": {csrf}})
+			require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+
+			versions, err := pluginCatalog.GetPluginVersions("jira")
+			require.NoError(t, err)
+			require.Len(t, versions, 1)
+			require.Equal(t, "3.0.1", versions[0].Manifest.Version)
+
+			persisted, err := ioutil.ReadFile(database)
+			require.NoError(t, err)
+			require.False(t, strings.Contains(string(persisted), "jira-3.0.0"))
+		})
+
+		t.Run("stats disabled", func(t *testing.T) {
+			resp, body := get(t, client, ts.URL+"/admin/stats")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Contains(t, body, "does not record download statistics")
+		})
+
+		t.Run("sign out", func(t *testing.T) {
+			resp, _ := post(t, client, ts.URL+"/admin/logout", url.Values{"csrf": {csrf}})
+			require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+
+			resp, _ = get(t, client, ts.URL+"/admin/plugins")
+			require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+			require.Equal(t, "/admin/login", resp.Header.Get("Location"))
+		})
+	})
+
+	t.Run("import disabled", func(t *testing.T) {
+		fixedStore, err := store.New(bytes.NewReader(data), logger)
+		require.NoError(t, err)
+		ts, client := serve(t, &api.Context{
+			Store:         catalog.New(fixedStore),
+			Authenticator: api.TokenAuthenticator{"moderator-token": "moderator"},
+			Moderators:    map[string]bool{"moderator": true},
+			AdminUI:       true,
+			Logger:        logger,
+		})
+		defer ts.Close()
+
+		resp, _ := post(t, client, ts.URL+"/admin/login", url.Values{"token": {"moderator-token"}})
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		_, body := get(t, client, ts.URL+"/admin/plugins/jira")
+		require.NotContains(t, body, "Publish an advisory")
+
+		resp, body = post(t, client, ts.URL+"/admin/plugins/jira/versions/3.0.0/delist", url.Values{"csrf": {csrfToken(t, body)}})
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+		require.Contains(t, body, "allow catalog imports")
+	})
+}
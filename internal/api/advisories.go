@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/advisories"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+)
+
+// maxAdvisoryRequestSize bounds the body of a request publishing an advisory.
+const maxAdvisoryRequestSize = 64 * 1024
+
+// initAdvisories registers the security advisory endpoints on the given router.
+func initAdvisories(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	advisoriesRouter := apiRouter.PathPrefix("/advisories").Subrouter()
+	advisoriesRouter.Handle("", addContext(handleGetAdvisories)).Methods("GET")
+	advisoriesRouter.Handle("", addContext(restrictWrites(handlePublishAdvisory))).Methods("POST")
+	advisoriesRouter.Handle("/{id}", addContext(restrictWrites(handleWithdrawAdvisory))).Methods("DELETE")
+	apiRouter.Handle("/plugins/{id}/advisories", addContext(handleGetPluginAdvisories)).Methods("GET")
+}
+
+// PublishAdvisoryRequest describes the parameters to publish a security advisory for a plugin.
+type PublishAdvisoryRequest struct {
+	PluginID    string                 `json:"plugin_id"`
+	Severity    model.AdvisorySeverity `json:"severity"`
+	Description string                 `json:"description"`
+	// FixedIn is the first version without the vulnerability, or empty if every version is
+	// affected.
+	FixedIn string `json:"fixed_in,omitempty"`
+}
+
+// authenticateAdvisories identifies the moderator publishing or withdrawing an advisory,
+// responding on failure, if advisories are not enabled, or if the user is not a moderator.
+func authenticateAdvisories(c *Context, w http.ResponseWriter, r *http.Request) (string, bool) {
+	if c.Advisories == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return "", false
+	}
+
+	return authenticateModerator(c, w, r)
+}
+
+// handleGetAdvisories responds to GET /api/v1/advisories, returning every published advisory, or
+// those of the plugin given by the plugin_id query parameter, most recent first.
+func handleGetAdvisories(c *Context, w http.ResponseWriter, r *http.Request) {
+	result := []*model.Advisory{}
+	if c.Advisories != nil {
+		result = c.Advisories.GetAdvisories(r.URL.Query().Get("plugin_id"))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, result)
+}
+
+// handleGetPluginAdvisories responds to GET /api/v1/plugins/{id}/advisories, returning the
+// advisories published for the given plugin, most recent first.
+func handleGetPluginAdvisories(c *Context, w http.ResponseWriter, r *http.Request) {
+	id, ok := resolvePlugin(c, w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	result := []*model.Advisory{}
+	if c.Advisories != nil {
+		result = c.Advisories.GetAdvisories(id)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, result)
+}
+
+// newAdvisory validates the given request to publish an advisory, returning the advisory to be
+// published by the given moderator under the plugin id it requests.
+func newAdvisory(request *PublishAdvisoryRequest, publisherID string) (*model.Advisory, error) {
+	advisory := &model.Advisory{
+		PluginID:    request.PluginID,
+		Severity:    request.Severity,
+		Description: strings.TrimSpace(request.Description),
+		FixedIn:     model.NormalizeVersion(strings.TrimSpace(request.FixedIn)),
+		PublisherID: publisherID,
+	}
+	if advisory.PluginID == "" || !advisory.Severity.IsValid() || advisory.Description == "" {
+		return nil, errors.New("advisory request lacks a plugin id, valid severity or description")
+	}
+	if advisory.FixedIn != "" {
+		if _, err := model.ParseVersion(advisory.FixedIn); err != nil {
+			return nil, errors.Wrapf(err, "invalid fixed version %s", advisory.FixedIn)
+		}
+	}
+
+	return advisory, nil
+}
+
+// handlePublishAdvisory responds to POST /api/v1/advisories, publishing an advisory flagging the
+// affected versions of the given plugin in subsequent responses.
+func handlePublishAdvisory(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticateAdvisories(c, w, r)
+	if !ok {
+		return
+	}
+
+	var request PublishAdvisoryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdvisoryRequestSize)).Decode(&request); err != nil {
+		c.Logger.WithError(err).Error("failed to decode advisory request")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	advisory, err := newAdvisory(&request, userID)
+	if err != nil {
+		c.Logger.WithError(err).Error("invalid advisory request")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	// Publish the advisory under the plugin's manifest id, which the requested id need only match
+	// case-insensitively or as an old id.
+	advisory.PluginID, ok = resolvePlugin(c, w, r, request.PluginID)
+	if !ok {
+		return
+	}
+
+	advisory, err = c.Advisories.Publish(advisory)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to publish advisory")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	c.Logger.WithField("advisory", advisory.ID).Info("Published advisory")
+	purgeResponseCache(c)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	outputJSON(c, w, advisory)
+}
+
+// handleWithdrawAdvisory responds to DELETE /api/v1/advisories/{id}, withdrawing the given
+// advisory.
+func handleWithdrawAdvisory(c *Context, w http.ResponseWriter, r *http.Request) {
+	if _, ok := authenticateAdvisories(c, w, r); !ok {
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	err := c.Advisories.Withdraw(id)
+	if errors.Cause(err) == advisories.ErrNotFound {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to withdraw advisory")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	c.Logger.WithField("advisory", id).Info("Withdrew advisory")
+	purgeResponseCache(c)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// withAdvisories returns copies of the given plugins annotated with the advisories affecting each
+// version, leaving the plugins held by the store untouched.
+func withAdvisories(c *Context, plugins []*model.Plugin) []*model.Plugin {
+	if c.Advisories == nil {
+		return plugins
+	}
+
+	byPlugin := c.Advisories.AdvisoriesByPlugin()
+	if len(byPlugin) == 0 {
+		return plugins
+	}
+
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		var affecting []*model.Advisory
+		for _, advisory := range byPlugin[plugin.Manifest.Id] {
+			if advisory.Affects(plugin.Manifest.Version) {
+				affecting = append(affecting, advisory)
+			}
+		}
+		if len(affecting) == 0 {
+			result = append(result, plugin)
+			continue
+		}
+
+		flaggedPlugin := *plugin
+		flaggedPlugin.Advisories = affecting
+		result = append(result, &flaggedPlugin)
+	}
+
+	return result
+}
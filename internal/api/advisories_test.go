@@ -0,0 +1,148 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/advisories"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisories(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	pluginV1 := &model.Plugin{
+		DownloadURL:  "https://example.com/jira-3.0.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "jira", Name: "Jira", Version: "3.0.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	pluginV2 := &model.Plugin{
+		DownloadURL:  "https://example.com/jira-3.0.1.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "jira", Name: "Jira", Version: "3.0.1"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+
+	data, err := json.Marshal([]*model.Plugin{pluginV1, pluginV2})
+	require.NoError(t, err)
+	store, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "advisories")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	registry, err := advisories.New(&advisories.FileBackend{Path: filepath.Join(dir, "advisories.json")})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:      store,
+		Advisories: registry,
+		Authenticator: api.TokenAuthenticator{
+			"alice-token":     "alice",
+			"moderator-token": "moderator",
+		},
+		Moderators: map[string]bool{"moderator": true},
+		Logger:     logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	clientFor := func(token string) *api.Client {
+		client := api.NewClient(ts.URL)
+		client.Token = token
+		return client
+	}
+	alice := clientFor("alice-token")
+	moderator := clientFor("moderator-token")
+
+	request := &api.PublishAdvisoryRequest{
+		PluginID:    "Jira",
+		Severity:    model.AdvisorySeverityHigh,
+		Description: "Webhook secrets are logged.",
+		FixedIn:     "3.0.1",
+	}
+
+	t.Run("not a moderator", func(t *testing.T) {
+		_, err := alice.PublishAdvisory(request)
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := moderator.PublishAdvisory(&api.PublishAdvisoryRequest{PluginID: "jira", Severity: "severe", Description: "Leaks secrets."})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+
+		_, err = moderator.PublishAdvisory(&api.PublishAdvisoryRequest{PluginID: "jira", Severity: model.AdvisorySeverityLow, Description: "Leaks secrets.", FixedIn: "next"})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		_, err := moderator.PublishAdvisory(&api.PublishAdvisoryRequest{PluginID: "unknown", Severity: model.AdvisorySeverityLow, Description: "Leaks secrets."})
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	advisory, err := moderator.PublishAdvisory(request)
+	require.NoError(t, err)
+	require.Equal(t, "jira", advisory.PluginID)
+	require.Equal(t, "moderator", advisory.PublisherID)
+
+	t.Run("lists advisories", func(t *testing.T) {
+		listed, err := alice.GetAdvisories("")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Advisory{advisory}, listed)
+
+		listed, err = alice.GetAdvisories("demo")
+		require.NoError(t, err)
+		require.Empty(t, listed)
+	})
+
+	t.Run("flags affected versions", func(t *testing.T) {
+		plugins, err := alice.GetPluginVersions("jira")
+		require.NoError(t, err)
+		require.Len(t, plugins, 2)
+		require.Empty(t, plugins[0].Advisories)
+		require.Equal(t, []*model.Advisory{advisory}, plugins[1].Advisories)
+
+		plugin, err := api.FindPlugin(alice, "jira", "3.0.0")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Advisory{advisory}, plugin.Advisories)
+
+		plugin, err = alice.GetPlugin("jira")
+		require.NoError(t, err)
+		require.Empty(t, plugin.Advisories)
+	})
+
+	t.Run("withdraw", func(t *testing.T) {
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, alice.WithdrawAdvisory(advisory.ID))
+		require.Equal(t, api.ErrNotFound, moderator.WithdrawAdvisory("unknown"))
+
+		require.NoError(t, moderator.WithdrawAdvisory(advisory.ID))
+
+		plugins, err := alice.GetPluginVersions("jira")
+		require.NoError(t, err)
+		require.Empty(t, plugins[1].Advisories)
+	})
+}
+
+func TestAdvisoriesDisabled(t *testing.T) {
+	client, tearDown := setupApi(t, nil)
+	defer tearDown()
+
+	advisories, err := client.GetAdvisories("")
+	require.NoError(t, err)
+	require.Empty(t, advisories)
+
+	_, err = client.PublishAdvisory(&api.PublishAdvisoryRequest{PluginID: "jira", Severity: model.AdvisorySeverityLow, Description: "Leaks secrets."})
+	require.Equal(t, api.ErrNotFound, err)
+}
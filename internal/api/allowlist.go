@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// forwardedForHeader lists the addresses a request was forwarded through, as appended by each proxy.
+const forwardedForHeader = "X-Forwarded-For"
+
+// IPAllowlist restricts requests to those originating from a set of networks.
+type IPAllowlist struct {
+	Networks []*net.IPNet
+	// TrustForwardedFor identifies the client by the last address in the X-Forwarded-For header,
+	// as appended by a trusted load balancer, instead of by the connection's remote address.
+	TrustForwardedFor bool
+}
+
+// ParseIPAllowlist parses the given CIDR ranges, such as 10.0.0.0/8, into an allowlist. Bare
+// addresses are accepted as ranges containing only that address.
+func ParseIPAllowlist(cidrs []string) (*IPAllowlist, error) {
+	allowlist := &IPAllowlist{}
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, errors.Errorf("invalid address %s", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			allowlist.Networks = append(allowlist.Networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid range %s", cidr)
+		}
+		allowlist.Networks = append(allowlist.Networks, network)
+	}
+
+	return allowlist, nil
+}
+
+// Allows reports whether the given request originates from an allowed network.
+func (a *IPAllowlist) Allows(r *http.Request) bool {
+	ip := clientIP(r, a.TrustForwardedFor)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range a.Networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientIP returns the address of the client making the request, or nil if it cannot be parsed.
+// If trustForwardedFor is set, the client is identified by the last address in the
+// X-Forwarded-For header, as appended by a trusted load balancer.
+func clientIP(r *http.Request, trustForwardedFor bool) net.IP {
+	if trustForwardedFor {
+		if forwardedFor := r.Header.Get(forwardedForHeader); forwardedFor != "" {
+			addresses := strings.Split(forwardedFor, ",")
+			return net.ParseIP(strings.TrimSpace(addresses[len(addresses)-1]))
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return net.ParseIP(host)
+}
+
+// restrictWrites wraps the given handler of a mutating endpoint, responding as forbidden to
+// requests from outside the context's write allowlist, if any, before authenticating them.
+func restrictWrites(handler contextHandlerFunc) contextHandlerFunc {
+	return func(c *Context, w http.ResponseWriter, r *http.Request) {
+		if c.WriteAllowlist != nil && !c.WriteAllowlist.Allows(r) {
+			c.Logger.WithField("remote_addr", r.RemoteAddr).Warn("Rejected write from outside allowlist")
+			writeError(c, w, r, http.StatusForbidden)
+			return
+		}
+
+		handler(c, w, r)
+	}
+}
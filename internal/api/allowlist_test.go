@@ -0,0 +1,106 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+)
+
+func TestParseIPAllowlist(t *testing.T) {
+	t.Run("ranges and addresses", func(t *testing.T) {
+		allowlist, err := api.ParseIPAllowlist([]string{"10.0.0.0/8", " 192.0.2.1 ", "2001:db8::/32", "::1"})
+		require.NoError(t, err)
+		require.Len(t, allowlist.Networks, 4)
+		require.Equal(t, "192.0.2.1/32", allowlist.Networks[1].String())
+		require.Equal(t, "::1/128", allowlist.Networks[3].String())
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := api.ParseIPAllowlist([]string{"10.0.0.0/33"})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := api.ParseIPAllowlist([]string{"example.com"})
+		require.EqualError(t, err, "invalid address example.com")
+	})
+}
+
+func TestIPAllowlistAllows(t *testing.T) {
+	allowlist, err := api.ParseIPAllowlist([]string{"10.0.0.0/8", "2001:db8::/32"})
+	require.NoError(t, err)
+
+	request := func(remoteAddr, forwardedFor string) *http.Request {
+		r := httptest.NewRequest("POST", "/api/v1/submissions", nil)
+		r.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		return r
+	}
+
+	t.Run("remote address", func(t *testing.T) {
+		require.True(t, allowlist.Allows(request("10.1.2.3:1234", "")))
+		require.True(t, allowlist.Allows(request("[2001:db8::1]:1234", "")))
+		require.False(t, allowlist.Allows(request("192.0.2.1:1234", "")))
+		require.False(t, allowlist.Allows(request("invalid", "")))
+	})
+
+	t.Run("forwarded for ignored unless trusted", func(t *testing.T) {
+		require.False(t, allowlist.Allows(request("192.0.2.1:1234", "10.1.2.3")))
+	})
+
+	t.Run("trusted forwarded for", func(t *testing.T) {
+		trusting := *allowlist
+		trusting.TrustForwardedFor = true
+
+		require.True(t, trusting.Allows(request("192.0.2.1:1234", "10.1.2.3")))
+		// Only the address appended by the trusted proxy is considered, not those the client sent.
+		require.False(t, trusting.Allows(request("192.0.2.1:1234", "10.1.2.3, 192.0.2.2")))
+		require.True(t, trusting.Allows(request("10.1.2.3:1234", "")))
+	})
+}
+
+func TestWriteAllowlist(t *testing.T) {
+	t.Run("outside allowlist", func(t *testing.T) {
+		allowlist, err := api.ParseIPAllowlist([]string{"10.0.0.0/8"})
+		require.NoError(t, err)
+
+		address, tearDown := setupSubmissionsApi(t, allowlist)
+		defer tearDown()
+
+		client := api.NewClient(address)
+		client.Token = "alice-token"
+
+		_, err = client.Submit("https://github.com/alice/demo")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+
+		// Reads are unrestricted.
+		submissions, err := client.GetSubmissions("")
+		require.NoError(t, err)
+		require.Empty(t, submissions)
+	})
+
+	t.Run("inside allowlist", func(t *testing.T) {
+		allowlist, err := api.ParseIPAllowlist([]string{"127.0.0.0/8", "::1"})
+		require.NoError(t, err)
+
+		address, tearDown := setupSubmissionsApi(t, allowlist)
+		defer tearDown()
+
+		client := api.NewClient(address)
+		client.Token = "alice-token"
+
+		_, err = client.Submit("https://github.com/alice/demo")
+		require.NoError(t, err)
+
+		// The allowlist supplements, rather than replaces, authentication.
+		client.Token = ""
+		_, err = client.Submit("https://github.com/alice/other")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusUnauthorized}, err)
+	})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Defaults of the query parameters of the analytics report.
+const (
+	defaultAnalyticsDays  = 30
+	defaultAnalyticsLimit = 10
+)
+
+// initAnalytics registers the query analytics endpoint on the given router.
+func initAnalytics(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	apiRouter.Handle("/analytics", addContext(handleGetAnalytics)).Methods("GET")
+}
+
+// recordPluginRequest counts a request for the plugin with the given manifest id, if the context
+// records query analytics.
+func recordPluginRequest(c *Context, pluginID string) {
+	if c.Analytics != nil {
+		c.Analytics.RecordPluginRequest(pluginID)
+	}
+}
+
+// handleGetAnalytics responds to GET /api/v1/analytics, reporting to moderators the anonymized
+// queries made over the number of days given by the days query parameter, listing at most the
+// number of values given by the limit query parameter.
+func handleGetAnalytics(c *Context, w http.ResponseWriter, r *http.Request) {
+	if c.Analytics == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	if _, ok := authenticateModerator(c, w, r); !ok {
+		return
+	}
+
+	days, err := parseInt(r.URL, "days", defaultAnalyticsDays)
+	if err != nil || days < 1 {
+		c.Logger.WithError(err).Error("invalid days")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	limit, err := parseInt(r.URL, "limit", defaultAnalyticsLimit)
+	if err != nil || limit < 1 {
+		c.Logger.WithError(err).Error("invalid limit")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, c.Analytics.Report(days, limit))
+}
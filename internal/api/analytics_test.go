@@ -0,0 +1,124 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/analytics"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalytics(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	data, err := json.Marshal([]*model.Plugin{
+		{
+			DownloadURL: "https://example.com/jira-3.0.0.tar.gz",
+			Manifest:    &mattermostModel.Manifest{Id: "jira", Name: "Jira", Version: "3.0.0"},
+		},
+		{
+			DownloadURL: "https://example.com/github-1.0.0.tar.gz",
+			Manifest:    &mattermostModel.Manifest{Id: "github", Name: "GitHub", Version: "1.0.0"},
+		},
+	})
+	require.NoError(t, err)
+	pluginStore, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "analytics")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setUp := func(t *testing.T, recorder api.Analytics) (*httptest.Server, func(token string) *api.Client) {
+		router := mux.NewRouter()
+		api.Register(router, &api.Context{
+			Store:     pluginStore,
+			Analytics: recorder,
+			Authenticator: api.TokenAuthenticator{
+				"alice-token":     "alice",
+				"moderator-token": "moderator",
+			},
+			Moderators: map[string]bool{"moderator": true},
+			Logger:     logger,
+		})
+		ts := httptest.NewServer(router)
+
+		return ts, func(token string) *api.Client {
+			client := api.NewClient(ts.URL)
+			client.Token = token
+			return client
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		ts, clientFor := setUp(t, nil)
+		defer ts.Close()
+
+		_, err := clientFor("moderator-token").GetAnalytics(30, 10)
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	recorder, err := analytics.NewRecorder(&analytics.FileBackend{Path: filepath.Join(dir, "analytics.json")})
+	require.NoError(t, err)
+	ts, clientFor := setUp(t, recorder)
+	defer ts.Close()
+
+	t.Run("not a moderator", func(t *testing.T) {
+		_, err := clientFor("alice-token").GetAnalytics(30, 10)
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		_, err := clientFor("moderator-token").GetAnalytics(0, 10)
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+
+		_, err = clientFor("moderator-token").GetAnalytics(30, 0)
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	t.Run("reports queries", func(t *testing.T) {
+		client := clientFor("")
+		for _, serverVersion := range []string{"5.18.0", "5.18.1", "5.20.0"} {
+			_, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: 10, Filter: "Jira", ServerVersion: serverVersion})
+			require.NoError(t, err)
+		}
+		_, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: 10})
+		require.NoError(t, err)
+		_, err = client.GetPlugin("JIRA")
+		require.NoError(t, err)
+		_, err = client.GetPluginVersions("github")
+		require.NoError(t, err)
+		_, err = client.GetPlugin("unknown")
+		require.Equal(t, api.ErrNotFound, err)
+
+		report, err := clientFor("moderator-token").GetAnalytics(30, 10)
+		require.NoError(t, err)
+		require.EqualValues(t, 4, report.TotalQueries)
+		require.Equal(t, []model.AnalyticsCount{
+			{Value: "5.18", Count: 2},
+			{Value: "5.20", Count: 1},
+			{Value: "unknown", Count: 1},
+		}, report.ServerVersions)
+		require.Equal(t, []model.AnalyticsCount{{Value: "jira", Count: 3}}, report.Filters)
+		require.Equal(t, []model.AnalyticsCount{
+			{Value: "github", Count: 1},
+			{Value: "jira", Count: 1},
+		}, report.Plugins)
+
+		report, err = clientFor("moderator-token").GetAnalytics(30, 1)
+		require.NoError(t, err)
+		require.Len(t, report.ServerVersions, 1)
+	})
+}
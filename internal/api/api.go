@@ -7,5 +7,24 @@ func Register(rootRouter *mux.Router, context *Context) {
 	apiRouter := rootRouter.PathPrefix("/api/v1").Subrouter()
 
 	initPlugins(apiRouter, context)
+	initApps(apiRouter, context)
+	initChanges(apiRouter, context)
+	initDigest(apiRouter, context)
+	initSigningKeys(apiRouter, context)
+	initSnapshots(apiRouter, context)
+	initStaging(apiRouter, context)
+	initImport(apiRouter, context)
+	initEntryValidation(apiRouter, context)
+	initStats(rootRouter, apiRouter, context)
+	initAnalytics(apiRouter, context)
+	initRatings(apiRouter, context)
+	initSubmissions(apiRouter, context)
+	initAPIKeys(apiRouter, context)
+	initBlocklist(apiRouter, context)
+	initAdvisories(apiRouter, context)
+	initFeatured(apiRouter, context)
+	initTombstones(apiRouter, context)
 	initHealthCheck(apiRouter, context)
+	initDownloads(rootRouter, context)
+	initAdmin(rootRouter, context)
 }
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/apikeys"
+	"github.com/pkg/errors"
+)
+
+// maxAPIKeyRequestSize bounds the body of a request creating an API key.
+const maxAPIKeyRequestSize = 64 * 1024
+
+// initAPIKeys registers the API key administration endpoints on the given router.
+func initAPIKeys(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	keysRouter := apiRouter.PathPrefix("/keys").Subrouter()
+	keysRouter.Handle("", addContext(handleGetAPIKeys)).Methods("GET")
+	keysRouter.Handle("", addContext(restrictWrites(handleCreateAPIKey))).Methods("POST")
+	keysRouter.Handle("/{id}", addContext(handleGetAPIKey)).Methods("GET")
+	keysRouter.Handle("/{id}/revoke", addContext(restrictWrites(handleRevokeAPIKey))).Methods("POST")
+}
+
+// CreateAPIKeyRequest describes the parameters to issue an API key to a partner.
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+	// RateLimit is the maximum number of requests accepted per minute, or 0 for no limit.
+	RateLimit int `json:"rate_limit,omitempty"`
+}
+
+// identifyAPIKey attributes a request bearing an API key token to the key, annotating the
+// context's logger, or responds as unauthorized if the token is invalid and as too many requests
+// if the key exceeds its rate limit. Requests without an API key token are left anonymous.
+func identifyAPIKey(c *Context, w http.ResponseWriter, r *http.Request) bool {
+	if c.APIKeys == nil {
+		return true
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), bearerPrefix))
+	if !strings.HasPrefix(token, apikeys.TokenPrefix) {
+		return true
+	}
+
+	key, err := c.APIKeys.Use(token)
+	switch errors.Cause(err) {
+	case nil:
+	case apikeys.ErrInvalidToken:
+		writeError(c, w, r, http.StatusUnauthorized)
+		return false
+	case apikeys.ErrRateLimited:
+		c.Logger.WithField("api_key", key.ID).Warn("Rejected request exceeding rate limit")
+		w.Header().Set("Retry-After", "60")
+		writeError(c, w, r, http.StatusTooManyRequests)
+		return false
+	default:
+		c.Logger.WithError(err).Error("failed to use api key")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return false
+	}
+
+	c.APIKey = key
+	c.Logger = c.Logger.WithField("api_key", key.ID)
+
+	return true
+}
+
+// authenticateAPIKeys identifies the moderator making an API key administration request,
+// responding on failure, if API keys are not issued, or if the user is not a moderator.
+func authenticateAPIKeys(c *Context, w http.ResponseWriter, r *http.Request) (string, bool) {
+	if c.APIKeys == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return "", false
+	}
+
+	return authenticateModerator(c, w, r)
+}
+
+// handleCreateAPIKey responds to POST /api/v1/keys, issuing an API key to the given partner and
+// returning it along with its token.
+func handleCreateAPIKey(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticateAPIKeys(c, w, r)
+	if !ok {
+		return
+	}
+
+	var request CreateAPIKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIKeyRequestSize)).Decode(&request); err != nil {
+		c.Logger.WithError(err).Error("failed to decode api key request")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(request.Name) == "" || request.RateLimit < 0 {
+		c.Logger.Error("invalid api key request")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	issuedKey, err := c.APIKeys.Create(request.Name, userID, request.RateLimit)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to create api key")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	c.Logger.WithField("api_key", issuedKey.ID).Info("Issued api key")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	outputJSON(c, w, issuedKey)
+}
+
+// handleGetAPIKeys responds to GET /api/v1/keys, returning every API key along with its usage.
+func handleGetAPIKeys(c *Context, w http.ResponseWriter, r *http.Request) {
+	if _, ok := authenticateAPIKeys(c, w, r); !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, c.APIKeys.GetAPIKeys())
+}
+
+// handleGetAPIKey responds to GET /api/v1/keys/{id}, returning the given API key along with its
+// usage.
+func handleGetAPIKey(c *Context, w http.ResponseWriter, r *http.Request) {
+	if _, ok := authenticateAPIKeys(c, w, r); !ok {
+		return
+	}
+
+	key, err := c.APIKeys.GetAPIKey(mux.Vars(r)["id"])
+	if errors.Cause(err) == apikeys.ErrNotFound {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to query api key")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, key)
+}
+
+// handleRevokeAPIKey responds to POST /api/v1/keys/{id}/revoke, no longer accepting the given API
+// key.
+func handleRevokeAPIKey(c *Context, w http.ResponseWriter, r *http.Request) {
+	if _, ok := authenticateAPIKeys(c, w, r); !ok {
+		return
+	}
+
+	key, err := c.APIKeys.Revoke(mux.Vars(r)["id"])
+	switch errors.Cause(err) {
+	case nil:
+	case apikeys.ErrNotFound:
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	case apikeys.ErrAlreadyRevoked:
+		writeError(c, w, r, http.StatusConflict)
+		return
+	default:
+		c.Logger.WithError(err).Error("failed to revoke api key")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	c.Logger.WithField("api_key", key.ID).Info("Revoked api key")
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, key)
+}
@@ -0,0 +1,146 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/apikeys"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAPIKeysApi(t *testing.T) (string, func()) {
+	logger := testlib.MakeLogger(t)
+
+	data, err := json.Marshal([]*model.Plugin{})
+	require.NoError(t, err)
+	store, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "apikeys")
+	require.NoError(t, err)
+	registry, err := apikeys.NewRegistry(&apikeys.FileBackend{Path: filepath.Join(dir, "keys.json")})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:   store,
+		APIKeys: registry,
+		Authenticator: api.TokenAuthenticator{
+			"alice-token":     "alice",
+			"moderator-token": "moderator",
+		},
+		Moderators: map[string]bool{"moderator": true},
+		Logger:     logger,
+	})
+	ts := httptest.NewServer(router)
+
+	return ts.URL, func() {
+		ts.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	address, tearDown := setupAPIKeysApi(t)
+	defer tearDown()
+
+	clientFor := func(token string) *api.Client {
+		client := api.NewClient(address)
+		client.Token = token
+		return client
+	}
+	alice := clientFor("alice-token")
+	moderator := clientFor("moderator-token")
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		_, err := clientFor("").GetAPIKeys()
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusUnauthorized}, err)
+	})
+
+	t.Run("not a moderator", func(t *testing.T) {
+		_, err := alice.CreateAPIKey(&api.CreateAPIKeyRequest{Name: "Acme"})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+
+		_, err = alice.GetAPIKeys()
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := moderator.CreateAPIKey(&api.CreateAPIKeyRequest{})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+
+		_, err = moderator.CreateAPIKey(&api.CreateAPIKeyRequest{Name: "Acme", RateLimit: -1})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	acme, err := moderator.CreateAPIKey(&api.CreateAPIKeyRequest{Name: "Acme", RateLimit: 2})
+	require.NoError(t, err)
+	require.Equal(t, "Acme", acme.Name)
+	require.Equal(t, 2, acme.RateLimit)
+	require.Equal(t, "moderator", acme.CreatorID)
+	require.NotEmpty(t, acme.Token)
+
+	partner := clientFor(acme.Token)
+
+	t.Run("requests are accounted and rate limited", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			_, err := partner.GetPlugins(&api.GetPluginsRequest{})
+			require.NoError(t, err)
+		}
+
+		_, err := partner.GetPlugins(&api.GetPluginsRequest{})
+		require.Equal(t, &api.RateLimitedError{RetryAfter: time.Minute}, err)
+
+		key, err := moderator.GetAPIKey(acme.ID)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, key.Requests)
+		require.NotNil(t, key.LastUsedAt)
+	})
+
+	t.Run("anonymous requests are unaffected", func(t *testing.T) {
+		_, err := clientFor("").GetPlugins(&api.GetPluginsRequest{})
+		require.NoError(t, err)
+	})
+
+	t.Run("list keys", func(t *testing.T) {
+		keys, err := moderator.GetAPIKeys()
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.Equal(t, acme.ID, keys[0].ID)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := moderator.GetAPIKey("unknown")
+		require.Equal(t, api.ErrNotFound, err)
+
+		_, err = moderator.RevokeAPIKey("unknown")
+		require.Equal(t, api.ErrNotFound, err)
+
+		_, err = clientFor(apikeys.TokenPrefix + "unknown").GetPlugins(&api.GetPluginsRequest{})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusUnauthorized}, err)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		revoked, err := moderator.RevokeAPIKey(acme.ID)
+		require.NoError(t, err)
+		require.NotNil(t, revoked.RevokedAt)
+
+		_, err = moderator.RevokeAPIKey(acme.ID)
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusConflict}, err)
+
+		_, err = partner.GetPlugins(&api.GetPluginsRequest{})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusUnauthorized}, err)
+	})
+}
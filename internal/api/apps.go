@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+)
+
+// initApps registers app endpoints on the given router.
+func initApps(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	appsRouter := apiRouter.PathPrefix("/apps").Subrouter()
+	appsRouter.Handle("", addContext(handleGetApps)).Methods("GET")
+}
+
+func parseAppFilter(u *url.URL) (*model.AppFilter, error) {
+	page, err := parseInt(u, "page", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	perPage, err := parseInt(u, "per_page", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	hostingType := model.AppHostingType(u.Query().Get("hosting_type"))
+	if hostingType != "" && !hostingType.IsValid() {
+		return nil, errors.Errorf("invalid hosting_type %s", hostingType)
+	}
+
+	return &model.AppFilter{
+		Page:        page,
+		PerPage:     perPage,
+		Filter:      u.Query().Get("filter"),
+		HostingType: hostingType,
+	}, nil
+}
+
+// handleGetApps responds to GET /api/v1/apps, returning the specified page of apps.
+func handleGetApps(c *Context, w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAppFilter(r.URL)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to parse query parameters")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	endSpan := traceStore(c, r, "GetApps")
+	apps, err := c.Store.GetApps(filter)
+	endSpan()
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to query apps")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	if apps == nil {
+		apps = []*model.App{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, apps)
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// GetAppsRequest describes the parameters to request a list of apps.
+type GetAppsRequest struct {
+	Page        int
+	PerPage     int
+	Filter      string
+	HostingType model.AppHostingType
+}
+
+// ApplyToURL modifies the given url to include query string parameters for the request.
+func (request *GetAppsRequest) ApplyToURL(u *url.URL) {
+	q := u.Query()
+	q.Add("page", strconv.Itoa(request.Page))
+	q.Add("per_page", strconv.Itoa(request.PerPage))
+	q.Add("filter", request.Filter)
+	if request.HostingType != "" {
+		q.Add("hosting_type", string(request.HostingType))
+	}
+	u.RawQuery = q.Encode()
+}
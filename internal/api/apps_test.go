@@ -0,0 +1,91 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAppsApi(t *testing.T, apps []*model.App) (*api.Client, func()) {
+	logger := testlib.MakeLogger(t)
+
+	store, err := store.New(bytes.NewReader([]byte(`[]`)), logger)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(apps)
+	require.NoError(t, err)
+	require.NoError(t, store.LoadApps(bytes.NewReader(data)))
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:  store,
+		Logger: logger,
+	})
+	ts := httptest.NewServer(router)
+
+	return api.NewClient(ts.URL), func() {
+		ts.Close()
+	}
+}
+
+func TestApps(t *testing.T) {
+	zendeskApp := &model.App{
+		ID:          "zendesk",
+		DisplayName: "Zendesk",
+		ManifestURL: "https://example.com/zendesk/manifest.json",
+		HostingType: model.AppHostingAWSLambda,
+		Permissions: []model.AppPermission{model.AppPermissionActAsBot},
+	}
+	helloApp := &model.App{
+		ID:          "hello-world",
+		DisplayName: "Hello World",
+		ManifestURL: "https://example.com/hello/manifest.json",
+		HostingType: model.AppHostingHTTP,
+	}
+
+	t.Run("no apps", func(t *testing.T) {
+		client, tearDown := setupAppsApi(t, nil)
+		defer tearDown()
+
+		apps, err := client.GetApps(&api.GetAppsRequest{PerPage: 10})
+		require.NoError(t, err)
+		require.Empty(t, apps)
+	})
+
+	client, tearDown := setupAppsApi(t, []*model.App{zendeskApp, helloApp})
+	defer tearDown()
+
+	t.Run("all apps", func(t *testing.T) {
+		apps, err := client.GetApps(&api.GetAppsRequest{PerPage: -1})
+		require.NoError(t, err)
+		require.Equal(t, []*model.App{helloApp, zendeskApp}, apps)
+	})
+
+	t.Run("hosting type", func(t *testing.T) {
+		apps, err := client.GetApps(&api.GetAppsRequest{PerPage: -1, HostingType: model.AppHostingAWSLambda})
+		require.NoError(t, err)
+		require.Equal(t, []*model.App{zendeskApp}, apps)
+	})
+
+	t.Run("invalid hosting_type", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/v1/apps?hosting_type=invalid", client.Address))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("invalid page", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/v1/apps?page=invalid", client.Address))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
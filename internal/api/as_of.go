@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/pkg/errors"
+)
+
+// asOfParameter names the query parameter selecting the catalog as it was served at a given time.
+const asOfParameter = "as_of"
+
+// Headers identifying the snapshot from which a response was served.
+const (
+	catalogSnapshotHeader          = "X-Catalog-Snapshot"
+	catalogSnapshotCreatedAtHeader = "X-Catalog-Snapshot-Created-At"
+)
+
+// parseAsOf parses the given time, either in RFC 3339 format, optionally with fractional seconds,
+// or as seconds since the Unix epoch.
+func parseAsOf(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%s is neither an RFC 3339 time nor a Unix timestamp", value)
+	}
+
+	return at, nil
+}
+
+// selectAsOf points the context's store at the snapshot the catalog was serving at the time given
+// by the request's as_of parameter, if any. It responds as a bad request if the time is malformed,
+// given to other than a read request, or to a store retaining no snapshots, and as gone if the
+// snapshot is no longer retained.
+func selectAsOf(c *Context, w http.ResponseWriter, r *http.Request) bool {
+	value := r.URL.Query().Get(asOfParameter)
+	if value == "" {
+		return true
+	}
+
+	at, err := parseAsOf(value)
+	if err != nil {
+		c.Logger.WithError(err).Debug("Invalid as_of parameter")
+		writeError(c, w, r, http.StatusBadRequest)
+		return false
+	}
+
+	timeTravel, ok := unwrapStore(c.Store).(TimeTravel)
+	if !ok || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		c.Logger.WithField("method", r.Method).Debug("Cannot serve the catalog as of a time")
+		writeError(c, w, r, http.StatusBadRequest)
+		return false
+	}
+
+	snapshot, snapshotStore, err := timeTravel.SnapshotAsOf(at)
+	if errors.Cause(err) == catalog.ErrUnknownSnapshot {
+		writeError(c, w, r, http.StatusGone)
+		return false
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to query catalog snapshot")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return false
+	}
+
+	c.Store = snapshotStore
+	c.Logger = c.Logger.WithField("snapshot", snapshot.ID)
+
+	w.Header().Set(catalogSnapshotHeader, strconv.FormatInt(snapshot.ID, 10))
+	w.Header().Set(catalogSnapshotCreatedAtHeader, snapshot.CreatedAt.UTC().Format(http.TimeFormat))
+
+	return true
+}
@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsOf(t *testing.T) {
+	makePlugin := func(version string) *model.Plugin {
+		return &model.Plugin{
+			DownloadURL:  "https://example.com/demo-" + version + ".tar.gz",
+			ReleaseStage: model.ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: version},
+		}
+	}
+
+	beforeCatalog := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	pluginCatalog := catalog.New(makeStore(t, makePlugin("0.1.0")))
+	time.Sleep(10 * time.Millisecond)
+	beforeReplace := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	pluginCatalog.Replace(makeStore(t, makePlugin("0.2.0")))
+
+	client, tearDown := setupChangesApi(t, pluginCatalog)
+	defer tearDown()
+
+	versionAsOf := func(t *testing.T, at time.Time) string {
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: -1, AsOf: at})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+
+		return plugins[0].Manifest.Version
+	}
+
+	t.Run("current", func(t *testing.T) {
+		require.Equal(t, "0.2.0", versionAsOf(t, time.Time{}))
+		require.Equal(t, "0.2.0", versionAsOf(t, time.Now()))
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		require.Equal(t, "0.1.0", versionAsOf(t, beforeReplace))
+
+		resp, err := http.Get(fmt.Sprintf("%s/api/v1/plugins/demo/versions?as_of=%s", client.Address, beforeReplace.UTC().Format(time.RFC3339Nano)))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "1", resp.Header.Get("X-Catalog-Snapshot"))
+		versions, err := model.PluginsFromReader(resp.Body)
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		require.Equal(t, "0.1.0", versions[0].Manifest.Version)
+	})
+
+	t.Run("not yet served", func(t *testing.T) {
+		_, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: -1, AsOf: beforeCatalog})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusGone}, err)
+	})
+
+	t.Run("invalid time", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/v1/plugins?as_of=yesterday", client.Address))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("no snapshots", func(t *testing.T) {
+		client, tearDown := setupApi(t, []*model.Plugin{makePlugin("0.1.0")})
+		defer tearDown()
+
+		_, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: -1, AsOf: time.Now()})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// bearerPrefix introduces the token in an Authorization header.
+const bearerPrefix = "Bearer "
+
+// Authenticator identifies the user making a request.
+type Authenticator interface {
+	// Authenticate returns the id of the user making the request, or false if the request does
+	// not carry valid credentials.
+	Authenticate(r *http.Request) (string, bool)
+}
+
+// authenticate identifies the user making the request, annotating the context's logger,
+// or responds as unauthorized if the request does not carry valid credentials.
+func authenticate(c *Context, w http.ResponseWriter, r *http.Request) (string, bool) {
+	if c.Authenticator == nil {
+		writeError(c, w, r, http.StatusUnauthorized)
+		return "", false
+	}
+
+	userID, ok := c.Authenticator.Authenticate(r)
+	if !ok {
+		writeError(c, w, r, http.StatusUnauthorized)
+		return "", false
+	}
+	c.Logger = c.Logger.WithField("user", userID)
+
+	return userID, true
+}
+
+// authenticateModerator identifies the moderator making the request, responding as unauthorized
+// if the request does not carry valid credentials, and as forbidden if the user is not one of the
+// context's moderators.
+func authenticateModerator(c *Context, w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, ok := authenticate(c, w, r)
+	if !ok {
+		return "", false
+	}
+	if !c.Moderators[userID] {
+		writeError(c, w, r, http.StatusForbidden)
+		return "", false
+	}
+
+	return userID, true
+}
+
+// MultiAuthenticator authenticates requests by the first of its authenticators to identify the
+// user, such as accepting both static tokens and tokens issued through single sign-on.
+type MultiAuthenticator []Authenticator
+
+// Authenticate returns the user identified by the first authenticator accepting the request.
+func (a MultiAuthenticator) Authenticate(r *http.Request) (string, bool) {
+	for _, authenticator := range a {
+		if userID, ok := authenticator.Authenticate(r); ok {
+			return userID, true
+		}
+	}
+
+	return "", false
+}
+
+// TokenAuthenticator authenticates requests bearing one of a fixed set of tokens, mapping each
+// token to the id of the user it was issued to.
+type TokenAuthenticator map[string]string
+
+// Authenticate returns the user to whom the request's bearer token was issued.
+func (a TokenAuthenticator) Authenticate(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return "", false
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+	if token == "" {
+		return "", false
+	}
+
+	userID, ok := a[token]
+	if !ok || userID == "" {
+		return "", false
+	}
+
+	return userID, true
+}
+
+// TokenAuthenticatorFromReader decodes a json-encoded object mapping tokens to user ids from the
+// given io.Reader.
+func TokenAuthenticatorFromReader(reader io.Reader) (TokenAuthenticator, error) {
+	authenticator := TokenAuthenticator{}
+	if err := json.NewDecoder(reader).Decode(&authenticator); err != nil {
+		return nil, errors.Wrap(err, "failed to decode tokens")
+	}
+
+	for token, userID := range authenticator {
+		if token == "" || userID == "" {
+			return nil, errors.New("tokens and user ids must not be empty")
+		}
+	}
+
+	return authenticator, nil
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/blocklist"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+)
+
+// maxBlockRequestSize bounds the body of a request adding a blocklist entry.
+const maxBlockRequestSize = 64 * 1024
+
+// initBlocklist registers the blocklist administration endpoints on the given router.
+func initBlocklist(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	blocklistRouter := apiRouter.PathPrefix("/blocklist").Subrouter()
+	blocklistRouter.Handle("", addContext(handleGetBlocklist)).Methods("GET")
+	blocklistRouter.Handle("", addContext(restrictWrites(handleBlock))).Methods("POST")
+	blocklistRouter.Handle("/{id}", addContext(restrictWrites(handleUnblock))).Methods("DELETE")
+}
+
+// BlockRequest describes the parameters to block the clients matching all of the given criteria.
+type BlockRequest struct {
+	// IP is the address, e.g. 192.0.2.1, or range of addresses, e.g. 198.51.100.0/24, to block.
+	IP string `json:"ip,omitempty"`
+	// UserAgent blocks the clients whose User-Agent header contains it, ignoring case.
+	UserAgent string `json:"user_agent,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	// Duration, if positive, lapses the entry after the given number of seconds.
+	Duration int64 `json:"duration,omitempty"`
+}
+
+// authenticateBlocklist identifies the moderator making a blocklist administration request,
+// responding on failure, if no blocklist is configured, or if the user is not a moderator.
+func authenticateBlocklist(c *Context, w http.ResponseWriter, r *http.Request) (string, bool) {
+	if c.Blocklist == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return "", false
+	}
+
+	return authenticateModerator(c, w, r)
+}
+
+// handleGetBlocklist responds to GET /api/v1/blocklist, returning every unexpired blocklist entry.
+func handleGetBlocklist(c *Context, w http.ResponseWriter, r *http.Request) {
+	if _, ok := authenticateBlocklist(c, w, r); !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, c.Blocklist.GetEntries())
+}
+
+// handleBlock responds to POST /api/v1/blocklist, denying access to the clients matching the given
+// criteria.
+func handleBlock(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticateBlocklist(c, w, r)
+	if !ok {
+		return
+	}
+
+	var request BlockRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBlockRequestSize)).Decode(&request); err != nil {
+		c.Logger.WithError(err).Error("failed to decode block request")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	entry := &model.BlocklistEntry{
+		IP:        strings.TrimSpace(request.IP),
+		UserAgent: strings.TrimSpace(request.UserAgent),
+		Reason:    request.Reason,
+		CreatorID: userID,
+	}
+	if request.Duration > 0 {
+		expiresAt := time.Now().Add(time.Duration(request.Duration) * time.Second).UTC()
+		entry.ExpiresAt = &expiresAt
+	}
+	if entry.IP == "" && entry.UserAgent == "" {
+		c.Logger.Error("block request has neither an ip nor a user agent")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	if _, err := entry.Network(); err != nil {
+		c.Logger.WithError(err).Error("invalid block request")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	entry, err := c.Blocklist.Block(entry)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to block clients")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	c.Logger.WithField("blocklist_entry", entry.ID).Info("Blocked clients")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	outputJSON(c, w, entry)
+}
+
+// handleUnblock responds to DELETE /api/v1/blocklist/{id}, removing the given blocklist entry.
+func handleUnblock(c *Context, w http.ResponseWriter, r *http.Request) {
+	if _, ok := authenticateBlocklist(c, w, r); !ok {
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	err := c.Blocklist.Unblock(id)
+	if errors.Cause(err) == blocklist.ErrNotFound {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to unblock clients")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	c.Logger.WithField("blocklist_entry", id).Info("Unblocked clients")
+
+	w.WriteHeader(http.StatusNoContent)
+}
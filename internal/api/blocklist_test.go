@@ -0,0 +1,102 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/blocklist"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocklist(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	data, err := json.Marshal([]*model.Plugin{})
+	require.NoError(t, err)
+	store, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "blocklist")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	clientBlocklist, err := blocklist.New(&blocklist.FileBackend{Path: filepath.Join(dir, "blocklist.json")})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:     store,
+		Blocklist: clientBlocklist,
+		Authenticator: api.TokenAuthenticator{
+			"alice-token":     "alice",
+			"moderator-token": "moderator",
+		},
+		Moderators: map[string]bool{"moderator": true},
+		Logger:     logger,
+	})
+	ts := httptest.NewServer(api.NewAbuseHandler(router, api.AbuseOptions{Blocklist: clientBlocklist}, logger))
+	defer ts.Close()
+
+	clientFor := func(token string) *api.Client {
+		client := api.NewClient(ts.URL)
+		client.Token = token
+		return client
+	}
+	alice := clientFor("alice-token")
+	moderator := clientFor("moderator-token")
+
+	t.Run("not a moderator", func(t *testing.T) {
+		_, err := alice.Block(&api.BlockRequest{UserAgent: "scraper"})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+
+		_, err = clientFor("").GetBlocklist()
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusUnauthorized}, err)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := moderator.Block(&api.BlockRequest{})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+
+		_, err = moderator.Block(&api.BlockRequest{IP: "not-an-ip"})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	entry, err := moderator.Block(&api.BlockRequest{UserAgent: "scraper", Reason: "scraping", Duration: 3600})
+	require.NoError(t, err)
+	require.Equal(t, "scraper", entry.UserAgent)
+	require.Equal(t, "moderator", entry.CreatorID)
+	require.NotNil(t, entry.ExpiresAt)
+
+	t.Run("blocked client", func(t *testing.T) {
+		scraper := clientFor("")
+		scraper.UserAgent = "scraper/1.0"
+		_, err := scraper.GetPlugins(&api.GetPluginsRequest{})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("list entries", func(t *testing.T) {
+		entries, err := moderator.GetBlocklist()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, entry.ID, entries[0].ID)
+	})
+
+	t.Run("unblock", func(t *testing.T) {
+		require.Equal(t, api.ErrNotFound, moderator.Unblock("unknown"))
+		require.NoError(t, moderator.Unblock(entry.ID))
+
+		entries, err := moderator.GetBlocklist()
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"bytes"
+	"container/list"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// cachedHeaders are the response headers replayed with a cached response. Headers set for each
+// request, such as its id, are deliberately excluded.
+var cachedHeaders = []string{"Content-Type", "Content-Language", "Vary"}
+
+// ResponseCache caches the serialized responses to plugin listings in memory, keyed by their
+// normalized query, so that hot queries are answered without filtering and marshaling the plugins
+// again. The least recently used responses are evicted beyond its capacity.
+//
+// Only listings of stores implementing Changes are cached, keyed by the cursor of their latest
+// change so that reloading a store with different plugins invalidates its responses, and by the
+// epoch of stores implementing Aging, so that their responses are invalidated as plugins grow
+// stale and pre-releases lapse. Responses are purged as ratings are submitted and advisories
+// published or withdrawn.
+type ResponseCache struct {
+	lock     sync.Mutex
+	capacity int
+	entries  map[responseCacheKey]*list.Element
+	// order lists the cached responses, most recently used first.
+	order *list.List
+}
+
+// responseCacheKey identifies a cached response.
+type responseCacheKey struct {
+	channel string
+	cursor  int64
+	query   string
+}
+
+type cachedResponse struct {
+	key    responseCacheKey
+	header http.Header
+	body   []byte
+}
+
+// NewResponseCache constructs a cache holding up to the given number of responses.
+func NewResponseCache(capacity int) *ResponseCache {
+	return &ResponseCache{
+		capacity: capacity,
+		entries:  map[responseCacheKey]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Purge discards every cached response.
+func (c *ResponseCache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries = map[responseCacheKey]*list.Element{}
+	c.order.Init()
+}
+
+func (c *ResponseCache) get(key responseCacheKey) (*cachedResponse, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+
+	return element.Value.(*cachedResponse), true
+}
+
+func (c *ResponseCache) put(response *cachedResponse) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if element, ok := c.entries[response.key]; ok {
+		element.Value = response
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[response.key] = c.order.PushFront(response)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// write replays the cached response.
+func (r *cachedResponse) write(w http.ResponseWriter) {
+	for name, values := range r.header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.Write(r.body)
+}
+
+// purgeResponseCache discards the cached responses of the context, if any, as the data they
+// annotate plugins with changes.
+func purgeResponseCache(c *Context) {
+	if c.ResponseCache != nil {
+		c.ResponseCache.Purge()
+	}
+}
+
+// pluginsCacheKey returns the key of the cached response to the given plugin listing, normalizing
+// its query, or false if the response may not be cached.
+func pluginsCacheKey(c *Context, r *http.Request, filter *model.PluginFilter) (responseCacheKey, bool) {
+	if c.ResponseCache == nil {
+		return responseCacheKey{}, false
+	}
+	changes, ok := unwrapStore(c.Store).(Changes)
+	if !ok {
+		return responseCacheKey{}, false
+	}
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(filter.Page))
+	query.Set("per_page", strconv.Itoa(filter.PerPage))
+	query.Set("filter", strings.ToLower(strings.TrimSpace(filter.Filter)))
+	query.Set("server_version", filter.ServerVersion)
+	query.Set("author_type", string(filter.AuthorType))
+	query.Set("hosting", string(filter.Hosting))
+	query.Set("license_tier", string(filter.LicenseTier))
+	query.Set("include_incompatible", strconv.FormatBool(filter.IncludeIncompatible))
+	query.Set("platform", filter.Platform)
+	query["label"] = filter.Labels
+	query.Set("sort", string(filter.Sort))
+	if c.Translations != nil {
+		locale, _ := c.Translations.Locale(r.Header.Get("Accept-Language"))
+		query.Set("locale", locale)
+	}
+	if c.IconStrippingThreshold > 0 || c.Bundles != nil {
+		query.Set("base_url", requestBaseURL(r))
+	}
+	if c.Bundles != nil && c.DownloadSigner != nil {
+		// Pre-signed urls change with their expiry.
+		query.Set("download_expiry", strconv.FormatInt(c.DownloadSigner.Expiry().Unix(), 10))
+	}
+	query.Set("media_type", negotiateMediaType(r))
+	if aging, ok := unwrapStore(c.Store).(Aging); ok {
+		// Responses change as plugins grow stale and pre-releases lapse, without the catalog
+		// changing.
+		query.Set("epoch", strconv.Itoa(aging.Epoch()))
+	}
+
+	return responseCacheKey{
+		channel: c.Channel,
+		cursor:  changes.Cursor(),
+		query:   query.Encode(),
+	}, true
+}
+
+// newCachedResponse captures the given response headers and body to be cached under the given key.
+func newCachedResponse(key responseCacheKey, header http.Header, body *bytes.Buffer) *cachedResponse {
+	response := &cachedResponse{
+		key:    key,
+		header: http.Header{},
+		body:   append([]byte(nil), body.Bytes()...),
+	}
+	for _, name := range cachedHeaders {
+		if values, ok := header[name]; ok {
+			response.header[name] = append([]string(nil), values...)
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,190 @@
+package api_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+// countingCatalog counts the plugin listings queried of the catalog it wraps.
+type countingCatalog struct {
+	*catalog.Catalog
+	queries int32
+}
+
+func (c *countingCatalog) GetPlugins(filter *model.PluginFilter) ([]*model.Plugin, error) {
+	atomic.AddInt32(&c.queries, 1)
+	return c.Catalog.GetPlugins(filter)
+}
+
+// countingStore counts the plugin listings queried of a store not reporting its changes.
+type countingStore struct {
+	api.Store
+	queries int32
+}
+
+func (s *countingStore) GetPlugins(filter *model.PluginFilter) ([]*model.Plugin, error) {
+	atomic.AddInt32(&s.queries, 1)
+	return s.Store.GetPlugins(filter)
+}
+
+func TestResponseCache(t *testing.T) {
+	demo := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0", MinServerVersion: "5.14.0"},
+	}
+	demo2 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0", MinServerVersion: "5.14.0"},
+	}
+
+	setup := func(t *testing.T, store api.Store, capacity int) (*api.Client, func()) {
+		router := mux.NewRouter()
+		api.Register(router, &api.Context{
+			Store:         store,
+			ResponseCache: api.NewResponseCache(capacity),
+			Logger:        testlib.MakeLogger(t),
+		})
+		ts := httptest.NewServer(router)
+
+		return api.NewClient(ts.URL), ts.Close
+	}
+
+	get := func(t *testing.T, client *api.Client, query string) string {
+		t.Helper()
+
+		resp, err := http.Get(client.Address + "/api/v1/plugins?" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("serves repeated queries from the cache", func(t *testing.T) {
+		pluginCatalog := &countingCatalog{Catalog: catalog.New(makeStore(t, demo))}
+		client, tearDown := setup(t, pluginCatalog, 10)
+		defer tearDown()
+
+		first := get(t, client, "filter=Demo&server_version=5.14.0")
+		require.Equal(t, first, get(t, client, "server_version=5.14.0&filter=demo%20"))
+		require.EqualValues(t, 1, pluginCatalog.queries)
+
+		get(t, client, "filter=demo&server_version=5.15.0")
+		require.EqualValues(t, 2, pluginCatalog.queries)
+	})
+
+	t.Run("invalidated as the catalog changes", func(t *testing.T) {
+		pluginCatalog := &countingCatalog{Catalog: catalog.New(makeStore(t, demo))}
+		client, tearDown := setup(t, pluginCatalog, 10)
+		defer tearDown()
+
+		require.Contains(t, get(t, client, ""), `"version":"0.1.0"`)
+
+		pluginCatalog.Replace(makeStore(t, demo, demo2))
+		require.Contains(t, get(t, client, ""), `"version":"0.2.0"`)
+		require.EqualValues(t, 2, pluginCatalog.queries)
+	})
+
+	t.Run("invalidated as plugins grow stale", func(t *testing.T) {
+		aging := *demo
+		aging.ReleasedAt = time.Now().Add(-time.Hour + 200*time.Millisecond)
+		pluginStore, err := store.NewFromPlugins([]*model.Plugin{&aging}, testlib.MakeLogger(t), store.Options{StaleAfter: time.Hour})
+		require.NoError(t, err)
+		pluginCatalog := &countingCatalog{Catalog: catalog.New(pluginStore)}
+		client, tearDown := setup(t, pluginCatalog, 10)
+		defer tearDown()
+
+		require.NotContains(t, get(t, client, ""), `"unmaintained"`)
+		require.NotContains(t, get(t, client, ""), `"unmaintained"`)
+		require.EqualValues(t, 1, pluginCatalog.queries)
+
+		time.Sleep(250 * time.Millisecond)
+		require.Contains(t, get(t, client, ""), `"unmaintained":"stale"`)
+		require.EqualValues(t, 2, pluginCatalog.queries)
+	})
+
+	t.Run("invalidated as pre-releases lapse", func(t *testing.T) {
+		beta := &model.Plugin{
+			DownloadURL:  "https://example.com/demo-0.2.0-rc1.tar.gz",
+			ReleaseStage: model.ReleaseStageBeta,
+			ReleasedAt:   time.Now().Add(-time.Hour + 200*time.Millisecond),
+			Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0-rc1", MinServerVersion: "5.14.0"},
+		}
+		pluginStore, err := store.NewFromPlugins([]*model.Plugin{demo, beta}, testlib.MakeLogger(t), store.Options{PreReleaseTTL: time.Hour})
+		require.NoError(t, err)
+		pluginCatalog := &countingCatalog{Catalog: catalog.New(pluginStore)}
+		client, tearDown := setup(t, pluginCatalog, 10)
+		defer tearDown()
+
+		require.Contains(t, get(t, client, ""), `"version":"0.2.0-rc1"`)
+		require.Contains(t, get(t, client, ""), `"version":"0.2.0-rc1"`)
+		require.EqualValues(t, 1, pluginCatalog.queries)
+
+		time.Sleep(250 * time.Millisecond)
+		require.Contains(t, get(t, client, ""), `"version":"0.1.0"`)
+		require.EqualValues(t, 2, pluginCatalog.queries)
+	})
+
+	t.Run("evicts the least recently used responses", func(t *testing.T) {
+		pluginCatalog := &countingCatalog{Catalog: catalog.New(makeStore(t, demo))}
+		client, tearDown := setup(t, pluginCatalog, 2)
+		defer tearDown()
+
+		get(t, client, "page=0")
+		get(t, client, "page=1")
+		get(t, client, "page=0")
+		get(t, client, "page=2")
+		require.EqualValues(t, 3, pluginCatalog.queries)
+
+		get(t, client, "page=0")
+		require.EqualValues(t, 3, pluginCatalog.queries)
+		get(t, client, "page=1")
+		require.EqualValues(t, 4, pluginCatalog.queries)
+	})
+
+	t.Run("caches each encoding separately", func(t *testing.T) {
+		pluginCatalog := &countingCatalog{Catalog: catalog.New(makeStore(t, demo))}
+		client, tearDown := setup(t, pluginCatalog, 10)
+		defer tearDown()
+
+		get(t, client, "")
+		for i := 0; i < 2; i++ {
+			req, err := http.NewRequest(http.MethodGet, client.Address+"/api/v1/plugins", nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", model.YAMLMediaType)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, model.YAMLMediaType, resp.Header.Get("Content-Type"))
+		}
+		require.EqualValues(t, 2, pluginCatalog.queries)
+	})
+
+	t.Run("does not cache stores without changes", func(t *testing.T) {
+		pluginStore := &countingStore{Store: makeStore(t, demo)}
+		client, tearDown := setup(t, pluginStore, 10)
+		defer tearDown()
+
+		get(t, client, "")
+		get(t, client, "")
+		require.EqualValues(t, 2, pluginStore.queries)
+	})
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+)
+
+// maxChangesWait bounds how long a request for changes waits for the next one, staying well within
+// the server's write timeout.
+const maxChangesWait = 5 * time.Second
+
+// initChanges registers the catalog change endpoints on the given router.
+func initChanges(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	apiRouter.Handle("/changes", addContext(handleGetChanges)).Methods("GET")
+	apiRouter.Handle("/catalog/changes", addContext(handleSyncCatalog)).Methods("GET")
+}
+
+// handleGetChanges responds to GET /api/v1/changes, returning the changes to the catalog after the
+// cursor given by since. If there are none yet, it waits up to the given number of seconds for the
+// next change. Without a cursor, it returns only the current cursor, from which to watch for
+// future changes. Changes are filtered by the requesting server's license tier, as for listings.
+func handleGetChanges(c *Context, w http.ResponseWriter, r *http.Request) {
+	feed, ok := unwrapStore(c.Store).(Changes)
+	if !ok {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	wait, err := parseInt(r.URL, "wait", 0)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to parse query parameters")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	if wait < 0 {
+		c.Logger.Errorf("invalid wait %d", wait)
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	timeout := time.Duration(wait) * time.Second
+	if timeout > maxChangesWait {
+		timeout = maxChangesWait
+	}
+
+	licenseTier, err := requestLicenseTier(r)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to parse query parameters")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	sinceStr := r.URL.Query().Get("since")
+	if sinceStr == "" {
+		w.Header().Set("Content-Type", "application/json")
+		outputJSON(c, w, &model.CatalogChanges{Cursor: feed.Cursor(), Changes: []*model.CatalogChange{}})
+		return
+	}
+	since, err := strconv.ParseInt(sinceStr, 10, 64)
+	if err != nil {
+		c.Logger.WithError(errors.Wrap(err, "failed to parse since as integer")).Error("failed to parse query parameters")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		changes, updated, err := feed.ChangesSince(since)
+		if err == catalog.ErrUnknownCursor {
+			writeError(c, w, r, http.StatusGone)
+			return
+		} else if err != nil {
+			c.Logger.WithError(err).Error("failed to query catalog changes")
+			writeError(c, w, r, http.StatusInternalServerError)
+			return
+		}
+
+		if len(changes) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			outputJSON(c, w, &model.CatalogChanges{Cursor: changes[len(changes)-1].Cursor, Changes: withoutPrivateChanges(withinLicenseTierChanges(changes, licenseTier))})
+			return
+		}
+
+		select {
+		case <-updated:
+			continue
+		case <-timer.C:
+		case <-r.Context().Done():
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		outputJSON(c, w, &model.CatalogChanges{Cursor: since, Changes: []*model.CatalogChange{}})
+		return
+	}
+}
+
+// handleSyncCatalog responds to GET /api/v1/catalog/changes, returning the net change to each plugin
+// version after the cursor given by since, letting clients keep a replica of the catalog without
+// transferring it in full. Without a cursor, or given one whose changes are no longer retained, it
+// returns the whole catalog, resetting the replica. Either is filtered by the requesting server's
+// license tier, as for listings.
+func handleSyncCatalog(c *Context, w http.ResponseWriter, r *http.Request) {
+	store := unwrapStore(c.Store)
+	feed, ok := store.(Changes)
+	if !ok {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+	enumerator, ok := store.(Enumerator)
+	if !ok {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	licenseTier, err := requestLicenseTier(r)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to parse query parameters")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	var since int64
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		since, err = strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil {
+			c.Logger.WithError(errors.Wrap(err, "failed to parse since as integer")).Error("failed to parse query parameters")
+			writeError(c, w, r, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if since != 0 {
+		changes, _, err := feed.ChangesSince(since)
+		if err == nil {
+			cursor := since
+			if len(changes) > 0 {
+				cursor = changes[len(changes)-1].Cursor
+			}
+			w.Header().Set("Content-Type", "application/json")
+			outputJSON(c, w, model.NewCatalogSync(cursor, withoutPrivateChanges(withinLicenseTierChanges(changes, licenseTier))))
+			return
+		} else if err != catalog.ErrUnknownCursor {
+			c.Logger.WithError(err).Error("failed to query catalog changes")
+			writeError(c, w, r, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// The cursor is taken before listing the catalog, so that any change in between is applied
+	// again by the next sync rather than missed.
+	sync := &model.CatalogSync{
+		Cursor:  feed.Cursor(),
+		Reset:   true,
+		Changed: []*model.Plugin{},
+		Removed: []*model.PluginVersionRef{},
+	}
+	endSpan := traceStore(c, r, "AllPlugins")
+	sync.Added = withoutPrivateBundles(withinLicenseTier(enumerator.AllPlugins(), licenseTier))
+	endSpan()
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, sync)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// GetChangesRequest describes the parameters to request the changes to the catalog.
+type GetChangesRequest struct {
+	// Since is the cursor of the latest change already seen.
+	Since int64
+	// Wait is how long the server should wait for a change if there are none after Since yet. The
+	// server waits at most a few seconds, regardless.
+	Wait time.Duration
+	// LicenseTier is the license tier of the requesting server, hiding the changes to plugins
+	// requiring a higher tier.
+	LicenseTier model.LicenseTier
+}
+
+// ApplyToURL modifies the given url to include query string parameters for the request.
+func (request *GetChangesRequest) ApplyToURL(u *url.URL) {
+	q := u.Query()
+	q.Add("since", strconv.FormatInt(request.Since, 10))
+	if request.Wait > 0 {
+		q.Add("wait", strconv.Itoa(int(request.Wait/time.Second)))
+	}
+	if request.LicenseTier != "" {
+		q.Add(licenseTierParameter, string(request.LicenseTier))
+	}
+	u.RawQuery = q.Encode()
+}
@@ -0,0 +1,311 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func makeStore(t *testing.T, plugins ...*model.Plugin) *store.Store {
+	t.Helper()
+
+	if plugins == nil {
+		plugins = []*model.Plugin{}
+	}
+	data, err := json.Marshal(plugins)
+	require.NoError(t, err)
+
+	pluginStore, err := store.New(bytes.NewReader(data), testlib.MakeLogger(t))
+	require.NoError(t, err)
+
+	return pluginStore
+}
+
+func setupChangesApi(t *testing.T, pluginCatalog *catalog.Catalog) (*api.Client, func()) {
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:  pluginCatalog,
+		Logger: testlib.MakeLogger(t),
+	})
+	ts := httptest.NewServer(router)
+
+	return api.NewClient(ts.URL), func() {
+		ts.Close()
+	}
+}
+
+func TestChanges(t *testing.T) {
+	demo := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}
+	starter := &model.Plugin{
+		DownloadURL:  "https://example.com/starter-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "starter", Name: "Starter", Version: "0.1.0"},
+	}
+
+	t.Run("static store", func(t *testing.T) {
+		client, tearDown := setupApi(t, []*model.Plugin{demo})
+		defer tearDown()
+
+		_, err := client.GetChangesCursor()
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		client, tearDown := setupChangesApi(t, catalog.New(makeStore(t, demo)))
+		defer tearDown()
+
+		for _, query := range []string{"since=invalid", "since=1&wait=invalid", "since=1&wait=-1", "since=1&license_tier=platinum"} {
+			resp, err := http.Get(fmt.Sprintf("%s/api/v1/changes?%s", client.Address, query))
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		}
+	})
+
+	t.Run("changes after cursor", func(t *testing.T) {
+		pluginCatalog := catalog.New(makeStore(t, demo))
+		client, tearDown := setupChangesApi(t, pluginCatalog)
+		defer tearDown()
+
+		cursor, err := client.GetChangesCursor()
+		require.NoError(t, err)
+
+		changes, err := client.GetChanges(&api.GetChangesRequest{Since: cursor})
+		require.NoError(t, err)
+		require.Equal(t, cursor, changes.Cursor)
+		require.Empty(t, changes.Changes)
+
+		pluginCatalog.Replace(makeStore(t, starter))
+
+		changes, err = client.GetChanges(&api.GetChangesRequest{Since: cursor})
+		require.NoError(t, err)
+		require.Equal(t, cursor+2, changes.Cursor)
+		require.Len(t, changes.Changes, 2)
+		require.Equal(t, model.ChangeTypeRemoved, changes.Changes[0].Type)
+		require.Equal(t, "demo", changes.Changes[0].PluginID)
+		require.Nil(t, changes.Changes[0].Plugin)
+		require.Equal(t, model.ChangeTypeAdded, changes.Changes[1].Type)
+		require.Equal(t, "starter", changes.Changes[1].PluginID)
+		require.Equal(t, starter.DownloadURL, changes.Changes[1].Plugin.DownloadURL)
+
+		changes, err = client.GetChanges(&api.GetChangesRequest{Since: cursor + 1})
+		require.NoError(t, err)
+		require.Len(t, changes.Changes, 1)
+		require.Equal(t, "starter", changes.Changes[0].PluginID)
+	})
+
+	t.Run("wait for change", func(t *testing.T) {
+		pluginCatalog := catalog.New(makeStore(t, demo))
+		client, tearDown := setupChangesApi(t, pluginCatalog)
+		defer tearDown()
+
+		cursor, err := client.GetChangesCursor()
+		require.NoError(t, err)
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			pluginCatalog.Replace(makeStore(t, demo, starter))
+		}()
+
+		changes, err := client.GetChanges(&api.GetChangesRequest{Since: cursor, Wait: 5 * time.Second})
+		require.NoError(t, err)
+		require.Len(t, changes.Changes, 1)
+		require.Equal(t, model.ChangeTypeAdded, changes.Changes[0].Type)
+		require.Equal(t, "starter", changes.Changes[0].PluginID)
+	})
+
+	t.Run("wait times out", func(t *testing.T) {
+		pluginCatalog := catalog.New(makeStore(t, demo))
+		client, tearDown := setupChangesApi(t, pluginCatalog)
+		defer tearDown()
+
+		cursor, err := client.GetChangesCursor()
+		require.NoError(t, err)
+
+		start := time.Now()
+		changes, err := client.GetChanges(&api.GetChangesRequest{Since: cursor, Wait: time.Second})
+		require.NoError(t, err)
+		require.True(t, time.Since(start) >= time.Second)
+		require.Equal(t, cursor, changes.Cursor)
+		require.Empty(t, changes.Changes)
+	})
+
+	t.Run("unknown cursor", func(t *testing.T) {
+		pluginCatalog := catalog.New(makeStore(t, demo))
+		client, tearDown := setupChangesApi(t, pluginCatalog)
+		defer tearDown()
+
+		cursor, err := client.GetChangesCursor()
+		require.NoError(t, err)
+
+		_, err = client.GetChanges(&api.GetChangesRequest{Since: cursor + 1})
+		require.Equal(t, api.ErrUnknownCursor, err)
+
+		_, err = client.GetChanges(&api.GetChangesRequest{Since: cursor - 1})
+		require.Equal(t, api.ErrUnknownCursor, err)
+	})
+
+	t.Run("license tier", func(t *testing.T) {
+		enterpriseStarter := *starter
+		enterpriseStarter.RequiredLicense = model.LicenseTierEnterprise
+		enterpriseDemo := *demo
+		enterpriseDemo.RequiredLicense = model.LicenseTierEnterprise
+
+		pluginCatalog := catalog.New(makeStore(t, demo))
+		client, tearDown := setupChangesApi(t, pluginCatalog)
+		defer tearDown()
+
+		cursor, err := client.GetChangesCursor()
+		require.NoError(t, err)
+
+		pluginCatalog.Replace(makeStore(t, &enterpriseDemo, &enterpriseStarter))
+
+		changes, err := client.GetChanges(&api.GetChangesRequest{Since: cursor, LicenseTier: model.LicenseTierTeam})
+		require.NoError(t, err)
+		require.Equal(t, cursor+2, changes.Cursor)
+		require.Len(t, changes.Changes, 1)
+		require.Equal(t, model.ChangeTypeRemoved, changes.Changes[0].Type)
+		require.Equal(t, "demo", changes.Changes[0].PluginID)
+		require.Nil(t, changes.Changes[0].Plugin)
+
+		changes, err = client.GetChanges(&api.GetChangesRequest{Since: cursor, LicenseTier: model.LicenseTierEnterprise})
+		require.NoError(t, err)
+		require.Len(t, changes.Changes, 2)
+		require.Equal(t, model.ChangeTypeUpdated, changes.Changes[0].Type)
+		require.Equal(t, model.ChangeTypeAdded, changes.Changes[1].Type)
+	})
+}
+
+func TestSyncCatalog(t *testing.T) {
+	demo := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}
+	newDemo := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0"},
+	}
+	starter := &model.Plugin{
+		DownloadURL:  "https://example.com/starter-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "starter", Name: "Starter", Version: "0.1.0"},
+	}
+
+	t.Run("static store", func(t *testing.T) {
+		client, tearDown := setupApi(t, []*model.Plugin{demo})
+		defer tearDown()
+
+		_, err := client.SyncCatalog(0)
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		client, tearDown := setupChangesApi(t, catalog.New(makeStore(t, demo)))
+		defer tearDown()
+
+		resp, err := http.Get(client.Address + "/api/v1/catalog/changes?since=invalid")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		pluginCatalog := catalog.New(makeStore(t, demo, starter))
+		client, tearDown := setupChangesApi(t, pluginCatalog)
+		defer tearDown()
+
+		sync, err := client.SyncCatalog(0)
+		require.NoError(t, err)
+		require.True(t, sync.Reset)
+		require.Equal(t, pluginCatalog.Cursor(), sync.Cursor)
+		replica := sync.Apply(nil)
+		require.Len(t, replica, 2)
+
+		sync, err = client.SyncCatalog(sync.Cursor)
+		require.NoError(t, err)
+		require.True(t, sync.IsEmpty())
+
+		pluginCatalog.Replace(makeStore(t, demo))
+		pluginCatalog.Replace(makeStore(t, demo, newDemo))
+
+		sync, err = client.SyncCatalog(sync.Cursor)
+		require.NoError(t, err)
+		require.False(t, sync.Reset)
+		require.Equal(t, pluginCatalog.Cursor(), sync.Cursor)
+		require.Len(t, sync.Added, 1)
+		require.Equal(t, newDemo.DownloadURL, sync.Added[0].DownloadURL)
+		require.Empty(t, sync.Changed)
+		require.Equal(t, []*model.PluginVersionRef{{PluginID: "starter", Version: "0.1.0"}}, sync.Removed)
+
+		replica = sync.Apply(replica)
+		require.Len(t, replica, 2)
+		require.Equal(t, "0.1.0", replica[0].Manifest.Version)
+		require.Equal(t, "0.2.0", replica[1].Manifest.Version)
+	})
+
+	t.Run("unknown cursor resets", func(t *testing.T) {
+		client, tearDown := setupChangesApi(t, catalog.New(makeStore(t, demo)))
+		defer tearDown()
+
+		sync, err := client.SyncCatalog(1)
+		require.NoError(t, err)
+		require.True(t, sync.Reset)
+		require.Len(t, sync.Added, 1)
+	})
+
+	t.Run("license tier", func(t *testing.T) {
+		enterpriseStarter := *starter
+		enterpriseStarter.RequiredLicense = model.LicenseTierEnterprise
+		enterpriseDemo := *demo
+		enterpriseDemo.RequiredLicense = model.LicenseTierEnterprise
+
+		pluginCatalog := catalog.New(makeStore(t, demo, &enterpriseStarter))
+		client, tearDown := setupChangesApi(t, pluginCatalog)
+		defer tearDown()
+		teamClient := *client
+		teamClient.Headers = http.Header{"X-Mattermost-License-Tier": []string{"team"}}
+
+		sync, err := teamClient.SyncCatalog(0)
+		require.NoError(t, err)
+		require.True(t, sync.Reset)
+		require.Equal(t, []*model.Plugin{demo}, sync.Added)
+		replica := sync.Apply(nil)
+
+		pluginCatalog.Replace(makeStore(t, &enterpriseDemo, &enterpriseStarter, newDemo))
+
+		sync, err = teamClient.SyncCatalog(sync.Cursor)
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{newDemo}, sync.Added)
+		require.Empty(t, sync.Changed)
+		require.Equal(t, []*model.PluginVersionRef{{PluginID: "demo", Version: "0.1.0"}}, sync.Removed)
+		require.Equal(t, []*model.Plugin{newDemo}, sync.Apply(replica))
+
+		sync, err = client.SyncCatalog(0)
+		require.NoError(t, err)
+		require.Len(t, sync.Added, 3)
+
+		resp, err := http.Get(client.Address + "/api/v1/catalog/changes?license_tier=platinum")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// channelParameter names the query parameter selecting a channel.
+const channelParameter = "channel"
+
+// selectChannel points the context's store at the channel selected by the request, if any,
+// responding as a bad request if the selected channel does not exist.
+//
+// An explicit channel parameter takes precedence over the channel implied by the request's host.
+func selectChannel(c *Context, w http.ResponseWriter, r *http.Request) bool {
+	channel := r.URL.Query().Get(channelParameter)
+	if channel == "" {
+		channel = c.ChannelHosts[requestHost(r)]
+	}
+	if channel == "" {
+		return true
+	}
+
+	channelStore, ok := c.Channels[channel]
+	if !ok {
+		c.Logger.WithField("channel", channel).Debug("Unknown channel")
+		writeError(c, w, r, http.StatusBadRequest)
+		return false
+	}
+
+	c.Store = channelStore
+	c.Channel = channel
+	c.Logger = c.Logger.WithField("channel", channel)
+
+	return true
+}
+
+// requestHost returns the lowercased host to which the request was addressed, without any port.
+func requestHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+
+	return strings.ToLower(host)
+}
@@ -0,0 +1,104 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+)
+
+func TestChannels(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	newStore := func(version string) *store.Store {
+		data, err := json.Marshal([]*model.Plugin{{
+			DownloadURL:  "https://example.com/demo-" + version + ".tar.gz",
+			ReleaseStage: model.ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: version},
+		}})
+		require.NoError(t, err)
+		pluginStore, err := store.New(bytes.NewReader(data), logger)
+		require.NoError(t, err)
+
+		return pluginStore
+	}
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store: newStore("1.0.0"),
+		Channels: map[string]api.Store{
+			"beta": newStore("1.1.0-beta"),
+		},
+		ChannelHosts: map[string]string{
+			"beta.marketplace.example.com": "beta",
+		},
+		Logger: logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	latestVersion := func(t *testing.T, client *api.Client) string {
+		t.Helper()
+
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: -1})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+
+		return plugins[0].Manifest.Version
+	}
+
+	t.Run("default channel", func(t *testing.T) {
+		require.Equal(t, "1.0.0", latestVersion(t, api.NewClient(ts.URL)))
+	})
+
+	t.Run("channel parameter", func(t *testing.T) {
+		client := api.NewClient(ts.URL)
+		client.Channel = "beta"
+		require.Equal(t, "1.1.0-beta", latestVersion(t, client))
+
+		versions, err := client.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		require.Equal(t, "1.1.0-beta", versions[0].Manifest.Version)
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		client := api.NewClient(ts.URL)
+		client.Channel = "nightly"
+
+		_, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: -1})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	t.Run("channel host", func(t *testing.T) {
+		request := func(host, query string) []*model.Plugin {
+			req, err := http.NewRequest("GET", ts.URL+"/api/v1/plugins"+query, nil)
+			require.NoError(t, err)
+			req.Host = host
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			plugins, err := model.PluginsFromReader(resp.Body)
+			require.NoError(t, err)
+			require.Len(t, plugins, 1)
+
+			return plugins
+		}
+
+		require.Equal(t, "1.1.0-beta", request("Beta.Marketplace.Example.com:443", "")[0].Manifest.Version)
+		require.Equal(t, "1.0.0", request("marketplace.example.com", "")[0].Manifest.Version)
+	})
+}
@@ -1,29 +1,88 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/tracing"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/openpgp"
 )
 
+// PluginClient describes the marketplace operations consumed by downstream projects, allowing
+// them to substitute a fake in tests. It is implemented by Client.
+type PluginClient interface {
+	GetPlugins(request *GetPluginsRequest) ([]*model.Plugin, error)
+	GetPluginVersions(id string) ([]*model.Plugin, error)
+	DownloadPlugin(plugin *model.Plugin, w io.Writer) error
+	DownloadPluginByID(id, version string, w io.Writer) error
+	GetPluginStats(id string) (*model.PluginStats, error)
+	GetMarketplaceStats() (*model.MarketplaceStats, error)
+}
+
+var _ PluginClient = (*Client)(nil)
+
 // Client is the programmatic interface to the marketplace server API.
 type Client struct {
-	Address    string
-	httpClient *http.Client
+	Address string
+	// PublicKeys, if set, causes DownloadPlugin to also verify bundle signatures.
+	PublicKeys openpgp.EntityList
+	// UserAgent, if set, identifies the client on every request it makes.
+	UserAgent string
+	// Headers are sent with every request to the marketplace server, but not to third-party
+	// hosts such as those serving plugin bundles.
+	Headers http.Header
+	// Token, if set, authenticates requests to the marketplace server, such as those submitting
+	// ratings.
+	Token string
+	// Channel, if set, selects the named catalog on servers hosting more than one, such as beta.
+	Channel string
+	// Tracer, if set, records a span for every request, propagating its trace context to the
+	// marketplace server.
+	Tracer trace.Tracer
+	// IconCacheDir, if set, caches the icons fetched by GetPluginIcon in the given directory,
+	// revalidating them with the server rather than transferring them again.
+	IconCacheDir string
+	httpClient   *http.Client
+	ctx          context.Context
 }
 
 // NewClient creates a client to the marketplace server at the given address.
 func NewClient(address string) *Client {
 	return &Client{
 		Address:    address,
+		Headers:    http.Header{},
 		httpClient: &http.Client{},
 	}
 }
 
+// WithContext returns a copy of the client making its requests with the given context, which
+// cancels them and carries the trace continued by the client's spans.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+
+	return &copied
+}
+
 // closeBody ensures the Body of an http.Response is properly closed.
 func closeBody(r *http.Response) {
 	if r.Body != nil {
@@ -36,8 +95,98 @@ func (c *Client) buildURL(urlPath string, args ...interface{}) string {
 	return fmt.Sprintf("%s%s", c.Address, fmt.Sprintf(urlPath, args...))
 }
 
+// isServerURL reports whether the given url targets the configured marketplace server.
+func (c *Client) isServerURL(u *url.URL) bool {
+	address, err := url.Parse(c.Address)
+	if err != nil || address.Host == "" {
+		return false
+	}
+
+	return strings.EqualFold(address.Scheme, u.Scheme) && strings.EqualFold(address.Host, u.Host)
+}
+
 func (c *Client) doGet(u string) (*http.Response, error) {
-	return c.httpClient.Get(u)
+	return c.doRequest(http.MethodGet, u, nil)
+}
+
+func (c *Client) doPost(u string, body io.Reader) (*http.Response, error) {
+	return c.doRequest(http.MethodPost, u, body)
+}
+
+func (c *Client) doRequest(method, u string, body io.Reader) (*http.Response, error) {
+	return c.doRequestWithHeader(method, u, body, nil)
+}
+
+// doRequestWithHeader makes a request like doRequest, additionally sending the given header.
+func (c *Client) doRequestWithHeader(method, u string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build request")
+	}
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	if c.isServerURL(req.URL) {
+		if c.Channel != "" {
+			q := req.URL.Query()
+			q.Set(channelParameter, c.Channel)
+			req.URL.RawQuery = q.Encode()
+		}
+		for name, values := range c.Headers {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		if c.Token != "" {
+			req.Header.Set("Authorization", bearerPrefix+c.Token)
+		}
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.ctx != nil {
+		req = req.WithContext(c.ctx)
+	}
+
+	if c.Tracer == nil {
+		if c.isServerURL(req.URL) {
+			tracing.Propagator.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+		}
+		return c.httpClient.Do(req)
+	}
+
+	ctx, span := c.Tracer.Start(req.Context(), method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", method),
+			attribute.String("http.url", req.URL.Redacted()),
+		),
+	)
+	defer span.End()
+
+	req = req.WithContext(ctx)
+	if c.isServerURL(req.URL) {
+		tracing.Propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	return resp, nil
 }
 
 // GetPlugins fetches the list of plugins from the configured server.
@@ -59,6 +208,1315 @@ func (c *Client) GetPlugins(request *GetPluginsRequest) ([]*model.Plugin, error)
 	case http.StatusOK:
 		return model.PluginsFromReader(resp.Body)
 	default:
-		return nil, errors.Errorf("failed with status code %d", resp.StatusCode)
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetChangesCursor fetches the cursor of the latest change to the catalog from the configured
+// server, from which to request future changes.
+func (c *Client) GetChangesCursor() (int64, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/changes"))
+	if err != nil {
+		return 0, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		changes, err := model.CatalogChangesFromReader(resp.Body)
+		if err != nil {
+			return 0, err
+		}
+		return changes.Cursor, nil
+	default:
+		return 0, errorFromResponse(resp)
+	}
+}
+
+// GetChanges fetches the changes to the catalog after the requested cursor from the configured
+// server, returning ErrUnknownCursor if the server no longer retains them.
+func (c *Client) GetChanges(request *GetChangesRequest) (*model.CatalogChanges, error) {
+	u, err := url.Parse(c.buildURL("/api/v1/changes"))
+	if err != nil {
+		return nil, err
+	}
+
+	request.ApplyToURL(u)
+
+	resp, err := c.doGet(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.CatalogChangesFromReader(resp.Body)
+	case http.StatusGone:
+		return nil, ErrUnknownCursor
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// SyncCatalog fetches the net changes to the catalog after the given cursor from the configured
+// server, or the whole catalog, marked as a reset, given no cursor or one the server no longer
+// retains. Apply the result to a replica of the catalog with CatalogSync.Apply, and pass its
+// Cursor to the next call.
+func (c *Client) SyncCatalog(since int64) (*model.CatalogSync, error) {
+	u, err := url.Parse(c.buildURL("/api/v1/catalog/changes"))
+	if err != nil {
+		return nil, err
+	}
+	if since != 0 {
+		u.RawQuery = url.Values{"since": []string{strconv.FormatInt(since, 10)}}.Encode()
+	}
+
+	resp, err := c.doGet(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.CatalogSyncFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetCatalogDigest fetches the digest of the catalog served by the configured server, signed if
+// the server signs catalogs.
+func (c *Client) GetCatalogDigest() (*model.CatalogDigest, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/catalog/digest"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.CatalogDigestFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetSigningKeys fetches the public keys verifying the signatures published by the configured
+// server.
+func (c *Client) GetSigningKeys() ([]*model.PublicKey, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/signing-keys"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.PublicKeysFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// PinSigningKeys fetches the public keys of the configured server and trusts those matching the
+// given fingerprints and currently valid, replacing the client's PublicKeys, so that
+// DownloadPlugin verifies bundle signatures against them. Keys served with other fingerprints are
+// ignored, so that a compromised server cannot introduce keys of its own.
+func (c *Client) PinSigningKeys(fingerprints ...string) error {
+	if len(fingerprints) == 0 {
+		return errors.New("no fingerprints to pin")
+	}
+	pinned := map[string]bool{}
+	for _, fingerprint := range fingerprints {
+		pinned[normalizeFingerprint(fingerprint)] = true
+	}
+
+	publicKeys, err := c.GetSigningKeys()
+	if err != nil {
+		return err
+	}
+
+	var keyring openpgp.EntityList
+	now := time.Now()
+	for _, publicKey := range publicKeys {
+		if !pinned[normalizeFingerprint(publicKey.Fingerprint)] || !publicKey.IsValidAt(now) {
+			continue
+		}
+
+		entities, err := ReadPublicKeys(strings.NewReader(publicKey.PublicKey))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read public key %s", publicKey.PublicKeyHash)
+		}
+		// Trust only the key the fingerprint pins, not whatever the served key actually holds.
+		for _, entity := range entities {
+			if !pinned[hex.EncodeToString(entity.PrimaryKey.Fingerprint[:])] {
+				return errors.Errorf("public key %s does not match its fingerprint %s", publicKey.PublicKeyHash, publicKey.Fingerprint)
+			}
+		}
+		keyring = append(keyring, entities...)
+	}
+	if len(keyring) == 0 {
+		return errors.New("server published no valid key matching the pinned fingerprints")
+	}
+
+	c.PublicKeys = keyring
+
+	return nil
+}
+
+// normalizeFingerprint lowercases the given hex-encoded fingerprint, dropping any spaces.
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.Replace(fingerprint, " ", "", -1))
+}
+
+// GetApps fetches the list of apps from the configured server.
+func (c *Client) GetApps(request *GetAppsRequest) ([]*model.App, error) {
+	u, err := url.Parse(c.buildURL("/api/v1/apps"))
+	if err != nil {
+		return nil, err
+	}
+
+	request.ApplyToURL(u)
+
+	resp, err := c.doGet(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.AppsFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetPlugin fetches the latest version of the given plugin from the configured server, resolving
+// ids case-insensitively and the old ids of renamed plugins.
+func (c *Client) GetPlugin(id string) (*model.Plugin, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/plugins/%s", url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.PluginFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// PluginIcon is the icon of a plugin.
+type PluginIcon struct {
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// cachedPluginIcon is a plugin icon cached on disk along with its entity tag.
+type cachedPluginIcon struct {
+	ETag string `json:"etag"`
+	PluginIcon
+}
+
+// GetPluginIcon fetches the icon of the latest version of the given plugin from the configured
+// server, following redirects to externally hosted icons. If IconCacheDir is set, a cached icon is
+// only transferred again once changed.
+func (c *Client) GetPluginIcon(id string) (*PluginIcon, error) {
+	cachePath := c.iconCachePath(id)
+
+	var cached *cachedPluginIcon
+	header := http.Header{}
+	if cachePath != "" {
+		cached = readCachedPluginIcon(cachePath)
+		if cached != nil {
+			header.Set("If-None-Match", cached.ETag)
+		}
+	}
+
+	resp, err := c.doRequestWithHeader(http.MethodGet, c.buildURL("/api/v1/plugins/%s/icon", url.PathEscape(id)), nil, header)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if cached == nil {
+			return nil, errors.New("icon not modified, but not cached")
+		}
+		return &cached.PluginIcon, nil
+	case http.StatusOK:
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read icon")
+		}
+		icon := &PluginIcon{ContentType: resp.Header.Get("Content-Type"), Data: data}
+		if etag := resp.Header.Get("ETag"); cachePath != "" && etag != "" {
+			// Failing to cache the icon only costs transferring it again.
+			_ = writeCachedPluginIcon(cachePath, &cachedPluginIcon{ETag: etag, PluginIcon: *icon})
+		}
+		return icon, nil
+	case http.StatusNotFound:
+		if cachePath != "" {
+			os.Remove(cachePath)
+		}
+		return nil, errorFromResponse(resp)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// ResolvePluginIcon returns the icon of the given plugin, decoding it when inlined as IconData or
+// fetching it when hosted externally at IconURL.
+func (c *Client) ResolvePluginIcon(plugin *model.Plugin) (*PluginIcon, error) {
+	if plugin.IconData != "" {
+		mimeType, data, err := model.DecodeIconData(plugin.IconData)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode icon")
+		}
+		return &PluginIcon{ContentType: mimeType, Data: data}, nil
+	}
+	if plugin.IconURL == "" {
+		return nil, errors.Wrap(ErrNotFound, "plugin has no icon")
+	}
+
+	resp, err := c.doGet(plugin.IconURL)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorFromResponse(resp)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read icon")
+	}
+
+	return &PluginIcon{ContentType: resp.Header.Get("Content-Type"), Data: data}, nil
+}
+
+// iconCachePath returns the path at which the icon of the given plugin is cached, keyed by the
+// server and channel it is fetched from, or the empty string if icons are not cached.
+func (c *Client) iconCachePath(id string) string {
+	if c.IconCacheDir == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(c.Address + "\x00" + c.Channel + "\x00" + id))
+
+	return filepath.Join(c.IconCacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readCachedPluginIcon reads the icon cached at the given path, if any.
+func readCachedPluginIcon(path string) *cachedPluginIcon {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var cached cachedPluginIcon
+	if err := json.Unmarshal(data, &cached); err != nil || cached.ETag == "" {
+		return nil
+	}
+
+	return &cached
+}
+
+// writeCachedPluginIcon atomically caches the given icon at the given path.
+func writeCachedPluginIcon(path string, cached *cachedPluginIcon) error {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode icon")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "failed to create icon cache")
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), ".icon-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create cached icon")
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return errors.Wrap(err, "failed to write cached icon")
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "failed to write cached icon")
+	}
+
+	return errors.Wrap(os.Rename(file.Name(), path), "failed to write cached icon")
+}
+
+// GetPluginVersions fetches every version of the given plugin from the configured server, sorted
+// by version descending.
+func (c *Client) GetPluginVersions(id string) ([]*model.Plugin, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/plugins/%s/versions", url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.PluginsFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetPluginVersionBuckets fetches every version of the given plugin from the configured server,
+// grouped by their minimum server version, most demanding first.
+func (c *Client) GetPluginVersionBuckets(id string) ([]*model.VersionBucket, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/plugins/%s/versions?group_by=min_server_version", url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.VersionBucketsFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetPluginStats fetches the download statistics of the given plugin from the configured server.
+func (c *Client) GetPluginStats(id string) (*model.PluginStats, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/plugins/%s/stats", url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.PluginStatsFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetPluginCompatibility fetches the version of the given plugin served to each of the given
+// server versions, or to each of the server's default versions if none are given.
+func (c *Client) GetPluginCompatibility(id string, serverVersions []string) (*model.CompatibilityMatrix, error) {
+	u, err := url.Parse(c.buildURL("/api/v1/plugins/%s/compatibility", url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	if len(serverVersions) > 0 {
+		q := u.Query()
+		q.Set("server_versions", strings.Join(serverVersions, ","))
+		u.RawQuery = q.Encode()
+	}
+
+	resp, err := c.doGet(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.CompatibilityMatrixFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
 	}
 }
+
+// GetPluginChangelog fetches the release notes of the versions of the given plugin released after
+// the from version, if given, up to and including the to version, or else the latest version.
+func (c *Client) GetPluginChangelog(id, from, to string) (*model.Changelog, error) {
+	u, err := url.Parse(c.buildURL("/api/v1/plugins/%s/changelog", url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	if from != "" {
+		q.Set("from", from)
+	}
+	if to != "" {
+		q.Set("to", to)
+	}
+	u.RawQuery = q.Encode()
+
+	resp, err := c.doGet(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.ChangelogFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// ReportInstall anonymously reports the installation or upgrade of a plugin to the configured
+// server, counting towards the plugin's adoption.
+func (c *Client) ReportInstall(event *model.InstallEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal install event")
+	}
+
+	resp, err := c.doPost(c.buildURL("/api/v1/telemetry/install"), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	default:
+		return errorFromResponse(resp)
+	}
+}
+
+// GetMarketplaceStats fetches the download statistics across all plugins from the configured
+// server.
+func (c *Client) GetMarketplaceStats() (*model.MarketplaceStats, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/stats"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.MarketplaceStatsFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetAnalytics fetches the report of the anonymized queries made over the given number of days,
+// listing at most limit values of each, requiring the client's Token to identify a moderator.
+func (c *Client) GetAnalytics(days, limit int) (*model.AnalyticsReport, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/analytics?days=%d&limit=%d", days, limit))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.AnalyticsReportFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetPluginRatings fetches the rating summary and reviews of the given plugin from the
+// configured server.
+func (c *Client) GetPluginRatings(id string) (*model.PluginRatings, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/plugins/%s/ratings", url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.PluginRatingsFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// SubmitRating rates the given plugin on behalf of the user identified by the client's Token,
+// returning the plugin's updated rating summary.
+func (c *Client) SubmitRating(id string, request *SubmitRatingRequest) (*model.RatingSummary, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal rating")
+	}
+
+	resp, err := c.doPost(c.buildURL("/api/v1/plugins/%s/ratings", url.PathEscape(id)), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.RatingSummaryFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// Submit submits the given plugin repository for inclusion on behalf of the user identified by
+// the client's Token.
+func (c *Client) Submit(repositoryURL string) (*model.Submission, error) {
+	return c.postSubmission(c.buildURL("/api/v1/submissions"), &SubmitRequest{RepositoryURL: repositoryURL}, http.StatusCreated)
+}
+
+// GetSubmissions fetches the submissions visible to the user identified by the client's Token,
+// optionally constrained to the given status.
+func (c *Client) GetSubmissions(status model.SubmissionStatus) ([]*model.Submission, error) {
+	u, err := url.Parse(c.buildURL("/api/v1/submissions"))
+	if err != nil {
+		return nil, err
+	}
+	if status != "" {
+		u.RawQuery = url.Values{"status": []string{string(status)}}.Encode()
+	}
+
+	resp, err := c.doGet(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.SubmissionsFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetSubmission fetches the given submission.
+func (c *Client) GetSubmission(id string) (*model.Submission, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/submissions/%s", url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.SubmissionFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// ApproveSubmission accepts the given submission, requiring the client's Token to identify a
+// moderator.
+func (c *Client) ApproveSubmission(id, reason string) (*model.Submission, error) {
+	return c.postSubmission(c.buildURL("/api/v1/submissions/%s/approve", url.PathEscape(id)), &ModerateRequest{Reason: reason}, http.StatusOK)
+}
+
+// RejectSubmission declines the given submission, requiring the client's Token to identify a
+// moderator.
+func (c *Client) RejectSubmission(id, reason string) (*model.Submission, error) {
+	return c.postSubmission(c.buildURL("/api/v1/submissions/%s/reject", url.PathEscape(id)), &ModerateRequest{Reason: reason}, http.StatusOK)
+}
+
+func (c *Client) postSubmission(u string, request interface{}, expectedStatusCode int) (*model.Submission, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal request")
+	}
+
+	resp, err := c.doPost(u, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case expectedStatusCode:
+		return model.SubmissionFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// CreateAPIKey issues an API key to a partner, requiring the client's Token to identify a
+// moderator. The returned token cannot be retrieved later.
+func (c *Client) CreateAPIKey(request *CreateAPIKeyRequest) (*model.IssuedAPIKey, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal request")
+	}
+
+	resp, err := c.doPost(c.buildURL("/api/v1/keys"), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return model.IssuedAPIKeyFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetAPIKeys fetches every API key along with its usage, requiring the client's Token to identify
+// a moderator.
+func (c *Client) GetAPIKeys() ([]*model.APIKey, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/keys"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.APIKeysFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetAPIKey fetches the given API key along with its usage, requiring the client's Token to
+// identify a moderator.
+func (c *Client) GetAPIKey(id string) (*model.APIKey, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/keys/%s", url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.APIKeyFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// RevokeAPIKey stops the server accepting the given API key, requiring the client's Token to
+// identify a moderator.
+func (c *Client) RevokeAPIKey(id string) (*model.APIKey, error) {
+	resp, err := c.doPost(c.buildURL("/api/v1/keys/%s/revoke", url.PathEscape(id)), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.APIKeyFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetSnapshots fetches the catalog snapshots retained by the server, most recent first, requiring
+// the client's Token to identify a moderator.
+func (c *Client) GetSnapshots() ([]*model.CatalogSnapshot, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/snapshots"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.CatalogSnapshotsFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// RollbackSnapshot restores the catalog of the given snapshot, requiring the client's Token to
+// identify a moderator.
+func (c *Client) RollbackSnapshot(id int64) (*model.CatalogSnapshot, error) {
+	resp, err := c.doPost(c.buildURL("/api/v1/snapshots/%d/rollback", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.CatalogSnapshotFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetCatalogGrowth fetches the composition of each catalog snapshot retained by the server, and
+// the growth over them, requiring the client's Token to identify a moderator.
+func (c *Client) GetCatalogGrowth() (*model.CatalogGrowth, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/snapshots/growth"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.CatalogGrowthFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// StageCatalog uploads the given plugins as a candidate to replace the server's catalog once
+// validated and promoted, requiring the client's Token to identify a moderator.
+func (c *Client) StageCatalog(plugins io.Reader) (*model.StagingReport, error) {
+	resp, err := c.doRequest(http.MethodPut, c.buildURL("/api/v1/staging"), plugins)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return model.StagingReportFromReader(resp.Body)
+	case http.StatusBadRequest:
+		report, err := model.StagingReportFromReader(resp.Body)
+		if err != nil {
+			return nil, errorFromResponse(resp)
+		}
+		return nil, &RejectedCandidateError{Problems: report.Problems}
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetStagedCatalog fetches the report of the candidate catalog staged on the server, requiring the
+// client's Token to identify a moderator.
+func (c *Client) GetStagedCatalog() (*model.StagingReport, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/staging"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.StagingReportFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// ValidateStagedCatalog validates the candidate catalog staged on the server, returning its
+// report, requiring the client's Token to identify a moderator.
+func (c *Client) ValidateStagedCatalog() (*model.StagingReport, error) {
+	resp, err := c.doPost(c.buildURL("/api/v1/staging/validate"), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.StagingReportFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// PromoteStagedCatalog serves the validated candidate catalog staged on the server, returning the
+// snapshot retaining it, requiring the client's Token to identify a moderator.
+func (c *Client) PromoteStagedCatalog() (*model.CatalogSnapshot, error) {
+	resp, err := c.doPost(c.buildURL("/api/v1/staging/promote"), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.CatalogSnapshotFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// DiscardStagedCatalog drops the candidate catalog staged on the server, requiring the client's
+// Token to identify a moderator.
+func (c *Client) DiscardStagedCatalog() error {
+	resp, err := c.doRequest(http.MethodDelete, c.buildURL("/api/v1/staging"), nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	default:
+		return errorFromResponse(resp)
+	}
+}
+
+// ImportCatalog replaces the server's catalog with the given plugins, returning the snapshot
+// retaining them, requiring the client's Token to identify a moderator. Plugins failing
+// validation are rejected with a RejectedCandidateError.
+func (c *Client) ImportCatalog(plugins io.Reader) (*model.CatalogSnapshot, error) {
+	resp, err := c.doRequest(http.MethodPut, c.buildURL("/api/v1/admin/catalog"), plugins)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.CatalogSnapshotFromReader(resp.Body)
+	case http.StatusBadRequest:
+		report, err := model.StagingReportFromReader(resp.Body)
+		if err != nil {
+			return nil, errorFromResponse(resp)
+		}
+		return nil, &RejectedCandidateError{Problems: report.Problems}
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// ValidatePluginEntry validates the given plugin entry as the server's catalog would, without the
+// server persisting it, requiring the client's Token to identify a user. Given checkURLs, the
+// server also checks the entry's urls respond and verifies its bundle, requiring a moderator.
+func (c *Client) ValidatePluginEntry(plugin *model.Plugin, checkURLs bool) (*EntryValidation, error) {
+	data, err := json.Marshal(plugin)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal plugin")
+	}
+
+	u := c.buildURL("/api/v1/admin/plugins/validate")
+	if checkURLs {
+		u += "?check_urls=true"
+	}
+
+	resp, err := c.doPost(u, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var validation EntryValidation
+		if err := json.NewDecoder(resp.Body).Decode(&validation); err != nil {
+			return nil, errors.Wrap(err, "failed to decode entry validation")
+		}
+		return &validation, nil
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// GetBlocklist fetches every unexpired blocklist entry, requiring the client's Token to identify
+// a moderator.
+func (c *Client) GetBlocklist() ([]*model.BlocklistEntry, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/blocklist"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.BlocklistEntriesFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// Block denies access to the clients matching the given criteria, requiring the client's Token
+// to identify a moderator.
+func (c *Client) Block(request *BlockRequest) (*model.BlocklistEntry, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal request")
+	}
+
+	resp, err := c.doPost(c.buildURL("/api/v1/blocklist"), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return model.BlocklistEntryFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// Unblock removes the given blocklist entry, requiring the client's Token to identify a
+// moderator.
+func (c *Client) Unblock(id string) error {
+	resp, err := c.doRequest(http.MethodDelete, c.buildURL("/api/v1/blocklist/%s", url.PathEscape(id)), nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	default:
+		return errorFromResponse(resp)
+	}
+}
+
+// GetAdvisories fetches the security advisories published for the given plugin, or for every
+// plugin if none is given, most recent first.
+func (c *Client) GetAdvisories(pluginID string) ([]*model.Advisory, error) {
+	u, err := url.Parse(c.buildURL("/api/v1/advisories"))
+	if err != nil {
+		return nil, err
+	}
+	if pluginID != "" {
+		q := u.Query()
+		q.Set("plugin_id", pluginID)
+		u.RawQuery = q.Encode()
+	}
+
+	resp, err := c.doGet(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.AdvisoriesFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// PublishAdvisory publishes a security advisory for a plugin, requiring the client's Token to
+// identify a moderator.
+func (c *Client) PublishAdvisory(request *PublishAdvisoryRequest) (*model.Advisory, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal request")
+	}
+
+	resp, err := c.doPost(c.buildURL("/api/v1/advisories"), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return model.AdvisoryFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// WithdrawAdvisory withdraws the given advisory, requiring the client's Token to identify a
+// moderator.
+func (c *Client) WithdrawAdvisory(id string) error {
+	resp, err := c.doRequest(http.MethodDelete, c.buildURL("/api/v1/advisories/%s", url.PathEscape(id)), nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	default:
+		return errorFromResponse(resp)
+	}
+}
+
+// GetFeaturings fetches the featurings set by moderators, featured plugins first.
+func (c *Client) GetFeaturings() ([]*model.Featuring, error) {
+	resp, err := c.doGet(c.buildURL("/api/v1/featured"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.FeaturingsFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// SetFeaturing features the given plugin, or stops featuring it, requiring the client's Token to
+// identify a moderator.
+func (c *Client) SetFeaturing(id string, request *SetFeaturingRequest) (*model.Featuring, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal request")
+	}
+
+	resp, err := c.doRequest(http.MethodPut, c.buildURL("/api/v1/featured/%s", url.PathEscape(id)), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.FeaturingFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// UnsetFeaturing reverts the given plugin to the featuring recorded in the database, requiring
+// the client's Token to identify a moderator.
+func (c *Client) UnsetFeaturing(id string) error {
+	resp, err := c.doRequest(http.MethodDelete, c.buildURL("/api/v1/featured/%s", url.PathEscape(id)), nil)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	default:
+		return errorFromResponse(resp)
+	}
+}
+
+// GetTombstones fetches the tombstones of the plugin versions removed from the catalog, limited to
+// the given plugin and version if given, most recently removed first.
+func (c *Client) GetTombstones(pluginID, version string) ([]*model.Tombstone, error) {
+	u, err := url.Parse(c.buildURL("/api/v1/tombstones"))
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	if pluginID != "" {
+		q.Set("plugin_id", pluginID)
+	}
+	if version != "" {
+		q.Set("version", version)
+	}
+	u.RawQuery = q.Encode()
+
+	resp, err := c.doGet(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return model.TombstonesFromReader(resp.Body)
+	default:
+		return nil, errorFromResponse(resp)
+	}
+}
+
+// DownloadPlugin streams the bundle of the given plugin to w.
+//
+// If the plugin records checksums, the streamed bytes are verified against them. If the
+// client has PublicKeys configured, the bundle's signature is also verified. Since verification
+// completes only once the stream ends, callers must discard anything written to w when an error
+// is returned.
+func (c *Client) DownloadPlugin(plugin *model.Plugin, w io.Writer) error {
+	if plugin.DownloadURL == "" {
+		return errors.New("plugin has no download url")
+	}
+
+	resp, err := c.doGet(plugin.DownloadURL)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return errorFromResponse(resp)
+	}
+
+	var checksumsWriter *model.ChecksumsWriter
+	if plugin.Checksums != nil {
+		checksumsWriter = model.NewChecksumsWriter()
+		w = io.MultiWriter(w, checksumsWriter)
+	}
+
+	var signatureWriter *io.PipeWriter
+	var signatureResult chan error
+	if len(c.PublicKeys) > 0 {
+		signature, err := selectSignature(plugin.AllSignatures(), c.PublicKeys)
+		if err != nil {
+			return err
+		}
+
+		var signatureReader *io.PipeReader
+		signatureReader, signatureWriter = io.Pipe()
+		signatureResult = make(chan error, 1)
+		go func() {
+			err := VerifyPluginSignature(signatureReader, signature, c.PublicKeys)
+			// Drain the remainder so the download never blocks on a failed verification.
+			_, _ = io.Copy(ioutil.Discard, signatureReader)
+			signatureResult <- err
+		}()
+		w = io.MultiWriter(w, signatureWriter)
+	}
+
+	err = c.streamBundle(plugin.DownloadURL, resp, w)
+	if signatureWriter != nil {
+		signatureWriter.CloseWithError(err)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to stream plugin bundle")
+	}
+
+	if checksumsWriter != nil {
+		if err := plugin.Checksums.Verify(checksumsWriter.Checksums()); err != nil {
+			return err
+		}
+	}
+
+	if signatureResult != nil {
+		if err := <-signatureResult; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// maxDownloadResumes bounds the times a failed bundle transfer is resumed.
+const maxDownloadResumes = 3
+
+// readErrorRecorder records the error, if any, reading from the wrapped reader, telling apart a
+// failed transfer from a failed write.
+type readErrorRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (r *readErrorRecorder) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+
+	return n, err
+}
+
+// streamBundle copies the bundle in the given response from the given url to w. Should the
+// transfer fail part way through, and the server accept range requests, it is resumed from the
+// bytes already written, provided the bundle is unchanged.
+func (c *Client) streamBundle(u string, resp *http.Response, w io.Writer) error {
+	etag := resp.Header.Get("ETag")
+
+	var written int64
+	for resumes := 0; ; resumes++ {
+		body := &readErrorRecorder{r: resp.Body}
+		n, err := io.Copy(w, body)
+		written += n
+		if err == nil {
+			return nil
+		}
+		if body.err == nil || resumes == maxDownloadResumes || resp.Header.Get("Accept-Ranges") != "bytes" {
+			return err
+		}
+
+		header := http.Header{}
+		header.Set("Range", fmt.Sprintf("bytes=%d-", written))
+		if etag != "" {
+			header.Set("If-Range", etag)
+		}
+		resumed, resumeErr := c.doRequestWithHeader(http.MethodGet, u, nil, header)
+		if resumeErr != nil {
+			return err
+		}
+		defer closeBody(resumed)
+		// Anything but the remainder, such as the whole of a changed bundle, cannot be appended.
+		if resumed.StatusCode != http.StatusPartialContent || !strings.HasPrefix(resumed.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", written)) {
+			return err
+		}
+		resp = resumed
+	}
+}
+
+// DownloadPluginByID resolves the plugin with the given id from the configured server and
+// streams its bundle to w, as per DownloadPlugin.
+//
+// An empty version accepts whichever version the server considers the latest.
+func (c *Client) DownloadPluginByID(id, version string, w io.Writer) error {
+	plugin, err := FindPlugin(c, id, version)
+	if err != nil {
+		return err
+	}
+
+	return c.DownloadPlugin(plugin, w)
+}
+
+// CheckUpdates reports which of the given installed plugins, mapping plugin ids to installed
+// versions, have a newer version compatible with the given server version on the configured
+// server, as per the CheckUpdates function.
+func (c *Client) CheckUpdates(installed map[string]string, serverVersion string) ([]*model.PluginUpdate, error) {
+	return CheckUpdates(c, installed, serverVersion)
+}
+
+// CheckUpdates reports which of the given installed plugins, mapping plugin ids to installed
+// versions, have a newer version compatible with the given server version using the given client.
+// Plugins installed under an old id of a renamed plugin are offered the renamed plugin, and
+// plugins unknown to the marketplace are ignored. An empty server version considers the latest
+// version of each plugin.
+func CheckUpdates(client PluginClient, installed map[string]string, serverVersion string) ([]*model.PluginUpdate, error) {
+	plugins, err := client.GetPlugins(&GetPluginsRequest{
+		PerPage:       model.AllPerPage,
+		ServerVersion: serverVersion,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get plugins")
+	}
+
+	return model.FindUpdates(installed, plugins)
+}
+
+// FindPlugin resolves the plugin with the given id and, if non-empty, version using the given
+// client.
+func FindPlugin(client PluginClient, id, version string) (*model.Plugin, error) {
+	if version != "" {
+		return findPluginVersion(client, id, version)
+	}
+
+	plugins, err := client.GetPlugins(&GetPluginsRequest{
+		Filter:  id,
+		PerPage: model.AllPerPage,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get plugin %s", id)
+	}
+
+	for _, plugin := range plugins {
+		if plugin.Manifest == nil || !plugin.HasID(id) {
+			continue
+		}
+
+		return plugin, nil
+	}
+
+	return nil, errors.Wrapf(ErrNotFound, "plugin %s", id)
+}
+
+// findPluginVersion resolves the given version of the plugin with the given id, which need not be
+// the latest.
+func findPluginVersion(client PluginClient, id, version string) (*model.Plugin, error) {
+	plugins, err := client.GetPluginVersions(id)
+	if err != nil && err != ErrNotFound {
+		return nil, errors.Wrapf(err, "failed to get plugin %s", id)
+	}
+
+	for _, plugin := range plugins {
+		if plugin.Manifest != nil && plugin.Manifest.Version == version {
+			return plugin, nil
+		}
+	}
+
+	return nil, errors.Wrapf(ErrNotFound, "plugin %s with version %s", id, version)
+}
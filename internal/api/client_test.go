@@ -0,0 +1,349 @@
+package api_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBundleServer(t *testing.T, bundle []byte) (string, func()) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bundle.tar.gz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write(bundle)
+	}))
+
+	return ts.URL + "/bundle.tar.gz", ts.Close
+}
+
+func TestDownloadPlugin(t *testing.T) {
+	bundle := []byte("plugin bundle contents")
+	checksum := sha256.Sum256(bundle)
+
+	downloadURL, tearDownBundleServer := setupBundleServer(t, bundle)
+	defer tearDownBundleServer()
+
+	plugin := &model.Plugin{
+		DownloadURL: downloadURL,
+		Checksums:   &model.Checksums{SHA256: hex.EncodeToString(checksum[:])},
+		Manifest:    &mattermostModel.Manifest{Id: "mattermost-plugin-demo", Name: "mattermost-plugin-demo", Version: "0.1.0"},
+	}
+
+	t.Run("matching checksum", func(t *testing.T) {
+		client := api.NewClient("")
+
+		var buf bytes.Buffer
+		err := client.DownloadPlugin(plugin, &buf)
+		require.NoError(t, err)
+		require.Equal(t, bundle, buf.Bytes())
+	})
+
+	t.Run("mismatched checksum", func(t *testing.T) {
+		client := api.NewClient("")
+
+		var buf bytes.Buffer
+		err := client.DownloadPlugin(&model.Plugin{
+			DownloadURL: downloadURL,
+			Checksums:   &model.Checksums{SHA256: "0000"},
+		}, &buf)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "checksum mismatch")
+	})
+
+	t.Run("no checksum", func(t *testing.T) {
+		client := api.NewClient("")
+
+		var buf bytes.Buffer
+		err := client.DownloadPlugin(&model.Plugin{DownloadURL: downloadURL}, &buf)
+		require.NoError(t, err)
+		require.Equal(t, bundle, buf.Bytes())
+	})
+
+	t.Run("missing bundle", func(t *testing.T) {
+		client := api.NewClient("")
+
+		var buf bytes.Buffer
+		err := client.DownloadPlugin(&model.Plugin{DownloadURL: downloadURL + ".missing"}, &buf)
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("resumes an interrupted transfer", func(t *testing.T) {
+		var requests, resumed int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.Header().Set("ETag", `"bundle"`)
+			if r.Header.Get("Range") != "" {
+				atomic.AddInt32(&resumed, 1)
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(bundle))
+				return
+			}
+
+			// Send only the first half of the bundle before dropping the connection.
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", strconv.Itoa(len(bundle)))
+			_, _ = w.Write(bundle[:len(bundle)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}))
+		defer ts.Close()
+
+		client := api.NewClient("")
+
+		var buf bytes.Buffer
+		err := client.DownloadPlugin(&model.Plugin{DownloadURL: ts.URL, Checksums: plugin.Checksums}, &buf)
+		require.NoError(t, err)
+		require.Equal(t, bundle, buf.Bytes())
+		require.EqualValues(t, 2, atomic.LoadInt32(&requests))
+		require.EqualValues(t, 1, atomic.LoadInt32(&resumed))
+	})
+
+	t.Run("by id", func(t *testing.T) {
+		client, tearDown := setupApi(t, []*model.Plugin{plugin})
+		defer tearDown()
+
+		var buf bytes.Buffer
+		err := client.DownloadPluginByID("mattermost-plugin-demo", "", &buf)
+		require.NoError(t, err)
+		require.Equal(t, bundle, buf.Bytes())
+	})
+
+	t.Run("by id and version", func(t *testing.T) {
+		client, tearDown := setupApi(t, []*model.Plugin{plugin})
+		defer tearDown()
+
+		var buf bytes.Buffer
+		err := client.DownloadPluginByID("mattermost-plugin-demo", "0.1.0", &buf)
+		require.NoError(t, err)
+		require.Equal(t, bundle, buf.Bytes())
+
+		err = client.DownloadPluginByID("mattermost-plugin-demo", "0.2.0", &buf)
+		require.EqualError(t, err, "plugin mattermost-plugin-demo with version 0.2.0: not found")
+		require.Equal(t, api.ErrNotFound, errors.Cause(err))
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		client, tearDown := setupApi(t, []*model.Plugin{plugin})
+		defer tearDown()
+
+		var buf bytes.Buffer
+		err := client.DownloadPluginByID("unknown", "", &buf)
+		require.EqualError(t, err, "plugin unknown: not found")
+		require.Equal(t, api.ErrNotFound, errors.Cause(err))
+	})
+}
+
+func TestCheckUpdates(t *testing.T) {
+	demoV1 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0", MinServerVersion: "9.5.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	demoV2 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0", MinServerVersion: "10.5.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	todo := &model.Plugin{
+		DownloadURL:  "https://example.com/todo-1.0.0.tar.gz",
+		OldIDs:       []string{"com.example.todo"},
+		Manifest:     &mattermostModel.Manifest{Id: "todo", Name: "Todo", Version: "1.0.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+
+	client, tearDown := setupApi(t, []*model.Plugin{demoV1, demoV2, todo})
+	defer tearDown()
+
+	installed := map[string]string{
+		"demo":             "0.1.0",
+		"com.example.todo": "0.9.0",
+		"unknown":          "1.0.0",
+	}
+
+	t.Run("latest server version", func(t *testing.T) {
+		updates, err := client.CheckUpdates(installed, "10.5.0")
+		require.NoError(t, err)
+		require.Len(t, updates, 2)
+		require.Equal(t, "com.example.todo", updates[0].PluginID)
+		require.Equal(t, "1.0.0", updates[0].LatestVersion)
+		require.Equal(t, "todo", updates[0].Plugin.Manifest.Id)
+		require.Equal(t, "demo", updates[1].PluginID)
+		require.Equal(t, "0.1.0", updates[1].InstalledVersion)
+		require.Equal(t, "0.2.0", updates[1].LatestVersion)
+	})
+
+	t.Run("older server version", func(t *testing.T) {
+		updates, err := client.CheckUpdates(installed, "9.11.0")
+		require.NoError(t, err)
+		require.Len(t, updates, 1)
+		require.Equal(t, "com.example.todo", updates[0].PluginID)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		updates, err := client.CheckUpdates(map[string]string{"demo": "0.2.0", "todo": "1.0.0"}, "")
+		require.NoError(t, err)
+		require.Empty(t, updates)
+	})
+
+	t.Run("invalid installed version", func(t *testing.T) {
+		_, err := client.CheckUpdates(map[string]string{"demo": "latest"}, "")
+		require.Error(t, err)
+	})
+}
+
+func TestClientHeaders(t *testing.T) {
+	var serverHeaders, bundleHeaders http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverHeaders = r.Header
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer ts.Close()
+
+	bundleServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bundleHeaders = r.Header
+	}))
+	defer bundleServer.Close()
+
+	client := api.NewClient(ts.URL)
+	client.UserAgent = "mattermost-server/5.18.0"
+	client.Headers.Set("X-Integration", "dashboard")
+
+	_, err := client.GetPlugins(&api.GetPluginsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "mattermost-server/5.18.0", serverHeaders.Get("User-Agent"))
+	require.Equal(t, "dashboard", serverHeaders.Get("X-Integration"))
+
+	err = client.DownloadPlugin(&model.Plugin{DownloadURL: bundleServer.URL}, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.Equal(t, "mattermost-server/5.18.0", bundleHeaders.Get("User-Agent"))
+	require.Empty(t, bundleHeaders.Get("X-Integration"))
+}
+
+func TestClientErrors(t *testing.T) {
+	setupServer := func(t *testing.T, handler http.HandlerFunc) (*api.Client, func()) {
+		ts := httptest.NewServer(handler)
+
+		return api.NewClient(ts.URL), ts.Close
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		client, tearDown := setupServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		defer tearDown()
+
+		_, err := client.GetPlugins(&api.GetPluginsRequest{})
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		client, tearDown := setupServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+		defer tearDown()
+
+		_, err := client.GetPlugins(&api.GetPluginsRequest{})
+		require.Equal(t, &api.RateLimitedError{RetryAfter: 30 * time.Second}, err)
+		require.EqualError(t, err, "rate limited, retry after 30s")
+	})
+
+	t.Run("rate limited, no retry after", func(t *testing.T) {
+		client, tearDown := setupServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+		defer tearDown()
+
+		_, err := client.GetPlugins(&api.GetPluginsRequest{})
+		require.Equal(t, &api.RateLimitedError{}, err)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		client, tearDown := setupServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Request-ID", "request-id")
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		defer tearDown()
+
+		_, err := client.GetPlugins(&api.GetPluginsRequest{})
+		require.Equal(t, &api.ServerError{StatusCode: http.StatusInternalServerError, RequestID: "request-id"}, err)
+		require.EqualError(t, err, "failed with status code 500 (request request-id)")
+	})
+
+	t.Run("other status", func(t *testing.T) {
+		client, tearDown := setupServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		})
+		defer tearDown()
+
+		_, err := client.GetPlugins(&api.GetPluginsRequest{})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	t.Run("request id from marketplace server", func(t *testing.T) {
+		client, tearDown := setupApi(t, nil)
+		defer tearDown()
+
+		resp, err := http.Get(client.Address + "/api/v1/plugins?page=invalid")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.NotEmpty(t, resp.Header.Get("X-Request-ID"))
+	})
+}
+
+func TestClientStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/stats":
+			_, _ = w.Write([]byte(`{"total_downloads":3,"plugins":{"demo":3},"daily":[{"date":"2019-11-01","downloads":3}]}`))
+		case "/api/v1/plugins/demo/stats":
+			_, _ = w.Write([]byte(`{"plugin_id":"demo","total_downloads":3,"versions":{"0.1.0":1,"0.2.0":2},"daily":[{"date":"2019-11-01","downloads":3}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client := api.NewClient(ts.URL)
+
+	t.Run("plugin stats", func(t *testing.T) {
+		stats, err := client.GetPluginStats("demo")
+		require.NoError(t, err)
+		require.Equal(t, &model.PluginStats{
+			PluginID:       "demo",
+			TotalDownloads: 3,
+			Versions:       map[string]int64{"0.1.0": 1, "0.2.0": 2},
+			Daily:          []model.DailyDownloads{{Date: "2019-11-01", Downloads: 3}},
+		}, stats)
+	})
+
+	t.Run("unknown plugin stats", func(t *testing.T) {
+		_, err := client.GetPluginStats("unknown")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("marketplace stats", func(t *testing.T) {
+		stats, err := client.GetMarketplaceStats()
+		require.NoError(t, err)
+		require.Equal(t, &model.MarketplaceStats{
+			TotalDownloads: 3,
+			Plugins:        map[string]int64{"demo": 3},
+			Daily:          []model.DailyDownloads{{Date: "2019-11-01", Downloads: 3}},
+		}, stats)
+	})
+}
@@ -3,6 +3,12 @@ package api
 import (
 	"encoding/json"
 	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
 )
 
 // outputJSON is a helper method to write the given data as JSON to the given writer.
@@ -16,3 +22,110 @@ func outputJSON(c *Context, w io.Writer, data interface{}) {
 		c.Logger.WithError(err).Error("failed to encode result")
 	}
 }
+
+// outputEncoded writes the given data to the given writer in the given media type, as negotiated
+// by negotiateMediaType, logging rather than returning any error as outputJSON does.
+func outputEncoded(c *Context, w io.Writer, mediaType string, data interface{}) {
+	var err error
+	switch mediaType {
+	case model.YAMLMediaType:
+		err = model.EncodeYAML(w, data)
+	case model.MessagePackMediaType:
+		err = model.EncodeMessagePack(w, data)
+	default:
+		outputJSON(c, w, data)
+	}
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to encode result")
+	}
+}
+
+// acceptedMediaTypes maps the media ranges of an Accept header to the media type of the encoding
+// they select.
+var acceptedMediaTypes = map[string]string{
+	"*/*":                     model.JSONMediaType,
+	"application/*":           model.JSONMediaType,
+	"application/json":        model.JSONMediaType,
+	"application/yaml":        model.YAMLMediaType,
+	"application/x-yaml":      model.YAMLMediaType,
+	"text/yaml":               model.YAMLMediaType,
+	"text/x-yaml":             model.YAMLMediaType,
+	"application/msgpack":     model.MessagePackMediaType,
+	"application/x-msgpack":   model.MessagePackMediaType,
+	"application/vnd.msgpack": model.MessagePackMediaType,
+}
+
+// negotiateMediaType returns the media type in which to respond to the given request: the encoding
+// most preferred by its Accept header among JSON, YAML and MessagePack, or else JSON.
+func negotiateMediaType(r *http.Request) string {
+	mediaType := model.JSONMediaType
+	bestQuality := 0.0
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		name, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		accepted, ok := acceptedMediaTypes[name]
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if quality > bestQuality {
+			mediaType = accepted
+			bestQuality = quality
+		}
+	}
+
+	return mediaType
+}
+
+// errorCodes maps the status codes of error responses to their error codes.
+var errorCodes = map[int]model.ErrorCode{
+	http.StatusBadRequest:          model.ErrorCodeBadRequest,
+	http.StatusUnauthorized:        model.ErrorCodeUnauthorized,
+	http.StatusForbidden:           model.ErrorCodeForbidden,
+	http.StatusNotFound:            model.ErrorCodeNotFound,
+	http.StatusConflict:            model.ErrorCodeConflict,
+	http.StatusGone:                model.ErrorCodeGone,
+	http.StatusTooManyRequests:     model.ErrorCodeRateLimited,
+	http.StatusInternalServerError: model.ErrorCodeInternal,
+}
+
+// writeError responds to the given request with the given error status code, describing the error
+// in the language best matching its Accept-Language header. Messages are translated under the
+// error code in the context's translations, e.g. error.not_found, and are otherwise in English.
+func writeError(c *Context, w http.ResponseWriter, r *http.Request, statusCode int) {
+	code, ok := errorCodes[statusCode]
+	if !ok {
+		code = model.ErrorCodeBadRequest
+		if statusCode >= http.StatusInternalServerError {
+			code = model.ErrorCodeInternal
+		}
+	}
+
+	apiError := &model.APIError{
+		Code:      code,
+		Message:   model.ErrorMessages[code],
+		RequestID: c.RequestID,
+	}
+	if c.Translations != nil {
+		w.Header().Add("Vary", "Accept-Language")
+		if locale, ok := c.Translations.Locale(r.Header.Get("Accept-Language")); ok {
+			if message, ok := c.Translations[locale]["error."+string(code)]; ok {
+				apiError.Message = message
+				w.Header().Set("Content-Language", locale)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	outputJSON(c, w, apiError)
+}
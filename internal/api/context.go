@@ -1,28 +1,344 @@
 package api
 
 import (
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
 	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Store describes the interface to the backing store.
+//
+// GetPlugins returns the latest version of each plugin matching the filter, sorted by name with the
+// featured plugins first unless filtered by text, and GetPluginVersions every version of a plugin,
+// sorted by version descending, or none if the plugin is unknown. Implementations must be safe for
+// concurrent use. The memstore package provides an in-memory implementation for projects embedding
+// the marketplace.
 type Store interface {
 	GetPlugins(filter *model.PluginFilter) ([]*model.Plugin, error)
+	GetPluginVersions(id string) ([]*model.Plugin, error)
+	GetApps(filter *model.AppFilter) ([]*model.App, error)
+}
+
+// StoreWrapper describes the interface to a store wrapping another, such as one failing over to a
+// secondary store. The optional interfaces of a store, such as Changes, are looked up on the store
+// it wraps.
+type StoreWrapper interface {
+	Unwrap() Store
+}
+
+// HealthReporter describes the interface to a store reporting its health at /api/v1/health.
+type HealthReporter interface {
+	Health() *model.StoreHealth
+}
+
+// StalenessReporter describes the interface to a store serving a copy of a catalog fetched from
+// elsewhere, such as an upstream marketplace, which goes stale while its source is unreachable.
+// Responses served from a stale catalog are marked by the Warning and X-Catalog-Fetched-At headers.
+type StalenessReporter interface {
+	Staleness() (fetchedAt time.Time, stale bool)
+}
+
+// unwrapStore returns the innermost store wrapped by the given store, on which its optional
+// interfaces are looked up.
+func unwrapStore(store Store) Store {
+	for {
+		wrapper, ok := store.(StoreWrapper)
+		if !ok {
+			return store
+		}
+		store = wrapper.Unwrap()
+	}
+}
+
+// Changes describes the interface to the feed of changes to a catalog. Stores implementing it
+// serve their changes at /api/v1/changes, and, if also Enumerators, sync replicas of their catalog
+// at /api/v1/catalog/changes.
+type Changes interface {
+	Cursor() int64
+	ChangesSince(cursor int64) ([]*model.CatalogChange, <-chan struct{}, error)
+}
+
+// Aging describes the interface to a store whose responses change as time passes, without its
+// catalog changing, such as when plugins grow stale or pre-releases lapse. Epoch counts those
+// changes so far, keying the responses cached from the store.
+type Aging interface {
+	Epoch() int
+}
+
+// Enumerator describes the interface to a store listing every plugin version it serves. Stores
+// implementing it serve a digest of their catalog at /api/v1/catalog/digest.
+type Enumerator interface {
+	AllPlugins() []*model.Plugin
+}
+
+// CatalogSigner describes the interface to the key signing catalog digests.
+type CatalogSigner interface {
+	Sign(message io.Reader) (*model.Signature, error)
+}
+
+// Snapshots describes the interface to a catalog retaining the stores it served for rolling back.
+type Snapshots interface {
+	Snapshots() []*model.CatalogSnapshot
+	Rollback(id int64) (*model.CatalogSnapshot, []*model.CatalogChange, error)
+}
+
+// Growth describes the interface to a catalog reporting its composition at each retained
+// snapshot.
+type Growth interface {
+	Growth() *model.CatalogGrowth
+}
+
+// TimeTravel describes the interface to a catalog serving the snapshot it was serving at a given
+// time, selected by read requests with the as_of query parameter.
+type TimeTravel interface {
+	SnapshotAsOf(at time.Time) (*model.CatalogSnapshot, *store.Store, error)
+}
+
+// Staging describes the interface to a catalog accepting candidates to be validated before they
+// are promoted to be served.
+type Staging interface {
+	Stage(reader io.Reader, logger logrus.FieldLogger) (*model.StagingReport, error)
+	Staged() (*model.StagingReport, error)
+	Validate() (*model.StagingReport, error)
+	Promote() (*model.CatalogSnapshot, []*model.CatalogChange, error)
+	Discard() error
+}
+
+// Importer describes the interface to a catalog accepting imports replacing its plugins.
+type Importer interface {
+	Import(reader io.Reader, logger logrus.FieldLogger) (*model.CatalogSnapshot, []*model.CatalogChange, error)
+}
+
+// EntryValidator describes the interface to a catalog checking plugins as if they were served,
+// without serving them.
+type EntryValidator interface {
+	ValidateEntry(plugin *model.Plugin, logger logrus.FieldLogger) []string
+}
+
+// Stats describes the interface to the download statistics and reported installations.
+type Stats interface {
+	RecordDownload(pluginID, version string)
+	RecordInstall(reporter, pluginID, version string, upgrade bool) bool
+	PluginStats(pluginID string) *model.PluginStats
+	MarketplaceStats() *model.MarketplaceStats
+	WriteMetrics(w io.Writer) error
+}
+
+// Analytics describes the interface to the anonymized query analytics.
+type Analytics interface {
+	RecordQuery(serverVersion, filter string)
+	RecordPluginRequest(pluginID string)
+	Report(days, limit int) *model.AnalyticsReport
+}
+
+// Metrics describes the interface to the request latency metrics.
+type Metrics interface {
+	ObserveRequest(route, method string, statusCode int, duration time.Duration)
+	WriteMetrics(w io.Writer) error
+}
+
+// Audit describes the interface to the consistency audits of the catalog served, exposing the
+// drift they find.
+type Audit interface {
+	WriteMetrics(w io.Writer) error
+}
+
+// Ratings describes the interface to the plugin ratings.
+type Ratings interface {
+	SubmitRating(rating *model.Rating) error
+	RatingSummaries() map[string]*model.RatingSummary
+	PluginRatings(pluginID string) *model.PluginRatings
+}
+
+// Submissions describes the interface to the community submission queue.
+type Submissions interface {
+	Submit(repositoryURL, submitterID string) (*model.Submission, error)
+	Approve(id, moderatorID, reason string) (*model.Submission, error)
+	Reject(id, moderatorID, reason string) (*model.Submission, error)
+	GetSubmission(id string) (*model.Submission, error)
+	GetSubmissions(filter *model.SubmissionFilter) []*model.Submission
+}
+
+// APIKeys describes the interface to the API keys issued to partners.
+type APIKeys interface {
+	Create(name, creatorID string, rateLimit int) (*model.IssuedAPIKey, error)
+	Revoke(id string) (*model.APIKey, error)
+	Use(token string) (*model.APIKey, error)
+	GetAPIKey(id string) (*model.APIKey, error)
+	GetAPIKeys() []*model.APIKey
+}
+
+// Blocklist describes the interface to the clients denied access to the API.
+type Blocklist interface {
+	Block(entry *model.BlocklistEntry) (*model.BlocklistEntry, error)
+	Unblock(id string) error
+	Blocks(ip net.IP, userAgent string) (*model.BlocklistEntry, bool)
+	GetEntries() []*model.BlocklistEntry
+}
+
+// Advisories describes the interface to the security advisories published for plugins.
+type Advisories interface {
+	Publish(advisory *model.Advisory) (*model.Advisory, error)
+	Withdraw(id string) error
+	GetAdvisories(pluginID string) []*model.Advisory
+	AdvisoriesByPlugin() map[string][]*model.Advisory
+}
+
+// Featuring describes the interface to the featuring of plugins set by moderators, overriding that
+// recorded in the database. Listings honor it only if the stores are given the same featuring.
+type Featuring interface {
+	Set(featuring *model.Featuring) (*model.Featuring, error)
+	Unset(pluginID string) error
+	GetFeaturings() []*model.Featuring
+}
+
+// Bundles describes the interface to the plugin bundles re-hosted by the marketplace itself.
+type Bundles interface {
+	Open(plugin *model.Plugin, platform string) (*os.File, error)
+}
+
+// DownloadSigner describes the interface to mint and verify the short-lived urls of the
+// re-hosted bundles of private plugins.
+type DownloadSigner interface {
+	// Sign adds an expiry and a signature to the query string of the given url.
+	Sign(u *url.URL)
+	// Verify verifies the given url was signed and has not yet expired.
+	Verify(u *url.URL) error
+	// Expiry returns the expiry of the urls signed now, changing at most once per lifetime.
+	Expiry() time.Time
+}
+
+// Tombstones describes the interface to the tombstones of the plugin versions removed from the
+// catalog.
+type Tombstones interface {
+	Record(changes []*model.CatalogChange, reason string) error
+	GetTombstones(pluginID, version string) []*model.Tombstone
 }
 
 // Context provides the API with all necessary data and interfaces for responding to requests.
 //
 // It is cloned before each request, allowing per-request changes such as logger annotations.
 type Context struct {
-	Store     Store
+	// Store serves the default catalog, used unless a request selects one of the Channels.
+	Store Store
+	// Channels maps the name of each additional catalog, such as beta, to the store serving it.
+	// Requests select a channel with the channel query parameter, or by a host in ChannelHosts.
+	Channels map[string]Store
+	// ChannelHosts maps a host name to the channel selected by requests addressed to it.
+	ChannelHosts map[string]string
+	// Stats, if set, records plugin downloads and serves the resulting statistics.
+	Stats Stats
+	// Analytics, if set, records anonymized patterns in the queries made, reported to moderators.
+	Analytics Analytics
+	// Metrics, if set, records the latency of every request by route.
+	Metrics Metrics
+	// Audit, if set, exposes the drift found by the consistency audits at /metrics.
+	Audit Audit
+	// Ratings, if set, accepts plugin ratings and summarizes them in plugin listings.
+	Ratings Ratings
+	// Submissions, if set, accepts plugin repositories submitted for inclusion.
+	Submissions Submissions
+	// Authenticator, if set, identifies the users submitting ratings and repositories.
+	Authenticator Authenticator
+	// APIKeys, if set, identifies, accounts for and rate limits the requests of partners bearing
+	// API keys, and lets moderators issue and revoke the keys.
+	APIKeys APIKeys
+	// Blocklist, if set, lets moderators deny clients access to the API. It is enforced by the
+	// handler returned by NewAbuseHandler.
+	Blocklist Blocklist
+	// Advisories, if set, lets moderators publish security advisories, flagging the affected
+	// plugin versions in responses.
+	Advisories Advisories
+	// Featuring, if set, lets moderators feature plugins, or stop featuring them, overriding the
+	// featuring recorded in the database.
+	Featuring Featuring
+	// Tombstones, if set, records the plugin versions removed from the default catalog through the
+	// API, serving them so that servers that installed them can be warned.
+	Tombstones Tombstones
+	// Bundles, if set, re-hosts the plugin bundles, served at /downloads/{id}/{version}.tar.gz in
+	// place of their original urls.
+	Bundles Bundles
+	// DownloadSigner, if set, mints short-lived urls for the re-hosted bundles of private plugins,
+	// which are otherwise not served.
+	DownloadSigner DownloadSigner
+	// CatalogSigner, if set, signs the catalog served with each catalog digest, allowing mirrors
+	// and clients to verify the catalog is the canonical one.
+	CatalogSigner CatalogSigner
+	// SigningKeys, if set, are the public keys served at /api/v1/signing-keys, verifying the
+	// signatures of the bundles and catalogs served.
+	SigningKeys []*model.PublicKey
+	// Moderators holds the ids of the users allowed to approve or reject submissions, to
+	// administer API keys and the blocklist, to publish advisories, and to check the urls of the
+	// entries they validate.
+	Moderators map[string]bool
+	// WriteAllowlist, if set, restricts the mutating endpoints to requests from its networks, in
+	// addition to any authentication they require.
+	WriteAllowlist *IPAllowlist
+	// TrustForwardedFor identifies clients by the last address in the X-Forwarded-For header, as
+	// appended by a trusted load balancer, when telling apart their installation reports.
+	TrustForwardedFor bool
+	// Translations, if set, localizes the enumerated fields of the plugins in responses, and the
+	// messages of errors, according to the request's Accept-Language header.
+	Translations Translations
+	// IconStrippingThreshold, if positive, bounds the serialized size in bytes of plugin listings
+	// before their icon data is replaced by urls of the plugin icon endpoint.
+	IconStrippingThreshold int
+	// CompatibilityServerVersions are the server versions for which plugin compatibility is
+	// reported unless a request gives its own, defaulting to DefaultCompatibilityServerVersions.
+	CompatibilityServerVersions []string
+	// ResponseCache, if set, caches the responses to plugin listings by their normalized query.
+	ResponseCache *ResponseCache
+	// AdminUI, if set, serves a web UI at /admin letting moderators browse the catalog, delist
+	// plugin versions, publish advisories and review download statistics.
+	AdminUI bool
+	// Tracer, if set, records a span for each request, continuing the trace of the caller.
+	Tracer    trace.Tracer
 	RequestID string
-	Logger    logrus.FieldLogger
+	// Channel names the channel selected by the request, if any.
+	Channel string
+	// APIKey identifies the partner making the request, if it bears an API key.
+	APIKey *model.APIKey
+	Logger logrus.FieldLogger
 }
 
 // Clone creates a shallow copy of context, allowing clones to apply per-request changes.
 func (c *Context) Clone() *Context {
 	return &Context{
-		Store:  c.Store,
-		Logger: c.Logger,
+		Store:                       c.Store,
+		Channels:                    c.Channels,
+		ChannelHosts:                c.ChannelHosts,
+		Stats:                       c.Stats,
+		Analytics:                   c.Analytics,
+		Metrics:                     c.Metrics,
+		Audit:                       c.Audit,
+		Ratings:                     c.Ratings,
+		Submissions:                 c.Submissions,
+		Authenticator:               c.Authenticator,
+		APIKeys:                     c.APIKeys,
+		Blocklist:                   c.Blocklist,
+		Advisories:                  c.Advisories,
+		Featuring:                   c.Featuring,
+		Tombstones:                  c.Tombstones,
+		Bundles:                     c.Bundles,
+		DownloadSigner:              c.DownloadSigner,
+		CatalogSigner:               c.CatalogSigner,
+		SigningKeys:                 c.SigningKeys,
+		Moderators:                  c.Moderators,
+		WriteAllowlist:              c.WriteAllowlist,
+		TrustForwardedFor:           c.TrustForwardedFor,
+		Translations:                c.Translations,
+		IconStrippingThreshold:      c.IconStrippingThreshold,
+		CompatibilityServerVersions: c.CompatibilityServerVersions,
+		ResponseCache:               c.ResponseCache,
+		AdminUI:                     c.AdminUI,
+		Tracer:                      c.Tracer,
+		Logger:                      c.Logger,
 	}
 }
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// startTime approximates when the process started, for reporting uptime.
+var startTime = time.Now()
+
+// RuntimeSnapshot summarizes the state of the Go runtime.
+type RuntimeSnapshot struct {
+	InstanceID    string    `json:"instance_id,omitempty"`
+	GoVersion     string    `json:"go_version"`
+	BuildHash     string    `json:"build_hash"`
+	StartedAt     time.Time `json:"started_at"`
+	UptimeSeconds float64   `json:"uptime_seconds"`
+	NumCPU        int       `json:"num_cpu"`
+	GOMAXPROCS    int       `json:"gomaxprocs"`
+	Goroutines    int       `json:"goroutines"`
+	HeapAlloc     uint64    `json:"heap_alloc_bytes"`
+	HeapInuse     uint64    `json:"heap_inuse_bytes"`
+	HeapObjects   uint64    `json:"heap_objects"`
+	Sys           uint64    `json:"sys_bytes"`
+	NumGC         uint32    `json:"num_gc"`
+	PauseTotalNs  uint64    `json:"gc_pause_total_ns"`
+	LastGC        time.Time `json:"last_gc"`
+}
+
+// RegisterDiagnostics registers profiling and runtime diagnostics endpoints on the given router.
+//
+// These endpoints expose process internals and may be expensive to serve, so the router must
+// only be reachable by operators, e.g. on a separate port bound to a private interface.
+func RegisterDiagnostics(router *mux.Router, instanceID string) {
+	router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	// The index also serves the named profiles, e.g. /debug/pprof/heap.
+	router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+
+	router.Handle("/debug/vars", expvar.Handler()).Methods("GET")
+	router.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(takeRuntimeSnapshot(instanceID))
+	}).Methods("GET")
+}
+
+func takeRuntimeSnapshot(instanceID string) *RuntimeSnapshot {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	snapshot := &RuntimeSnapshot{
+		InstanceID:    instanceID,
+		GoVersion:     runtime.Version(),
+		BuildHash:     buildHash,
+		StartedAt:     startTime.UTC(),
+		UptimeSeconds: time.Since(startTime).Seconds(),
+		NumCPU:        runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAlloc:     memStats.HeapAlloc,
+		HeapInuse:     memStats.HeapInuse,
+		HeapObjects:   memStats.HeapObjects,
+		Sys:           memStats.Sys,
+		NumGC:         memStats.NumGC,
+		PauseTotalNs:  memStats.PauseTotalNs,
+	}
+	if memStats.LastGC > 0 {
+		snapshot.LastGC = time.Unix(0, int64(memStats.LastGC)).UTC()
+	}
+
+	return snapshot
+}
@@ -0,0 +1,71 @@
+package api_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnostics(t *testing.T) {
+	router := mux.NewRouter()
+	api.RegisterDiagnostics(router, "instance")
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	get := func(t *testing.T, path string) (*http.Response, []byte) {
+		t.Helper()
+
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, body
+	}
+
+	t.Run("runtime snapshot", func(t *testing.T) {
+		resp, body := get(t, "/debug/runtime")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var snapshot api.RuntimeSnapshot
+		require.NoError(t, json.Unmarshal(body, &snapshot))
+		require.Equal(t, "instance", snapshot.InstanceID)
+		require.NotEmpty(t, snapshot.GoVersion)
+		require.NotZero(t, snapshot.Goroutines)
+		require.NotZero(t, snapshot.HeapAlloc)
+	})
+
+	t.Run("expvar", func(t *testing.T) {
+		resp, body := get(t, "/debug/vars")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, string(body), `"memstats"`)
+	})
+
+	t.Run("pprof index", func(t *testing.T) {
+		resp, body := get(t, "/debug/pprof/")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, string(body), "goroutine")
+	})
+
+	t.Run("named profile", func(t *testing.T) {
+		resp, _ := get(t, "/debug/pprof/goroutine?debug=1")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("not served by the public api", func(t *testing.T) {
+		publicRouter := mux.NewRouter()
+		api.Register(publicRouter, &api.Context{})
+
+		w := httptest.NewRecorder()
+		publicRouter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
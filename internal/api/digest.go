@@ -0,0 +1,60 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// initDigest registers the catalog digest endpoint on the given router.
+func initDigest(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	apiRouter.Handle("/catalog/digest", addContext(handleGetCatalogDigest)).Methods("GET")
+}
+
+// handleGetCatalogDigest responds to GET /api/v1/catalog/digest, returning a digest of the catalog
+// currently served, signed if the context has a catalog signer.
+func handleGetCatalogDigest(c *Context, w http.ResponseWriter, r *http.Request) {
+	enumerator, ok := unwrapStore(c.Store).(Enumerator)
+	if !ok {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	endSpan := traceStore(c, r, "AllPlugins")
+	plugins := enumerator.AllPlugins()
+	endSpan()
+
+	var database bytes.Buffer
+	if err := model.PluginsToWriter(&database, plugins, model.PluginsWriterOptions{}); err != nil {
+		c.Logger.WithError(err).Error("failed to encode catalog")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(database.Bytes())
+
+	digest := &model.CatalogDigest{
+		Plugins:   len(plugins),
+		Algorithm: model.DigestAlgorithmSHA256,
+		Digest:    hex.EncodeToString(sum[:]),
+	}
+	if c.CatalogSigner != nil {
+		signature, err := c.CatalogSigner.Sign(bytes.NewReader(database.Bytes()))
+		if err != nil {
+			c.Logger.WithError(err).Error("failed to sign catalog")
+			writeError(c, w, r, http.StatusInternalServerError)
+			return
+		}
+		digest.Signature = signature
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, digest)
+}
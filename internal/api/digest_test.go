@@ -0,0 +1,113 @@
+package api_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+)
+
+// entitySigner signs catalogs with an OpenPGP entity.
+type entitySigner struct {
+	t      *testing.T
+	entity *openpgp.Entity
+}
+
+func (s *entitySigner) Sign(message io.Reader) (*model.Signature, error) {
+	data, err := ioutil.ReadAll(message)
+	require.NoError(s.t, err)
+
+	return &model.Signature{
+		Signature:     sign(s.t, s.entity, data, false),
+		PublicKeyHash: s.entity.PrimaryKey.KeyIdString(),
+	}, nil
+}
+
+func TestCatalogDigest(t *testing.T) {
+	demo := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}
+	starter := &model.Plugin{
+		DownloadURL:  "https://example.com/starter-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "starter", Name: "Starter", Version: "0.1.0"},
+	}
+
+	// expectedDigest returns the hex-encoded digest of the database of the given plugins.
+	expectedDigest := func(t *testing.T, plugins ...*model.Plugin) string {
+		var database bytes.Buffer
+		require.NoError(t, model.PluginsToWriter(&database, plugins, model.PluginsWriterOptions{}))
+		sum := sha256.Sum256(database.Bytes())
+		return hex.EncodeToString(sum[:])
+	}
+
+	setup := func(t *testing.T, store api.Store, signer api.CatalogSigner) (*api.Client, func()) {
+		router := mux.NewRouter()
+		api.Register(router, &api.Context{
+			Store:         store,
+			CatalogSigner: signer,
+			Logger:        testlib.MakeLogger(t),
+		})
+		ts := httptest.NewServer(router)
+
+		return api.NewClient(ts.URL), ts.Close
+	}
+
+	t.Run("unsigned", func(t *testing.T) {
+		client, tearDown := setup(t, makeStore(t, starter, demo), nil)
+		defer tearDown()
+
+		digest, err := client.GetCatalogDigest()
+		require.NoError(t, err)
+		require.Equal(t, 2, digest.Plugins)
+		require.Equal(t, model.DigestAlgorithmSHA256, digest.Algorithm)
+		require.Equal(t, expectedDigest(t, demo, starter), digest.Digest)
+		require.Nil(t, digest.Signature)
+	})
+
+	t.Run("follows catalog replacement", func(t *testing.T) {
+		pluginCatalog := catalog.New(makeStore(t, demo))
+		client, tearDown := setup(t, pluginCatalog, nil)
+		defer tearDown()
+
+		before, err := client.GetCatalogDigest()
+		require.NoError(t, err)
+		require.Equal(t, expectedDigest(t, demo), before.Digest)
+
+		pluginCatalog.Replace(makeStore(t, demo, starter))
+
+		after, err := client.GetCatalogDigest()
+		require.NoError(t, err)
+		require.Equal(t, 2, after.Plugins)
+		require.Equal(t, expectedDigest(t, demo, starter), after.Digest)
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		entity := makeKey(t)
+		client, tearDown := setup(t, makeStore(t, demo), &entitySigner{t: t, entity: entity})
+		defer tearDown()
+
+		digest, err := client.GetCatalogDigest()
+		require.NoError(t, err)
+		require.NotNil(t, digest.Signature)
+		require.Equal(t, entity.PrimaryKey.KeyIdString(), digest.Signature.PublicKeyHash)
+
+		var database bytes.Buffer
+		require.NoError(t, model.PluginsToWriter(&database, []*model.Plugin{demo}, model.PluginsWriterOptions{}))
+		require.NoError(t, api.VerifyPluginSignature(&database, digest.Signature.Signature, openpgp.EntityList{entity}))
+	})
+}
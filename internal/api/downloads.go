@@ -0,0 +1,223 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/rehost"
+	"github.com/pkg/errors"
+)
+
+// bundleExtension suffixes the file name of each re-hosted bundle.
+const bundleExtension = ".tar.gz"
+
+// initDownloads registers the re-hosted bundle endpoint on the given router.
+func initDownloads(rootRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	rootRouter.Handle("/downloads/{id}/{file}", addContext(handleDownloadBundle)).Methods("GET")
+}
+
+// handleDownloadBundle responds to GET /downloads/{id}/{version}.tar.gz, serving the re-hosted
+// bundle of the given plugin version, or of the platform given by the platform query parameter.
+func handleDownloadBundle(c *Context, w http.ResponseWriter, r *http.Request) {
+	if c.Bundles == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	file := mux.Vars(r)["file"]
+	if !strings.HasSuffix(file, bundleExtension) {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+	version := model.NormalizeVersion(strings.TrimSuffix(file, bundleExtension))
+
+	plugins, ok := getLicensedPluginVersions(c, w, r)
+	if !ok {
+		return
+	}
+
+	var plugin *model.Plugin
+	for _, candidate := range plugins {
+		if candidate.Manifest.Version == version {
+			plugin = candidate
+			break
+		}
+	}
+	if plugin == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	serveBundle(c, w, r, plugin, r.URL.Query().Get("platform"))
+}
+
+// serveBundle serves the re-hosted bundle of the given plugin, or of the given platform, recording
+// the download. The bundles of private plugins are only served given a valid pre-signed url.
+func serveBundle(c *Context, w http.ResponseWriter, r *http.Request, plugin *model.Plugin, platform string) {
+	if plugin.Private && !isPresignedDownload(c, r) {
+		writeError(c, w, r, http.StatusForbidden)
+		return
+	}
+
+	if platform != "" {
+		if _, ok := plugin.Platforms[platform]; !ok {
+			writeError(c, w, r, http.StatusNotFound)
+			return
+		}
+		plugin = plugin.ForPlatform(platform)
+	}
+
+	file, err := c.Bundles.Open(plugin, platform)
+	if errors.Cause(err) == rehost.ErrNotFound {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to open re-hosted bundle")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to stat re-hosted bundle")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	// Resuming a transfer is not another download.
+	if !isResumedDownload(r) {
+		if c.Stats != nil {
+			c.Stats.RecordDownload(plugin.Manifest.Id, plugin.Manifest.Version)
+		}
+		recordPluginRequest(c, plugin.Manifest.Id)
+	}
+
+	// The bundle of a plugin version only changes should its entry be republished, which the
+	// entity tag reveals on revalidation or on resuming through If-Range.
+	w.Header().Set("Content-Type", "application/gzip")
+	if plugin.Private {
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+	}
+	w.Header().Set("ETag", bundleETag(plugin, info))
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
+
+// bundleETag returns the entity tag identifying the re-hosted bundle of the given plugin, by its
+// recorded checksum if any, or else by the size and modification time of the given file.
+func bundleETag(plugin *model.Plugin, info os.FileInfo) string {
+	if plugin.Checksums != nil && plugin.Checksums.SHA256 != "" {
+		return `"` + plugin.Checksums.SHA256 + `"`
+	}
+
+	return fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+}
+
+// isResumedDownload reports whether the given request resumes a download, asking only for the
+// bytes after those already transferred.
+func isResumedDownload(r *http.Request) bool {
+	rangeHeader := r.Header.Get("Range")
+
+	return rangeHeader != "" && !strings.HasPrefix(rangeHeader, "bytes=0-")
+}
+
+// isPresignedDownload reports whether the given request bears a valid pre-signed url.
+func isPresignedDownload(c *Context, r *http.Request) bool {
+	if c.DownloadSigner == nil {
+		return false
+	}
+
+	if err := c.DownloadSigner.Verify(r.URL); err != nil {
+		c.Logger.WithError(err).Debug("rejecting download of private bundle")
+		return false
+	}
+
+	return true
+}
+
+// withRehostedBundles returns the given plugins with the urls of their bundles pointing at the
+// bundles re-hosted by the context, if any, pre-signed for private plugins. Without re-hosting, the
+// urls of private plugins are omitted rather than exposed. Plugins are copied rather than modified.
+func withRehostedBundles(c *Context, r *http.Request, plugins []*model.Plugin) []*model.Plugin {
+	if c.Bundles == nil {
+		return withoutPrivateBundles(plugins)
+	}
+
+	baseURL := requestBaseURL(r)
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		bundleURL := fmt.Sprintf("%s/downloads/%s/%s%s", baseURL, url.PathEscape(plugin.Manifest.Id), url.PathEscape(plugin.Manifest.Version), bundleExtension)
+
+		rehostedPlugin := *plugin
+		if plugin.DownloadURL != "" {
+			rehostedPlugin.DownloadURL = presignBundleURL(c, plugin, bundleURL)
+		}
+		if len(plugin.Platforms) > 0 {
+			rehostedPlugin.Platforms = make(map[string]*model.PlatformBundle, len(plugin.Platforms))
+			for platform, bundle := range plugin.Platforms {
+				rehostedBundle := *bundle
+				rehostedBundle.DownloadURL = presignBundleURL(c, plugin, bundleURL+"?platform="+url.QueryEscape(platform))
+				rehostedPlugin.Platforms[platform] = &rehostedBundle
+			}
+		}
+		result = append(result, &rehostedPlugin)
+	}
+
+	return result
+}
+
+// presignBundleURL returns the given url of a re-hosted bundle of the given plugin, pre-signed if
+// the plugin is private and the context signs downloads.
+func presignBundleURL(c *Context, plugin *model.Plugin, bundleURL string) string {
+	if !plugin.Private || c.DownloadSigner == nil {
+		return bundleURL
+	}
+
+	u, err := url.Parse(bundleURL)
+	if err != nil {
+		// The url is built from the request and escaped components, so fails to parse only in
+		// theory.
+		return bundleURL
+	}
+	c.DownloadSigner.Sign(u)
+
+	return u.String()
+}
+
+// withoutPrivateBundles returns the given plugins, omitting the urls of the bundles of private
+// plugins. Private plugins are copied rather than modified.
+func withoutPrivateBundles(plugins []*model.Plugin) []*model.Plugin {
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		result = append(result, plugin.WithoutPrivateBundles())
+	}
+
+	return result
+}
+
+// withoutPrivateChanges returns the given changes, omitting the urls of the bundles of private
+// plugins. Changes to private plugins are copied rather than modified.
+func withoutPrivateChanges(changes []*model.CatalogChange) []*model.CatalogChange {
+	result := make([]*model.CatalogChange, 0, len(changes))
+	for _, change := range changes {
+		if change.Plugin != nil && change.Plugin.Private {
+			privateChange := *change
+			privateChange.Plugin = change.Plugin.WithoutPrivateBundles()
+			change = &privateChange
+		}
+		result = append(result, change)
+	}
+
+	return result
+}
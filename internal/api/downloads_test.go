@@ -0,0 +1,317 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/presign"
+	"github.com/mattermost/mattermost-marketplace/internal/rehost"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRehostedBundles(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	bundles := map[string][]byte{
+		"/jira-3.0.0.tar.gz":             []byte("jira bundle"),
+		"/jira-3.0.0-linux-amd64.tar.gz": []byte("jira linux bundle"),
+	}
+	var upstreamRequests int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&upstreamRequests, 1)
+		bundle, ok := bundles[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(bundle)
+	}))
+	defer upstream.Close()
+
+	checksumsOf := func(data []byte) *model.Checksums {
+		checksumsWriter := model.NewChecksumsWriter()
+		checksumsWriter.Write(data)
+		return checksumsWriter.Checksums()
+	}
+
+	jira := &model.Plugin{
+		DownloadURL:  upstream.URL + "/jira-3.0.0.tar.gz",
+		Checksums:    checksumsOf(bundles["/jira-3.0.0.tar.gz"]),
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "jira", Name: "Jira", Version: "3.0.0"},
+		Platforms: map[string]*model.PlatformBundle{
+			"linux-amd64": {
+				DownloadURL: upstream.URL + "/jira-3.0.0-linux-amd64.tar.gz",
+				Checksums:   checksumsOf(bundles["/jira-3.0.0-linux-amd64.tar.gz"]),
+			},
+		},
+	}
+	data, err := json.Marshal([]*model.Plugin{jira})
+	require.NoError(t, err)
+	pluginStore, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "rehost")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	host, err := rehost.New(dir)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:   pluginStore,
+		Bundles: host,
+		Logger:  logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+	client := api.NewClient(ts.URL)
+
+	get := func(t *testing.T, path string) (int, []byte) {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, body
+	}
+
+	t.Run("listed with re-hosted urls", func(t *testing.T) {
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: -1})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+		require.Equal(t, ts.URL+"/downloads/jira/3.0.0.tar.gz", plugins[0].DownloadURL)
+		require.Equal(t, ts.URL+"/downloads/jira/3.0.0.tar.gz?platform=linux-amd64", plugins[0].Platforms["linux-amd64"].DownloadURL)
+
+		var bundle bytes.Buffer
+		require.NoError(t, client.DownloadPlugin(plugins[0], &bundle))
+		require.Equal(t, "jira bundle", bundle.String())
+
+		bundle.Reset()
+		require.NoError(t, client.DownloadPlugin(plugins[0].ForPlatform("linux-amd64"), &bundle))
+		require.Equal(t, "jira linux bundle", bundle.String())
+	})
+
+	t.Run("fetched once", func(t *testing.T) {
+		statusCode, body := get(t, "/downloads/jira/3.0.0.tar.gz")
+		require.Equal(t, http.StatusOK, statusCode)
+		require.Equal(t, "jira bundle", string(body))
+		require.EqualValues(t, 2, atomic.LoadInt32(&upstreamRequests))
+	})
+
+	t.Run("download endpoint serves the bundle", func(t *testing.T) {
+		statusCode, body := get(t, "/api/v1/plugins/jira/download?platform=linux-amd64")
+		require.Equal(t, http.StatusOK, statusCode)
+		require.Equal(t, "jira linux bundle", string(body))
+
+		statusCode, body = get(t, "/api/v1/plugins/jira/download?platform=windows-amd64")
+		require.Equal(t, http.StatusOK, statusCode)
+		require.Equal(t, "jira bundle", string(body))
+	})
+
+	t.Run("range requests", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/downloads/jira/3.0.0.tar.gz", nil)
+		require.NoError(t, err)
+		req.Header.Set("Range", "bytes=5-")
+		req.Header.Set("If-Range", `"`+jira.Checksums.SHA256+`"`)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		require.Equal(t, "bytes 5-10/11", resp.Header.Get("Content-Range"))
+		require.Equal(t, "bundle", string(body))
+
+		req.Header.Set("If-Range", `"stale"`)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err = ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "a changed bundle is sent whole")
+		require.Equal(t, "jira bundle", string(body))
+	})
+
+	t.Run("caching headers", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/downloads/jira/3.0.0.tar.gz")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+		require.Equal(t, "public, max-age=86400", resp.Header.Get("Cache-Control"))
+		etag := resp.Header.Get("ETag")
+		require.Equal(t, `"`+jira.Checksums.SHA256+`"`, etag)
+
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/downloads/jira/3.0.0.tar.gz", nil)
+		require.NoError(t, err)
+		req.Header.Set("If-None-Match", etag)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotModified, resp.StatusCode)
+	})
+
+	t.Run("unknown bundle", func(t *testing.T) {
+		for _, path := range []string{
+			"/downloads/jira/2.0.0.tar.gz",
+			"/downloads/jira/3.0.0.zip",
+			"/downloads/jira/3.0.0.tar.gz?platform=windows-amd64",
+			"/downloads/unknown/1.0.0.tar.gz",
+		} {
+			statusCode, _ := get(t, path)
+			require.Equal(t, http.StatusNotFound, statusCode, path)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client, tearDown := setupApi(t, []*model.Plugin{jira})
+		defer tearDown()
+
+		resp, err := http.Get(client.Address + "/downloads/jira/3.0.0.tar.gz")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestPrivateBundles(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("bundle " + r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	legal := &model.Plugin{
+		DownloadURL:  upstream.URL + "/legal-1.0.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Private:      true,
+		Manifest:     &mattermostModel.Manifest{Id: "legal", Name: "Legal Hold", Version: "1.0.0"},
+		Platforms: map[string]*model.PlatformBundle{
+			"linux-amd64": {DownloadURL: upstream.URL + "/legal-1.0.0-linux-amd64.tar.gz"},
+		},
+	}
+	jira := &model.Plugin{
+		DownloadURL:  upstream.URL + "/jira-3.0.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "jira", Name: "Jira", Version: "3.0.0"},
+	}
+	data, err := json.Marshal([]*model.Plugin{legal, jira})
+	require.NoError(t, err)
+	pluginStore, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "rehost")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	host, err := rehost.New(dir)
+	require.NoError(t, err)
+
+	signer, err := presign.New([]byte("0123456789abcdef0123456789abcdef"), 5*time.Minute)
+	require.NoError(t, err)
+
+	serve := func(context *api.Context) *httptest.Server {
+		router := mux.NewRouter()
+		api.Register(router, context)
+		return httptest.NewServer(router)
+	}
+	ts := serve(&api.Context{Store: pluginStore, Bundles: host, DownloadSigner: signer, Logger: logger})
+	defer ts.Close()
+	client := api.NewClient(ts.URL)
+
+	get := func(t *testing.T, u string) *http.Response {
+		resp, err := http.Get(u)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	getPlugin := func(t *testing.T, client *api.Client, id string) *model.Plugin {
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: -1})
+		require.NoError(t, err)
+		for _, plugin := range plugins {
+			if plugin.Manifest.Id == id {
+				return plugin
+			}
+		}
+		require.FailNow(t, "plugin not listed", id)
+		return nil
+	}
+
+	t.Run("listed with pre-signed urls", func(t *testing.T) {
+		plugin := getPlugin(t, client, "legal")
+		require.Contains(t, plugin.DownloadURL, ts.URL+"/downloads/legal/1.0.0.tar.gz?expires=")
+		require.Contains(t, plugin.DownloadURL, "signature=")
+		require.Contains(t, plugin.Platforms["linux-amd64"].DownloadURL, "platform=linux-amd64")
+		require.Contains(t, plugin.Platforms["linux-amd64"].DownloadURL, "signature=")
+
+		var bundle bytes.Buffer
+		require.NoError(t, client.DownloadPlugin(plugin, &bundle))
+		require.Equal(t, "bundle /legal-1.0.0.tar.gz", bundle.String())
+
+		bundle.Reset()
+		require.NoError(t, client.DownloadPlugin(plugin.ForPlatform("linux-amd64"), &bundle))
+		require.Equal(t, "bundle /legal-1.0.0-linux-amd64.tar.gz", bundle.String())
+
+		resp := get(t, plugin.DownloadURL)
+		require.Equal(t, "private, max-age=86400", resp.Header.Get("Cache-Control"))
+	})
+
+	t.Run("public plugins unaffected", func(t *testing.T) {
+		plugin := getPlugin(t, client, "jira")
+		require.Equal(t, ts.URL+"/downloads/jira/3.0.0.tar.gz", plugin.DownloadURL)
+		require.Equal(t, http.StatusOK, get(t, plugin.DownloadURL).StatusCode)
+	})
+
+	t.Run("refused without a valid signature", func(t *testing.T) {
+		plugin := getPlugin(t, client, "legal")
+
+		for _, u := range []string{
+			ts.URL + "/downloads/legal/1.0.0.tar.gz",
+			ts.URL + "/api/v1/plugins/legal/download",
+			strings.Replace(plugin.DownloadURL, "1.0.0.tar.gz", "1.0.0.tar.gz?platform=linux-amd64&", 1),
+			plugin.DownloadURL[:len(plugin.DownloadURL)-4] + "AAAA",
+		} {
+			require.Equal(t, http.StatusForbidden, get(t, u).StatusCode, u)
+		}
+	})
+
+	t.Run("refused without a signer", func(t *testing.T) {
+		ts := serve(&api.Context{Store: pluginStore, Bundles: host, Logger: logger})
+		defer ts.Close()
+
+		plugin := getPlugin(t, api.NewClient(ts.URL), "legal")
+		require.Equal(t, ts.URL+"/downloads/legal/1.0.0.tar.gz", plugin.DownloadURL)
+		require.Equal(t, http.StatusForbidden, get(t, plugin.DownloadURL).StatusCode)
+	})
+
+	t.Run("urls omitted without re-hosting", func(t *testing.T) {
+		client, tearDown := setupApi(t, []*model.Plugin{legal, jira})
+		defer tearDown()
+
+		plugin := getPlugin(t, client, "legal")
+		require.Empty(t, plugin.DownloadURL)
+		require.Empty(t, plugin.Platforms)
+		require.Equal(t, upstream.URL+"/jira-3.0.0.tar.gz", getPlugin(t, client, "jira").DownloadURL)
+
+		resp, err := (&http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}).Get(client.Address + "/api/v1/plugins/legal/download")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
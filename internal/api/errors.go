@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// requestIDHeader carries the server-assigned request id, assisting in correlating client
+// failures with server logs.
+const requestIDHeader = "X-Request-ID"
+
+// ErrNotFound is returned by the client when the requested resource does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrUnknownCursor is returned by the client when the server no longer retains the changes after
+// the requested cursor, such as after a restart. Clients should resynchronize by fetching the
+// plugins afresh.
+var ErrUnknownCursor = errors.New("unknown cursor")
+
+// RateLimitedError is returned by the client when the server is throttling requests.
+type RateLimitedError struct {
+	// RetryAfter is the delay requested by the server before retrying, if any.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter)
+	}
+
+	return "rate limited"
+}
+
+// RejectedCandidateError is returned by the client when the server cannot parse a candidate
+// catalog staged for promotion, or rejects an imported catalog failing validation.
+type RejectedCandidateError struct {
+	Problems []string
+}
+
+func (e *RejectedCandidateError) Error() string {
+	return fmt.Sprintf("candidate rejected: %s", strings.Join(e.Problems, "; "))
+}
+
+// ServerError is returned by the client when the server fails to handle a request.
+type ServerError struct {
+	StatusCode int
+	RequestID  string
+}
+
+func (e *ServerError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("failed with status code %d (request %s)", e.StatusCode, e.RequestID)
+	}
+
+	return fmt.Sprintf("failed with status code %d", e.StatusCode)
+}
+
+// StatusError is returned by the client for any other unexpected status code.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed with status code %d", e.StatusCode)
+}
+
+// errorFromResponse maps an unsuccessful response to one of the typed client errors.
+func errorFromResponse(resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitedError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return &ServerError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get(requestIDHeader)}
+	default:
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+}
+
+// parseRetryAfter interprets a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
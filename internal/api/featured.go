@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/featured"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+)
+
+// maxFeaturingRequestSize bounds the body of a request featuring a plugin.
+const maxFeaturingRequestSize = 4 * 1024
+
+// initFeatured registers the featured plugin endpoints on the given router.
+func initFeatured(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	featuredRouter := apiRouter.PathPrefix("/featured").Subrouter()
+	featuredRouter.Handle("", addContext(handleGetFeaturings)).Methods("GET")
+	featuredRouter.Handle("/{id}", addContext(restrictWrites(handleSetFeaturing))).Methods("PUT")
+	featuredRouter.Handle("/{id}", addContext(restrictWrites(handleUnsetFeaturing))).Methods("DELETE")
+}
+
+// SetFeaturingRequest describes the parameters to feature a plugin, or to stop featuring it.
+type SetFeaturingRequest struct {
+	Featured bool `json:"featured"`
+	// Weight orders the featured plugins, heaviest first.
+	Weight int `json:"weight,omitempty"`
+}
+
+// authenticateFeaturing identifies the moderator featuring a plugin, responding on failure, if
+// featuring is not enabled, or if the user is not a moderator.
+func authenticateFeaturing(c *Context, w http.ResponseWriter, r *http.Request) (string, bool) {
+	if c.Featuring == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return "", false
+	}
+
+	return authenticateModerator(c, w, r)
+}
+
+// handleGetFeaturings responds to GET /api/v1/featured, returning the featuring of each plugin
+// overridden by moderators, the featured plugins first, heaviest first.
+func handleGetFeaturings(c *Context, w http.ResponseWriter, r *http.Request) {
+	result := []*model.Featuring{}
+	if c.Featuring != nil {
+		result = c.Featuring.GetFeaturings()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, result)
+}
+
+// handleSetFeaturing responds to PUT /api/v1/featured/{id}, overriding whether the given plugin is
+// featured, and its weight, in subsequent listings.
+func handleSetFeaturing(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticateFeaturing(c, w, r)
+	if !ok {
+		return
+	}
+
+	var request SetFeaturingRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFeaturingRequestSize)).Decode(&request); err != nil {
+		c.Logger.WithError(err).Error("failed to decode featuring request")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	if !request.Featured && request.Weight != 0 {
+		c.Logger.Error("invalid featuring request: weight given without featuring")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	// Record the featuring under the plugin's manifest id, which the requested id need only match
+	// case-insensitively or as an old id.
+	pluginID, ok := resolvePlugin(c, w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	featuring, err := c.Featuring.Set(&model.Featuring{
+		PluginID:  pluginID,
+		Featured:  request.Featured,
+		Weight:    request.Weight,
+		UpdatedBy: userID,
+	})
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to set featuring")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	c.Logger.WithField("plugin_id", pluginID).WithField("featured", featuring.Featured).Info("Set featuring")
+	purgeResponseCache(c)
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, featuring)
+}
+
+// handleUnsetFeaturing responds to DELETE /api/v1/featured/{id}, dropping the override of the given
+// plugin's featuring, restoring that recorded in the database.
+func handleUnsetFeaturing(c *Context, w http.ResponseWriter, r *http.Request) {
+	if _, ok := authenticateFeaturing(c, w, r); !ok {
+		return
+	}
+
+	pluginID, ok := resolvePlugin(c, w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	err := c.Featuring.Unset(pluginID)
+	if errors.Cause(err) == featured.ErrNotFound {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to unset featuring")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	c.Logger.WithField("plugin_id", pluginID).Info("Unset featuring")
+	purgeResponseCache(c)
+
+	w.WriteHeader(http.StatusNoContent)
+}
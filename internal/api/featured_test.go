@@ -0,0 +1,146 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/featured"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatured(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	makePlugin := func(id, name string) *model.Plugin {
+		return &model.Plugin{
+			DownloadURL:  "https://example.com/" + id + "-1.0.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: id, Name: name, Version: "1.0.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+		}
+	}
+	demo := makePlugin("demo", "Demo")
+	jira := makePlugin("jira", "Jira")
+	zoom := makePlugin("zoom", "Zoom")
+	zoom.Featured = true
+
+	dir, err := ioutil.TempDir("", "featured")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	registry, err := featured.New(&featured.FileBackend{Path: filepath.Join(dir, "featured.json")})
+	require.NoError(t, err)
+
+	data, err := json.Marshal([]*model.Plugin{demo, jira, zoom})
+	require.NoError(t, err)
+	store, err := store.NewWithOptions(bytes.NewReader(data), logger, store.Options{Featuring: registry})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:     store,
+		Featuring: registry,
+		Authenticator: api.TokenAuthenticator{
+			"alice-token":     "alice",
+			"moderator-token": "moderator",
+		},
+		Moderators: map[string]bool{"moderator": true},
+		Logger:     logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	clientFor := func(token string) *api.Client {
+		client := api.NewClient(ts.URL)
+		client.Token = token
+		return client
+	}
+	alice := clientFor("alice-token")
+	moderator := clientFor("moderator-token")
+
+	listedIDs := func(t *testing.T) []string {
+		plugins, err := alice.GetPlugins(&api.GetPluginsRequest{PerPage: -1})
+		require.NoError(t, err)
+
+		ids := []string{}
+		for _, plugin := range plugins {
+			ids = append(ids, plugin.Manifest.Id)
+		}
+		return ids
+	}
+
+	t.Run("featured in the database", func(t *testing.T) {
+		require.Equal(t, []string{"zoom", "demo", "jira"}, listedIDs(t))
+	})
+
+	t.Run("not a moderator", func(t *testing.T) {
+		_, err := alice.SetFeaturing("jira", &api.SetFeaturingRequest{Featured: true})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+
+		err = alice.UnsetFeaturing("zoom")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := moderator.SetFeaturing("jira", &api.SetFeaturingRequest{Weight: 10})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		_, err := moderator.SetFeaturing("unknown", &api.SetFeaturingRequest{Featured: true})
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("featured by a moderator", func(t *testing.T) {
+		featuring, err := moderator.SetFeaturing("Jira", &api.SetFeaturingRequest{Featured: true, Weight: 10})
+		require.NoError(t, err)
+		require.Equal(t, "jira", featuring.PluginID)
+		require.Equal(t, "moderator", featuring.UpdatedBy)
+
+		_, err = moderator.SetFeaturing("zoom", &api.SetFeaturingRequest{Featured: false})
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"jira", "demo", "zoom"}, listedIDs(t))
+
+		plugin, err := alice.GetPlugin("jira")
+		require.NoError(t, err)
+		require.True(t, plugin.Featured)
+		require.Equal(t, 10, plugin.FeaturedWeight)
+
+		featurings, err := alice.GetFeaturings()
+		require.NoError(t, err)
+		require.Len(t, featurings, 2)
+		require.Equal(t, "jira", featurings[0].PluginID)
+		require.Equal(t, "zoom", featurings[1].PluginID)
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		require.NoError(t, moderator.UnsetFeaturing("zoom"))
+		require.Equal(t, []string{"jira", "zoom", "demo"}, listedIDs(t))
+
+		err := moderator.UnsetFeaturing("zoom")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+}
+
+func TestFeaturedDisabled(t *testing.T) {
+	client, tearDown := setupApi(t, nil)
+	defer tearDown()
+
+	featurings, err := client.GetFeaturings()
+	require.NoError(t, err)
+	require.Empty(t, featurings)
+
+	_, err = client.SetFeaturing("jira", &api.SetFeaturingRequest{Featured: true})
+	require.Equal(t, api.ErrNotFound, err)
+}
@@ -2,8 +2,12 @@ package api
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type contextHandlerFunc func(c *Context, w http.ResponseWriter, r *http.Request)
@@ -13,17 +17,159 @@ type contextHandler struct {
 	handler contextHandlerFunc
 }
 
+// statusWriter records the status code of the response written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: w}
+}
+
+// status returns the status code written, which defaults to 200 if the handler wrote nothing.
+func (w *statusWriter) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Headers marking responses served from a stale catalog.
+const (
+	warningHeader          = "Warning"
+	catalogFetchedAtHeader = "X-Catalog-Fetched-At"
+	staleWarning           = `110 - "Response is Stale"`
+)
+
+// staleWriter marks the response written through it as stale should the catalog it was served from
+// be stale once it is written, which may only be known after the catalog was queried.
+type staleWriter struct {
+	http.ResponseWriter
+	reporter StalenessReporter
+	marked   bool
+}
+
+func (w *staleWriter) mark() {
+	if w.marked {
+		return
+	}
+	w.marked = true
+
+	fetchedAt, stale := w.reporter.Staleness()
+	if !stale {
+		return
+	}
+	w.Header().Set(warningHeader, staleWarning)
+	if !fetchedAt.IsZero() {
+		w.Header().Set(catalogFetchedAtHeader, fetchedAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+func (w *staleWriter) WriteHeader(statusCode int) {
+	w.mark()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *staleWriter) Write(b []byte) (int, error) {
+	w.mark()
+	return w.ResponseWriter.Write(b)
+}
+
 func (h contextHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var statusWriter *statusWriter
+	if h.context.Metrics != nil || h.context.Tracer != nil {
+		statusWriter = newStatusWriter(w)
+		w = statusWriter
+	}
+
+	if h.context.Metrics != nil {
+		start := time.Now()
+
+		defer func() {
+			h.context.Metrics.ObserveRequest(requestRoute(r), r.Method, statusWriter.status(), time.Since(start))
+		}()
+	}
+
+	if h.context.Tracer != nil {
+		var span trace.Span
+		r, span = startRequestSpan(h.context.Tracer, r)
+		defer endRequestSpan(span, statusWriter)
+	}
+
 	context := h.context.Clone()
 	context.RequestID = model.NewId()
 	context.Logger = context.Logger.WithFields(map[string]interface{}{
-		"path":    r.URL.Path,
-		"request": context.RequestID,
+		"path":       r.URL.Path,
+		"request":    context.RequestID,
+		"user_agent": r.UserAgent(),
 	})
+	if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+		context.Logger = context.Logger.WithField("trace_id", spanContext.TraceID().String())
+	}
+
+	w.Header().Set(requestIDHeader, context.RequestID)
+
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		// ErrAbortHandler deliberately aborts the response, and is handled by net/http.
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+
+		// The error records the stack of the panicking goroutine, which has yet to unwind.
+		context.Logger.WithError(errors.Errorf("panic: %v", recovered)).Error("Recovered from panic")
+		writeError(context, w, r, http.StatusInternalServerError)
+	}()
+
+	if !identifyAPIKey(context, w, r) {
+		return
+	}
+
+	if !selectChannel(context, w, r) {
+		return
+	}
+
+	if !selectAsOf(context, w, r) {
+		return
+	}
+
+	if reporter, ok := context.Store.(StalenessReporter); ok {
+		w = &staleWriter{ResponseWriter: w, reporter: reporter}
+	}
 
 	h.handler(context, w, r)
 }
 
+// requestRoute returns the path template of the route matching the given request, or its path if
+// it matched none, identifying the endpoint without the cardinality of its parameters.
+func requestRoute(r *http.Request) string {
+	if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+		if template, err := currentRoute.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+
+	return r.URL.Path
+}
+
 func newContextHandler(context *Context, handler contextHandlerFunc) *contextHandler {
 	return &contextHandler{
 		context: context,
@@ -0,0 +1,164 @@
+package api_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/metrics"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+)
+
+type panickingStore struct{}
+
+func (s *panickingStore) GetPlugins(filter *model.PluginFilter) ([]*model.Plugin, error) {
+	panic("store exploded")
+}
+
+func (s *panickingStore) GetPluginVersions(id string) ([]*model.Plugin, error) {
+	panic("store exploded")
+}
+
+func (s *panickingStore) GetApps(filter *model.AppFilter) ([]*model.App, error) {
+	panic("store exploded")
+}
+
+func TestHandlerPanic(t *testing.T) {
+	logger, hook := logrusTest.NewNullLogger()
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:  &panickingStore{},
+		Logger: logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/v1/plugins")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	require.Equal(t, logrus.ErrorLevel, entry.Level)
+	require.EqualError(t, entry.Data[logrus.ErrorKey].(error), "panic: store exploded")
+	require.Equal(t, resp.Header.Get("X-Request-ID"), entry.Data["request"])
+	require.NotEmpty(t, entry.Data["request"])
+
+	// The server keeps serving after a panic.
+	resp, err = http.Get(ts.URL + "/api/v1/health")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRequestMetrics(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	pluginStore, err := store.New(bytes.NewReader([]byte("[]")), logger)
+	require.NoError(t, err)
+
+	recorder, err := metrics.NewRecorder(nil, []metrics.Objective{{
+		Route:   "/api/v1/plugins",
+		Latency: time.Minute,
+		Target:  0.99,
+	}})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:   pluginStore,
+		Metrics: recorder,
+		Logger:  logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	for _, path := range []string{"/api/v1/plugins", "/api/v1/plugins?page=invalid", "/api/v1/plugins/demo/stats"} {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `marketplace_http_requests_total{route="/api/v1/plugins",method="GET",code="200"} 1`)
+	require.Contains(t, string(body), `marketplace_http_requests_total{route="/api/v1/plugins",method="GET",code="400"} 1`)
+	require.Contains(t, string(body), `marketplace_http_requests_total{route="/api/v1/plugins/{id}/stats",method="GET",code="404"} 1`)
+	require.Contains(t, string(body), `marketplace_http_request_duration_seconds_count{route="/api/v1/plugins",method="GET"} 2`)
+	require.Contains(t, string(body), `marketplace_slo_good_requests_total{route="/api/v1/plugins"} 2`)
+}
+
+// staleStore serves the plugins of its store, reporting them stale once queried while stale is set.
+type staleStore struct {
+	*store.Store
+	fetchedAt time.Time
+	stale     bool
+	queried   bool
+}
+
+func (s *staleStore) GetPlugins(filter *model.PluginFilter) ([]*model.Plugin, error) {
+	s.queried = true
+	return s.Store.GetPlugins(filter)
+}
+
+func (s *staleStore) Staleness() (time.Time, bool) {
+	return s.fetchedAt, s.stale && s.queried
+}
+
+func TestStaleResponses(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	pluginStore, err := store.New(bytes.NewReader([]byte("[]")), logger)
+	require.NoError(t, err)
+	staleStore := &staleStore{
+		Store:     pluginStore,
+		fetchedAt: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:  staleStore,
+		Logger: logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	t.Run("fresh", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/api/v1/plugins")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("Warning"))
+		require.Empty(t, resp.Header.Get("X-Catalog-Fetched-At"))
+	})
+
+	t.Run("stale once queried", func(t *testing.T) {
+		staleStore.stale = true
+		staleStore.queried = false
+
+		resp, err := http.Get(ts.URL + "/api/v1/plugins")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `110 - "Response is Stale"`, resp.Header.Get("Warning"))
+		require.Equal(t, "Wed, 01 Jan 2020 12:00:00 GMT", resp.Header.Get("X-Catalog-Fetched-At"))
+	})
+}
@@ -2,8 +2,12 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
 )
 
 var (
@@ -37,8 +41,27 @@ func handleHealthCheck(c *Context, w http.ResponseWriter, r *http.Request) {
 	details := make(map[string]map[string]string)
 	details["buildInfo"] = buildInfo
 
+	// Stores failing over to a secondary report their health, warning while degraded.
+	status := "pass"
+	stores := map[string]Store{"store": c.Store}
+	for name, channelStore := range c.Channels {
+		stores["store:"+name] = channelStore
+	}
+	for name, store := range stores {
+		reporter, ok := store.(HealthReporter)
+		if !ok {
+			continue
+		}
+
+		health := reporter.Health()
+		if health.Degraded {
+			status = "warn"
+		}
+		details[name] = storeHealthDetails(health)
+	}
+
 	response := healthCheckResponse{
-		Status:      "pass",
+		Status:      status,
 		Version:     "1",
 		ReleaseID:   buildTag,
 		Details:     details,
@@ -47,3 +70,26 @@ func handleHealthCheck(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/health+json")
 	outputJSON(c, w, response)
 }
+
+// storeHealthDetails describes the given store health as health check details.
+func storeHealthDetails(health *model.StoreHealth) map[string]string {
+	details := map[string]string{
+		"backend":             "primary",
+		"consecutiveFailures": strconv.Itoa(health.ConsecutiveFailures),
+		"failovers":           strconv.FormatInt(health.Failovers, 10),
+	}
+	if health.Degraded {
+		details["backend"] = "secondary"
+	}
+	if health.LastError != "" {
+		details["lastError"] = health.LastError
+	}
+	if !health.LastFailureAt.IsZero() {
+		details["lastFailureAt"] = health.LastFailureAt.UTC().Format(time.RFC3339)
+	}
+	if !health.RecoveredAt.IsZero() {
+		details["recoveredAt"] = health.RecoveredAt.UTC().Format(time.RFC3339)
+	}
+
+	return details
+}
@@ -5,11 +5,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -41,3 +44,48 @@ func TestHealthCheck(t *testing.T) {
 	assert.NotEmpty(t, respose.Details["buildInfo"]["buildHashShort"])
 	assert.NotEmpty(t, respose.Description)
 }
+
+// degradedStore is a store reporting that it failed over to its secondary.
+type degradedStore struct {
+	Store
+}
+
+func (s *degradedStore) Health() *model.StoreHealth {
+	return &model.StoreHealth{
+		Degraded:            true,
+		ConsecutiveFailures: 2,
+		Failovers:           5,
+		LastError:           "connection refused",
+		LastFailureAt:       time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestHealthCheckStoreHealth(t *testing.T) {
+	router := mux.NewRouter()
+
+	Register(router, &Context{
+		Store:  &degradedStore{},
+		Logger: logrus.New(),
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+	router.ServeHTTP(w, r)
+
+	result := w.Result()
+	require.NotNil(t, result)
+	defer result.Body.Close()
+
+	response := &healthCheckResponse{}
+	err := json.NewDecoder(result.Body).Decode(&response)
+	require.NoError(t, err)
+
+	assert.Equal(t, "warn", response.Status)
+	assert.Equal(t, map[string]string{
+		"backend":             "secondary",
+		"consecutiveFailures": "2",
+		"failovers":           "5",
+		"lastError":           "connection refused",
+		"lastFailureAt":       "2020-01-02T03:04:05Z",
+	}, response.Details["store"])
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// handleGetPluginIcon responds to GET /api/v1/plugins/{id}/icon, serving the icon of the requested
+// version of the given plugin, or of the latest version if none is given. Icons hosted externally
+// are redirected to. Icons are tagged by their contents, so that clients can revalidate cached
+// icons with If-None-Match.
+func handleGetPluginIcon(c *Context, w http.ResponseWriter, r *http.Request) {
+	version := r.URL.Query().Get("version")
+
+	plugins, ok := getLicensedPluginVersions(c, w, r)
+	if !ok {
+		return
+	}
+
+	var plugin *model.Plugin
+	for _, candidate := range plugins {
+		if version == "" || candidate.Manifest.Version == version {
+			plugin = candidate
+			break
+		}
+	}
+	if plugin == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	if plugin.IconData == "" {
+		if plugin.IconURL == "" {
+			writeError(c, w, r, http.StatusNotFound)
+			return
+		}
+
+		http.Redirect(w, r, plugin.IconURL, http.StatusFound)
+		return
+	}
+
+	mimeType, data, err := model.DecodeIconData(plugin.IconData)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to decode icon")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	etag := iconETag(data)
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", mimeType)
+	w.Write(data)
+}
+
+// iconETag returns the entity tag identifying the given icon by its contents.
+func iconETag(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ifNoneMatch reports whether the If-None-Match header of the given request lists the given
+// entity tag, or any.
+func ifNoneMatch(r *http.Request, etag string) bool {
+	for _, header := range r.Header["If-None-Match"] {
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// withoutOversizedIcons returns the given plugins with their IconData replaced by a url of the
+// plugin icon endpoint if, with the icons, they would serialize to more than the context's
+// IconStrippingThreshold. Plugins are copied rather than modified, and returned unchanged if the
+// threshold is not exceeded.
+func withoutOversizedIcons(c *Context, r *http.Request, plugins []*model.Plugin) []*model.Plugin {
+	if c.IconStrippingThreshold <= 0 {
+		return plugins
+	}
+
+	data, err := json.Marshal(plugins)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to measure response size")
+		return plugins
+	}
+	if len(data) <= c.IconStrippingThreshold {
+		return plugins
+	}
+
+	c.Logger.Debugf("omitting icons from a %d byte response exceeding the threshold of %d bytes", len(data), c.IconStrippingThreshold)
+
+	baseURL := requestBaseURL(r)
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		if plugin.IconData == "" {
+			result = append(result, plugin)
+			continue
+		}
+
+		strippedPlugin := *plugin
+		strippedPlugin.IconData = ""
+		if strippedPlugin.IconURL == "" {
+			strippedPlugin.IconURL = fmt.Sprintf("%s/api/v1/plugins/%s/icon?version=%s", baseURL, url.PathEscape(plugin.Manifest.Id), url.QueryEscape(plugin.Manifest.Version))
+		}
+		result = append(result, &strippedPlugin)
+	}
+
+	return result
+}
+
+// requestBaseURL returns the scheme and host by which the client addressed the request, honoring
+// the X-Forwarded-Proto header set by load balancers terminating TLS.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if forwardedProto := r.Header.Get("X-Forwarded-Proto"); forwardedProto == "http" || forwardedProto == "https" {
+		scheme = forwardedProto
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
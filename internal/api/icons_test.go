@@ -0,0 +1,277 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupIconsApi(t *testing.T, plugins []*model.Plugin, iconStrippingThreshold int) (*api.Client, func()) {
+	logger := testlib.MakeLogger(t)
+
+	data, err := json.Marshal(plugins)
+	require.NoError(t, err)
+	store, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:                  store,
+		IconStrippingThreshold: iconStrippingThreshold,
+		Logger:                 logger,
+	})
+	ts := httptest.NewServer(router)
+
+	return api.NewClient(ts.URL), func() {
+		ts.Close()
+	}
+}
+
+func TestPluginIcons(t *testing.T) {
+	demoV1 := &model.Plugin{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+		IconData:     "data:image/svg+xml;base64,PHN2Zy8+",
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}
+	demoV2 := &model.Plugin{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+		IconData:     "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=",
+		DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0"},
+	}
+	hosted := &model.Plugin{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-hosted",
+		IconURL:      "https://icons.example.com/hosted.svg",
+		DownloadURL:  "https://example.com/hosted-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "hosted", Name: "Hosted", Version: "0.1.0"},
+	}
+	plain := &model.Plugin{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-plain",
+		DownloadURL:  "https://example.com/plain-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "plain", Name: "Plain", Version: "0.1.0"},
+	}
+	allPlugins := []*model.Plugin{demoV1, demoV2, hosted, plain}
+
+	noRedirects := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	t.Run("icon endpoint", func(t *testing.T) {
+		client, tearDown := setupIconsApi(t, allPlugins, 0)
+		defer tearDown()
+
+		t.Run("latest version", func(t *testing.T) {
+			resp, err := noRedirects.Get(client.Address + "/api/v1/plugins/demo/icon")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "image/svg+xml", resp.Header.Get("Content-Type"))
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "<svg></svg>", string(body))
+		})
+
+		t.Run("revalidation", func(t *testing.T) {
+			resp, err := noRedirects.Get(client.Address + "/api/v1/plugins/demo/icon")
+			require.NoError(t, err)
+			resp.Body.Close()
+			etag := resp.Header.Get("ETag")
+			require.NotEmpty(t, etag)
+
+			req, err := http.NewRequest(http.MethodGet, client.Address+"/api/v1/plugins/demo/icon", nil)
+			require.NoError(t, err)
+			req.Header.Set("If-None-Match", etag)
+			resp, err = noRedirects.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+			req.URL.RawQuery = "version=0.1.0"
+			resp, err = noRedirects.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+		})
+
+		t.Run("specific version", func(t *testing.T) {
+			resp, err := noRedirects.Get(client.Address + "/api/v1/plugins/demo/icon?version=0.1.0")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "<svg/>", string(body))
+		})
+
+		t.Run("unknown version", func(t *testing.T) {
+			resp, err := noRedirects.Get(client.Address + "/api/v1/plugins/demo/icon?version=9.9.9")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		})
+
+		t.Run("hosted icon", func(t *testing.T) {
+			resp, err := noRedirects.Get(client.Address + "/api/v1/plugins/hosted/icon")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusFound, resp.StatusCode)
+			assert.Equal(t, hosted.IconURL, resp.Header.Get("Location"))
+		})
+
+		t.Run("no icon", func(t *testing.T) {
+			resp, err := noRedirects.Get(client.Address + "/api/v1/plugins/plain/icon")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		})
+	})
+
+	t.Run("client", func(t *testing.T) {
+		client, tearDown := setupIconsApi(t, allPlugins, 0)
+		defer tearDown()
+
+		t.Run("without cache", func(t *testing.T) {
+			icon, err := client.GetPluginIcon("demo")
+			require.NoError(t, err)
+			assert.Equal(t, "image/svg+xml", icon.ContentType)
+			assert.Equal(t, "<svg></svg>", string(icon.Data))
+		})
+
+		t.Run("no icon", func(t *testing.T) {
+			_, err := client.GetPluginIcon("plain")
+			assert.Equal(t, api.ErrNotFound, err)
+		})
+
+		t.Run("cached", func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "icons")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+			client.IconCacheDir = dir
+			defer func() { client.IconCacheDir = "" }()
+
+			icon, err := client.GetPluginIcon("demo")
+			require.NoError(t, err)
+			assert.Equal(t, "<svg></svg>", string(icon.Data))
+
+			paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+			require.NoError(t, err)
+			require.Len(t, paths, 1)
+
+			// Tamper with the cached icon to observe that revalidation serves it from the cache.
+			data, err := ioutil.ReadFile(paths[0])
+			require.NoError(t, err)
+			var cached map[string]interface{}
+			require.NoError(t, json.Unmarshal(data, &cached))
+			cached["data"] = []byte("<svg>cached</svg>")
+			data, err = json.Marshal(cached)
+			require.NoError(t, err)
+			require.NoError(t, ioutil.WriteFile(paths[0], data, 0600))
+
+			icon, err = client.GetPluginIcon("demo")
+			require.NoError(t, err)
+			assert.Equal(t, "image/svg+xml", icon.ContentType)
+			assert.Equal(t, "<svg>cached</svg>", string(icon.Data))
+
+			// A stale entity tag transfers the icon again.
+			cached["etag"] = `"stale"`
+			data, err = json.Marshal(cached)
+			require.NoError(t, err)
+			require.NoError(t, ioutil.WriteFile(paths[0], data, 0600))
+
+			icon, err = client.GetPluginIcon("demo")
+			require.NoError(t, err)
+			assert.Equal(t, "<svg></svg>", string(icon.Data))
+		})
+	})
+
+	t.Run("listing below threshold", func(t *testing.T) {
+		client, tearDown := setupIconsApi(t, allPlugins, 1<<20)
+		defer tearDown()
+
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoV2, hosted, plain}, plugins)
+	})
+
+	t.Run("listing above threshold", func(t *testing.T) {
+		client, tearDown := setupIconsApi(t, allPlugins, 100)
+		defer tearDown()
+
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Len(t, plugins, 3)
+
+		assert.Empty(t, plugins[0].IconData)
+		assert.Equal(t, client.Address+"/api/v1/plugins/demo/icon?version=0.2.0", plugins[0].IconURL)
+		assert.Equal(t, hosted, plugins[1])
+		assert.Equal(t, plain, plugins[2])
+	})
+
+	t.Run("versions above threshold", func(t *testing.T) {
+		client, tearDown := setupIconsApi(t, allPlugins, 100)
+		defer tearDown()
+
+		plugins, err := client.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, plugins, 2)
+		assert.Empty(t, plugins[0].IconData)
+		assert.Equal(t, client.Address+"/api/v1/plugins/demo/icon?version=0.2.0", plugins[0].IconURL)
+		assert.Empty(t, plugins[1].IconData)
+		assert.Equal(t, client.Address+"/api/v1/plugins/demo/icon?version=0.1.0", plugins[1].IconURL)
+	})
+
+	t.Run("resolving either form", func(t *testing.T) {
+		client, tearDown := setupIconsApi(t, allPlugins, 100)
+		defer tearDown()
+
+		icon, err := client.ResolvePluginIcon(demoV2)
+		require.NoError(t, err)
+		assert.Equal(t, "image/svg+xml", icon.ContentType)
+		assert.Equal(t, "<svg></svg>", string(icon.Data))
+
+		plugins, err := client.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.NotEmpty(t, plugins[1].IconURL)
+		icon, err = client.ResolvePluginIcon(plugins[1])
+		require.NoError(t, err)
+		assert.Equal(t, "image/svg+xml", icon.ContentType)
+		assert.Equal(t, "<svg/>", string(icon.Data))
+
+		_, err = client.ResolvePluginIcon(plain)
+		assert.Equal(t, api.ErrNotFound, errors.Cause(err))
+	})
+
+	t.Run("single plugin keeps its icon", func(t *testing.T) {
+		client, tearDown := setupIconsApi(t, allPlugins, 100)
+		defer tearDown()
+
+		plugin, err := client.GetPlugin("demo")
+		require.NoError(t, err)
+		assert.Equal(t, demoV2.IconData, plugin.IconData)
+	})
+}
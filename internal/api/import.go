@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// initImport registers the catalog import endpoint on the given router.
+func initImport(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	apiRouter.Handle("/admin/catalog", addContext(restrictWrites(handleImportCatalog))).Methods("PUT")
+}
+
+// handleImportCatalog responds to PUT /api/v1/admin/catalog, validating the plugins in the request
+// body and atomically serving and persisting them in place of the catalog, returning the snapshot
+// retaining them. Plugins failing validation are rejected with their problems.
+func handleImportCatalog(c *Context, w http.ResponseWriter, r *http.Request) {
+	importer, ok := unwrapStore(c.Store).(Importer)
+	if !ok {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	if _, ok := authenticateModerator(c, w, r); !ok {
+		return
+	}
+
+	snapshot, changes, err := importer.Import(r.Body, c.Logger)
+	if validationErr, ok := errors.Cause(err).(*catalog.ValidationError); ok {
+		c.Logger.WithError(err).Warn("Rejected invalid import")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		outputJSON(c, w, &model.StagingReport{Problems: validationErr.Problems})
+		return
+	} else if errors.Cause(err) == catalog.ErrImportDisabled {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to import catalog")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	recordTombstones(c, changes, model.TombstoneReasonImported)
+	c.Logger.WithFields(logrus.Fields{
+		"snapshot": snapshot.ID,
+		"changes":  len(changes),
+	}).Info("Imported catalog")
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, snapshot)
+}
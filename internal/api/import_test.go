@@ -0,0 +1,113 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCatalog(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	dir, err := ioutil.TempDir("", "import")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	database := filepath.Join(dir, "plugins.json")
+
+	initialStore, err := store.New(strings.NewReader(`[]`), logger)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store: catalog.NewWithOptions(initialStore, catalog.Options{
+			Backend: &catalog.FileBackend{Path: database},
+		}),
+		Authenticator: api.TokenAuthenticator{
+			"alice-token":     "alice",
+			"moderator-token": "moderator",
+		},
+		Moderators: map[string]bool{"moderator": true},
+		Logger:     logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	clientFor := func(token string) *api.Client {
+		client := api.NewClient(ts.URL)
+		client.Token = token
+		return client
+	}
+	moderator := clientFor("moderator-token")
+
+	plugins := func(downloadURL string) *bytes.Reader {
+		data, err := json.Marshal([]*model.Plugin{{
+			DownloadURL: downloadURL,
+			Manifest:    &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+		}})
+		require.NoError(t, err)
+		return bytes.NewReader(data)
+	}
+
+	t.Run("not a moderator", func(t *testing.T) {
+		_, err := clientFor("alice-token").ImportCatalog(plugins("https://example.com/demo.tar.gz"))
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("invalid plugins", func(t *testing.T) {
+		_, err := moderator.ImportCatalog(plugins(""))
+		require.Equal(t, &api.RejectedCandidateError{Problems: []string{"plugin demo 0.1.0 has no download url"}}, err)
+
+		_, err = moderator.ImportCatalog(strings.NewReader(`{`))
+		require.IsType(t, &api.RejectedCandidateError{}, err)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		snapshot, err := moderator.ImportCatalog(plugins("https://example.com/demo.tar.gz"))
+		require.NoError(t, err)
+		require.Equal(t, model.SnapshotSourceImported, snapshot.Source)
+
+		served, err := moderator.GetPlugins(&api.GetPluginsRequest{PerPage: 10})
+		require.NoError(t, err)
+		require.Len(t, served, 1)
+
+		_, err = os.Stat(database)
+		require.NoError(t, err)
+	})
+}
+
+func TestImportCatalogDisabled(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	initialStore, err := store.New(strings.NewReader(`[]`), logger)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:         catalog.New(initialStore),
+		Authenticator: api.TokenAuthenticator{"moderator-token": "moderator"},
+		Moderators:    map[string]bool{"moderator": true},
+		Logger:        logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := api.NewClient(ts.URL)
+	client.Token = "moderator-token"
+	_, err = client.ImportCatalog(strings.NewReader(`[]`))
+	require.Equal(t, api.ErrNotFound, err)
+}
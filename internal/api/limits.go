@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Limits bounds the size of the requests accepted by the API.
+//
+// Header size is bounded separately by http.Server.MaxHeaderBytes, since headers are read before
+// any handler runs.
+type Limits struct {
+	// MaxBodySize is the maximum size in bytes of a request body, or 0 for no limit.
+	MaxBodySize int64
+	// MaxURLLength is the maximum length of a request's path and query, or 0 for no limit.
+	MaxURLLength int
+}
+
+// DefaultLimits are generous enough for any request made by the marketplace client.
+var DefaultLimits = Limits{
+	MaxBodySize:  1 << 20,
+	MaxURLLength: 8192,
+}
+
+// limitHandler rejects requests exceeding its limits before passing them to the next handler.
+type limitHandler struct {
+	next   http.Handler
+	limits Limits
+}
+
+// NewLimitHandler wraps the given handler, rejecting requests that exceed the given limits.
+//
+// Requests with an overlong url are answered with a 414, and those declaring an oversized body
+// with a 413. Bodies of undeclared length are truncated at the limit, failing the handler's read.
+func NewLimitHandler(next http.Handler, limits Limits) http.Handler {
+	return &limitHandler{
+		next:   next,
+		limits: limits,
+	}
+}
+
+func (h *limitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.limits.MaxURLLength > 0 && len(r.URL.RequestURI()) > h.limits.MaxURLLength {
+		w.WriteHeader(http.StatusRequestURITooLong)
+		return
+	}
+
+	if h.limits.MaxBodySize > 0 {
+		if r.ContentLength > h.limits.MaxBodySize {
+			w.Header().Set("Connection", "close")
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, h.limits.MaxBodySize)
+	}
+
+	h.next.ServeHTTP(w, r)
+}
@@ -0,0 +1,74 @@
+package api_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+)
+
+func TestLimitHandler(t *testing.T) {
+	var received []byte
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = data
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ts := httptest.NewServer(api.NewLimitHandler(next, api.Limits{
+		MaxBodySize:  16,
+		MaxURLLength: 32,
+	}))
+	defer ts.Close()
+
+	t.Run("within limits", func(t *testing.T) {
+		resp, err := http.Post(ts.URL+"/api/v1/plugins", "application/json", strings.NewReader("small body"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, []byte("small body"), received)
+	})
+
+	t.Run("url too long", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/api/v1/plugins?filter=" + strings.Repeat("a", 32))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusRequestURITooLong, resp.StatusCode)
+	})
+
+	t.Run("declared body too large", func(t *testing.T) {
+		resp, err := http.Post(ts.URL+"/api/v1/plugins", "application/json", bytes.NewReader(make([]byte, 17)))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+
+	t.Run("undeclared body too large", func(t *testing.T) {
+		// Wrapping the reader hides its length, so the body is sent chunked.
+		body := ioutil.NopCloser(bytes.NewReader(make([]byte, 17)))
+		resp, err := http.Post(ts.URL+"/api/v1/plugins", "application/json", body)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		ts := httptest.NewServer(api.NewLimitHandler(next, api.Limits{}))
+		defer ts.Close()
+
+		resp, err := http.Post(ts.URL+"/api/v1/plugins?filter="+strings.Repeat("a", 64), "application/json", bytes.NewReader(make([]byte, 64)))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
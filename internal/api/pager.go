@@ -0,0 +1,73 @@
+package api
+
+import (
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// PluginsPager walks the pages of a plugin listing, tracking page state on behalf of the caller.
+type PluginsPager struct {
+	client  PluginClient
+	request GetPluginsRequest
+	done    bool
+}
+
+// PluginsPager creates a pager over the plugins matching the given request, starting with
+// request.Page.
+func (c *Client) PluginsPager(request *GetPluginsRequest) *PluginsPager {
+	return NewPluginsPager(c, request)
+}
+
+// NewPluginsPager creates a pager over the plugins matching the given request using any
+// PluginClient, starting with request.Page.
+func NewPluginsPager(client PluginClient, request *GetPluginsRequest) *PluginsPager {
+	pagerRequest := *request
+	if pagerRequest.PerPage == 0 {
+		pagerRequest.PerPage = defaultPerPage
+	}
+
+	return &PluginsPager{
+		client:  client,
+		request: pagerRequest,
+	}
+}
+
+// Next fetches the next page of plugins, returning an empty result once all pages are consumed.
+//
+// A failed request may be retried by calling Next again.
+func (p *PluginsPager) Next() ([]*model.Plugin, error) {
+	if p.done {
+		return nil, nil
+	}
+
+	plugins, err := p.client.GetPlugins(&p.request)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.request.PerPage == model.AllPerPage || len(plugins) < p.request.PerPage {
+		p.done = true
+	}
+	p.request.Page++
+
+	if len(plugins) == 0 {
+		return nil, nil
+	}
+
+	return plugins, nil
+}
+
+// All consumes the remaining pages, returning every plugin seen.
+func (p *PluginsPager) All() ([]*model.Plugin, error) {
+	var result []*model.Plugin
+	for {
+		plugins, err := p.Next()
+		if err != nil {
+			return nil, err
+		}
+		if len(plugins) == 0 {
+			return result, nil
+		}
+
+		result = append(result, plugins...)
+	}
+}
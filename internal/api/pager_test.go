@@ -0,0 +1,94 @@
+package api_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginsPager(t *testing.T) {
+	var allPlugins []*model.Plugin
+	for i := 0; i < 5; i++ {
+		allPlugins = append(allPlugins, &model.Plugin{
+			Manifest: &mattermostModel.Manifest{
+				Id:      fmt.Sprintf("plugin-%d", i),
+				Name:    fmt.Sprintf("plugin-%d", i),
+				Version: "0.1.0",
+			},
+			ReleaseStage: model.ReleaseStageProduction,
+		})
+	}
+
+	t.Run("no plugins", func(t *testing.T) {
+		client, tearDown := setupApi(t, nil)
+		defer tearDown()
+
+		pager := client.PluginsPager(&api.GetPluginsRequest{PerPage: 2})
+		plugins, err := pager.Next()
+		require.NoError(t, err)
+		require.Empty(t, plugins)
+	})
+
+	t.Run("walks pages", func(t *testing.T) {
+		client, tearDown := setupApi(t, allPlugins)
+		defer tearDown()
+
+		pager := client.PluginsPager(&api.GetPluginsRequest{PerPage: 2})
+
+		plugins, err := pager.Next()
+		require.NoError(t, err)
+		require.Equal(t, allPlugins[0:2], plugins)
+
+		plugins, err = pager.Next()
+		require.NoError(t, err)
+		require.Equal(t, allPlugins[2:4], plugins)
+
+		plugins, err = pager.Next()
+		require.NoError(t, err)
+		require.Equal(t, allPlugins[4:5], plugins)
+
+		plugins, err = pager.Next()
+		require.NoError(t, err)
+		require.Empty(t, plugins)
+	})
+
+	t.Run("exact multiple of page size", func(t *testing.T) {
+		client, tearDown := setupApi(t, allPlugins[0:4])
+		defer tearDown()
+
+		plugins, err := client.PluginsPager(&api.GetPluginsRequest{PerPage: 2}).All()
+		require.NoError(t, err)
+		require.Equal(t, allPlugins[0:4], plugins)
+	})
+
+	t.Run("starting page", func(t *testing.T) {
+		client, tearDown := setupApi(t, allPlugins)
+		defer tearDown()
+
+		plugins, err := client.PluginsPager(&api.GetPluginsRequest{Page: 1, PerPage: 2}).All()
+		require.NoError(t, err)
+		require.Equal(t, allPlugins[2:5], plugins)
+	})
+
+	t.Run("all per page", func(t *testing.T) {
+		client, tearDown := setupApi(t, allPlugins)
+		defer tearDown()
+
+		plugins, err := client.PluginsPager(&api.GetPluginsRequest{PerPage: model.AllPerPage}).All()
+		require.NoError(t, err)
+		require.Equal(t, allPlugins, plugins)
+	})
+
+	t.Run("default page size", func(t *testing.T) {
+		client, tearDown := setupApi(t, allPlugins)
+		defer tearDown()
+
+		plugins, err := client.PluginsPager(&api.GetPluginsRequest{}).All()
+		require.NoError(t, err)
+		require.Equal(t, allPlugins, plugins)
+	})
+}
@@ -1,11 +1,13 @@
 package api
 
 import (
+	"bytes"
 	"net/http"
-	"net/url"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
 )
 
 // initPlugins registers plugin endpoints on the given router.
@@ -16,49 +18,337 @@ func initPlugins(apiRouter *mux.Router, context *Context) {
 
 	pluginsRouter := apiRouter.PathPrefix("/plugins").Subrouter()
 	pluginsRouter.Handle("", addContext(handleGetPlugins)).Methods("GET")
+	pluginsRouter.Handle("/{id}", addContext(handleGetPlugin)).Methods("GET")
+	pluginsRouter.Handle("/{id}/versions", addContext(handleGetPluginVersions)).Methods("GET")
+	pluginsRouter.Handle("/{id}/compatibility", addContext(handleGetPluginCompatibility)).Methods("GET")
+	pluginsRouter.Handle("/{id}/changelog", addContext(handleGetPluginChangelog)).Methods("GET")
+	pluginsRouter.Handle("/{id}/download", addContext(handleDownloadPlugin)).Methods("GET")
+	pluginsRouter.Handle("/{id}/icon", addContext(handleGetPluginIcon)).Methods("GET")
 }
 
-func parsePluginFilter(u *url.URL) (*model.PluginFilter, error) {
-	page, err := parseInt(u, "page", 0)
+// Headers by which Mattermost servers describe themselves, in place of the equivalent query
+// parameters.
+const (
+	serverVersionHeader = "X-Mattermost-Server-Version"
+	licenseTierHeader   = "X-Mattermost-License-Tier"
+)
+
+// DefaultCompatibilityServerVersions are the latest Mattermost Extended Support Releases, for which
+// plugin compatibility is reported by default.
+var DefaultCompatibilityServerVersions = []string{"7.8.0", "8.1.0", "9.5.0", "9.11.0", "10.5.0", "10.11.0"}
+
+// requestLicenseTier returns the license tier of the requesting server, given by the license_tier
+// query parameter or else the X-Mattermost-License-Tier header, if any, case-insensitively.
+func requestLicenseTier(r *http.Request) (model.LicenseTier, error) {
+	value := r.URL.Query().Get(licenseTierParameter)
+	if value == "" {
+		value = r.Header.Get(licenseTierHeader)
+	}
+	licenseTier := model.LicenseTier(strings.ToLower(value))
+	if licenseTier != "" && !licenseTier.IsValid() {
+		return "", errors.Errorf("invalid license tier %s", licenseTier)
+	}
+
+	return licenseTier, nil
+}
+
+// withinLicenseTier returns the given plugins that may be installed by servers with the given
+// license tier, or all of them if no tier is given.
+func withinLicenseTier(plugins []*model.Plugin, licenseTier model.LicenseTier) []*model.Plugin {
+	if licenseTier == "" {
+		return plugins
+	}
+
+	var result []*model.Plugin
+	for _, plugin := range plugins {
+		if licenseTier.Satisfies(plugin.RequiredLicense) {
+			result = append(result, plugin)
+		}
+	}
+
+	return result
+}
+
+// withinLicenseTierChanges returns the given changes as seen by servers with the given license
+// tier, or all of them if no tier is given. Versions requiring a higher tier are never shown as
+// added, and their updates are shown as removals, so that replicas drop versions whose required
+// license was raised.
+func withinLicenseTierChanges(changes []*model.CatalogChange, licenseTier model.LicenseTier) []*model.CatalogChange {
+	if licenseTier == "" {
+		return changes
+	}
+
+	result := make([]*model.CatalogChange, 0, len(changes))
+	for _, change := range changes {
+		if change.Plugin != nil && !licenseTier.Satisfies(change.Plugin.RequiredLicense) {
+			if change.Type == model.ChangeTypeAdded {
+				continue
+			}
+			removal := *change
+			removal.Type = model.ChangeTypeRemoved
+			removal.Plugin = nil
+			change = &removal
+		}
+		result = append(result, change)
+	}
+
+	return result
+}
+
+// getLicensedPluginVersions returns the versions of the plugin identified by the request, less
+// those requiring a higher license tier than the requesting server's. Every request for a single
+// plugin queries it through here, so that gated versions are hidden from each of them alike. It
+// responds with an error and returns false if the request is invalid or no version remains.
+func getLicensedPluginVersions(c *Context, w http.ResponseWriter, r *http.Request) ([]*model.Plugin, bool) {
+	licenseTier, err := requestLicenseTier(r)
 	if err != nil {
-		return nil, err
+		c.Logger.WithError(err).Error("failed to parse license tier")
+		writeError(c, w, r, http.StatusBadRequest)
+		return nil, false
 	}
 
-	perPage, err := parseInt(u, "per_page", 100)
+	endSpan := traceStore(c, r, "GetPluginVersions")
+	plugins, err := c.Store.GetPluginVersions(mux.Vars(r)["id"])
+	endSpan()
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to query plugin versions")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return nil, false
+	}
+	plugins = withinLicenseTier(plugins, licenseTier)
+	if len(plugins) == 0 {
+		writeError(c, w, r, http.StatusNotFound)
+		return nil, false
+	}
+
+	return plugins, true
+}
+
+func parsePluginFilter(r *http.Request) (*model.PluginFilter, error) {
+	request, err := ParseGetPluginsRequest(r.URL.Query())
 	if err != nil {
 		return nil, err
 	}
 
-	filter := u.Query().Get("filter")
-	serverVersion := u.Query().Get("server_version")
+	if request.ServerVersion == "" {
+		request.ServerVersion = r.Header.Get(serverVersionHeader)
+	}
+	if request.LicenseTier == "" {
+		if request.LicenseTier, err = requestLicenseTier(r); err != nil {
+			return nil, err
+		}
+	}
 
-	return &model.PluginFilter{
-		Page:          page,
-		PerPage:       perPage,
-		Filter:        filter,
-		ServerVersion: serverVersion,
-	}, nil
+	return request.PluginFilter(), nil
 }
 
 // handleGetPlugins responds to GET /api/v1/plugins, returning the specified page of plugins.
 func handleGetPlugins(c *Context, w http.ResponseWriter, r *http.Request) {
-	filter, err := parsePluginFilter(r.URL)
+	filter, err := parsePluginFilter(r)
 	if err != nil {
-		c.Logger.WithError(err).Error("failed to parse paging parameters")
-		w.WriteHeader(http.StatusBadRequest)
+		c.Logger.WithError(err).Error("failed to parse query parameters")
+		writeError(c, w, r, http.StatusBadRequest)
 		return
 	}
+	if c.Analytics != nil {
+		c.Analytics.RecordQuery(filter.ServerVersion, filter.Filter)
+	}
 
+	cacheKey, cacheable := pluginsCacheKey(c, r, filter)
+	if cacheable {
+		if response, ok := c.ResponseCache.get(cacheKey); ok {
+			response.write(w)
+			return
+		}
+	}
+
+	endSpan := traceStore(c, r, "GetPlugins")
 	plugins, err := c.Store.GetPlugins(filter)
+	endSpan()
 	if err != nil {
 		c.Logger.WithError(err).Error("failed to query plugins")
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(c, w, r, http.StatusInternalServerError)
 		return
 	}
 	if plugins == nil {
 		plugins = []*model.Plugin{}
 	}
+	if c.Ratings != nil {
+		plugins = withRatingSummaries(plugins, c.Ratings.RatingSummaries())
+	}
+	plugins = withAdvisories(c, plugins)
+	plugins = withLocalizations(c, w, r, plugins)
+	plugins = withRehostedBundles(c, r, plugins)
+	plugins = withoutOversizedIcons(c, r, plugins)
+
+	mediaType := negotiateMediaType(r)
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Add("Vary", "Accept")
+	if !cacheable {
+		outputEncoded(c, w, mediaType, plugins)
+		return
+	}
+
+	var body bytes.Buffer
+	outputEncoded(c, &body, mediaType, plugins)
+	c.ResponseCache.put(newCachedResponse(cacheKey, w.Header(), &body))
+	w.Write(body.Bytes())
+}
+
+// handleGetPlugin responds to GET /api/v1/plugins/{id}, returning the latest version of the given
+// plugin.
+func handleGetPlugin(c *Context, w http.ResponseWriter, r *http.Request) {
+	plugins, ok := getLicensedPluginVersions(c, w, r)
+	if !ok {
+		return
+	}
+
+	recordPluginRequest(c, plugins[0].Manifest.Id)
+
+	plugin := plugins[0]
+	if c.Ratings != nil {
+		plugin = withRatingSummaries(plugins[:1], c.Ratings.RatingSummaries())[0]
+	}
+	plugin = withAdvisories(c, []*model.Plugin{plugin})[0]
+	plugin = withLocalizations(c, w, r, []*model.Plugin{plugin})[0]
+	plugin = withRehostedBundles(c, r, []*model.Plugin{plugin})[0]
 
 	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, plugin)
+}
+
+// groupByMinServerVersion requests versions grouped by their minimum server version.
+const groupByMinServerVersion = "min_server_version"
+
+// handleGetPluginVersions responds to GET /api/v1/plugins/{id}/versions, returning every version of
+// the given plugin, or, given group_by=min_server_version, the versions grouped by their minimum
+// server version.
+func handleGetPluginVersions(c *Context, w http.ResponseWriter, r *http.Request) {
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy != "" && groupBy != groupByMinServerVersion {
+		c.Logger.WithField("group_by", groupBy).Error("failed to parse query parameters")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	plugins, ok := getLicensedPluginVersions(c, w, r)
+	if !ok {
+		return
+	}
+	recordPluginRequest(c, plugins[0].Manifest.Id)
+	plugins = withAdvisories(c, plugins)
+	plugins = withLocalizations(c, w, r, plugins)
+	plugins = withRehostedBundles(c, r, plugins)
+	plugins = withoutOversizedIcons(c, r, plugins)
+
+	w.Header().Set("Content-Type", "application/json")
+	if groupBy == groupByMinServerVersion {
+		outputJSON(c, w, model.NewVersionBuckets(plugins))
+		return
+	}
 	outputJSON(c, w, plugins)
 }
+
+// handleGetPluginCompatibility responds to GET /api/v1/plugins/{id}/compatibility, returning the
+// version of the given plugin served to each of the server versions given by the comma-separated
+// server_versions query parameter, or else to each of the context's compatibility server versions.
+func handleGetPluginCompatibility(c *Context, w http.ResponseWriter, r *http.Request) {
+	serverVersions := c.CompatibilityServerVersions
+	if len(serverVersions) == 0 {
+		serverVersions = DefaultCompatibilityServerVersions
+	}
+	if query := r.URL.Query().Get("server_versions"); query != "" {
+		serverVersions = strings.Split(query, ",")
+	}
+
+	plugins, ok := getLicensedPluginVersions(c, w, r)
+	if !ok {
+		return
+	}
+
+	matrix, err := model.NewCompatibilityMatrix(plugins, serverVersions)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to determine compatibility")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, matrix)
+}
+
+// handleGetPluginChangelog responds to GET /api/v1/plugins/{id}/changelog, aggregating the release
+// notes of the versions of the given plugin released after the version given by the from query
+// parameter, if any, up to and including the version given by the to query parameter, or else the
+// latest version.
+func handleGetPluginChangelog(c *Context, w http.ResponseWriter, r *http.Request) {
+	plugins, ok := getLicensedPluginVersions(c, w, r)
+	if !ok {
+		return
+	}
+
+	changelog, err := model.NewChangelog(plugins, r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to aggregate release notes")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, changelog)
+}
+
+// handleDownloadPlugin responds to GET /api/v1/plugins/{id}/download, recording the download
+// before redirecting to the bundle of the requested version, or of the latest version if none
+// is given. Re-hosted bundles are served directly instead.
+func handleDownloadPlugin(c *Context, w http.ResponseWriter, r *http.Request) {
+	version := model.NormalizeVersion(r.URL.Query().Get("version"))
+	platform := r.URL.Query().Get("platform")
+
+	plugins, ok := getLicensedPluginVersions(c, w, r)
+	if !ok {
+		return
+	}
+
+	var plugin *model.Plugin
+	for _, candidate := range plugins {
+		if version == "" || candidate.Manifest.Version == version {
+			plugin = candidate
+			break
+		}
+	}
+	if plugin == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	if c.Bundles != nil {
+		// As for redirects, an unknown platform is served the default bundle.
+		if _, ok := plugin.Platforms[platform]; !ok {
+			platform = ""
+		}
+		serveBundle(c, w, r, plugin, platform)
+		return
+	}
+
+	// Private bundles are only served re-hosted, through pre-signed urls.
+	if plugin.Private {
+		writeError(c, w, r, http.StatusForbidden)
+		return
+	}
+
+	if platform != "" {
+		plugin = plugin.ForPlatform(platform)
+	}
+	if plugin.DownloadURL == "" {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	if c.Stats != nil {
+		c.Stats.RecordDownload(plugin.Manifest.Id, plugin.Manifest.Version)
+	}
+	recordPluginRequest(c, plugin.Manifest.Id)
+
+	http.Redirect(w, r, plugin.DownloadURL, http.StatusFound)
+}
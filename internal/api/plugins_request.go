@@ -3,22 +3,158 @@ package api
 import (
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// The query parameters of a GetPluginsRequest, shared by the client encoding a request and the
+// server parsing it.
+const (
+	pageParameter                = "page"
+	perPageParameter             = "per_page"
+	filterParameter              = "filter"
+	serverVersionParameter       = "server_version"
+	authorTypeParameter          = "author_type"
+	hostingParameter             = "hosting"
+	licenseTierParameter         = "license_tier"
+	includeIncompatibleParameter = "include_incompatible"
+	platformParameter            = "platform"
+	labelParameter               = "label"
+	sortParameter                = "sort"
 )
 
+// defaultPerPage is the page size the server assumes when none is given.
+const defaultPerPage = 100
+
 // GetPluginsRequest describes the parameters to request a list of plugins.
 type GetPluginsRequest struct {
 	Page          int
 	PerPage       int
 	Filter        string
 	ServerVersion string
+	AuthorType    model.AuthorType
+	Hosting       model.HostingRequirement
+	// LicenseTier excludes plugins requiring a higher license tier than the requesting server's.
+	LicenseTier model.LicenseTier
+	// IncludeIncompatible also requests plugins incompatible with ServerVersion, annotated with
+	// their Compatibility.
+	IncludeIncompatible bool
+	// Platform excludes plugins without a bundle installable on the given platform, e.g.
+	// linux-amd64.
+	Platform string
+	// Labels excludes plugins missing any of the given label names.
+	Labels []string
+	// Sort orders the plugins, defaulting to featured plugins first, or the most relevant first
+	// given a Filter.
+	Sort model.PluginSort
+	// AsOf, if set, requests the plugins served at the given time, as retained by the catalog.
+	AsOf time.Time
 }
 
 // ApplyToURL modifies the given url to include query string parameters for the request.
 func (request *GetPluginsRequest) ApplyToURL(u *url.URL) {
 	q := u.Query()
-	q.Add("page", strconv.Itoa(request.Page))
-	q.Add("per_page", strconv.Itoa(request.PerPage))
-	q.Add("filter", request.Filter)
-	q.Add("server_version", request.ServerVersion)
+	q.Add(pageParameter, strconv.Itoa(request.Page))
+	q.Add(perPageParameter, strconv.Itoa(request.PerPage))
+	q.Add(filterParameter, request.Filter)
+	q.Add(serverVersionParameter, request.ServerVersion)
+	if request.AuthorType != "" {
+		q.Add(authorTypeParameter, string(request.AuthorType))
+	}
+	if request.Hosting != "" {
+		q.Add(hostingParameter, string(request.Hosting))
+	}
+	if request.LicenseTier != "" {
+		q.Add(licenseTierParameter, string(request.LicenseTier))
+	}
+	if request.IncludeIncompatible {
+		q.Add(includeIncompatibleParameter, "true")
+	}
+	if request.Platform != "" {
+		q.Add(platformParameter, request.Platform)
+	}
+	for _, label := range request.Labels {
+		q.Add(labelParameter, label)
+	}
+	if request.Sort != model.PluginSortDefault {
+		q.Add(sortParameter, string(request.Sort))
+	}
+	if !request.AsOf.IsZero() {
+		q.Add(asOfParameter, request.AsOf.UTC().Format(time.RFC3339Nano))
+	}
 	u.RawQuery = q.Encode()
 }
+
+// ParseGetPluginsRequest parses the request encoded in the given query string parameters by
+// ApplyToURL, defaulting PerPage when not given.
+func ParseGetPluginsRequest(q url.Values) (*GetPluginsRequest, error) {
+	request := &GetPluginsRequest{
+		PerPage:       defaultPerPage,
+		Filter:        q.Get(filterParameter),
+		ServerVersion: q.Get(serverVersionParameter),
+		AuthorType:    model.AuthorType(q.Get(authorTypeParameter)),
+		Hosting:       model.HostingRequirement(q.Get(hostingParameter)),
+		LicenseTier:   model.LicenseTier(strings.ToLower(q.Get(licenseTierParameter))),
+		Platform:      q.Get(platformParameter),
+		Labels:        q[labelParameter],
+		Sort:          model.PluginSort(q.Get(sortParameter)),
+	}
+
+	var err error
+	if value := q.Get(pageParameter); value != "" {
+		if request.Page, err = strconv.Atoi(value); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s as integer", pageParameter)
+		}
+	}
+	if value := q.Get(perPageParameter); value != "" {
+		if request.PerPage, err = strconv.Atoi(value); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s as integer", perPageParameter)
+		}
+	}
+	if value := q.Get(includeIncompatibleParameter); value != "" {
+		if request.IncludeIncompatible, err = strconv.ParseBool(value); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s as boolean", includeIncompatibleParameter)
+		}
+	}
+	if value := q.Get(asOfParameter); value != "" {
+		if request.AsOf, err = parseAsOf(value); err != nil {
+			return nil, err
+		}
+	}
+
+	if request.AuthorType != "" && !request.AuthorType.IsValid() {
+		return nil, errors.Errorf("invalid %s %s", authorTypeParameter, request.AuthorType)
+	}
+	if request.Hosting != "" && !request.Hosting.IsValid() {
+		return nil, errors.Errorf("invalid %s %s", hostingParameter, request.Hosting)
+	}
+	if request.LicenseTier != "" && !request.LicenseTier.IsValid() {
+		return nil, errors.Errorf("invalid license tier %s", request.LicenseTier)
+	}
+	if !request.Sort.IsValid() {
+		return nil, errors.Errorf("invalid %s %s", sortParameter, request.Sort)
+	}
+
+	return request, nil
+}
+
+// PluginFilter returns the filter selecting the plugins requested from a store.
+func (request *GetPluginsRequest) PluginFilter() *model.PluginFilter {
+	return &model.PluginFilter{
+		Page:                request.Page,
+		PerPage:             request.PerPage,
+		Filter:              request.Filter,
+		ServerVersion:       request.ServerVersion,
+		AuthorType:          request.AuthorType,
+		Hosting:             request.Hosting,
+		LicenseTier:         request.LicenseTier,
+		IncludeIncompatible: request.IncludeIncompatible,
+		Platform:            request.Platform,
+		Labels:              request.Labels,
+		Sort:                request.Sort,
+	}
+}
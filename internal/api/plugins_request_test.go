@@ -0,0 +1,65 @@
+package api_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func TestGetPluginsRequest(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		for description, request := range map[string]*api.GetPluginsRequest{
+			"empty": {},
+			"full": {
+				Page:                2,
+				PerPage:             50,
+				Filter:              "jira & confluence",
+				ServerVersion:       "5.20.0",
+				AuthorType:          model.AuthorTypeMattermost,
+				Hosting:             model.HostingCloud,
+				LicenseTier:         model.LicenseTierEnterprise,
+				IncludeIncompatible: true,
+				Platform:            "linux-amd64",
+				Labels:              []string{"Official", "Beta"},
+				Sort:                model.PluginSortReleased,
+				AsOf:                time.Date(2020, 3, 10, 9, 0, 0, 0, time.UTC),
+			},
+		} {
+			u := &url.URL{Path: "/api/v1/plugins"}
+			request.ApplyToURL(u)
+
+			parsed, err := api.ParseGetPluginsRequest(u.Query())
+			require.NoError(t, err, description)
+			require.Equal(t, request, parsed, description)
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		parsed, err := api.ParseGetPluginsRequest(url.Values{})
+		require.NoError(t, err)
+		require.Equal(t, &api.GetPluginsRequest{PerPage: 100}, parsed)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, query := range []string{
+			"page=first",
+			"per_page=all",
+			"include_incompatible=maybe",
+			"as_of=yesterday",
+			"author_type=robot",
+			"hosting=moon",
+			"license_tier=platinum",
+			"sort=popularity",
+		} {
+			q, err := url.ParseQuery(query)
+			require.NoError(t, err)
+			_, err = api.ParseGetPluginsRequest(q)
+			require.Error(t, err, query)
+		}
+	})
+}
@@ -87,6 +87,33 @@ func TestPlugins(t *testing.T) {
 			require.Equal(t, http.StatusOK, resp.StatusCode)
 		})
 
+		t.Run("invalid hosting", func(t *testing.T) {
+			client, tearDown := setupApi(t, nil)
+			defer tearDown()
+
+			resp, err := http.Get(fmt.Sprintf("%s/api/v1/plugins?hosting=invalid", client.Address))
+			require.NoError(t, err)
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+
+		t.Run("invalid include_incompatible", func(t *testing.T) {
+			client, tearDown := setupApi(t, []*model.Plugin{})
+			defer tearDown()
+
+			resp, err := http.Get(fmt.Sprintf("%s/api/v1/plugins?include_incompatible=maybe", client.Address))
+			require.NoError(t, err)
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+
+		t.Run("invalid author_type", func(t *testing.T) {
+			client, tearDown := setupApi(t, nil)
+			defer tearDown()
+
+			resp, err := http.Get(fmt.Sprintf("%s/api/v1/plugins?author_type=invalid", client.Address))
+			require.NoError(t, err)
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+
 		t.Run("missing perPage", func(t *testing.T) {
 			client, tearDown := setupApi(t, nil)
 			defer tearDown()
@@ -99,63 +126,73 @@ func TestPlugins(t *testing.T) {
 
 	t.Run("plugins", func(t *testing.T) {
 		plugin1_V1Min515 := &model.Plugin{
-			HomepageURL: "https://github.com/mattermost/mattermost-plugin-demo",
-			IconData:    "icon-data.svg",
-			DownloadURL: "https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz",
-			Manifest:    &mattermostModel.Manifest{Id: "mattermost-plugin-demo", Name: "mattermost-plugin-demo", Version: "0.1.0", MinServerVersion: "5.15.0"},
-			Signature:   "signature1",
+			HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+			IconData:     "data:image/svg+xml;base64,PHN2Zy8+",
+			DownloadURL:  "https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "mattermost-plugin-demo", Name: "mattermost-plugin-demo", Version: "0.1.0", MinServerVersion: "5.15.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			Signature:    "signature1",
 		}
 		plugin1_V2Min515 := &model.Plugin{
-			HomepageURL: "https://github.com/mattermost/mattermost-plugin-demo",
-			IconData:    "icon-data.svg",
-			DownloadURL: "https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.2.0/com.mattermost.demo-plugin-0.2.0.tar.gz",
-			Manifest:    &mattermostModel.Manifest{Id: "mattermost-plugin-demo", Name: "mattermost-plugin-demo", Version: "0.2.0", MinServerVersion: "5.15.0"},
-			Signature:   "signature1",
+			HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+			IconData:     "data:image/svg+xml;base64,PHN2Zy8+",
+			DownloadURL:  "https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.2.0/com.mattermost.demo-plugin-0.2.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "mattermost-plugin-demo", Name: "mattermost-plugin-demo", Version: "0.2.0", MinServerVersion: "5.15.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			Signature:    "signature1",
 		}
 		plugin1_V3Min515 := &model.Plugin{
-			HomepageURL: "https://github.com/mattermost/mattermost-plugin-demo",
-			IconData:    "icon-data.svg",
-			DownloadURL: "https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.3.0/com.mattermost.demo-plugin-0.3.0.tar.gz",
-			Manifest:    &mattermostModel.Manifest{Id: "mattermost-plugin-demo", Name: "mattermost-plugin-demo", Version: "0.3.0", MinServerVersion: "5.15.0"},
-			Signature:   "signature1",
+			HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+			IconData:     "data:image/svg+xml;base64,PHN2Zy8+",
+			DownloadURL:  "https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.3.0/com.mattermost.demo-plugin-0.3.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "mattermost-plugin-demo", Name: "mattermost-plugin-demo", Version: "0.3.0", MinServerVersion: "5.15.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			Signature:    "signature1",
 		}
 		plugin2_V1Min516 := &model.Plugin{
-			HomepageURL: "https://github.com/mattermost/mattermost-plugin-starter-template",
-			IconData:    "icon-data2.svg",
-			DownloadURL: "https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz",
-			Manifest:    &mattermostModel.Manifest{Id: "mattermost-plugin-starter-template", Name: "mattermost-plugin-starter-template", Version: "0.1.0", MinServerVersion: "5.16.0"},
-			Signature:   "signature2",
+			HomepageURL:  "https://github.com/mattermost/mattermost-plugin-starter-template",
+			IconData:     "data:image/png;base64,iVBORw0KGgo=",
+			DownloadURL:  "https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "mattermost-plugin-starter-template", Name: "mattermost-plugin-starter-template", Version: "0.1.0", MinServerVersion: "5.16.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			Signature:    "signature2",
 		}
 		plugin3_V1NoMin := &model.Plugin{
-			HomepageURL: "https://github.com/matterpoll/matterpoll",
-			IconData:    "icon-data3.svg",
-			DownloadURL: "https://github.com/matterpoll/matterpoll/releases/download/v1.1.0/com.github.matterpoll.matterpoll-1.1.0.tar.gz",
-			Manifest:    &mattermostModel.Manifest{Id: "matterpoll", Name: "matterpoll", Version: "1.1.0"},
-			Signature:   "signature3",
+			HomepageURL:  "https://github.com/matterpoll/matterpoll",
+			IconData:     "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=",
+			DownloadURL:  "https://github.com/matterpoll/matterpoll/releases/download/v1.1.0/com.github.matterpoll.matterpoll-1.1.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "matterpoll", Name: "matterpoll", Version: "1.1.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			Signature:    "signature3",
 		}
 
 		plugin3_V2Min516 := &model.Plugin{
-			HomepageURL: "https://github.com/matterpoll/matterpoll",
-			IconData:    "icon-data3.svg",
-			DownloadURL: "https://github.com/matterpoll/matterpoll/releases/download/v1.2.0/com.github.matterpoll.matterpoll-1.2.0.tar.gz",
-			Manifest:    &mattermostModel.Manifest{Id: "matterpoll", Name: "matterpoll", Version: "1.2.0", MinServerVersion: "5.16.0"},
-			Signature:   "signature3",
+			HomepageURL:  "https://github.com/matterpoll/matterpoll",
+			IconData:     "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=",
+			DownloadURL:  "https://github.com/matterpoll/matterpoll/releases/download/v1.2.0/com.github.matterpoll.matterpoll-1.2.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "matterpoll", Name: "matterpoll", Version: "1.2.0", MinServerVersion: "5.16.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			Signature:    "signature3",
 		}
 
 		plugin3_V3Min517 := &model.Plugin{
-			HomepageURL: "https://github.com/matterpoll/matterpoll",
-			IconData:    "icon-data3.svg",
-			DownloadURL: "https://github.com/matterpoll/matterpoll/releases/download/v1.3.0/com.github.matterpoll.matterpoll-1.3.0.tar.gz",
-			Manifest:    &mattermostModel.Manifest{Id: "matterpoll", Name: "matterpoll", Version: "1.3.0", MinServerVersion: "5.17.0"},
-			Signature:   "signature3",
+			HomepageURL:  "https://github.com/matterpoll/matterpoll",
+			IconData:     "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=",
+			DownloadURL:  "https://github.com/matterpoll/matterpoll/releases/download/v1.3.0/com.github.matterpoll.matterpoll-1.3.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "matterpoll", Name: "matterpoll", Version: "1.3.0", MinServerVersion: "5.17.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			Signature:    "signature3",
 		}
 
 		plugin4_V1NoMin := &model.Plugin{
-			HomepageURL: "fake_plugin",
-			IconData:    "icon-data3.svg",
-			DownloadURL: "fake_plugin.tar.gz",
-			Manifest:    &mattermostModel.Manifest{Id: "fake_plugin", Name: "Zfake_plugin", Version: "1.2.4"},
-			Signature:   "signature3",
+			HomepageURL:        "fake_plugin",
+			IconData:           "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=",
+			DownloadURL:        "fake_plugin.tar.gz",
+			Manifest:           &mattermostModel.Manifest{Id: "fake_plugin", Name: "Zfake_plugin", Version: "1.2.4"},
+			ReleaseStage:       model.ReleaseStageProduction,
+			Signature:          "signature3",
+			AuthorType:         model.AuthorTypeCommunity,
+			HostingRequirement: model.HostingCloud,
 		}
 
 		allPlugins := []*model.Plugin{plugin1_V1Min515, plugin1_V2Min515, plugin1_V3Min515, plugin2_V1Min516, plugin3_V1NoMin, plugin3_V2Min516, plugin3_V3Min517}
@@ -208,6 +245,37 @@ func TestPlugins(t *testing.T) {
 			require.Equal(t, []*model.Plugin{plugin1_V3Min515, plugin3_V1NoMin}, plugins)
 		})
 
+		t.Run("server version including incompatible plugins", func(t *testing.T) {
+			client, tearDown := setupApi(t, allPlugins)
+			defer tearDown()
+
+			plugins, err := client.GetPlugins(&api.GetPluginsRequest{
+				PerPage:             model.AllPerPage,
+				ServerVersion:       "5.15.0",
+				IncludeIncompatible: true,
+			})
+			require.NoError(t, err)
+
+			incompatiblePlugin2 := *plugin2_V1Min516
+			incompatiblePlugin2.Compatibility = &model.Compatibility{
+				Reason:                model.CompatibilityReasonMinServerVersion,
+				RequiredServerVersion: "5.16.0",
+			}
+			require.Equal(t, []*model.Plugin{plugin1_V3Min515, &incompatiblePlugin2, plugin3_V1NoMin}, plugins)
+		})
+
+		t.Run("including incompatible plugins without a server version", func(t *testing.T) {
+			client, tearDown := setupApi(t, allPlugins)
+			defer tearDown()
+
+			plugins, err := client.GetPlugins(&api.GetPluginsRequest{
+				PerPage:             model.AllPerPage,
+				IncludeIncompatible: true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{plugin1_V3Min515, plugin2_V1Min516, plugin3_V3Min517}, plugins)
+		})
+
 		t.Run("server version that satisfies no plugin", func(t *testing.T) {
 			client, tearDown := setupApi(t, []*model.Plugin{plugin1_V1Min515, plugin1_V2Min515, plugin1_V3Min515, plugin2_V1Min516, plugin3_V2Min516, plugin3_V3Min517})
 
@@ -281,6 +349,30 @@ func TestPlugins(t *testing.T) {
 			require.Equal(t, []*model.Plugin{plugin1_V3Min515, plugin2_V1Min516, plugin3_V3Min517, plugin4_V1NoMin}, plugins)
 		})
 
+		t.Run("author type", func(t *testing.T) {
+			client, tearDown := setupApi(t, append(allPlugins, plugin4_V1NoMin))
+			defer tearDown()
+
+			plugins, err := client.GetPlugins(&api.GetPluginsRequest{
+				PerPage:    -1,
+				AuthorType: model.AuthorTypeCommunity,
+			})
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{plugin4_V1NoMin}, plugins)
+		})
+
+		t.Run("hosting", func(t *testing.T) {
+			client, tearDown := setupApi(t, append(allPlugins, plugin4_V1NoMin))
+			defer tearDown()
+
+			plugins, err := client.GetPlugins(&api.GetPluginsRequest{
+				PerPage: -1,
+				Hosting: model.HostingCloud,
+			})
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{plugin4_V1NoMin}, plugins)
+		})
+
 		t.Run("invalid server_version format", func(t *testing.T) {
 			client, tearDown := setupApi(t, allPlugins)
 			defer tearDown()
@@ -301,3 +393,424 @@ func TestPlugins(t *testing.T) {
 		})
 	})
 }
+
+func TestPluginVersions(t *testing.T) {
+	pluginV1 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "com.mattermost.demo", Name: "Demo", Version: "0.1.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	pluginV10 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.10.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "com.mattermost.demo", Name: "Demo", Version: "0.10.0", MinServerVersion: "5.20.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	otherPlugin := &model.Plugin{
+		DownloadURL:  "https://example.com/other-0.2.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "other", Name: "Other", Version: "0.2.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+
+	client, tearDown := setupApi(t, []*model.Plugin{pluginV1, otherPlugin, pluginV10})
+	defer tearDown()
+
+	t.Run("versions", func(t *testing.T) {
+		plugins, err := client.GetPluginVersions("com.mattermost.demo")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{pluginV10, pluginV1}, plugins)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		_, err := client.GetPluginVersions("unknown")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("find older version", func(t *testing.T) {
+		plugin, err := api.FindPlugin(client, "com.mattermost.demo", "0.1.0")
+		require.NoError(t, err)
+		require.Equal(t, pluginV1, plugin)
+	})
+
+	t.Run("grouped by min server version", func(t *testing.T) {
+		buckets, err := client.GetPluginVersionBuckets("com.mattermost.demo")
+		require.NoError(t, err)
+		require.Equal(t, []*model.VersionBucket{
+			{MinServerVersion: "5.20.0", LatestVersion: "0.10.0", Versions: []*model.Plugin{pluginV10}},
+			{MinServerVersion: "", LatestVersion: "0.1.0", Versions: []*model.Plugin{pluginV1}},
+		}, buckets)
+
+		_, err = client.GetPluginVersionBuckets("unknown")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("invalid grouping", func(t *testing.T) {
+		resp, err := http.Get(client.Address + "/api/v1/plugins/com.mattermost.demo/versions?group_by=author")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestPluginAliases(t *testing.T) {
+	oldPlugin := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "com.example.demo", Name: "Demo", Version: "0.1.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	renamedPlugin := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "com.mattermost.demo", Name: "Demo", Version: "0.2.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+		OldIDs:       []string{"com.example.demo"},
+	}
+
+	client, tearDown := setupApi(t, []*model.Plugin{oldPlugin, renamedPlugin})
+	defer tearDown()
+
+	t.Run("get plugin", func(t *testing.T) {
+		plugin, err := client.GetPlugin("com.mattermost.demo")
+		require.NoError(t, err)
+		require.Equal(t, renamedPlugin, plugin)
+	})
+
+	t.Run("case-insensitive id", func(t *testing.T) {
+		plugin, err := client.GetPlugin("COM.Mattermost.Demo")
+		require.NoError(t, err)
+		require.Equal(t, renamedPlugin, plugin)
+	})
+
+	t.Run("old id", func(t *testing.T) {
+		plugin, err := client.GetPlugin("com.example.demo")
+		require.NoError(t, err)
+		require.Equal(t, renamedPlugin, plugin)
+
+		plugins, err := client.GetPluginVersions("Com.Example.Demo")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{renamedPlugin}, plugins)
+
+		plugin, err = api.FindPlugin(client, "com.example.demo", "")
+		require.NoError(t, err)
+		require.Equal(t, renamedPlugin, plugin)
+	})
+
+	t.Run("listed once by current id", func(t *testing.T) {
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{renamedPlugin}, plugins)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		_, err := client.GetPlugin("unknown")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+}
+
+func TestPluginLicenseTiers(t *testing.T) {
+	freePlugin := &model.Plugin{
+		DownloadURL:  "https://example.com/free-0.1.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "free", Name: "Free", Version: "0.1.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	professionalPlugin := &model.Plugin{
+		DownloadURL:     "https://example.com/professional-0.1.0.tar.gz",
+		Manifest:        &mattermostModel.Manifest{Id: "professional", Name: "Professional", Version: "0.1.0"},
+		ReleaseStage:    model.ReleaseStageProduction,
+		RequiredLicense: model.LicenseTierProfessional,
+	}
+
+	client, tearDown := setupApi(t, []*model.Plugin{freePlugin, professionalPlugin})
+	defer tearDown()
+
+	t.Run("no tier declared", func(t *testing.T) {
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{freePlugin, professionalPlugin}, plugins)
+	})
+
+	t.Run("query parameter", func(t *testing.T) {
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage, LicenseTier: model.LicenseTierTeam})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{freePlugin}, plugins)
+
+		plugins, err = client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage, LicenseTier: model.LicenseTierEnterprise})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{freePlugin, professionalPlugin}, plugins)
+
+		plugins, err = client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage, LicenseTier: "Team"})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{freePlugin}, plugins)
+	})
+
+	t.Run("invalid tier", func(t *testing.T) {
+		_, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage, LicenseTier: "platinum"})
+		require.Error(t, err)
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		teamClient := *client
+		teamClient.Headers = http.Header{
+			"X-Mattermost-License-Tier":   []string{"Team"},
+			"X-Mattermost-Server-Version": []string{"5.30.0"},
+		}
+
+		plugins, err := teamClient.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{freePlugin}, plugins)
+
+		_, err = teamClient.GetPlugin("professional")
+		require.Equal(t, api.ErrNotFound, err)
+
+		_, err = teamClient.GetPluginVersions("professional")
+		require.Equal(t, api.ErrNotFound, err)
+
+		plugin, err := teamClient.GetPlugin("free")
+		require.NoError(t, err)
+		require.Equal(t, freePlugin, plugin)
+	})
+
+	t.Run("downloads", func(t *testing.T) {
+		noRedirects := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+		download := func(t *testing.T, path string) int {
+			resp, err := noRedirects.Get(client.Address + path)
+			require.NoError(t, err)
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+
+		require.Equal(t, http.StatusFound, download(t, "/api/v1/plugins/professional/download"))
+		require.Equal(t, http.StatusFound, download(t, "/api/v1/plugins/professional/download?license_tier=enterprise"))
+		require.Equal(t, http.StatusFound, download(t, "/api/v1/plugins/free/download?license_tier=team"))
+		require.Equal(t, http.StatusNotFound, download(t, "/api/v1/plugins/professional/download?license_tier=team"))
+		require.Equal(t, http.StatusNotFound, download(t, "/api/v1/plugins/professional/download?license_tier=team&version=0.1.0"))
+		require.Equal(t, http.StatusBadRequest, download(t, "/api/v1/plugins/professional/download?license_tier=platinum"))
+	})
+}
+
+func TestPluginCompatibility(t *testing.T) {
+	pluginV1 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0", MinServerVersion: "7.8.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	pluginV2 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0", MinServerVersion: "9.11.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+
+	client, tearDown := setupApi(t, []*model.Plugin{pluginV1, pluginV2})
+	defer tearDown()
+
+	t.Run("default server versions", func(t *testing.T) {
+		matrix, err := client.GetPluginCompatibility("Demo", nil)
+		require.NoError(t, err)
+		require.Equal(t, "demo", matrix.PluginID)
+		require.Equal(t, "0.2.0", matrix.LatestVersion)
+		require.Len(t, matrix.Served, len(api.DefaultCompatibilityServerVersions))
+	})
+
+	t.Run("given server versions", func(t *testing.T) {
+		matrix, err := client.GetPluginCompatibility("demo", []string{"7.1.0", "9.5.0", "10.5.0"})
+		require.NoError(t, err)
+		incompatibility := &model.Compatibility{Reason: model.CompatibilityReasonMinServerVersion, RequiredServerVersion: "9.11.0"}
+		require.Equal(t, []*model.ServedVersion{
+			{ServerVersion: "7.1.0", Compatibility: incompatibility},
+			{ServerVersion: "9.5.0", PluginVersion: "0.1.0", Compatibility: incompatibility},
+			{ServerVersion: "10.5.0", PluginVersion: "0.2.0"},
+		}, matrix.Served)
+	})
+
+	t.Run("invalid server version", func(t *testing.T) {
+		_, err := client.GetPluginCompatibility("demo", []string{"latest"})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		_, err := client.GetPluginCompatibility("unknown", nil)
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("license tier", func(t *testing.T) {
+		freeV1 := &model.Plugin{
+			DownloadURL:  "https://example.com/gated-0.1.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "gated", Name: "Gated", Version: "0.1.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+		}
+		enterpriseV2 := &model.Plugin{
+			DownloadURL:     "https://example.com/gated-0.2.0.tar.gz",
+			Manifest:        &mattermostModel.Manifest{Id: "gated", Name: "Gated", Version: "0.2.0"},
+			ReleaseStage:    model.ReleaseStageProduction,
+			RequiredLicense: model.LicenseTierEnterprise,
+		}
+		enterpriseOnly := &model.Plugin{
+			DownloadURL:     "https://example.com/enterprise-0.1.0.tar.gz",
+			Manifest:        &mattermostModel.Manifest{Id: "enterprise", Name: "Enterprise", Version: "0.1.0"},
+			ReleaseStage:    model.ReleaseStageProduction,
+			RequiredLicense: model.LicenseTierEnterprise,
+		}
+
+		client, tearDown := setupApi(t, []*model.Plugin{freeV1, enterpriseV2, enterpriseOnly})
+		defer tearDown()
+
+		matrix, err := client.GetPluginCompatibility("gated", []string{"10.5.0"})
+		require.NoError(t, err)
+		require.Equal(t, "0.2.0", matrix.LatestVersion)
+
+		teamClient := *client
+		teamClient.Headers = http.Header{"X-Mattermost-License-Tier": []string{"team"}}
+
+		matrix, err = teamClient.GetPluginCompatibility("gated", []string{"10.5.0"})
+		require.NoError(t, err)
+		require.Equal(t, "0.1.0", matrix.LatestVersion)
+		require.Equal(t, []*model.ServedVersion{{ServerVersion: "10.5.0", PluginVersion: "0.1.0"}}, matrix.Served)
+
+		_, err = teamClient.GetPluginCompatibility("enterprise", nil)
+		require.Equal(t, api.ErrNotFound, err)
+	})
+}
+
+func TestPluginChangelog(t *testing.T) {
+	pluginV1 := &model.Plugin{
+		DownloadURL:     "https://example.com/demo-0.1.0.tar.gz",
+		ReleaseNotesURL: "https://example.com/demo/releases/v0.1.0",
+		Manifest:        &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+		ReleaseStage:    model.ReleaseStageProduction,
+	}
+	pluginV2 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+		ReleaseNotes: "Adds slash commands.",
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	pluginV3 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.3.0.tar.gz",
+		ReleaseNotes: "Fixes webhooks.",
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.3.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+
+	client, tearDown := setupApi(t, []*model.Plugin{pluginV1, pluginV2, pluginV3})
+	defer tearDown()
+
+	t.Run("since running version", func(t *testing.T) {
+		changelog, err := client.GetPluginChangelog("Demo", "0.1.0", "")
+		require.NoError(t, err)
+		require.Equal(t, "demo", changelog.PluginID)
+		require.Equal(t, "0.1.0", changelog.FromVersion)
+		require.Equal(t, "0.3.0", changelog.ToVersion)
+		require.Len(t, changelog.Releases, 2)
+		require.Equal(t, "0.3.0", changelog.Releases[0].Version)
+		require.Equal(t, "0.2.0", changelog.Releases[1].Version)
+		require.Equal(t, "## 0.3.0\n\nFixes webhooks.\n\n## 0.2.0\n\nAdds slash commands.", changelog.ReleaseNotes)
+	})
+
+	t.Run("up to version", func(t *testing.T) {
+		changelog, err := client.GetPluginChangelog("demo", "", "0.2.0")
+		require.NoError(t, err)
+		require.Len(t, changelog.Releases, 2)
+		require.Equal(t, "0.2.0", changelog.Releases[0].Version)
+		require.Equal(t, "0.1.0", changelog.Releases[1].Version)
+		require.Equal(t, "https://example.com/demo/releases/v0.1.0", changelog.Releases[1].ReleaseNotesURL)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := client.GetPluginChangelog("demo", "0.3.0", "0.2.0")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+
+		_, err = client.GetPluginChangelog("demo", "latest", "")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		_, err := client.GetPluginChangelog("unknown", "", "")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+}
+
+func TestPluginDependencies(t *testing.T) {
+	jira := &model.Plugin{
+		DownloadURL:  "https://example.com/jira-3.0.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "jira", Name: "Jira", Version: "3.0.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	demo := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		Dependencies: []*model.Dependency{{PluginID: "jira", MinVersion: "3.0.0"}},
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+
+	client, tearDown := setupApi(t, []*model.Plugin{jira, demo})
+	defer tearDown()
+
+	plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage})
+	require.NoError(t, err)
+	require.Len(t, plugins, 2)
+	require.Equal(t, "demo", plugins[0].Manifest.Id)
+	require.Equal(t, []*model.Dependency{{PluginID: "jira", MinVersion: "3.0.0"}}, plugins[0].Dependencies)
+	require.Empty(t, plugins[1].Dependencies)
+}
+
+func TestPluginsContentNegotiation(t *testing.T) {
+	demo := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		Labels:       []model.Label{{Name: "Beta", Color: "#ff0000"}},
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+
+	client, tearDown := setupApi(t, []*model.Plugin{demo})
+	defer tearDown()
+
+	get := func(t *testing.T, accept string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, client.Address+"/api/v1/plugins", nil)
+		require.NoError(t, err)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "Accept", resp.Header.Get("Vary"))
+
+		return resp
+	}
+
+	for _, accept := range []string{"", "*/*", "application/json", "text/html", "application/yaml;q=0"} {
+		t.Run("json for "+accept, func(t *testing.T) {
+			resp := get(t, accept)
+			defer resp.Body.Close()
+			require.Equal(t, model.JSONMediaType, resp.Header.Get("Content-Type"))
+
+			plugins, err := model.PluginsFromReader(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{demo}, plugins)
+		})
+	}
+
+	for _, accept := range []string{"application/yaml", "text/x-yaml", "application/json;q=0.5, application/x-yaml"} {
+		t.Run("yaml for "+accept, func(t *testing.T) {
+			resp := get(t, accept)
+			defer resp.Body.Close()
+			require.Equal(t, model.YAMLMediaType, resp.Header.Get("Content-Type"))
+
+			plugins, err := model.PluginsFromYAML(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{demo}, plugins)
+		})
+	}
+
+	for _, accept := range []string{"application/msgpack", "application/vnd.msgpack, application/json;q=0.9"} {
+		t.Run("msgpack for "+accept, func(t *testing.T) {
+			resp := get(t, accept)
+			defer resp.Body.Close()
+			require.Equal(t, model.MessagePackMediaType, resp.Header.Get("Content-Type"))
+
+			plugins, err := model.PluginsFromMessagePack(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{demo}, plugins)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// maxRatingRequestSize bounds the body of a rating submission.
+const maxRatingRequestSize = 64 * 1024
+
+// initRatings registers the plugin ratings endpoints on the given router.
+func initRatings(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	apiRouter.Handle("/plugins/{id}/ratings", addContext(handleGetPluginRatings)).Methods("GET")
+	apiRouter.Handle("/plugins/{id}/ratings", addContext(restrictWrites(handleSubmitRating))).Methods("POST")
+}
+
+// resolvePlugin returns the manifest id of the given plugin if any version of it is in the store,
+// responding on failure or if it is not.
+func resolvePlugin(c *Context, w http.ResponseWriter, r *http.Request, id string) (string, bool) {
+	endSpan := traceStore(c, r, "GetPluginVersions")
+	plugins, err := c.Store.GetPluginVersions(id)
+	endSpan()
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to query plugin versions")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return "", false
+	}
+	if len(plugins) == 0 {
+		writeError(c, w, r, http.StatusNotFound)
+		return "", false
+	}
+
+	return plugins[0].Manifest.Id, true
+}
+
+// handleGetPluginRatings responds to GET /api/v1/plugins/{id}/ratings, returning the rating
+// summary and reviews of the given plugin.
+func handleGetPluginRatings(c *Context, w http.ResponseWriter, r *http.Request) {
+	id, ok := resolvePlugin(c, w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	ratings := &model.PluginRatings{PluginID: id, Summary: &model.RatingSummary{}, Reviews: []*model.Rating{}}
+	if c.Ratings != nil {
+		ratings = c.Ratings.PluginRatings(id)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, ratings)
+}
+
+// handleSubmitRating responds to POST /api/v1/plugins/{id}/ratings, recording the authenticated
+// user's rating of the given plugin and returning the plugin's updated rating summary.
+func handleSubmitRating(c *Context, w http.ResponseWriter, r *http.Request) {
+	if c.Ratings == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	userID, ok := authenticate(c, w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := resolvePlugin(c, w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	rating, err := model.RatingFromReader(http.MaxBytesReader(w, r.Body, maxRatingRequestSize))
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to decode rating")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	rating.PluginID = id
+	rating.UserID = userID
+
+	if err := rating.IsValid(); err != nil {
+		c.Logger.WithError(err).Error("invalid rating")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if err := c.Ratings.SubmitRating(rating); err != nil {
+		c.Logger.WithError(err).Error("failed to submit rating")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	purgeResponseCache(c)
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, c.Ratings.PluginRatings(id).Summary)
+}
+
+// withRatingSummaries returns copies of the given plugins annotated with their rating summaries,
+// leaving the plugins held by the store untouched.
+func withRatingSummaries(plugins []*model.Plugin, summaries map[string]*model.RatingSummary) []*model.Plugin {
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		summary, ok := summaries[plugin.Manifest.Id]
+		if !ok {
+			result = append(result, plugin)
+			continue
+		}
+
+		ratedPlugin := *plugin
+		ratedPlugin.Rating = summary
+		result = append(result, &ratedPlugin)
+	}
+
+	return result
+}
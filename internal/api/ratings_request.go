@@ -0,0 +1,7 @@
+package api
+
+// SubmitRatingRequest describes the parameters to rate a plugin.
+type SubmitRatingRequest struct {
+	Stars  int    `json:"stars"`
+	Review string `json:"review,omitempty"`
+}
@@ -0,0 +1,194 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/ratings"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRatingsApi(t *testing.T, plugins []*model.Plugin, authenticator api.Authenticator) (*api.Client, func()) {
+	logger := testlib.MakeLogger(t)
+
+	data, err := json.Marshal(plugins)
+	require.NoError(t, err)
+	store, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "ratings")
+	require.NoError(t, err)
+	aggregator, err := ratings.NewAggregator(&ratings.FileBackend{Path: filepath.Join(dir, "ratings.json")})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:         store,
+		Ratings:       aggregator,
+		Authenticator: authenticator,
+		Logger:        logger,
+	})
+	ts := httptest.NewServer(router)
+
+	return api.NewClient(ts.URL), func() {
+		ts.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRatings(t *testing.T) {
+	demoPlugin := &model.Plugin{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}
+	starterPlugin := &model.Plugin{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-starter-template",
+		DownloadURL:  "https://example.com/starter-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "starter", Name: "Starter", Version: "0.1.0"},
+	}
+
+	client, tearDown := setupRatingsApi(t, []*model.Plugin{demoPlugin, starterPlugin}, api.TokenAuthenticator{
+		"alice-token": "alice",
+		"bob-token":   "bob",
+	})
+	defer tearDown()
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		_, err := client.SubmitRating("demo", &api.SubmitRatingRequest{Stars: 5})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusUnauthorized}, err)
+
+		client.Token = "unknown"
+		defer func() { client.Token = "" }()
+		_, err = client.SubmitRating("demo", &api.SubmitRatingRequest{Stars: 5})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusUnauthorized}, err)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		client.Token = "alice-token"
+		defer func() { client.Token = "" }()
+
+		_, err := client.SubmitRating("unknown", &api.SubmitRatingRequest{Stars: 5})
+		require.Equal(t, api.ErrNotFound, err)
+
+		_, err = client.GetPluginRatings("unknown")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("invalid rating", func(t *testing.T) {
+		client.Token = "alice-token"
+		defer func() { client.Token = "" }()
+
+		_, err := client.SubmitRating("demo", &api.SubmitRatingRequest{Stars: 0})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+
+		_, err = client.SubmitRating("demo", &api.SubmitRatingRequest{Stars: 5, Review: strings.Repeat("a", model.MaxReviewLength+1)})
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	t.Run("no ratings", func(t *testing.T) {
+		pluginRatings, err := client.GetPluginRatings("demo")
+		require.NoError(t, err)
+		require.Equal(t, &model.PluginRatings{
+			PluginID: "demo",
+			Summary:  &model.RatingSummary{},
+			Reviews:  []*model.Rating{},
+		}, pluginRatings)
+
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: -1})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPlugin, starterPlugin}, plugins)
+	})
+
+	t.Run("submit ratings", func(t *testing.T) {
+		client.Token = "alice-token"
+		summary, err := client.SubmitRating("demo", &api.SubmitRatingRequest{Stars: 5, Review: "Great."})
+		require.NoError(t, err)
+		require.Equal(t, &model.RatingSummary{Average: 5, Count: 1}, summary)
+
+		client.Token = "bob-token"
+		defer func() { client.Token = "" }()
+		summary, err = client.SubmitRating("demo", &api.SubmitRatingRequest{Stars: 2})
+		require.NoError(t, err)
+		require.Equal(t, &model.RatingSummary{Average: 3.5, Count: 2}, summary)
+
+		pluginRatings, err := client.GetPluginRatings("demo")
+		require.NoError(t, err)
+		require.Equal(t, &model.RatingSummary{Average: 3.5, Count: 2}, pluginRatings.Summary)
+		require.Len(t, pluginRatings.Reviews, 1)
+		require.Equal(t, "alice", pluginRatings.Reviews[0].UserID)
+		require.Equal(t, "Great.", pluginRatings.Reviews[0].Review)
+	})
+
+	t.Run("listing includes rating summaries", func(t *testing.T) {
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: -1})
+		require.NoError(t, err)
+		require.Len(t, plugins, 2)
+		require.Equal(t, "demo", plugins[0].Manifest.Id)
+		require.Equal(t, &model.RatingSummary{Average: 3.5, Count: 2}, plugins[0].Rating)
+		require.Equal(t, "starter", plugins[1].Manifest.Id)
+		require.Nil(t, plugins[1].Rating)
+	})
+}
+
+func TestRatingsDisabled(t *testing.T) {
+	plugins := []*model.Plugin{{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}}
+
+	client, tearDown := setupApi(t, plugins)
+	defer tearDown()
+	client.Token = "token"
+
+	_, err := client.SubmitRating("demo", &api.SubmitRatingRequest{Stars: 5})
+	require.Equal(t, api.ErrNotFound, err)
+
+	pluginRatings, err := client.GetPluginRatings("demo")
+	require.NoError(t, err)
+	require.Equal(t, &model.RatingSummary{}, pluginRatings.Summary)
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	authenticator, err := api.TokenAuthenticatorFromReader(strings.NewReader(`{"secret": "alice"}`))
+	require.NoError(t, err)
+
+	request := func(header string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		return r
+	}
+
+	userID, ok := authenticator.Authenticate(request("Bearer secret"))
+	require.True(t, ok)
+	require.Equal(t, "alice", userID)
+
+	for _, header := range []string{"", "Bearer ", "Bearer other", "Basic secret", "secret"} {
+		_, ok := authenticator.Authenticate(request(header))
+		require.False(t, ok, header)
+	}
+
+	_, err = api.TokenAuthenticatorFromReader(strings.NewReader(`{"secret": ""}`))
+	require.EqualError(t, err, "tokens and user ids must not be empty")
+
+	_, err = api.TokenAuthenticatorFromReader(strings.NewReader(`[]`))
+	require.Error(t, err)
+}
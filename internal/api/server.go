@@ -0,0 +1,64 @@
+package api
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+)
+
+// ServerOptions tunes the connections accepted by an http.Server, such as for the few long-lived
+// connections of a CDN or the many short-lived connections of Mattermost servers.
+type ServerOptions struct {
+	// ReadTimeout bounds the time to read a request, including its body, or is 0 for no limit.
+	ReadTimeout time.Duration
+	// WriteTimeout bounds the time to write a response, or is 0 for no limit.
+	WriteTimeout time.Duration
+	// IdleTimeout bounds the time a kept-alive connection waits for its next request, or is 0
+	// for ReadTimeout.
+	IdleTimeout time.Duration
+	// DisableKeepAlives closes each connection after a single request.
+	DisableKeepAlives bool
+	// DisableHTTP2 serves only HTTP/1.1 over TLS. HTTP/2 is never served without TLS.
+	DisableHTTP2 bool
+	// MaxConcurrentStreams bounds the concurrent requests of each HTTP/2 connection, or is 0 for
+	// the default of 250.
+	MaxConcurrentStreams uint32
+}
+
+// DefaultServerOptions suit clients connecting directly to the server.
+var DefaultServerOptions = ServerOptions{
+	ReadTimeout:  10 * time.Second,
+	WriteTimeout: 10 * time.Second,
+	IdleTimeout:  60 * time.Second,
+}
+
+// ConfigureServer applies the given options to the given server, which must already have any
+// TLSConfig it is served with.
+func ConfigureServer(srv *http.Server, options ServerOptions) error {
+	if options.ReadTimeout < 0 || options.WriteTimeout < 0 || options.IdleTimeout < 0 {
+		return errors.New("server timeouts must not be negative")
+	}
+
+	srv.ReadTimeout = options.ReadTimeout
+	srv.WriteTimeout = options.WriteTimeout
+	srv.IdleTimeout = options.IdleTimeout
+	srv.SetKeepAlivesEnabled(!options.DisableKeepAlives)
+
+	if options.DisableHTTP2 {
+		// A non-nil map stops the server from enabling HTTP/2 on its own.
+		srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		return nil
+	}
+
+	if err := http2.ConfigureServer(srv, &http2.Server{
+		MaxConcurrentStreams: options.MaxConcurrentStreams,
+		IdleTimeout:          options.IdleTimeout,
+	}); err != nil {
+		return errors.Wrap(err, "failed to configure http/2")
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package api_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureServer(t *testing.T) {
+	// serve starts a TLS server configured with the given options, returning the response to a
+	// request of it and a function stopping it.
+	serve := func(t *testing.T, options api.ServerOptions) (*http.Response, func()) {
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		ts.Config.TLSConfig = &tls.Config{}
+		require.NoError(t, api.ConfigureServer(ts.Config, options))
+		ts.TLS = ts.Config.TLSConfig
+		ts.StartTLS()
+
+		transport := &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(ts.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp, ts.Close
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		resp, tearDown := serve(t, api.DefaultServerOptions)
+		defer tearDown()
+
+		require.Equal(t, 2, resp.ProtoMajor)
+	})
+
+	t.Run("http/2 disabled", func(t *testing.T) {
+		resp, tearDown := serve(t, api.ServerOptions{DisableHTTP2: true})
+		defer tearDown()
+
+		require.Equal(t, 1, resp.ProtoMajor)
+		require.False(t, resp.Close)
+	})
+
+	t.Run("keep-alives disabled", func(t *testing.T) {
+		resp, tearDown := serve(t, api.ServerOptions{DisableHTTP2: true, DisableKeepAlives: true})
+		defer tearDown()
+
+		require.True(t, resp.Close)
+	})
+
+	t.Run("timeouts", func(t *testing.T) {
+		srv := &http.Server{}
+		require.NoError(t, api.ConfigureServer(srv, api.ServerOptions{
+			ReadTimeout:  time.Second,
+			WriteTimeout: 2 * time.Second,
+			IdleTimeout:  3 * time.Second,
+		}))
+		require.Equal(t, time.Second, srv.ReadTimeout)
+		require.Equal(t, 2*time.Second, srv.WriteTimeout)
+		require.Equal(t, 3*time.Second, srv.IdleTimeout)
+
+		require.Error(t, api.ConfigureServer(&http.Server{}, api.ServerOptions{ReadTimeout: -time.Second}))
+	})
+}
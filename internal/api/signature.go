@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// armoredPrefix identifies ASCII-armored OpenPGP data, as opposed to its binary form.
+var armoredPrefix = []byte("-----BEGIN")
+
+// ReadPublicKeys parses the given armored or binary OpenPGP public keys into a single keyring.
+func ReadPublicKeys(readers ...io.Reader) (openpgp.EntityList, error) {
+	var keyring openpgp.EntityList
+	for i, reader := range readers {
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read public key %d", i)
+		}
+
+		var entities openpgp.EntityList
+		if bytes.HasPrefix(bytes.TrimSpace(data), armoredPrefix) {
+			entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+		} else {
+			entities, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse public key %d", i)
+		}
+
+		keyring = append(keyring, entities...)
+	}
+
+	return keyring, nil
+}
+
+// VerifyPluginSignature verifies the given bundle against a base64-encoded detached signature, as
+// recorded in model.Plugin.Signature, using the given keyring.
+func VerifyPluginSignature(bundle io.Reader, signature string, keyring openpgp.EntityList) error {
+	if signature == "" {
+		return errors.New("plugin has no signature")
+	}
+	if len(keyring) == 0 {
+		return errors.New("no public keys to verify signature against")
+	}
+
+	signatureData, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode signature")
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(signatureData), armoredPrefix) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, bundle, bytes.NewReader(signatureData))
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyring, bundle, bytes.NewReader(signatureData))
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to verify signature")
+	}
+
+	return nil
+}
+
+// SignatureKeyID returns the hex-encoded id of the key that issued the given base64-encoded
+// detached signature.
+func SignatureKeyID(signature string) (string, error) {
+	keyID, err := signatureIssuer(signature)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%016x", keyID), nil
+}
+
+// signatureIssuer parses the given base64-encoded detached signature for its issuing key id.
+func signatureIssuer(signature string) (uint64, error) {
+	signatureData, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to decode signature")
+	}
+
+	var reader io.Reader = bytes.NewReader(signatureData)
+	if bytes.HasPrefix(bytes.TrimSpace(signatureData), armoredPrefix) {
+		block, err := armor.Decode(reader)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to decode armored signature")
+		}
+		reader = block.Body
+	}
+
+	p, err := packet.Read(reader)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read signature")
+	}
+
+	switch sig := p.(type) {
+	case *packet.Signature:
+		if sig.IssuerKeyId == nil {
+			return 0, errors.New("signature has no issuer")
+		}
+		return *sig.IssuerKeyId, nil
+	case *packet.SignatureV3:
+		return sig.IssuerKeyId, nil
+	default:
+		return 0, errors.New("not a signature")
+	}
+}
+
+// selectSignature picks the first of the given signatures issued by a key in the keyring.
+func selectSignature(signatures []*model.Signature, keyring openpgp.EntityList) (string, error) {
+	if len(signatures) == 0 {
+		return "", errors.New("plugin has no signature")
+	}
+
+	for _, signature := range signatures {
+		keyID, err := signatureIssuer(signature.Signature)
+		if err != nil {
+			continue
+		}
+
+		if len(keyring.KeysById(keyID)) > 0 {
+			return signature.Signature, nil
+		}
+	}
+
+	return "", errors.New("plugin has no signature from a trusted key")
+}
@@ -0,0 +1,195 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func makeKey(t *testing.T) *openpgp.Entity {
+	entity, err := openpgp.NewEntity("Marketplace Test", "", "test@example.com", nil)
+	require.NoError(t, err)
+
+	return entity
+}
+
+func sign(t *testing.T, entity *openpgp.Entity, data []byte, armored bool) string {
+	var signature bytes.Buffer
+	if armored {
+		require.NoError(t, openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(data), nil))
+	} else {
+		require.NoError(t, openpgp.DetachSign(&signature, entity, bytes.NewReader(data), nil))
+	}
+
+	return base64.StdEncoding.EncodeToString(signature.Bytes())
+}
+
+func TestReadPublicKeys(t *testing.T) {
+	entity := makeKey(t)
+
+	t.Run("binary", func(t *testing.T) {
+		var publicKey bytes.Buffer
+		require.NoError(t, entity.Serialize(&publicKey))
+
+		keyring, err := api.ReadPublicKeys(&publicKey)
+		require.NoError(t, err)
+		require.Len(t, keyring, 1)
+	})
+
+	t.Run("armored", func(t *testing.T) {
+		var publicKey bytes.Buffer
+		armorWriter, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+		require.NoError(t, err)
+		require.NoError(t, entity.Serialize(armorWriter))
+		require.NoError(t, armorWriter.Close())
+
+		keyring, err := api.ReadPublicKeys(&publicKey)
+		require.NoError(t, err)
+		require.Len(t, keyring, 1)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := api.ReadPublicKeys(bytes.NewReader([]byte("invalid")))
+		require.Error(t, err)
+	})
+}
+
+func TestVerifyPluginSignature(t *testing.T) {
+	entity := makeKey(t)
+	otherEntity := makeKey(t)
+	bundle := []byte("plugin bundle contents")
+
+	t.Run("binary signature", func(t *testing.T) {
+		err := api.VerifyPluginSignature(bytes.NewReader(bundle), sign(t, entity, bundle, false), openpgp.EntityList{entity})
+		require.NoError(t, err)
+	})
+
+	t.Run("armored signature", func(t *testing.T) {
+		err := api.VerifyPluginSignature(bytes.NewReader(bundle), sign(t, entity, bundle, true), openpgp.EntityList{entity})
+		require.NoError(t, err)
+	})
+
+	t.Run("any of multiple keys", func(t *testing.T) {
+		err := api.VerifyPluginSignature(bytes.NewReader(bundle), sign(t, entity, bundle, false), openpgp.EntityList{otherEntity, entity})
+		require.NoError(t, err)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		err := api.VerifyPluginSignature(bytes.NewReader(bundle), sign(t, entity, bundle, false), openpgp.EntityList{otherEntity})
+		require.Error(t, err)
+	})
+
+	t.Run("tampered bundle", func(t *testing.T) {
+		err := api.VerifyPluginSignature(bytes.NewReader([]byte("tampered")), sign(t, entity, bundle, false), openpgp.EntityList{entity})
+		require.Error(t, err)
+	})
+
+	t.Run("no signature", func(t *testing.T) {
+		err := api.VerifyPluginSignature(bytes.NewReader(bundle), "", openpgp.EntityList{entity})
+		require.EqualError(t, err, "plugin has no signature")
+	})
+
+	t.Run("no keys", func(t *testing.T) {
+		err := api.VerifyPluginSignature(bytes.NewReader(bundle), sign(t, entity, bundle, false), nil)
+		require.EqualError(t, err, "no public keys to verify signature against")
+	})
+}
+
+func TestSignatureKeyID(t *testing.T) {
+	entity := makeKey(t)
+	bundle := []byte("plugin bundle contents")
+
+	keyID, err := api.SignatureKeyID(sign(t, entity, bundle, false))
+	require.NoError(t, err)
+	require.Equal(t, entity.PrimaryKey.KeyIdString(), strings.ToUpper(keyID))
+
+	keyID, err = api.SignatureKeyID(sign(t, entity, bundle, true))
+	require.NoError(t, err)
+	require.Equal(t, entity.PrimaryKey.KeyIdString(), strings.ToUpper(keyID))
+
+	_, err = api.SignatureKeyID("c2lnbmF0dXJl")
+	require.Error(t, err)
+}
+
+func TestDownloadPluginSignature(t *testing.T) {
+	entity := makeKey(t)
+	bundle := []byte("plugin bundle contents")
+
+	downloadURL, tearDownBundleServer := setupBundleServer(t, bundle)
+	defer tearDownBundleServer()
+
+	t.Run("valid signature", func(t *testing.T) {
+		client := api.NewClient("")
+		client.PublicKeys = openpgp.EntityList{entity}
+
+		var buf bytes.Buffer
+		err := client.DownloadPlugin(&model.Plugin{
+			DownloadURL: downloadURL,
+			Signature:   sign(t, entity, bundle, false),
+		}, &buf)
+		require.NoError(t, err)
+		require.Equal(t, bundle, buf.Bytes())
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		client := api.NewClient("")
+		client.PublicKeys = openpgp.EntityList{entity}
+
+		var buf bytes.Buffer
+		err := client.DownloadPlugin(&model.Plugin{
+			DownloadURL: downloadURL,
+			Signature:   sign(t, entity, []byte("other contents"), false),
+		}, &buf)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to verify signature")
+	})
+
+	t.Run("multiple signatures", func(t *testing.T) {
+		otherEntity := makeKey(t)
+
+		client := api.NewClient("")
+		client.PublicKeys = openpgp.EntityList{entity}
+
+		var buf bytes.Buffer
+		err := client.DownloadPlugin(&model.Plugin{
+			DownloadURL: downloadURL,
+			Signatures: []*model.Signature{
+				{Signature: sign(t, otherEntity, bundle, false), PublicKeyHash: "other"},
+				{Signature: sign(t, entity, bundle, true), PublicKeyHash: "trusted"},
+			},
+		}, &buf)
+		require.NoError(t, err)
+		require.Equal(t, bundle, buf.Bytes())
+	})
+
+	t.Run("no signature from a trusted key", func(t *testing.T) {
+		otherEntity := makeKey(t)
+
+		client := api.NewClient("")
+		client.PublicKeys = openpgp.EntityList{entity}
+
+		err := client.DownloadPlugin(&model.Plugin{
+			DownloadURL: downloadURL,
+			Signatures: []*model.Signature{
+				{Signature: sign(t, otherEntity, bundle, false), PublicKeyHash: "other"},
+			},
+		}, &bytes.Buffer{})
+		require.EqualError(t, err, "plugin has no signature from a trusted key")
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		client := api.NewClient("")
+		client.PublicKeys = openpgp.EntityList{entity}
+
+		var buf bytes.Buffer
+		err := client.DownloadPlugin(&model.Plugin{DownloadURL: downloadURL}, &buf)
+		require.EqualError(t, err, "plugin has no signature")
+	})
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// initSigningKeys registers the signing key distribution endpoint on the given router.
+func initSigningKeys(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	apiRouter.Handle("/signing-keys", addContext(handleGetSigningKeys)).Methods("GET")
+}
+
+// handleGetSigningKeys responds to GET /api/v1/signing-keys, returning the public keys whose
+// signatures the marketplace publishes.
+func handleGetSigningKeys(c *Context, w http.ResponseWriter, r *http.Request) {
+	if c.SigningKeys == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, c.SigningKeys)
+}
+
+// DescribePublicKeys describes each key of the given keyring for distribution by the marketplace,
+// taking its validity window from its self-signature. Private keys are never described.
+func DescribePublicKeys(keyring openpgp.EntityList) ([]*model.PublicKey, error) {
+	publicKeys := []*model.PublicKey{}
+	for _, entity := range keyring {
+		var armored bytes.Buffer
+		armorWriter, err := armor.Encode(&armored, openpgp.PublicKeyType, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to armor public key")
+		}
+		if err := entity.Serialize(armorWriter); err != nil {
+			return nil, errors.Wrapf(err, "failed to serialize public key %s", entity.PrimaryKey.KeyIdString())
+		}
+		if err := armorWriter.Close(); err != nil {
+			return nil, errors.Wrap(err, "failed to armor public key")
+		}
+
+		publicKey := &model.PublicKey{
+			PublicKeyHash: entity.PrimaryKey.KeyIdString(),
+			Fingerprint:   hex.EncodeToString(entity.PrimaryKey.Fingerprint[:]),
+			CreatedAt:     entity.PrimaryKey.CreationTime.UTC(),
+			Revoked:       len(entity.Revocations) > 0,
+			PublicKey:     armored.String(),
+		}
+		if identity := primaryIdentity(entity); identity != nil && identity.SelfSignature.KeyLifetimeSecs != nil && *identity.SelfSignature.KeyLifetimeSecs > 0 {
+			expiresAt := publicKey.CreatedAt.Add(time.Duration(*identity.SelfSignature.KeyLifetimeSecs) * time.Second)
+			publicKey.ExpiresAt = &expiresAt
+		}
+
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	return publicKeys, nil
+}
+
+// primaryIdentity returns the self-signed identity of the given entity marked primary, or else
+// the first by name, if any.
+func primaryIdentity(entity *openpgp.Entity) *openpgp.Identity {
+	names := make([]string, 0, len(entity.Identities))
+	for name := range entity.Identities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var first *openpgp.Identity
+	for _, name := range names {
+		identity := entity.Identities[name]
+		if identity.SelfSignature == nil {
+			continue
+		}
+		if identity.SelfSignature.IsPrimaryId != nil && *identity.SelfSignature.IsPrimaryId {
+			return identity
+		}
+		if first == nil {
+			first = identity
+		}
+	}
+
+	return first
+}
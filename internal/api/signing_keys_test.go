@@ -0,0 +1,105 @@
+package api_test
+
+import (
+	"encoding/hex"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestSigningKeys(t *testing.T) {
+	setup := func(t *testing.T, signingKeys []*model.PublicKey) (*api.Client, func()) {
+		router := mux.NewRouter()
+		api.Register(router, &api.Context{
+			Store:       makeStore(t),
+			SigningKeys: signingKeys,
+			Logger:      testlib.MakeLogger(t),
+		})
+		ts := httptest.NewServer(router)
+
+		return api.NewClient(ts.URL), ts.Close
+	}
+
+	fingerprint := func(entity *openpgp.Entity) string {
+		return hex.EncodeToString(entity.PrimaryKey.Fingerprint[:])
+	}
+
+	entity := makeKey(t)
+	otherEntity := makeKey(t)
+	describe := func(t *testing.T, entities ...*openpgp.Entity) []*model.PublicKey {
+		signingKeys, err := api.DescribePublicKeys(entities)
+		require.NoError(t, err)
+		return signingKeys
+	}
+
+	t.Run("not served", func(t *testing.T) {
+		client, tearDown := setup(t, nil)
+		defer tearDown()
+
+		_, err := client.GetSigningKeys()
+		require.Error(t, err)
+	})
+
+	t.Run("served", func(t *testing.T) {
+		client, tearDown := setup(t, describe(t, entity, otherEntity))
+		defer tearDown()
+
+		signingKeys, err := client.GetSigningKeys()
+		require.NoError(t, err)
+		require.Len(t, signingKeys, 2)
+		require.Equal(t, entity.PrimaryKey.KeyIdString(), signingKeys[0].PublicKeyHash)
+		require.Equal(t, fingerprint(entity), signingKeys[0].Fingerprint)
+		require.Equal(t, entity.PrimaryKey.CreationTime.Unix(), signingKeys[0].CreatedAt.Unix())
+		require.Nil(t, signingKeys[0].ExpiresAt)
+		require.False(t, signingKeys[0].Revoked)
+		require.NotContains(t, signingKeys[0].PublicKey, "PRIVATE")
+
+		keyring, err := api.ReadPublicKeys(strings.NewReader(signingKeys[0].PublicKey))
+		require.NoError(t, err)
+		require.Len(t, keyring, 1)
+		require.Nil(t, keyring[0].PrivateKey)
+	})
+
+	t.Run("pinned", func(t *testing.T) {
+		client, tearDown := setup(t, describe(t, entity, otherEntity))
+		defer tearDown()
+
+		require.NoError(t, client.PinSigningKeys(fingerprint(entity)))
+		require.Len(t, client.PublicKeys, 1)
+		require.Equal(t, entity.PrimaryKey.KeyId, client.PublicKeys[0].PrimaryKey.KeyId)
+	})
+
+	t.Run("no pinned key served", func(t *testing.T) {
+		client, tearDown := setup(t, describe(t, otherEntity))
+		defer tearDown()
+
+		require.Error(t, client.PinSigningKeys(fingerprint(entity)))
+		require.Empty(t, client.PublicKeys)
+	})
+
+	t.Run("revoked key not pinned", func(t *testing.T) {
+		signingKeys := describe(t, entity)
+		signingKeys[0].Revoked = true
+		client, tearDown := setup(t, signingKeys)
+		defer tearDown()
+
+		require.Error(t, client.PinSigningKeys(fingerprint(entity)))
+	})
+
+	t.Run("key not matching its fingerprint", func(t *testing.T) {
+		signingKeys := describe(t, otherEntity)
+		signingKeys[0].Fingerprint = fingerprint(entity)
+		client, tearDown := setup(t, signingKeys)
+		defer tearDown()
+
+		require.Error(t, client.PinSigningKeys(fingerprint(entity)))
+		require.Empty(t, client.PublicKeys)
+	})
+}
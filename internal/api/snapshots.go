@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// initSnapshots registers the catalog snapshot administration endpoints on the given router.
+func initSnapshots(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	snapshotsRouter := apiRouter.PathPrefix("/snapshots").Subrouter()
+	snapshotsRouter.Handle("", addContext(handleGetSnapshots)).Methods("GET")
+	snapshotsRouter.Handle("/growth", addContext(handleGetCatalogGrowth)).Methods("GET")
+	snapshotsRouter.Handle("/{id:[0-9]+}/rollback", addContext(restrictWrites(handleRollbackSnapshot))).Methods("POST")
+}
+
+// authenticateSnapshots identifies the moderator making a snapshot administration request,
+// responding on failure, if the catalog retains no snapshots, or if the user is not a moderator.
+func authenticateSnapshots(c *Context, w http.ResponseWriter, r *http.Request) (Snapshots, bool) {
+	snapshots, ok := unwrapStore(c.Store).(Snapshots)
+	if !ok {
+		writeError(c, w, r, http.StatusNotFound)
+		return nil, false
+	}
+
+	if _, ok := authenticateModerator(c, w, r); !ok {
+		return nil, false
+	}
+
+	return snapshots, true
+}
+
+// handleGetSnapshots responds to GET /api/v1/snapshots, returning the retained catalog snapshots,
+// most recent first.
+func handleGetSnapshots(c *Context, w http.ResponseWriter, r *http.Request) {
+	snapshots, ok := authenticateSnapshots(c, w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, snapshots.Snapshots())
+}
+
+// handleGetCatalogGrowth responds to GET /api/v1/snapshots/growth, returning the composition of
+// each retained catalog snapshot, oldest first, and the growth over them.
+func handleGetCatalogGrowth(c *Context, w http.ResponseWriter, r *http.Request) {
+	growth, ok := unwrapStore(c.Store).(Growth)
+	if !ok {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+	if _, ok := authenticateModerator(c, w, r); !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, growth.Growth())
+}
+
+// handleRollbackSnapshot responds to POST /api/v1/snapshots/{id}/rollback, serving the catalog of
+// the given snapshot in place of the current one and returning the snapshot recording the rollback.
+func handleRollbackSnapshot(c *Context, w http.ResponseWriter, r *http.Request) {
+	snapshots, ok := authenticateSnapshots(c, w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	snapshot, changes, err := snapshots.Rollback(id)
+	if errors.Cause(err) == catalog.ErrUnknownSnapshot {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to roll back catalog")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	recordTombstones(c, changes, model.TombstoneReasonRolledBack)
+	c.Logger.WithFields(logrus.Fields{
+		"snapshot": id,
+		"changes":  len(changes),
+	}).Warn("Rolled back catalog")
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, snapshot)
+}
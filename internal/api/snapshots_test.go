@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshots(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	makeStore := func(plugins ...*model.Plugin) *store.Store {
+		if plugins == nil {
+			plugins = []*model.Plugin{}
+		}
+		data, err := json.Marshal(plugins)
+		require.NoError(t, err)
+		pluginStore, err := store.New(bytes.NewReader(data), logger)
+		require.NoError(t, err)
+		return pluginStore
+	}
+
+	pluginCatalog := catalog.New(makeStore(&model.Plugin{
+		Manifest: &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}))
+	pluginCatalog.Replace(makeStore())
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store: pluginCatalog,
+		Authenticator: api.TokenAuthenticator{
+			"alice-token":     "alice",
+			"moderator-token": "moderator",
+		},
+		Moderators: map[string]bool{"moderator": true},
+		Logger:     logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	clientFor := func(token string) *api.Client {
+		client := api.NewClient(ts.URL)
+		client.Token = token
+		return client
+	}
+	moderator := clientFor("moderator-token")
+
+	t.Run("not a moderator", func(t *testing.T) {
+		_, err := clientFor("alice-token").GetSnapshots()
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+
+		_, err = clientFor("alice-token").RollbackSnapshot(1)
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("list snapshots", func(t *testing.T) {
+		snapshots, err := moderator.GetSnapshots()
+		require.NoError(t, err)
+		require.Len(t, snapshots, 2)
+		require.EqualValues(t, 2, snapshots[0].ID)
+		require.True(t, snapshots[0].Current)
+		require.Equal(t, model.SnapshotSourceInitial, snapshots[1].Source)
+	})
+
+	t.Run("growth", func(t *testing.T) {
+		_, err := clientFor("alice-token").GetCatalogGrowth()
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+
+		growth, err := moderator.GetCatalogGrowth()
+		require.NoError(t, err)
+		require.Len(t, growth.Snapshots, 2)
+		require.EqualValues(t, 1, growth.Snapshots[0].SnapshotID)
+		require.Equal(t, 1, growth.Snapshots[0].Entries)
+		require.Positive(t, growth.Snapshots[0].Bytes)
+		require.Equal(t, 0, growth.Snapshots[1].Entries)
+	})
+
+	t.Run("unknown snapshot", func(t *testing.T) {
+		_, err := moderator.RollbackSnapshot(42)
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		snapshot, err := moderator.RollbackSnapshot(1)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, snapshot.ID)
+		require.EqualValues(t, 1, snapshot.RestoredFrom)
+		require.Equal(t, model.SnapshotSourceRollback, snapshot.Source)
+
+		plugins, err := moderator.GetPlugins(&api.GetPluginsRequest{PerPage: 10})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+	})
+}
+
+func TestSnapshotsUnsupported(t *testing.T) {
+	client, tearDown := setupApi(t, []*model.Plugin{})
+	defer tearDown()
+
+	_, err := client.GetSnapshots()
+	require.Equal(t, api.ErrNotFound, err)
+
+	_, err = client.GetCatalogGrowth()
+	require.Equal(t, api.ErrNotFound, err)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// initStaging registers the blue/green publishing endpoints on the given router.
+func initStaging(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	stagingRouter := apiRouter.PathPrefix("/staging").Subrouter()
+	stagingRouter.Handle("", addContext(handleGetStaged)).Methods("GET")
+	stagingRouter.Handle("", addContext(restrictWrites(handleStage))).Methods("PUT")
+	stagingRouter.Handle("", addContext(restrictWrites(handleDiscardStaged))).Methods("DELETE")
+	stagingRouter.Handle("/validate", addContext(restrictWrites(handleValidateStaged))).Methods("POST")
+	stagingRouter.Handle("/promote", addContext(restrictWrites(handlePromoteStaged))).Methods("POST")
+}
+
+// authenticateStaging identifies the moderator making a publishing request, responding on
+// failure, if the catalog accepts no candidates, or if the user is not a moderator.
+func authenticateStaging(c *Context, w http.ResponseWriter, r *http.Request) (Staging, bool) {
+	staging, ok := unwrapStore(c.Store).(Staging)
+	if !ok {
+		writeError(c, w, r, http.StatusNotFound)
+		return nil, false
+	}
+
+	if _, ok := authenticateModerator(c, w, r); !ok {
+		return nil, false
+	}
+
+	return staging, true
+}
+
+// writeStagingError responds to a failed publishing request, reporting no staged candidate as not
+// found.
+func writeStagingError(c *Context, w http.ResponseWriter, r *http.Request, err error, message string) {
+	if errors.Cause(err) == catalog.ErrNothingStaged {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	c.Logger.WithError(err).Error(message)
+	writeError(c, w, r, http.StatusInternalServerError)
+}
+
+// handleStage responds to PUT /api/v1/staging, staging the plugins in the request body as a
+// candidate to replace the catalog and returning its report. A candidate that cannot be parsed is
+// rejected with its problem, leaving any previous candidate staged.
+func handleStage(c *Context, w http.ResponseWriter, r *http.Request) {
+	staging, ok := authenticateStaging(c, w, r)
+	if !ok {
+		return
+	}
+
+	report, err := staging.Stage(r.Body, c.Logger)
+	if err != nil {
+		c.Logger.WithError(err).Warn("Rejected malformed candidate")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		outputJSON(c, w, &model.StagingReport{Problems: []string{err.Error()}})
+		return
+	}
+	c.Logger.WithField("plugins", report.Plugins).Info("Staged candidate")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	outputJSON(c, w, report)
+}
+
+// handleGetStaged responds to GET /api/v1/staging, returning the report of the staged candidate.
+func handleGetStaged(c *Context, w http.ResponseWriter, r *http.Request) {
+	staging, ok := authenticateStaging(c, w, r)
+	if !ok {
+		return
+	}
+
+	report, err := staging.Staged()
+	if err != nil {
+		writeStagingError(c, w, r, err, "failed to query staged candidate")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, report)
+}
+
+// handleValidateStaged responds to POST /api/v1/staging/validate, validating and running smoke
+// queries against the staged candidate and returning its report.
+func handleValidateStaged(c *Context, w http.ResponseWriter, r *http.Request) {
+	staging, ok := authenticateStaging(c, w, r)
+	if !ok {
+		return
+	}
+
+	report, err := staging.Validate()
+	if err != nil {
+		writeStagingError(c, w, r, err, "failed to validate staged candidate")
+		return
+	}
+	c.Logger.WithField("problems", len(report.Problems)).Info("Validated staged candidate")
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, report)
+}
+
+// handlePromoteStaged responds to POST /api/v1/staging/promote, atomically serving the staged
+// candidate in place of the catalog and returning the snapshot retaining it. A candidate that has
+// not passed validation is refused as a conflict.
+func handlePromoteStaged(c *Context, w http.ResponseWriter, r *http.Request) {
+	staging, ok := authenticateStaging(c, w, r)
+	if !ok {
+		return
+	}
+
+	snapshot, changes, err := staging.Promote()
+	if errors.Cause(err) == catalog.ErrNotValidated {
+		writeError(c, w, r, http.StatusConflict)
+		return
+	} else if err != nil {
+		writeStagingError(c, w, r, err, "failed to promote staged candidate")
+		return
+	}
+	recordTombstones(c, changes, model.TombstoneReasonPromoted)
+	c.Logger.WithFields(logrus.Fields{
+		"snapshot": snapshot.ID,
+		"changes":  len(changes),
+	}).Info("Promoted staged candidate")
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, snapshot)
+}
+
+// handleDiscardStaged responds to DELETE /api/v1/staging, dropping the staged candidate.
+func handleDiscardStaged(c *Context, w http.ResponseWriter, r *http.Request) {
+	staging, ok := authenticateStaging(c, w, r)
+	if !ok {
+		return
+	}
+
+	if err := staging.Discard(); err != nil {
+		writeStagingError(c, w, r, err, "failed to discard staged candidate")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
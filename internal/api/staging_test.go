@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaging(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	initialStore, err := store.New(strings.NewReader(`[]`), logger)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store: catalog.New(initialStore),
+		Authenticator: api.TokenAuthenticator{
+			"alice-token":     "alice",
+			"moderator-token": "moderator",
+		},
+		Moderators: map[string]bool{"moderator": true},
+		Logger:     logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	clientFor := func(token string) *api.Client {
+		client := api.NewClient(ts.URL)
+		client.Token = token
+		return client
+	}
+	moderator := clientFor("moderator-token")
+
+	candidate := func(downloadURL string) *bytes.Reader {
+		data, err := json.Marshal([]*model.Plugin{{
+			DownloadURL: downloadURL,
+			Manifest:    &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+		}})
+		require.NoError(t, err)
+		return bytes.NewReader(data)
+	}
+
+	t.Run("not a moderator", func(t *testing.T) {
+		_, err := clientFor("alice-token").StageCatalog(candidate("https://example.com/demo.tar.gz"))
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+
+		_, err = clientFor("alice-token").PromoteStagedCatalog()
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("nothing staged", func(t *testing.T) {
+		_, err := moderator.GetStagedCatalog()
+		require.Equal(t, api.ErrNotFound, err)
+
+		_, err = moderator.PromoteStagedCatalog()
+		require.Equal(t, api.ErrNotFound, err)
+
+		require.Equal(t, api.ErrNotFound, moderator.DiscardStagedCatalog())
+	})
+
+	t.Run("malformed candidate", func(t *testing.T) {
+		_, err := moderator.StageCatalog(strings.NewReader(`{`))
+		require.IsType(t, &api.RejectedCandidateError{}, err)
+		require.NotEmpty(t, err.(*api.RejectedCandidateError).Problems)
+	})
+
+	t.Run("unvalidated candidate", func(t *testing.T) {
+		report, err := moderator.StageCatalog(candidate(""))
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Added)
+
+		_, err = moderator.PromoteStagedCatalog()
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusConflict}, err)
+
+		report, err = moderator.ValidateStagedCatalog()
+		require.NoError(t, err)
+		require.Len(t, report.Problems, 1)
+
+		_, err = moderator.PromoteStagedCatalog()
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusConflict}, err)
+
+		require.NoError(t, moderator.DiscardStagedCatalog())
+	})
+
+	t.Run("promote", func(t *testing.T) {
+		_, err := moderator.StageCatalog(candidate("https://example.com/demo.tar.gz"))
+		require.NoError(t, err)
+
+		plugins, err := moderator.GetPlugins(&api.GetPluginsRequest{PerPage: 10})
+		require.NoError(t, err)
+		require.Empty(t, plugins)
+
+		report, err := moderator.ValidateStagedCatalog()
+		require.NoError(t, err)
+		require.True(t, report.Passed())
+
+		snapshot, err := moderator.PromoteStagedCatalog()
+		require.NoError(t, err)
+		require.Equal(t, model.SnapshotSourcePromoted, snapshot.Source)
+
+		plugins, err = moderator.GetPlugins(&api.GetPluginsRequest{PerPage: 10})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+	})
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// maxInstallEventSize bounds the body of a reported installation.
+const maxInstallEventSize = 4 * 1024
+
+// initStats registers the download statistics and installation telemetry endpoints on the given
+// routers.
+func initStats(rootRouter, apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	apiRouter.Handle("/stats", addContext(handleGetMarketplaceStats)).Methods("GET")
+	apiRouter.Handle("/plugins/{id}/stats", addContext(handleGetPluginStats)).Methods("GET")
+	apiRouter.Handle("/telemetry/install", addContext(handleReportInstall)).Methods("POST")
+	rootRouter.Handle("/metrics", addContext(handleGetMetrics)).Methods("GET")
+}
+
+// handleReportInstall responds to POST /api/v1/telemetry/install, counting a Mattermost server's
+// anonymous report of installing or upgrading a plugin towards the plugin's adoption.
+//
+// The endpoint is open to every server, so repeated reports of the same event by a server, known
+// by its id or else its address, are acknowledged but counted only once per day.
+func handleReportInstall(c *Context, w http.ResponseWriter, r *http.Request) {
+	if c.Stats == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	event, err := model.InstallEventFromReader(http.MaxBytesReader(w, r.Body, maxInstallEventSize))
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to decode install event")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	if err := event.IsValid(); err != nil {
+		c.Logger.WithError(err).Error("invalid install event")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	// Count the report under the plugin's manifest id, as for downloads.
+	id, ok := resolvePlugin(c, w, r, event.PluginID)
+	if !ok {
+		return
+	}
+
+	reporter := event.ServerID
+	if reporter == "" {
+		reporter = clientIP(r, c.TrustForwardedFor).String()
+	}
+	if !c.Stats.RecordInstall(reporter, id, event.Version, event.Event == model.InstallEventUpgrade) {
+		c.Logger.WithField("plugin_id", id).Debug("Ignored repeated install report")
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetPluginStats responds to GET /api/v1/plugins/{id}/stats, returning the download
+// statistics of the given plugin.
+func handleGetPluginStats(c *Context, w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	endSpan := traceStore(c, r, "GetPluginVersions")
+	plugins, err := c.Store.GetPluginVersions(id)
+	endSpan()
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to query plugin versions")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	if len(plugins) == 0 {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	// Report the statistics recorded under the plugin's manifest id, which the requested id need
+	// only match case-insensitively or as an old id.
+	id = plugins[0].Manifest.Id
+
+	stats := &model.PluginStats{PluginID: id, Versions: map[string]int64{}, Daily: []model.DailyDownloads{}}
+	if c.Stats != nil {
+		stats = c.Stats.PluginStats(id)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, stats)
+}
+
+// handleGetMarketplaceStats responds to GET /api/v1/stats, returning the download statistics
+// across all plugins.
+func handleGetMarketplaceStats(c *Context, w http.ResponseWriter, r *http.Request) {
+	stats := &model.MarketplaceStats{Plugins: map[string]int64{}, Daily: []model.DailyDownloads{}}
+	if c.Stats != nil {
+		stats = c.Stats.MarketplaceStats()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, stats)
+}
+
+// handleGetMetrics responds to GET /metrics, exposing the download counters, request latencies
+// and audit drift to Prometheus.
+func handleGetMetrics(c *Context, w http.ResponseWriter, r *http.Request) {
+	if c.Stats == nil && c.Metrics == nil && c.Audit == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if c.Stats != nil {
+		if err := c.Stats.WriteMetrics(w); err != nil {
+			c.Logger.WithError(err).Error("failed to write metrics")
+			return
+		}
+	}
+	if c.Metrics != nil {
+		if err := c.Metrics.WriteMetrics(w); err != nil {
+			c.Logger.WithError(err).Error("failed to write metrics")
+			return
+		}
+	}
+	if c.Audit != nil {
+		if err := c.Audit.WriteMetrics(w); err != nil {
+			c.Logger.WithError(err).Error("failed to write metrics")
+		}
+	}
+}
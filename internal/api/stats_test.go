@@ -0,0 +1,246 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/audit"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/stats"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStatsApi(t *testing.T, plugins []*model.Plugin) (string, *api.Client, func()) {
+	logger := testlib.MakeLogger(t)
+
+	data, err := json.Marshal(plugins)
+	require.NoError(t, err)
+	store, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "stats")
+	require.NoError(t, err)
+	tracker, err := stats.NewTracker(&stats.FileBackend{Path: filepath.Join(dir, "stats.json")})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:  store,
+		Stats:  tracker,
+		Logger: logger,
+	})
+	ts := httptest.NewServer(router)
+
+	return ts.URL, api.NewClient(ts.URL), func() {
+		ts.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDownloadStats(t *testing.T) {
+	plugins := []*model.Plugin{
+		{
+			HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+			DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+			ReleaseStage: model.ReleaseStageProduction,
+			Platforms: map[string]*model.PlatformBundle{
+				"linux-amd64": &model.PlatformBundle{DownloadURL: "https://example.com/demo-0.2.0-linux-amd64.tar.gz"},
+			},
+			Manifest: &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0"},
+		},
+		{
+			HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+			DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+			ReleaseStage: model.ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+		},
+	}
+
+	address, client, tearDown := setupStatsApi(t, plugins)
+	defer tearDown()
+
+	httpClient := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	download := func(t *testing.T, query string) *http.Response {
+		t.Helper()
+
+		resp, err := httpClient.Get(address + "/api/v1/plugins" + query)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp
+	}
+
+	t.Run("no downloads", func(t *testing.T) {
+		stats, err := client.GetPluginStats("demo")
+		require.NoError(t, err)
+		require.EqualValues(t, 0, stats.TotalDownloads)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, download(t, "/unknown/download").StatusCode)
+
+		_, err := client.GetPluginStats("unknown")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, download(t, "/demo/download?version=0.3.0").StatusCode)
+	})
+
+	t.Run("redirects to the latest version", func(t *testing.T) {
+		resp := download(t, "/demo/download")
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		require.Equal(t, "https://example.com/demo-0.2.0.tar.gz", resp.Header.Get("Location"))
+	})
+
+	t.Run("redirects to the requested version", func(t *testing.T) {
+		resp := download(t, "/demo/download?version=0.1.0")
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		require.Equal(t, "https://example.com/demo-0.1.0.tar.gz", resp.Header.Get("Location"))
+	})
+
+	t.Run("redirects to the requested platform", func(t *testing.T) {
+		resp := download(t, "/demo/download?platform=linux-amd64")
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		require.Equal(t, "https://example.com/demo-0.2.0-linux-amd64.tar.gz", resp.Header.Get("Location"))
+	})
+
+	t.Run("counts redirects", func(t *testing.T) {
+		stats, err := client.GetPluginStats("demo")
+		require.NoError(t, err)
+		require.EqualValues(t, 3, stats.TotalDownloads)
+		require.Equal(t, map[string]int64{"0.1.0": 1, "0.2.0": 2}, stats.Versions)
+		require.Len(t, stats.Daily, 1)
+
+		marketplaceStats, err := client.GetMarketplaceStats()
+		require.NoError(t, err)
+		require.EqualValues(t, 3, marketplaceStats.TotalDownloads)
+		require.Equal(t, map[string]int64{"demo": 3}, marketplaceStats.Plugins)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		resp, err := http.Get(address + "/metrics")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), `marketplace_plugin_downloads_total{plugin_id="demo",version="0.2.0"} 2`)
+	})
+}
+
+func TestInstallTelemetry(t *testing.T) {
+	plugins := []*model.Plugin{
+		{
+			DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+			ReleaseStage: model.ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0"},
+		},
+	}
+
+	_, client, tearDown := setupStatsApi(t, plugins)
+	defer tearDown()
+
+	t.Run("invalid event", func(t *testing.T) {
+		err := client.ReportInstall(&model.InstallEvent{Event: "uninstall", PluginID: "demo", Version: "0.2.0"})
+		require.Error(t, err)
+
+		err = client.ReportInstall(&model.InstallEvent{Event: model.InstallEventInstall, PluginID: "demo", Version: "latest"})
+		require.Error(t, err)
+	})
+
+	t.Run("unknown plugin", func(t *testing.T) {
+		err := client.ReportInstall(&model.InstallEvent{Event: model.InstallEventInstall, PluginID: "unknown", Version: "0.2.0"})
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("counts towards adoption", func(t *testing.T) {
+		require.NoError(t, client.ReportInstall(&model.InstallEvent{Event: model.InstallEventInstall, PluginID: "Demo", Version: "0.1.0"}))
+		require.NoError(t, client.ReportInstall(&model.InstallEvent{Event: model.InstallEventUpgrade, PluginID: "demo", Version: "0.2.0"}))
+
+		stats, err := client.GetPluginStats("demo")
+		require.NoError(t, err)
+		require.EqualValues(t, 0, stats.TotalDownloads)
+		require.EqualValues(t, 1, stats.TotalInstalls)
+		require.EqualValues(t, 1, stats.TotalUpgrades)
+		require.Equal(t, map[string]*model.VersionAdoption{
+			"0.1.0": {Installs: 1},
+			"0.2.0": {Upgrades: 1},
+		}, stats.Adoption)
+	})
+
+	t.Run("repeated reports counted once", func(t *testing.T) {
+		require.NoError(t, client.ReportInstall(&model.InstallEvent{Event: model.InstallEventInstall, PluginID: "demo", Version: "0.1.0"}))
+		require.NoError(t, client.ReportInstall(&model.InstallEvent{Event: model.InstallEventInstall, PluginID: "demo", Version: "0.2.0", ServerID: "server1"}))
+		require.NoError(t, client.ReportInstall(&model.InstallEvent{Event: model.InstallEventInstall, PluginID: "demo", Version: "0.2.0", ServerID: "server1"}))
+		require.NoError(t, client.ReportInstall(&model.InstallEvent{Event: model.InstallEventInstall, PluginID: "demo", Version: "0.2.0", ServerID: "server2"}))
+
+		stats, err := client.GetPluginStats("demo")
+		require.NoError(t, err)
+		require.Equal(t, map[string]*model.VersionAdoption{
+			"0.1.0": {Installs: 1},
+			"0.2.0": {Installs: 2, Upgrades: 1},
+		}, stats.Adoption)
+	})
+}
+
+func TestDownloadStatsDisabled(t *testing.T) {
+	client, tearDown := setupApi(t, nil)
+	defer tearDown()
+
+	stats, err := client.GetMarketplaceStats()
+	require.NoError(t, err)
+	require.EqualValues(t, 0, stats.TotalDownloads)
+
+	resp, err := http.Get(client.Address + "/metrics")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	err = client.ReportInstall(&model.InstallEvent{Event: model.InstallEventInstall, PluginID: "demo", Version: "0.1.0"})
+	require.Equal(t, api.ErrNotFound, err)
+}
+
+func TestAuditMetrics(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+	pluginStore, err := store.New(bytes.NewReader([]byte("[]")), logger)
+	require.NoError(t, err)
+
+	auditor := audit.New(logger, audit.Options{})
+	auditor.Audit(nil)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:  pluginStore,
+		Audit:  auditor,
+		Logger: logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "marketplace_audit_runs_total 1\n")
+	require.Contains(t, string(body), `marketplace_audit_drift{check="checksum"} 0`)
+}
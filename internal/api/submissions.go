@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/submissions"
+	"github.com/pkg/errors"
+)
+
+// maxSubmissionRequestSize bounds the body of a submission or moderation request.
+const maxSubmissionRequestSize = 64 * 1024
+
+// initSubmissions registers the community submission endpoints on the given router.
+func initSubmissions(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	submissionsRouter := apiRouter.PathPrefix("/submissions").Subrouter()
+	submissionsRouter.Handle("", addContext(handleGetSubmissions)).Methods("GET")
+	submissionsRouter.Handle("", addContext(restrictWrites(handleSubmit))).Methods("POST")
+	submissionsRouter.Handle("/{id}", addContext(handleGetSubmission)).Methods("GET")
+	submissionsRouter.Handle("/{id}/approve", addContext(restrictWrites(handleApproveSubmission))).Methods("POST")
+	submissionsRouter.Handle("/{id}/reject", addContext(restrictWrites(handleRejectSubmission))).Methods("POST")
+}
+
+// SubmitRequest describes the parameters to submit a plugin repository for inclusion.
+type SubmitRequest struct {
+	RepositoryURL string `json:"repository_url"`
+}
+
+// ModerateRequest describes the optional parameters to approve or reject a submission.
+type ModerateRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// authenticateSubmissions identifies the user making a submissions request, responding on
+// failure or if submissions are not accepted.
+func authenticateSubmissions(c *Context, w http.ResponseWriter, r *http.Request) (string, bool) {
+	if c.Submissions == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return "", false
+	}
+
+	return authenticate(c, w, r)
+}
+
+// handleSubmit responds to POST /api/v1/submissions, queueing the given repository for moderation.
+func handleSubmit(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticateSubmissions(c, w, r)
+	if !ok {
+		return
+	}
+
+	var request SubmitRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSubmissionRequestSize)).Decode(&request); err != nil {
+		c.Logger.WithError(err).Error("failed to decode submission")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	if _, _, err := model.ParseGitHubRepository(request.RepositoryURL); err != nil {
+		c.Logger.WithError(err).Error("invalid submission")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	submission, err := c.Submissions.Submit(request.RepositoryURL, userID)
+	if errors.Cause(err) == submissions.ErrDuplicate {
+		writeError(c, w, r, http.StatusConflict)
+		return
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to submit repository")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	outputJSON(c, w, submission)
+}
+
+// handleGetSubmissions responds to GET /api/v1/submissions, returning every submission to
+// moderators, and only their own submissions to other users.
+func handleGetSubmissions(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticateSubmissions(c, w, r)
+	if !ok {
+		return
+	}
+
+	filter := &model.SubmissionFilter{
+		Status: model.SubmissionStatus(r.URL.Query().Get("status")),
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		c.Logger.Errorf("invalid status %s", filter.Status)
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	if !c.Moderators[userID] {
+		filter.SubmitterID = userID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, c.Submissions.GetSubmissions(filter))
+}
+
+// handleGetSubmission responds to GET /api/v1/submissions/{id}, returning the given submission to
+// moderators and to its submitter.
+func handleGetSubmission(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticateSubmissions(c, w, r)
+	if !ok {
+		return
+	}
+
+	submission, err := c.Submissions.GetSubmission(mux.Vars(r)["id"])
+	if errors.Cause(err) == submissions.ErrNotFound {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	} else if err != nil {
+		c.Logger.WithError(err).Error("failed to query submission")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	if !c.Moderators[userID] && submission.SubmitterID != userID {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, submission)
+}
+
+// handleApproveSubmission responds to POST /api/v1/submissions/{id}/approve, accepting the given
+// submission for inclusion in the marketplace.
+func handleApproveSubmission(c *Context, w http.ResponseWriter, r *http.Request) {
+	handleModerateSubmission(c, w, r, model.SubmissionStatusApproved)
+}
+
+// handleRejectSubmission responds to POST /api/v1/submissions/{id}/reject, declining the given
+// submission.
+func handleRejectSubmission(c *Context, w http.ResponseWriter, r *http.Request) {
+	handleModerateSubmission(c, w, r, model.SubmissionStatusRejected)
+}
+
+func handleModerateSubmission(c *Context, w http.ResponseWriter, r *http.Request, status model.SubmissionStatus) {
+	userID, ok := authenticateSubmissions(c, w, r)
+	if !ok {
+		return
+	}
+	if !c.Moderators[userID] {
+		writeError(c, w, r, http.StatusForbidden)
+		return
+	}
+
+	var request ModerateRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSubmissionRequestSize)).Decode(&request)
+	if err != nil && err != io.EOF {
+		c.Logger.WithError(err).Error("failed to decode moderation request")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	var submission *model.Submission
+	if status == model.SubmissionStatusApproved {
+		submission, err = c.Submissions.Approve(id, userID, request.Reason)
+	} else {
+		submission, err = c.Submissions.Reject(id, userID, request.Reason)
+	}
+	switch errors.Cause(err) {
+	case nil:
+	case submissions.ErrNotFound:
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	case submissions.ErrAlreadyModerated:
+		writeError(c, w, r, http.StatusConflict)
+		return
+	default:
+		c.Logger.WithError(err).Error("failed to moderate submission")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, submission)
+}
@@ -0,0 +1,166 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/submissions"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSubmissionsApi(t *testing.T, writeAllowlist *api.IPAllowlist) (string, func()) {
+	logger := testlib.MakeLogger(t)
+
+	data, err := json.Marshal([]*model.Plugin{})
+	require.NoError(t, err)
+	store, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "submissions")
+	require.NoError(t, err)
+	queue, err := submissions.NewQueue(&submissions.FileBackend{Path: filepath.Join(dir, "submissions.json")})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:       store,
+		Submissions: queue,
+		Authenticator: api.TokenAuthenticator{
+			"alice-token":     "alice",
+			"bob-token":       "bob",
+			"moderator-token": "moderator",
+		},
+		Moderators:     map[string]bool{"moderator": true},
+		WriteAllowlist: writeAllowlist,
+		Logger:         logger,
+	})
+	ts := httptest.NewServer(router)
+
+	return ts.URL, func() {
+		ts.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSubmissions(t *testing.T) {
+	address, tearDown := setupSubmissionsApi(t, nil)
+	defer tearDown()
+
+	clientFor := func(token string) *api.Client {
+		client := api.NewClient(address)
+		client.Token = token
+		return client
+	}
+	alice := clientFor("alice-token")
+	bob := clientFor("bob-token")
+	moderator := clientFor("moderator-token")
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		_, err := clientFor("").Submit("https://github.com/alice/demo")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusUnauthorized}, err)
+
+		_, err = clientFor("unknown").GetSubmissions("")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusUnauthorized}, err)
+	})
+
+	t.Run("invalid repository", func(t *testing.T) {
+		_, err := alice.Submit("https://example.com/demo")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	demo, err := alice.Submit("https://github.com/alice/demo")
+	require.NoError(t, err)
+	require.Equal(t, "alice", demo.SubmitterID)
+	require.Equal(t, model.SubmissionStatusPending, demo.Status)
+
+	starter, err := bob.Submit("https://github.com/bob/starter")
+	require.NoError(t, err)
+
+	t.Run("duplicate repository", func(t *testing.T) {
+		_, err := bob.Submit("https://github.com/alice/demo")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusConflict}, err)
+	})
+
+	t.Run("submitters see their own submissions", func(t *testing.T) {
+		aliceSubmissions, err := alice.GetSubmissions("")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Submission{demo}, aliceSubmissions)
+
+		submission, err := alice.GetSubmission(demo.ID)
+		require.NoError(t, err)
+		require.Equal(t, demo, submission)
+
+		_, err = alice.GetSubmission(starter.ID)
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("moderators see all submissions", func(t *testing.T) {
+		allSubmissions, err := moderator.GetSubmissions("")
+		require.NoError(t, err)
+		require.Len(t, allSubmissions, 2)
+
+		_, err = moderator.GetSubmissions("merged")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusBadRequest}, err)
+	})
+
+	t.Run("only moderators moderate", func(t *testing.T) {
+		_, err := alice.ApproveSubmission(demo.ID, "")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+
+		_, err = alice.RejectSubmission(starter.ID, "")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		approved, err := moderator.ApproveSubmission(demo.ID, "")
+		require.NoError(t, err)
+		require.Equal(t, model.SubmissionStatusApproved, approved.Status)
+		require.Equal(t, "moderator", approved.ModeratorID)
+
+		_, err = moderator.ApproveSubmission(demo.ID, "")
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusConflict}, err)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		rejected, err := moderator.RejectSubmission(starter.ID, "No releases.")
+		require.NoError(t, err)
+		require.Equal(t, model.SubmissionStatusRejected, rejected.Status)
+		require.Equal(t, "No releases.", rejected.Reason)
+
+		submission, err := bob.GetSubmission(starter.ID)
+		require.NoError(t, err)
+		require.Equal(t, rejected, submission)
+	})
+
+	t.Run("unknown submission", func(t *testing.T) {
+		_, err := moderator.ApproveSubmission("unknown", "")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("filter by status", func(t *testing.T) {
+		approved, err := moderator.GetSubmissions(model.SubmissionStatusApproved)
+		require.NoError(t, err)
+		require.Len(t, approved, 1)
+		require.Equal(t, demo.ID, approved[0].ID)
+	})
+}
+
+func TestSubmissionsDisabled(t *testing.T) {
+	client, tearDown := setupApi(t, nil)
+	defer tearDown()
+	client.Token = "token"
+
+	_, err := client.Submit("https://github.com/alice/demo")
+	require.Equal(t, api.ErrNotFound, err)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// Tenant is a customer of a managed marketplace, served its own catalog, configuration and
+// authentication by a process shared with other tenants.
+type Tenant struct {
+	Name string
+	// Hosts are the lowercased host names, without ports, whose requests are served to the tenant.
+	Hosts []string
+	// PathPrefix, if set, serves the requests under it to the tenant, e.g. /tenants/acme for
+	// /tenants/acme/api/v1/plugins.
+	PathPrefix string
+	// Context serves the tenant's requests.
+	Context *Context
+}
+
+// IsValid verifies the tenant is well-formed and selected by at least one host or a path prefix.
+func (t *Tenant) IsValid() error {
+	if t.Name == "" {
+		return errors.New("tenant name is empty")
+	}
+	if len(t.Hosts) == 0 && t.PathPrefix == "" {
+		return errors.Errorf("tenant %s has neither hosts nor a path prefix", t.Name)
+	}
+	if t.PathPrefix != "" && (!strings.HasPrefix(t.PathPrefix, "/") || strings.HasSuffix(t.PathPrefix, "/")) {
+		return errors.Errorf("tenant %s path prefix %s must start but not end with /", t.Name, t.PathPrefix)
+	}
+	if t.Context == nil {
+		return errors.Errorf("tenant %s has no context", t.Name)
+	}
+
+	return nil
+}
+
+// RegisterTenants registers the API endpoints of each of the given tenants on the given router,
+// selected by the host or path prefix of each request. A request matching both a host and a path
+// prefix is served to the tenant owning the path prefix.
+//
+// Tenants must be registered before any endpoints serving requests selecting no tenant, which the
+// router would otherwise match first.
+func RegisterTenants(rootRouter *mux.Router, tenants []*Tenant) error {
+	hosts := map[string]string{}
+	pathPrefixes := map[string]string{}
+	for _, tenant := range tenants {
+		if err := tenant.IsValid(); err != nil {
+			return err
+		}
+
+		for _, host := range tenant.Hosts {
+			if other, ok := hosts[host]; ok {
+				return errors.Errorf("host %s is claimed by both tenants %s and %s", host, other, tenant.Name)
+			}
+			hosts[host] = tenant.Name
+		}
+		if tenant.PathPrefix != "" {
+			if other, ok := pathPrefixes[tenant.PathPrefix]; ok {
+				return errors.Errorf("path prefix %s is claimed by both tenants %s and %s", tenant.PathPrefix, other, tenant.Name)
+			}
+			pathPrefixes[tenant.PathPrefix] = tenant.Name
+		}
+	}
+
+	for _, tenant := range tenants {
+		if tenant.PathPrefix != "" {
+			Register(rootRouter.PathPrefix(tenant.PathPrefix).Subrouter(), tenant.Context)
+		}
+	}
+
+	for _, tenant := range tenants {
+		if len(tenant.Hosts) == 0 {
+			continue
+		}
+
+		tenantHosts := map[string]bool{}
+		for _, host := range tenant.Hosts {
+			tenantHosts[host] = true
+		}
+		hostRouter := rootRouter.MatcherFunc(func(r *http.Request, match *mux.RouteMatch) bool {
+			return tenantHosts[requestHost(r)]
+		}).Subrouter()
+		Register(hostRouter, tenant.Context)
+	}
+
+	return nil
+}
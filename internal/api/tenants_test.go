@@ -0,0 +1,112 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenants(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	newContext := func(t *testing.T, id string) *api.Context {
+		data, err := json.Marshal([]*model.Plugin{{
+			HomepageURL:  "https://github.com/mattermost/mattermost-plugin-" + id,
+			DownloadURL:  "https://example.com/" + id + "-0.1.0.tar.gz",
+			ReleaseStage: model.ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: id, Name: id, Version: "0.1.0"},
+		}})
+		require.NoError(t, err)
+		store, err := store.New(bytes.NewReader(data), logger)
+		require.NoError(t, err)
+
+		return &api.Context{Store: store, Logger: logger}
+	}
+
+	t.Run("invalid tenants", func(t *testing.T) {
+		testCases := []struct {
+			Description string
+			Tenants     []*api.Tenant
+		}{
+			{"no name", []*api.Tenant{{Hosts: []string{"acme.example.com"}, Context: &api.Context{}}}},
+			{"no hosts or path prefix", []*api.Tenant{{Name: "acme", Context: &api.Context{}}}},
+			{"relative path prefix", []*api.Tenant{{Name: "acme", PathPrefix: "acme", Context: &api.Context{}}}},
+			{"trailing slash", []*api.Tenant{{Name: "acme", PathPrefix: "/acme/", Context: &api.Context{}}}},
+			{"no context", []*api.Tenant{{Name: "acme", PathPrefix: "/acme"}}},
+			{"shared host", []*api.Tenant{
+				{Name: "acme", Hosts: []string{"example.com"}, Context: &api.Context{}},
+				{Name: "globex", Hosts: []string{"example.com"}, Context: &api.Context{}},
+			}},
+			{"shared path prefix", []*api.Tenant{
+				{Name: "acme", PathPrefix: "/tenant", Context: &api.Context{}},
+				{Name: "globex", PathPrefix: "/tenant", Context: &api.Context{}},
+			}},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.Description, func(t *testing.T) {
+				require.Error(t, api.RegisterTenants(mux.NewRouter(), testCase.Tenants))
+			})
+		}
+	})
+
+	router := mux.NewRouter()
+	err := api.RegisterTenants(router, []*api.Tenant{
+		{Name: "acme", Hosts: []string{"acme.example.com"}, PathPrefix: "/tenants/acme", Context: newContext(t, "acme-plugin")},
+		{Name: "globex", PathPrefix: "/tenants/globex", Context: newContext(t, "globex-plugin")},
+	})
+	require.NoError(t, err)
+	api.Register(router, newContext(t, "default-plugin"))
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	getPluginIDs := func(t *testing.T, path, host string) []string {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		require.NoError(t, err)
+		if host != "" {
+			req.Host = host
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		plugins, err := model.PluginsFromReader(resp.Body)
+		require.NoError(t, err)
+
+		var ids []string
+		for _, plugin := range plugins {
+			ids = append(ids, plugin.Manifest.Id)
+		}
+		return ids
+	}
+
+	t.Run("default catalog", func(t *testing.T) {
+		require.Equal(t, []string{"default-plugin"}, getPluginIDs(t, "/api/v1/plugins", ""))
+	})
+
+	t.Run("by host", func(t *testing.T) {
+		require.Equal(t, []string{"acme-plugin"}, getPluginIDs(t, "/api/v1/plugins", "ACME.example.com:8065"))
+	})
+
+	t.Run("by path prefix", func(t *testing.T) {
+		require.Equal(t, []string{"acme-plugin"}, getPluginIDs(t, "/tenants/acme/api/v1/plugins", ""))
+		require.Equal(t, []string{"globex-plugin"}, getPluginIDs(t, "/tenants/globex/api/v1/plugins", ""))
+	})
+
+	t.Run("path prefix takes precedence over host", func(t *testing.T) {
+		require.Equal(t, []string{"globex-plugin"}, getPluginIDs(t, "/tenants/globex/api/v1/plugins", "acme.example.com"))
+	})
+}
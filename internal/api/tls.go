@@ -0,0 +1,125 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Client certificate requirements accepted by TLSOptions.ClientAuth.
+const (
+	// ClientAuthNone accepts clients without asking for a certificate.
+	ClientAuthNone = "none"
+	// ClientAuthOptional verifies the certificates of the clients presenting one, accepting
+	// clients without a certificate.
+	ClientAuthOptional = "optional"
+	// ClientAuthRequired rejects clients without a certificate signed by one of the client CAs.
+	ClientAuthRequired = "required"
+)
+
+// tlsVersions maps the names accepted by TLSOptions.MinVersion to the TLS versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSOptions configures the TLS connections accepted by the server.
+type TLSOptions struct {
+	// CertFile and KeyFile hold the PEM-encoded certificate chain and private key presented to
+	// clients.
+	CertFile string
+	KeyFile  string
+	// MinVersion is the oldest TLS version accepted, e.g. 1.2, or empty for Go's default.
+	MinVersion string
+	// CipherSuites names the cipher suites accepted for TLS 1.2 and older, e.g.
+	// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, or is empty for Go's default. The cipher suites of
+	// TLS 1.3 are not configurable.
+	CipherSuites []string
+	// ClientCAFile holds the PEM-encoded certificates of the authorities signing the client
+	// certificates accepted for mutual TLS.
+	ClientCAFile string
+	// ClientAuth is one of ClientAuthNone, ClientAuthOptional or ClientAuthRequired, defaulting to
+	// ClientAuthRequired given a ClientCAFile and to ClientAuthNone otherwise.
+	ClientAuth string
+}
+
+// NewTLSConfig loads the certificates and validates the settings of the given options, returning
+// the resulting configuration for an http.Server.
+func NewTLSConfig(options TLSOptions) (*tls.Config, error) {
+	if options.CertFile == "" || options.KeyFile == "" {
+		return nil, errors.New("tls requires both a certificate and a key file")
+	}
+
+	certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load tls certificate")
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+	}
+
+	if options.MinVersion != "" {
+		minVersion, ok := tlsVersions[options.MinVersion]
+		if !ok {
+			return nil, errors.Errorf("unknown tls version %s", options.MinVersion)
+		}
+		config.MinVersion = minVersion
+	}
+
+	if len(options.CipherSuites) > 0 {
+		suites := map[string]uint16{}
+		for _, suite := range tls.CipherSuites() {
+			suites[suite.Name] = suite.ID
+		}
+
+		for _, name := range options.CipherSuites {
+			id, ok := suites[strings.TrimSpace(name)]
+			if !ok {
+				return nil, errors.Errorf("unknown or insecure cipher suite %s", name)
+			}
+			config.CipherSuites = append(config.CipherSuites, id)
+		}
+	}
+
+	clientAuth := options.ClientAuth
+	if clientAuth == "" {
+		clientAuth = ClientAuthNone
+		if options.ClientCAFile != "" {
+			clientAuth = ClientAuthRequired
+		}
+	}
+
+	switch clientAuth {
+	case ClientAuthNone:
+		if options.ClientCAFile != "" {
+			return nil, errors.New("client CAs are given, but client certificates are not verified")
+		}
+		return config, nil
+	case ClientAuthOptional:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequired:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, errors.Errorf("unknown client auth %s", clientAuth)
+	}
+
+	if options.ClientCAFile == "" {
+		return nil, errors.Errorf("client auth %s requires client CAs", clientAuth)
+	}
+	data, err := ioutil.ReadFile(options.ClientCAFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", options.ClientCAFile)
+	}
+	config.ClientCAs = x509.NewCertPool()
+	if !config.ClientCAs.AppendCertsFromPEM(data) {
+		return nil, errors.Errorf("no certificates found in %s", options.ClientCAFile)
+	}
+
+	return config, nil
+}
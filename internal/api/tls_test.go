@@ -0,0 +1,178 @@
+package api_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate is a certificate along with its private key, signed by a parent if any.
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	der         []byte
+}
+
+func newTestCertificate(t *testing.T, name string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCertificate{certificate: certificate, key: key, der: der}
+}
+
+// write writes the certificate and its key as PEM to files in the given directory, returning
+// their paths.
+func (c *testCertificate) write(t *testing.T, dir, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600))
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile
+}
+
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCertificate(t, "ca", nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	serverCertFile, serverKeyFile := newTestCertificate(t, "server", ca).write(t, dir, "server")
+	client := newTestCertificate(t, "client", ca)
+	stranger := newTestCertificate(t, "stranger", newTestCertificate(t, "other-ca", nil))
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ca.certificate)
+
+	// serve starts a TLS server with the given options, returning a function requesting it with
+	// the given client certificates and a function stopping it.
+	serve := func(t *testing.T, options api.TLSOptions) (func(certificates ...tls.Certificate) error, func()) {
+		options.CertFile = serverCertFile
+		options.KeyFile = serverKeyFile
+		config, err := api.NewTLSConfig(options)
+		require.NoError(t, err)
+
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		ts.TLS = config
+		// Rejected handshakes are expected.
+		ts.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+		ts.StartTLS()
+
+		return func(certificates ...tls.Certificate) error {
+			httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs:    rootCAs,
+				MaxVersion: tls.VersionTLS12,
+				// Present the certificate even if not signed by one of the server's client CAs.
+				GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					if len(certificates) == 0 {
+						return &tls.Certificate{}, nil
+					}
+					return &certificates[0], nil
+				},
+			}}}
+			resp, err := httpClient.Get(ts.URL)
+			if err != nil {
+				return err
+			}
+			return resp.Body.Close()
+		}, ts.Close
+	}
+
+	t.Run("no client certificates", func(t *testing.T) {
+		request, tearDown := serve(t, api.TLSOptions{MinVersion: "1.2"})
+		defer tearDown()
+		require.NoError(t, request())
+	})
+
+	t.Run("minimum version", func(t *testing.T) {
+		request, tearDown := serve(t, api.TLSOptions{MinVersion: "1.3"})
+		defer tearDown()
+		require.Error(t, request())
+	})
+
+	t.Run("cipher suites", func(t *testing.T) {
+		request, tearDown := serve(t, api.TLSOptions{CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"}})
+		defer tearDown()
+		require.NoError(t, request())
+	})
+
+	t.Run("client certificates required", func(t *testing.T) {
+		request, tearDown := serve(t, api.TLSOptions{ClientCAFile: caFile})
+		defer tearDown()
+		require.NoError(t, request(client.tlsCertificate()))
+		require.Error(t, request())
+		require.Error(t, request(stranger.tlsCertificate()))
+	})
+
+	t.Run("client certificates optional", func(t *testing.T) {
+		request, tearDown := serve(t, api.TLSOptions{ClientCAFile: caFile, ClientAuth: api.ClientAuthOptional})
+		defer tearDown()
+		require.NoError(t, request(client.tlsCertificate()))
+		require.NoError(t, request())
+		require.Error(t, request(stranger.tlsCertificate()))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, options := range []api.TLSOptions{
+			{CertFile: serverCertFile},
+			{CertFile: serverCertFile, KeyFile: caFile},
+			{CertFile: serverCertFile, KeyFile: serverKeyFile, MinVersion: "1.4"},
+			{CertFile: serverCertFile, KeyFile: serverKeyFile, CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			{CertFile: serverCertFile, KeyFile: serverKeyFile, ClientAuth: api.ClientAuthRequired},
+			{CertFile: serverCertFile, KeyFile: serverKeyFile, ClientAuth: "sometimes", ClientCAFile: caFile},
+			{CertFile: serverCertFile, KeyFile: serverKeyFile, ClientAuth: api.ClientAuthNone, ClientCAFile: caFile},
+			{CertFile: serverCertFile, KeyFile: serverKeyFile, ClientCAFile: serverKeyFile},
+		} {
+			_, err := api.NewTLSConfig(options)
+			require.Error(t, err, "%+v", options)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// initTombstones registers the endpoint serving the tombstones of removed plugin versions on the
+// given router.
+func initTombstones(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	apiRouter.Handle("/tombstones", addContext(handleGetTombstones)).Methods("GET")
+}
+
+// handleGetTombstones responds to GET /api/v1/tombstones, returning the tombstones of the plugin
+// versions removed from the catalog, most recently removed first. The plugin_id and version query
+// parameters limit the tombstones to those of a plugin, or of a single version.
+func handleGetTombstones(c *Context, w http.ResponseWriter, r *http.Request) {
+	if c.Tombstones == nil {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	result := c.Tombstones.GetTombstones(query.Get("plugin_id"), query.Get("version"))
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, result)
+}
+
+// recordTombstones records the plugin versions removed from the default catalog by the given
+// changes with the given reason, if tombstones are enabled. The catalog having already changed, a
+// failure to record them is logged rather than failing the request.
+func recordTombstones(c *Context, changes []*model.CatalogChange, reason string) {
+	if c.Tombstones == nil {
+		return
+	}
+
+	if err := c.Tombstones.Record(changes, reason); err != nil {
+		c.Logger.WithError(err).Error("failed to record tombstones of removed plugin versions")
+	}
+}
@@ -0,0 +1,123 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	"github.com/mattermost/mattermost-marketplace/internal/tombstones"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTombstones(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	dir, err := ioutil.TempDir("", "tombstones")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	initialStore, err := store.New(strings.NewReader(`[]`), logger)
+	require.NoError(t, err)
+	registry, err := tombstones.New(&tombstones.FileBackend{Path: filepath.Join(dir, "tombstones.json")})
+	require.NoError(t, err)
+
+	newServer := func(registry api.Tombstones) *httptest.Server {
+		router := mux.NewRouter()
+		api.Register(router, &api.Context{
+			Store: catalog.NewWithOptions(initialStore, catalog.Options{
+				Backend: &catalog.FileBackend{Path: filepath.Join(dir, "plugins.json")},
+			}),
+			Tombstones:    registry,
+			Authenticator: api.TokenAuthenticator{"moderator-token": "moderator"},
+			Moderators:    map[string]bool{"moderator": true},
+			Logger:        logger,
+		})
+		return httptest.NewServer(router)
+	}
+
+	plugins := func(versions ...string) *bytes.Reader {
+		var plugins []*model.Plugin
+		for _, version := range versions {
+			plugins = append(plugins, &model.Plugin{
+				DownloadURL: "https://example.com/demo-" + version + ".tar.gz",
+				Manifest:    &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: version},
+			})
+		}
+		data, err := json.Marshal(plugins)
+		require.NoError(t, err)
+		return bytes.NewReader(data)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		ts := newServer(nil)
+		defer ts.Close()
+
+		_, err := api.NewClient(ts.URL).GetTombstones("", "")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	ts := newServer(registry)
+	defer ts.Close()
+	client := api.NewClient(ts.URL)
+	moderator := api.NewClient(ts.URL)
+	moderator.Token = "moderator-token"
+
+	t.Run("no tombstones", func(t *testing.T) {
+		result, err := client.GetTombstones("", "")
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+
+	t.Run("removed by import", func(t *testing.T) {
+		_, err := moderator.ImportCatalog(plugins("0.1.0", "0.2.0"))
+		require.NoError(t, err)
+		_, err = moderator.ImportCatalog(plugins("0.2.0"))
+		require.NoError(t, err)
+
+		result, err := client.GetTombstones("demo", "")
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, "0.1.0", result[0].Version)
+		require.Equal(t, model.TombstoneReasonImported, result[0].Reason)
+		require.False(t, result[0].RemovedAt.IsZero())
+
+		result, err = client.GetTombstones("demo", "0.2.0")
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+
+	t.Run("restored by rollback", func(t *testing.T) {
+		snapshots, err := moderator.GetSnapshots()
+		require.NoError(t, err)
+		require.True(t, len(snapshots) >= 3)
+
+		// Restore the first import, serving both versions.
+		_, err = moderator.RollbackSnapshot(snapshots[1].ID)
+		require.NoError(t, err)
+
+		result, err := client.GetTombstones("", "")
+		require.NoError(t, err)
+		require.Empty(t, result)
+
+		// Restore the second import, removing 0.1.0 again.
+		_, err = moderator.RollbackSnapshot(snapshots[0].ID)
+		require.NoError(t, err)
+
+		result, err = client.GetTombstones("demo", "0.1.0")
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, model.TombstoneReasonRolledBack, result[0].Reason)
+	})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-marketplace/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startRequestSpan starts the span of the given request, continuing the trace propagated by the
+// caller if any, and returns the request carrying the span.
+func startRequestSpan(tracer trace.Tracer, r *http.Request) (*http.Request, trace.Span) {
+	route := requestRoute(r)
+	ctx := tracing.Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer.Start(ctx, r.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.String("http.target", r.URL.RequestURI()),
+			attribute.String("http.user_agent", r.UserAgent()),
+		),
+	)
+
+	return r.WithContext(ctx), span
+}
+
+// endRequestSpan ends the span of a request, recording the status code of its response and
+// marking server errors as failures.
+func endRequestSpan(span trace.Span, w *statusWriter) {
+	statusCode := w.status()
+	span.SetAttributes(attribute.Int("http.status_code", statusCode))
+	if statusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+	span.End()
+}
+
+// traceStore starts a span timing the given query of the store made while serving the given
+// request, returning the function ending it. Without a tracer, it records nothing.
+func traceStore(c *Context, r *http.Request, operation string) func() {
+	if c.Tracer == nil {
+		return func() {}
+	}
+
+	_, span := c.Tracer.Start(r.Context(), "store."+operation, trace.WithSpanKind(trace.SpanKindInternal))
+
+	return func() { span.End() }
+}
@@ -0,0 +1,100 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	"github.com/mattermost/mattermost-marketplace/internal/tracing"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+
+	data, err := json.Marshal([]*model.Plugin{{
+		Manifest: &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}})
+	require.NoError(t, err)
+	pluginStore, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer(tracing.InstrumentationName)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:  pluginStore,
+		Tracer: tracer,
+		Logger: logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	spansNamed := func(name string) []sdktrace.ReadOnlySpan {
+		var spans []sdktrace.ReadOnlySpan
+		for _, span := range recorder.Ended() {
+			if span.Name() == name {
+				spans = append(spans, span)
+			}
+		}
+		return spans
+	}
+
+	t.Run("untraced client", func(t *testing.T) {
+		_, err := api.NewClient(ts.URL).GetPluginVersions("demo")
+		require.NoError(t, err)
+
+		requestSpans := spansNamed("GET /api/v1/plugins/{id}/versions")
+		require.Len(t, requestSpans, 1)
+		require.Equal(t, trace.SpanKindServer, requestSpans[0].SpanKind())
+		require.False(t, requestSpans[0].Parent().IsValid())
+
+		storeSpans := spansNamed("store.GetPluginVersions")
+		require.Len(t, storeSpans, 1)
+		require.Equal(t, requestSpans[0].SpanContext().SpanID(), storeSpans[0].Parent().SpanID())
+	})
+
+	t.Run("traced client", func(t *testing.T) {
+		ctx, parent := tracer.Start(context.Background(), "caller")
+		client := api.NewClient(ts.URL).WithContext(ctx)
+		client.Tracer = tracer
+
+		_, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: 10})
+		require.NoError(t, err)
+		parent.End()
+
+		clientSpans := spansNamed("GET")
+		require.Len(t, clientSpans, 1)
+		require.Equal(t, trace.SpanKindClient, clientSpans[0].SpanKind())
+		require.Equal(t, parent.SpanContext().SpanID(), clientSpans[0].Parent().SpanID())
+
+		requestSpans := spansNamed("GET /api/v1/plugins")
+		require.Len(t, requestSpans, 1)
+		require.Equal(t, parent.SpanContext().TraceID(), requestSpans[0].SpanContext().TraceID())
+		require.Equal(t, clientSpans[0].SpanContext().SpanID(), requestSpans[0].Parent().SpanID())
+	})
+
+	t.Run("propagates the context of an untraced client", func(t *testing.T) {
+		ctx, parent := tracer.Start(context.Background(), "caller")
+		_, err := api.NewClient(ts.URL).WithContext(ctx).GetPlugins(&api.GetPluginsRequest{PerPage: 10})
+		require.NoError(t, err)
+		parent.End()
+
+		requestSpans := spansNamed("GET /api/v1/plugins")
+		require.Len(t, requestSpans, 2)
+		require.Equal(t, parent.SpanContext().SpanID(), requestSpans[1].Parent().SpanID())
+	})
+}
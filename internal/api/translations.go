@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/pkg/errors"
+)
+
+// Translations maps each locale, e.g. fr or pt-br, to the display text of enumerated plugin fields
+// keyed by field and value, e.g. release_stage.beta, author_type.partner, label.Official or
+// label.Official.description, and to the messages of API errors keyed by code, e.g.
+// error.not_found.
+type Translations map[string]map[string]string
+
+// TranslationsFromReader decodes a json-encoded object mapping locales to translations from the
+// given io.Reader.
+func TranslationsFromReader(reader io.Reader) (Translations, error) {
+	var decoded Translations
+	if err := json.NewDecoder(reader).Decode(&decoded); err != nil {
+		return nil, errors.Wrap(err, "failed to decode translations")
+	}
+
+	translations := Translations{}
+	for locale, messages := range decoded {
+		if locale == "" {
+			return nil, errors.New("translations given for an empty locale")
+		}
+		translations[strings.ToLower(locale)] = messages
+	}
+
+	return translations, nil
+}
+
+// Locale returns the locale best matching the given Accept-Language header, falling back from a
+// regional locale such as fr-ca to its language, or false if no translations match.
+func (t Translations) Locale(acceptLanguage string) (string, bool) {
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if _, ok := t[tag]; ok {
+			return tag, true
+		}
+		if i := strings.Index(tag, "-"); i > 0 {
+			if _, ok := t[tag[:i]]; ok {
+				return tag[:i], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// Localize returns the display text of the given plugin's enumerated fields in the given locale.
+// Fields without a translation are omitted, and labels without one keep their original text.
+func (t Translations) Localize(locale string, plugin *model.Plugin) *model.Localization {
+	messages := t[locale]
+
+	localization := &model.Localization{
+		Locale:       locale,
+		ReleaseStage: messages["release_stage."+string(plugin.ReleaseStage)],
+	}
+	if plugin.AuthorType != "" {
+		localization.AuthorType = messages["author_type."+string(plugin.AuthorType)]
+	}
+	for _, label := range plugin.Labels {
+		key := "label." + label.Name
+		if name, ok := messages[key]; ok {
+			label.Name = name
+		}
+		if description, ok := messages[key+".description"]; ok {
+			label.Description = description
+		}
+		localization.Labels = append(localization.Labels, label)
+	}
+
+	return localization
+}
+
+// parseAcceptLanguage returns the lowercased language tags of the given Accept-Language header,
+// in order of preference, omitting wildcards and tags of quality zero.
+func parseAcceptLanguage(header string) []string {
+	type weightedTag struct {
+		tag     string
+		quality float64
+	}
+
+	var weightedTags []weightedTag
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+				quality = q
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		weightedTags = append(weightedTags, weightedTag{tag, quality})
+	}
+
+	sort.SliceStable(weightedTags, func(i, j int) bool {
+		return weightedTags[i].quality > weightedTags[j].quality
+	})
+
+	tags := make([]string, 0, len(weightedTags))
+	for _, weightedTag := range weightedTags {
+		tags = append(tags, weightedTag.tag)
+	}
+
+	return tags
+}
+
+// withLocalizations returns the given plugins annotated with the display text of their enumerated
+// fields in the locale best matching the request's Accept-Language header, copying rather than
+// modifying the plugins. The plugins are returned unchanged if no translations match.
+func withLocalizations(c *Context, w http.ResponseWriter, r *http.Request, plugins []*model.Plugin) []*model.Plugin {
+	if c.Translations == nil {
+		return plugins
+	}
+
+	w.Header().Add("Vary", "Accept-Language")
+	locale, ok := c.Translations.Locale(r.Header.Get("Accept-Language"))
+	if !ok {
+		return plugins
+	}
+	w.Header().Set("Content-Language", locale)
+
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		localizedPlugin := *plugin
+		localizedPlugin.Localization = c.Translations.Localize(locale, plugin)
+		result = append(result, &localizedPlugin)
+	}
+
+	return result
+}
@@ -0,0 +1,255 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslationsFromReader(t *testing.T) {
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := api.TranslationsFromReader(strings.NewReader("{"))
+		require.Error(t, err)
+	})
+
+	t.Run("empty locale", func(t *testing.T) {
+		_, err := api.TranslationsFromReader(strings.NewReader(`{"": {"release_stage.beta": "Beta"}}`))
+		require.Error(t, err)
+	})
+
+	t.Run("lowercases locales", func(t *testing.T) {
+		translations, err := api.TranslationsFromReader(strings.NewReader(`{"pt-BR": {"release_stage.beta": "Beta"}}`))
+		require.NoError(t, err)
+		require.Equal(t, api.Translations{"pt-br": {"release_stage.beta": "Beta"}}, translations)
+	})
+}
+
+func TestTranslationsLocale(t *testing.T) {
+	translations := api.Translations{
+		"fr":    {},
+		"pt-br": {},
+	}
+
+	testCases := []struct {
+		acceptLanguage string
+		expectedLocale string
+		expectedOK     bool
+	}{
+		{"", "", false},
+		{"*", "", false},
+		{"de", "", false},
+		{"fr", "fr", true},
+		{"FR-ca", "fr", true},
+		{"pt-BR", "pt-br", true},
+		{"pt", "", false},
+		{"de, fr;q=0.5", "fr", true},
+		{"fr;q=0.5, pt-br;q=0.8", "pt-br", true},
+		{"fr;q=0, de", "", false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.acceptLanguage, func(t *testing.T) {
+			locale, ok := translations.Locale(testCase.acceptLanguage)
+			assert.Equal(t, testCase.expectedOK, ok)
+			assert.Equal(t, testCase.expectedLocale, locale)
+		})
+	}
+}
+
+func TestLocalizedPlugins(t *testing.T) {
+	plugin := &model.Plugin{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageBeta,
+		AuthorType:   model.AuthorTypePartner,
+		Labels: []model.Label{
+			{Name: "Official", Description: "Maintained by Mattermost"},
+			{Name: "Enterprise"},
+		},
+		Manifest: &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}
+
+	logger := testlib.MakeLogger(t)
+	data, err := json.Marshal([]*model.Plugin{plugin})
+	require.NoError(t, err)
+	store, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store: store,
+		Translations: api.Translations{
+			"fr": {
+				"release_stage.beta":         "Bêta",
+				"author_type.partner":        "Partenaire",
+				"label.Official":             "Officiel",
+				"label.Official.description": "Maintenu par Mattermost",
+			},
+		},
+		Logger: logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	get := func(t *testing.T, path, acceptLanguage string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s%s", ts.URL, path), nil)
+		require.NoError(t, err)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		return resp
+	}
+
+	expectedLocalization := &model.Localization{
+		Locale:       "fr",
+		AuthorType:   "Partenaire",
+		ReleaseStage: "Bêta",
+		Labels: []model.Label{
+			{Name: "Officiel", Description: "Maintenu par Mattermost"},
+			{Name: "Enterprise"},
+		},
+	}
+
+	t.Run("listing", func(t *testing.T) {
+		resp := get(t, "/api/v1/plugins", "fr-CA,en;q=0.8")
+		defer resp.Body.Close()
+		assert.Equal(t, "fr", resp.Header.Get("Content-Language"))
+
+		var plugins []*model.Plugin
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&plugins))
+		require.Len(t, plugins, 1)
+		assert.Equal(t, model.ReleaseStageBeta, plugins[0].ReleaseStage)
+		assert.Equal(t, plugin.Labels, plugins[0].Labels)
+		assert.Equal(t, expectedLocalization, plugins[0].Localization)
+	})
+
+	t.Run("single plugin", func(t *testing.T) {
+		resp := get(t, "/api/v1/plugins/demo", "fr")
+		defer resp.Body.Close()
+
+		var result *model.Plugin
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, expectedLocalization, result.Localization)
+	})
+
+	t.Run("unsupported language", func(t *testing.T) {
+		resp := get(t, "/api/v1/plugins", "de")
+		defer resp.Body.Close()
+		assert.Empty(t, resp.Header.Get("Content-Language"))
+		assert.Equal(t, "Accept-Language", resp.Header.Get("Vary"))
+
+		var plugins []*model.Plugin
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&plugins))
+		require.Len(t, plugins, 1)
+		assert.Nil(t, plugins[0].Localization)
+	})
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+	store, err := store.New(bytes.NewReader([]byte("[]")), logger)
+	require.NoError(t, err)
+
+	setup := func(t *testing.T, translations api.Translations) *httptest.Server {
+		router := mux.NewRouter()
+		api.Register(router, &api.Context{
+			Store:        store,
+			Translations: translations,
+			Logger:       logger,
+		})
+		return httptest.NewServer(router)
+	}
+
+	get := func(t *testing.T, ts *httptest.Server, path, acceptLanguage string) (*http.Response, *model.APIError) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		require.NoError(t, err)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+		apiError, err := model.APIErrorFromReader(resp.Body)
+		require.NoError(t, err)
+		require.NotEmpty(t, apiError.RequestID)
+		require.Equal(t, resp.Header.Get("X-Request-ID"), apiError.RequestID)
+
+		return resp, apiError
+	}
+
+	t.Run("english without translations", func(t *testing.T) {
+		ts := setup(t, nil)
+		defer ts.Close()
+
+		resp, apiError := get(t, ts, "/api/v1/plugins/unknown", "fr")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Equal(t, model.ErrorCodeNotFound, apiError.Code)
+		require.Equal(t, model.ErrorMessages[model.ErrorCodeNotFound], apiError.Message)
+		require.Empty(t, resp.Header.Get("Content-Language"))
+	})
+
+	translations := api.Translations{
+		"fr": {
+			"error.not_found":   "La ressource demandée est introuvable.",
+			"error.bad_request": "La requête est invalide.",
+		},
+	}
+
+	t.Run("translated", func(t *testing.T) {
+		ts := setup(t, translations)
+		defer ts.Close()
+
+		resp, apiError := get(t, ts, "/api/v1/plugins/unknown", "fr-CA, en;q=0.5")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Equal(t, model.ErrorCodeNotFound, apiError.Code)
+		require.Equal(t, "La ressource demandée est introuvable.", apiError.Message)
+		require.Equal(t, "fr", resp.Header.Get("Content-Language"))
+		require.Equal(t, "Accept-Language", resp.Header.Get("Vary"))
+
+		resp, apiError = get(t, ts, "/api/v1/plugins?page=invalid", "fr")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Equal(t, model.ErrorCodeBadRequest, apiError.Code)
+		require.Equal(t, "La requête est invalide.", apiError.Message)
+	})
+
+	t.Run("untranslated language", func(t *testing.T) {
+		ts := setup(t, translations)
+		defer ts.Close()
+
+		resp, apiError := get(t, ts, "/api/v1/plugins/unknown", "de")
+		require.Equal(t, model.ErrorMessages[model.ErrorCodeNotFound], apiError.Message)
+		require.Empty(t, resp.Header.Get("Content-Language"))
+	})
+
+	t.Run("untranslated code", func(t *testing.T) {
+		ts := setup(t, api.Translations{"fr": {"error.bad_request": "La requête est invalide."}})
+		defer ts.Close()
+
+		resp, apiError := get(t, ts, "/api/v1/plugins/unknown", "fr")
+		require.Equal(t, model.ErrorMessages[model.ErrorCodeNotFound], apiError.Message)
+		require.Empty(t, resp.Header.Get("Content-Language"))
+	})
+}
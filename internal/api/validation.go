@@ -0,0 +1,270 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// urlCheckTimeout bounds the time taken to check that each url of an entry responds.
+const urlCheckTimeout = 10 * time.Second
+
+// EntryValidation reports the dry-run validation of a catalog entry.
+type EntryValidation struct {
+	PluginID string `json:"plugin_id,omitempty"`
+	Version  string `json:"version,omitempty"`
+	// Problems lists why the entry would be refused by the catalog, or fails verification.
+	Problems []string `json:"problems"`
+	// Verification reports the checks of the entry's bundle against its checksums and signatures,
+	// if its urls were checked.
+	Verification *PluginVerification `json:"verification,omitempty"`
+}
+
+// Passed reports whether the entry was validated without problems.
+func (v *EntryValidation) Passed() bool {
+	return len(v.Problems) == 0
+}
+
+// initEntryValidation registers the dry-run validation endpoint on the given router.
+func initEntryValidation(apiRouter *mux.Router, context *Context) {
+	addContext := func(handler contextHandlerFunc) *contextHandler {
+		return newContextHandler(context, handler)
+	}
+
+	apiRouter.Handle("/admin/plugins/validate", addContext(restrictWrites(handleValidateEntry))).Methods("POST")
+}
+
+// handleValidateEntry responds to POST /api/v1/admin/plugins/validate, validating the plugin entry
+// in the request body against the schema, the catalog and the signing keys served, without
+// serving or persisting it. Given check_urls=true, the entry's urls must also respond, and its
+// bundle is downloaded to verify its checksums and signatures.
+//
+// Any authenticated user may validate entries, such as the CI of a plugin repository, but only
+// moderators may check urls, as the server then requests whatever urls the entry gives.
+func handleValidateEntry(c *Context, w http.ResponseWriter, r *http.Request) {
+	validator, ok := unwrapStore(c.Store).(EntryValidator)
+	if !ok {
+		writeError(c, w, r, http.StatusNotFound)
+		return
+	}
+
+	userID, ok := authenticate(c, w, r)
+	if !ok {
+		return
+	}
+
+	checkURLs, err := parseBool(r.URL, "check_urls", false)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to parse query parameters")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+	if checkURLs && !c.Moderators[userID] {
+		c.Logger.Warn("Rejected url checks by a user who is not a moderator")
+		writeError(c, w, r, http.StatusForbidden)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		c.Logger.WithError(err).Warn("failed to read entry")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	plugin, err := model.PluginFromReader(bytes.NewReader(data))
+	if err != nil || plugin == nil {
+		c.Logger.WithError(err).Warn("failed to parse entry")
+		writeError(c, w, r, http.StatusBadRequest)
+		return
+	}
+
+	validation := &EntryValidation{Problems: []string{}}
+	if plugin.Manifest != nil {
+		validation.PluginID = plugin.Manifest.Id
+		validation.Version = plugin.Manifest.Version
+	}
+
+	validation.Problems = append(validation.Problems, validateEntrySchema(data)...)
+	validation.Problems = append(validation.Problems, validator.ValidateEntry(plugin, c.Logger)...)
+
+	keyring, err := signingKeyring(c.SigningKeys)
+	if err != nil {
+		c.Logger.WithError(err).Error("failed to read signing keys")
+		writeError(c, w, r, http.StatusInternalServerError)
+		return
+	}
+	validation.Problems = append(validation.Problems, validateEntrySignatures(plugin, keyring)...)
+
+	if checkURLs {
+		client := NewClient("").WithContext(r.Context())
+		client.httpClient = &http.Client{Timeout: urlCheckTimeout}
+		validation.Problems = append(validation.Problems, checkEntryURLs(client, plugin)...)
+
+		if plugin.DownloadURL != "" {
+			verification, err := client.verifyPlugin(plugin, keyring)
+			if err != nil {
+				validation.Problems = append(validation.Problems, fmt.Sprintf("failed to download bundle: %s", err))
+			} else {
+				validation.Verification = verification
+				validation.Problems = append(validation.Problems, verificationProblems(verification)...)
+			}
+		}
+	}
+
+	c.Logger.WithField("problems", len(validation.Problems)).Info("Validated entry")
+
+	w.Header().Set("Content-Type", "application/json")
+	outputJSON(c, w, validation)
+}
+
+// validateEntrySchema returns how the given json-encoded entry fails to match the plugins schema.
+func validateEntrySchema(data []byte) []string {
+	database := append(append([]byte("["), data...), ']')
+
+	err := model.ValidateAgainstSchema(bytes.NewReader(database))
+	if err == nil {
+		return nil
+	}
+	schemaErr, ok := err.(*model.SchemaError)
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	problems := make([]string, 0, len(schemaErr.Errors))
+	for _, violation := range schemaErr.Errors {
+		// Violations are reported as of the database wrapping the entry.
+		problems = append(problems, "schema: "+strings.TrimPrefix(violation, "0."))
+	}
+
+	return problems
+}
+
+// signingKeyring reads the given signing keys still valid into a keyring.
+func signingKeyring(signingKeys []*model.PublicKey) (openpgp.EntityList, error) {
+	now := time.Now()
+
+	var keyring openpgp.EntityList
+	for _, signingKey := range signingKeys {
+		if !signingKey.IsValidAt(now) {
+			continue
+		}
+
+		entities, err := ReadPublicKeys(strings.NewReader(signingKey.PublicKey))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read signing key %s", signingKey.Fingerprint)
+		}
+		keyring = append(keyring, entities...)
+	}
+
+	return keyring, nil
+}
+
+// validateEntrySignatures returns the signatures of the given plugin that are null, cannot be
+// parsed or, given a keyring, were not issued by one of its keys.
+func validateEntrySignatures(plugin *model.Plugin, keyring openpgp.EntityList) []string {
+	signatures := plugin.Signatures
+	if len(signatures) == 0 {
+		signatures = plugin.AllSignatures()
+	}
+
+	var problems []string
+	for i, signature := range signatures {
+		if signature == nil {
+			problems = append(problems, fmt.Sprintf("signature %d: empty", i))
+			continue
+		}
+		keyID, err := signatureIssuer(signature.Signature)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("signature %d: %s", i, err))
+			continue
+		}
+		if len(keyring) > 0 && len(keyring.KeysById(keyID)) == 0 {
+			problems = append(problems, fmt.Sprintf("signature %d: issued by unknown key %016x", i, keyID))
+		}
+	}
+
+	return problems
+}
+
+// checkEntryURLs returns the urls of the given plugin that do not respond successfully.
+func checkEntryURLs(client *Client, plugin *model.Plugin) []string {
+	urls := map[string]string{
+		"homepage_url":      plugin.HomepageURL,
+		"release_notes_url": plugin.ReleaseNotesURL,
+		"download_url":      plugin.DownloadURL,
+		"icon_url":          plugin.IconURL,
+		"banner_image_url":  plugin.BannerImageURL,
+	}
+	for i, screenshot := range plugin.Screenshots {
+		urls[fmt.Sprintf("screenshots.%d", i)] = screenshot
+	}
+	for platform, bundle := range plugin.Platforms {
+		if bundle != nil {
+			urls["platforms."+platform+".download_url"] = bundle.DownloadURL
+		}
+	}
+
+	fields := make([]string, 0, len(urls))
+	for field, u := range urls {
+		// Data URIs, such as inline screenshots, need no checking.
+		if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var problems []string
+	for _, field := range fields {
+		if err := checkURL(client, urls[field]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s: %s", field, urls[field], err))
+		}
+	}
+
+	return problems
+}
+
+// checkURL verifies the given url responds successfully, falling back to GET for servers refusing
+// HEAD requests.
+func checkURL(client *Client, u string) error {
+	resp, err := client.doRequest(http.MethodHead, u, nil)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		closeBody(resp)
+		resp, err = client.doGet(u)
+	}
+	if err != nil {
+		return err
+	}
+	closeBody(resp)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("responded %s", resp.Status)
+	}
+
+	return nil
+}
+
+// verificationProblems describes the failed checks of the given verification.
+func verificationProblems(verification *PluginVerification) []string {
+	var problems []string
+	if verification.ChecksumError != "" {
+		problems = append(problems, "checksums: "+verification.ChecksumError)
+	}
+	for i, signature := range verification.Signatures {
+		if signature.Trusted && signature.Error != "" {
+			problems = append(problems, fmt.Sprintf("signature %d: %s", i, signature.Error))
+		}
+	}
+
+	return problems
+}
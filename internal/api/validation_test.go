@@ -0,0 +1,206 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestValidatePluginEntry(t *testing.T) {
+	logger := testlib.MakeLogger(t)
+	entity := makeKey(t)
+	otherEntity := makeKey(t)
+	bundle := []byte("plugin bundle contents")
+
+	checksumsWriter := model.NewChecksumsWriter()
+	_, _ = checksumsWriter.Write(bundle)
+	checksums := checksumsWriter.Checksums()
+
+	downloadURL, tearDownBundleServer := setupBundleServer(t, bundle)
+	defer tearDownBundleServer()
+
+	signingKeys, err := api.DescribePublicKeys(openpgp.EntityList{entity})
+	require.NoError(t, err)
+
+	existing := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		OldIDs:       []string{"legacy"},
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}
+	initialStore, err := store.NewFromPlugins([]*model.Plugin{existing}, logger, store.Options{})
+	require.NoError(t, err)
+	served := catalog.New(initialStore)
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:         served,
+		SigningKeys:   signingKeys,
+		Authenticator: api.TokenAuthenticator{"ci-token": "ci", "moderator-token": "moderator"},
+		Moderators:    map[string]bool{"moderator": true},
+		Logger:        logger,
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	client := api.NewClient(ts.URL)
+	client.Token = "ci-token"
+	moderatorClient := api.NewClient(ts.URL)
+	moderatorClient.Token = "moderator-token"
+
+	makePlugin := func(signatures ...*model.Signature) *model.Plugin {
+		return &model.Plugin{
+			HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+			DownloadURL:  downloadURL,
+			Checksums:    checksums,
+			Signatures:   signatures,
+			ReleaseStage: model.ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0"},
+		}
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		_, err := api.NewClient(ts.URL).ValidatePluginEntry(makePlugin(), false)
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusUnauthorized}, err)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		validation, err := client.ValidatePluginEntry(makePlugin(&model.Signature{Signature: sign(t, entity, bundle, false), PublicKeyHash: "hash"}), false)
+		require.NoError(t, err)
+		require.True(t, validation.Passed(), validation.Problems)
+		require.Equal(t, "demo", validation.PluginID)
+		require.Equal(t, "0.2.0", validation.Version)
+		require.Nil(t, validation.Verification)
+	})
+
+	t.Run("invalid against the schema", func(t *testing.T) {
+		plugin := makePlugin()
+		plugin.ReleaseStage = "unreleased"
+		validation, err := client.ValidatePluginEntry(plugin, false)
+		require.NoError(t, err)
+		require.False(t, validation.Passed())
+		require.Contains(t, strings.Join(validation.Problems, "\n"), "schema: ")
+	})
+
+	t.Run("conflicting with the catalog", func(t *testing.T) {
+		plugin := makePlugin()
+		plugin.Manifest.Id = "other"
+		plugin.OldIDs = []string{"legacy"}
+		validation, err := client.ValidatePluginEntry(plugin, false)
+		require.NoError(t, err)
+		require.Len(t, validation.Problems, 1)
+		require.Contains(t, validation.Problems[0], "old id legacy is claimed by both")
+	})
+
+	t.Run("signed by an unknown key", func(t *testing.T) {
+		validation, err := client.ValidatePluginEntry(makePlugin(
+			&model.Signature{Signature: sign(t, otherEntity, bundle, false), PublicKeyHash: "hash"},
+			&model.Signature{Signature: "invalid", PublicKeyHash: "other"},
+		), false)
+		require.NoError(t, err)
+		require.Len(t, validation.Problems, 2)
+		require.Contains(t, validation.Problems[0], "signature 0: issued by unknown key")
+		require.Contains(t, validation.Problems[1], "signature 1: ")
+	})
+
+	t.Run("null signature", func(t *testing.T) {
+		validation, err := client.ValidatePluginEntry(makePlugin(
+			&model.Signature{Signature: sign(t, entity, bundle, false), PublicKeyHash: "hash"},
+			nil,
+		), false)
+		require.NoError(t, err)
+		require.Contains(t, validation.Problems, "signature 1: empty")
+	})
+
+	t.Run("urls checked only for moderators", func(t *testing.T) {
+		plugin := makePlugin(&model.Signature{Signature: sign(t, entity, bundle, false), PublicKeyHash: "hash"})
+		plugin.HomepageURL = "http://169.254.169.254/latest/meta-data/"
+		_, err := client.ValidatePluginEntry(plugin, true)
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+
+	t.Run("urls checked", func(t *testing.T) {
+		plugin := makePlugin(&model.Signature{Signature: sign(t, entity, bundle, false), PublicKeyHash: "hash"})
+		plugin.HomepageURL = ts.URL + "/api/v1/plugins"
+		validation, err := moderatorClient.ValidatePluginEntry(plugin, true)
+		require.NoError(t, err)
+		require.True(t, validation.Passed(), validation.Problems)
+		require.NotNil(t, validation.Verification)
+		require.True(t, validation.Verification.Verified())
+	})
+
+	t.Run("dead urls and a mismatched bundle", func(t *testing.T) {
+		plugin := makePlugin(&model.Signature{Signature: sign(t, entity, []byte("other contents"), false), PublicKeyHash: "hash"})
+		plugin.HomepageURL = ts.URL + "/missing"
+		plugin.Checksums = &model.Checksums{SHA256: checksums.SHA512[:64]}
+		validation, err := moderatorClient.ValidatePluginEntry(plugin, true)
+		require.NoError(t, err)
+		require.Len(t, validation.Problems, 3)
+		require.Contains(t, validation.Problems[0], "homepage_url "+ts.URL+"/missing: responded 404")
+		require.Contains(t, validation.Problems[1], "checksums: checksum mismatch")
+		require.Contains(t, validation.Problems[2], "signature 0: failed to verify signature")
+	})
+
+	t.Run("unparseable entry", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/plugins/validate", strings.NewReader(`{`))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer ci-token")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("catalog unchanged", func(t *testing.T) {
+		plugins := served.AllPlugins()
+		require.Len(t, plugins, 1)
+		require.Equal(t, "0.1.0", plugins[0].Manifest.Version)
+	})
+
+	t.Run("store without validation", func(t *testing.T) {
+		router := mux.NewRouter()
+		api.Register(router, &api.Context{
+			Store:         initialStore,
+			Authenticator: api.TokenAuthenticator{"ci-token": "ci"},
+			Logger:        logger,
+		})
+		ts := httptest.NewServer(router)
+		defer ts.Close()
+
+		client := api.NewClient(ts.URL)
+		client.Token = "ci-token"
+		_, err := client.ValidatePluginEntry(makePlugin(), false)
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("outside the write allowlist", func(t *testing.T) {
+		allowlist, err := api.ParseIPAllowlist([]string{"10.0.0.0/8"})
+		require.NoError(t, err)
+
+		router := mux.NewRouter()
+		api.Register(router, &api.Context{
+			Store:          served,
+			Authenticator:  api.TokenAuthenticator{"ci-token": "ci"},
+			WriteAllowlist: allowlist,
+			Logger:         logger,
+		})
+		ts := httptest.NewServer(router)
+		defer ts.Close()
+
+		client := api.NewClient(ts.URL)
+		client.Token = "ci-token"
+		_, err = client.ValidatePluginEntry(makePlugin(), false)
+		require.Equal(t, &api.StatusError{StatusCode: http.StatusForbidden}, err)
+	})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// PluginVerification reports the checks of a downloaded plugin bundle against the checksums and
+// signatures recorded by the marketplace.
+type PluginVerification struct {
+	PluginID    string `json:"plugin_id"`
+	Version     string `json:"version"`
+	DownloadURL string `json:"download_url"`
+	// Checksums are computed from the downloaded bundle.
+	Checksums *model.Checksums `json:"checksums"`
+	// ChecksumError describes why the bundle does not match its recorded checksums, if it does
+	// not, including when none are recorded.
+	ChecksumError string                   `json:"checksum_error,omitempty"`
+	Signatures    []*SignatureVerification `json:"signatures"`
+}
+
+// SignatureVerification reports the check of a single signature of a plugin bundle.
+type SignatureVerification struct {
+	// KeyID is the hex-encoded id of the key that issued the signature, if it could be parsed.
+	KeyID string `json:"key_id,omitempty"`
+	// Trusted reports whether the signature was issued by a key in the keyring. Signatures by
+	// other keys are not checked.
+	Trusted bool `json:"trusted"`
+	// Error describes why the signature could not be parsed or failed verification, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// Verified reports whether the bundle matches its recorded checksums and bears at least one
+// signature by a trusted key, with every signature by a trusted key verifying.
+func (v *PluginVerification) Verified() bool {
+	if v.ChecksumError != "" {
+		return false
+	}
+
+	verified := false
+	for _, signature := range v.Signatures {
+		if !signature.Trusted {
+			continue
+		}
+		if signature.Error != "" {
+			return false
+		}
+		verified = true
+	}
+
+	return verified
+}
+
+// VerifyPlugin downloads the bundle of the given plugin, checking it against the plugin's
+// recorded checksums and each of its signatures issued by a key in the given keyring.
+//
+// An error is returned only if the bundle cannot be downloaded. Failed checks are reported by the
+// returned verification instead.
+func (c *Client) VerifyPlugin(plugin *model.Plugin, keyring openpgp.EntityList) (*PluginVerification, error) {
+	if plugin.DownloadURL == "" {
+		return nil, errors.New("plugin has no download url")
+	}
+	if len(keyring) == 0 {
+		return nil, errors.New("no public keys to verify signatures against")
+	}
+
+	return c.verifyPlugin(plugin, keyring)
+}
+
+// CheckPlugin downloads and checks the bundle of the given plugin like VerifyPlugin, without
+// requiring a keyring: no signature is checked if the given keyring is empty.
+func (c *Client) CheckPlugin(plugin *model.Plugin, keyring openpgp.EntityList) (*PluginVerification, error) {
+	if plugin.DownloadURL == "" {
+		return nil, errors.New("plugin has no download url")
+	}
+
+	return c.verifyPlugin(plugin, keyring)
+}
+
+// verifyPlugin downloads and checks the bundle of the given plugin like VerifyPlugin, checking no
+// signature if the given keyring is empty.
+func (c *Client) verifyPlugin(plugin *model.Plugin, keyring openpgp.EntityList) (*PluginVerification, error) {
+	resp, err := c.doGet(plugin.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorFromResponse(resp)
+	}
+
+	var bundle bytes.Buffer
+	checksumsWriter := model.NewChecksumsWriter()
+	if _, err := io.Copy(io.MultiWriter(&bundle, checksumsWriter), resp.Body); err != nil {
+		return nil, errors.Wrap(err, "failed to download plugin bundle")
+	}
+
+	verification := &PluginVerification{
+		DownloadURL: plugin.DownloadURL,
+		Checksums:   checksumsWriter.Checksums(),
+		Signatures:  []*SignatureVerification{},
+	}
+	if plugin.Manifest != nil {
+		verification.PluginID = plugin.Manifest.Id
+		verification.Version = plugin.Manifest.Version
+	}
+
+	if plugin.Checksums == nil {
+		verification.ChecksumError = "no checksums recorded"
+	} else if err := plugin.Checksums.Verify(verification.Checksums); err != nil {
+		verification.ChecksumError = err.Error()
+	}
+
+	for _, signature := range plugin.AllSignatures() {
+		verification.Signatures = append(verification.Signatures, verifySignature(bundle.Bytes(), signature.Signature, keyring))
+	}
+
+	return verification, nil
+}
+
+// verifySignature checks the given base64-encoded detached signature of the given bundle, if it was
+// issued by a key in the given keyring.
+func verifySignature(bundle []byte, signature string, keyring openpgp.EntityList) *SignatureVerification {
+	keyID, err := signatureIssuer(signature)
+	if err != nil {
+		return &SignatureVerification{Error: err.Error()}
+	}
+
+	result := &SignatureVerification{
+		KeyID:   fmt.Sprintf("%016x", keyID),
+		Trusted: len(keyring.KeysById(keyID)) > 0,
+	}
+	if !result.Trusted {
+		return result
+	}
+
+	if err := VerifyPluginSignature(bytes.NewReader(bundle), signature, keyring); err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
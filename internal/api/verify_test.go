@@ -0,0 +1,108 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestVerifyPlugin(t *testing.T) {
+	entity := makeKey(t)
+	otherEntity := makeKey(t)
+	bundle := []byte("plugin bundle contents")
+
+	checksumsWriter := model.NewChecksumsWriter()
+	_, _ = checksumsWriter.Write(bundle)
+	checksums := checksumsWriter.Checksums()
+
+	downloadURL, tearDownBundleServer := setupBundleServer(t, bundle)
+	defer tearDownBundleServer()
+
+	keyring := openpgp.EntityList{entity}
+	client := api.NewClient("")
+
+	makePlugin := func(checksums *model.Checksums, signatures ...*model.Signature) *model.Plugin {
+		return &model.Plugin{
+			DownloadURL: downloadURL,
+			Checksums:   checksums,
+			Signatures:  signatures,
+			Manifest:    &mattermostModel.Manifest{Id: "demo", Version: "0.1.0"},
+		}
+	}
+
+	t.Run("verified", func(t *testing.T) {
+		verification, err := client.VerifyPlugin(makePlugin(checksums,
+			&model.Signature{Signature: sign(t, otherEntity, bundle, false), PublicKeyHash: "other"},
+			&model.Signature{Signature: sign(t, entity, bundle, true), PublicKeyHash: "trusted"},
+		), keyring)
+		require.NoError(t, err)
+		require.True(t, verification.Verified())
+		require.Equal(t, "demo", verification.PluginID)
+		require.Equal(t, "0.1.0", verification.Version)
+		require.Equal(t, checksums, verification.Checksums)
+		require.Empty(t, verification.ChecksumError)
+		require.Len(t, verification.Signatures, 2)
+		require.False(t, verification.Signatures[0].Trusted)
+		require.True(t, verification.Signatures[1].Trusted)
+		require.Empty(t, verification.Signatures[1].Error)
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		verification, err := client.VerifyPlugin(makePlugin(&model.Checksums{SHA256: checksums.SHA512[:64]},
+			&model.Signature{Signature: sign(t, entity, bundle, false)},
+		), keyring)
+		require.NoError(t, err)
+		require.False(t, verification.Verified())
+		require.Contains(t, verification.ChecksumError, "checksum mismatch")
+	})
+
+	t.Run("no checksums", func(t *testing.T) {
+		verification, err := client.VerifyPlugin(makePlugin(nil,
+			&model.Signature{Signature: sign(t, entity, bundle, false)},
+		), keyring)
+		require.NoError(t, err)
+		require.False(t, verification.Verified())
+		require.Equal(t, "no checksums recorded", verification.ChecksumError)
+	})
+
+	t.Run("invalid trusted signature", func(t *testing.T) {
+		verification, err := client.VerifyPlugin(makePlugin(checksums,
+			&model.Signature{Signature: sign(t, entity, bundle, false)},
+			&model.Signature{Signature: sign(t, entity, []byte("other contents"), false)},
+		), keyring)
+		require.NoError(t, err)
+		require.False(t, verification.Verified())
+		require.Contains(t, verification.Signatures[1].Error, "failed to verify signature")
+	})
+
+	t.Run("no signature from a trusted key", func(t *testing.T) {
+		verification, err := client.VerifyPlugin(makePlugin(checksums,
+			&model.Signature{Signature: sign(t, otherEntity, bundle, false)},
+		), keyring)
+		require.NoError(t, err)
+		require.False(t, verification.Verified())
+	})
+
+	t.Run("unparseable signature", func(t *testing.T) {
+		verification, err := client.VerifyPlugin(makePlugin(checksums,
+			&model.Signature{Signature: "invalid"},
+		), keyring)
+		require.NoError(t, err)
+		require.False(t, verification.Verified())
+		require.NotEmpty(t, verification.Signatures[0].Error)
+	})
+
+	t.Run("download failure", func(t *testing.T) {
+		plugin := makePlugin(checksums)
+		plugin.DownloadURL += ".missing"
+		_, err := client.VerifyPlugin(plugin, keyring)
+		require.Error(t, err)
+
+		_, err = client.VerifyPlugin(makePlugin(checksums), nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,294 @@
+// Package apikeys issues API keys identifying the partners integrating with the marketplace,
+// accounting for and rate limiting the requests made with each, and persisting them to a
+// pluggable backend.
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// TokenPrefix introduces every API key token, distinguishing it from other bearer tokens.
+const TokenPrefix = "mpk_"
+
+// tokenSize is the number of random bytes in a token.
+const tokenSize = 32
+
+var (
+	// ErrNotFound is returned when the requested API key does not exist.
+	ErrNotFound = errors.New("api key not found")
+	// ErrAlreadyRevoked is returned when revoking an API key that is already revoked.
+	ErrAlreadyRevoked = errors.New("api key already revoked")
+	// ErrInvalidToken is returned when a token matches no API key, or a revoked one.
+	ErrInvalidToken = errors.New("invalid api key token")
+	// ErrRateLimited is returned when an API key exceeds its rate limit.
+	ErrRateLimited = errors.New("api key rate limited")
+)
+
+// Backend persists API keys.
+type Backend interface {
+	// Load returns all persisted API keys, or none if nothing was persisted yet.
+	Load() ([]*model.APIKey, error)
+	// Save replaces the persisted API keys with the given API keys.
+	Save(keys []*model.APIKey) error
+}
+
+// window counts the requests made with a key during the minute starting at start.
+type window struct {
+	start    time.Time
+	requests int
+}
+
+// Registry holds every API key, ordered by creation.
+//
+// Keys are saved as soon as they are created or revoked, while their usage is accounted in memory
+// and saved by Flush.
+type Registry struct {
+	backend Backend
+	now     func() time.Time
+	newID   func() string
+
+	lock    sync.Mutex
+	keys    []*model.APIKey
+	windows map[string]*window
+	dirty   bool
+}
+
+// NewRegistry creates a registry initialized with the API keys persisted to the given backend.
+func NewRegistry(backend Backend) (*Registry, error) {
+	keys, err := backend.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load api keys")
+	}
+
+	for _, key := range keys {
+		if err := key.IsValid(); err != nil {
+			return nil, errors.Wrapf(err, "invalid api key %s", key.ID)
+		}
+	}
+
+	return &Registry{
+		backend: backend,
+		now:     time.Now,
+		newID:   mattermostModel.NewId,
+		keys:    keys,
+		windows: map[string]*window{},
+	}, nil
+}
+
+// hashToken returns the hex-encoded SHA-256 digest of the given token.
+func hashToken(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
+
+// newToken generates a random token.
+func newToken() (string, error) {
+	data := make([]byte, tokenSize)
+	if _, err := rand.Read(data); err != nil {
+		return "", errors.Wrap(err, "failed to generate token")
+	}
+
+	return TokenPrefix + hex.EncodeToString(data), nil
+}
+
+// Create issues a new API key to the named partner on behalf of the given moderator, returning it
+// along with its token.
+func (r *Registry) Create(name, creatorID string, rateLimit int) (*model.IssuedAPIKey, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &model.APIKey{
+		ID:        r.newID(),
+		Name:      strings.TrimSpace(name),
+		TokenHash: hashToken(token),
+		RateLimit: rateLimit,
+		CreatorID: creatorID,
+		CreatedAt: r.now().UTC(),
+	}
+	if err := key.IsValid(); err != nil {
+		return nil, errors.Wrap(err, "invalid api key")
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.save(append(r.keys, key)); err != nil {
+		return nil, err
+	}
+
+	result := *key
+	return &model.IssuedAPIKey{APIKey: &result, Token: token}, nil
+}
+
+// Revoke stops accepting the token of the given API key.
+func (r *Registry) Revoke(id string) (*model.APIKey, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	index := r.find(id)
+	if index < 0 {
+		return nil, ErrNotFound
+	}
+	if r.keys[index].RevokedAt != nil {
+		return nil, ErrAlreadyRevoked
+	}
+
+	revoked := *r.keys[index]
+	revokedAt := r.now().UTC()
+	revoked.RevokedAt = &revokedAt
+
+	keys := append([]*model.APIKey(nil), r.keys...)
+	keys[index] = &revoked
+	if err := r.save(keys); err != nil {
+		return nil, err
+	}
+	delete(r.windows, id)
+
+	result := revoked
+	return &result, nil
+}
+
+// Use accounts for a request made with the given token, returning the API key it was issued as.
+// Requests exceeding the key's rate limit are not counted, and return the key with ErrRateLimited.
+func (r *Registry) Use(token string) (*model.APIKey, error) {
+	tokenHash := hashToken(token)
+	now := r.now().UTC()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	index := -1
+	for i, key := range r.keys {
+		if key.TokenHash == tokenHash {
+			index = i
+			break
+		}
+	}
+	if index < 0 || r.keys[index].RevokedAt != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key := r.keys[index]
+	if key.RateLimit > 0 {
+		current := r.windows[key.ID]
+		if current == nil || now.Sub(current.start) >= time.Minute {
+			current = &window{start: now}
+			r.windows[key.ID] = current
+		}
+		if current.requests >= key.RateLimit {
+			result := *key
+			return &result, ErrRateLimited
+		}
+		current.requests++
+	}
+
+	used := *key
+	used.Requests++
+	used.LastUsedAt = &now
+	r.keys[index] = &used
+	r.dirty = true
+
+	result := used
+	return &result, nil
+}
+
+// Flush saves the usage accounted since the last flush.
+func (r *Registry) Flush() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.dirty {
+		return nil
+	}
+
+	return r.save(r.keys)
+}
+
+// Run flushes the registry at the given interval until done is closed, flushing a final time
+// before returning.
+func (r *Registry) Run(interval time.Duration, done <-chan struct{}, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Flush(); err != nil {
+				onError(err)
+			}
+		case <-done:
+			if err := r.Flush(); err != nil {
+				onError(err)
+			}
+			return
+		}
+	}
+}
+
+// save persists the given API keys before adopting them. The lock must be held.
+func (r *Registry) save(keys []*model.APIKey) error {
+	if err := r.backend.Save(keys); err != nil {
+		return errors.Wrap(err, "failed to save api keys")
+	}
+
+	r.keys = keys
+	r.dirty = false
+
+	return nil
+}
+
+// find returns the index of the given API key, or -1 if none. The lock must be held.
+func (r *Registry) find(id string) int {
+	for i, key := range r.keys {
+		if key.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// GetAPIKey returns the given API key.
+func (r *Registry) GetAPIKey(id string) (*model.APIKey, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	index := r.find(id)
+	if index < 0 {
+		return nil, ErrNotFound
+	}
+
+	result := *r.keys[index]
+	return &result, nil
+}
+
+// GetAPIKeys returns every API key, most recent first.
+func (r *Registry) GetAPIKeys() []*model.APIKey {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result := make([]*model.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		copied := *key
+		result = append(result, &copied)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result
+}
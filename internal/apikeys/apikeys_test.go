@@ -0,0 +1,146 @@
+package apikeys
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apikeys")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys.json")
+
+	now := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+	nextID := 0
+	newRegistry := func(t *testing.T) *Registry {
+		registry, err := NewRegistry(&FileBackend{Path: path})
+		require.NoError(t, err)
+		registry.now = func() time.Time { return now }
+		registry.newID = func() string {
+			nextID++
+			return fmt.Sprintf("key%d", nextID)
+		}
+
+		return registry
+	}
+
+	registry := newRegistry(t)
+
+	t.Run("no keys", func(t *testing.T) {
+		require.Empty(t, registry.GetAPIKeys())
+
+		_, err := registry.GetAPIKey("unknown")
+		require.Equal(t, ErrNotFound, err)
+
+		_, err = registry.Use(TokenPrefix + "unknown")
+		require.Equal(t, ErrInvalidToken, err)
+	})
+
+	acme, err := registry.Create("Acme", "moderator", 0)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(acme.Token, TokenPrefix))
+	require.Equal(t, &model.APIKey{
+		ID:        "key1",
+		Name:      "Acme",
+		TokenHash: hashToken(acme.Token),
+		CreatorID: "moderator",
+		CreatedAt: now,
+	}, acme.APIKey)
+
+	now = now.Add(time.Hour)
+	limited, err := registry.Create("Limited", "moderator", 2)
+	require.NoError(t, err)
+	require.NotEqual(t, acme.Token, limited.Token)
+
+	t.Run("usage", func(t *testing.T) {
+		key, err := registry.Use(acme.Token)
+		require.NoError(t, err)
+		require.Equal(t, "key1", key.ID)
+		require.EqualValues(t, 1, key.Requests)
+		require.Equal(t, now, *key.LastUsedAt)
+
+		_, err = registry.Use(acme.Token)
+		require.NoError(t, err)
+
+		key, err = registry.GetAPIKey("key1")
+		require.NoError(t, err)
+		require.EqualValues(t, 2, key.Requests)
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			_, err := registry.Use(limited.Token)
+			require.NoError(t, err)
+		}
+
+		key, err := registry.Use(limited.Token)
+		require.Equal(t, ErrRateLimited, err)
+		require.Equal(t, "key2", key.ID)
+		require.EqualValues(t, 2, key.Requests)
+
+		now = now.Add(time.Minute)
+		key, err = registry.Use(limited.Token)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, key.Requests)
+	})
+
+	t.Run("usage is persisted on flush", func(t *testing.T) {
+		key, err := newRegistry(t).GetAPIKey("key1")
+		require.NoError(t, err)
+		require.EqualValues(t, 0, key.Requests)
+
+		require.NoError(t, registry.Flush())
+
+		key, err = newRegistry(t).GetAPIKey("key1")
+		require.NoError(t, err)
+		require.EqualValues(t, 2, key.Requests)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		revoked, err := registry.Revoke("key1")
+		require.NoError(t, err)
+		require.Equal(t, now, *revoked.RevokedAt)
+
+		_, err = registry.Use(acme.Token)
+		require.Equal(t, ErrInvalidToken, err)
+
+		_, err = registry.Revoke("key1")
+		require.Equal(t, ErrAlreadyRevoked, err)
+
+		_, err = registry.Revoke("unknown")
+		require.Equal(t, ErrNotFound, err)
+
+		key, err := newRegistry(t).GetAPIKey("key1")
+		require.NoError(t, err)
+		require.NotNil(t, key.RevokedAt)
+	})
+
+	t.Run("most recent first", func(t *testing.T) {
+		keys := registry.GetAPIKeys()
+		require.Len(t, keys, 2)
+		require.Equal(t, "key2", keys[0].ID)
+		require.Equal(t, "key1", keys[1].ID)
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		_, err := registry.Create(" ", "moderator", 0)
+		require.Error(t, err)
+	})
+
+	t.Run("tokens are not persisted", func(t *testing.T) {
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), acme.Token)
+		require.NotContains(t, string(data), limited.Token)
+	})
+}
@@ -0,0 +1,256 @@
+// Package audit periodically re-verifies a sample of the plugin versions served, checking that
+// their bundles can still be downloaded and still match their recorded checksums and signatures,
+// so that rot in a long-lived catalog is caught rather than discovered by the servers installing
+// it.
+package audit
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// DefaultSampleSize is the number of plugin versions checked by each audit.
+const DefaultSampleSize = 20
+
+// Source lists every plugin version served, such as a catalog.
+type Source interface {
+	AllPlugins() []*model.Plugin
+}
+
+// Options configures an auditor.
+type Options struct {
+	// SampleSize is the number of plugin versions checked by each audit, chosen at random.
+	// Defaults to DefaultSampleSize.
+	SampleSize int
+	// Keyring holds the keys trusted to sign plugin bundles. Signatures by other keys, and every
+	// signature if empty, are not checked.
+	Keyring openpgp.EntityList
+	// Client downloads the bundles checked. Defaults to a client without a server address.
+	Client *api.Client
+	// OnDrift, if set, is called with each finding not also found by the previous check of the
+	// same plugin version, such as to notify of it.
+	OnDrift func(finding *model.AuditFinding)
+}
+
+// Auditor checks samples of the plugin versions served, reporting those that drifted from what
+// the catalog records.
+type Auditor struct {
+	client     *api.Client
+	keyring    openpgp.EntityList
+	sampleSize int
+	onDrift    func(finding *model.AuditFinding)
+	logger     logrus.FieldLogger
+	now        func() time.Time
+	random     *rand.Rand
+
+	lock    sync.Mutex
+	audits  int64
+	checked int64
+	last    *model.AuditReport
+	// drifted holds the findings of the latest check of each plugin version, without their
+	// errors.
+	drifted map[model.AuditFinding]bool
+}
+
+// New creates an auditor.
+func New(logger logrus.FieldLogger, options Options) *Auditor {
+	a := &Auditor{
+		client:     options.Client,
+		keyring:    options.Keyring,
+		sampleSize: options.SampleSize,
+		onDrift:    options.OnDrift,
+		logger:     logger,
+		now:        time.Now,
+		random:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		drifted:    map[model.AuditFinding]bool{},
+	}
+	if a.client == nil {
+		a.client = api.NewClient("")
+	}
+	if a.sampleSize <= 0 {
+		a.sampleSize = DefaultSampleSize
+	}
+
+	return a
+}
+
+// Audit checks a sample of the given plugin versions, returning the findings of those that
+// drifted.
+func (a *Auditor) Audit(plugins []*model.Plugin) *model.AuditReport {
+	sample := a.sample(plugins)
+
+	report := &model.AuditReport{
+		AuditedAt: a.now().UTC(),
+		Checked:   len(sample),
+		Findings:  []*model.AuditFinding{},
+	}
+	for _, plugin := range sample {
+		findings := a.check(plugin)
+		report.Findings = append(report.Findings, findings...)
+		a.recordCheck(plugin, findings)
+	}
+
+	a.lock.Lock()
+	a.audits++
+	a.checked += int64(report.Checked)
+	a.last = report
+	a.lock.Unlock()
+
+	return report
+}
+
+// Run audits the plugin versions of the given source at the given interval until done is closed.
+func (a *Auditor) Run(source Source, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			report := a.Audit(source.AllPlugins())
+			logger := a.logger.WithFields(logrus.Fields{
+				"checked":  report.Checked,
+				"findings": len(report.Findings),
+			})
+			if len(report.Findings) > 0 {
+				logger.Warn("Catalog audit found drift")
+			} else {
+				logger.Debug("Catalog audit found no drift")
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// sample chooses up to the sample size of the given plugin versions at random, ignoring those
+// without a bundle to check.
+func (a *Auditor) sample(plugins []*model.Plugin) []*model.Plugin {
+	var candidates []*model.Plugin
+	for _, plugin := range plugins {
+		if plugin.Manifest != nil && plugin.DownloadURL != "" {
+			candidates = append(candidates, plugin)
+		}
+	}
+
+	a.lock.Lock()
+	a.random.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	a.lock.Unlock()
+
+	if len(candidates) > a.sampleSize {
+		candidates = candidates[:a.sampleSize]
+	}
+
+	return candidates
+}
+
+// check downloads and checks the bundle of the given plugin version.
+func (a *Auditor) check(plugin *model.Plugin) []*model.AuditFinding {
+	finding := func(check model.AuditCheck, err string) *model.AuditFinding {
+		return &model.AuditFinding{
+			PluginID:    plugin.Manifest.Id,
+			Version:     plugin.Manifest.Version,
+			DownloadURL: plugin.DownloadURL,
+			Check:       check,
+			Error:       err,
+		}
+	}
+
+	verification, err := a.client.CheckPlugin(plugin, a.keyring)
+	if err != nil {
+		return []*model.AuditFinding{finding(model.AuditCheckDownload, err.Error())}
+	}
+
+	var findings []*model.AuditFinding
+	// Entries recorded without checksums have none to drift from.
+	if plugin.Checksums != nil && verification.ChecksumError != "" {
+		findings = append(findings, finding(model.AuditCheckChecksum, verification.ChecksumError))
+	}
+	for _, signature := range verification.Signatures {
+		if signature.Trusted && signature.Error != "" {
+			findings = append(findings, finding(model.AuditCheckSignature, signature.Error))
+		}
+	}
+
+	return findings
+}
+
+// recordCheck replaces the findings of the previous check of the given plugin version, reporting
+// those not found then as drift.
+func (a *Auditor) recordCheck(plugin *model.Plugin, findings []*model.AuditFinding) {
+	a.lock.Lock()
+	previous := map[model.AuditFinding]bool{}
+	for key := range a.drifted {
+		if key.PluginID == plugin.Manifest.Id && key.Version == plugin.Manifest.Version {
+			previous[key] = true
+			delete(a.drifted, key)
+		}
+	}
+
+	var drifted []*model.AuditFinding
+	for _, finding := range findings {
+		// Findings are told apart by their check alone, errors varying between downloads.
+		key := *finding
+		key.Error = ""
+		if !previous[key] {
+			drifted = append(drifted, finding)
+		}
+		a.drifted[key] = true
+	}
+	a.lock.Unlock()
+
+	for _, finding := range drifted {
+		a.logger.WithFields(logrus.Fields{
+			"plugin_id": finding.PluginID,
+			"version":   finding.Version,
+			"check":     finding.Check,
+			"error":     finding.Error,
+		}).Warn("Plugin version drifted")
+		if a.onDrift != nil {
+			a.onDrift(finding)
+		}
+	}
+}
+
+// WriteMetrics writes the audits performed and the drift found by the latest one in the
+// Prometheus text exposition format.
+func (a *Auditor) WriteMetrics(w io.Writer) error {
+	a.lock.Lock()
+	audits, checked, last := a.audits, a.checked, a.last
+	a.lock.Unlock()
+
+	drift := map[model.AuditCheck]int{}
+	if last != nil {
+		for _, finding := range last.Findings {
+			drift[finding.Check]++
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "# HELP marketplace_audit_runs_total Consistency audits of the catalog served.\n# TYPE marketplace_audit_runs_total counter\nmarketplace_audit_runs_total %d\n", audits); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "# HELP marketplace_audit_checked_total Plugin versions checked by consistency audits.\n# TYPE marketplace_audit_checked_total counter\nmarketplace_audit_checked_total %d\n", checked); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(w, "# HELP marketplace_audit_drift Plugin versions failing each check of the latest consistency audit.\n# TYPE marketplace_audit_drift gauge\n"); err != nil {
+		return err
+	}
+	for _, check := range model.AuditChecks {
+		if _, err := fmt.Fprintf(w, "marketplace_audit_drift{check=%q} %d\n", check, drift[check]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+)
+
+func TestAuditor(t *testing.T) {
+	entity, err := openpgp.NewEntity("Marketplace Test", "", "test@example.com", nil)
+	require.NoError(t, err)
+	sign := func(data []byte) string {
+		var signature bytes.Buffer
+		require.NoError(t, openpgp.DetachSign(&signature, entity, bytes.NewReader(data), nil))
+		return base64.StdEncoding.EncodeToString(signature.Bytes())
+	}
+
+	bundles := map[string][]byte{
+		"/demo.tar.gz": []byte("demo bundle"),
+		"/jira.tar.gz": []byte("jira bundle"),
+		"/zoom.tar.gz": []byte("zoom bundle"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bundle, ok := bundles[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(bundle)
+	}))
+	defer server.Close()
+
+	makePlugin := func(id, path string, bundle []byte) *model.Plugin {
+		checksums := model.NewChecksumsWriter()
+		_, _ = checksums.Write(bundle)
+
+		return &model.Plugin{
+			DownloadURL: server.URL + path,
+			Checksums:   checksums.Checksums(),
+			Signatures:  []*model.Signature{{Signature: sign(bundle)}},
+			Manifest:    &mattermostModel.Manifest{Id: id, Version: "1.0.0"},
+		}
+	}
+	plugins := []*model.Plugin{
+		makePlugin("demo", "/demo.tar.gz", bundles["/demo.tar.gz"]),
+		makePlugin("jira", "/jira.tar.gz", bundles["/jira.tar.gz"]),
+		makePlugin("zoom", "/zoom.tar.gz", bundles["/zoom.tar.gz"]),
+		makePlugin("gone", "/gone.tar.gz", []byte("gone bundle")),
+	}
+
+	var drifted []*model.AuditFinding
+	auditor := New(testlib.MakeLogger(t), Options{
+		SampleSize: len(plugins),
+		Keyring:    openpgp.EntityList{entity},
+		OnDrift: func(finding *model.AuditFinding) {
+			drifted = append(drifted, finding)
+		},
+	})
+
+	findingsOf := func(report *model.AuditReport) map[string]model.AuditCheck {
+		findings := map[string]model.AuditCheck{}
+		for _, finding := range report.Findings {
+			findings[finding.PluginID] = finding.Check
+		}
+		return findings
+	}
+
+	t.Run("reports unreachable bundles", func(t *testing.T) {
+		report := auditor.Audit(plugins)
+		require.Equal(t, 4, report.Checked)
+		require.Equal(t, map[string]model.AuditCheck{"gone": model.AuditCheckDownload}, findingsOf(report))
+		require.Len(t, drifted, 1)
+	})
+
+	t.Run("reports bundles no longer matching their checksums and signatures", func(t *testing.T) {
+		bundles["/jira.tar.gz"] = []byte("tampered jira bundle")
+		plugins[2].Checksums = nil
+
+		report := auditor.Audit(plugins)
+		require.Len(t, report.Findings, 3)
+		require.Equal(t, map[string]model.AuditCheck{
+			"gone": model.AuditCheckDownload,
+			"jira": model.AuditCheckSignature,
+		}, findingsOf(report))
+		require.Len(t, drifted, 3, "drift already reported is not reported again")
+	})
+
+	t.Run("samples", func(t *testing.T) {
+		auditor.sampleSize = 2
+		require.Equal(t, 2, auditor.Audit(plugins).Checked)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		auditor.sampleSize = len(plugins)
+		auditor.Audit(plugins)
+
+		var buf bytes.Buffer
+		require.NoError(t, auditor.WriteMetrics(&buf))
+		require.Equal(t, `# HELP marketplace_audit_runs_total Consistency audits of the catalog served.
+# TYPE marketplace_audit_runs_total counter
+marketplace_audit_runs_total 4
+# HELP marketplace_audit_checked_total Plugin versions checked by consistency audits.
+# TYPE marketplace_audit_checked_total counter
+marketplace_audit_checked_total 14
+# HELP marketplace_audit_drift Plugin versions failing each check of the latest consistency audit.
+# TYPE marketplace_audit_drift gauge
+marketplace_audit_drift{check="download"} 1
+marketplace_audit_drift{check="checksum"} 1
+marketplace_audit_drift{check="signature"} 1
+`, buf.String())
+	})
+}
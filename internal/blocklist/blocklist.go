@@ -0,0 +1,156 @@
+// Package blocklist tracks the clients denied access to the marketplace by address or User-Agent,
+// persisting them to a pluggable backend.
+package blocklist
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// ErrNotFound is returned when the requested blocklist entry does not exist.
+var ErrNotFound = errors.New("blocklist entry not found")
+
+// Backend persists blocklist entries.
+type Backend interface {
+	// Load returns all persisted entries, or none if nothing was persisted yet.
+	Load() ([]*model.BlocklistEntry, error)
+	// Save replaces the persisted entries with the given entries.
+	Save(entries []*model.BlocklistEntry) error
+}
+
+// Blocklist holds every unexpired entry, ordered by creation.
+type Blocklist struct {
+	backend Backend
+	now     func() time.Time
+	newID   func() string
+
+	lock    sync.RWMutex
+	entries []*model.BlocklistEntry
+}
+
+// New creates a blocklist initialized with the entries persisted to the given backend.
+func New(backend Backend) (*Blocklist, error) {
+	entries, err := backend.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load blocklist")
+	}
+
+	for _, entry := range entries {
+		if err := entry.IsValid(); err != nil {
+			return nil, errors.Wrapf(err, "invalid blocklist entry %s", entry.ID)
+		}
+	}
+
+	return &Blocklist{
+		backend: backend,
+		now:     time.Now,
+		newID:   mattermostModel.NewId,
+		entries: entries,
+	}, nil
+}
+
+// Block adds an entry matching the given criteria, returning it with its id and creation time.
+func (b *Blocklist) Block(entry *model.BlocklistEntry) (*model.BlocklistEntry, error) {
+	now := b.now().UTC()
+
+	added := *entry
+	added.ID = b.newID()
+	added.CreatedAt = now
+	if err := added.IsValid(); err != nil {
+		return nil, errors.Wrap(err, "invalid blocklist entry")
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	entries := []*model.BlocklistEntry{}
+	for _, existing := range b.entries {
+		if !existing.IsExpired(now) {
+			entries = append(entries, existing)
+		}
+	}
+	if err := b.save(append(entries, &added)); err != nil {
+		return nil, err
+	}
+
+	result := added
+	return &result, nil
+}
+
+// Unblock removes the given entry.
+func (b *Blocklist) Unblock(id string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	entries := []*model.BlocklistEntry{}
+	for _, existing := range b.entries {
+		if existing.ID != id {
+			entries = append(entries, existing)
+		}
+	}
+	if len(entries) == len(b.entries) {
+		return ErrNotFound
+	}
+
+	return b.save(entries)
+}
+
+// save persists the given entries before adopting them. The lock must be held.
+func (b *Blocklist) save(entries []*model.BlocklistEntry) error {
+	if err := b.backend.Save(entries); err != nil {
+		return errors.Wrap(err, "failed to save blocklist")
+	}
+
+	b.entries = entries
+
+	return nil
+}
+
+// Blocks returns the first unexpired entry matching the client with the given address and
+// User-Agent header, or false if the client is not blocked.
+func (b *Blocklist) Blocks(ip net.IP, userAgent string) (*model.BlocklistEntry, bool) {
+	now := b.now()
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	for _, entry := range b.entries {
+		if entry.Matches(ip, userAgent, now) {
+			result := *entry
+			return &result, true
+		}
+	}
+
+	return nil, false
+}
+
+// GetEntries returns every unexpired entry, most recent first.
+func (b *Blocklist) GetEntries() []*model.BlocklistEntry {
+	now := b.now()
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	result := []*model.BlocklistEntry{}
+	for _, entry := range b.entries {
+		if entry.IsExpired(now) {
+			continue
+		}
+
+		copied := *entry
+		result = append(result, &copied)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result
+}
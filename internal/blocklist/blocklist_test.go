@@ -0,0 +1,100 @@
+package blocklist
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func TestBlocklist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blocklist")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "blocklist.json")
+
+	now := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+	nextID := 0
+	newBlocklist := func(t *testing.T) *Blocklist {
+		blocklist, err := New(&FileBackend{Path: path})
+		require.NoError(t, err)
+		blocklist.now = func() time.Time { return now }
+		blocklist.newID = func() string {
+			nextID++
+			return fmt.Sprintf("entry%d", nextID)
+		}
+
+		return blocklist
+	}
+
+	blocklist := newBlocklist(t)
+
+	t.Run("no entries", func(t *testing.T) {
+		require.Empty(t, blocklist.GetEntries())
+
+		_, blocked := blocklist.Blocks(net.ParseIP("192.0.2.1"), "scraper")
+		require.False(t, blocked)
+
+		require.Equal(t, ErrNotFound, blocklist.Unblock("unknown"))
+	})
+
+	t.Run("invalid entry", func(t *testing.T) {
+		_, err := blocklist.Block(&model.BlocklistEntry{})
+		require.Error(t, err)
+	})
+
+	scraper, err := blocklist.Block(&model.BlocklistEntry{UserAgent: "scraper", Reason: "scraping", CreatorID: "moderator"})
+	require.NoError(t, err)
+	require.Equal(t, &model.BlocklistEntry{
+		ID:        "entry2",
+		UserAgent: "scraper",
+		Reason:    "scraping",
+		CreatorID: "moderator",
+		CreatedAt: now,
+	}, scraper)
+
+	now = now.Add(time.Minute)
+	expiresAt := now.Add(time.Hour)
+	burst, err := blocklist.Block(&model.BlocklistEntry{IP: "192.0.2.1", ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	t.Run("blocks", func(t *testing.T) {
+		entry, blocked := blocklist.Blocks(net.ParseIP("198.51.100.1"), "Scraper/1.0")
+		require.True(t, blocked)
+		require.Equal(t, scraper, entry)
+
+		entry, blocked = blocklist.Blocks(net.ParseIP("192.0.2.1"), "Mattermost/5.18")
+		require.True(t, blocked)
+		require.Equal(t, burst, entry)
+
+		_, blocked = blocklist.Blocks(net.ParseIP("198.51.100.1"), "Mattermost/5.18")
+		require.False(t, blocked)
+	})
+
+	t.Run("persisted", func(t *testing.T) {
+		require.Equal(t, []*model.BlocklistEntry{burst, scraper}, newBlocklist(t).GetEntries())
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		now = expiresAt
+
+		_, blocked := blocklist.Blocks(net.ParseIP("192.0.2.1"), "Mattermost/5.18")
+		require.False(t, blocked)
+		require.Equal(t, []*model.BlocklistEntry{scraper}, blocklist.GetEntries())
+	})
+
+	t.Run("unblock", func(t *testing.T) {
+		require.NoError(t, blocklist.Unblock(scraper.ID))
+
+		_, blocked := blocklist.Blocks(net.ParseIP("198.51.100.1"), "Scraper/1.0")
+		require.False(t, blocked)
+		require.Empty(t, newBlocklist(t).GetEntries())
+	})
+}
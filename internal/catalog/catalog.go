@@ -0,0 +1,308 @@
+// Package catalog serves a plugin store that may be replaced while running, recording the changes
+// between successive stores as a feed for watchers.
+package catalog
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+// ErrUnknownCursor is returned when asked for the changes after a cursor that was never issued,
+// or whose subsequent changes are no longer retained. Watchers should resynchronize by listing
+// the catalog afresh.
+var ErrUnknownCursor = errors.New("cursor is unknown or no longer retained")
+
+// ErrUnknownSnapshot is returned when asked to restore a snapshot that was never taken, or that is
+// no longer retained.
+var ErrUnknownSnapshot = errors.New("snapshot is unknown or no longer retained")
+
+// DefaultHistorySize is the number of changes retained for watchers resuming from a cursor.
+const DefaultHistorySize = 1000
+
+// DefaultSnapshotLimit is the number of snapshots retained for rolling back, including the one
+// currently served.
+const DefaultSnapshotLimit = 10
+
+// Options configures a catalog.
+type Options struct {
+	// SnapshotLimit is the number of snapshots retained for rolling back, including the one
+	// currently served. Defaults to DefaultSnapshotLimit.
+	SnapshotLimit int
+	// StoreOptions configures the validation of candidates staged for promotion or imported.
+	StoreOptions store.Options
+	// Backend, if set, accepts imports replacing the catalog, persisting the imported plugins.
+	Backend Backend
+}
+
+// snapshot is a store once served by the catalog.
+type snapshot struct {
+	*model.CatalogSnapshot
+	store       *store.Store
+	composition *model.CatalogComposition
+}
+
+// Catalog serves the plugins of its current store.
+//
+// Cursors start from the time the catalog was created, in milliseconds, so that a cursor issued
+// before a restart is recognized as unknown rather than mistaken for a later change.
+type Catalog struct {
+	historySize   int
+	snapshotLimit int
+	storeOptions  store.Options
+	backend       Backend
+	now           func() time.Time
+
+	lock           sync.RWMutex
+	store          *store.Store
+	base           int64
+	cursor         int64
+	changes        []*model.CatalogChange
+	updated        chan struct{}
+	snapshots      []*snapshot
+	lastSnapshotID int64
+	currentID      int64
+	staged         *store.Store
+	stagedReport   *model.StagingReport
+}
+
+// New creates a catalog serving the given store.
+func New(initialStore *store.Store) *Catalog {
+	return NewWithOptions(initialStore, Options{})
+}
+
+// NewWithOptions creates a catalog serving the given store, configured by the given options.
+func NewWithOptions(initialStore *store.Store, options Options) *Catalog {
+	catalog := newCatalog(initialStore, time.Now)
+	if options.SnapshotLimit > 0 {
+		catalog.snapshotLimit = options.SnapshotLimit
+	}
+	catalog.storeOptions = options.StoreOptions
+	catalog.backend = options.Backend
+
+	return catalog
+}
+
+func newCatalog(initialStore *store.Store, now func() time.Time) *Catalog {
+	base := now().UnixNano() / int64(time.Millisecond)
+
+	catalog := &Catalog{
+		historySize:   DefaultHistorySize,
+		snapshotLimit: DefaultSnapshotLimit,
+		now:           now,
+		store:         initialStore,
+		base:          base,
+		cursor:        base,
+		updated:       make(chan struct{}),
+	}
+	catalog.takeSnapshot(initialStore, model.SnapshotSourceInitial, 0)
+
+	return catalog
+}
+
+func (c *Catalog) currentStore() *store.Store {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.store
+}
+
+// GetPlugins fetches the given page of plugins from the current store.
+func (c *Catalog) GetPlugins(filter *model.PluginFilter) ([]*model.Plugin, error) {
+	return c.currentStore().GetPlugins(filter)
+}
+
+// GetPluginVersions fetches every version of the given plugin from the current store.
+func (c *Catalog) GetPluginVersions(id string) ([]*model.Plugin, error) {
+	return c.currentStore().GetPluginVersions(id)
+}
+
+// GetApps fetches the given page of apps from the current store.
+func (c *Catalog) GetApps(filter *model.AppFilter) ([]*model.App, error) {
+	return c.currentStore().GetApps(filter)
+}
+
+// AllPlugins returns every version of every plugin in the current store, in database order.
+func (c *Catalog) AllPlugins() []*model.Plugin {
+	return c.currentStore().AllPlugins()
+}
+
+// Epoch counts the changes to the responses of the current store due to the passage of time.
+func (c *Catalog) Epoch() int {
+	return c.currentStore().Epoch()
+}
+
+// Cursor returns the cursor of the latest change, from which a watcher receives only future
+// changes.
+func (c *Catalog) Cursor() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.cursor
+}
+
+// Replace serves the given store in place of the current one, returning the resulting changes. The
+// given store is retained as a snapshot to which the catalog may later be rolled back.
+func (c *Catalog) Replace(newStore *store.Store) []*model.CatalogChange {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.takeSnapshot(newStore, model.SnapshotSourceReplaced, 0)
+
+	return c.replace(newStore)
+}
+
+// Rollback serves the store of the given snapshot in place of the current one, recording the
+// resulting changes for watchers. The restored store is retained as a new snapshot, so that the
+// rollback may itself be undone.
+func (c *Catalog) Rollback(id int64) (*model.CatalogSnapshot, []*model.CatalogChange, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var restored *snapshot
+	for _, snapshot := range c.snapshots {
+		if snapshot.ID == id {
+			restored = snapshot
+			break
+		}
+	}
+	if restored == nil {
+		return nil, nil, ErrUnknownSnapshot
+	}
+
+	taken := c.takeSnapshot(restored.store, model.SnapshotSourceRollback, id)
+	changes := c.replace(restored.store)
+
+	result := *taken.CatalogSnapshot
+	result.Current = true
+
+	return &result, changes, nil
+}
+
+// Snapshots returns the retained snapshots, most recent first.
+func (c *Catalog) Snapshots() []*model.CatalogSnapshot {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	result := make([]*model.CatalogSnapshot, 0, len(c.snapshots))
+	for i := len(c.snapshots) - 1; i >= 0; i-- {
+		copied := *c.snapshots[i].CatalogSnapshot
+		copied.Current = copied.ID == c.currentID
+		result = append(result, &copied)
+	}
+
+	return result
+}
+
+// Growth reports the composition of each retained snapshot, oldest first, and the growth over
+// them.
+func (c *Catalog) Growth() *model.CatalogGrowth {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	compositions := make([]*model.CatalogComposition, 0, len(c.snapshots))
+	for _, snapshot := range c.snapshots {
+		copied := *snapshot.composition
+		compositions = append(compositions, &copied)
+	}
+
+	return model.NewCatalogGrowth(compositions)
+}
+
+// SnapshotAsOf returns the snapshot the catalog was serving at the given time, and its store, or
+// ErrUnknownSnapshot if the catalog was not yet serving then, or no longer retains the snapshot.
+func (c *Catalog) SnapshotAsOf(at time.Time) (*model.CatalogSnapshot, *store.Store, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for i := len(c.snapshots) - 1; i >= 0; i-- {
+		if c.snapshots[i].CreatedAt.After(at) {
+			continue
+		}
+		copied := *c.snapshots[i].CatalogSnapshot
+		copied.Current = copied.ID == c.currentID
+		return &copied, c.snapshots[i].store, nil
+	}
+
+	return nil, nil, ErrUnknownSnapshot
+}
+
+// takeSnapshot retains the given store as the snapshot served next, discarding the oldest
+// snapshots beyond the limit. The lock must be held.
+func (c *Catalog) takeSnapshot(newStore *store.Store, source model.SnapshotSource, restoredFrom int64) *snapshot {
+	c.lastSnapshotID++
+	taken := &snapshot{
+		CatalogSnapshot: &model.CatalogSnapshot{
+			ID:           c.lastSnapshotID,
+			Source:       source,
+			RestoredFrom: restoredFrom,
+			Plugins:      len(newStore.AllPlugins()),
+			CreatedAt:    c.now().UTC(),
+		},
+		store:       newStore,
+		composition: model.ComposeCatalog(newStore.AllPlugins()),
+	}
+	taken.composition.SnapshotID = taken.ID
+	taken.composition.CreatedAt = taken.CreatedAt
+	c.currentID = taken.ID
+
+	c.snapshots = append(c.snapshots, taken)
+	if len(c.snapshots) > c.snapshotLimit {
+		c.snapshots = append([]*snapshot(nil), c.snapshots[len(c.snapshots)-c.snapshotLimit:]...)
+	}
+
+	return taken
+}
+
+// replace serves the given store, recording the changes from the current one. The lock must be
+// held.
+func (c *Catalog) replace(newStore *store.Store) []*model.CatalogChange {
+	changes := model.DiffPlugins(c.store.AllPlugins(), newStore.AllPlugins()).CatalogChanges()
+	c.store = newStore
+	if len(changes) == 0 {
+		return nil
+	}
+
+	changedAt := c.now()
+	for _, change := range changes {
+		c.cursor++
+		change.Cursor = c.cursor
+		change.ChangedAt = changedAt
+	}
+
+	c.changes = append(c.changes, changes...)
+	if len(c.changes) > c.historySize {
+		c.changes = append([]*model.CatalogChange(nil), c.changes[len(c.changes)-c.historySize:]...)
+	}
+
+	close(c.updated)
+	c.updated = make(chan struct{})
+
+	return changes
+}
+
+// ChangesSince returns the retained changes after the given cursor, oldest first, along with a
+// channel closed when the next change is recorded.
+func (c *Catalog) ChangesSince(cursor int64) ([]*model.CatalogChange, <-chan struct{}, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	oldest := c.base
+	if len(c.changes) > 0 {
+		oldest = c.changes[0].Cursor - 1
+	}
+	if cursor < oldest || cursor > c.cursor {
+		return nil, nil, ErrUnknownCursor
+	}
+
+	start := sort.Search(len(c.changes), func(i int) bool {
+		return c.changes[i].Cursor > cursor
+	})
+
+	return append([]*model.CatalogChange(nil), c.changes[start:]...), c.updated, nil
+}
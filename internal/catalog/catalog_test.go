@@ -0,0 +1,284 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+func makePlugin(id, version, downloadURL string) *model.Plugin {
+	return &model.Plugin{
+		DownloadURL:  downloadURL,
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: id, Name: id, Version: version},
+	}
+}
+
+func makeStore(t *testing.T, plugins ...*model.Plugin) *store.Store {
+	t.Helper()
+
+	if plugins == nil {
+		plugins = []*model.Plugin{}
+	}
+	data, err := json.Marshal(plugins)
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	pluginStore, err := store.New(bytes.NewReader(data), logger)
+	require.NoError(t, err)
+
+	return pluginStore
+}
+
+func TestCatalog(t *testing.T) {
+	now := time.Date(2019, 12, 1, 12, 0, 0, 0, time.UTC)
+	base := now.UnixNano() / int64(time.Millisecond)
+
+	catalog := newCatalog(makeStore(t,
+		makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0.tar.gz"),
+		makePlugin("starter", "0.1.0", "https://example.com/starter-0.1.0.tar.gz"),
+	), func() time.Time { return now })
+	require.Equal(t, base, catalog.Cursor())
+
+	t.Run("serves the current store", func(t *testing.T) {
+		versions, err := catalog.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+	})
+
+	changes, updated, err := catalog.ChangesSince(base)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	now = now.Add(time.Minute)
+	replaced := catalog.Replace(makeStore(t,
+		makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0-rebuilt.tar.gz"),
+		makePlugin("demo", "0.2.0", "https://example.com/demo-0.2.0.tar.gz"),
+	))
+
+	select {
+	case <-updated:
+	default:
+		require.Fail(t, "expected watchers to be notified")
+	}
+
+	require.Equal(t, []*model.CatalogChange{
+		{Cursor: base + 1, Type: model.ChangeTypeUpdated, PluginID: "demo", Version: "0.1.0", Plugin: replaced[0].Plugin, ChangedAt: now},
+		{Cursor: base + 2, Type: model.ChangeTypeAdded, PluginID: "demo", Version: "0.2.0", Plugin: replaced[1].Plugin, ChangedAt: now},
+		{Cursor: base + 3, Type: model.ChangeTypeRemoved, PluginID: "starter", Version: "0.1.0", ChangedAt: now},
+	}, replaced)
+	require.Equal(t, "https://example.com/demo-0.1.0-rebuilt.tar.gz", replaced[0].Plugin.DownloadURL)
+	require.Equal(t, base+3, catalog.Cursor())
+
+	t.Run("serves the replacement store", func(t *testing.T) {
+		versions, err := catalog.GetPluginVersions("starter")
+		require.NoError(t, err)
+		require.Empty(t, versions)
+	})
+
+	t.Run("changes since cursor", func(t *testing.T) {
+		changes, _, err := catalog.ChangesSince(base)
+		require.NoError(t, err)
+		require.Equal(t, replaced, changes)
+
+		changes, _, err = catalog.ChangesSince(base + 2)
+		require.NoError(t, err)
+		require.Equal(t, replaced[2:], changes)
+
+		changes, _, err = catalog.ChangesSince(base + 3)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+
+	t.Run("unknown cursor", func(t *testing.T) {
+		_, _, err := catalog.ChangesSince(base - 1)
+		require.Equal(t, ErrUnknownCursor, err)
+
+		_, _, err = catalog.ChangesSince(base + 4)
+		require.Equal(t, ErrUnknownCursor, err)
+	})
+
+	t.Run("unchanged replacement", func(t *testing.T) {
+		require.Empty(t, catalog.Replace(makeStore(t,
+			makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0-rebuilt.tar.gz"),
+			makePlugin("demo", "0.2.0", "https://example.com/demo-0.2.0.tar.gz"),
+		)))
+		require.Equal(t, base+3, catalog.Cursor())
+	})
+
+	t.Run("trimmed history", func(t *testing.T) {
+		catalog.historySize = 2
+		catalog.Replace(makeStore(t))
+
+		// Only the two removals remain, so earlier cursors can no longer be resumed.
+		_, _, err := catalog.ChangesSince(base + 2)
+		require.Equal(t, ErrUnknownCursor, err)
+
+		changes, _, err := catalog.ChangesSince(base + 3)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+
+		changes, _, err = catalog.ChangesSince(base + 4)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, model.ChangeTypeRemoved, changes[0].Type)
+	})
+}
+
+func TestCatalogSnapshots(t *testing.T) {
+	now := time.Date(2019, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	catalog := newCatalog(makeStore(t,
+		makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0.tar.gz"),
+	), func() time.Time { return now })
+	catalog.snapshotLimit = 3
+
+	t.Run("initial snapshot", func(t *testing.T) {
+		require.Equal(t, []*model.CatalogSnapshot{
+			{ID: 1, Source: model.SnapshotSourceInitial, Plugins: 1, Current: true, CreatedAt: now},
+		}, catalog.Snapshots())
+	})
+
+	now = now.Add(time.Minute)
+	catalog.Replace(makeStore(t))
+
+	t.Run("replacement snapshot", func(t *testing.T) {
+		snapshots := catalog.Snapshots()
+		require.Len(t, snapshots, 2)
+		require.Equal(t, &model.CatalogSnapshot{ID: 2, Source: model.SnapshotSourceReplaced, Plugins: 0, Current: true, CreatedAt: now}, snapshots[0])
+		require.False(t, snapshots[1].Current)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		cursor := catalog.Cursor()
+
+		snapshot, changes, err := catalog.Rollback(1)
+		require.NoError(t, err)
+		require.Equal(t, &model.CatalogSnapshot{ID: 3, Source: model.SnapshotSourceRollback, RestoredFrom: 1, Plugins: 1, Current: true, CreatedAt: now}, snapshot)
+		require.Len(t, changes, 1)
+		require.Equal(t, model.ChangeTypeAdded, changes[0].Type)
+		require.Equal(t, cursor+1, catalog.Cursor())
+
+		versions, err := catalog.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+	})
+
+	t.Run("unknown snapshot", func(t *testing.T) {
+		_, _, err := catalog.Rollback(42)
+		require.Equal(t, ErrUnknownSnapshot, err)
+	})
+
+	t.Run("trimmed snapshots", func(t *testing.T) {
+		catalog.Replace(makeStore(t))
+
+		snapshots := catalog.Snapshots()
+		require.Len(t, snapshots, 3)
+		require.EqualValues(t, 4, snapshots[0].ID)
+		require.EqualValues(t, 2, snapshots[2].ID)
+
+		_, _, err := catalog.Rollback(1)
+		require.Equal(t, ErrUnknownSnapshot, err)
+	})
+}
+
+func TestCatalogSnapshotAsOf(t *testing.T) {
+	start := time.Date(2019, 12, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	catalog := newCatalog(makeStore(t,
+		makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0.tar.gz"),
+	), func() time.Time { return now })
+	catalog.snapshotLimit = 2
+
+	now = now.Add(time.Hour)
+	catalog.Replace(makeStore(t,
+		makePlugin("demo", "0.2.0", "https://example.com/demo-0.2.0.tar.gz"),
+	))
+
+	versionAsOf := func(t *testing.T, at time.Time) string {
+		_, snapshotStore, err := catalog.SnapshotAsOf(at)
+		require.NoError(t, err)
+		plugins, err := snapshotStore.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+
+		return plugins[0].Manifest.Version
+	}
+
+	t.Run("served at the time", func(t *testing.T) {
+		require.Equal(t, "0.1.0", versionAsOf(t, start))
+		require.Equal(t, "0.1.0", versionAsOf(t, start.Add(30*time.Minute)))
+		require.Equal(t, "0.2.0", versionAsOf(t, now))
+		require.Equal(t, "0.2.0", versionAsOf(t, now.Add(24*time.Hour)))
+
+		snapshot, _, err := catalog.SnapshotAsOf(start)
+		require.NoError(t, err)
+		require.Equal(t, &model.CatalogSnapshot{ID: 1, Source: model.SnapshotSourceInitial, Plugins: 1, CreatedAt: start}, snapshot)
+	})
+
+	t.Run("before the catalog", func(t *testing.T) {
+		_, _, err := catalog.SnapshotAsOf(start.Add(-time.Minute))
+		require.Equal(t, ErrUnknownSnapshot, err)
+	})
+
+	t.Run("no longer retained", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		catalog.Replace(makeStore(t))
+
+		_, _, err := catalog.SnapshotAsOf(start.Add(30 * time.Minute))
+		require.Equal(t, ErrUnknownSnapshot, err)
+		require.Equal(t, "0.2.0", versionAsOf(t, now.Add(-time.Minute)))
+	})
+}
+
+func TestCatalogGrowth(t *testing.T) {
+	start := time.Date(2019, 12, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+
+	demo := makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0.tar.gz")
+	demo.Labels = []model.Label{{Name: "Beta"}}
+	catalog := newCatalog(makeStore(t, demo), func() time.Time { return now })
+
+	t.Run("single snapshot", func(t *testing.T) {
+		growth := catalog.Growth()
+		require.Len(t, growth.Snapshots, 1)
+		require.Equal(t, 1, growth.Snapshots[0].Entries)
+		require.Equal(t, map[string]int{"Beta": 1}, growth.Snapshots[0].Labels)
+		require.Zero(t, growth.EntriesPerDay)
+	})
+
+	now = now.Add(48 * time.Hour)
+	catalog.Replace(makeStore(t,
+		demo,
+		makePlugin("demo", "0.2.0", "https://example.com/demo-0.2.0.tar.gz"),
+		makePlugin("starter", "0.1.0", "https://example.com/starter-0.1.0.tar.gz"),
+	))
+
+	t.Run("grown", func(t *testing.T) {
+		growth := catalog.Growth()
+		require.Len(t, growth.Snapshots, 2)
+		require.EqualValues(t, 1, growth.Snapshots[0].SnapshotID)
+		require.Equal(t, start, growth.Snapshots[0].CreatedAt)
+
+		latest := growth.Snapshots[1]
+		require.EqualValues(t, 2, latest.SnapshotID)
+		require.Equal(t, now, latest.CreatedAt)
+		require.Equal(t, 2, latest.Plugins)
+		require.Equal(t, 3, latest.Entries)
+		require.Greater(t, latest.Bytes, growth.Snapshots[0].Bytes)
+		require.Equal(t, 1.0, growth.EntriesPerDay)
+		require.Equal(t, float64(latest.Bytes-growth.Snapshots[0].Bytes)/2, growth.BytesPerDay)
+	})
+}
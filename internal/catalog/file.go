@@ -0,0 +1,48 @@
+package catalog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// FileBackend persists imported plugins to a local database file, in the canonical format written
+// by the generator, or to the directory of a split database.
+type FileBackend struct {
+	Path string
+}
+
+// Save atomically replaces the file with the given plugins. A split database is instead rewritten
+// file by file.
+func (b *FileBackend) Save(plugins []*model.Plugin) error {
+	if info, err := os.Stat(b.Path); err == nil && info.IsDir() {
+		if _, err := model.WriteSplitDatabase(b.Path, plugins, model.PluginsWriterOptions{Indent: true}); err != nil {
+			return errors.Wrapf(err, "failed to write %s", b.Path)
+		}
+		return nil
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(b.Path), filepath.Base(b.Path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(file.Name())
+
+	if err := model.PluginsToWriter(file, plugins, model.PluginsWriterOptions{Indent: true}); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to write %s", file.Name())
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", file.Name())
+	}
+
+	if err := os.Rename(file.Name(), b.Path); err != nil {
+		return errors.Wrapf(err, "failed to replace %s", b.Path)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+// ErrImportDisabled is returned when asked to import plugins into a catalog without a backend to
+// persist them.
+var ErrImportDisabled = errors.New("catalog does not accept imports")
+
+// Backend persists the plugins imported into a catalog, so that they survive a restart.
+type Backend interface {
+	// Save replaces the persisted plugins with the given plugins.
+	Save(plugins []*model.Plugin) error
+}
+
+// ValidationError is returned when imported plugins fail validation.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("failed validation: %s", strings.Join(e.Problems, "; "))
+}
+
+// Import parses and validates the given plugins, as for a staged candidate, then persists them to
+// the catalog's backend and serves them in place of the current store, along with the apps of the
+// current store. It returns the snapshot retaining the imported store.
+//
+// Plugins that cannot be parsed, or that fail validation, leave the catalog unchanged.
+func (c *Catalog) Import(reader io.Reader, logger logrus.FieldLogger) (*model.CatalogSnapshot, []*model.CatalogChange, error) {
+	if c.backend == nil {
+		return nil, nil, ErrImportDisabled
+	}
+
+	imported, err := store.NewWithOptions(reader, logger, c.storeOptions)
+	if err != nil {
+		return nil, nil, &ValidationError{Problems: []string{errors.Wrap(err, "failed to parse plugins").Error()}}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if problems := validateCandidate(c.store, imported); len(problems) > 0 {
+		return nil, nil, &ValidationError{Problems: problems}
+	}
+	imported.ShareApps(c.store)
+
+	if err := c.backend.Save(imported.AllPlugins()); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to persist imported plugins")
+	}
+
+	taken := c.takeSnapshot(imported, model.SnapshotSourceImported, 0)
+	changes := c.replace(imported)
+
+	result := *taken.CatalogSnapshot
+	result.Current = true
+
+	return &result, changes, nil
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+func TestCatalogImport(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	marshal := func(t *testing.T, plugins ...*model.Plugin) *bytes.Reader {
+		t.Helper()
+
+		data, err := json.Marshal(plugins)
+		require.NoError(t, err)
+		return bytes.NewReader(data)
+	}
+
+	t.Run("without a backend", func(t *testing.T) {
+		catalog := New(makeStore(t))
+
+		_, _, err := catalog.Import(marshal(t, makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0.tar.gz")), logger)
+		require.Equal(t, ErrImportDisabled, err)
+	})
+
+	dir, err := ioutil.TempDir("", "catalog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	database := filepath.Join(dir, "plugins.json")
+
+	catalog := NewWithOptions(makeStore(t,
+		makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0.tar.gz"),
+	), Options{Backend: &FileBackend{Path: database}})
+
+	t.Run("malformed plugins", func(t *testing.T) {
+		_, _, err := catalog.Import(strings.NewReader(`{`), logger)
+		require.IsType(t, &ValidationError{}, err)
+
+		_, err = os.Stat(database)
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("plugins failing validation", func(t *testing.T) {
+		_, _, err := catalog.Import(marshal(t, makePlugin("demo", "0.2.0", "")), logger)
+		require.Equal(t, &ValidationError{Problems: []string{"plugin demo 0.2.0 has no download url"}}, err)
+
+		versions, err := catalog.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		require.Equal(t, "0.1.0", versions[0].Manifest.Version)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		snapshot, changes, err := catalog.Import(marshal(t,
+			makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0.tar.gz"),
+			makePlugin("demo", "0.2.0", "https://example.com/demo-0.2.0.tar.gz"),
+		), logger)
+		require.NoError(t, err)
+		require.Equal(t, model.SnapshotSourceImported, snapshot.Source)
+		require.Len(t, changes, 1)
+
+		versions, err := catalog.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+
+		file, err := os.Open(database)
+		require.NoError(t, err)
+		defer file.Close()
+
+		persisted, err := store.New(file, logger)
+		require.NoError(t, err)
+		require.Len(t, persisted.AllPlugins(), 2)
+	})
+	t.Run("split database", func(t *testing.T) {
+		splitDir := filepath.Join(dir, "split")
+		require.NoError(t, os.Mkdir(splitDir, 0755))
+		splitCatalog := NewWithOptions(makeStore(t), Options{Backend: &FileBackend{Path: splitDir}})
+
+		_, _, err := splitCatalog.Import(marshal(t,
+			makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0.tar.gz"),
+			makePlugin("starter", "0.1.0", "https://example.com/starter-0.1.0.tar.gz"),
+		), logger)
+		require.NoError(t, err)
+
+		persisted, err := model.ReadSplitDatabase(splitDir)
+		require.NoError(t, err)
+		require.Len(t, persisted, 2)
+	})
+}
@@ -0,0 +1,196 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+// ErrNothingStaged is returned when asked to validate, promote or discard a candidate when none is
+// staged.
+var ErrNothingStaged = errors.New("no candidate is staged")
+
+// ErrNotValidated is returned when asked to promote a candidate that has not passed validation.
+var ErrNotValidated = errors.New("staged candidate has not passed validation")
+
+// Stage parses the given plugins as a candidate to replace the catalog's store, serving the apps
+// of the current store, in place of any previously staged candidate. The candidate is not served
+// until validated and promoted.
+func (c *Catalog) Stage(reader io.Reader, logger logrus.FieldLogger) (*model.StagingReport, error) {
+	candidate, err := store.NewWithOptions(reader, logger, c.storeOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse candidate")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	candidate.ShareApps(c.store)
+
+	diff := model.DiffPlugins(c.store.AllPlugins(), candidate.AllPlugins())
+	report := &model.StagingReport{
+		Plugins:  len(candidate.AllPlugins()),
+		Added:    len(diff.Added),
+		Updated:  len(diff.Changed),
+		Removed:  len(diff.Removed),
+		StagedAt: c.now().UTC(),
+		Warnings: candidate.Warnings(),
+	}
+
+	c.staged = candidate
+	c.stagedReport = report
+
+	result := *report
+	return &result, nil
+}
+
+// Staged returns the report of the staged candidate.
+func (c *Catalog) Staged() (*model.StagingReport, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.staged == nil {
+		return nil, ErrNothingStaged
+	}
+
+	result := *c.stagedReport
+	return &result, nil
+}
+
+// Validate checks the staged candidate and runs smoke queries against it, recording any problems
+// found in the returned report. Only a candidate validated without problems may be promoted.
+func (c *Catalog) Validate() (*model.StagingReport, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.staged == nil {
+		return nil, ErrNothingStaged
+	}
+
+	validatedAt := c.now().UTC()
+	c.stagedReport.ValidatedAt = &validatedAt
+	c.stagedReport.Problems = validateCandidate(c.store, c.staged)
+
+	result := *c.stagedReport
+	return &result, nil
+}
+
+// Promote serves the staged candidate in place of the current store, returning the snapshot
+// retaining it. The candidate must have passed validation.
+func (c *Catalog) Promote() (*model.CatalogSnapshot, []*model.CatalogChange, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.staged == nil {
+		return nil, nil, ErrNothingStaged
+	}
+	if !c.stagedReport.Passed() {
+		return nil, nil, ErrNotValidated
+	}
+
+	promoted := c.staged
+	c.staged = nil
+	c.stagedReport = nil
+
+	taken := c.takeSnapshot(promoted, model.SnapshotSourcePromoted, 0)
+	changes := c.replace(promoted)
+
+	result := *taken.CatalogSnapshot
+	result.Current = true
+
+	return &result, changes, nil
+}
+
+// Discard drops the staged candidate.
+func (c *Catalog) Discard() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.staged == nil {
+		return ErrNothingStaged
+	}
+
+	c.staged = nil
+	c.stagedReport = nil
+
+	return nil
+}
+
+// ValidateEntry checks the given plugin as if it were served alongside the catalog's current
+// plugins, in place of any with the same id and version, returning the problems that would prevent
+// it from being served. The catalog is left unchanged.
+func (c *Catalog) ValidateEntry(plugin *model.Plugin, logger logrus.FieldLogger) []string {
+	if plugin == nil || plugin.Manifest == nil {
+		return []string{"plugin has no manifest"}
+	}
+
+	current := c.currentStore()
+	plugins := []*model.Plugin{}
+	for _, existing := range current.AllPlugins() {
+		if existing.Manifest.Id == plugin.Manifest.Id && existing.Manifest.Version == plugin.Manifest.Version {
+			continue
+		}
+		plugins = append(plugins, existing)
+	}
+	plugins = append(plugins, plugin)
+
+	candidate, err := store.NewFromPlugins(plugins, logger, c.storeOptions)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	if plugin.DownloadURL == "" && len(plugin.Platforms) == 0 {
+		problems = append(problems, fmt.Sprintf("plugin %s %s has no download url", plugin.Manifest.Id, plugin.Manifest.Version))
+	}
+	if versions, err := candidate.GetPluginVersions(plugin.Manifest.Id); err != nil {
+		problems = append(problems, fmt.Sprintf("failed to get versions of plugin %s: %s", plugin.Manifest.Id, err))
+	} else if len(versions) == 0 {
+		problems = append(problems, fmt.Sprintf("plugin %s has no versions", plugin.Manifest.Id))
+	}
+
+	return problems
+}
+
+// validateCandidate returns the problems preventing the given candidate from replacing the current
+// store, querying it as clients would.
+func validateCandidate(current, candidate *store.Store) []string {
+	var problems []string
+
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, plugin := range candidate.AllPlugins() {
+		if plugin.DownloadURL == "" && len(plugin.Platforms) == 0 {
+			problems = append(problems, fmt.Sprintf("plugin %s %s has no download url", plugin.Manifest.Id, plugin.Manifest.Version))
+		}
+		if !seen[plugin.Manifest.Id] {
+			seen[plugin.Manifest.Id] = true
+			ids = append(ids, plugin.Manifest.Id)
+		}
+	}
+
+	listed, err := candidate.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("failed to list plugins: %s", err))
+	} else if len(listed) == 0 {
+		if currentListed, err := current.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage}); err == nil && len(currentListed) > 0 {
+			problems = append(problems, fmt.Sprintf("lists no plugins, where %d are listed now", len(currentListed)))
+		}
+	}
+
+	for _, id := range ids {
+		versions, err := candidate.GetPluginVersions(id)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("failed to get versions of plugin %s: %s", id, err))
+		} else if len(versions) == 0 {
+			problems = append(problems, fmt.Sprintf("plugin %s has no versions", id))
+		}
+	}
+
+	return problems
+}
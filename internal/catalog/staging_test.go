@@ -0,0 +1,175 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func TestCatalogStaging(t *testing.T) {
+	now := time.Date(2019, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	catalog := newCatalog(makeStore(t,
+		makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0.tar.gz"),
+		makePlugin("starter", "0.1.0", "https://example.com/starter-0.1.0.tar.gz"),
+	), func() time.Time { return now })
+
+	stage := func(t *testing.T, plugins ...*model.Plugin) *model.StagingReport {
+		t.Helper()
+
+		if plugins == nil {
+			plugins = []*model.Plugin{}
+		}
+		data, err := json.Marshal(plugins)
+		require.NoError(t, err)
+
+		report, err := catalog.Stage(bytes.NewReader(data), logger)
+		require.NoError(t, err)
+		return report
+	}
+
+	t.Run("nothing staged", func(t *testing.T) {
+		_, err := catalog.Staged()
+		require.Equal(t, ErrNothingStaged, err)
+
+		_, err = catalog.Validate()
+		require.Equal(t, ErrNothingStaged, err)
+
+		_, _, err = catalog.Promote()
+		require.Equal(t, ErrNothingStaged, err)
+
+		require.Equal(t, ErrNothingStaged, catalog.Discard())
+	})
+
+	t.Run("malformed candidate", func(t *testing.T) {
+		_, err := catalog.Stage(bytes.NewReader([]byte(`[{"manifest": {"id": "demo", "version": "x"}}]`)), logger)
+		require.Error(t, err)
+
+		_, err = catalog.Staged()
+		require.Equal(t, ErrNothingStaged, err)
+	})
+
+	t.Run("candidate failing validation", func(t *testing.T) {
+		report := stage(t, makePlugin("demo", "0.2.0", ""))
+		require.Equal(t, &model.StagingReport{Plugins: 1, Added: 1, Removed: 2, StagedAt: now}, report)
+
+		_, _, err := catalog.Promote()
+		require.Equal(t, ErrNotValidated, err)
+
+		report, err = catalog.Validate()
+		require.NoError(t, err)
+		require.False(t, report.Passed())
+		require.Equal(t, []string{"plugin demo 0.2.0 has no download url"}, report.Problems)
+
+		_, _, err = catalog.Promote()
+		require.Equal(t, ErrNotValidated, err)
+	})
+
+	t.Run("candidate with a malformed icon", func(t *testing.T) {
+		plugin := makePlugin("demo", "0.2.0", "https://example.com/demo-0.2.0.tar.gz")
+		plugin.IconData = "icon.svg"
+		report := stage(t, plugin)
+		require.Equal(t, 1, report.Plugins)
+		require.Len(t, report.Warnings, 1)
+		require.Contains(t, report.Warnings[0], "dropped malformed icon for plugin 0 (manifest.Id demo, version 0.2.0")
+
+		report, err := catalog.Validate()
+		require.NoError(t, err)
+		require.Empty(t, report.Problems)
+		require.NoError(t, catalog.Discard())
+	})
+
+	t.Run("empty candidate", func(t *testing.T) {
+		stage(t)
+
+		report, err := catalog.Validate()
+		require.NoError(t, err)
+		require.Equal(t, []string{"lists no plugins, where 2 are listed now"}, report.Problems)
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		stage(t)
+		require.NoError(t, catalog.Discard())
+
+		_, err := catalog.Staged()
+		require.Equal(t, ErrNothingStaged, err)
+	})
+
+	t.Run("promote", func(t *testing.T) {
+		stage(t, makePlugin("demo", "0.2.0", "https://example.com/demo-0.2.0.tar.gz"))
+
+		report, err := catalog.Validate()
+		require.NoError(t, err)
+		require.True(t, report.Passed())
+
+		staged, err := catalog.Staged()
+		require.NoError(t, err)
+		require.Equal(t, report, staged)
+
+		snapshot, changes, err := catalog.Promote()
+		require.NoError(t, err)
+		require.Equal(t, model.SnapshotSourcePromoted, snapshot.Source)
+		require.True(t, snapshot.Current)
+		require.Len(t, changes, 3)
+
+		versions, err := catalog.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		require.Equal(t, "0.2.0", versions[0].Manifest.Version)
+
+		_, err = catalog.Staged()
+		require.Equal(t, ErrNothingStaged, err)
+	})
+}
+
+func TestCatalogValidateEntry(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	renamed := makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0.tar.gz")
+	renamed.OldIDs = []string{"legacy"}
+	catalog := New(makeStore(t, renamed))
+
+	t.Run("valid new version", func(t *testing.T) {
+		require.Empty(t, catalog.ValidateEntry(makePlugin("demo", "0.2.0", "https://example.com/demo-0.2.0.tar.gz"), logger))
+	})
+
+	t.Run("replacing an existing version", func(t *testing.T) {
+		replacement := makePlugin("demo", "0.1.0", "https://example.com/demo-0.1.0-rebuilt.tar.gz")
+		replacement.OldIDs = []string{"legacy"}
+		require.Empty(t, catalog.ValidateEntry(replacement, logger))
+	})
+
+	t.Run("conflicting with the catalog", func(t *testing.T) {
+		claimant := makePlugin("other", "1.0.0", "https://example.com/other-1.0.0.tar.gz")
+		claimant.OldIDs = []string{"legacy"}
+		problems := catalog.ValidateEntry(claimant, logger)
+		require.Len(t, problems, 1)
+		require.Contains(t, problems[0], "old id legacy is claimed by both")
+	})
+
+	t.Run("no download url", func(t *testing.T) {
+		require.Equal(t, []string{"plugin other 1.0.0 has no download url"}, catalog.ValidateEntry(makePlugin("other", "1.0.0", ""), logger))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		require.Len(t, catalog.ValidateEntry(makePlugin("other", "latest", "https://example.com/other.tar.gz"), logger), 1)
+		require.Equal(t, []string{"plugin has no manifest"}, catalog.ValidateEntry(&model.Plugin{}, logger))
+	})
+
+	t.Run("catalog unchanged", func(t *testing.T) {
+		plugins := catalog.AllPlugins()
+		require.Len(t, plugins, 1)
+		require.Equal(t, "https://example.com/demo-0.1.0.tar.gz", plugins[0].DownloadURL)
+	})
+}
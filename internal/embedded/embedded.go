@@ -0,0 +1,57 @@
+// Package embedded loads the databases compiled into the binary by statik, as bundled by make
+// generate, serving as a fallback when the configured databases are unavailable.
+package embedded
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/rakyll/statik/fs"
+	"github.com/sirupsen/logrus"
+
+	// Registers the bundled databases with statik.
+	_ "github.com/mattermost/mattermost-marketplace/data/statik"
+
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+// PluginsPath is the path of the plugin database within the bundle.
+const PluginsPath = "/plugins.json"
+
+// AppsPath is the path of the optional apps database bundled alongside the plugin database.
+const AppsPath = "/apps.json"
+
+// NewStore loads a store from the plugin database at the given path within the bundle, along with
+// the apps database if one was bundled.
+func NewStore(pluginsPath string, logger logrus.FieldLogger, options store.Options) (*store.Store, error) {
+	statikFS, err := fs.New()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open statik fileystem")
+	}
+
+	database, err := statikFS.Open(pluginsPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", pluginsPath)
+	}
+	defer database.Close()
+
+	embeddedStore, err := store.NewWithOptions(database, logger, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize store")
+	}
+
+	// The apps database is optional, so proceed without apps if none was bundled.
+	appsDatabase, err := statikFS.Open(AppsPath)
+	if os.IsNotExist(err) {
+		return embeddedStore, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", AppsPath)
+	}
+	defer appsDatabase.Close()
+
+	if err := embeddedStore.LoadApps(appsDatabase); err != nil {
+		return nil, errors.Wrap(err, "failed to load apps")
+	}
+
+	return embeddedStore, nil
+}
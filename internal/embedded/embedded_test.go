@@ -0,0 +1,27 @@
+package embedded_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/embedded"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+)
+
+func TestNewStore(t *testing.T) {
+	t.Run("bundled database", func(t *testing.T) {
+		embeddedStore, err := embedded.NewStore(embedded.PluginsPath, testlib.MakeLogger(t), store.Options{})
+		require.NoError(t, err)
+
+		_, err = embeddedStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+	})
+
+	t.Run("missing database", func(t *testing.T) {
+		_, err := embedded.NewStore("/missing.json", testlib.MakeLogger(t), store.Options{})
+		require.Error(t, err)
+	})
+}
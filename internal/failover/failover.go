@@ -0,0 +1,193 @@
+// Package failover serves a plugin store from a primary backend, failing over to a secondary
+// backend, such as a snapshot of the catalog, whenever the primary fails.
+package failover
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// DefaultRetryInterval is how long queries bypass a failed primary before it is tried again.
+const DefaultRetryInterval = 10 * time.Second
+
+// Options configures a failover store.
+type Options struct {
+	// RetryInterval is how long queries bypass a failed primary before it is tried again.
+	// Defaults to DefaultRetryInterval.
+	RetryInterval time.Duration
+}
+
+// Store serves queries from its primary store while it succeeds, and from its secondary store
+// otherwise. Once the primary fails, queries go straight to the secondary until the retry interval
+// elapses, so that a primary in an outage is not waited on by every request. Should the secondary
+// fail too, the primary is tried regardless.
+//
+// The optional interfaces of the api package, such as api.Changes, are looked up on the primary,
+// which Unwrap returns.
+type Store struct {
+	primary       api.Store
+	secondary     api.Store
+	retryInterval time.Duration
+	logger        logrus.FieldLogger
+	now           func() time.Time
+
+	lock   sync.Mutex
+	health model.StoreHealth
+	// retryAt is when a degraded primary is next tried.
+	retryAt time.Time
+}
+
+// New creates a store serving queries from the given primary, failing over to the given
+// secondary, and logging the primary's failures and recoveries to the given logger.
+func New(primary, secondary api.Store, logger logrus.FieldLogger, options Options) *Store {
+	retryInterval := options.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = DefaultRetryInterval
+	}
+
+	return &Store{
+		primary:       primary,
+		secondary:     secondary,
+		retryInterval: retryInterval,
+		logger:        logger,
+		now:           time.Now,
+	}
+}
+
+// GetPlugins fetches the given page of plugins.
+func (s *Store) GetPlugins(filter *model.PluginFilter) ([]*model.Plugin, error) {
+	var plugins []*model.Plugin
+	err := s.query(func(store api.Store) error {
+		var err error
+		plugins, err = store.GetPlugins(filter)
+		return err
+	})
+
+	return plugins, err
+}
+
+// GetPluginVersions fetches every version of the given plugin.
+func (s *Store) GetPluginVersions(id string) ([]*model.Plugin, error) {
+	var plugins []*model.Plugin
+	err := s.query(func(store api.Store) error {
+		var err error
+		plugins, err = store.GetPluginVersions(id)
+		return err
+	})
+
+	return plugins, err
+}
+
+// GetApps fetches the given page of apps.
+func (s *Store) GetApps(filter *model.AppFilter) ([]*model.App, error) {
+	var apps []*model.App
+	err := s.query(func(store api.Store) error {
+		var err error
+		apps, err = store.GetApps(filter)
+		return err
+	})
+
+	return apps, err
+}
+
+// Unwrap returns the primary store.
+func (s *Store) Unwrap() api.Store {
+	return s.primary
+}
+
+// Health reports whether queries are currently served by the secondary, and the primary's
+// failures.
+func (s *Store) Health() *model.StoreHealth {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	health := s.health
+	return &health
+}
+
+// query runs the given query against the primary, unless it is bypassed after failing, and then
+// against the secondary until one succeeds.
+func (s *Store) query(run func(store api.Store) error) error {
+	primaryFirst := s.primaryAvailable()
+	if primaryFirst {
+		err := run(s.primary)
+		if err == nil {
+			s.recordSuccess()
+			return nil
+		}
+		s.recordFailure(err)
+	}
+
+	err := run(s.secondary)
+	if err == nil {
+		s.recordFailover()
+		return nil
+	}
+	if primaryFirst {
+		return errors.Wrap(err, "failed to query both primary and secondary stores")
+	}
+
+	s.logger.WithError(err).Warn("Secondary store failed, retrying the primary store")
+	if primaryErr := run(s.primary); primaryErr != nil {
+		s.recordFailure(primaryErr)
+		return errors.Wrap(primaryErr, "failed to query both primary and secondary stores")
+	}
+	s.recordSuccess()
+
+	return nil
+}
+
+// primaryAvailable reports whether the primary is to be queried first: unless it is degraded, or
+// once its retry interval has elapsed.
+func (s *Store) primaryAvailable() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return !s.health.Degraded || !s.now().Before(s.retryAt)
+}
+
+func (s *Store) recordSuccess() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.health.Degraded {
+		return
+	}
+
+	s.health.Degraded = false
+	s.health.ConsecutiveFailures = 0
+	s.health.RecoveredAt = s.now()
+	s.logger.Info("Primary store recovered")
+}
+
+func (s *Store) recordFailure(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	s.health.ConsecutiveFailures++
+	s.health.LastError = err.Error()
+	s.health.LastFailureAt = now
+	s.retryAt = now.Add(s.retryInterval)
+
+	logger := s.logger.WithError(err).WithField("consecutive_failures", s.health.ConsecutiveFailures)
+	if !s.health.Degraded {
+		logger.Error("Primary store failed, failing over to the secondary store")
+	} else {
+		logger.Warn("Primary store failed again")
+	}
+	s.health.Degraded = true
+}
+
+func (s *Store) recordFailover() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.health.Failovers++
+}
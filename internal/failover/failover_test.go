@@ -0,0 +1,144 @@
+package failover
+
+import (
+	"testing"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// fakeStore serves a single plugin, or fails while err is set.
+type fakeStore struct {
+	plugin  *model.Plugin
+	err     error
+	queries int
+}
+
+func newFakeStore(id string) *fakeStore {
+	return &fakeStore{
+		plugin: &model.Plugin{Manifest: &mattermostModel.Manifest{Id: id, Version: "1.0.0"}},
+	}
+}
+
+func (s *fakeStore) GetPlugins(filter *model.PluginFilter) ([]*model.Plugin, error) {
+	s.queries++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []*model.Plugin{s.plugin}, nil
+}
+
+func (s *fakeStore) GetPluginVersions(id string) ([]*model.Plugin, error) {
+	return s.GetPlugins(nil)
+}
+
+func (s *fakeStore) GetApps(filter *model.AppFilter) ([]*model.App, error) {
+	s.queries++
+	return nil, s.err
+}
+
+func TestStore(t *testing.T) {
+	primary := newFakeStore("primary")
+	secondary := newFakeStore("secondary")
+	store := New(primary, secondary, logrus.New(), Options{RetryInterval: time.Minute})
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	getPlugin := func() string {
+		t.Helper()
+
+		plugins, err := store.GetPlugins(&model.PluginFilter{})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+		return plugins[0].Manifest.Id
+	}
+
+	t.Run("primary healthy", func(t *testing.T) {
+		require.Equal(t, "primary", getPlugin())
+		require.Equal(t, &model.StoreHealth{}, store.Health())
+	})
+
+	t.Run("primary failing over", func(t *testing.T) {
+		primary.err = errors.New("connection refused")
+
+		require.Equal(t, "secondary", getPlugin())
+		require.Equal(t, &model.StoreHealth{
+			Degraded:            true,
+			ConsecutiveFailures: 1,
+			Failovers:           1,
+			LastError:           "connection refused",
+			LastFailureAt:       now,
+		}, store.Health())
+	})
+
+	t.Run("primary bypassed until the retry interval elapses", func(t *testing.T) {
+		primary.err = nil
+		queries := primary.queries
+
+		now = now.Add(30 * time.Second)
+		require.Equal(t, "secondary", getPlugin())
+		require.Equal(t, queries, primary.queries)
+		require.True(t, store.Health().Degraded)
+	})
+
+	t.Run("primary retried and failing again", func(t *testing.T) {
+		primary.err = errors.New("timeout")
+
+		now = now.Add(time.Minute)
+		require.Equal(t, "secondary", getPlugin())
+
+		health := store.Health()
+		require.True(t, health.Degraded)
+		require.Equal(t, 2, health.ConsecutiveFailures)
+		require.Equal(t, int64(3), health.Failovers)
+		require.Equal(t, "timeout", health.LastError)
+		require.Equal(t, now, health.LastFailureAt)
+	})
+
+	t.Run("primary retried after the secondary fails", func(t *testing.T) {
+		primary.err = nil
+		secondary.err = errors.New("missing snapshot")
+
+		require.Equal(t, "primary", getPlugin())
+		require.False(t, store.Health().Degraded)
+		require.Equal(t, now, store.Health().RecoveredAt)
+	})
+
+	t.Run("both failing", func(t *testing.T) {
+		primary.err = errors.New("connection refused")
+
+		_, err := store.GetPluginVersions("primary")
+		require.EqualError(t, err, "failed to query both primary and secondary stores: missing snapshot")
+
+		_, err = store.GetApps(&model.AppFilter{})
+		require.EqualError(t, err, "failed to query both primary and secondary stores: connection refused")
+	})
+
+	t.Run("recovered", func(t *testing.T) {
+		primary.err = nil
+		secondary.err = nil
+
+		now = now.Add(time.Minute)
+		require.Equal(t, "primary", getPlugin())
+
+		health := store.Health()
+		require.False(t, health.Degraded)
+		require.Zero(t, health.ConsecutiveFailures)
+		require.Equal(t, "connection refused", health.LastError)
+	})
+
+	t.Run("unwrap", func(t *testing.T) {
+		var wrapper api.StoreWrapper = store
+		require.Equal(t, primary, wrapper.Unwrap())
+
+		var reporter api.HealthReporter = store
+		require.NotNil(t, reporter.Health())
+	})
+}
@@ -0,0 +1,160 @@
+// Package featured tracks the plugins featured by moderators, overriding the featuring recorded in
+// the database, persisting them to a pluggable backend.
+package featured
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// ErrNotFound is returned when the featuring of a plugin is not overridden.
+var ErrNotFound = errors.New("featuring not found")
+
+// Backend persists featurings.
+type Backend interface {
+	// Load returns all persisted featurings, or none if nothing was persisted yet.
+	Load() ([]*model.Featuring, error)
+	// Save replaces the persisted featurings with the given featurings.
+	Save(featurings []*model.Featuring) error
+}
+
+// Registry holds the featuring of each plugin whose featuring is overridden.
+type Registry struct {
+	backend Backend
+	now     func() time.Time
+
+	lock       sync.RWMutex
+	featurings map[string]*model.Featuring
+}
+
+// New creates a registry initialized with the featurings persisted to the given backend.
+func New(backend Backend) (*Registry, error) {
+	featurings, err := backend.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load featurings")
+	}
+
+	byPlugin := map[string]*model.Featuring{}
+	for _, featuring := range featurings {
+		if err := featuring.IsValid(); err != nil {
+			return nil, errors.Wrap(err, "invalid featuring")
+		}
+		byPlugin[featuring.PluginID] = featuring
+	}
+
+	return &Registry{
+		backend:    backend,
+		now:        time.Now,
+		featurings: byPlugin,
+	}, nil
+}
+
+// Set overrides the featuring of the given featuring's plugin, returning it with its update time.
+func (r *Registry) Set(featuring *model.Featuring) (*model.Featuring, error) {
+	updated := *featuring
+	updated.UpdatedAt = r.now().UTC()
+	if err := updated.IsValid(); err != nil {
+		return nil, errors.Wrap(err, "invalid featuring")
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	featurings := r.copyFeaturings()
+	featurings[updated.PluginID] = &updated
+	if err := r.save(featurings); err != nil {
+		return nil, err
+	}
+
+	result := updated
+	return &result, nil
+}
+
+// Unset drops the override of the given plugin's featuring, restoring that of the database.
+func (r *Registry) Unset(pluginID string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.featurings[pluginID]; !ok {
+		return ErrNotFound
+	}
+
+	featurings := r.copyFeaturings()
+	delete(featurings, pluginID)
+
+	return r.save(featurings)
+}
+
+// copyFeaturings returns a copy of the featurings map. The lock must be held.
+func (r *Registry) copyFeaturings() map[string]*model.Featuring {
+	featurings := make(map[string]*model.Featuring, len(r.featurings))
+	for pluginID, featuring := range r.featurings {
+		featurings[pluginID] = featuring
+	}
+
+	return featurings
+}
+
+// save persists the given featurings before adopting them. The lock must be held.
+func (r *Registry) save(featurings map[string]*model.Featuring) error {
+	list := make([]*model.Featuring, 0, len(featurings))
+	for _, featuring := range featurings {
+		list = append(list, featuring)
+	}
+	sortFeaturings(list)
+
+	if err := r.backend.Save(list); err != nil {
+		return errors.Wrap(err, "failed to save featurings")
+	}
+
+	r.featurings = featurings
+
+	return nil
+}
+
+// GetFeaturing returns the featuring of the given plugin, or nil if it is not overridden.
+func (r *Registry) GetFeaturing(pluginID string) *model.Featuring {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	featuring, ok := r.featurings[pluginID]
+	if !ok {
+		return nil
+	}
+
+	copied := *featuring
+	return &copied
+}
+
+// GetFeaturings returns every overridden featuring, the featured plugins first, heaviest first,
+// and otherwise by plugin id.
+func (r *Registry) GetFeaturings() []*model.Featuring {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	result := make([]*model.Featuring, 0, len(r.featurings))
+	for _, featuring := range r.featurings {
+		copied := *featuring
+		result = append(result, &copied)
+	}
+	sortFeaturings(result)
+
+	return result
+}
+
+func sortFeaturings(featurings []*model.Featuring) {
+	sort.Slice(featurings, func(i, j int) bool {
+		if featurings[i].Featured != featurings[j].Featured {
+			return featurings[i].Featured
+		}
+		if featurings[i].Weight != featurings[j].Weight {
+			return featurings[i].Weight > featurings[j].Weight
+		}
+		return featurings[i].PluginID < featurings[j].PluginID
+	})
+}
@@ -0,0 +1,77 @@
+package featured
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "featured")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "featured.json")
+
+	now := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+	newRegistry := func(t *testing.T) *Registry {
+		registry, err := New(&FileBackend{Path: path})
+		require.NoError(t, err)
+		registry.now = func() time.Time { return now }
+
+		return registry
+	}
+
+	registry := newRegistry(t)
+
+	t.Run("no featurings", func(t *testing.T) {
+		require.Empty(t, registry.GetFeaturings())
+		require.Nil(t, registry.GetFeaturing("jira"))
+	})
+
+	t.Run("invalid featuring", func(t *testing.T) {
+		_, err := registry.Set(&model.Featuring{Featured: true})
+		require.Error(t, err)
+
+		_, err = registry.Set(&model.Featuring{PluginID: "jira", Weight: 10})
+		require.Error(t, err)
+	})
+
+	jira, err := registry.Set(&model.Featuring{PluginID: "jira", Featured: true, Weight: 10, UpdatedBy: "moderator"})
+	require.NoError(t, err)
+	require.Equal(t, now, jira.UpdatedAt)
+
+	demo, err := registry.Set(&model.Featuring{PluginID: "demo", Featured: true})
+	require.NoError(t, err)
+	zoom, err := registry.Set(&model.Featuring{PluginID: "zoom"})
+	require.NoError(t, err)
+
+	t.Run("featured first, heaviest first", func(t *testing.T) {
+		require.Equal(t, []*model.Featuring{jira, demo, zoom}, registry.GetFeaturings())
+		require.Equal(t, jira, registry.GetFeaturing("jira"))
+	})
+
+	t.Run("persists across restarts", func(t *testing.T) {
+		require.Equal(t, []*model.Featuring{jira, demo, zoom}, newRegistry(t).GetFeaturings())
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		updated, err := registry.Set(&model.Featuring{PluginID: "demo", Featured: true, Weight: 20})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Featuring{updated, jira, zoom}, registry.GetFeaturings())
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		require.Equal(t, ErrNotFound, registry.Unset("unknown"))
+
+		require.NoError(t, registry.Unset("zoom"))
+		require.Nil(t, registry.GetFeaturing("zoom"))
+		require.Len(t, newRegistry(t).GetFeaturings(), 2)
+	})
+}
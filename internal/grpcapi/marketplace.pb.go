@@ -0,0 +1,1061 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: marketplace.proto
+
+package grpcapi
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type PluginChange_Type int32
+
+const (
+	PluginChange_TYPE_UNSPECIFIED PluginChange_Type = 0
+	PluginChange_ADDED            PluginChange_Type = 1
+	PluginChange_UPDATED          PluginChange_Type = 2
+	PluginChange_REMOVED          PluginChange_Type = 3
+)
+
+var PluginChange_Type_name = map[int32]string{
+	0: "TYPE_UNSPECIFIED",
+	1: "ADDED",
+	2: "UPDATED",
+	3: "REMOVED",
+}
+
+var PluginChange_Type_value = map[string]int32{
+	"TYPE_UNSPECIFIED": 0,
+	"ADDED":            1,
+	"UPDATED":          2,
+	"REMOVED":          3,
+}
+
+func (x PluginChange_Type) String() string {
+	return proto.EnumName(PluginChange_Type_name, int32(x))
+}
+
+func (PluginChange_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{4, 0}
+}
+
+type ListPluginsRequest struct {
+	// page is the zero-based page of plugins to list.
+	Page int32 `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	// per_page is the number of plugins per page, or -1 for all plugins.
+	PerPage       int32  `protobuf:"varint,2,opt,name=per_page,json=perPage,proto3" json:"per_page,omitempty"`
+	Filter        string `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
+	ServerVersion string `protobuf:"bytes,4,opt,name=server_version,json=serverVersion,proto3" json:"server_version,omitempty"`
+	AuthorType    string `protobuf:"bytes,5,opt,name=author_type,json=authorType,proto3" json:"author_type,omitempty"`
+	Hosting       string `protobuf:"bytes,6,opt,name=hosting,proto3" json:"hosting,omitempty"`
+	// license_tier is the license tier of the requesting server, omitting plugins requiring a
+	// higher tier.
+	LicenseTier          string   `protobuf:"bytes,7,opt,name=license_tier,json=licenseTier,proto3" json:"license_tier,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListPluginsRequest) Reset()         { *m = ListPluginsRequest{} }
+func (m *ListPluginsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListPluginsRequest) ProtoMessage()    {}
+func (*ListPluginsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{0}
+}
+
+func (m *ListPluginsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPluginsRequest.Unmarshal(m, b)
+}
+func (m *ListPluginsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPluginsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListPluginsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPluginsRequest.Merge(m, src)
+}
+func (m *ListPluginsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListPluginsRequest.Size(m)
+}
+func (m *ListPluginsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPluginsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPluginsRequest proto.InternalMessageInfo
+
+func (m *ListPluginsRequest) GetPage() int32 {
+	if m != nil {
+		return m.Page
+	}
+	return 0
+}
+
+func (m *ListPluginsRequest) GetPerPage() int32 {
+	if m != nil {
+		return m.PerPage
+	}
+	return 0
+}
+
+func (m *ListPluginsRequest) GetFilter() string {
+	if m != nil {
+		return m.Filter
+	}
+	return ""
+}
+
+func (m *ListPluginsRequest) GetServerVersion() string {
+	if m != nil {
+		return m.ServerVersion
+	}
+	return ""
+}
+
+func (m *ListPluginsRequest) GetAuthorType() string {
+	if m != nil {
+		return m.AuthorType
+	}
+	return ""
+}
+
+func (m *ListPluginsRequest) GetHosting() string {
+	if m != nil {
+		return m.Hosting
+	}
+	return ""
+}
+
+func (m *ListPluginsRequest) GetLicenseTier() string {
+	if m != nil {
+		return m.LicenseTier
+	}
+	return ""
+}
+
+type ListPluginsResponse struct {
+	Plugins              []*Plugin `protobuf:"bytes,1,rep,name=plugins,proto3" json:"plugins,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ListPluginsResponse) Reset()         { *m = ListPluginsResponse{} }
+func (m *ListPluginsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListPluginsResponse) ProtoMessage()    {}
+func (*ListPluginsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{1}
+}
+
+func (m *ListPluginsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPluginsResponse.Unmarshal(m, b)
+}
+func (m *ListPluginsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPluginsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListPluginsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPluginsResponse.Merge(m, src)
+}
+func (m *ListPluginsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListPluginsResponse.Size(m)
+}
+func (m *ListPluginsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPluginsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPluginsResponse proto.InternalMessageInfo
+
+func (m *ListPluginsResponse) GetPlugins() []*Plugin {
+	if m != nil {
+		return m.Plugins
+	}
+	return nil
+}
+
+type GetPluginRequest struct {
+	Id      string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	// license_tier is the license tier of the requesting server, hiding versions requiring a
+	// higher tier.
+	LicenseTier          string   `protobuf:"bytes,3,opt,name=license_tier,json=licenseTier,proto3" json:"license_tier,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPluginRequest) Reset()         { *m = GetPluginRequest{} }
+func (m *GetPluginRequest) String() string { return proto.CompactTextString(m) }
+func (*GetPluginRequest) ProtoMessage()    {}
+func (*GetPluginRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{2}
+}
+
+func (m *GetPluginRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPluginRequest.Unmarshal(m, b)
+}
+func (m *GetPluginRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPluginRequest.Marshal(b, m, deterministic)
+}
+func (m *GetPluginRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPluginRequest.Merge(m, src)
+}
+func (m *GetPluginRequest) XXX_Size() int {
+	return xxx_messageInfo_GetPluginRequest.Size(m)
+}
+func (m *GetPluginRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPluginRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPluginRequest proto.InternalMessageInfo
+
+func (m *GetPluginRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *GetPluginRequest) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *GetPluginRequest) GetLicenseTier() string {
+	if m != nil {
+		return m.LicenseTier
+	}
+	return ""
+}
+
+type WatchPluginsRequest struct {
+	// since is the cursor of the last change already seen, or 0 to receive only future changes.
+	Since                int64    `protobuf:"varint,1,opt,name=since,proto3" json:"since,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchPluginsRequest) Reset()         { *m = WatchPluginsRequest{} }
+func (m *WatchPluginsRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchPluginsRequest) ProtoMessage()    {}
+func (*WatchPluginsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{3}
+}
+
+func (m *WatchPluginsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchPluginsRequest.Unmarshal(m, b)
+}
+func (m *WatchPluginsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchPluginsRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchPluginsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchPluginsRequest.Merge(m, src)
+}
+func (m *WatchPluginsRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchPluginsRequest.Size(m)
+}
+func (m *WatchPluginsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchPluginsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchPluginsRequest proto.InternalMessageInfo
+
+func (m *WatchPluginsRequest) GetSince() int64 {
+	if m != nil {
+		return m.Since
+	}
+	return 0
+}
+
+type PluginChange struct {
+	// cursor identifies the change, for resuming a watch after it.
+	Cursor   int64             `protobuf:"varint,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Type     PluginChange_Type `protobuf:"varint,2,opt,name=type,proto3,enum=marketplace.v1.PluginChange_Type" json:"type,omitempty"`
+	PluginId string            `protobuf:"bytes,3,opt,name=plugin_id,json=pluginId,proto3" json:"plugin_id,omitempty"`
+	Version  string            `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	// plugin is the added or updated plugin, and is unset when the plugin was removed.
+	Plugin               *Plugin              `protobuf:"bytes,5,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	ChangedAt            *timestamp.Timestamp `protobuf:"bytes,6,opt,name=changed_at,json=changedAt,proto3" json:"changed_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *PluginChange) Reset()         { *m = PluginChange{} }
+func (m *PluginChange) String() string { return proto.CompactTextString(m) }
+func (*PluginChange) ProtoMessage()    {}
+func (*PluginChange) Descriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{4}
+}
+
+func (m *PluginChange) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PluginChange.Unmarshal(m, b)
+}
+func (m *PluginChange) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PluginChange.Marshal(b, m, deterministic)
+}
+func (m *PluginChange) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PluginChange.Merge(m, src)
+}
+func (m *PluginChange) XXX_Size() int {
+	return xxx_messageInfo_PluginChange.Size(m)
+}
+func (m *PluginChange) XXX_DiscardUnknown() {
+	xxx_messageInfo_PluginChange.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PluginChange proto.InternalMessageInfo
+
+func (m *PluginChange) GetCursor() int64 {
+	if m != nil {
+		return m.Cursor
+	}
+	return 0
+}
+
+func (m *PluginChange) GetType() PluginChange_Type {
+	if m != nil {
+		return m.Type
+	}
+	return PluginChange_TYPE_UNSPECIFIED
+}
+
+func (m *PluginChange) GetPluginId() string {
+	if m != nil {
+		return m.PluginId
+	}
+	return ""
+}
+
+func (m *PluginChange) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *PluginChange) GetPlugin() *Plugin {
+	if m != nil {
+		return m.Plugin
+	}
+	return nil
+}
+
+func (m *PluginChange) GetChangedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ChangedAt
+	}
+	return nil
+}
+
+type Signature struct {
+	Signature            string   `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"`
+	PublicKeyHash        string   `protobuf:"bytes,2,opt,name=public_key_hash,json=publicKeyHash,proto3" json:"public_key_hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Signature) Reset()         { *m = Signature{} }
+func (m *Signature) String() string { return proto.CompactTextString(m) }
+func (*Signature) ProtoMessage()    {}
+func (*Signature) Descriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{5}
+}
+
+func (m *Signature) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Signature.Unmarshal(m, b)
+}
+func (m *Signature) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Signature.Marshal(b, m, deterministic)
+}
+func (m *Signature) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Signature.Merge(m, src)
+}
+func (m *Signature) XXX_Size() int {
+	return xxx_messageInfo_Signature.Size(m)
+}
+func (m *Signature) XXX_DiscardUnknown() {
+	xxx_messageInfo_Signature.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Signature proto.InternalMessageInfo
+
+func (m *Signature) GetSignature() string {
+	if m != nil {
+		return m.Signature
+	}
+	return ""
+}
+
+func (m *Signature) GetPublicKeyHash() string {
+	if m != nil {
+		return m.PublicKeyHash
+	}
+	return ""
+}
+
+type Checksums struct {
+	Sha256               string   `protobuf:"bytes,1,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Sha512               string   `protobuf:"bytes,2,opt,name=sha512,proto3" json:"sha512,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Checksums) Reset()         { *m = Checksums{} }
+func (m *Checksums) String() string { return proto.CompactTextString(m) }
+func (*Checksums) ProtoMessage()    {}
+func (*Checksums) Descriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{6}
+}
+
+func (m *Checksums) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Checksums.Unmarshal(m, b)
+}
+func (m *Checksums) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Checksums.Marshal(b, m, deterministic)
+}
+func (m *Checksums) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Checksums.Merge(m, src)
+}
+func (m *Checksums) XXX_Size() int {
+	return xxx_messageInfo_Checksums.Size(m)
+}
+func (m *Checksums) XXX_DiscardUnknown() {
+	xxx_messageInfo_Checksums.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Checksums proto.InternalMessageInfo
+
+func (m *Checksums) GetSha256() string {
+	if m != nil {
+		return m.Sha256
+	}
+	return ""
+}
+
+func (m *Checksums) GetSha512() string {
+	if m != nil {
+		return m.Sha512
+	}
+	return ""
+}
+
+type PlatformBundle struct {
+	DownloadUrl          string       `protobuf:"bytes,1,opt,name=download_url,json=downloadUrl,proto3" json:"download_url,omitempty"`
+	Signatures           []*Signature `protobuf:"bytes,2,rep,name=signatures,proto3" json:"signatures,omitempty"`
+	Checksums            *Checksums   `protobuf:"bytes,3,opt,name=checksums,proto3" json:"checksums,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *PlatformBundle) Reset()         { *m = PlatformBundle{} }
+func (m *PlatformBundle) String() string { return proto.CompactTextString(m) }
+func (*PlatformBundle) ProtoMessage()    {}
+func (*PlatformBundle) Descriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{7}
+}
+
+func (m *PlatformBundle) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlatformBundle.Unmarshal(m, b)
+}
+func (m *PlatformBundle) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlatformBundle.Marshal(b, m, deterministic)
+}
+func (m *PlatformBundle) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlatformBundle.Merge(m, src)
+}
+func (m *PlatformBundle) XXX_Size() int {
+	return xxx_messageInfo_PlatformBundle.Size(m)
+}
+func (m *PlatformBundle) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlatformBundle.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlatformBundle proto.InternalMessageInfo
+
+func (m *PlatformBundle) GetDownloadUrl() string {
+	if m != nil {
+		return m.DownloadUrl
+	}
+	return ""
+}
+
+func (m *PlatformBundle) GetSignatures() []*Signature {
+	if m != nil {
+		return m.Signatures
+	}
+	return nil
+}
+
+func (m *PlatformBundle) GetChecksums() *Checksums {
+	if m != nil {
+		return m.Checksums
+	}
+	return nil
+}
+
+type Label struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description          string   `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Color                string   `protobuf:"bytes,3,opt,name=color,proto3" json:"color,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Label) Reset()         { *m = Label{} }
+func (m *Label) String() string { return proto.CompactTextString(m) }
+func (*Label) ProtoMessage()    {}
+func (*Label) Descriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{8}
+}
+
+func (m *Label) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Label.Unmarshal(m, b)
+}
+func (m *Label) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Label.Marshal(b, m, deterministic)
+}
+func (m *Label) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Label.Merge(m, src)
+}
+func (m *Label) XXX_Size() int {
+	return xxx_messageInfo_Label.Size(m)
+}
+func (m *Label) XXX_DiscardUnknown() {
+	xxx_messageInfo_Label.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Label proto.InternalMessageInfo
+
+func (m *Label) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Label) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Label) GetColor() string {
+	if m != nil {
+		return m.Color
+	}
+	return ""
+}
+
+type Plugin struct {
+	Id                 string                     `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name               string                     `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description        string                     `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Version            string                     `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	MinServerVersion   string                     `protobuf:"bytes,5,opt,name=min_server_version,json=minServerVersion,proto3" json:"min_server_version,omitempty"`
+	HomepageUrl        string                     `protobuf:"bytes,6,opt,name=homepage_url,json=homepageUrl,proto3" json:"homepage_url,omitempty"`
+	IconData           string                     `protobuf:"bytes,7,opt,name=icon_data,json=iconData,proto3" json:"icon_data,omitempty"`
+	DownloadUrl        string                     `protobuf:"bytes,8,opt,name=download_url,json=downloadUrl,proto3" json:"download_url,omitempty"`
+	ReleaseNotesUrl    string                     `protobuf:"bytes,9,opt,name=release_notes_url,json=releaseNotesUrl,proto3" json:"release_notes_url,omitempty"`
+	Screenshots        []string                   `protobuf:"bytes,10,rep,name=screenshots,proto3" json:"screenshots,omitempty"`
+	BannerImageUrl     string                     `protobuf:"bytes,11,opt,name=banner_image_url,json=bannerImageUrl,proto3" json:"banner_image_url,omitempty"`
+	Signatures         []*Signature               `protobuf:"bytes,12,rep,name=signatures,proto3" json:"signatures,omitempty"`
+	Checksums          *Checksums                 `protobuf:"bytes,13,opt,name=checksums,proto3" json:"checksums,omitempty"`
+	Platforms          map[string]*PlatformBundle `protobuf:"bytes,14,rep,name=platforms,proto3" json:"platforms,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Labels             []*Label                   `protobuf:"bytes,15,rep,name=labels,proto3" json:"labels,omitempty"`
+	AuthorType         string                     `protobuf:"bytes,16,opt,name=author_type,json=authorType,proto3" json:"author_type,omitempty"`
+	ReleaseStage       string                     `protobuf:"bytes,17,opt,name=release_stage,json=releaseStage,proto3" json:"release_stage,omitempty"`
+	Hosting            string                     `protobuf:"bytes,18,opt,name=hosting,proto3" json:"hosting,omitempty"`
+	ServerVersionRange string                     `protobuf:"bytes,19,opt,name=server_version_range,json=serverVersionRange,proto3" json:"server_version_range,omitempty"`
+	UpdatedAt          *timestamp.Timestamp       `protobuf:"bytes,20,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ReleasedAt         *timestamp.Timestamp       `protobuf:"bytes,21,opt,name=released_at,json=releasedAt,proto3" json:"released_at,omitempty"`
+	// manifest_json is the plugin's full manifest, as served by the REST API.
+	ManifestJson string `protobuf:"bytes,22,opt,name=manifest_json,json=manifestJson,proto3" json:"manifest_json,omitempty"`
+	// icon_url references the plugin's icon hosted externally, in place of icon_data.
+	IconUrl              string   `protobuf:"bytes,23,opt,name=icon_url,json=iconUrl,proto3" json:"icon_url,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Plugin) Reset()         { *m = Plugin{} }
+func (m *Plugin) String() string { return proto.CompactTextString(m) }
+func (*Plugin) ProtoMessage()    {}
+func (*Plugin) Descriptor() ([]byte, []int) {
+	return fileDescriptor_db4ec923061e406a, []int{9}
+}
+
+func (m *Plugin) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Plugin.Unmarshal(m, b)
+}
+func (m *Plugin) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Plugin.Marshal(b, m, deterministic)
+}
+func (m *Plugin) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Plugin.Merge(m, src)
+}
+func (m *Plugin) XXX_Size() int {
+	return xxx_messageInfo_Plugin.Size(m)
+}
+func (m *Plugin) XXX_DiscardUnknown() {
+	xxx_messageInfo_Plugin.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Plugin proto.InternalMessageInfo
+
+func (m *Plugin) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Plugin) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Plugin) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Plugin) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *Plugin) GetMinServerVersion() string {
+	if m != nil {
+		return m.MinServerVersion
+	}
+	return ""
+}
+
+func (m *Plugin) GetHomepageUrl() string {
+	if m != nil {
+		return m.HomepageUrl
+	}
+	return ""
+}
+
+func (m *Plugin) GetIconData() string {
+	if m != nil {
+		return m.IconData
+	}
+	return ""
+}
+
+func (m *Plugin) GetDownloadUrl() string {
+	if m != nil {
+		return m.DownloadUrl
+	}
+	return ""
+}
+
+func (m *Plugin) GetReleaseNotesUrl() string {
+	if m != nil {
+		return m.ReleaseNotesUrl
+	}
+	return ""
+}
+
+func (m *Plugin) GetScreenshots() []string {
+	if m != nil {
+		return m.Screenshots
+	}
+	return nil
+}
+
+func (m *Plugin) GetBannerImageUrl() string {
+	if m != nil {
+		return m.BannerImageUrl
+	}
+	return ""
+}
+
+func (m *Plugin) GetSignatures() []*Signature {
+	if m != nil {
+		return m.Signatures
+	}
+	return nil
+}
+
+func (m *Plugin) GetChecksums() *Checksums {
+	if m != nil {
+		return m.Checksums
+	}
+	return nil
+}
+
+func (m *Plugin) GetPlatforms() map[string]*PlatformBundle {
+	if m != nil {
+		return m.Platforms
+	}
+	return nil
+}
+
+func (m *Plugin) GetLabels() []*Label {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *Plugin) GetAuthorType() string {
+	if m != nil {
+		return m.AuthorType
+	}
+	return ""
+}
+
+func (m *Plugin) GetReleaseStage() string {
+	if m != nil {
+		return m.ReleaseStage
+	}
+	return ""
+}
+
+func (m *Plugin) GetHosting() string {
+	if m != nil {
+		return m.Hosting
+	}
+	return ""
+}
+
+func (m *Plugin) GetServerVersionRange() string {
+	if m != nil {
+		return m.ServerVersionRange
+	}
+	return ""
+}
+
+func (m *Plugin) GetUpdatedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return nil
+}
+
+func (m *Plugin) GetReleasedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ReleasedAt
+	}
+	return nil
+}
+
+func (m *Plugin) GetManifestJson() string {
+	if m != nil {
+		return m.ManifestJson
+	}
+	return ""
+}
+
+func (m *Plugin) GetIconUrl() string {
+	if m != nil {
+		return m.IconUrl
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterEnum("marketplace.v1.PluginChange_Type", PluginChange_Type_name, PluginChange_Type_value)
+	proto.RegisterType((*ListPluginsRequest)(nil), "marketplace.v1.ListPluginsRequest")
+	proto.RegisterType((*ListPluginsResponse)(nil), "marketplace.v1.ListPluginsResponse")
+	proto.RegisterType((*GetPluginRequest)(nil), "marketplace.v1.GetPluginRequest")
+	proto.RegisterType((*WatchPluginsRequest)(nil), "marketplace.v1.WatchPluginsRequest")
+	proto.RegisterType((*PluginChange)(nil), "marketplace.v1.PluginChange")
+	proto.RegisterType((*Signature)(nil), "marketplace.v1.Signature")
+	proto.RegisterType((*Checksums)(nil), "marketplace.v1.Checksums")
+	proto.RegisterType((*PlatformBundle)(nil), "marketplace.v1.PlatformBundle")
+	proto.RegisterType((*Label)(nil), "marketplace.v1.Label")
+	proto.RegisterType((*Plugin)(nil), "marketplace.v1.Plugin")
+	proto.RegisterMapType((map[string]*PlatformBundle)(nil), "marketplace.v1.Plugin.PlatformsEntry")
+}
+
+func init() { proto.RegisterFile("marketplace.proto", fileDescriptor_db4ec923061e406a) }
+
+var fileDescriptor_db4ec923061e406a = []byte{
+	// 1073 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0xeb, 0x72, 0xdb, 0x44,
+	0x14, 0xc6, 0x76, 0x6c, 0x47, 0x47, 0x8e, 0xeb, 0x6c, 0xd2, 0xa0, 0xba, 0x1d, 0xea, 0xb8, 0x53,
+	0x26, 0xc3, 0xc5, 0x4d, 0x0d, 0xe1, 0xd6, 0x5f, 0x6e, 0x6c, 0x4a, 0xa0, 0x2d, 0x46, 0x4e, 0xc2,
+	0xc0, 0x30, 0xa3, 0xd9, 0xc8, 0x1b, 0x7b, 0x89, 0xb4, 0x12, 0xbb, 0xab, 0x30, 0xfe, 0xd9, 0xa7,
+	0xe1, 0x3d, 0x78, 0x12, 0x1e, 0x85, 0xd9, 0x8b, 0x12, 0x5f, 0x92, 0x96, 0xe1, 0x9f, 0xce, 0xb7,
+	0xdf, 0xee, 0x9e, 0xcb, 0xb7, 0xe7, 0x08, 0x36, 0x63, 0xcc, 0x2f, 0x88, 0x4c, 0x23, 0x1c, 0x92,
+	0x4e, 0xca, 0x13, 0x99, 0xa0, 0xfa, 0x3c, 0x74, 0xf9, 0xb4, 0xf9, 0x70, 0x92, 0x24, 0x93, 0x88,
+	0x3c, 0xd1, 0xab, 0x67, 0xd9, 0xf9, 0x13, 0x49, 0x63, 0x22, 0x24, 0x8e, 0x53, 0xb3, 0xa1, 0xfd,
+	0x4f, 0x01, 0xd0, 0x4b, 0x2a, 0xe4, 0x30, 0xca, 0x26, 0x94, 0x09, 0x9f, 0xfc, 0x91, 0x11, 0x21,
+	0x11, 0x82, 0xb5, 0x14, 0x4f, 0x88, 0x57, 0x68, 0x15, 0xf6, 0xca, 0xbe, 0xfe, 0x46, 0xf7, 0x60,
+	0x3d, 0x25, 0x3c, 0xd0, 0x78, 0x51, 0xe3, 0xd5, 0x94, 0xf0, 0xa1, 0x5a, 0xda, 0x81, 0xca, 0x39,
+	0x8d, 0x24, 0xe1, 0x5e, 0xa9, 0x55, 0xd8, 0x73, 0x7c, 0x6b, 0xa1, 0xc7, 0x50, 0x17, 0x84, 0x5f,
+	0x12, 0x1e, 0x5c, 0x12, 0x2e, 0x68, 0xc2, 0xbc, 0x35, 0xbd, 0xbe, 0x61, 0xd0, 0x53, 0x03, 0xa2,
+	0x87, 0xe0, 0xe2, 0x4c, 0x4e, 0x13, 0x1e, 0xc8, 0x59, 0x4a, 0xbc, 0xb2, 0xe6, 0x80, 0x81, 0x8e,
+	0x67, 0x29, 0x41, 0x1e, 0x54, 0xa7, 0x89, 0x90, 0x94, 0x4d, 0xbc, 0x8a, 0x5e, 0xcc, 0x4d, 0xb4,
+	0x0b, 0xb5, 0x88, 0x86, 0x84, 0x09, 0x12, 0x48, 0x4a, 0xb8, 0x57, 0xd5, 0xcb, 0xae, 0xc5, 0x8e,
+	0x29, 0xe1, 0xed, 0x17, 0xb0, 0xb5, 0x10, 0xa1, 0x48, 0x13, 0x26, 0x08, 0xda, 0x87, 0x6a, 0x6a,
+	0x20, 0xaf, 0xd0, 0x2a, 0xed, 0xb9, 0xdd, 0x9d, 0xce, 0x62, 0xf2, 0x3a, 0x66, 0x87, 0x9f, 0xd3,
+	0xda, 0x01, 0x34, 0x5e, 0x10, 0x7b, 0x4e, 0x9e, 0xa8, 0x3a, 0x14, 0xe9, 0x58, 0xa7, 0xc9, 0xf1,
+	0x8b, 0x74, 0xac, 0x3c, 0xcd, 0x43, 0x2d, 0x1a, 0x4f, 0xad, 0xb9, 0xe2, 0x69, 0x69, 0xd5, 0xd3,
+	0x8f, 0x61, 0xeb, 0x67, 0x2c, 0xc3, 0xe9, 0x52, 0x31, 0xb6, 0xa1, 0x2c, 0x28, 0x0b, 0x4d, 0x35,
+	0x4a, 0xbe, 0x31, 0xda, 0x7f, 0x17, 0xa1, 0x66, 0x88, 0x87, 0x53, 0xcc, 0x4c, 0x11, 0xc2, 0x8c,
+	0x8b, 0x84, 0x5b, 0x9e, 0xb5, 0xd0, 0x01, 0xac, 0xe9, 0xb4, 0x2a, 0x7f, 0xea, 0xdd, 0xdd, 0x9b,
+	0xa3, 0x34, 0x67, 0x74, 0x54, 0xb6, 0x7d, 0x4d, 0x47, 0xf7, 0xc1, 0x31, 0x81, 0x07, 0x74, 0x6c,
+	0x9d, 0x5d, 0x37, 0xc0, 0xd1, 0x42, 0x98, 0x6b, 0x8b, 0x61, 0x76, 0xa0, 0x62, 0x58, 0xba, 0x8c,
+	0xb7, 0x67, 0xd5, 0xb2, 0xd0, 0xd7, 0x00, 0xa1, 0xbe, 0x7b, 0x1c, 0x60, 0xa9, 0xab, 0xeb, 0x76,
+	0x9b, 0x1d, 0x23, 0xdb, 0x4e, 0x2e, 0xdb, 0xce, 0x71, 0x2e, 0x5b, 0xdf, 0xb1, 0xec, 0x9e, 0x6c,
+	0xf7, 0x60, 0x4d, 0xab, 0x63, 0x1b, 0x1a, 0xc7, 0xbf, 0x0c, 0x07, 0xc1, 0xc9, 0xeb, 0xd1, 0x70,
+	0x70, 0x78, 0xf4, 0xed, 0xd1, 0xa0, 0xdf, 0x78, 0x0f, 0x39, 0x50, 0xee, 0xf5, 0xfb, 0x83, 0x7e,
+	0xa3, 0x80, 0x5c, 0xa8, 0x9e, 0x0c, 0xfb, 0xbd, 0xe3, 0x41, 0xbf, 0x51, 0x54, 0x86, 0x3f, 0x78,
+	0xf5, 0xe3, 0xe9, 0xa0, 0xdf, 0x28, 0xb5, 0x7f, 0x02, 0x67, 0x44, 0x27, 0x0c, 0xcb, 0x8c, 0x13,
+	0xf4, 0x00, 0x1c, 0x91, 0x1b, 0xb6, 0xa4, 0xd7, 0x00, 0xfa, 0x10, 0xee, 0xa4, 0xd9, 0x59, 0x44,
+	0xc3, 0xe0, 0x82, 0xcc, 0x82, 0x29, 0x16, 0x53, 0x5b, 0xe1, 0x0d, 0x03, 0xff, 0x40, 0x66, 0xdf,
+	0x61, 0x31, 0x6d, 0x3f, 0x03, 0xe7, 0x70, 0x4a, 0xc2, 0x0b, 0x91, 0xc5, 0x42, 0xd5, 0x44, 0x4c,
+	0x71, 0xf7, 0xe0, 0x0b, 0x7b, 0x9e, 0xb5, 0x2c, 0x7e, 0xf0, 0xb4, 0x6b, 0xcf, 0xb0, 0x56, 0xfb,
+	0xaf, 0x02, 0xd4, 0x87, 0x11, 0x96, 0xe7, 0x09, 0x8f, 0x9f, 0x67, 0x6c, 0x1c, 0x11, 0xa5, 0x9b,
+	0x71, 0xf2, 0x27, 0x8b, 0x12, 0x3c, 0x0e, 0x32, 0x1e, 0xd9, 0x83, 0xdc, 0x1c, 0x3b, 0xe1, 0x91,
+	0xca, 0xe1, 0x95, 0x9f, 0xc2, 0x2b, 0x6a, 0x35, 0xdf, 0x5b, 0xce, 0xfb, 0x55, 0x9c, 0xfe, 0x1c,
+	0x19, 0x7d, 0x09, 0x4e, 0x98, 0x7b, 0xab, 0xab, 0x7c, 0xc3, 0xce, 0xab, 0x70, 0xfc, 0x6b, 0x6e,
+	0x7b, 0x04, 0xe5, 0x97, 0xf8, 0x8c, 0x44, 0xaa, 0x55, 0x30, 0x1c, 0xe7, 0x09, 0xd3, 0xdf, 0xa8,
+	0x05, 0xee, 0x98, 0x88, 0x90, 0xd3, 0x54, 0x5e, 0xbf, 0x84, 0x79, 0x48, 0x69, 0x3a, 0x4c, 0xa2,
+	0x24, 0x7f, 0x06, 0xc6, 0x68, 0xbf, 0x59, 0x87, 0x8a, 0xd1, 0xc7, 0xca, 0xc3, 0xca, 0xaf, 0x29,
+	0xde, 0x7e, 0x4d, 0x69, 0xf5, 0x9a, 0xdb, 0x75, 0xfa, 0x09, 0xa0, 0x98, 0xb2, 0x60, 0xa9, 0x3d,
+	0x99, 0xd6, 0xd3, 0x88, 0x29, 0x1b, 0x2d, 0x74, 0xa8, 0x5d, 0xa8, 0x4d, 0x93, 0x98, 0xa8, 0xde,
+	0xa7, 0x8b, 0x60, 0xba, 0x90, 0x9b, 0x63, 0xaa, 0x08, 0xf7, 0xc1, 0xa1, 0x61, 0xc2, 0x82, 0x31,
+	0x96, 0xd8, 0xb6, 0xa1, 0x75, 0x05, 0xf4, 0xb1, 0xc4, 0x2b, 0x45, 0x5c, 0x5f, 0x2d, 0xe2, 0x47,
+	0xb0, 0xc9, 0x49, 0x44, 0xb0, 0x20, 0x01, 0x4b, 0x24, 0x11, 0x9a, 0xe7, 0x68, 0xde, 0x1d, 0xbb,
+	0xf0, 0x5a, 0xe1, 0x8a, 0xdb, 0x02, 0x57, 0x84, 0x9c, 0x10, 0x26, 0xa6, 0x89, 0x14, 0x1e, 0xb4,
+	0x4a, 0xea, 0xb4, 0x39, 0x08, 0xed, 0x41, 0xe3, 0x0c, 0x33, 0x46, 0x78, 0x40, 0xe3, 0xdc, 0x69,
+	0x57, 0x1f, 0x56, 0x37, 0xf8, 0x51, 0x6c, 0xfd, 0x5e, 0x14, 0x4f, 0xed, 0x7f, 0x8b, 0x67, 0xe3,
+	0xbf, 0x8b, 0x07, 0x1d, 0xaa, 0xde, 0x62, 0x54, 0x2e, 0xbc, 0xba, 0xbe, 0xf2, 0xf1, 0xcd, 0x7d,
+	0xa2, 0x93, 0xbf, 0x06, 0x31, 0x60, 0x92, 0xcf, 0xfc, 0xeb, 0x7d, 0xe8, 0x53, 0xa8, 0x44, 0x4a,
+	0x81, 0xc2, 0xbb, 0xa3, 0x4f, 0xb8, 0xbb, 0x7c, 0x82, 0xd6, 0xa7, 0x6f, 0x49, 0xcb, 0x43, 0xa6,
+	0xb1, 0x32, 0x64, 0x1e, 0xc1, 0x46, 0x5e, 0x00, 0x21, 0xd5, 0x90, 0xdb, 0xd4, 0x94, 0x9a, 0x05,
+	0x47, 0x0a, 0x9b, 0x9f, 0x44, 0x68, 0x71, 0x12, 0xed, 0xc3, 0xf6, 0xa2, 0x98, 0x02, 0xae, 0x1a,
+	0x95, 0xb7, 0xa5, 0x69, 0x68, 0x61, 0xe2, 0xf9, 0x6a, 0x45, 0x65, 0x3e, 0x4b, 0xc7, 0x58, 0x9a,
+	0xd6, 0xb7, 0xfd, 0xee, 0xd6, 0x67, 0xd9, 0x3d, 0x89, 0x9e, 0x81, 0x6b, 0xdd, 0xd2, 0x7b, 0xef,
+	0xbe, 0x73, 0x2f, 0xe4, 0xf4, 0x9e, 0x54, 0x81, 0xc6, 0x98, 0xd1, 0x73, 0x22, 0x64, 0xf0, 0xbb,
+	0x48, 0x98, 0xb7, 0x63, 0x02, 0xcd, 0xc1, 0xef, 0x45, 0xc2, 0xd4, 0xb4, 0xd7, 0x72, 0x56, 0xc2,
+	0x79, 0xdf, 0x44, 0xaa, 0xec, 0x13, 0x1e, 0x35, 0x7f, 0x83, 0xfa, 0x62, 0x55, 0x50, 0x03, 0x4a,
+	0x17, 0x64, 0x66, 0x5f, 0xab, 0xfa, 0x44, 0x9f, 0x43, 0xf9, 0x12, 0x47, 0x99, 0x79, 0xaf, 0x6e,
+	0xf7, 0x83, 0xd5, 0xea, 0xce, 0x37, 0x39, 0xdf, 0x90, 0xbf, 0x29, 0x7e, 0x55, 0xe8, 0xbe, 0x29,
+	0x82, 0xfb, 0xea, 0x9a, 0x8c, 0x4e, 0xc1, 0x9d, 0x1b, 0xdf, 0xa8, 0xbd, 0x52, 0xe5, 0x95, 0xbf,
+	0x97, 0xe6, 0xa3, 0xb7, 0x72, 0xec, 0xfc, 0x1f, 0x80, 0x73, 0x35, 0xcd, 0x51, 0x6b, 0x79, 0xc7,
+	0xf2, 0xa0, 0x6f, 0xde, 0x32, 0xc7, 0xd0, 0x08, 0x6a, 0xf3, 0x33, 0x1b, 0xad, 0xdc, 0x7d, 0xc3,
+	0x44, 0x6f, 0x3e, 0x78, 0xdb, 0x10, 0xde, 0x2f, 0x3c, 0x77, 0x7e, 0xad, 0x4e, 0x78, 0x1a, 0xe2,
+	0x94, 0x9e, 0x55, 0x74, 0x31, 0x3f, 0xfb, 0x77, 0x00, 0x72, 0x02, 0x04, 0x75, 0xed, 0x09, 0x00,
+	0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// MarketplaceClient is the client API for Marketplace service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type MarketplaceClient interface {
+	// ListPlugins lists the latest compatible version of each plugin, as GET /api/v1/plugins.
+	ListPlugins(ctx context.Context, in *ListPluginsRequest, opts ...grpc.CallOption) (*ListPluginsResponse, error)
+	// GetPlugin returns the given version of a plugin, or its latest version if none is given.
+	GetPlugin(ctx context.Context, in *GetPluginRequest, opts ...grpc.CallOption) (*Plugin, error)
+	// WatchPlugins streams changes to the catalog as it is reloaded.
+	WatchPlugins(ctx context.Context, in *WatchPluginsRequest, opts ...grpc.CallOption) (Marketplace_WatchPluginsClient, error)
+}
+
+type marketplaceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMarketplaceClient(cc *grpc.ClientConn) MarketplaceClient {
+	return &marketplaceClient{cc}
+}
+
+func (c *marketplaceClient) ListPlugins(ctx context.Context, in *ListPluginsRequest, opts ...grpc.CallOption) (*ListPluginsResponse, error) {
+	out := new(ListPluginsResponse)
+	err := c.cc.Invoke(ctx, "/marketplace.v1.Marketplace/ListPlugins", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketplaceClient) GetPlugin(ctx context.Context, in *GetPluginRequest, opts ...grpc.CallOption) (*Plugin, error) {
+	out := new(Plugin)
+	err := c.cc.Invoke(ctx, "/marketplace.v1.Marketplace/GetPlugin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marketplaceClient) WatchPlugins(ctx context.Context, in *WatchPluginsRequest, opts ...grpc.CallOption) (Marketplace_WatchPluginsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Marketplace_serviceDesc.Streams[0], "/marketplace.v1.Marketplace/WatchPlugins", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &marketplaceWatchPluginsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Marketplace_WatchPluginsClient interface {
+	Recv() (*PluginChange, error)
+	grpc.ClientStream
+}
+
+type marketplaceWatchPluginsClient struct {
+	grpc.ClientStream
+}
+
+func (x *marketplaceWatchPluginsClient) Recv() (*PluginChange, error) {
+	m := new(PluginChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MarketplaceServer is the server API for Marketplace service.
+type MarketplaceServer interface {
+	// ListPlugins lists the latest compatible version of each plugin, as GET /api/v1/plugins.
+	ListPlugins(context.Context, *ListPluginsRequest) (*ListPluginsResponse, error)
+	// GetPlugin returns the given version of a plugin, or its latest version if none is given.
+	GetPlugin(context.Context, *GetPluginRequest) (*Plugin, error)
+	// WatchPlugins streams changes to the catalog as it is reloaded.
+	WatchPlugins(*WatchPluginsRequest, Marketplace_WatchPluginsServer) error
+}
+
+// UnimplementedMarketplaceServer can be embedded to have forward compatible implementations.
+type UnimplementedMarketplaceServer struct {
+}
+
+func (*UnimplementedMarketplaceServer) ListPlugins(ctx context.Context, req *ListPluginsRequest) (*ListPluginsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPlugins not implemented")
+}
+func (*UnimplementedMarketplaceServer) GetPlugin(ctx context.Context, req *GetPluginRequest) (*Plugin, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlugin not implemented")
+}
+func (*UnimplementedMarketplaceServer) WatchPlugins(req *WatchPluginsRequest, srv Marketplace_WatchPluginsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPlugins not implemented")
+}
+
+func RegisterMarketplaceServer(s *grpc.Server, srv MarketplaceServer) {
+	s.RegisterService(&_Marketplace_serviceDesc, srv)
+}
+
+func _Marketplace_ListPlugins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPluginsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketplaceServer).ListPlugins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/marketplace.v1.Marketplace/ListPlugins",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketplaceServer).ListPlugins(ctx, req.(*ListPluginsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Marketplace_GetPlugin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPluginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarketplaceServer).GetPlugin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/marketplace.v1.Marketplace/GetPlugin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarketplaceServer).GetPlugin(ctx, req.(*GetPluginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Marketplace_WatchPlugins_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPluginsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MarketplaceServer).WatchPlugins(m, &marketplaceWatchPluginsServer{stream})
+}
+
+type Marketplace_WatchPluginsServer interface {
+	Send(*PluginChange) error
+	grpc.ServerStream
+}
+
+type marketplaceWatchPluginsServer struct {
+	grpc.ServerStream
+}
+
+func (x *marketplaceWatchPluginsServer) Send(m *PluginChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Marketplace_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "marketplace.v1.Marketplace",
+	HandlerType: (*MarketplaceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListPlugins",
+			Handler:    _Marketplace_ListPlugins_Handler,
+		},
+		{
+			MethodName: "GetPlugin",
+			Handler:    _Marketplace_GetPlugin_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchPlugins",
+			Handler:       _Marketplace_WatchPlugins_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "marketplace.proto",
+}
@@ -0,0 +1,301 @@
+// Package grpcapi serves the marketplace catalog over gRPC, for clients preferring protobuf
+// contracts over the JSON REST API.
+//
+// After editing marketplace.proto, regenerate marketplace.pb.go with protoc-gen-go v1.3.2:
+//
+//	protoc --go_out=plugins=grpc:. marketplace.proto
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Watcher describes the interface to the feed of catalog changes.
+type Watcher interface {
+	Cursor() int64
+	ChangesSince(cursor int64) ([]*model.CatalogChange, <-chan struct{}, error)
+}
+
+// Server implements the Marketplace gRPC service.
+type Server struct {
+	Store api.Store
+	// Watcher, if set, streams catalog changes to WatchPlugins. Without it, WatchPlugins is
+	// unimplemented.
+	Watcher Watcher
+	Logger  logrus.FieldLogger
+}
+
+var _ MarketplaceServer = (*Server)(nil)
+
+// Register registers the given server's service on the given gRPC server.
+func Register(grpcServer *grpc.Server, server *Server) {
+	RegisterMarketplaceServer(grpcServer, server)
+}
+
+// ListPlugins lists the latest compatible version of each plugin matching the request.
+func (s *Server) ListPlugins(ctx context.Context, request *ListPluginsRequest) (*ListPluginsResponse, error) {
+	authorType := model.AuthorType(request.AuthorType)
+	if authorType != "" && !authorType.IsValid() {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid author_type %s", authorType)
+	}
+	hosting := model.HostingRequirement(request.Hosting)
+	if hosting != "" && !hosting.IsValid() {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid hosting %s", hosting)
+	}
+	licenseTier, err := parseLicenseTier(request.LicenseTier)
+	if err != nil {
+		return nil, err
+	}
+
+	perPage := int(request.PerPage)
+	if perPage == 0 {
+		perPage = 100
+	}
+
+	plugins, err := s.Store.GetPlugins(&model.PluginFilter{
+		Page:          int(request.Page),
+		PerPage:       perPage,
+		Filter:        request.Filter,
+		ServerVersion: request.ServerVersion,
+		AuthorType:    authorType,
+		Hosting:       hosting,
+		LicenseTier:   licenseTier,
+	})
+	if err != nil {
+		s.Logger.WithError(err).Error("failed to query plugins")
+		return nil, status.Error(codes.Internal, "failed to query plugins")
+	}
+
+	response := &ListPluginsResponse{Plugins: make([]*Plugin, 0, len(plugins))}
+	for _, plugin := range plugins {
+		pluginProto, err := pluginToProto(plugin)
+		if err != nil {
+			s.Logger.WithError(err).Error("failed to convert plugin")
+			return nil, status.Error(codes.Internal, "failed to convert plugin")
+		}
+		response.Plugins = append(response.Plugins, pluginProto)
+	}
+
+	return response, nil
+}
+
+// GetPlugin returns the requested version of a plugin, or its latest version if none is given.
+// Versions requiring a higher license tier than the requested one are treated as missing.
+func (s *Server) GetPlugin(ctx context.Context, request *GetPluginRequest) (*Plugin, error) {
+	if request.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "plugin id is required")
+	}
+	licenseTier, err := parseLicenseTier(request.LicenseTier)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.Store.GetPluginVersions(request.Id)
+	if err != nil {
+		s.Logger.WithError(err).Error("failed to query plugin versions")
+		return nil, status.Error(codes.Internal, "failed to query plugin versions")
+	}
+
+	for _, plugin := range versions {
+		if licenseTier != "" && !licenseTier.Satisfies(plugin.RequiredLicense) {
+			continue
+		}
+		if request.Version == "" || plugin.Manifest.Version == request.Version {
+			pluginProto, err := pluginToProto(plugin)
+			if err != nil {
+				s.Logger.WithError(err).Error("failed to convert plugin")
+				return nil, status.Error(codes.Internal, "failed to convert plugin")
+			}
+			return pluginProto, nil
+		}
+	}
+
+	return nil, status.Errorf(codes.NotFound, "plugin %s not found", request.Id)
+}
+
+// parseLicenseTier parses the license tier of a request, matched case-insensitively as by the
+// REST API, returning an InvalidArgument error if it is unknown.
+func parseLicenseTier(value string) (model.LicenseTier, error) {
+	licenseTier := model.LicenseTier(strings.ToLower(value))
+	if licenseTier != "" && !licenseTier.IsValid() {
+		return "", status.Errorf(codes.InvalidArgument, "invalid license_tier %s", value)
+	}
+
+	return licenseTier, nil
+}
+
+// WatchPlugins streams the changes to the catalog after the requested cursor until the client
+// disconnects.
+func (s *Server) WatchPlugins(request *WatchPluginsRequest, stream Marketplace_WatchPluginsServer) error {
+	if s.Watcher == nil {
+		return status.Error(codes.Unimplemented, "catalog changes are not tracked")
+	}
+
+	cursor := request.Since
+	if cursor == 0 {
+		cursor = s.Watcher.Cursor()
+	}
+
+	for {
+		changes, updated, err := s.Watcher.ChangesSince(cursor)
+		if err == catalog.ErrUnknownCursor {
+			return status.Error(codes.OutOfRange, err.Error())
+		} else if err != nil {
+			s.Logger.WithError(err).Error("failed to query catalog changes")
+			return status.Error(codes.Internal, "failed to query catalog changes")
+		}
+
+		for _, change := range changes {
+			changeProto, err := changeToProto(change)
+			if err != nil {
+				s.Logger.WithError(err).Error("failed to convert catalog change")
+				return status.Error(codes.Internal, "failed to convert catalog change")
+			}
+			if err := stream.Send(changeProto); err != nil {
+				return err
+			}
+			cursor = change.Cursor
+		}
+
+		select {
+		case <-updated:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+var changeTypes = map[model.ChangeType]PluginChange_Type{
+	model.ChangeTypeAdded:   PluginChange_ADDED,
+	model.ChangeTypeUpdated: PluginChange_UPDATED,
+	model.ChangeTypeRemoved: PluginChange_REMOVED,
+}
+
+func changeToProto(change *model.CatalogChange) (*PluginChange, error) {
+	changedAt, err := timestampProto(change.ChangedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	changeProto := &PluginChange{
+		Cursor:    change.Cursor,
+		Type:      changeTypes[change.Type],
+		PluginId:  change.PluginID,
+		Version:   change.Version,
+		ChangedAt: changedAt,
+	}
+	if change.Plugin != nil {
+		changeProto.Plugin, err = pluginToProto(change.Plugin)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changeProto, nil
+}
+
+func pluginToProto(plugin *model.Plugin) (*Plugin, error) {
+	// Private bundles are only served re-hosted by the HTTP API, through pre-signed urls.
+	plugin = plugin.WithoutPrivateBundles()
+
+	manifestJSON, err := json.Marshal(plugin.Manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal manifest")
+	}
+	updatedAt, err := timestampProto(plugin.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	releasedAt, err := timestampProto(plugin.ReleasedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	pluginProto := &Plugin{
+		Id:                 plugin.Manifest.Id,
+		Name:               plugin.Manifest.Name,
+		Description:        plugin.Manifest.Description,
+		Version:            plugin.Manifest.Version,
+		MinServerVersion:   plugin.Manifest.MinServerVersion,
+		HomepageUrl:        plugin.HomepageURL,
+		IconData:           plugin.IconData,
+		IconUrl:            plugin.IconURL,
+		DownloadUrl:        plugin.DownloadURL,
+		ReleaseNotesUrl:    plugin.ReleaseNotesURL,
+		Screenshots:        plugin.Screenshots,
+		BannerImageUrl:     plugin.BannerImageURL,
+		Signatures:         signaturesToProto(plugin.AllSignatures()),
+		Checksums:          checksumsToProto(plugin.Checksums),
+		AuthorType:         string(plugin.AuthorType),
+		ReleaseStage:       string(plugin.ReleaseStage),
+		Hosting:            string(plugin.HostingRequirement),
+		ServerVersionRange: plugin.ServerVersionRange,
+		UpdatedAt:          updatedAt,
+		ReleasedAt:         releasedAt,
+		ManifestJson:       string(manifestJSON),
+	}
+
+	if len(plugin.Platforms) > 0 {
+		pluginProto.Platforms = map[string]*PlatformBundle{}
+		for platform, bundle := range plugin.Platforms {
+			pluginProto.Platforms[platform] = &PlatformBundle{
+				DownloadUrl: bundle.DownloadURL,
+				Signatures:  signaturesToProto(bundle.Signatures),
+				Checksums:   checksumsToProto(bundle.Checksums),
+			}
+		}
+	}
+	for _, label := range plugin.Labels {
+		pluginProto.Labels = append(pluginProto.Labels, &Label{
+			Name:        label.Name,
+			Description: label.Description,
+			Color:       label.Color,
+		})
+	}
+
+	return pluginProto, nil
+}
+
+func signaturesToProto(signatures []*model.Signature) []*Signature {
+	var signaturesProto []*Signature
+	for _, signature := range signatures {
+		signaturesProto = append(signaturesProto, &Signature{
+			Signature:     signature.Signature,
+			PublicKeyHash: signature.PublicKeyHash,
+		})
+	}
+
+	return signaturesProto
+}
+
+func checksumsToProto(checksums *model.Checksums) *Checksums {
+	if checksums == nil {
+		return nil
+	}
+
+	return &Checksums{Sha256: checksums.SHA256, Sha512: checksums.SHA512}
+}
+
+// timestampProto converts the given time, leaving zero times unset.
+func timestampProto(t time.Time) (*timestamp.Timestamp, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+
+	return ptypes.TimestampProto(t)
+}
@@ -0,0 +1,239 @@
+package grpcapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/grpcapi"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+)
+
+func makeStore(t *testing.T, plugins ...*model.Plugin) *store.Store {
+	t.Helper()
+
+	if plugins == nil {
+		plugins = []*model.Plugin{}
+	}
+	data, err := json.Marshal(plugins)
+	require.NoError(t, err)
+
+	pluginStore, err := store.New(bytes.NewReader(data), testlib.MakeLogger(t))
+	require.NoError(t, err)
+
+	return pluginStore
+}
+
+func setupServer(t *testing.T, server *grpcapi.Server) (grpcapi.MarketplaceClient, func()) {
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	grpcapi.Register(grpcServer, server)
+	go grpcServer.Serve(listener)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	require.NoError(t, err)
+
+	return grpcapi.NewMarketplaceClient(conn), func() {
+		conn.Close()
+		grpcServer.Stop()
+	}
+}
+
+func TestServer(t *testing.T) {
+	demo010 := &model.Plugin{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		AuthorType:   model.AuthorTypeMattermost,
+		Checksums:    &model.Checksums{SHA256: "abababababababababababababababababababababababababababababababab"},
+		Signatures:   []*model.Signature{{Signature: "c2lnbmF0dXJl", PublicKeyHash: "key"}},
+		UpdatedAt:    time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC),
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0", MinServerVersion: "5.14.0"},
+	}
+	demo020 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Platforms: map[string]*model.PlatformBundle{
+			"linux-amd64": {DownloadURL: "https://example.com/demo-0.2.0-linux-amd64.tar.gz"},
+		},
+		Manifest: &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0"},
+	}
+	starter := &model.Plugin{
+		DownloadURL:  "https://example.com/starter-0.1.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "starter", Name: "Starter", Version: "0.1.0"},
+	}
+
+	pluginCatalog := catalog.New(makeStore(t, demo010, demo020))
+	client, tearDown := setupServer(t, &grpcapi.Server{
+		Store:   pluginCatalog,
+		Watcher: pluginCatalog,
+		Logger:  testlib.MakeLogger(t),
+	})
+	defer tearDown()
+
+	ctx := context.Background()
+
+	t.Run("list plugins", func(t *testing.T) {
+		response, err := client.ListPlugins(ctx, &grpcapi.ListPluginsRequest{PerPage: -1})
+		require.NoError(t, err)
+		require.Len(t, response.Plugins, 1)
+		require.Equal(t, "0.2.0", response.Plugins[0].Version)
+		require.Equal(t, "https://example.com/demo-0.2.0-linux-amd64.tar.gz", response.Plugins[0].Platforms["linux-amd64"].DownloadUrl)
+
+		response, err = client.ListPlugins(ctx, &grpcapi.ListPluginsRequest{Filter: "missing"})
+		require.NoError(t, err)
+		require.Empty(t, response.Plugins)
+
+		_, err = client.ListPlugins(ctx, &grpcapi.ListPluginsRequest{AuthorType: "invalid"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("get plugin", func(t *testing.T) {
+		plugin, err := client.GetPlugin(ctx, &grpcapi.GetPluginRequest{Id: "demo"})
+		require.NoError(t, err)
+		require.Equal(t, "0.2.0", plugin.Version)
+
+		plugin, err = client.GetPlugin(ctx, &grpcapi.GetPluginRequest{Id: "demo", Version: "0.1.0"})
+		require.NoError(t, err)
+		require.Equal(t, "Demo", plugin.Name)
+		require.Equal(t, "5.14.0", plugin.MinServerVersion)
+		require.Equal(t, "mattermost", plugin.AuthorType)
+		require.Equal(t, "abababababababababababababababababababababababababababababababab", plugin.Checksums.Sha256)
+		require.Equal(t, "key", plugin.Signatures[0].PublicKeyHash)
+		require.Equal(t, int64(1575158400), plugin.UpdatedAt.Seconds)
+		require.Nil(t, plugin.ReleasedAt)
+		require.Contains(t, plugin.ManifestJson, `"min_server_version":"5.14.0"`)
+
+		_, err = client.GetPlugin(ctx, &grpcapi.GetPluginRequest{Id: "demo", Version: "0.3.0"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		_, err = client.GetPlugin(ctx, &grpcapi.GetPluginRequest{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("watch plugins", func(t *testing.T) {
+		watchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		stream, err := client.WatchPlugins(watchCtx, &grpcapi.WatchPluginsRequest{Since: pluginCatalog.Cursor()})
+		require.NoError(t, err)
+
+		pluginCatalog.Replace(makeStore(t, demo020, starter))
+
+		removed, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, grpcapi.PluginChange_REMOVED, removed.Type)
+		require.Equal(t, "demo", removed.PluginId)
+		require.Equal(t, "0.1.0", removed.Version)
+		require.Nil(t, removed.Plugin)
+
+		added, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, grpcapi.PluginChange_ADDED, added.Type)
+		require.Equal(t, "starter", added.Plugin.Id)
+		require.Equal(t, removed.Cursor+1, added.Cursor)
+	})
+
+	t.Run("watch from unknown cursor", func(t *testing.T) {
+		stream, err := client.WatchPlugins(ctx, &grpcapi.WatchPluginsRequest{Since: 1})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		require.Equal(t, codes.OutOfRange, status.Code(err))
+	})
+}
+
+func TestServerWithoutWatcher(t *testing.T) {
+	client, tearDown := setupServer(t, &grpcapi.Server{
+		Store:  makeStore(t),
+		Logger: testlib.MakeLogger(t),
+	})
+	defer tearDown()
+
+	stream, err := client.WatchPlugins(context.Background(), &grpcapi.WatchPluginsRequest{})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestServerLicenseTiers(t *testing.T) {
+	demo010 := &model.Plugin{
+		DownloadURL:     "https://example.com/demo-0.1.0.tar.gz",
+		ReleaseStage:    model.ReleaseStageProduction,
+		RequiredLicense: model.LicenseTierEnterprise,
+		Manifest:        &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+	}
+	demo020 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0"},
+	}
+	enterprise := &model.Plugin{
+		DownloadURL:     "https://example.com/enterprise-0.1.0.tar.gz",
+		ReleaseStage:    model.ReleaseStageProduction,
+		RequiredLicense: model.LicenseTierEnterprise,
+		Manifest:        &mattermostModel.Manifest{Id: "enterprise", Name: "Enterprise", Version: "0.1.0"},
+	}
+
+	client, tearDown := setupServer(t, &grpcapi.Server{
+		Store:  makeStore(t, demo010, demo020, enterprise),
+		Logger: testlib.MakeLogger(t),
+	})
+	defer tearDown()
+
+	ctx := context.Background()
+
+	t.Run("list plugins", func(t *testing.T) {
+		response, err := client.ListPlugins(ctx, &grpcapi.ListPluginsRequest{PerPage: -1})
+		require.NoError(t, err)
+		require.Len(t, response.Plugins, 2)
+
+		response, err = client.ListPlugins(ctx, &grpcapi.ListPluginsRequest{PerPage: -1, LicenseTier: "Team"})
+		require.NoError(t, err)
+		require.Len(t, response.Plugins, 1)
+		require.Equal(t, "demo", response.Plugins[0].Id)
+
+		_, err = client.ListPlugins(ctx, &grpcapi.ListPluginsRequest{LicenseTier: "platinum"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("get plugin", func(t *testing.T) {
+		plugin, err := client.GetPlugin(ctx, &grpcapi.GetPluginRequest{Id: "demo", LicenseTier: "team"})
+		require.NoError(t, err)
+		require.Equal(t, "0.2.0", plugin.Version)
+
+		_, err = client.GetPlugin(ctx, &grpcapi.GetPluginRequest{Id: "demo", Version: "0.1.0", LicenseTier: "team"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		plugin, err = client.GetPlugin(ctx, &grpcapi.GetPluginRequest{Id: "demo", Version: "0.1.0"})
+		require.NoError(t, err)
+		require.Equal(t, "0.1.0", plugin.Version)
+
+		_, err = client.GetPlugin(ctx, &grpcapi.GetPluginRequest{Id: "enterprise", LicenseTier: "team"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		plugin, err = client.GetPlugin(ctx, &grpcapi.GetPluginRequest{Id: "enterprise", LicenseTier: "enterprise"})
+		require.NoError(t, err)
+		require.Equal(t, "0.1.0", plugin.Version)
+
+		_, err = client.GetPlugin(ctx, &grpcapi.GetPluginRequest{Id: "demo", LicenseTier: "platinum"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
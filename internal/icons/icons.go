@@ -0,0 +1,87 @@
+// Package icons hosts plugin icons outside the database, so that plugins reference their icon by
+// url rather than inlining it as a data URI.
+package icons
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/pkg/errors"
+)
+
+// Store describes the interface to a host of plugin icons.
+type Store interface {
+	// Put stores the given icon, returning the url at which it is served.
+	Put(mimeType string, data []byte) (string, error)
+}
+
+// extensions maps the allowed icon mime types to the extension of the stored object.
+var extensions = map[string]string{
+	"image/svg+xml": ".svg",
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+}
+
+// S3Store stores icons as objects in S3, typically fronted by a CDN.
+//
+// Objects are named after the digest of their contents, so an unchanged icon is uploaded only
+// once and may be cached indefinitely.
+type S3Store struct {
+	Client s3iface.S3API
+	Bucket string
+	// Prefix is prepended to the key of each object, e.g. icons/.
+	Prefix string
+	// BaseURL is the url serving the bucket, defaulting to the bucket's S3 endpoint.
+	BaseURL string
+}
+
+// Put uploads the given icon unless an identical icon was already uploaded.
+func (s *S3Store) Put(mimeType string, data []byte) (string, error) {
+	extension, ok := extensions[mimeType]
+	if !ok {
+		return "", errors.Errorf("icon has disallowed mime type %s", mimeType)
+	}
+
+	digest := sha256.Sum256(data)
+	key := s.Prefix + hex.EncodeToString(digest[:]) + extension
+
+	_, err := s.Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if awsErr, ok := err.(awserr.RequestFailure); ok && awsErr.StatusCode() == http.StatusNotFound {
+		_, err = s.Client.PutObject(&s3.PutObjectInput{
+			Bucket:       aws.String(s.Bucket),
+			Key:          aws.String(key),
+			Body:         bytes.NewReader(data),
+			ContentType:  aws.String(mimeType),
+			CacheControl: aws.String("public, max-age=31536000, immutable"),
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to put s3://%s/%s", s.Bucket, key)
+		}
+	} else if err != nil {
+		return "", errors.Wrapf(err, "failed to head s3://%s/%s", s.Bucket, key)
+	}
+
+	return s.url(key), nil
+}
+
+// url returns the url at which the given key is served.
+func (s *S3Store) url(key string) string {
+	baseURL := s.BaseURL
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("https://%s.s3.amazonaws.com", s.Bucket)
+	}
+
+	return strings.TrimSuffix(baseURL, "/") + "/" + key
+}
@@ -0,0 +1,74 @@
+package icons
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/require"
+)
+
+type mockS3Client struct {
+	s3iface.S3API
+	objects map[string][]byte
+	puts    int
+}
+
+func (m *mockS3Client) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	if _, ok := m.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)]; !ok {
+		return nil, awserr.NewRequestFailure(awserr.New("NotFound", "not found", nil), http.StatusNotFound, "")
+	}
+
+	return &s3.HeadObjectOutput{}, nil
+}
+
+func (m *mockS3Client) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	data, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)] = data
+	m.puts++
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestS3Store(t *testing.T) {
+	t.Run("uploads once", func(t *testing.T) {
+		client := &mockS3Client{objects: map[string][]byte{}}
+		store := &S3Store{Client: client, Bucket: "bucket", Prefix: "icons/", BaseURL: "https://cdn.example.com/"}
+
+		iconURL, err := store.Put("image/svg+xml", []byte("<svg></svg>"))
+		require.NoError(t, err)
+		require.Regexp(t, `^https://cdn\.example\.com/icons/[0-9a-f]{64}\.svg$`, iconURL)
+
+		again, err := store.Put("image/svg+xml", []byte("<svg></svg>"))
+		require.NoError(t, err)
+		require.Equal(t, iconURL, again)
+		require.Equal(t, 1, client.puts)
+
+		other, err := store.Put("image/png", []byte("png"))
+		require.NoError(t, err)
+		require.NotEqual(t, iconURL, other)
+		require.Equal(t, 2, client.puts)
+	})
+
+	t.Run("defaults to the bucket endpoint", func(t *testing.T) {
+		store := &S3Store{Client: &mockS3Client{objects: map[string][]byte{}}, Bucket: "bucket"}
+
+		iconURL, err := store.Put("image/png", []byte("png"))
+		require.NoError(t, err)
+		require.Regexp(t, `^https://bucket\.s3\.amazonaws\.com/[0-9a-f]{64}\.png$`, iconURL)
+	})
+
+	t.Run("disallowed mime type", func(t *testing.T) {
+		store := &S3Store{Client: &mockS3Client{objects: map[string][]byte{}}, Bucket: "bucket"}
+
+		_, err := store.Put("image/bmp", []byte("bmp"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,302 @@
+// Package metrics records the latency of API requests per route, exposing histograms and service
+// level objective burn rates in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the latency histogram buckets, matching the
+// defaults of the Prometheus client libraries.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// burnRateWindows are the trailing windows over which each objective's burn rate is reported.
+var burnRateWindows = []struct {
+	name    string
+	minutes int64
+}{
+	{"5m", 5},
+	{"1h", 60},
+}
+
+// windowMinutes is the number of trailing minutes retained to compute burn rates.
+const windowMinutes = 60
+
+// Objective is a service level objective for a route: the fraction of requests that must succeed
+// within the given latency.
+type Objective struct {
+	// Route is the path template of the route, such as /api/v1/plugins.
+	Route string
+	// Latency is the duration within which a successful request counts towards the objective.
+	Latency time.Duration
+	// Target is the fraction of requests expected to meet the objective, such as 0.99.
+	Target float64
+}
+
+// IsValid verifies the objective is well-formed.
+func (o Objective) IsValid() error {
+	if o.Route == "" {
+		return errors.New("objective route must not be empty")
+	}
+	if o.Latency <= 0 {
+		return errors.New("objective latency must be positive")
+	}
+	if o.Target <= 0 || o.Target >= 1 {
+		return errors.New("objective target must be between 0 and 1, exclusive")
+	}
+
+	return nil
+}
+
+// ParseObjective parses an objective of the form route=latency:target, such as
+// /api/v1/plugins=250ms:0.99.
+func ParseObjective(value string) (Objective, error) {
+	separator := strings.LastIndex(value, "=")
+	if separator == -1 {
+		return Objective{}, errors.Errorf("objective %s is not of the form route=latency:target", value)
+	}
+	parts := strings.SplitN(value[separator+1:], ":", 2)
+	if len(parts) != 2 {
+		return Objective{}, errors.Errorf("objective %s is not of the form route=latency:target", value)
+	}
+
+	latency, err := time.ParseDuration(parts[0])
+	if err != nil {
+		return Objective{}, errors.Wrapf(err, "invalid latency in objective %s", value)
+	}
+	target, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return Objective{}, errors.Wrapf(err, "invalid target in objective %s", value)
+	}
+
+	objective := Objective{Route: value[:separator], Latency: latency, Target: target}
+	if err := objective.IsValid(); err != nil {
+		return Objective{}, errors.Wrapf(err, "invalid objective %s", value)
+	}
+
+	return objective, nil
+}
+
+type seriesKey struct {
+	route  string
+	method string
+}
+
+type histogram struct {
+	counts []int64
+	sum    float64
+	count  int64
+}
+
+type minute struct {
+	minute int64
+	total  int64
+	good   int64
+}
+
+type objectiveCounts struct {
+	objective Objective
+	total     int64
+	good      int64
+	minutes   [windowMinutes]minute
+}
+
+// Recorder aggregates request latencies in memory.
+type Recorder struct {
+	buckets []float64
+	now     func() time.Time
+
+	lock       sync.Mutex
+	histograms map[seriesKey]*histogram
+	statuses   map[seriesKey]map[int]int64
+	objectives map[string]*objectiveCounts
+}
+
+// NewRecorder creates a recorder with the given histogram buckets, or DefaultBuckets if none,
+// tracking the given objectives.
+func NewRecorder(buckets []float64, objectives []Objective) (*Recorder, error) {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	recorder := &Recorder{
+		buckets:    buckets,
+		now:        time.Now,
+		histograms: map[seriesKey]*histogram{},
+		statuses:   map[seriesKey]map[int]int64{},
+		objectives: map[string]*objectiveCounts{},
+	}
+
+	for _, objective := range objectives {
+		if err := objective.IsValid(); err != nil {
+			return nil, errors.Wrapf(err, "invalid objective for %s", objective.Route)
+		}
+		if _, ok := recorder.objectives[objective.Route]; ok {
+			return nil, errors.Errorf("route %s has more than one objective", objective.Route)
+		}
+		recorder.objectives[objective.Route] = &objectiveCounts{objective: objective}
+	}
+
+	return recorder, nil
+}
+
+// ObserveRequest records a request to the given route answered with the given status code after
+// the given duration.
+func (r *Recorder) ObserveRequest(route, method string, statusCode int, duration time.Duration) {
+	seconds := duration.Seconds()
+	k := seriesKey{route, method}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	h, ok := r.histograms[k]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(r.buckets))}
+		r.histograms[k] = h
+		r.statuses[k] = map[int]int64{}
+	}
+	for i, bucket := range r.buckets {
+		if seconds <= bucket {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+	r.statuses[k][statusCode]++
+
+	counts, ok := r.objectives[route]
+	if !ok {
+		return
+	}
+
+	good := statusCode < 500 && duration <= counts.objective.Latency
+	current := r.now().Unix() / 60
+	m := &counts.minutes[current%windowMinutes]
+	if m.minute != current {
+		*m = minute{minute: current}
+	}
+
+	counts.total++
+	m.total++
+	if good {
+		counts.good++
+		m.good++
+	}
+}
+
+// burnRate returns the rate at which the objective's error budget was spent over the trailing
+// minutes, where 1 spends exactly the budget over the objective's period.
+func (c *objectiveCounts) burnRate(current, minutes int64) float64 {
+	var total, good int64
+	for i := int64(0); i < minutes; i++ {
+		m := c.minutes[(current-i)%windowMinutes]
+		if m.minute == current-i {
+			total += m.total
+			good += m.good
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	return float64(total-good) / float64(total) / (1 - c.objective.Target)
+}
+
+// WriteMetrics writes the recorded latencies and objectives in the Prometheus text format.
+func (r *Recorder) WriteMetrics(w io.Writer) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	keys := make([]seriesKey, 0, len(r.histograms))
+	for k := range r.histograms {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("# HELP marketplace_http_requests_total Requests served by the marketplace, by route, method and status code.\n# TYPE marketplace_http_requests_total counter\n")
+	for _, k := range keys {
+		statusCodes := make([]int, 0, len(r.statuses[k]))
+		for statusCode := range r.statuses[k] {
+			statusCodes = append(statusCodes, statusCode)
+		}
+		sort.Ints(statusCodes)
+
+		for _, statusCode := range statusCodes {
+			printf("marketplace_http_requests_total{route=%q,method=%q,code=\"%d\"} %d\n", k.route, k.method, statusCode, r.statuses[k][statusCode])
+		}
+	}
+
+	printf("# HELP marketplace_http_request_duration_seconds Latency of requests served by the marketplace, by route and method.\n# TYPE marketplace_http_request_duration_seconds histogram\n")
+	for _, k := range keys {
+		h := r.histograms[k]
+		for i, bucket := range r.buckets {
+			printf("marketplace_http_request_duration_seconds_bucket{route=%q,method=%q,le=%q} %d\n", k.route, k.method, formatFloat(bucket), h.counts[i])
+		}
+		printf("marketplace_http_request_duration_seconds_bucket{route=%q,method=%q,le=\"+Inf\"} %d\n", k.route, k.method, h.count)
+		printf("marketplace_http_request_duration_seconds_sum{route=%q,method=%q} %s\n", k.route, k.method, formatFloat(h.sum))
+		printf("marketplace_http_request_duration_seconds_count{route=%q,method=%q} %d\n", k.route, k.method, h.count)
+	}
+
+	if len(r.objectives) == 0 {
+		return err
+	}
+
+	routes := make([]string, 0, len(r.objectives))
+	for route := range r.objectives {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	printf("# HELP marketplace_slo_target Fraction of requests expected to meet the route's objective.\n# TYPE marketplace_slo_target gauge\n")
+	for _, route := range routes {
+		printf("marketplace_slo_target{route=%q} %s\n", route, formatFloat(r.objectives[route].objective.Target))
+	}
+	printf("# HELP marketplace_slo_latency_seconds Latency within which a successful request meets the route's objective.\n# TYPE marketplace_slo_latency_seconds gauge\n")
+	for _, route := range routes {
+		printf("marketplace_slo_latency_seconds{route=%q} %s\n", route, formatFloat(r.objectives[route].objective.Latency.Seconds()))
+	}
+	printf("# HELP marketplace_slo_requests_total Requests counted towards the route's objective.\n# TYPE marketplace_slo_requests_total counter\n")
+	for _, route := range routes {
+		printf("marketplace_slo_requests_total{route=%q} %d\n", route, r.objectives[route].total)
+	}
+	printf("# HELP marketplace_slo_good_requests_total Requests meeting the route's objective.\n# TYPE marketplace_slo_good_requests_total counter\n")
+	for _, route := range routes {
+		printf("marketplace_slo_good_requests_total{route=%q} %d\n", route, r.objectives[route].good)
+	}
+
+	current := r.now().Unix() / 60
+	printf("# HELP marketplace_slo_burn_rate Rate at which the route's error budget was spent over the trailing window.\n# TYPE marketplace_slo_burn_rate gauge\n")
+	for _, route := range routes {
+		for _, window := range burnRateWindows {
+			printf("marketplace_slo_burn_rate{route=%q,window=%q} %s\n", route, window.name, formatFloat(r.objectives[route].burnRate(current, window.minutes)))
+		}
+	}
+
+	return err
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseObjective(t *testing.T) {
+	objective, err := ParseObjective("/api/v1/plugins=250ms:0.99")
+	require.NoError(t, err)
+	require.Equal(t, Objective{Route: "/api/v1/plugins", Latency: 250 * time.Millisecond, Target: 0.99}, objective)
+
+	for _, value := range []string{
+		"/api/v1/plugins",
+		"/api/v1/plugins=250ms",
+		"/api/v1/plugins=fast:0.99",
+		"/api/v1/plugins=250ms:high",
+		"/api/v1/plugins=250ms:1",
+		"=250ms:0.99",
+	} {
+		_, err := ParseObjective(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestNewRecorder(t *testing.T) {
+	_, err := NewRecorder(nil, []Objective{{Route: "/api/v1/plugins", Latency: time.Second, Target: 2}})
+	require.Error(t, err)
+
+	_, err = NewRecorder(nil, []Objective{
+		{Route: "/api/v1/plugins", Latency: time.Second, Target: 0.9},
+		{Route: "/api/v1/plugins", Latency: time.Second, Target: 0.99},
+	})
+	require.EqualError(t, err, "route /api/v1/plugins has more than one objective")
+}
+
+func TestRecorder(t *testing.T) {
+	recorder, err := NewRecorder([]float64{0.5, 0.1}, []Objective{{
+		Route:   "/api/v1/plugins",
+		Latency: 200 * time.Millisecond,
+		Target:  0.75,
+	}})
+	require.NoError(t, err)
+
+	now := time.Date(2019, 12, 1, 12, 0, 0, 0, time.UTC)
+	recorder.now = func() time.Time { return now }
+
+	// Half an hour ago: only the hourly window counts these.
+	now = now.Add(-30 * time.Minute)
+	recorder.ObserveRequest("/api/v1/plugins", "GET", http.StatusOK, 50*time.Millisecond)
+	recorder.ObserveRequest("/api/v1/plugins", "GET", http.StatusOK, 50*time.Millisecond)
+	now = now.Add(30 * time.Minute)
+
+	recorder.ObserveRequest("/api/v1/plugins", "GET", http.StatusOK, 50*time.Millisecond)
+	recorder.ObserveRequest("/api/v1/plugins", "GET", http.StatusOK, 300*time.Millisecond)
+	recorder.ObserveRequest("/api/v1/plugins", "GET", http.StatusInternalServerError, 10*time.Millisecond)
+	recorder.ObserveRequest("/api/v1/plugins", "GET", http.StatusBadRequest, 10*time.Millisecond)
+	recorder.ObserveRequest("/api/v1/plugins/{id}/ratings", "POST", http.StatusCreated, time.Second)
+
+	var buf bytes.Buffer
+	require.NoError(t, recorder.WriteMetrics(&buf))
+	require.Equal(t, `# HELP marketplace_http_requests_total Requests served by the marketplace, by route, method and status code.
+# TYPE marketplace_http_requests_total counter
+marketplace_http_requests_total{route="/api/v1/plugins",method="GET",code="200"} 4
+marketplace_http_requests_total{route="/api/v1/plugins",method="GET",code="400"} 1
+marketplace_http_requests_total{route="/api/v1/plugins",method="GET",code="500"} 1
+marketplace_http_requests_total{route="/api/v1/plugins/{id}/ratings",method="POST",code="201"} 1
+# HELP marketplace_http_request_duration_seconds Latency of requests served by the marketplace, by route and method.
+# TYPE marketplace_http_request_duration_seconds histogram
+marketplace_http_request_duration_seconds_bucket{route="/api/v1/plugins",method="GET",le="0.1"} 5
+marketplace_http_request_duration_seconds_bucket{route="/api/v1/plugins",method="GET",le="0.5"} 6
+marketplace_http_request_duration_seconds_bucket{route="/api/v1/plugins",method="GET",le="+Inf"} 6
+marketplace_http_request_duration_seconds_sum{route="/api/v1/plugins",method="GET"} 0.47000000000000003
+marketplace_http_request_duration_seconds_count{route="/api/v1/plugins",method="GET"} 6
+marketplace_http_request_duration_seconds_bucket{route="/api/v1/plugins/{id}/ratings",method="POST",le="0.1"} 0
+marketplace_http_request_duration_seconds_bucket{route="/api/v1/plugins/{id}/ratings",method="POST",le="0.5"} 0
+marketplace_http_request_duration_seconds_bucket{route="/api/v1/plugins/{id}/ratings",method="POST",le="+Inf"} 1
+marketplace_http_request_duration_seconds_sum{route="/api/v1/plugins/{id}/ratings",method="POST"} 1
+marketplace_http_request_duration_seconds_count{route="/api/v1/plugins/{id}/ratings",method="POST"} 1
+# HELP marketplace_slo_target Fraction of requests expected to meet the route's objective.
+# TYPE marketplace_slo_target gauge
+marketplace_slo_target{route="/api/v1/plugins"} 0.75
+# HELP marketplace_slo_latency_seconds Latency within which a successful request meets the route's objective.
+# TYPE marketplace_slo_latency_seconds gauge
+marketplace_slo_latency_seconds{route="/api/v1/plugins"} 0.2
+# HELP marketplace_slo_requests_total Requests counted towards the route's objective.
+# TYPE marketplace_slo_requests_total counter
+marketplace_slo_requests_total{route="/api/v1/plugins"} 6
+# HELP marketplace_slo_good_requests_total Requests meeting the route's objective.
+# TYPE marketplace_slo_good_requests_total counter
+marketplace_slo_good_requests_total{route="/api/v1/plugins"} 4
+# HELP marketplace_slo_burn_rate Rate at which the route's error budget was spent over the trailing window.
+# TYPE marketplace_slo_burn_rate gauge
+marketplace_slo_burn_rate{route="/api/v1/plugins",window="5m"} 2
+marketplace_slo_burn_rate{route="/api/v1/plugins",window="1h"} 1.3333333333333333
+`, buf.String())
+
+	t.Run("burn rate decays", func(t *testing.T) {
+		now = now.Add(2 * time.Hour)
+
+		var buf bytes.Buffer
+		require.NoError(t, recorder.WriteMetrics(&buf))
+		require.Contains(t, buf.String(), `marketplace_slo_burn_rate{route="/api/v1/plugins",window="1h"} 0`+"\n")
+		require.Contains(t, buf.String(), `marketplace_slo_requests_total{route="/api/v1/plugins"} 6`+"\n")
+	})
+}
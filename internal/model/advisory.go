@@ -0,0 +1,113 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AdvisorySeverity describes how severe the vulnerability disclosed by an advisory is.
+type AdvisorySeverity string
+
+const (
+	// AdvisorySeverityLow describes a vulnerability of limited impact.
+	AdvisorySeverityLow AdvisorySeverity = "low"
+	// AdvisorySeverityMedium describes a vulnerability admins should address at their convenience.
+	AdvisorySeverityMedium AdvisorySeverity = "medium"
+	// AdvisorySeverityHigh describes a vulnerability admins should address promptly.
+	AdvisorySeverityHigh AdvisorySeverity = "high"
+	// AdvisorySeverityCritical describes a vulnerability admins should address immediately.
+	AdvisorySeverityCritical AdvisorySeverity = "critical"
+)
+
+// IsValid reports whether the severity is one of the known values.
+func (s AdvisorySeverity) IsValid() bool {
+	switch s {
+	case AdvisorySeverityLow, AdvisorySeverityMedium, AdvisorySeverityHigh, AdvisorySeverityCritical:
+		return true
+	}
+
+	return false
+}
+
+// Advisory discloses a vulnerability in the versions of a plugin preceding the one fixing it.
+type Advisory struct {
+	ID          string           `json:"id"`
+	PluginID    string           `json:"plugin_id"`
+	Severity    AdvisorySeverity `json:"severity"`
+	Description string           `json:"description"`
+	// FixedIn is the first version without the vulnerability, or empty if every version is
+	// affected.
+	FixedIn string `json:"fixed_in,omitempty"`
+	// PublisherID identifies the moderator who published the advisory.
+	PublisherID string    `json:"publisher_id,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// IsValid verifies the advisory is well-formed.
+func (a *Advisory) IsValid() error {
+	if a.ID == "" {
+		return errors.New("advisory id is empty")
+	}
+	if a.PluginID == "" {
+		return errors.Errorf("advisory %s has no plugin id", a.ID)
+	}
+	if !a.Severity.IsValid() {
+		return errors.Errorf("advisory %s has invalid severity %s", a.ID, a.Severity)
+	}
+	if a.Description == "" {
+		return errors.Errorf("advisory %s has no description", a.ID)
+	}
+	if a.FixedIn != "" {
+		if _, err := ParseVersion(a.FixedIn); err != nil {
+			return errors.Wrapf(err, "advisory %s has invalid fixed_in version %s", a.ID, a.FixedIn)
+		}
+	}
+
+	return nil
+}
+
+// Affects reports whether the given version of the advisory's plugin has the vulnerability.
+// Versions that cannot be parsed are considered affected unless they are the fixed version.
+func (a *Advisory) Affects(version string) bool {
+	if a.FixedIn == "" {
+		return true
+	}
+
+	fixedIn, err := ParseVersion(a.FixedIn)
+	if err != nil {
+		return version != a.FixedIn
+	}
+	v, err := ParseVersion(version)
+	if err != nil {
+		return version != a.FixedIn
+	}
+
+	return v.LT(fixedIn)
+}
+
+// AdvisoryFromReader decodes a json-encoded Advisory from the given io.Reader.
+func AdvisoryFromReader(reader io.Reader) (*Advisory, error) {
+	advisory := Advisory{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&advisory)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &advisory, nil
+}
+
+// AdvisoriesFromReader decodes a json-encoded list of advisories from the given io.Reader.
+func AdvisoriesFromReader(reader io.Reader) ([]*Advisory, error) {
+	advisories := []*Advisory{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&advisories)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return advisories, nil
+}
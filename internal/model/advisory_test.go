@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryIsValid(t *testing.T) {
+	validAdvisory := func() *Advisory {
+		return &Advisory{
+			ID:          "advisory",
+			PluginID:    "jira",
+			Severity:    AdvisorySeverityHigh,
+			Description: "Webhook secrets are logged.",
+			FixedIn:     "3.0.1",
+		}
+	}
+
+	require.NoError(t, validAdvisory().IsValid())
+
+	testCases := []struct {
+		Description   string
+		Modify        func(advisory *Advisory)
+		ExpectedError string
+	}{
+		{"no id", func(advisory *Advisory) { advisory.ID = "" }, "advisory id is empty"},
+		{"no plugin id", func(advisory *Advisory) { advisory.PluginID = "" }, "advisory advisory has no plugin id"},
+		{"unknown severity", func(advisory *Advisory) { advisory.Severity = "severe" }, "advisory advisory has invalid severity severe"},
+		{"no description", func(advisory *Advisory) { advisory.Description = "" }, "advisory advisory has no description"},
+		{"invalid fixed version", func(advisory *Advisory) { advisory.FixedIn = "next" }, "advisory advisory has invalid fixed_in version next: No Major.Minor.Patch elements found"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			advisory := validAdvisory()
+			testCase.Modify(advisory)
+			require.EqualError(t, advisory.IsValid(), testCase.ExpectedError)
+		})
+	}
+}
+
+func TestAdvisoryAffects(t *testing.T) {
+	advisory := &Advisory{FixedIn: "3.0.1"}
+	require.True(t, advisory.Affects("2.4.0"))
+	require.True(t, advisory.Affects("3.0.0"))
+	require.False(t, advisory.Affects("3.0.1"))
+	require.False(t, advisory.Affects("3.1.0"))
+	require.True(t, advisory.Affects("unparsable"))
+
+	unfixed := &Advisory{}
+	require.True(t, unfixed.Affects("3.1.0"))
+}
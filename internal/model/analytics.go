@@ -0,0 +1,41 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// AnalyticsCount counts the queries sharing a single value, such as a server version.
+type AnalyticsCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// AnalyticsReport summarizes the anonymized queries made of the marketplace over a range of UTC
+// days.
+type AnalyticsReport struct {
+	// Since and Until are the first and last days covered, formatted as YYYY-MM-DD.
+	Since string `json:"since"`
+	Until string `json:"until"`
+	// TotalQueries counts the plugin listings queried.
+	TotalQueries int64 `json:"total_queries"`
+	// ServerVersions counts the plugin listings queried by each major and minor server version,
+	// most queried first, with unspecified or invalid versions counted as unknown.
+	ServerVersions []AnalyticsCount `json:"server_versions"`
+	// Filters counts the most common search filters, omitting those too rare to be anonymous.
+	Filters []AnalyticsCount `json:"filters"`
+	// Plugins counts the requests for the most requested plugins.
+	Plugins []AnalyticsCount `json:"plugins"`
+}
+
+// AnalyticsReportFromReader decodes a json-encoded AnalyticsReport from the given io.Reader.
+func AnalyticsReportFromReader(reader io.Reader) (*AnalyticsReport, error) {
+	report := AnalyticsReport{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&report)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &report, nil
+}
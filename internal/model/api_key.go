@@ -0,0 +1,90 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// APIKey identifies a partner integrating with the marketplace. Requests bearing the key's token
+// are attributed to it, counted and optionally rate limited.
+type APIKey struct {
+	ID string `json:"id"`
+	// Name describes the partner to whom the key was issued.
+	Name string `json:"name"`
+	// TokenHash is the hex-encoded SHA-256 digest of the key's token. The token itself is only
+	// returned when the key is created, and is never recorded.
+	TokenHash string `json:"token_hash"`
+	// RateLimit is the maximum number of requests accepted per minute, or 0 for no limit.
+	RateLimit int `json:"rate_limit,omitempty"`
+	// CreatorID identifies the moderator who issued the key.
+	CreatorID string    `json:"creator_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// RevokedAt records when the key was revoked, after which its token is no longer accepted.
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// Requests counts the requests made with the key.
+	Requests   int64      `json:"requests"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// IsValid verifies the API key is well-formed.
+func (k *APIKey) IsValid() error {
+	if k.ID == "" {
+		return errors.New("api key id is empty")
+	}
+	if k.Name == "" {
+		return errors.Errorf("api key %s has an empty name", k.ID)
+	}
+	if k.TokenHash == "" {
+		return errors.Errorf("api key %s has an empty token hash", k.ID)
+	}
+	if k.RateLimit < 0 {
+		return errors.Errorf("api key %s has negative rate limit %d", k.ID, k.RateLimit)
+	}
+
+	return nil
+}
+
+// IssuedAPIKey is a newly created API key along with its token, which cannot be retrieved later.
+type IssuedAPIKey struct {
+	*APIKey
+	Token string `json:"token"`
+}
+
+// APIKeyFromReader decodes a json-encoded APIKey from the given io.Reader.
+func APIKeyFromReader(reader io.Reader) (*APIKey, error) {
+	key := APIKey{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&key)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// APIKeysFromReader decodes a json-encoded list of API keys from the given io.Reader.
+func APIKeysFromReader(reader io.Reader) ([]*APIKey, error) {
+	keys := []*APIKey{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&keys)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// IssuedAPIKeyFromReader decodes a json-encoded IssuedAPIKey from the given io.Reader.
+func IssuedAPIKeyFromReader(reader io.Reader) (*IssuedAPIKey, error) {
+	issuedKey := IssuedAPIKey{APIKey: &APIKey{}}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&issuedKey)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &issuedKey, nil
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AppHostingType describes how a Mattermost App is deployed.
+type AppHostingType string
+
+const (
+	// AppHostingHTTP identifies Apps served by a remote HTTP server.
+	AppHostingHTTP AppHostingType = "http"
+	// AppHostingAWSLambda identifies Apps deployed as AWS Lambda functions.
+	AppHostingAWSLambda AppHostingType = "aws_lambda"
+	// AppHostingPlugin identifies Apps implemented by a Mattermost plugin.
+	AppHostingPlugin AppHostingType = "plugin"
+)
+
+// IsValid reports whether the hosting type is one of the known values.
+func (t AppHostingType) IsValid() bool {
+	switch t {
+	case AppHostingHTTP, AppHostingAWSLambda, AppHostingPlugin:
+		return true
+	default:
+		return false
+	}
+}
+
+// AppPermission describes a privilege an App requests when installed.
+type AppPermission string
+
+const (
+	// AppPermissionActAsBot allows the App to act as its bot user.
+	AppPermissionActAsBot AppPermission = "act_as_bot"
+	// AppPermissionActAsUser allows the App to act on behalf of the invoking user.
+	AppPermissionActAsUser AppPermission = "act_as_user"
+	// AppPermissionRemoteWebhooks allows the App to receive webhooks from remote systems.
+	AppPermissionRemoteWebhooks AppPermission = "remote_webhooks"
+	// AppPermissionRemoteOAuth2 allows the App to authenticate users against remote systems.
+	AppPermissionRemoteOAuth2 AppPermission = "remote_oauth2"
+)
+
+// IsValid reports whether the permission is one of the known values.
+func (p AppPermission) IsValid() bool {
+	switch p {
+	case AppPermissionActAsBot, AppPermissionActAsUser, AppPermissionRemoteWebhooks, AppPermissionRemoteOAuth2:
+		return true
+	default:
+		return false
+	}
+}
+
+// App represents a Mattermost App in the marketplace, listed alongside plugins.
+type App struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	Description string `json:"description,omitempty"`
+	HomepageURL string `json:"homepage_url,omitempty"`
+	IconData    string `json:"icon_data,omitempty"`
+	// ManifestURL references the App manifest consumed by the Apps framework on install.
+	ManifestURL string         `json:"manifest_url"`
+	HostingType AppHostingType `json:"hosting_type"`
+	// Permissions lists the privileges the App requests when installed.
+	Permissions []AppPermission `json:"permissions,omitempty"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// IsValid verifies the App is well-formed.
+func (a *App) IsValid() error {
+	if a.ID == "" {
+		return errors.New("app id is empty")
+	}
+	if a.DisplayName == "" {
+		return errors.New("app display name is empty")
+	}
+
+	manifestURL, err := url.Parse(a.ManifestURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse manifest url")
+	}
+	if manifestURL.Scheme != "http" && manifestURL.Scheme != "https" {
+		return errors.Errorf("manifest url %s must use http or https", a.ManifestURL)
+	}
+
+	if !a.HostingType.IsValid() {
+		return errors.Errorf("invalid hosting type %s", a.HostingType)
+	}
+
+	for _, permission := range a.Permissions {
+		if !permission.IsValid() {
+			return errors.Errorf("invalid permission %s", permission)
+		}
+	}
+
+	return nil
+}
+
+// AppsFromReader decodes a json-encoded list of apps from the given io.Reader.
+func AppsFromReader(reader io.Reader) ([]*App, error) {
+	apps := []*App{}
+	decoder := json.NewDecoder(reader)
+
+	err := decoder.Decode(&apps)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return apps, nil
+}
+
+// AppFilter describes the parameters used to constrain a set of apps.
+type AppFilter struct {
+	Page        int
+	PerPage     int
+	Filter      string
+	HostingType AppHostingType
+}
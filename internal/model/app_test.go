@@ -0,0 +1,71 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppIsValid(t *testing.T) {
+	validApp := func() *App {
+		return &App{
+			ID:          "zendesk",
+			DisplayName: "Zendesk",
+			ManifestURL: "https://example.com/manifest.json",
+			HostingType: AppHostingAWSLambda,
+			Permissions: []AppPermission{AppPermissionActAsBot, AppPermissionRemoteWebhooks},
+		}
+	}
+
+	require.NoError(t, validApp().IsValid())
+
+	testCases := []struct {
+		Description   string
+		Modify        func(app *App)
+		ExpectedError string
+	}{
+		{"no id", func(app *App) { app.ID = "" }, "app id is empty"},
+		{"no display name", func(app *App) { app.DisplayName = "" }, "app display name is empty"},
+		{"no manifest url", func(app *App) { app.ManifestURL = "" }, "manifest url  must use http or https"},
+		{"relative manifest url", func(app *App) { app.ManifestURL = "manifest.json" }, "manifest url manifest.json must use http or https"},
+		{"invalid hosting type", func(app *App) { app.HostingType = "kubernetes" }, "invalid hosting type kubernetes"},
+		{"invalid permission", func(app *App) { app.Permissions = []AppPermission{"admin"} }, "invalid permission admin"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			app := validApp()
+			testCase.Modify(app)
+			require.EqualError(t, app.IsValid(), testCase.ExpectedError)
+		})
+	}
+}
+
+func TestAppsFromReader(t *testing.T) {
+	t.Run("empty request", func(t *testing.T) {
+		apps, err := AppsFromReader(bytes.NewReader([]byte(``)))
+		require.NoError(t, err)
+		require.Equal(t, []*App{}, apps)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		apps, err := AppsFromReader(bytes.NewReader([]byte(`{test`)))
+		require.Error(t, err)
+		require.Nil(t, apps)
+	})
+
+	t.Run("request", func(t *testing.T) {
+		apps, err := AppsFromReader(bytes.NewReader([]byte(
+			`[{"id":"zendesk","display_name":"Zendesk","manifest_url":"https://example.com/manifest.json","hosting_type":"http","permissions":["act_as_user"]}]`,
+		)))
+		require.NoError(t, err)
+		require.Equal(t, []*App{{
+			ID:          "zendesk",
+			DisplayName: "Zendesk",
+			ManifestURL: "https://example.com/manifest.json",
+			HostingType: AppHostingHTTP,
+			Permissions: []AppPermission{AppPermissionActAsUser},
+		}}, apps)
+	})
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// AuditCheck identifies a check of a served plugin version performed by a consistency audit.
+type AuditCheck string
+
+const (
+	// AuditCheckDownload checks that the bundle of a plugin version can still be downloaded.
+	AuditCheckDownload AuditCheck = "download"
+	// AuditCheckChecksum checks that the bundle still matches its recorded checksums.
+	AuditCheckChecksum AuditCheck = "checksum"
+	// AuditCheckSignature checks that each signature of the bundle by a trusted key still
+	// verifies.
+	AuditCheckSignature AuditCheck = "signature"
+)
+
+// AuditChecks lists every audit check.
+var AuditChecks = []AuditCheck{AuditCheckDownload, AuditCheckChecksum, AuditCheckSignature}
+
+// AuditFinding records a served plugin version failing one of the checks of a consistency audit,
+// having drifted from what the catalog records.
+type AuditFinding struct {
+	PluginID    string     `json:"plugin_id"`
+	Version     string     `json:"version"`
+	DownloadURL string     `json:"download_url"`
+	Check       AuditCheck `json:"check"`
+	Error       string     `json:"error"`
+}
+
+// AuditReport summarizes a consistency audit of a sample of the plugin versions served.
+type AuditReport struct {
+	AuditedAt time.Time `json:"audited_at"`
+	// Checked is the number of plugin versions sampled and checked.
+	Checked  int             `json:"checked"`
+	Findings []*AuditFinding `json:"findings"`
+}
@@ -0,0 +1,23 @@
+package model
+
+// AuthorType describes who develops and maintains a plugin, indicating its level of trust.
+type AuthorType string
+
+const (
+	// AuthorTypeMattermost identifies plugins maintained by Mattermost.
+	AuthorTypeMattermost AuthorType = "mattermost"
+	// AuthorTypePartner identifies plugins maintained by a Mattermost partner.
+	AuthorTypePartner AuthorType = "partner"
+	// AuthorTypeCommunity identifies plugins maintained by the community.
+	AuthorTypeCommunity AuthorType = "community"
+)
+
+// IsValid reports whether the author type is one of the known values.
+func (t AuthorType) IsValid() bool {
+	switch t {
+	case AuthorTypeMattermost, AuthorTypePartner, AuthorTypeCommunity:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorTypeIsValid(t *testing.T) {
+	require.True(t, AuthorTypeMattermost.IsValid())
+	require.True(t, AuthorTypePartner.IsValid())
+	require.True(t, AuthorTypeCommunity.IsValid())
+	require.False(t, AuthorType("").IsValid())
+	require.False(t, AuthorType("Mattermost").IsValid())
+	require.False(t, AuthorType("unknown").IsValid())
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BlocklistEntry denies access to the clients matching all of its criteria.
+type BlocklistEntry struct {
+	ID string `json:"id"`
+	// IP is the address, e.g. 192.0.2.1, or range of addresses, e.g. 198.51.100.0/24, of the
+	// blocked clients.
+	IP string `json:"ip,omitempty"`
+	// UserAgent blocks the clients whose User-Agent header contains it, ignoring case.
+	UserAgent string `json:"user_agent,omitempty"`
+	// Reason explains why the clients are blocked.
+	Reason string `json:"reason,omitempty"`
+	// CreatorID identifies the moderator who added the entry, and is empty for entries added
+	// automatically on detecting abuse.
+	CreatorID string    `json:"creator_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt, if set, records when the entry lapses.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// IsValid verifies the blocklist entry is well-formed.
+func (e *BlocklistEntry) IsValid() error {
+	if e.ID == "" {
+		return errors.New("blocklist entry id is empty")
+	}
+	if e.IP == "" && e.UserAgent == "" {
+		return errors.Errorf("blocklist entry %s has neither an ip nor a user agent", e.ID)
+	}
+	if e.IP != "" {
+		if _, err := e.Network(); err != nil {
+			return errors.Wrapf(err, "blocklist entry %s has an invalid ip", e.ID)
+		}
+	}
+
+	return nil
+}
+
+// Network returns the range of addresses blocked by the entry, or nil if it blocks any address.
+// A bare address is returned as a range containing only that address.
+func (e *BlocklistEntry) Network() (*net.IPNet, error) {
+	if e.IP == "" {
+		return nil, nil
+	}
+
+	if !strings.Contains(e.IP, "/") {
+		ip := net.ParseIP(e.IP)
+		if ip == nil {
+			return nil, errors.Errorf("invalid address %s", e.IP)
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(e.IP)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid range %s", e.IP)
+	}
+
+	return network, nil
+}
+
+// IsExpired reports whether the entry lapsed before the given time.
+func (e *BlocklistEntry) IsExpired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+// Matches reports whether the entry blocks the client with the given address and User-Agent
+// header at the given time.
+func (e *BlocklistEntry) Matches(ip net.IP, userAgent string, now time.Time) bool {
+	if e.IsExpired(now) {
+		return false
+	}
+
+	if e.IP != "" {
+		network, err := e.Network()
+		if err != nil || ip == nil || !network.Contains(ip) {
+			return false
+		}
+	}
+
+	if e.UserAgent != "" && !strings.Contains(strings.ToLower(userAgent), strings.ToLower(e.UserAgent)) {
+		return false
+	}
+
+	return true
+}
+
+// BlocklistEntryFromReader decodes a json-encoded BlocklistEntry from the given io.Reader.
+func BlocklistEntryFromReader(reader io.Reader) (*BlocklistEntry, error) {
+	entry := BlocklistEntry{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&entry)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// BlocklistEntriesFromReader decodes a json-encoded list of blocklist entries from the given
+// io.Reader.
+func BlocklistEntriesFromReader(reader io.Reader) ([]*BlocklistEntry, error) {
+	entries := []*BlocklistEntry{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&entries)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocklistEntryIsValid(t *testing.T) {
+	require.Error(t, (&BlocklistEntry{IP: "192.0.2.1"}).IsValid())
+	require.Error(t, (&BlocklistEntry{ID: "entry"}).IsValid())
+	require.Error(t, (&BlocklistEntry{ID: "entry", IP: "not-an-ip"}).IsValid())
+	require.Error(t, (&BlocklistEntry{ID: "entry", IP: "192.0.2.0/33"}).IsValid())
+	require.NoError(t, (&BlocklistEntry{ID: "entry", IP: "192.0.2.1"}).IsValid())
+	require.NoError(t, (&BlocklistEntry{ID: "entry", IP: "2001:db8::/32"}).IsValid())
+	require.NoError(t, (&BlocklistEntry{ID: "entry", UserAgent: "scraper"}).IsValid())
+}
+
+func TestBlocklistEntryMatches(t *testing.T) {
+	now := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Minute)
+
+	testCases := []struct {
+		Description string
+		Entry       BlocklistEntry
+		IP          string
+		UserAgent   string
+		Expected    bool
+	}{
+		{"matching address", BlocklistEntry{IP: "192.0.2.1"}, "192.0.2.1", "", true},
+		{"other address", BlocklistEntry{IP: "192.0.2.1"}, "192.0.2.2", "", false},
+		{"matching range", BlocklistEntry{IP: "198.51.100.0/24"}, "198.51.100.7", "", true},
+		{"unknown address", BlocklistEntry{IP: "198.51.100.0/24"}, "", "", false},
+		{"matching user agent ignoring case", BlocklistEntry{UserAgent: "Scraper"}, "192.0.2.1", "evil-scraper/1.0", true},
+		{"other user agent", BlocklistEntry{UserAgent: "scraper"}, "192.0.2.1", "Mattermost/5.18", false},
+		{"both criteria", BlocklistEntry{IP: "192.0.2.1", UserAgent: "scraper"}, "192.0.2.1", "scraper", true},
+		{"only one criterion", BlocklistEntry{IP: "192.0.2.1", UserAgent: "scraper"}, "192.0.2.1", "Mattermost/5.18", false},
+		{"unexpired", BlocklistEntry{IP: "192.0.2.1", ExpiresAt: &expiresAt}, "192.0.2.1", "", true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			require.Equal(t, testCase.Expected, testCase.Entry.Matches(net.ParseIP(testCase.IP), testCase.UserAgent, now))
+		})
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		entry := BlocklistEntry{IP: "192.0.2.1", ExpiresAt: &expiresAt}
+		require.False(t, entry.Matches(net.ParseIP("192.0.2.1"), "", expiresAt))
+	})
+}
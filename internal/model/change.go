@@ -0,0 +1,105 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ChangeType describes how a plugin version changed between successive versions of a catalog.
+type ChangeType string
+
+const (
+	// ChangeTypeAdded identifies a plugin version newly published to the catalog.
+	ChangeTypeAdded ChangeType = "added"
+	// ChangeTypeUpdated identifies a plugin version whose details changed, e.g. a replaced bundle.
+	ChangeTypeUpdated ChangeType = "updated"
+	// ChangeTypeRemoved identifies a plugin version withdrawn from the catalog.
+	ChangeTypeRemoved ChangeType = "removed"
+)
+
+// CatalogChange records a change to a single plugin version in the catalog.
+type CatalogChange struct {
+	// Cursor identifies the change, increasing with each change to the catalog.
+	Cursor   int64      `json:"cursor"`
+	Type     ChangeType `json:"type"`
+	PluginID string     `json:"plugin_id"`
+	Version  string     `json:"version"`
+	// Plugin is the added or updated plugin version, and is nil if the version was removed.
+	Plugin    *Plugin   `json:"plugin,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// CatalogChanges is a page of the changes to a catalog.
+type CatalogChanges struct {
+	// Cursor identifies the latest change seen, from which to request the next page.
+	Cursor  int64            `json:"cursor"`
+	Changes []*CatalogChange `json:"changes"`
+}
+
+// CatalogChangesFromReader decodes a json-encoded CatalogChanges from the given io.Reader.
+func CatalogChangesFromReader(reader io.Reader) (*CatalogChanges, error) {
+	changes := CatalogChanges{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&changes)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &changes, nil
+}
+
+// SnapshotSource describes how a catalog snapshot came to be served.
+type SnapshotSource string
+
+const (
+	// SnapshotSourceInitial identifies the store a catalog was created with.
+	SnapshotSourceInitial SnapshotSource = "initial"
+	// SnapshotSourceReplaced identifies a store that replaced the previous one, e.g. on reload.
+	SnapshotSourceReplaced SnapshotSource = "replaced"
+	// SnapshotSourceRollback identifies a store restored from an earlier snapshot.
+	SnapshotSourceRollback SnapshotSource = "rollback"
+	// SnapshotSourcePromoted identifies a staged store promoted after passing validation.
+	SnapshotSourcePromoted SnapshotSource = "promoted"
+	// SnapshotSourceImported identifies a store imported through the admin API.
+	SnapshotSourceImported SnapshotSource = "imported"
+)
+
+// CatalogSnapshot describes a store served by a catalog, retained so that it may be restored.
+type CatalogSnapshot struct {
+	// ID identifies the snapshot, increasing with each snapshot of the catalog.
+	ID     int64          `json:"id"`
+	Source SnapshotSource `json:"source"`
+	// RestoredFrom identifies the snapshot restored by a rollback.
+	RestoredFrom int64 `json:"restored_from,omitempty"`
+	// Plugins counts the plugin versions in the snapshot.
+	Plugins int `json:"plugins"`
+	// Current reports whether the catalog is serving the snapshot.
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CatalogSnapshotFromReader decodes a json-encoded CatalogSnapshot from the given io.Reader.
+func CatalogSnapshotFromReader(reader io.Reader) (*CatalogSnapshot, error) {
+	snapshot := CatalogSnapshot{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&snapshot)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// CatalogSnapshotsFromReader decodes a json-encoded list of catalog snapshots from the given
+// io.Reader.
+func CatalogSnapshotsFromReader(reader io.Reader) ([]*CatalogSnapshot, error) {
+	snapshots := []*CatalogSnapshot{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&snapshots)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// ChangelogRelease describes the release notes of a single plugin version in a changelog.
+type ChangelogRelease struct {
+	Version         string    `json:"version"`
+	ReleasedAt      time.Time `json:"released_at"`
+	ReleaseNotesURL string    `json:"release_notes_url,omitempty"`
+	ReleaseNotes    string    `json:"release_notes,omitempty"`
+}
+
+// Changelog aggregates the release notes of the versions of a plugin released after FromVersion,
+// up to and including ToVersion.
+type Changelog struct {
+	PluginID string `json:"plugin_id"`
+	// FromVersion is the version whose successors are described, or empty if every version up to
+	// ToVersion is.
+	FromVersion string `json:"from_version,omitempty"`
+	ToVersion   string `json:"to_version"`
+	// Releases describe each version in the range, sorted by version descending.
+	Releases []*ChangelogRelease `json:"releases"`
+	// ReleaseNotes concatenates the release notes of the Releases as Markdown, each under a
+	// heading naming its version.
+	ReleaseNotes string `json:"release_notes"`
+}
+
+// NewChangelog aggregates the release notes of the given versions of a plugin, sorted by version
+// descending, released after from and up to and including to. An empty from includes every
+// version up to to, and an empty to defaults to the latest version.
+func NewChangelog(versions []*Plugin, from, to string) (*Changelog, error) {
+	if len(versions) == 0 {
+		return nil, errors.New("plugin has no versions")
+	}
+
+	latest := versions[0]
+	changelog := &Changelog{
+		PluginID:    latest.Manifest.Id,
+		FromVersion: from,
+		ToVersion:   to,
+		Releases:    []*ChangelogRelease{},
+	}
+	if changelog.ToVersion == "" {
+		changelog.ToVersion = latest.Manifest.Version
+	}
+
+	toVersion, err := semver.ParseTolerant(changelog.ToVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid version %s", changelog.ToVersion)
+	}
+	var fromVersion *semver.Version
+	if from != "" {
+		parsed, err := semver.ParseTolerant(from)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version %s", from)
+		}
+		if parsed.GT(toVersion) {
+			return nil, errors.Errorf("version %s follows version %s", from, changelog.ToVersion)
+		}
+		fromVersion = &parsed
+	}
+
+	var notes strings.Builder
+	for _, plugin := range versions {
+		version, err := semver.ParseTolerant(plugin.Manifest.Version)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version %s", plugin.Manifest.Version)
+		}
+		if version.GT(toVersion) || (fromVersion != nil && version.LTE(*fromVersion)) {
+			continue
+		}
+
+		changelog.Releases = append(changelog.Releases, &ChangelogRelease{
+			Version:         plugin.Manifest.Version,
+			ReleasedAt:      plugin.ReleasedAt,
+			ReleaseNotesURL: plugin.ReleaseNotesURL,
+			ReleaseNotes:    plugin.ReleaseNotes,
+		})
+
+		if notes.Len() > 0 {
+			notes.WriteString("\n\n")
+		}
+		fmt.Fprintf(&notes, "## %s\n\n", plugin.Manifest.Version)
+		if releaseNotes := strings.TrimSpace(plugin.ReleaseNotes); releaseNotes != "" {
+			notes.WriteString(releaseNotes)
+		} else if plugin.ReleaseNotesURL != "" {
+			fmt.Fprintf(&notes, "See the [release notes](%s).", plugin.ReleaseNotesURL)
+		} else {
+			notes.WriteString("No release notes were published.")
+		}
+	}
+	changelog.ReleaseNotes = notes.String()
+
+	return changelog, nil
+}
+
+// ChangelogFromReader decodes a json-encoded Changelog from the given io.Reader.
+func ChangelogFromReader(reader io.Reader) (*Changelog, error) {
+	changelog := Changelog{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&changelog)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &changelog, nil
+}
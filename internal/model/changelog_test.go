@@ -0,0 +1,68 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChangelog(t *testing.T) {
+	releasedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	versions := []*Plugin{
+		{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "3.0.0"}, ReleaseNotes: "Drops support for 7.x.\n", ReleasedAt: releasedAt.AddDate(0, 2, 0)},
+		{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "2.1.0"}, ReleaseNotesURL: "https://example.com/demo/v2.1.0", ReleasedAt: releasedAt.AddDate(0, 1, 0)},
+		{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "2.0.0"}, ReleasedAt: releasedAt},
+		{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "1.0.0"}, ReleaseNotes: "First release."},
+	}
+
+	changelog, err := NewChangelog(versions, "1.0.0", "")
+	require.NoError(t, err)
+	require.Equal(t, &Changelog{
+		PluginID:    "demo",
+		FromVersion: "1.0.0",
+		ToVersion:   "3.0.0",
+		Releases: []*ChangelogRelease{
+			{Version: "3.0.0", ReleasedAt: releasedAt.AddDate(0, 2, 0), ReleaseNotes: "Drops support for 7.x.\n"},
+			{Version: "2.1.0", ReleasedAt: releasedAt.AddDate(0, 1, 0), ReleaseNotesURL: "https://example.com/demo/v2.1.0"},
+			{Version: "2.0.0", ReleasedAt: releasedAt},
+		},
+		ReleaseNotes: "## 3.0.0\n\nDrops support for 7.x.\n\n" +
+			"## 2.1.0\n\nSee the [release notes](https://example.com/demo/v2.1.0).\n\n" +
+			"## 2.0.0\n\nNo release notes were published.",
+	}, changelog)
+
+	t.Run("range", func(t *testing.T) {
+		changelog, err := NewChangelog(versions, "2.0.0", "2.1.0")
+		require.NoError(t, err)
+		require.Len(t, changelog.Releases, 1)
+		require.Equal(t, "2.1.0", changelog.Releases[0].Version)
+	})
+
+	t.Run("unknown running version", func(t *testing.T) {
+		changelog, err := NewChangelog(versions, "2.0.5", "")
+		require.NoError(t, err)
+		require.Len(t, changelog.Releases, 2)
+	})
+
+	t.Run("empty range", func(t *testing.T) {
+		changelog, err := NewChangelog(versions, "3.0.0", "")
+		require.NoError(t, err)
+		require.Empty(t, changelog.Releases)
+		require.Empty(t, changelog.ReleaseNotes)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := NewChangelog(versions, "3.0.0", "2.0.0")
+		require.Error(t, err)
+
+		_, err = NewChangelog(versions, "latest", "")
+		require.Error(t, err)
+	})
+
+	t.Run("no versions", func(t *testing.T) {
+		_, err := NewChangelog(nil, "", "")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+
+	"github.com/pkg/errors"
+)
+
+// Checksums records hex-encoded digests of a plugin bundle, allowing its download to be verified.
+type Checksums struct {
+	SHA256 string `json:"sha256,omitempty"`
+	SHA512 string `json:"sha512,omitempty"`
+}
+
+// IsValid verifies each recorded checksum is well-formed hex of the expected length.
+func (c *Checksums) IsValid() error {
+	if c.SHA256 == "" && c.SHA512 == "" {
+		return errors.New("no checksums recorded")
+	}
+	if err := validateChecksum("sha256", c.SHA256, sha256.Size); err != nil {
+		return err
+	}
+	if err := validateChecksum("sha512", c.SHA512, sha512.Size); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func validateChecksum(name, checksum string, size int) error {
+	if checksum == "" {
+		return nil
+	}
+
+	decoded, err := hex.DecodeString(checksum)
+	if err != nil {
+		return errors.Wrapf(err, "%s checksum is not hex-encoded", name)
+	}
+	if len(decoded) != size {
+		return errors.Errorf("%s checksum has length %d, expected %d", name, len(decoded), size)
+	}
+
+	return nil
+}
+
+// Verify compares the checksums recorded in c against the given computed checksums, ignoring
+// any algorithm that c does not record.
+func (c *Checksums) Verify(actual *Checksums) error {
+	if c.SHA256 != "" && c.SHA256 != actual.SHA256 {
+		return errors.Errorf("checksum mismatch: expected sha256 %s, got %s", c.SHA256, actual.SHA256)
+	}
+	if c.SHA512 != "" && c.SHA512 != actual.SHA512 {
+		return errors.Errorf("checksum mismatch: expected sha512 %s, got %s", c.SHA512, actual.SHA512)
+	}
+
+	return nil
+}
+
+// ChecksumsWriter computes the checksums of everything written to it.
+type ChecksumsWriter struct {
+	sha256 hash.Hash
+	sha512 hash.Hash
+}
+
+// NewChecksumsWriter creates a new ChecksumsWriter.
+func NewChecksumsWriter() *ChecksumsWriter {
+	return &ChecksumsWriter{
+		sha256: sha256.New(),
+		sha512: sha512.New(),
+	}
+}
+
+// Write adds the given bytes to the running checksums.
+func (w *ChecksumsWriter) Write(p []byte) (int, error) {
+	_, _ = w.sha256.Write(p)
+	_, _ = w.sha512.Write(p)
+
+	return len(p), nil
+}
+
+// Checksums returns the checksums of the bytes written so far.
+func (w *ChecksumsWriter) Checksums() *Checksums {
+	return &Checksums{
+		SHA256: hex.EncodeToString(w.sha256.Sum(nil)),
+		SHA512: hex.EncodeToString(w.sha512.Sum(nil)),
+	}
+}
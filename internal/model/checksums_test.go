@@ -0,0 +1,38 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksums(t *testing.T) {
+	writer := NewChecksumsWriter()
+	_, err := writer.Write([]byte("plugin bundle contents"))
+	require.NoError(t, err)
+	checksums := writer.Checksums()
+
+	t.Run("computed checksums are valid", func(t *testing.T) {
+		require.NoError(t, checksums.IsValid())
+		require.Len(t, checksums.SHA256, 64)
+		require.Len(t, checksums.SHA512, 128)
+	})
+
+	t.Run("is valid", func(t *testing.T) {
+		require.NoError(t, (&Checksums{SHA256: checksums.SHA256}).IsValid())
+		require.NoError(t, (&Checksums{SHA512: checksums.SHA512}).IsValid())
+		require.EqualError(t, (&Checksums{}).IsValid(), "no checksums recorded")
+		require.EqualError(t, (&Checksums{SHA256: "abcd"}).IsValid(), "sha256 checksum has length 2, expected 32")
+		require.EqualError(t, (&Checksums{SHA256: strings.Repeat("z", 64)}).IsValid(), "sha256 checksum is not hex-encoded: encoding/hex: invalid byte: U+007A 'z'")
+		require.EqualError(t, (&Checksums{SHA256: checksums.SHA256, SHA512: checksums.SHA256}).IsValid(), "sha512 checksum has length 32, expected 64")
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		require.NoError(t, (&Checksums{SHA256: checksums.SHA256}).Verify(checksums))
+		require.NoError(t, (&Checksums{SHA512: checksums.SHA512}).Verify(checksums))
+		require.NoError(t, checksums.Verify(checksums))
+		require.EqualError(t, (&Checksums{SHA256: "abcd"}).Verify(checksums), "checksum mismatch: expected sha256 abcd, got "+checksums.SHA256)
+		require.EqualError(t, (&Checksums{SHA512: "abcd"}).Verify(checksums), "checksum mismatch: expected sha512 abcd, got "+checksums.SHA512)
+	})
+}
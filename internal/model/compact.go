@@ -0,0 +1,119 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CompactDatabaseFormat identifies a database in the compact format.
+const CompactDatabaseFormat = "compact"
+
+// iconReferencePrefix prefixes the icon data of plugins in a compact database, referencing an icon
+// of the database by its digest.
+const iconReferencePrefix = "sha256:"
+
+// CompactDatabase is the compact format of the plugins database, storing each distinct icon once
+// rather than inlining it in every version of a plugin. The icon data of each plugin instead
+// references an icon by the hex-encoded sha256 digest of its data URI, e.g. sha256:ab12…
+type CompactDatabase struct {
+	Format string `json:"format"`
+	// Icons maps the digest of each distinct icon to its data URI.
+	Icons   map[string]string `json:"icons"`
+	Plugins []*Plugin         `json:"plugins"`
+}
+
+// compactPlugins returns copies of the given plugins referencing their icons by digest, along with
+// the icons referenced.
+func compactPlugins(plugins []*Plugin) ([]*Plugin, map[string]string) {
+	icons := map[string]string{}
+	compacted := make([]*Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		if plugin.IconData == "" {
+			compacted = append(compacted, plugin)
+			continue
+		}
+
+		sum := sha256.Sum256([]byte(plugin.IconData))
+		digest := hex.EncodeToString(sum[:])
+		icons[digest] = plugin.IconData
+
+		compact := *plugin
+		compact.IconData = iconReferencePrefix + digest
+		compacted = append(compacted, &compact)
+	}
+
+	return compacted, icons
+}
+
+// expandCompactDatabase decodes the given compact database, inlining the icon referenced by each
+// plugin.
+func expandCompactDatabase(data []byte) ([]*Plugin, error) {
+	var database CompactDatabase
+	if err := json.Unmarshal(data, &database); err != nil {
+		return nil, err
+	}
+
+	plugins := []*Plugin{}
+	for _, plugin := range database.Plugins {
+		if plugin != nil && strings.HasPrefix(plugin.IconData, iconReferencePrefix) {
+			digest := strings.TrimPrefix(plugin.IconData, iconReferencePrefix)
+			iconData, ok := database.Icons[digest]
+			if !ok {
+				return nil, errors.Errorf("plugin references missing icon %s", digest)
+			}
+			plugin.IconData = iconData
+		}
+		plugins = append(plugins, plugin)
+	}
+
+	return plugins, nil
+}
+
+// isCompactDatabase reports whether the given encoded database is in the compact format.
+func isCompactDatabase(data []byte) bool {
+	return databaseFormat(data) == CompactDatabaseFormat
+}
+
+// databaseFormat returns the format declared by the given encoded database, or the empty string
+// if it is not an object declaring its format, as is the list of plugins of the canonical format.
+func databaseFormat(data []byte) string {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return ""
+	}
+
+	var header struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return ""
+	}
+
+	return header.Format
+}
+
+// compactPluginsToWriter encodes the given plugins, already ordered, as a compact database.
+func compactPluginsToWriter(w io.Writer, plugins []*Plugin, opts PluginsWriterOptions) error {
+	compacted, icons := compactPlugins(plugins)
+
+	encoder := json.NewEncoder(w)
+	if opts.Indent {
+		encoder.SetIndent("", "  ")
+	}
+	// Maps encode with sorted keys, keeping the output reproducible.
+	err := encoder.Encode(&CompactDatabase{
+		Format:  CompactDatabaseFormat,
+		Icons:   icons,
+		Plugins: compacted,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode compact database")
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactDatabase(t *testing.T) {
+	makePlugin := func(id, version, iconData string) *Plugin {
+		return &Plugin{
+			IconData:     iconData,
+			DownloadURL:  "https://example.com/" + id + "-" + version + ".tar.gz",
+			ReleaseStage: ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: id, Version: version},
+		}
+	}
+	icon := "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4="
+	demo1 := makePlugin("demo", "0.1.0", icon)
+	demo2 := makePlugin("demo", "0.2.0", icon)
+	starter := makePlugin("starter", "1.0.0", "")
+
+	var buffer bytes.Buffer
+	require.NoError(t, PluginsToWriter(&buffer, []*Plugin{starter, demo1, demo2}, PluginsWriterOptions{Compact: true}))
+
+	t.Run("stores each icon once", func(t *testing.T) {
+		require.Equal(t, 1, strings.Count(buffer.String(), icon))
+
+		var database CompactDatabase
+		require.NoError(t, json.Unmarshal(buffer.Bytes(), &database))
+		require.Equal(t, CompactDatabaseFormat, database.Format)
+		require.Len(t, database.Icons, 1)
+		require.Len(t, database.Plugins, 3)
+		require.Regexp(t, "^sha256:[0-9a-f]{64}$", database.Plugins[0].IconData)
+		require.Equal(t, database.Plugins[0].IconData, database.Plugins[1].IconData)
+		require.Empty(t, database.Plugins[2].IconData)
+	})
+
+	t.Run("leaves the given plugins unchanged", func(t *testing.T) {
+		require.Equal(t, icon, demo1.IconData)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		plugins, err := PluginsFromReader(bytes.NewReader(buffer.Bytes()))
+		require.NoError(t, err)
+		require.Equal(t, []*Plugin{demo2, demo1, starter}, plugins)
+	})
+
+	t.Run("validates against the schema", func(t *testing.T) {
+		require.NoError(t, ValidateAgainstSchema(bytes.NewReader(buffer.Bytes())))
+	})
+
+	t.Run("missing icon", func(t *testing.T) {
+		_, err := PluginsFromReader(strings.NewReader(`{"format":"compact","icons":{},"plugins":[{"icon_data":"sha256:abc"}]}`))
+		require.EqualError(t, err, "plugin references missing icon abc")
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := PluginsFromReader(strings.NewReader(`{"format":"other","plugins":[]}`))
+		require.Error(t, err)
+	})
+}
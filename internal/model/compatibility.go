@@ -0,0 +1,85 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// ServedVersion describes the version of a plugin served to a single server version.
+type ServedVersion struct {
+	ServerVersion string `json:"server_version"`
+	// PluginVersion is the latest version compatible with the server version, or empty if none
+	// is.
+	PluginVersion string `json:"plugin_version,omitempty"`
+	// Compatibility explains why the latest version of the plugin is not served, if it is not.
+	Compatibility *Compatibility `json:"compatibility,omitempty"`
+}
+
+// CompatibilityMatrix describes the version of a plugin served to each of several server
+// versions.
+type CompatibilityMatrix struct {
+	PluginID      string           `json:"plugin_id"`
+	LatestVersion string           `json:"latest_version"`
+	Served        []*ServedVersion `json:"served"`
+}
+
+// NewCompatibilityMatrix determines the version served to each of the given server versions from
+// the given versions of a plugin, sorted by version descending.
+func NewCompatibilityMatrix(versions []*Plugin, serverVersions []string) (*CompatibilityMatrix, error) {
+	if len(versions) == 0 {
+		return nil, errors.New("plugin has no versions")
+	}
+
+	latest := versions[0]
+	matrix := &CompatibilityMatrix{
+		PluginID:      latest.Manifest.Id,
+		LatestVersion: latest.Manifest.Version,
+		Served:        []*ServedVersion{},
+	}
+
+	for _, serverVersion := range serverVersions {
+		if _, err := semver.Parse(serverVersion); err != nil {
+			return nil, errors.Wrapf(err, "invalid server version %s", serverVersion)
+		}
+
+		served := &ServedVersion{ServerVersion: serverVersion}
+		for _, version := range versions {
+			compatible, err := version.MeetsServerVersion(serverVersion)
+			if err != nil {
+				return nil, err
+			}
+			if compatible {
+				served.PluginVersion = version.Manifest.Version
+				break
+			}
+		}
+
+		if served.PluginVersion != latest.Manifest.Version {
+			compatibility, err := latest.ServerVersionIncompatibility(serverVersion)
+			if err != nil {
+				return nil, err
+			}
+			served.Compatibility = compatibility
+		}
+
+		matrix.Served = append(matrix.Served, served)
+	}
+
+	return matrix, nil
+}
+
+// CompatibilityMatrixFromReader decodes a json-encoded CompatibilityMatrix from the given
+// io.Reader.
+func CompatibilityMatrixFromReader(reader io.Reader) (*CompatibilityMatrix, error) {
+	matrix := CompatibilityMatrix{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&matrix)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &matrix, nil
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCompatibilityMatrix(t *testing.T) {
+	versions := []*Plugin{
+		{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "3.0.0", MinServerVersion: "9.5.0"}},
+		{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "2.0.0", MinServerVersion: "7.8.0"}, ServerVersionRange: "<9.11"},
+		{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "1.0.0", MinServerVersion: "7.8.0"}},
+	}
+
+	matrix, err := NewCompatibilityMatrix(versions, []string{"7.1.0", "8.1.0", "9.5.0", "9.11.0"})
+	require.NoError(t, err)
+	require.Equal(t, &CompatibilityMatrix{
+		PluginID:      "demo",
+		LatestVersion: "3.0.0",
+		Served: []*ServedVersion{
+			{ServerVersion: "7.1.0", Compatibility: &Compatibility{Reason: CompatibilityReasonMinServerVersion, RequiredServerVersion: "9.5.0"}},
+			{ServerVersion: "8.1.0", PluginVersion: "2.0.0", Compatibility: &Compatibility{Reason: CompatibilityReasonMinServerVersion, RequiredServerVersion: "9.5.0"}},
+			{ServerVersion: "9.5.0", PluginVersion: "3.0.0"},
+			{ServerVersion: "9.11.0", PluginVersion: "3.0.0"},
+		},
+	}, matrix)
+
+	t.Run("invalid server version", func(t *testing.T) {
+		_, err := NewCompatibilityMatrix(versions, []string{"9.5"})
+		require.Error(t, err)
+	})
+
+	t.Run("no versions", func(t *testing.T) {
+		_, err := NewCompatibilityMatrix(nil, []string{"9.5.0"})
+		require.Error(t, err)
+	})
+}
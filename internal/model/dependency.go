@@ -0,0 +1,71 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+)
+
+// ManifestDependenciesProp names the manifest prop by which a plugin declares its dependencies,
+// mapping the id of each plugin it requires to the minimum version required, or to an empty
+// string if any version will do.
+const ManifestDependenciesProp = "dependencies"
+
+// Dependency names a plugin that must be installed for another plugin to work.
+type Dependency struct {
+	PluginID string `json:"plugin_id"`
+	// MinVersion is the minimum version of the plugin required, if any.
+	MinVersion string `json:"min_version,omitempty"`
+}
+
+// IsValid verifies the dependency is well-formed.
+func (d *Dependency) IsValid() error {
+	if d.PluginID == "" {
+		return errors.New("dependency plugin id is empty")
+	}
+	if d.MinVersion != "" {
+		if _, err := ParseVersion(d.MinVersion); err != nil {
+			return errors.Wrapf(err, "dependency %s has invalid min version %s", d.PluginID, d.MinVersion)
+		}
+	}
+
+	return nil
+}
+
+// DependenciesFromManifest returns the dependencies declared by the given manifest, sorted by
+// plugin id, or nil if it declares none.
+func DependenciesFromManifest(manifest *mattermostModel.Manifest) ([]*Dependency, error) {
+	if manifest == nil || manifest.Props == nil {
+		return nil, nil
+	}
+	prop, ok := manifest.Props[ManifestDependenciesProp]
+	if !ok {
+		return nil, nil
+	}
+
+	declared, ok := prop.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("manifest prop %s is not an object", ManifestDependenciesProp)
+	}
+
+	dependencies := []*Dependency{}
+	for pluginID, value := range declared {
+		minVersion, ok := value.(string)
+		if !ok {
+			return nil, errors.Errorf("min version of dependency %s is not a string", pluginID)
+		}
+
+		dependency := &Dependency{PluginID: pluginID, MinVersion: minVersion}
+		if err := dependency.IsValid(); err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].PluginID < dependencies[j].PluginID
+	})
+
+	return dependencies, nil
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyIsValid(t *testing.T) {
+	require.NoError(t, (&Dependency{PluginID: "jira"}).IsValid())
+	require.NoError(t, (&Dependency{PluginID: "jira", MinVersion: "3.0.0"}).IsValid())
+	require.Error(t, (&Dependency{MinVersion: "3.0.0"}).IsValid())
+	require.Error(t, (&Dependency{PluginID: "jira", MinVersion: "3"}).IsValid())
+}
+
+func TestDependenciesFromManifest(t *testing.T) {
+	t.Run("declared", func(t *testing.T) {
+		manifest := mattermostModel.ManifestFromJson(strings.NewReader(`{
+			"id": "demo",
+			"version": "1.0.0",
+			"props": {"dependencies": {"jira": "3.0.0", "github": ""}}
+		}`))
+		dependencies, err := DependenciesFromManifest(manifest)
+		require.NoError(t, err)
+		require.Equal(t, []*Dependency{
+			{PluginID: "github"},
+			{PluginID: "jira", MinVersion: "3.0.0"},
+		}, dependencies)
+	})
+
+	t.Run("none declared", func(t *testing.T) {
+		dependencies, err := DependenciesFromManifest(&mattermostModel.Manifest{Id: "demo"})
+		require.NoError(t, err)
+		require.Nil(t, dependencies)
+
+		dependencies, err = DependenciesFromManifest(&mattermostModel.Manifest{Id: "demo", Props: map[string]interface{}{"other": true}})
+		require.NoError(t, err)
+		require.Nil(t, dependencies)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		testCases := map[string]interface{}{
+			"not an object":        []interface{}{"jira"},
+			"version not a string": map[string]interface{}{"jira": 3},
+			"invalid min version":  map[string]interface{}{"jira": "latest"},
+			"empty plugin id":      map[string]interface{}{"": "1.0.0"},
+		}
+		for description, prop := range testCases {
+			t.Run(description, func(t *testing.T) {
+				_, err := DependenciesFromManifest(&mattermostModel.Manifest{Id: "demo", Props: map[string]interface{}{"dependencies": prop}})
+				require.Error(t, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"reflect"
+	"sort"
+)
+
+// PluginDiff describes the plugin versions that differ between two databases, each sorted by
+// plugin id and version.
+type PluginDiff struct {
+	// Added lists the plugin versions only in the new database.
+	Added []*Plugin `json:"added"`
+	// Changed lists the plugin versions in both databases whose details differ.
+	Changed []*PluginVersionChange `json:"changed"`
+	// Removed lists the plugin versions only in the old database.
+	Removed []*Plugin `json:"removed"`
+}
+
+// PluginVersionChange records the old and new details of a changed plugin version.
+type PluginVersionChange struct {
+	Old *Plugin `json:"old"`
+	New *Plugin `json:"new"`
+}
+
+// IsEmpty determines if the databases held the same plugin versions.
+func (d *PluginDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// CatalogChanges returns the diff as catalog changes, ordered by plugin id and version, leaving
+// their cursor and time unset.
+func (d *PluginDiff) CatalogChanges() []*CatalogChange {
+	var changes []*CatalogChange
+	for _, plugin := range d.Added {
+		changes = append(changes, &CatalogChange{Type: ChangeTypeAdded, PluginID: plugin.Manifest.Id, Version: plugin.Manifest.Version, Plugin: plugin})
+	}
+	for _, change := range d.Changed {
+		changes = append(changes, &CatalogChange{Type: ChangeTypeUpdated, PluginID: change.New.Manifest.Id, Version: change.New.Manifest.Version, Plugin: change.New})
+	}
+	for _, plugin := range d.Removed {
+		changes = append(changes, &CatalogChange{Type: ChangeTypeRemoved, PluginID: plugin.Manifest.Id, Version: plugin.Manifest.Version})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].PluginID != changes[j].PluginID {
+			return changes[i].PluginID < changes[j].PluginID
+		}
+		return changes[i].Version < changes[j].Version
+	})
+
+	return changes
+}
+
+type pluginVersionKey struct {
+	id      string
+	version string
+}
+
+func pluginVersionKeyOf(plugin *Plugin) pluginVersionKey {
+	return pluginVersionKey{plugin.Manifest.Id, plugin.Manifest.Version}
+}
+
+// DiffPlugins compares the plugin versions of two databases, identifying each version by its
+// plugin id and version. Plugins without a manifest are ignored.
+func DiffPlugins(oldPlugins, newPlugins []*Plugin) *PluginDiff {
+	oldVersions := map[pluginVersionKey]*Plugin{}
+	for _, plugin := range oldPlugins {
+		if plugin.Manifest != nil {
+			oldVersions[pluginVersionKeyOf(plugin)] = plugin
+		}
+	}
+	newVersions := map[pluginVersionKey]*Plugin{}
+	for _, plugin := range newPlugins {
+		if plugin.Manifest != nil {
+			newVersions[pluginVersionKeyOf(plugin)] = plugin
+		}
+	}
+
+	diff := &PluginDiff{}
+	for k, plugin := range newVersions {
+		oldPlugin, ok := oldVersions[k]
+		if !ok {
+			diff.Added = append(diff.Added, plugin)
+		} else if !reflect.DeepEqual(oldPlugin, plugin) {
+			diff.Changed = append(diff.Changed, &PluginVersionChange{Old: oldPlugin, New: plugin})
+		}
+	}
+	for k, plugin := range oldVersions {
+		if _, ok := newVersions[k]; !ok {
+			diff.Removed = append(diff.Removed, plugin)
+		}
+	}
+
+	sortPluginVersions(diff.Added)
+	sortPluginVersions(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return lessPluginVersion(diff.Changed[i].New, diff.Changed[j].New)
+	})
+
+	return diff
+}
+
+func sortPluginVersions(plugins []*Plugin) {
+	sort.Slice(plugins, func(i, j int) bool {
+		return lessPluginVersion(plugins[i], plugins[j])
+	})
+}
+
+func lessPluginVersion(a, b *Plugin) bool {
+	if a.Manifest.Id != b.Manifest.Id {
+		return a.Manifest.Id < b.Manifest.Id
+	}
+	return a.Manifest.Version < b.Manifest.Version
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPlugins(t *testing.T) {
+	makePlugin := func(id, version, downloadURL string) *Plugin {
+		return &Plugin{
+			DownloadURL: downloadURL,
+			Manifest:    &mattermostModel.Manifest{Id: id, Version: version},
+		}
+	}
+
+	t.Run("identical", func(t *testing.T) {
+		plugins := []*Plugin{makePlugin("demo", "0.1.0", "a")}
+
+		diff := DiffPlugins(plugins, []*Plugin{makePlugin("demo", "0.1.0", "a")})
+		require.True(t, diff.IsEmpty())
+		require.Empty(t, diff.CatalogChanges())
+	})
+
+	t.Run("added, changed and removed", func(t *testing.T) {
+		oldDemo := makePlugin("demo", "0.1.0", "a")
+		newDemo := makePlugin("demo", "0.1.0", "b")
+		removed := makePlugin("demo", "0.0.9", "c")
+		added := []*Plugin{makePlugin("zoom", "1.0.0", "d"), makePlugin("demo", "0.2.0", "e")}
+
+		diff := DiffPlugins(
+			[]*Plugin{removed, oldDemo, {}},
+			[]*Plugin{added[0], newDemo, added[1]},
+		)
+		require.False(t, diff.IsEmpty())
+		require.Equal(t, &PluginDiff{
+			Added:   []*Plugin{added[1], added[0]},
+			Changed: []*PluginVersionChange{{Old: oldDemo, New: newDemo}},
+			Removed: []*Plugin{removed},
+		}, diff)
+
+		require.Equal(t, []*CatalogChange{
+			{Type: ChangeTypeRemoved, PluginID: "demo", Version: "0.0.9"},
+			{Type: ChangeTypeUpdated, PluginID: "demo", Version: "0.1.0", Plugin: newDemo},
+			{Type: ChangeTypeAdded, PluginID: "demo", Version: "0.2.0", Plugin: added[1]},
+			{Type: ChangeTypeAdded, PluginID: "zoom", Version: "1.0.0", Plugin: added[0]},
+		}, diff.CatalogChanges())
+	})
+}
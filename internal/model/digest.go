@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// DigestAlgorithmSHA256 identifies a hex-encoded SHA-256 digest.
+const DigestAlgorithmSHA256 = "sha256"
+
+// CatalogDigest summarizes the catalog served, allowing mirrors and clients to detect divergence
+// from the canonical database.
+//
+// The digest is computed over the catalog encoded by PluginsToWriter without indentation, i.e. the
+// database as written by the generator, so that a mirror may compare it against the digest of its
+// own copy.
+type CatalogDigest struct {
+	// Plugins counts the plugin versions in the catalog.
+	Plugins   int    `json:"plugins"`
+	Algorithm string `json:"algorithm"`
+	Digest    string `json:"digest"`
+	// Signature is a detached signature of the encoded catalog, if the server signs catalogs.
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+// CatalogDigestFromReader decodes a json-encoded CatalogDigest from the given io.Reader.
+func CatalogDigestFromReader(reader io.Reader) (*CatalogDigest, error) {
+	digest := CatalogDigest{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&digest)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &digest, nil
+}
@@ -0,0 +1,227 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack/v4"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// JSONMediaType identifies json-encoded responses, the default.
+	JSONMediaType = "application/json"
+	// YAMLMediaType identifies YAML-encoded responses, meant for people and tools reading them.
+	YAMLMediaType = "application/yaml"
+	// MessagePackMediaType identifies MessagePack-encoded responses, meant for automated consumers
+	// sensitive to bandwidth.
+	MessagePackMediaType = "application/msgpack"
+)
+
+// EncodeYAML writes the given data as YAML to the given writer. Fields are named and omitted as
+// they are when encoded as json, so that both encodings describe the same document.
+func EncodeYAML(w io.Writer, data interface{}) error {
+	document, err := toDocument(data)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := yaml.Marshal(document)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode yaml")
+	}
+	_, err = w.Write(encoded)
+
+	return err
+}
+
+// EncodeMessagePack writes the given data as MessagePack to the given writer. Fields are named and
+// omitted as they are when encoded as json, so that both encodings describe the same document.
+func EncodeMessagePack(w io.Writer, data interface{}) error {
+	document, err := toDocument(data)
+	if err != nil {
+		return err
+	}
+
+	// Map keys are sorted so that the encoding is deterministic, and integers written in their most
+	// compact form rather than as the int64 of the document.
+	var buffer bytes.Buffer
+	encoder := msgpack.NewEncoder(&buffer).SortMapKeys(true).UseCompactEncoding(true)
+	if err := encoder.Encode(document); err != nil {
+		return errors.Wrap(err, "failed to encode msgpack")
+	}
+	_, err = w.Write(buffer.Bytes())
+
+	return err
+}
+
+// PluginsFromYAML decodes a YAML-encoded list of plugins from the given io.Reader.
+func PluginsFromYAML(reader io.Reader) ([]*Plugin, error) {
+	var document interface{}
+	if err := yaml.NewDecoder(reader).Decode(&document); err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "failed to decode yaml")
+	}
+
+	return pluginsFromDocument(document)
+}
+
+// PluginsFromMessagePack decodes a MessagePack-encoded list of plugins from the given io.Reader.
+func PluginsFromMessagePack(reader io.Reader) ([]*Plugin, error) {
+	bufferedReader := bufio.NewReader(reader)
+	if _, err := bufferedReader.Peek(1); err == io.EOF {
+		return []*Plugin{}, nil
+	}
+
+	document, err := msgpack.NewDecoder(bufferedReader).DecodeInterface()
+	if err != nil {
+		return nil, errors.Wrap(unexpectedEOF(err), "failed to decode msgpack")
+	}
+	if exceedsDepth(document, 1+MaxPluginDepth) {
+		return nil, errors.Errorf("failed to decode msgpack: exceeds maximum depth of %d", 1+MaxPluginDepth)
+	}
+
+	return pluginsFromDocument(document)
+}
+
+// toDocument converts the given data to the generic document of its json encoding, with numbers
+// as int64 where they are integers and float64 otherwise.
+func toDocument(data interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode json")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, errors.Wrap(err, "failed to decode json")
+	}
+
+	return withTypedNumbers(document), nil
+}
+
+func withTypedNumbers(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	case []interface{}:
+		for i, item := range value {
+			value[i] = withTypedNumbers(item)
+		}
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = withTypedNumbers(item)
+		}
+	}
+
+	return value
+}
+
+// pluginsFromDocument decodes the plugins of the given generic document, as PluginsFromReader
+// would its json encoding.
+func pluginsFromDocument(document interface{}) ([]*Plugin, error) {
+	if document == nil {
+		return []*Plugin{}, nil
+	}
+
+	document, err := withStringKeys(document)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode json")
+	}
+
+	return PluginsFromReader(bytes.NewReader(encoded))
+}
+
+// withStringKeys converts the maps of the given generic document, as decoded from YAML or
+// MessagePack, to maps keyed by strings, as json requires. MessagePack binary data is converted to
+// strings alike.
+func withStringKeys(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case []byte:
+		return string(value), nil
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			name, ok := key.(string)
+			if !ok {
+				return nil, errors.Errorf("unexpected key %v", key)
+			}
+			item, err := withStringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[name] = item
+		}
+		return converted, nil
+	case map[string]interface{}:
+		for key, item := range value {
+			item, err := withStringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			value[key] = item
+		}
+	case []interface{}:
+		for i, item := range value {
+			item, err := withStringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = item
+		}
+	}
+
+	return value, nil
+}
+
+// exceedsDepth reports whether the arrays and maps of the given generic document nest deeper than
+// the given depth.
+func exceedsDepth(value interface{}, depth int) bool {
+	if depth < 0 {
+		return true
+	}
+
+	switch value := value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if exceedsDepth(item, depth-1) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range value {
+			if exceedsDepth(item, depth-1) {
+				return true
+			}
+		}
+	case map[interface{}]interface{}:
+		for _, item := range value {
+			if exceedsDepth(item, depth-1) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
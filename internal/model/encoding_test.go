@@ -0,0 +1,97 @@
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+func TestEncoding(t *testing.T) {
+	plugins := []*Plugin{
+		{
+			HomepageURL:  "https://github.com/mattermost/mattermost-plugin-demo",
+			DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+			ReleaseStage: ReleaseStageBeta,
+			Labels:       []Label{{Name: "Beta", Color: "#ff0000"}},
+			ReleasedAt:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			Manifest: &mattermostModel.Manifest{
+				Id:               "demo",
+				Name:             strings.Repeat("Demo ", 20),
+				Version:          "0.2.0",
+				MinServerVersion: "5.20.0",
+			},
+		},
+		{
+			DownloadURL:  "https://example.com/starter-1.0.0.tar.gz",
+			ReleaseStage: ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: "starter", Version: "1.0.0"},
+		},
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		var buffer bytes.Buffer
+		require.NoError(t, EncodeYAML(&buffer, plugins))
+		require.Contains(t, buffer.String(), "download_url: https://example.com/starter-1.0.0.tar.gz")
+		require.Contains(t, buffer.String(), "release_stage: beta")
+
+		decoded, err := PluginsFromYAML(&buffer)
+		require.NoError(t, err)
+		require.Equal(t, plugins, decoded)
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		var buffer bytes.Buffer
+		require.NoError(t, EncodeMessagePack(&buffer, plugins))
+		require.Equal(t, byte(0x92), buffer.Bytes()[0])
+
+		decoded, err := PluginsFromMessagePack(&buffer)
+		require.NoError(t, err)
+		require.Equal(t, plugins, decoded)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		decoded, err := PluginsFromYAML(strings.NewReader(""))
+		require.NoError(t, err)
+		require.Empty(t, decoded)
+
+		decoded, err = PluginsFromMessagePack(strings.NewReader(""))
+		require.NoError(t, err)
+		require.Empty(t, decoded)
+	})
+
+	t.Run("truncated msgpack", func(t *testing.T) {
+		var buffer bytes.Buffer
+		require.NoError(t, EncodeMessagePack(&buffer, plugins))
+
+		_, err := PluginsFromMessagePack(bytes.NewReader(buffer.Bytes()[:buffer.Len()/2]))
+		require.EqualError(t, err, "failed to decode msgpack: unexpected EOF")
+	})
+
+	t.Run("bogus msgpack length", func(t *testing.T) {
+		_, err := PluginsFromMessagePack(bytes.NewReader([]byte{0xdb, 0xff, 0xff, 0xff, 0xff, 'a'}))
+		require.EqualError(t, err, "failed to decode msgpack: unexpected EOF")
+	})
+
+	t.Run("msgpack binary as strings", func(t *testing.T) {
+		encoded, err := msgpack.Marshal([]interface{}{map[string]interface{}{
+			"download_url": []byte("https://example.com/starter-1.0.0.tar.gz"),
+			"manifest":     map[string]interface{}{"id": "starter", "version": "1.0.0"},
+		}})
+		require.NoError(t, err)
+
+		decoded, err := PluginsFromMessagePack(bytes.NewReader(encoded))
+		require.NoError(t, err)
+		require.Len(t, decoded, 1)
+		require.Equal(t, "https://example.com/starter-1.0.0.tar.gz", decoded[0].DownloadURL)
+	})
+
+	t.Run("deeply nested msgpack", func(t *testing.T) {
+		_, err := PluginsFromMessagePack(bytes.NewReader(append(bytes.Repeat([]byte{0x91}, 100), 0xc0)))
+		require.EqualError(t, err, "failed to decode msgpack: exceeds maximum depth of 33")
+	})
+}
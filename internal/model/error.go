@@ -0,0 +1,62 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ErrorCode identifies the kind of error with which the API answered a request, keying the
+// translations of its message.
+type ErrorCode string
+
+const (
+	// ErrorCodeBadRequest identifies requests with invalid parameters or bodies.
+	ErrorCodeBadRequest ErrorCode = "bad_request"
+	// ErrorCodeUnauthorized identifies requests lacking valid credentials.
+	ErrorCodeUnauthorized ErrorCode = "unauthorized"
+	// ErrorCodeForbidden identifies requests whose credentials or origin do not permit them.
+	ErrorCodeForbidden ErrorCode = "forbidden"
+	// ErrorCodeNotFound identifies requests for unknown resources, or for disabled features.
+	ErrorCodeNotFound ErrorCode = "not_found"
+	// ErrorCodeConflict identifies requests conflicting with the current state of a resource.
+	ErrorCodeConflict ErrorCode = "conflict"
+	// ErrorCodeGone identifies requests for resources no longer retained.
+	ErrorCodeGone ErrorCode = "gone"
+	// ErrorCodeRateLimited identifies requests exceeding a rate limit.
+	ErrorCodeRateLimited ErrorCode = "rate_limited"
+	// ErrorCodeInternal identifies requests the server failed to handle.
+	ErrorCodeInternal ErrorCode = "internal_error"
+)
+
+// ErrorMessages maps each error code to its message in English, served unless translated.
+var ErrorMessages = map[ErrorCode]string{
+	ErrorCodeBadRequest:   "The request is invalid.",
+	ErrorCodeUnauthorized: "The request requires valid credentials.",
+	ErrorCodeForbidden:    "The request is not permitted.",
+	ErrorCodeNotFound:     "The requested resource was not found.",
+	ErrorCodeConflict:     "The request conflicts with the current state of the resource.",
+	ErrorCodeGone:         "The requested resource is no longer available.",
+	ErrorCodeRateLimited:  "Too many requests were made. Try again later.",
+	ErrorCodeInternal:     "The server failed to handle the request.",
+}
+
+// APIError describes an error with which the API answered a request.
+type APIError struct {
+	Code ErrorCode `json:"code"`
+	// Message describes the error in the language requested by the Accept-Language header, if
+	// translated, or else in English.
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// APIErrorFromReader decodes a json-encoded APIError from the given io.Reader.
+func APIErrorFromReader(reader io.Reader) (*APIError, error) {
+	apiError := APIError{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&apiError)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &apiError, nil
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Featuring records whether a plugin is featured, overriding the Featured and FeaturedWeight
+// recorded in the database. Featured plugins are listed first by default, heaviest first.
+type Featuring struct {
+	PluginID string `json:"plugin_id"`
+	Featured bool   `json:"featured"`
+	// Weight orders the featured plugins, heaviest first, ties being listed by name.
+	Weight int `json:"weight,omitempty"`
+	// UpdatedBy identifies the moderator who last set the featuring, if any.
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsValid verifies the featuring is well-formed.
+func (f *Featuring) IsValid() error {
+	if f.PluginID == "" {
+		return errors.New("featuring has no plugin id")
+	}
+	if !f.Featured && f.Weight != 0 {
+		return errors.Errorf("featuring of %s has a weight but is not featured", f.PluginID)
+	}
+
+	return nil
+}
+
+// FeaturingFromReader decodes a json-encoded featuring from the given io.Reader.
+func FeaturingFromReader(reader io.Reader) (*Featuring, error) {
+	featuring := Featuring{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&featuring)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &featuring, nil
+}
+
+// FeaturingsFromReader decodes a json-encoded list of featurings from the given io.Reader.
+func FeaturingsFromReader(reader io.Reader) ([]*Featuring, error) {
+	featurings := []*Featuring{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&featurings)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return featurings, nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeaturingIsValid(t *testing.T) {
+	validFeaturing := func() *Featuring {
+		return &Featuring{PluginID: "jira", Featured: true, Weight: 10}
+	}
+
+	require.NoError(t, validFeaturing().IsValid())
+	require.NoError(t, (&Featuring{PluginID: "jira"}).IsValid())
+
+	testCases := []struct {
+		Description   string
+		Modify        func(featuring *Featuring)
+		ExpectedError string
+	}{
+		{"no plugin id", func(featuring *Featuring) { featuring.PluginID = "" }, "featuring has no plugin id"},
+		{"weight without featuring", func(featuring *Featuring) { featuring.Featured = false }, "featuring of jira has a weight but is not featured"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			featuring := validFeaturing()
+			testCase.Modify(featuring)
+			require.EqualError(t, featuring.IsValid(), testCase.ExpectedError)
+		})
+	}
+}
+
+func TestFeaturingsFromReader(t *testing.T) {
+	featurings, err := FeaturingsFromReader(strings.NewReader(""))
+	require.NoError(t, err)
+	require.Empty(t, featurings)
+
+	featurings, err = FeaturingsFromReader(strings.NewReader(`[{"plugin_id":"jira","featured":true,"weight":10,"updated_at":"2020-01-01T00:00:00Z"}]`))
+	require.NoError(t, err)
+	require.Len(t, featurings, 1)
+	require.Equal(t, "jira", featurings[0].PluginID)
+	require.Equal(t, 10, featurings[0].Weight)
+}
@@ -0,0 +1,104 @@
+package model
+
+// CompatibilityFilter describes the constraints a plugin must meet to be offered to a server.
+//
+// Empty fields impose no constraint.
+type CompatibilityFilter struct {
+	// ServerVersion excludes plugins incompatible with the given Mattermost server version.
+	ServerVersion string
+	// Platform excludes plugins without a bundle installable on the given platform, e.g.
+	// linux-amd64.
+	Platform string
+	// Labels excludes plugins missing any of the given label names.
+	Labels []string
+}
+
+// FilterPlugins returns the plugins meeting the given constraints, preserving their order.
+func FilterPlugins(plugins []*Plugin, filter CompatibilityFilter) ([]*Plugin, error) {
+	var result []*Plugin
+	for _, plugin := range plugins {
+		compatible, err := plugin.IsCompatible(filter)
+		if err != nil {
+			return nil, err
+		}
+		if compatible {
+			result = append(result, plugin)
+		}
+	}
+
+	return result, nil
+}
+
+// IsCompatible reports whether the plugin meets the given constraints.
+func (p *Plugin) IsCompatible(filter CompatibilityFilter) (bool, error) {
+	if filter.ServerVersion != "" {
+		meetsServerVersion, err := p.MeetsServerVersion(filter.ServerVersion)
+		if err != nil {
+			return false, err
+		}
+		if !meetsServerVersion {
+			return false, nil
+		}
+	}
+
+	if filter.Platform != "" && !p.SupportsPlatform(filter.Platform) {
+		return false, nil
+	}
+
+	for _, name := range filter.Labels {
+		if !p.HasLabel(name) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// SupportsPlatform reports whether the plugin has a bundle installable on the given platform,
+// either built specifically for it or covering all platforms.
+func (p *Plugin) SupportsPlatform(platform string) bool {
+	if p.DownloadURL != "" {
+		return true
+	}
+
+	_, ok := p.Platforms[platform]
+	return ok
+}
+
+// HasLabel reports whether the plugin carries a label with the given name.
+func (p *Plugin) HasLabel(name string) bool {
+	for _, label := range p.Labels {
+		if label.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LatestVersions returns only the highest version of each plugin, in the order each plugin id is
+// first seen. Versions that fail to parse as semver rank below any valid version.
+func LatestVersions(plugins []*Plugin) []*Plugin {
+	var result []*Plugin
+	indexByID := map[string]int{}
+
+	for _, plugin := range plugins {
+		index, ok := indexByID[plugin.Manifest.Id]
+		if !ok {
+			indexByID[plugin.Manifest.Id] = len(result)
+			result = append(result, plugin)
+			continue
+		}
+
+		version, err := ParseVersion(plugin.Manifest.Version)
+		if err != nil {
+			continue
+		}
+		lastSeenVersion, err := ParseVersion(result[index].Manifest.Version)
+		if err != nil || version.GT(lastSeenVersion) {
+			result[index] = plugin
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterPlugins(t *testing.T) {
+	demoMin514 := &Plugin{
+		DownloadURL: "https://example.com/demo-0.1.0.tar.gz",
+		Labels:      []Label{{Name: "Official"}, {Name: "Beta"}},
+		Manifest:    &mattermostModel.Manifest{Id: "demo", Version: "0.1.0", MinServerVersion: "5.14.0"},
+	}
+	starterMin516 := &Plugin{
+		DownloadURL: "https://example.com/starter-0.1.0.tar.gz",
+		Labels:      []Label{{Name: "Official"}},
+		Manifest:    &mattermostModel.Manifest{Id: "starter", Version: "0.1.0", MinServerVersion: "5.16.0"},
+	}
+	linuxOnly := &Plugin{
+		Platforms: map[string]*PlatformBundle{
+			"linux-amd64": {DownloadURL: "https://example.com/native-linux-amd64.tar.gz"},
+		},
+		Manifest: &mattermostModel.Manifest{Id: "native", Version: "0.1.0"},
+	}
+	plugins := []*Plugin{demoMin514, starterMin516, linuxOnly}
+
+	testCases := []struct {
+		Description string
+		Filter      CompatibilityFilter
+		Expected    []*Plugin
+	}{
+		{"no constraints", CompatibilityFilter{}, plugins},
+		{"server version", CompatibilityFilter{ServerVersion: "5.15.0"}, []*Plugin{demoMin514, linuxOnly}},
+		{"platform", CompatibilityFilter{Platform: "linux-amd64"}, plugins},
+		{"unsupported platform", CompatibilityFilter{Platform: "darwin-amd64"}, []*Plugin{demoMin514, starterMin516}},
+		{"label", CompatibilityFilter{Labels: []string{"Official"}}, []*Plugin{demoMin514, starterMin516}},
+		{"all labels", CompatibilityFilter{Labels: []string{"Official", "Beta"}}, []*Plugin{demoMin514}},
+		{"no matches", CompatibilityFilter{ServerVersion: "5.13.0", Labels: []string{"Beta"}}, nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			filtered, err := FilterPlugins(plugins, testCase.Filter)
+			require.NoError(t, err)
+			require.Equal(t, testCase.Expected, filtered)
+		})
+	}
+}
+
+func TestLatestVersions(t *testing.T) {
+	demoV1 := &Plugin{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "0.1.0"}}
+	demoV2 := &Plugin{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "0.2.0"}}
+	demoV10 := &Plugin{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "0.10.0"}}
+	demoInvalid := &Plugin{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "invalid"}}
+	starterV1 := &Plugin{Manifest: &mattermostModel.Manifest{Id: "starter", Version: "0.1.0"}}
+
+	require.Nil(t, LatestVersions(nil))
+	require.Equal(t, []*Plugin{demoV10, starterV1}, LatestVersions([]*Plugin{demoV2, starterV1, demoV10, demoV1}))
+	require.Equal(t, []*Plugin{demoV1}, LatestVersions([]*Plugin{demoInvalid, demoV1}))
+	require.Equal(t, []*Plugin{demoV1}, LatestVersions([]*Plugin{demoV1, demoInvalid}))
+}
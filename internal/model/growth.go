@@ -0,0 +1,88 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// CatalogComposition describes the size of a catalog snapshot, informing pruning and storage.
+type CatalogComposition struct {
+	// SnapshotID identifies the snapshot described.
+	SnapshotID int64     `json:"snapshot_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Plugins counts the distinct plugins, and Entries their versions.
+	Plugins int `json:"plugins"`
+	Entries int `json:"entries"`
+	// Bytes is the size of the entries encoded as json, of which IconBytes are inlined icons.
+	Bytes     int64 `json:"bytes"`
+	IconBytes int64 `json:"icon_bytes"`
+	// Labels counts the entries carrying each label, by name.
+	Labels map[string]int `json:"labels"`
+}
+
+// ComposeCatalog describes the size of a catalog of the given plugins.
+func ComposeCatalog(plugins []*Plugin) *CatalogComposition {
+	composition := &CatalogComposition{
+		Entries: len(plugins),
+		Labels:  map[string]int{},
+	}
+
+	ids := map[string]bool{}
+	for _, plugin := range plugins {
+		if plugin.Manifest != nil {
+			ids[plugin.Manifest.Id] = true
+		}
+		// Entries were validated when loaded, so fail to encode only in theory.
+		if data, err := json.Marshal(plugin); err == nil {
+			composition.Bytes += int64(len(data))
+		}
+		composition.IconBytes += int64(len(plugin.IconData))
+		for _, label := range plugin.Labels {
+			composition.Labels[label.Name]++
+		}
+	}
+	composition.Plugins = len(ids)
+
+	return composition
+}
+
+// CatalogGrowth reports how a catalog grew over its retained snapshots.
+type CatalogGrowth struct {
+	// Snapshots describes each retained snapshot, oldest first.
+	Snapshots []*CatalogComposition `json:"snapshots"`
+	// EntriesPerDay and BytesPerDay average the growth from the oldest to the latest snapshot,
+	// and are zero given fewer than two snapshots.
+	EntriesPerDay float64 `json:"entries_per_day"`
+	BytesPerDay   float64 `json:"bytes_per_day"`
+}
+
+// NewCatalogGrowth reports the growth over the given snapshots, oldest first.
+func NewCatalogGrowth(snapshots []*CatalogComposition) *CatalogGrowth {
+	growth := &CatalogGrowth{Snapshots: snapshots}
+	if len(snapshots) < 2 {
+		return growth
+	}
+
+	oldest, latest := snapshots[0], snapshots[len(snapshots)-1]
+	days := latest.CreatedAt.Sub(oldest.CreatedAt).Hours() / 24
+	if days <= 0 {
+		return growth
+	}
+	growth.EntriesPerDay = float64(latest.Entries-oldest.Entries) / days
+	growth.BytesPerDay = float64(latest.Bytes-oldest.Bytes) / days
+
+	return growth
+}
+
+// CatalogGrowthFromReader decodes a json-encoded CatalogGrowth from the given io.Reader.
+func CatalogGrowthFromReader(reader io.Reader) (*CatalogGrowth, error) {
+	growth := CatalogGrowth{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&growth)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &growth, nil
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeCatalog(t *testing.T) {
+	plugins := []*Plugin{
+		{
+			IconData: "data:image/svg+xml;base64,PHN2Zy8+",
+			Labels:   []Label{{Name: "Official"}, {Name: "Beta"}},
+			Manifest: &mattermostModel.Manifest{Id: "demo", Version: "0.2.0"},
+		},
+		{
+			IconData: "data:image/svg+xml;base64,PHN2Zy8+",
+			Labels:   []Label{{Name: "Official"}},
+			Manifest: &mattermostModel.Manifest{Id: "demo", Version: "0.1.0"},
+		},
+		{
+			Manifest: &mattermostModel.Manifest{Id: "starter", Version: "0.1.0"},
+		},
+	}
+
+	var bytes int64
+	for _, plugin := range plugins {
+		data, err := json.Marshal(plugin)
+		require.NoError(t, err)
+		bytes += int64(len(data))
+	}
+
+	require.Equal(t, &CatalogComposition{
+		Plugins:   2,
+		Entries:   3,
+		Bytes:     bytes,
+		IconBytes: 68,
+		Labels:    map[string]int{"Official": 2, "Beta": 1},
+	}, ComposeCatalog(plugins))
+
+	require.Equal(t, &CatalogComposition{Labels: map[string]int{}}, ComposeCatalog(nil))
+}
+
+func TestNewCatalogGrowth(t *testing.T) {
+	start := time.Date(2019, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("no snapshots", func(t *testing.T) {
+		require.Equal(t, &CatalogGrowth{}, NewCatalogGrowth(nil))
+	})
+
+	t.Run("averaged over the snapshots", func(t *testing.T) {
+		snapshots := []*CatalogComposition{
+			{SnapshotID: 1, CreatedAt: start, Entries: 10, Bytes: 1000},
+			{SnapshotID: 2, CreatedAt: start.Add(24 * time.Hour), Entries: 12, Bytes: 1500},
+			{SnapshotID: 3, CreatedAt: start.Add(4 * 24 * time.Hour), Entries: 18, Bytes: 3000},
+		}
+
+		growth := NewCatalogGrowth(snapshots)
+		require.Equal(t, snapshots, growth.Snapshots)
+		require.Equal(t, 2.0, growth.EntriesPerDay)
+		require.Equal(t, 500.0, growth.BytesPerDay)
+	})
+
+	t.Run("taken at once", func(t *testing.T) {
+		growth := NewCatalogGrowth([]*CatalogComposition{
+			{SnapshotID: 1, CreatedAt: start, Entries: 10},
+			{SnapshotID: 2, CreatedAt: start, Entries: 12},
+		})
+		require.Zero(t, growth.EntriesPerDay)
+	})
+}
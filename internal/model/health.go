@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// StoreHealth reports the health of a store failing over from a primary backend to a secondary
+// one.
+type StoreHealth struct {
+	// Degraded is set while queries are served by the secondary backend.
+	Degraded bool
+	// ConsecutiveFailures counts the queries the primary backend failed since it last succeeded.
+	ConsecutiveFailures int
+	// Failovers counts the queries served by the secondary backend since startup.
+	Failovers int64
+	// LastError describes the latest failure of the primary backend, if any.
+	LastError string
+	// LastFailureAt is when the primary backend last failed, or zero if it never did.
+	LastFailureAt time.Time
+	// RecoveredAt is when the primary backend last succeeded after failing, or zero if it never
+	// recovered.
+	RecoveredAt time.Time
+}
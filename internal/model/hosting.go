@@ -0,0 +1,28 @@
+package model
+
+// HostingRequirement describes the kind of Mattermost installation a plugin supports.
+type HostingRequirement string
+
+const (
+	// HostingOnPrem identifies plugins supported only on self-hosted installations.
+	HostingOnPrem HostingRequirement = "on-prem"
+	// HostingCloud identifies plugins supported only on Mattermost Cloud.
+	HostingCloud HostingRequirement = "cloud"
+	// HostingBoth identifies plugins supported on any installation.
+	HostingBoth HostingRequirement = "both"
+)
+
+// IsValid reports whether the hosting requirement is one of the known values.
+func (h HostingRequirement) IsValid() bool {
+	switch h {
+	case HostingOnPrem, HostingCloud, HostingBoth:
+		return true
+	default:
+		return false
+	}
+}
+
+// Supports reports whether a plugin with this requirement may be installed on the given hosting.
+func (h HostingRequirement) Supports(hosting HostingRequirement) bool {
+	return h == hosting || h == HostingBoth
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostingRequirementIsValid(t *testing.T) {
+	require.True(t, HostingOnPrem.IsValid())
+	require.True(t, HostingCloud.IsValid())
+	require.True(t, HostingBoth.IsValid())
+	require.False(t, HostingRequirement("").IsValid())
+	require.False(t, HostingRequirement("hybrid").IsValid())
+}
+
+func TestHostingRequirementSupports(t *testing.T) {
+	require.True(t, HostingOnPrem.Supports(HostingOnPrem))
+	require.False(t, HostingOnPrem.Supports(HostingCloud))
+	require.True(t, HostingCloud.Supports(HostingCloud))
+	require.False(t, HostingCloud.Supports(HostingOnPrem))
+	require.True(t, HostingBoth.Supports(HostingOnPrem))
+	require.True(t, HostingBoth.Supports(HostingCloud))
+	require.False(t, HostingRequirement("").Supports(HostingCloud))
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxIconSize is the default limit, in decoded bytes, on the size of a plugin icon.
+const DefaultMaxIconSize = 128 * 1024
+
+// allowedIconMimeTypes are the image formats clients are known to render as plugin icons.
+var allowedIconMimeTypes = map[string]bool{
+	"image/svg+xml": true,
+	"image/png":     true,
+	"image/jpeg":    true,
+	"image/gif":     true,
+}
+
+// ValidateIconData verifies the given icon is a base64 data URI of an allowed image type, no
+// larger than maxSize decoded bytes.
+func ValidateIconData(iconData string, maxSize int) error {
+	mimeType, data, err := parseDataURI(iconData)
+	if err != nil {
+		return err
+	}
+	if !allowedIconMimeTypes[mimeType] {
+		return errors.Errorf("icon has disallowed mime type %s", mimeType)
+	}
+	if len(data) > maxSize {
+		return errors.Errorf("icon is %d bytes, exceeding the maximum of %d", len(data), maxSize)
+	}
+
+	return nil
+}
+
+// DecodeIconData returns the mime type and decoded image of the given icon data URI.
+func DecodeIconData(iconData string) (string, []byte, error) {
+	return parseDataURI(iconData)
+}
+
+// ValidateIconURL verifies the given icon is referenced by an absolute https URL. Unlike other
+// images, icons are shown by every server browsing the marketplace, so mixed content is refused.
+func ValidateIconURL(iconURL string) error {
+	if strings.HasPrefix(iconURL, "data:") {
+		return errors.New("icon url must not be a data uri")
+	}
+	if err := ValidateImageReference(iconURL); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(iconURL, "https://") {
+		return errors.Errorf("icon url %s must use https", iconURL)
+	}
+
+	return nil
+}
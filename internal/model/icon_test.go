@@ -0,0 +1,60 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIconData(t *testing.T) {
+	testCases := []struct {
+		Description   string
+		IconData      string
+		ExpectedError string
+	}{
+		{"svg", "data:image/svg+xml;base64,PHN2Zy8+", ""},
+		{"png", "data:image/png;base64,iVBORw0KGgo=", ""},
+		{"path", "icon.svg", "not a data uri"},
+		{"url", "https://example.com/icon.svg", "not a data uri"},
+		{"not base64-encoded", "data:image/svg+xml,<svg/>", "data uri is not base64-encoded"},
+		{"malformed base64", "data:image/png;base64,!!!", "failed to decode data uri: illegal base64 data at input byte 0"},
+		{"disallowed mime type", "data:text/html;base64,PGh0bWw+", "icon has disallowed mime type text/html"},
+		{"oversized", "data:image/png;base64,AAAAAAAAAAAA", "icon is 9 bytes, exceeding the maximum of 8"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			err := ValidateIconData(testCase.IconData, 8)
+			if testCase.ExpectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, testCase.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestValidateIconURL(t *testing.T) {
+	testCases := []struct {
+		Description   string
+		IconURL       string
+		ExpectedError string
+	}{
+		{"https", "https://cdn.example.com/icons/demo.svg", ""},
+		{"http", "http://cdn.example.com/icons/demo.svg", "icon url http://cdn.example.com/icons/demo.svg must use https"},
+		{"data uri", "data:image/svg+xml;base64,PHN2Zy8+", "icon url must not be a data uri"},
+		{"other scheme", "ftp://example.com/icon.svg", "url ftp://example.com/icon.svg must use http or https"},
+		{"no host", "https:///icon.svg", "url https:///icon.svg has no host"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			err := ValidateIconURL(testCase.IconURL)
+			if testCase.ExpectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, testCase.ExpectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseDataURI decodes a base64 data URI such as data:image/png;base64,..., returning its MIME
+// type and contents.
+func parseDataURI(value string) (string, []byte, error) {
+	if !strings.HasPrefix(value, "data:") {
+		return "", nil, errors.New("not a data uri")
+	}
+
+	separator := strings.Index(value, ",")
+	if separator < 0 {
+		return "", nil, errors.New("data uri has no data")
+	}
+
+	mediaType := value[len("data:"):separator]
+	if !strings.HasSuffix(mediaType, ";base64") {
+		return "", nil, errors.New("data uri is not base64-encoded")
+	}
+	mimeType := strings.TrimSuffix(mediaType, ";base64")
+	if mimeType == "" {
+		return "", nil, errors.New("data uri has no mime type")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value[separator+1:])
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to decode data uri")
+	}
+
+	return mimeType, data, nil
+}
+
+// ValidateImageReference verifies the given value refers to an image, either as an absolute
+// http(s) URL or as a base64 image data URI.
+func ValidateImageReference(value string) error {
+	if strings.HasPrefix(value, "data:") {
+		mimeType, _, err := parseDataURI(value)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(mimeType, "image/") {
+			return errors.Errorf("data uri has non-image mime type %s", mimeType)
+		}
+
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse url")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("url %s must use http or https", value)
+	}
+	if u.Host == "" {
+		return errors.Errorf("url %s has no host", value)
+	}
+
+	return nil
+}
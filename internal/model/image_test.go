@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDataURI(t *testing.T) {
+	mimeType, data, err := parseDataURI("data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=")
+	require.NoError(t, err)
+	require.Equal(t, "image/svg+xml", mimeType)
+	require.Equal(t, []byte("<svg></svg>"), data)
+
+	_, _, err = parseDataURI("https://example.com/icon.svg")
+	require.EqualError(t, err, "not a data uri")
+
+	_, _, err = parseDataURI("data:image/png;base64")
+	require.EqualError(t, err, "data uri has no data")
+
+	_, _, err = parseDataURI("data:image/svg+xml,<svg></svg>")
+	require.EqualError(t, err, "data uri is not base64-encoded")
+
+	_, _, err = parseDataURI("data:;base64,aGVsbG8=")
+	require.EqualError(t, err, "data uri has no mime type")
+
+	_, _, err = parseDataURI("data:image/png;base64,!!!")
+	require.Error(t, err)
+}
+
+func TestValidateImageReference(t *testing.T) {
+	require.NoError(t, ValidateImageReference("https://example.com/screenshot.png"))
+	require.NoError(t, ValidateImageReference("http://example.com/screenshot.png"))
+	require.NoError(t, ValidateImageReference("data:image/png;base64,iVBORw0KGgo="))
+
+	require.EqualError(t, ValidateImageReference("/screenshot.png"), "url /screenshot.png must use http or https")
+	require.EqualError(t, ValidateImageReference("https:///screenshot.png"), "url https:///screenshot.png has no host")
+	require.EqualError(t, ValidateImageReference("data:text/html;base64,PGI+PC9iPg=="), "data uri has non-image mime type text/html")
+	require.Error(t, ValidateImageReference("https://exa mple.com/%zz"))
+}
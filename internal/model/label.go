@@ -0,0 +1,30 @@
+package model
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// labelColorRegexp matches CSS hex colors such as #fff or #1e325c.
+var labelColorRegexp = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Label represents a badge displayed alongside a plugin, such as "Official" or "Beta".
+type Label struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Color is an optional CSS hex color, e.g. #1e325c.
+	Color string `json:"color,omitempty"`
+}
+
+// IsValid verifies the label is well-formed.
+func (l *Label) IsValid() error {
+	if l.Name == "" {
+		return errors.New("label name is empty")
+	}
+	if l.Color != "" && !labelColorRegexp.MatchString(l.Color) {
+		return errors.Errorf("label %s has invalid color %s", l.Name, l.Color)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelIsValid(t *testing.T) {
+	testCases := []struct {
+		Description   string
+		Label         Label
+		ExpectedError string
+	}{
+		{"name only", Label{Name: "Official"}, ""},
+		{"short color", Label{Name: "Official", Color: "#fff"}, ""},
+		{"long color", Label{Name: "Official", Description: "Maintained by Mattermost", Color: "#1e325C"}, ""},
+		{"empty name", Label{Color: "#fff"}, "label name is empty"},
+		{"color without hash", Label{Name: "Beta", Color: "fff"}, "label Beta has invalid color fff"},
+		{"named color", Label{Name: "Beta", Color: "red"}, "label Beta has invalid color red"},
+		{"invalid hex", Label{Name: "Beta", Color: "#ggg"}, "label Beta has invalid color #ggg"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			err := testCase.Label.IsValid()
+			if testCase.ExpectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, testCase.ExpectedError)
+			}
+		})
+	}
+}
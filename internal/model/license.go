@@ -0,0 +1,36 @@
+package model
+
+// LicenseTier describes the license of a Mattermost installation.
+type LicenseTier string
+
+const (
+	// LicenseTierTeam identifies installations without a license, such as Team Edition.
+	LicenseTierTeam LicenseTier = "team"
+	// LicenseTierProfessional identifies installations licensed for Mattermost Professional.
+	LicenseTierProfessional LicenseTier = "professional"
+	// LicenseTierEnterprise identifies installations licensed for Mattermost Enterprise.
+	LicenseTierEnterprise LicenseTier = "enterprise"
+)
+
+// licenseTierRanks orders the license tiers, each including the features of those below it.
+var licenseTierRanks = map[LicenseTier]int{
+	LicenseTierTeam:         0,
+	LicenseTierProfessional: 1,
+	LicenseTierEnterprise:   2,
+}
+
+// IsValid reports whether the license tier is one of the known values.
+func (t LicenseTier) IsValid() bool {
+	_, ok := licenseTierRanks[t]
+	return ok
+}
+
+// Satisfies reports whether an installation with this license tier may install a plugin requiring
+// the given license tier. Any installation satisfies an empty requirement.
+func (t LicenseTier) Satisfies(required LicenseTier) bool {
+	if required == "" {
+		return true
+	}
+
+	return licenseTierRanks[t] >= licenseTierRanks[required]
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenseTierIsValid(t *testing.T) {
+	require.True(t, LicenseTierTeam.IsValid())
+	require.True(t, LicenseTierProfessional.IsValid())
+	require.True(t, LicenseTierEnterprise.IsValid())
+	require.False(t, LicenseTier("").IsValid())
+	require.False(t, LicenseTier("e20").IsValid())
+}
+
+func TestLicenseTierSatisfies(t *testing.T) {
+	require.True(t, LicenseTierTeam.Satisfies(""))
+	require.True(t, LicenseTierTeam.Satisfies(LicenseTierTeam))
+	require.False(t, LicenseTierTeam.Satisfies(LicenseTierProfessional))
+	require.False(t, LicenseTierTeam.Satisfies(LicenseTierEnterprise))
+	require.True(t, LicenseTierProfessional.Satisfies(LicenseTierProfessional))
+	require.False(t, LicenseTierProfessional.Satisfies(LicenseTierEnterprise))
+	require.True(t, LicenseTierEnterprise.Satisfies(LicenseTierProfessional))
+	require.True(t, LicenseTierEnterprise.Satisfies(LicenseTierEnterprise))
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"github.com/pkg/errors"
+)
+
+// MaxPluginSize limits the encoded size, in bytes, of each plugin in a database. It leaves ample
+// room for an icon of DefaultMaxIconSize, base64-encoded, alongside the rest of the plugin.
+const MaxPluginSize = 1024 * 1024
+
+// MaxPluginDepth limits how deeply the values of each plugin in a database may nest, e.g. within
+// the settings schema of its manifest.
+const MaxPluginDepth = 32
+
+// databaseObjectDepth is the depth at which the plugins of a compact or v2 database nest, within
+// the database object, its list of plugins and, for v2 databases, the versions of each plugin.
+const databaseObjectDepth = 4
+
+// checkNesting verifies the given encoded json nests objects and arrays no deeper than maxDepth,
+// so that pathological input is rejected before it is decoded.
+func checkNesting(data []byte, maxDepth int) error {
+	depth := 0
+	inString := false
+	escaped := false
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return errors.Errorf("nesting exceeds the maximum depth of %d", maxDepth)
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+
+	return nil
+}
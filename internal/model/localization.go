@@ -0,0 +1,13 @@
+package model
+
+// Localization holds the display text of a plugin's enumerated fields in the language requested
+// by the client, leaving the fields themselves unchanged.
+type Localization struct {
+	// Locale identifies the language of the display text, e.g. fr or pt-br.
+	Locale       string `json:"locale"`
+	AuthorType   string `json:"author_type,omitempty"`
+	ReleaseStage string `json:"release_stage,omitempty"`
+	// Labels holds the translated name and description of each of the plugin's labels, in the
+	// same order.
+	Labels []Label `json:"labels,omitempty"`
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"net/mail"
+	"net/url"
+
+	"github.com/pkg/errors"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+)
+
+// ManifestMaintainersProp names the manifest prop by which a plugin declares its maintainers, as a
+// list of objects each giving a name and optionally an email and url.
+const ManifestMaintainersProp = "maintainers"
+
+// Maintainer identifies a person or team to contact about a plugin, such as when it is broken or
+// vulnerable.
+type Maintainer struct {
+	Name string `json:"name" yaml:"name"`
+	// Email is the address at which to reach the maintainer, if any.
+	Email string `json:"email,omitempty" yaml:"email"`
+	// URL is a page at which to reach the maintainer, such as an issue tracker, if any.
+	URL string `json:"url,omitempty" yaml:"url"`
+}
+
+// IsValid verifies the maintainer is named, and that its email and url are well-formed.
+func (m *Maintainer) IsValid() error {
+	if m.Name == "" {
+		return errors.New("maintainer name is empty")
+	}
+	if m.Email != "" {
+		if _, err := mail.ParseAddress(m.Email); err != nil {
+			return errors.Wrapf(err, "maintainer %s has invalid email %s", m.Name, m.Email)
+		}
+	}
+	if m.URL != "" {
+		u, err := url.Parse(m.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf("maintainer %s has invalid url %s", m.Name, m.URL)
+		}
+	}
+
+	return nil
+}
+
+// MaintainersFromManifest returns the maintainers declared by the given manifest, in the order
+// declared, or nil if it declares none.
+func MaintainersFromManifest(manifest *mattermostModel.Manifest) ([]*Maintainer, error) {
+	if manifest == nil || manifest.Props == nil {
+		return nil, nil
+	}
+	prop, ok := manifest.Props[ManifestMaintainersProp]
+	if !ok {
+		return nil, nil
+	}
+
+	declared, ok := prop.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("manifest prop %s is not a list", ManifestMaintainersProp)
+	}
+
+	maintainers := []*Maintainer{}
+	for i, value := range declared {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("maintainer %d is not an object", i)
+		}
+
+		maintainer := &Maintainer{}
+		for name, target := range map[string]*string{"name": &maintainer.Name, "email": &maintainer.Email, "url": &maintainer.URL} {
+			field, ok := fields[name]
+			if !ok {
+				continue
+			}
+			if *target, ok = field.(string); !ok {
+				return nil, errors.Errorf("%s of maintainer %d is not a string", name, i)
+			}
+		}
+		if err := maintainer.IsValid(); err != nil {
+			return nil, err
+		}
+		maintainers = append(maintainers, maintainer)
+	}
+
+	return maintainers, nil
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintainerIsValid(t *testing.T) {
+	require.NoError(t, (&Maintainer{Name: "Integrations Team"}).IsValid())
+	require.NoError(t, (&Maintainer{Name: "Jane", Email: "jane@example.com", URL: "https://example.com/issues"}).IsValid())
+	require.Error(t, (&Maintainer{Email: "jane@example.com"}).IsValid())
+	require.Error(t, (&Maintainer{Name: "Jane", Email: "jane"}).IsValid())
+	require.Error(t, (&Maintainer{Name: "Jane", URL: "mailto:jane@example.com"}).IsValid())
+	require.Error(t, (&Maintainer{Name: "Jane", URL: "https://"}).IsValid())
+}
+
+func TestMaintainersFromManifest(t *testing.T) {
+	t.Run("declared", func(t *testing.T) {
+		manifest := mattermostModel.ManifestFromJson(strings.NewReader(`{
+			"id": "demo",
+			"version": "1.0.0",
+			"props": {"maintainers": [
+				{"name": "Jane", "email": "jane@example.com"},
+				{"name": "Integrations Team", "url": "https://example.com/issues"}
+			]}
+		}`))
+		maintainers, err := MaintainersFromManifest(manifest)
+		require.NoError(t, err)
+		require.Equal(t, []*Maintainer{
+			{Name: "Jane", Email: "jane@example.com"},
+			{Name: "Integrations Team", URL: "https://example.com/issues"},
+		}, maintainers)
+	})
+
+	t.Run("none declared", func(t *testing.T) {
+		maintainers, err := MaintainersFromManifest(&mattermostModel.Manifest{Id: "demo"})
+		require.NoError(t, err)
+		require.Nil(t, maintainers)
+
+		maintainers, err = MaintainersFromManifest(&mattermostModel.Manifest{Id: "demo", Props: map[string]interface{}{"other": true}})
+		require.NoError(t, err)
+		require.Nil(t, maintainers)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		testCases := map[string]interface{}{
+			"not a list":        map[string]interface{}{"name": "Jane"},
+			"not an object":     []interface{}{"Jane"},
+			"name not a string": []interface{}{map[string]interface{}{"name": 3}},
+			"missing name":      []interface{}{map[string]interface{}{"email": "jane@example.com"}},
+			"invalid email":     []interface{}{map[string]interface{}{"name": "Jane", "email": "jane"}},
+			"invalid url":       []interface{}{map[string]interface{}{"name": "Jane", "url": "example.com"}},
+		}
+		for description, prop := range testCases {
+			t.Run(description, func(t *testing.T) {
+				_, err := MaintainersFromManifest(&mattermostModel.Manifest{Id: "demo", Props: map[string]interface{}{"maintainers": prop}})
+				require.Error(t, err)
+			})
+		}
+	})
+}
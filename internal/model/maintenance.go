@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// UnmaintainedReason explains why a plugin is considered unmaintained.
+type UnmaintainedReason string
+
+const (
+	// UnmaintainedRepositoryArchived identifies plugins whose repository was archived.
+	UnmaintainedRepositoryArchived UnmaintainedReason = "repository_archived"
+	// UnmaintainedStale identifies plugins without a release for longer than the marketplace's
+	// configured age.
+	UnmaintainedStale UnmaintainedReason = "stale"
+)
+
+// UnmaintainedLabel is the label with which unmaintained plugins are flagged in responses.
+var UnmaintainedLabel = Label{
+	Name:        "Unmaintained",
+	Description: "This plugin is no longer actively maintained.",
+	Color:       "#d24b4e",
+}
+
+// Maintenance summarizes the activity of a plugin across its versions.
+type Maintenance struct {
+	// RepositoryArchived is set if any version records its repository as archived.
+	RepositoryArchived bool
+	// LastReleasedAt is when the most recent version was released, or zero if none records it.
+	LastReleasedAt time.Time
+}
+
+// NewMaintenance summarizes the activity of the plugin with the given versions.
+func NewMaintenance(versions []*Plugin) Maintenance {
+	var maintenance Maintenance
+	for _, plugin := range versions {
+		maintenance.RepositoryArchived = maintenance.RepositoryArchived || plugin.RepositoryArchived
+		if plugin.ReleasedAt.After(maintenance.LastReleasedAt) {
+			maintenance.LastReleasedAt = plugin.ReleasedAt
+		}
+	}
+
+	return maintenance
+}
+
+// Unmaintained returns why the plugin is unmaintained at the given time, or an empty reason if it
+// is maintained. A plugin is stale if staleAfter is positive and it recorded no release within
+// staleAfter of now.
+func (m Maintenance) Unmaintained(staleAfter time.Duration, now time.Time) UnmaintainedReason {
+	if m.RepositoryArchived {
+		return UnmaintainedRepositoryArchived
+	}
+	if staleAfter > 0 && !m.LastReleasedAt.IsZero() && now.Sub(m.LastReleasedAt) > staleAfter {
+		return UnmaintainedStale
+	}
+
+	return ""
+}
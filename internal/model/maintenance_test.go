@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenance(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	year := 365 * 24 * time.Hour
+
+	recent := &Plugin{ReleasedAt: now.AddDate(0, -2, 0)}
+	old := &Plugin{ReleasedAt: now.AddDate(-2, 0, 0)}
+	archived := &Plugin{ReleasedAt: now.AddDate(0, -1, 0), RepositoryArchived: true}
+	undated := &Plugin{}
+
+	testCases := []struct {
+		Description string
+		Versions    []*Plugin
+		StaleAfter  time.Duration
+		Expected    UnmaintainedReason
+	}{
+		{"recently released", []*Plugin{old, recent}, year, ""},
+		{"stale", []*Plugin{old}, year, UnmaintainedStale},
+		{"stale without policy", []*Plugin{old}, 0, ""},
+		{"archived", []*Plugin{recent, archived}, 0, UnmaintainedRepositoryArchived},
+		{"archived and stale", []*Plugin{old, archived}, time.Hour, UnmaintainedRepositoryArchived},
+		{"no release dates", []*Plugin{undated}, time.Hour, ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			require.Equal(t, testCase.Expected, NewMaintenance(testCase.Versions).Unmaintained(testCase.StaleAfter, now))
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// platformRegexp matches platform names in the form used by plugin manifests, e.g. linux-amd64.
+var platformRegexp = regexp.MustCompile(`^(linux|darwin|windows|freebsd)-[a-z0-9]+$`)
+
+// PlatformBundle describes the bundle of a plugin built for a single platform.
+type PlatformBundle struct {
+	DownloadURL string       `json:"download_url"`
+	Signatures  []*Signature `json:"signatures,omitempty"`
+	Checksums   *Checksums   `json:"checksums,omitempty"`
+}
+
+// IsValidPlatform reports whether the given name is a well-formed platform, e.g. linux-amd64.
+func IsValidPlatform(platform string) bool {
+	return platformRegexp.MatchString(platform)
+}
+
+// IsValid verifies the platform bundle is well-formed.
+func (b *PlatformBundle) IsValid() error {
+	if b.DownloadURL == "" {
+		return errors.New("download url is empty")
+	}
+	if b.Checksums != nil {
+		if err := b.Checksums.IsValid(); err != nil {
+			return errors.Wrap(err, "invalid checksums")
+		}
+	}
+
+	return nil
+}
+
+// ForPlatform returns a copy of the plugin describing the bundle for the given platform, or the
+// plugin itself if no platform-specific bundle is recorded.
+func (p *Plugin) ForPlatform(platform string) *Plugin {
+	bundle, ok := p.Platforms[platform]
+	if !ok {
+		return p
+	}
+
+	platformPlugin := *p
+	platformPlugin.DownloadURL = bundle.DownloadURL
+	platformPlugin.Signatures = bundle.Signatures
+	platformPlugin.Checksums = bundle.Checksums
+	// The legacy signature describes the default bundle, not this one.
+	platformPlugin.Signature = ""
+	if len(bundle.Signatures) > 0 {
+		platformPlugin.Signature = bundle.Signatures[0].Signature
+	}
+
+	return &platformPlugin
+}
+
+// WithoutPrivateBundles returns a copy of the plugin omitting the urls of its bundles if it is
+// private, so that they are not exposed beyond pre-signed urls, or else the plugin itself.
+func (p *Plugin) WithoutPrivateBundles() *Plugin {
+	if !p.Private {
+		return p
+	}
+
+	privatePlugin := *p
+	privatePlugin.DownloadURL = ""
+	privatePlugin.Platforms = nil
+
+	return &privatePlugin
+}
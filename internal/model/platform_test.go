@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidPlatform(t *testing.T) {
+	require.True(t, IsValidPlatform("linux-amd64"))
+	require.True(t, IsValidPlatform("darwin-arm64"))
+	require.True(t, IsValidPlatform("windows-amd64"))
+	require.False(t, IsValidPlatform("amd64"))
+	require.False(t, IsValidPlatform("plan9-amd64"))
+	require.False(t, IsValidPlatform("Linux-AMD64"))
+	require.False(t, IsValidPlatform(""))
+}
+
+func TestPluginForPlatform(t *testing.T) {
+	plugin := &Plugin{
+		DownloadURL: "https://example.com/demo.tar.gz",
+		Signature:   "signature",
+		Signatures:  []*Signature{{Signature: "signature", PublicKeyHash: "hash"}},
+		Platforms: map[string]*PlatformBundle{
+			"linux-amd64": {
+				DownloadURL: "https://example.com/demo-linux-amd64.tar.gz",
+				Signatures:  []*Signature{{Signature: "linux-signature", PublicKeyHash: "hash"}},
+				Checksums:   &Checksums{SHA256: "checksum"},
+			},
+			"darwin-amd64": {
+				DownloadURL: "https://example.com/demo-darwin-amd64.tar.gz",
+			},
+		},
+	}
+
+	t.Run("platform bundle", func(t *testing.T) {
+		linuxPlugin := plugin.ForPlatform("linux-amd64")
+		require.Equal(t, "https://example.com/demo-linux-amd64.tar.gz", linuxPlugin.DownloadURL)
+		require.Equal(t, "linux-signature", linuxPlugin.Signature)
+		require.Equal(t, []*Signature{{Signature: "linux-signature", PublicKeyHash: "hash"}}, linuxPlugin.AllSignatures())
+		require.Equal(t, &Checksums{SHA256: "checksum"}, linuxPlugin.Checksums)
+		require.Equal(t, "https://example.com/demo.tar.gz", plugin.DownloadURL)
+	})
+
+	t.Run("unsigned platform bundle", func(t *testing.T) {
+		darwinPlugin := plugin.ForPlatform("darwin-amd64")
+		require.Equal(t, "https://example.com/demo-darwin-amd64.tar.gz", darwinPlugin.DownloadURL)
+		require.Empty(t, darwinPlugin.AllSignatures())
+		require.Nil(t, darwinPlugin.Checksums)
+	})
+
+	t.Run("no platform bundle", func(t *testing.T) {
+		require.Equal(t, plugin, plugin.ForPlatform("windows-amd64"))
+	})
+}
@@ -3,21 +3,174 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
+
 	mattermostModel "github.com/mattermost/mattermost-server/model"
 )
 
 // Plugin represents a Mattermost plugin in the marketplace.
 type Plugin struct {
-	HomepageURL     string `json:"homepage_url"`
-	IconData        string `json:"icon_data"`
+	HomepageURL string `json:"homepage_url"`
+	IconData    string `json:"icon_data"`
+	// IconURL references the plugin's icon hosted externally, in place of IconData.
+	IconURL         string `json:"icon_url,omitempty"`
 	DownloadURL     string `json:"download_url"`
 	ReleaseNotesURL string `json:"release_notes_url"`
+	// ReleaseNotes holds the notes published with the release, in Markdown, if recorded.
+	ReleaseNotes string `json:"release_notes,omitempty"`
+	// LongDescription describes the plugin at length, in Markdown, complementing the manifest's
+	// short description.
+	LongDescription string `json:"long_description,omitempty"`
+	// Screenshots reference images of the plugin in use, as URLs or image data URIs.
+	Screenshots []string `json:"screenshots,omitempty"`
+	// BannerImageURL references an image displayed prominently with the plugin, as a URL or an
+	// image data URI.
+	BannerImageURL string `json:"banner_image_url,omitempty"`
 	// Signature represents a signature of a plugin saved in base64 encoding.
-	Signature string                    `json:"signature"`
-	Manifest  *mattermostModel.Manifest `json:"manifest"`
-	UpdatedAt time.Time                 `json:"updated_at"`
+	//
+	// Deprecated: Signature is retained for older Mattermost servers; use Signatures instead.
+	Signature string `json:"signature"`
+	// Signatures holds the signatures of the plugin bundle, one per signing key.
+	Signatures []*Signature `json:"signatures,omitempty"`
+	// Checksums record digests of the bundle at DownloadURL.
+	Checksums *Checksums `json:"checksums,omitempty"`
+	// Platforms maps platforms, e.g. linux-amd64, to bundles built specifically for them.
+	Platforms map[string]*PlatformBundle `json:"platforms,omitempty"`
+	Labels    []Label                    `json:"labels,omitempty"`
+	// AuthorType describes who maintains the plugin, if known.
+	AuthorType AuthorType `json:"author_type,omitempty"`
+	// Maintainers identify whom to contact about the plugin, such as when it is broken or
+	// vulnerable.
+	Maintainers []*Maintainer `json:"maintainers,omitempty"`
+	// ReleaseStage describes the maturity of the release, defaulting from the manifest version
+	// when not recorded.
+	ReleaseStage ReleaseStage `json:"release_stage"`
+	// ExpiresAt, if set, records when a release not yet promoted to production lapses, after
+	// which it is no longer served, overriding the lifetime configured for pre-releases.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// HostingRequirement describes the installations supporting the plugin, if known.
+	HostingRequirement HostingRequirement `json:"hosting,omitempty"`
+	// RequiredLicense is the minimum license tier of installations offered the plugin, if any.
+	RequiredLicense LicenseTier `json:"required_license,omitempty"`
+	// Private marks a plugin whose bundles are only served re-hosted by the marketplace, through
+	// short-lived pre-signed urls, such as one released from a private repository.
+	Private bool `json:"private,omitempty"`
+	// ServerVersionRange optionally constrains the compatible server versions beyond the
+	// manifest's minimum server version, e.g. ">=5.26 <7.0".
+	ServerVersionRange string `json:"server_version_range,omitempty"`
+	// OldIDs lists the manifest ids under which the plugin was previously published, by which
+	// it remains discoverable and upgradeable.
+	OldIDs []string `json:"old_ids,omitempty"`
+	// Dependencies name the plugins that must be installed for the plugin to work.
+	Dependencies []*Dependency `json:"dependencies,omitempty"`
+	// Supersedes lists the ids of the plugins this plugin replaces, such as plugins merged into
+	// it, which unlike OldIDs remain listed under their own ids.
+	Supersedes []string `json:"supersedes,omitempty"`
+	// ConflictsWith lists the ids of the plugins that must not be installed alongside this one.
+	ConflictsWith []string `json:"conflicts_with,omitempty"`
+	// SupersededBy names the replacement of a deprecated plugin. It is populated by the server from
+	// the Supersedes of the replacement, or may be recorded in the database when the replacement
+	// is not a plugin of the catalog, such as a feature merged into the Mattermost server.
+	SupersededBy string `json:"superseded_by,omitempty"`
+	// Featured pins the plugin to the top of listings by default, as curated by the marketplace
+	// operator. Moderators may override it at runtime, in which case responses carry the override.
+	Featured bool `json:"featured,omitempty"`
+	// FeaturedWeight orders the featured plugins, heaviest first, ties being listed by name.
+	FeaturedWeight int `json:"featured_weight,omitempty"`
+	// RepositoryArchived records that the repository publishing the plugin was archived, marking
+	// the plugin as unmaintained.
+	RepositoryArchived bool `json:"repository_archived,omitempty"`
+	// Unmaintained explains why the plugin is considered unmaintained, if it is. It is populated
+	// by the server in responses and is never recorded in the database.
+	Unmaintained UnmaintainedReason        `json:"unmaintained,omitempty"`
+	Manifest     *mattermostModel.Manifest `json:"manifest"`
+	// UpdatedAt tracks the last modification of the release asset backing DownloadURL.
+	UpdatedAt time.Time `json:"updated_at"`
+	// ReleasedAt tracks when the release containing the plugin was published.
+	ReleasedAt time.Time `json:"released_at"`
+	// Provenance records how the plugin version entered the catalog, if known.
+	Provenance *Provenance `json:"provenance,omitempty"`
+	// Rating summarizes the ratings submitted for the plugin. It is populated by the server in
+	// listings and is never recorded in the database.
+	Rating *RatingSummary `json:"rating,omitempty"`
+	// Advisories disclose the vulnerabilities affecting this version of the plugin. They are
+	// populated by the server in responses and are never recorded in the database.
+	Advisories []*Advisory `json:"advisories,omitempty"`
+	// Compatibility explains why the plugin may not be installed on the requesting server. It is
+	// populated by the server only in listings including incompatible plugins, and is never
+	// recorded in the database.
+	Compatibility *Compatibility `json:"compatibility,omitempty"`
+	// Settings summarizes the settings of the manifest's settings schema, showing admins what the
+	// plugin needs configured. It is populated by the server in responses and is never recorded in
+	// the database.
+	Settings []*SettingSummary `json:"settings,omitempty"`
+	// Localization holds the display text of the plugin's labels, release stage and author type
+	// in the language requested by the client. It is populated by the server in responses and is
+	// never recorded in the database.
+	Localization *Localization `json:"localization,omitempty"`
+}
+
+// UnmarshalJSON decodes a plugin, accepting the legacy DownloadSignature field in place of
+// Signature and defaulting ReleaseStage for entries that predate it.
+func (p *Plugin) UnmarshalJSON(data []byte) error {
+	type plugin Plugin
+	// Decode the legacy field alongside the others, in a single pass over the data.
+	decoded := struct {
+		*plugin
+		DownloadSignature string
+	}{plugin: (*plugin)(p)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if p.Signature == "" {
+		p.Signature = decoded.DownloadSignature
+	}
+
+	if p.ReleaseStage == "" && p.Manifest != nil {
+		p.ReleaseStage = DefaultReleaseStage(p.Manifest.Version)
+	}
+
+	return nil
+}
+
+// AllSignatures returns the signatures of the plugin bundle, including the legacy Signature
+// field when no other signatures are recorded. Null entries, which only unvalidated plugins may
+// hold, are skipped.
+func (p *Plugin) AllSignatures() []*Signature {
+	var signatures []*Signature
+	for _, signature := range p.Signatures {
+		if signature != nil {
+			signatures = append(signatures, signature)
+		}
+	}
+	if len(signatures) > 0 {
+		return signatures
+	}
+	if p.Signature != "" {
+		return []*Signature{{Signature: p.Signature}}
+	}
+
+	return nil
+}
+
+// HasID reports whether the plugin is identified by the given id, case-insensitively, either as
+// its manifest id or one of its old ids.
+func (p *Plugin) HasID(id string) bool {
+	if p.Manifest != nil && strings.EqualFold(p.Manifest.Id, id) {
+		return true
+	}
+	for _, oldID := range p.OldIDs {
+		if strings.EqualFold(oldID, id) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // PluginFromReader decodes a json-encoded cluster from the given io.Reader.
@@ -32,23 +185,188 @@ func PluginFromReader(reader io.Reader) (*Plugin, error) {
 	return &cluster, nil
 }
 
-// PluginsFromReader decodes a json-encoded list of plugins from the given io.Reader.
+// PluginsFromReader decodes a json-encoded list of plugins from the given io.Reader, accepting
+// the canonical, compact or v2 database format.
+//
+// The canonical format is decoded a plugin at a time, so that decoding a large database needs
+// little more memory than the plugins themselves, and a malformed plugin is reported by its index
+// and offset in the stream. Plugins larger than MaxPluginSize, or nesting deeper than
+// MaxPluginDepth, are rejected. Invalid UTF-8 within strings is replaced by the Unicode
+// replacement character.
 func PluginsFromReader(reader io.Reader) ([]*Plugin, error) {
-	plugins := []*Plugin{}
 	decoder := json.NewDecoder(reader)
 
-	err := decoder.Decode(&plugins)
-	if err != nil && err != io.EOF {
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return []*Plugin{}, nil
+	} else if err != nil {
 		return nil, err
 	}
 
+	switch token {
+	case json.Delim('['):
+	case json.Delim('{'):
+		// The compact and v2 formats are objects, decoded whole.
+		var data json.RawMessage
+		objectReader := io.MultiReader(strings.NewReader("{"), decoder.Buffered(), reader)
+		if err := json.NewDecoder(objectReader).Decode(&data); err != nil {
+			return nil, err
+		}
+		if err := checkNesting(data, databaseObjectDepth+MaxPluginDepth); err != nil {
+			return nil, errors.Wrap(err, "failed to decode database")
+		}
+		return pluginsFromDatabaseObject(data)
+	case nil:
+		return []*Plugin{}, nil
+	default:
+		return nil, errors.Errorf("expected a list of plugins, found %v", token)
+	}
+
+	plugins := []*Plugin{}
+	for index := 0; decoder.More(); index++ {
+		offset := decoder.InputOffset()
+
+		var entry json.RawMessage
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode plugin %d at offset %d", index, offset)
+		}
+		if len(entry) > MaxPluginSize {
+			return nil, errors.Errorf("plugin %d at offset %d is %d bytes, exceeding the maximum of %d", index, offset, len(entry), MaxPluginSize)
+		}
+		if err := checkNesting(entry, MaxPluginDepth); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode plugin %d at offset %d", index, offset)
+		}
+
+		var plugin *Plugin
+		if err := json.Unmarshal(entry, &plugin); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode plugin %d at offset %d", index, offset)
+		}
+		plugins = append(plugins, plugin)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode plugins at offset %d", decoder.InputOffset())
+	}
+
 	return plugins, nil
 }
 
+// pluginsFromDatabaseObject decodes the given database, encoded as an object declaring its format.
+func pluginsFromDatabaseObject(data []byte) ([]*Plugin, error) {
+	if isCompactDatabase(data) {
+		return expandCompactDatabase(data)
+	}
+	if isDatabaseV2(data) {
+		return expandDatabaseV2(data)
+	}
+
+	return nil, errors.Errorf("expected a list of plugins, or a %s or %s database", CompactDatabaseFormat, DatabaseV2Format)
+}
+
+// PluginsWriterOptions describes how PluginsToWriter formats its output.
+type PluginsWriterOptions struct {
+	// Indent formats the output for humans, with each field on its own line.
+	Indent bool
+	// Compact encodes the plugins in the compact database format, storing each distinct icon once.
+	Compact bool
+	// V2 encodes the plugins in the v2 database format, storing the metadata shared by the versions
+	// of each plugin once. It takes precedence over Compact.
+	V2 bool
+}
+
+// PluginsToWriter encodes the given plugins to the given io.Writer in the canonical database
+// format: ordered by manifest id and then by descending version, so that regenerating an unchanged
+// database produces identical output. The given slice is not modified.
+func PluginsToWriter(w io.Writer, plugins []*Plugin, opts PluginsWriterOptions) error {
+	sorted := make([]*Plugin, len(plugins))
+	copy(sorted, plugins)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return pluginLess(sorted[i], sorted[j])
+	})
+
+	if opts.V2 {
+		return databaseV2ToWriter(w, sorted, opts)
+	}
+	if opts.Compact {
+		return compactPluginsToWriter(w, sorted, opts)
+	}
+
+	encoder := json.NewEncoder(w)
+	if opts.Indent {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(sorted); err != nil {
+		return errors.Wrap(err, "failed to encode plugins")
+	}
+
+	return nil
+}
+
+// pluginLess orders plugins by manifest id ascending, then by version descending.
+func pluginLess(a, b *Plugin) bool {
+	var aID, bID, aVersion, bVersion string
+	if a.Manifest != nil {
+		aID, aVersion = a.Manifest.Id, a.Manifest.Version
+	}
+	if b.Manifest != nil {
+		bID, bVersion = b.Manifest.Id, b.Manifest.Version
+	}
+
+	if aID != bID {
+		return aID < bID
+	}
+
+	aSemver, aErr := ParseVersion(aVersion)
+	bSemver, bErr := ParseVersion(bVersion)
+	if aErr == nil && bErr == nil {
+		return aSemver.GT(bSemver)
+	}
+
+	return aVersion > bVersion
+}
+
 // PluginFilter describes the parameters used to constrain a set of plugins.
 type PluginFilter struct {
 	Page          int
 	PerPage       int
 	Filter        string
 	ServerVersion string
+	AuthorType    AuthorType
+	Hosting       HostingRequirement
+	// LicenseTier excludes plugins requiring a higher license tier than the given one.
+	LicenseTier LicenseTier
+	// IncludeIncompatible also returns the latest version of plugins with no version compatible
+	// with ServerVersion, annotated with their Compatibility.
+	IncludeIncompatible bool
+	// Platform excludes plugins without a bundle installable on the given platform, e.g.
+	// linux-amd64.
+	Platform string
+	// Labels excludes plugins missing any of the given label names.
+	Labels []string
+	// Sort orders the plugins, defaulting to featured plugins first, or the most relevant first
+	// given a Filter.
+	Sort PluginSort
+}
+
+// PluginSort describes the order in which plugins are listed.
+type PluginSort string
+
+const (
+	// PluginSortDefault lists featured plugins first, or the most relevant first given a filter,
+	// and otherwise by name.
+	PluginSortDefault PluginSort = ""
+	// PluginSortName lists plugins by name.
+	PluginSortName PluginSort = "name"
+	// PluginSortReleased lists the most recently released plugins first.
+	PluginSortReleased PluginSort = "released"
+)
+
+// IsValid reports whether the sort is one of the known values.
+func (s PluginSort) IsValid() bool {
+	switch s {
+	case PluginSortDefault, PluginSortName, PluginSortReleased:
+		return true
+	default:
+		return false
+	}
 }
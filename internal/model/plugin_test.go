@@ -2,7 +2,10 @@ package model
 
 import (
 	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	mattermostModel "github.com/mattermost/mattermost-server/model"
 
@@ -38,6 +41,7 @@ func TestPluginFromReader(t *testing.T) {
 			Signature:       "signature1",
 			ReleaseNotesURL: "https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0",
 			Manifest:        &mattermostModel.Manifest{},
+			ReleaseStage:    ReleaseStageProduction,
 		}, plugin)
 	})
 }
@@ -59,6 +63,57 @@ func TestPluginsFromReader(t *testing.T) {
 		require.Nil(t, plugins)
 	})
 
+	t.Run("null", func(t *testing.T) {
+		plugins, err := PluginsFromReader(strings.NewReader(`null`))
+		require.NoError(t, err)
+		require.Equal(t, []*Plugin{}, plugins)
+	})
+
+	t.Run("not a list", func(t *testing.T) {
+		_, err := PluginsFromReader(strings.NewReader(`"plugins"`))
+		require.EqualError(t, err, "expected a list of plugins, found plugins")
+
+		_, err = PluginsFromReader(strings.NewReader(`{"plugins":[]}`))
+		require.EqualError(t, err, "expected a list of plugins, or a compact or v2 database")
+	})
+
+	t.Run("malformed plugin", func(t *testing.T) {
+		_, err := PluginsFromReader(strings.NewReader(`[{"manifest":{"id":"demo"}}, {"manifest":"starter"}]`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to decode plugin 1 at offset 27")
+	})
+
+	t.Run("deeply nested", func(t *testing.T) {
+		nested := strings.Repeat(`{"a":`, MaxPluginDepth) + `1` + strings.Repeat(`}`, MaxPluginDepth)
+		_, err := PluginsFromReader(strings.NewReader(`[{"manifest":{"id":"demo","props":` + nested + `}}]`))
+		require.EqualError(t, err, "failed to decode plugin 0 at offset 1: nesting exceeds the maximum depth of 32")
+
+		_, err = PluginsFromReader(strings.NewReader(`{"format":"v2","plugins":[{"id":"demo","versions":[{"manifest":{"id":"demo","props":` + nested + `}}]}]}`))
+		require.EqualError(t, err, "failed to decode database: nesting exceeds the maximum depth of 36")
+
+		// Brackets within strings do not nest.
+		plugins, err := PluginsFromReader(strings.NewReader(`[{"manifest":{"id":"demo","name":"` + strings.Repeat(`[{\"`, MaxPluginDepth) + `"}}]`))
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+	})
+
+	t.Run("oversized plugin", func(t *testing.T) {
+		_, err := PluginsFromReader(strings.NewReader(`[{"manifest":{"id":"demo"}},{"manifest":{"id":"demo","description":"` + strings.Repeat("a", MaxPluginSize) + `"}}]`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "plugin 1 at offset 27 is 1048619 bytes, exceeding the maximum of 1048576")
+	})
+
+	t.Run("invalid utf-8", func(t *testing.T) {
+		plugins, err := PluginsFromReader(bytes.NewReader([]byte("[{\"manifest\":{\"id\":\"demo\xff\"}}]")))
+		require.NoError(t, err)
+		require.Equal(t, "demo\ufffd", plugins[0].Manifest.Id)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := PluginsFromReader(strings.NewReader(`[{"manifest":{"id":"demo"}},`))
+		require.Error(t, err)
+	})
+
 	t.Run("request", func(t *testing.T) {
 		plugin, err := PluginsFromReader(bytes.NewReader([]byte(
 			`[{"homepage_url":"https://github.com/mattermost/mattermost-plugin-demo","icon_data":"icon-data.svg","download_url":"https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz","signature":"signature1","release_notes_url":"https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0","manifest":{}},{"homepage_url":"https://github.com/mattermost/mattermost-plugin-starter-template","icon_data":"icon-data2.svg","download_url":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz","signature":"signature2","release_notes_url":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/v0.1.0","manifest":{}}]`,
@@ -72,6 +127,7 @@ func TestPluginsFromReader(t *testing.T) {
 				Signature:       "signature1",
 				ReleaseNotesURL: "https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0",
 				Manifest:        &mattermostModel.Manifest{},
+				ReleaseStage:    ReleaseStageProduction,
 			},
 			{
 				HomepageURL:     "https://github.com/mattermost/mattermost-plugin-starter-template",
@@ -80,7 +136,139 @@ func TestPluginsFromReader(t *testing.T) {
 				Signature:       "signature2",
 				ReleaseNotesURL: "https://github.com/mattermost/mattermost-plugin-starter-template/releases/v0.1.0",
 				Manifest:        &mattermostModel.Manifest{},
+				ReleaseStage:    ReleaseStageProduction,
 			},
 		}, plugin)
 	})
 }
+
+func TestPluginLabelsRoundTrip(t *testing.T) {
+	plugins, err := PluginsFromReader(bytes.NewReader([]byte(
+		`[{"download_url":"https://example.com/demo.tar.gz","labels":[{"name":"Official","description":"Maintained by Mattermost","color":"#1e325c"},{"name":"Beta"}],"manifest":{}}]`,
+	)))
+	require.NoError(t, err)
+	require.Equal(t, []Label{
+		{Name: "Official", Description: "Maintained by Mattermost", Color: "#1e325c"},
+		{Name: "Beta"},
+	}, plugins[0].Labels)
+
+	data, err := json.Marshal(plugins)
+	require.NoError(t, err)
+
+	roundTripped, err := PluginsFromReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, plugins, roundTripped)
+}
+
+func TestPluginTimestamps(t *testing.T) {
+	plugin, err := PluginFromReader(bytes.NewReader([]byte(
+		`{"download_url":"https://example.com/demo.tar.gz","updated_at":"2019-11-02T10:00:00Z","released_at":"2019-10-30T08:30:00Z","manifest":{}}`,
+	)))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2019, 11, 2, 10, 0, 0, 0, time.UTC), plugin.UpdatedAt.UTC())
+	require.Equal(t, time.Date(2019, 10, 30, 8, 30, 0, 0, time.UTC), plugin.ReleasedAt.UTC())
+}
+
+func TestPluginProvenance(t *testing.T) {
+	plugin, err := PluginFromReader(strings.NewReader(
+		`{"download_url":"https://example.com/demo.tar.gz","manifest":{},"provenance":{"source_repository":"https://github.com/mattermost/mattermost-plugin-demo","release_tag":"v0.1.0","generator_version":"abc123","generated_at":"2019-11-02T10:00:00Z","data_commit":"def456"}}`,
+	))
+	require.NoError(t, err)
+	require.Equal(t, &Provenance{
+		SourceRepository: "https://github.com/mattermost/mattermost-plugin-demo",
+		ReleaseTag:       "v0.1.0",
+		GeneratorVersion: "abc123",
+		GeneratedAt:      time.Date(2019, 11, 2, 10, 0, 0, 0, time.UTC),
+		DataCommit:       "def456",
+	}, plugin.Provenance)
+
+	for _, options := range []PluginsWriterOptions{{}, {Compact: true}, {V2: true}} {
+		var database bytes.Buffer
+		require.NoError(t, PluginsToWriter(&database, []*Plugin{plugin}, options))
+
+		plugins, err := PluginsFromReader(&database)
+		require.NoError(t, err)
+		require.Equal(t, plugin.Provenance, plugins[0].Provenance)
+	}
+}
+
+func TestPluginsToWriter(t *testing.T) {
+	plugins := []*Plugin{
+		{Manifest: &mattermostModel.Manifest{Id: "starter", Version: "0.1.0"}, ReleaseStage: ReleaseStageProduction},
+		{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "0.9.0"}, ReleaseStage: ReleaseStageProduction},
+		{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "0.10.0"}, ReleaseStage: ReleaseStageProduction},
+	}
+
+	t.Run("stable ordering", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, PluginsToWriter(&buf, plugins, PluginsWriterOptions{}))
+
+		written, err := PluginsFromReader(&buf)
+		require.NoError(t, err)
+		require.Equal(t, []*Plugin{plugins[2], plugins[1], plugins[0]}, written)
+
+		// The given slice is left untouched.
+		require.Equal(t, "starter", plugins[0].Manifest.Id)
+	})
+
+	t.Run("identical output regardless of input order", func(t *testing.T) {
+		var first, second bytes.Buffer
+		require.NoError(t, PluginsToWriter(&first, plugins, PluginsWriterOptions{}))
+		require.NoError(t, PluginsToWriter(&second, []*Plugin{plugins[1], plugins[0], plugins[2]}, PluginsWriterOptions{}))
+		require.Equal(t, first.String(), second.String())
+	})
+
+	t.Run("indent", func(t *testing.T) {
+		var compact, indented bytes.Buffer
+		require.NoError(t, PluginsToWriter(&compact, plugins, PluginsWriterOptions{}))
+		require.NoError(t, PluginsToWriter(&indented, plugins, PluginsWriterOptions{Indent: true}))
+		require.NotContains(t, compact.String(), "\n  ")
+		require.Contains(t, indented.String(), "\n  ")
+
+		var expected bytes.Buffer
+		require.NoError(t, json.Compact(&expected, indented.Bytes()))
+		require.Equal(t, compact.String(), expected.String()+"\n")
+	})
+}
+
+func TestPluginHasID(t *testing.T) {
+	plugin := &Plugin{
+		Manifest: &mattermostModel.Manifest{Id: "com.mattermost.demo"},
+		OldIDs:   []string{"com.example.demo"},
+	}
+
+	require.True(t, plugin.HasID("com.mattermost.demo"))
+	require.True(t, plugin.HasID("COM.MATTERMOST.DEMO"))
+	require.True(t, plugin.HasID("Com.Example.Demo"))
+	require.False(t, plugin.HasID("com.mattermost"))
+	require.False(t, (&Plugin{}).HasID(""))
+}
+
+func FuzzPluginsFromReader(f *testing.F) {
+	f.Add([]byte(``))
+	f.Add([]byte(`[{"homepage_url":"https://example.com/demo","download_url":"https://example.com/demo-0.1.0.tar.gz","manifest":{"id":"demo","version":"0.1.0","settings_schema":{"settings":[{"key":"a"}]}}}]`))
+	f.Add([]byte(`[{"manifest":{"id":"demo\xff","name":"\ud800"}},null]`))
+	f.Add([]byte(`{"format":"compact","icons":{"abc":"data:image/svg+xml;base64,PHN2Zy8+"},"plugins":[{"icon_data":"sha256:abc","manifest":{"id":"demo"}}]}`))
+	f.Add([]byte(`{"format":"v2","plugins":[{"id":"demo","name":"Demo","versions":[{"manifest":{"id":"demo","version":"0.1.0"}}]}]}`))
+	f.Add([]byte(`[` + strings.Repeat(`[`, 100) + strings.Repeat(`]`, 100) + `]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		plugins, err := PluginsFromReader(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+
+		// Whatever decodes must encode and decode again to the same number of plugins.
+		var buffer bytes.Buffer
+		if err := json.NewEncoder(&buffer).Encode(plugins); err != nil {
+			t.Fatalf("failed to encode decoded plugins: %v", err)
+		}
+		decoded, err := PluginsFromReader(&buffer)
+		if err != nil {
+			t.Fatalf("failed to decode encoded plugins: %v", err)
+		}
+		if len(decoded) != len(plugins) {
+			t.Fatalf("decoded %d plugins, then %d", len(plugins), len(decoded))
+		}
+	})
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Provenance records how a plugin version entered the catalog, supporting audits of the supply
+// chain of each bundle served.
+type Provenance struct {
+	// SourceRepository is the url of the repository whose release published the bundle.
+	SourceRepository string `json:"source_repository,omitempty"`
+	// ReleaseTag is the tag of the release publishing the bundle.
+	ReleaseTag string `json:"release_tag,omitempty"`
+	// GeneratorVersion identifies the build of the generator that first recorded the version.
+	GeneratorVersion string `json:"generator_version,omitempty"`
+	// GeneratedAt is when the generator first recorded the version.
+	GeneratedAt time.Time `json:"generated_at"`
+	// DataCommit is the commit of the data repository, holding the generator's configuration,
+	// from which the version was generated.
+	DataCommit string `json:"data_commit,omitempty"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// PublicKey describes a key whose signatures the marketplace publishes, allowing clients to
+// obtain the keys verifying bundle and catalog signatures from the marketplace itself.
+type PublicKey struct {
+	// PublicKeyHash identifies the key, matching Signature.PublicKeyHash.
+	PublicKeyHash string `json:"public_key_hash"`
+	// Fingerprint is the hex-encoded OpenPGP fingerprint of the key, against which clients may
+	// pin it.
+	Fingerprint string `json:"fingerprint"`
+	// CreatedAt is the time from which the key is valid.
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is the time from which the key is no longer valid, if it expires.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Revoked is set if the key has been revoked.
+	Revoked bool `json:"revoked,omitempty"`
+	// PublicKey is the armored OpenPGP public key.
+	PublicKey string `json:"public_key"`
+}
+
+// IsValidAt reports whether the key was valid at the given time, i.e. created by then, not yet
+// expired and not revoked.
+func (k *PublicKey) IsValidAt(now time.Time) bool {
+	if k.Revoked || now.Before(k.CreatedAt) {
+		return false
+	}
+
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// PublicKeysFromReader decodes a json-encoded list of public keys from the given io.Reader.
+func PublicKeysFromReader(reader io.Reader) ([]*PublicKey, error) {
+	publicKeys := []*PublicKey{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&publicKeys)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return publicKeys, nil
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicKeyIsValidAt(t *testing.T) {
+	now := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+	createdAt := now.Add(-time.Hour)
+	expiresAt := now.Add(time.Minute)
+	expiredAt := now.Add(-time.Minute)
+
+	testCases := []struct {
+		Description string
+		Key         PublicKey
+		Expected    bool
+	}{
+		{"without expiry", PublicKey{CreatedAt: createdAt}, true},
+		{"unexpired", PublicKey{CreatedAt: createdAt, ExpiresAt: &expiresAt}, true},
+		{"expired", PublicKey{CreatedAt: createdAt, ExpiresAt: &expiredAt}, false},
+		{"not yet created", PublicKey{CreatedAt: now.Add(time.Hour)}, false},
+		{"revoked", PublicKey{CreatedAt: createdAt, Revoked: true}, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			require.Equal(t, testCase.Expected, testCase.Key.IsValidAt(now))
+		})
+	}
+}
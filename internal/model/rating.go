@@ -0,0 +1,97 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MinRatingStars is the lowest star rating a plugin may be given.
+	MinRatingStars = 1
+	// MaxRatingStars is the highest star rating a plugin may be given.
+	MaxRatingStars = 5
+	// MaxReviewLength is the maximum length, in characters, of a review accompanying a rating.
+	MaxReviewLength = 500
+)
+
+// Rating is a single user's star rating of a plugin, optionally accompanied by a short review.
+type Rating struct {
+	PluginID  string    `json:"plugin_id"`
+	UserID    string    `json:"user_id"`
+	Stars     int       `json:"stars"`
+	Review    string    `json:"review,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsValid verifies the rating is well-formed.
+func (r *Rating) IsValid() error {
+	if r.PluginID == "" {
+		return errors.New("plugin id is empty")
+	}
+	if r.UserID == "" {
+		return errors.New("user id is empty")
+	}
+	if r.Stars < MinRatingStars || r.Stars > MaxRatingStars {
+		return errors.Errorf("stars must be between %d and %d", MinRatingStars, MaxRatingStars)
+	}
+	if utf8.RuneCountInString(r.Review) > MaxReviewLength {
+		return errors.Errorf("review exceeds %d characters", MaxReviewLength)
+	}
+
+	return nil
+}
+
+// RatingSummary aggregates the ratings of a single plugin.
+type RatingSummary struct {
+	// Average is the mean star rating, or zero if the plugin has not been rated.
+	Average float64 `json:"average"`
+	Count   int64   `json:"count"`
+}
+
+// PluginRatings describes the ratings and reviews of a single plugin.
+type PluginRatings struct {
+	PluginID string         `json:"plugin_id"`
+	Summary  *RatingSummary `json:"summary"`
+	// Reviews holds the ratings accompanied by a review, most recently updated first.
+	Reviews []*Rating `json:"reviews"`
+}
+
+// RatingFromReader decodes a json-encoded Rating from the given io.Reader.
+func RatingFromReader(reader io.Reader) (*Rating, error) {
+	rating := Rating{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&rating)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &rating, nil
+}
+
+// RatingSummaryFromReader decodes a json-encoded RatingSummary from the given io.Reader.
+func RatingSummaryFromReader(reader io.Reader) (*RatingSummary, error) {
+	summary := RatingSummary{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&summary)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+// PluginRatingsFromReader decodes a json-encoded PluginRatings from the given io.Reader.
+func PluginRatingsFromReader(reader io.Reader) (*PluginRatings, error) {
+	ratings := PluginRatings{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&ratings)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &ratings, nil
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatingIsValid(t *testing.T) {
+	validRating := func() *Rating {
+		return &Rating{
+			PluginID: "jira",
+			UserID:   "alice",
+			Stars:    4,
+			Review:   "Works well.",
+		}
+	}
+
+	require.NoError(t, validRating().IsValid())
+
+	testCases := []struct {
+		Description   string
+		Modify        func(rating *Rating)
+		ExpectedError string
+	}{
+		{"no plugin id", func(rating *Rating) { rating.PluginID = "" }, "plugin id is empty"},
+		{"no user id", func(rating *Rating) { rating.UserID = "" }, "user id is empty"},
+		{"too few stars", func(rating *Rating) { rating.Stars = 0 }, "stars must be between 1 and 5"},
+		{"too many stars", func(rating *Rating) { rating.Stars = 6 }, "stars must be between 1 and 5"},
+		{"review too long", func(rating *Rating) { rating.Review = strings.Repeat("a", MaxReviewLength+1) }, "review exceeds 500 characters"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			rating := validRating()
+			testCase.Modify(rating)
+			require.EqualError(t, rating.IsValid(), testCase.ExpectedError)
+		})
+	}
+
+	t.Run("review measured in characters", func(t *testing.T) {
+		rating := validRating()
+		rating.Review = strings.Repeat("é", MaxReviewLength)
+		require.NoError(t, rating.IsValid())
+	})
+}
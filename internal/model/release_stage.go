@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// ReleaseStage describes the maturity of a plugin release.
+type ReleaseStage string
+
+const (
+	// ReleaseStageProduction identifies releases ready for general use.
+	ReleaseStageProduction ReleaseStage = "production"
+	// ReleaseStageBeta identifies releases made available for early feedback.
+	ReleaseStageBeta ReleaseStage = "beta"
+	// ReleaseStageExperimental identifies releases that may change or break without notice.
+	ReleaseStageExperimental ReleaseStage = "experimental"
+)
+
+// IsValid reports whether the release stage is one of the known values.
+func (s ReleaseStage) IsValid() bool {
+	switch s {
+	case ReleaseStageProduction, ReleaseStageBeta, ReleaseStageExperimental:
+		return true
+	default:
+		return false
+	}
+}
+
+// DefaultReleaseStage infers the release stage of entries recorded before the stage was tracked:
+// prerelease versions are considered beta, and everything else production.
+func DefaultReleaseStage(version string) ReleaseStage {
+	if v, err := ParseVersion(version); err == nil && len(v.Pre) > 0 {
+		return ReleaseStageBeta
+	}
+
+	return ReleaseStageProduction
+}
+
+// Expiry returns when the plugin lapses, after which it is no longer served, or the zero time if
+// it never does. Production releases never lapse, so that promoting a pre-release to production
+// retains it. Other releases lapse at their recorded ExpiresAt or, failing that, preReleaseTTL
+// after their release, if positive.
+func (p *Plugin) Expiry(preReleaseTTL time.Duration) time.Time {
+	stage := p.ReleaseStage
+	if stage == "" && p.Manifest != nil {
+		stage = DefaultReleaseStage(p.Manifest.Version)
+	}
+	if stage == ReleaseStageProduction {
+		return time.Time{}
+	}
+
+	if p.ExpiresAt != nil {
+		return *p.ExpiresAt
+	}
+	if preReleaseTTL > 0 && !p.ReleasedAt.IsZero() {
+		return p.ReleasedAt.Add(preReleaseTTL)
+	}
+
+	return time.Time{}
+}
+
+// IsExpired reports whether the plugin lapsed by the given time, as described by Expiry.
+func (p *Plugin) IsExpired(preReleaseTTL time.Duration, now time.Time) bool {
+	expiry := p.Expiry(preReleaseTTL)
+
+	return !expiry.IsZero() && !now.Before(expiry)
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseStageIsValid(t *testing.T) {
+	require.True(t, ReleaseStageProduction.IsValid())
+	require.True(t, ReleaseStageBeta.IsValid())
+	require.True(t, ReleaseStageExperimental.IsValid())
+	require.False(t, ReleaseStage("").IsValid())
+	require.False(t, ReleaseStage("alpha").IsValid())
+}
+
+func TestPluginReleaseStage(t *testing.T) {
+	testCases := []struct {
+		Description string
+		Data        string
+		Expected    ReleaseStage
+	}{
+		{"recorded", `{"release_stage":"experimental","manifest":{"version":"1.0.0"}}`, ReleaseStageExperimental},
+		{"default for release", `{"manifest":{"version":"1.0.0"}}`, ReleaseStageProduction},
+		{"default for prerelease", `{"manifest":{"version":"1.0.0-rc1"}}`, ReleaseStageBeta},
+		{"default for invalid version", `{"manifest":{"version":"invalid"}}`, ReleaseStageProduction},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			plugin, err := PluginFromReader(bytes.NewReader([]byte(testCase.Data)))
+			require.NoError(t, err)
+			require.Equal(t, testCase.Expected, plugin.ReleaseStage)
+		})
+	}
+}
+
+func TestPluginExpiry(t *testing.T) {
+	releasedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := releasedAt.Add(24 * time.Hour)
+	ttl := 30 * 24 * time.Hour
+
+	testCases := []struct {
+		Description string
+		Plugin      *Plugin
+		Expected    time.Time
+	}{
+		{"production", &Plugin{ReleaseStage: ReleaseStageProduction, ReleasedAt: releasedAt}, time.Time{}},
+		{"promoted", &Plugin{ReleaseStage: ReleaseStageProduction, ReleasedAt: releasedAt, ExpiresAt: &expiresAt}, time.Time{}},
+		{"beta", &Plugin{ReleaseStage: ReleaseStageBeta, ReleasedAt: releasedAt}, releasedAt.Add(ttl)},
+		{"experimental", &Plugin{ReleaseStage: ReleaseStageExperimental, ReleasedAt: releasedAt}, releasedAt.Add(ttl)},
+		{"recorded expiry", &Plugin{ReleaseStage: ReleaseStageBeta, ReleasedAt: releasedAt, ExpiresAt: &expiresAt}, expiresAt},
+		{"no release time", &Plugin{ReleaseStage: ReleaseStageBeta}, time.Time{}},
+		{"default stage", &Plugin{ReleasedAt: releasedAt, Manifest: &mattermostModel.Manifest{Version: "1.0.0-rc1"}}, releasedAt.Add(ttl)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			require.Equal(t, testCase.Expected, testCase.Plugin.Expiry(ttl))
+			if !testCase.Expected.IsZero() {
+				require.False(t, testCase.Plugin.IsExpired(ttl, testCase.Expected.Add(-time.Second)))
+				require.True(t, testCase.Plugin.IsExpired(ttl, testCase.Expected))
+			}
+		})
+	}
+
+	t.Run("without a lifetime", func(t *testing.T) {
+		plugin := &Plugin{ReleaseStage: ReleaseStageBeta, ReleasedAt: releasedAt}
+		require.True(t, plugin.Expiry(0).IsZero())
+		require.False(t, plugin.IsExpired(0, releasedAt.AddDate(10, 0, 0)))
+	})
+}
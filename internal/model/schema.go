@@ -0,0 +1,211 @@
+package model
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// PluginsSchema is the JSON Schema describing the plugins.json database format.
+//
+// The schema must be kept in sync with Plugin and the types it references.
+const PluginsSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "Mattermost Marketplace plugins database",
+  "type": "array",
+  "items": { "$ref": "#/definitions/plugin" },
+  "definitions": {
+    "plugin": {
+      "type": "object",
+      "required": ["manifest"],
+      "additionalProperties": false,
+      "properties": {
+        "homepage_url": { "type": "string" },
+        "icon_data": { "type": "string", "pattern": "^(data:image/(svg\\+xml|png|jpeg|gif);base64,.*)?$" },
+        "icon_url": { "type": "string", "pattern": "^https?://" },
+        "download_url": { "type": "string" },
+        "release_notes_url": { "type": "string" },
+        "release_notes": { "type": "string" },
+        "long_description": { "type": "string" },
+        "screenshots": { "type": "array", "items": { "type": "string", "minLength": 1 } },
+        "banner_image_url": { "type": "string" },
+        "signature": { "type": "string" },
+        "DownloadSignature": { "type": "string", "description": "Deprecated: use signature." },
+        "signatures": { "$ref": "#/definitions/signatures" },
+        "checksums": { "$ref": "#/definitions/checksums" },
+        "platforms": {
+          "type": "object",
+          "propertyNames": { "pattern": "^(linux|darwin|windows|freebsd)-[a-z0-9]+$" },
+          "additionalProperties": { "$ref": "#/definitions/platformBundle" }
+        },
+        "labels": { "type": "array", "items": { "$ref": "#/definitions/label" } },
+        "author_type": { "enum": ["mattermost", "partner", "community"] },
+        "release_stage": { "enum": ["production", "beta", "experimental"] },
+        "expires_at": { "type": "string", "format": "date-time" },
+        "hosting": { "enum": ["on-prem", "cloud", "both"] },
+        "required_license": { "enum": ["team", "professional", "enterprise"] },
+        "private": { "type": "boolean" },
+        "server_version_range": { "type": "string", "minLength": 1 },
+        "old_ids": { "type": "array", "items": { "type": "string", "minLength": 1 } },
+        "dependencies": { "type": "array", "items": { "$ref": "#/definitions/dependency" } },
+        "maintainers": { "type": "array", "items": { "$ref": "#/definitions/maintainer" } },
+        "supersedes": { "type": "array", "items": { "type": "string", "minLength": 1 } },
+        "conflicts_with": { "type": "array", "items": { "type": "string", "minLength": 1 } },
+        "superseded_by": { "type": "string", "minLength": 1 },
+        "featured": { "type": "boolean" },
+        "featured_weight": { "type": "integer" },
+        "repository_archived": { "type": "boolean" },
+        "manifest": { "$ref": "#/definitions/manifest" },
+        "updated_at": { "type": "string", "format": "date-time" },
+        "released_at": { "type": "string", "format": "date-time" },
+        "provenance": { "$ref": "#/definitions/provenance" }
+      }
+    },
+    "manifest": {
+      "type": "object",
+      "required": ["id", "version"],
+      "properties": {
+        "id": { "type": "string", "minLength": 1 },
+        "name": { "type": "string" },
+        "description": { "type": "string" },
+        "version": { "type": "string", "minLength": 1 },
+        "min_server_version": { "type": "string" }
+      }
+    },
+    "signatures": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["signature", "public_key_hash"],
+        "additionalProperties": false,
+        "properties": {
+          "signature": { "type": "string", "minLength": 1 },
+          "public_key_hash": { "type": "string", "minLength": 1 }
+        }
+      }
+    },
+    "checksums": {
+      "type": "object",
+      "additionalProperties": false,
+      "minProperties": 1,
+      "properties": {
+        "sha256": { "type": "string", "pattern": "^[0-9a-fA-F]{64}$" },
+        "sha512": { "type": "string", "pattern": "^[0-9a-fA-F]{128}$" }
+      }
+    },
+    "platformBundle": {
+      "type": "object",
+      "required": ["download_url"],
+      "additionalProperties": false,
+      "properties": {
+        "download_url": { "type": "string", "minLength": 1 },
+        "signatures": { "$ref": "#/definitions/signatures" },
+        "checksums": { "$ref": "#/definitions/checksums" }
+      }
+    },
+    "provenance": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "source_repository": { "type": "string" },
+        "release_tag": { "type": "string" },
+        "generator_version": { "type": "string" },
+        "generated_at": { "type": "string", "format": "date-time" },
+        "data_commit": { "type": "string" }
+      }
+    },
+    "dependency": {
+      "type": "object",
+      "required": ["plugin_id"],
+      "additionalProperties": false,
+      "properties": {
+        "plugin_id": { "type": "string", "minLength": 1 },
+        "min_version": { "type": "string", "minLength": 1 }
+      }
+    },
+    "maintainer": {
+      "type": "object",
+      "required": ["name"],
+      "additionalProperties": false,
+      "properties": {
+        "name": { "type": "string", "minLength": 1 },
+        "email": { "type": "string", "minLength": 1 },
+        "url": { "type": "string", "pattern": "^https?://" }
+      }
+    },
+    "label": {
+      "type": "object",
+      "required": ["name"],
+      "additionalProperties": false,
+      "properties": {
+        "name": { "type": "string", "minLength": 1 },
+        "description": { "type": "string" },
+        "color": { "type": "string", "pattern": "^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$" }
+      }
+    }
+  }
+}`
+
+// pluginsSchema is the compiled form of PluginsSchema.
+var pluginsSchema = mustLoadPluginsSchema()
+
+func mustLoadPluginsSchema() *gojsonschema.Schema {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(PluginsSchema))
+	if err != nil {
+		panic(errors.Wrap(err, "failed to load plugins schema"))
+	}
+
+	return schema
+}
+
+// SchemaError describes how a document fails to match PluginsSchema.
+type SchemaError struct {
+	// Errors describes each violation, prefixed by the path to the offending field.
+	Errors []string
+}
+
+func (e *SchemaError) Error() string {
+	return "does not match schema: " + strings.Join(e.Errors, "; ")
+}
+
+// ValidateAgainstSchema verifies the json-encoded plugins database read from the given io.Reader
+// matches PluginsSchema, returning a *SchemaError describing any violations.
+func ValidateAgainstSchema(reader io.Reader) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to read plugins")
+	}
+
+	// Validate a compact or v2 database as the flat plugins it expands to.
+	if isCompactDatabase(data) || isDatabaseV2(data) {
+		plugins, err := PluginsFromReader(bytes.NewReader(data))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s database", databaseFormat(data))
+		}
+
+		var expanded bytes.Buffer
+		if err := PluginsToWriter(&expanded, plugins, PluginsWriterOptions{}); err != nil {
+			return err
+		}
+		data = expanded.Bytes()
+	}
+
+	result, err := pluginsSchema.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return errors.Wrap(err, "failed to validate plugins")
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	schemaError := &SchemaError{}
+	for _, resultError := range result.Errors() {
+		schemaError.Errors = append(schemaError.Errors, resultError.String())
+	}
+
+	return schemaError
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAgainstSchema(t *testing.T) {
+	t.Run("generated database", func(t *testing.T) {
+		plugins := []*Plugin{
+			{
+				DownloadURL: "https://example.com/demo-0.1.0.tar.gz",
+				IconData:    "data:image/svg+xml;base64,PHN2Zy8+",
+				IconURL:     "https://cdn.example.com/icons/demo.svg",
+				Signatures:  []*Signature{{Signature: "signature1", PublicKeyHash: "hash1"}},
+				Checksums:   &Checksums{SHA256: strings.Repeat("a", 64)},
+				Platforms: map[string]*PlatformBundle{
+					"linux-amd64": {DownloadURL: "https://example.com/demo-0.1.0-linux-amd64.tar.gz"},
+				},
+				Labels:             []Label{{Name: "Official", Color: "#1e325c"}},
+				AuthorType:         AuthorTypeMattermost,
+				ReleaseStage:       ReleaseStageProduction,
+				HostingRequirement: HostingBoth,
+				RequiredLicense:    LicenseTierEnterprise,
+				ServerVersionRange: ">=5.20",
+				OldIDs:             []string{"com.example.demo"},
+				Dependencies:       []*Dependency{{PluginID: "jira", MinVersion: "3.0.0"}},
+				Maintainers:        []*Maintainer{{Name: "Jane", Email: "jane@example.com", URL: "https://example.com/issues"}},
+				Supersedes:         []string{"legacy-demo"},
+				ConflictsWith:      []string{"other-demo"},
+				SupersededBy:       "mattermost-server",
+				Featured:           true,
+				FeaturedWeight:     10,
+				RepositoryArchived: true,
+				Manifest:           &mattermostModel.Manifest{Id: "demo", Version: "0.1.0"},
+				Provenance: &Provenance{
+					SourceRepository: "https://github.com/example/demo",
+					ReleaseTag:       "v0.1.0",
+					GeneratorVersion: "0123456789ab",
+					GeneratedAt:      time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+					DataCommit:       "fedcba9876543210",
+				},
+			},
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, PluginsToWriter(&buf, plugins, PluginsWriterOptions{}))
+		require.NoError(t, ValidateAgainstSchema(&buf))
+	})
+
+	t.Run("legacy signature", func(t *testing.T) {
+		require.NoError(t, ValidateAgainstSchema(strings.NewReader(
+			`[{"DownloadSignature":"signature1","manifest":{"id":"demo","version":"0.1.0"}}]`,
+		)))
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		err := ValidateAgainstSchema(strings.NewReader(`[{`))
+		require.Error(t, err)
+		_, ok := err.(*SchemaError)
+		require.False(t, ok)
+	})
+
+	testCases := []struct {
+		Description string
+		Data        string
+		Expected    []string
+	}{
+		{
+			"not a list",
+			`{}`,
+			[]string{"(root): Invalid type. Expected: array, given: object"},
+		},
+		{
+			"missing manifest",
+			`[{"download_url":"https://example.com/demo.tar.gz"}]`,
+			[]string{"0: manifest is required"},
+		},
+		{
+			"missing manifest version",
+			`[{"manifest":{"id":"demo"}}]`,
+			[]string{"0.manifest: version is required"},
+		},
+		{
+			"unknown field",
+			`[{"downloadurl":"https://example.com/demo.tar.gz","manifest":{"id":"demo","version":"0.1.0"}}]`,
+			[]string{"0: Additional property downloadurl is not allowed"},
+		},
+		{
+			"invalid author type",
+			`[{"author_type":"unknown","manifest":{"id":"demo","version":"0.1.0"}}]`,
+			[]string{`0.author_type: 0.author_type must be one of the following: "mattermost", "partner", "community"`},
+		},
+		{
+			"invalid icon",
+			`[{"icon_data":"icon.svg","manifest":{"id":"demo","version":"0.1.0"}}]`,
+			[]string{"0.icon_data: Does not match pattern"},
+		},
+		{
+			"invalid checksum",
+			`[{"checksums":{"sha256":"abc"},"manifest":{"id":"demo","version":"0.1.0"}}]`,
+			[]string{"0.checksums.sha256: Does not match pattern '^[0-9a-fA-F]{64}$'"},
+		},
+		{
+			"invalid platform",
+			`[{"platforms":{"amiga":{"download_url":"https://example.com/demo.tar.gz"}},"manifest":{"id":"demo","version":"0.1.0"}}]`,
+			[]string{`0.platforms: Property name of "amiga" does not match`},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			err := ValidateAgainstSchema(strings.NewReader(testCase.Data))
+			require.Error(t, err)
+
+			schemaErr, ok := err.(*SchemaError)
+			require.True(t, ok)
+			for _, expected := range testCase.Expected {
+				require.Contains(t, strings.Join(schemaErr.Errors, "\n"), expected)
+			}
+		})
+	}
+}
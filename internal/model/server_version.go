@@ -0,0 +1,97 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// partialVersionRegexp matches versions omitting the minor or patch number, e.g. 5.26.
+var partialVersionRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// ParseServerVersionRange parses a range of compatible server versions, e.g. ">=5.26 <7.0".
+//
+// Ranges follow github.com/blang/semver, except that versions may omit their minor or patch
+// numbers, which default to zero.
+func ParseServerVersionRange(versionRange string) (semver.Range, error) {
+	fields := strings.Fields(versionRange)
+	for i, field := range fields {
+		version := strings.TrimLeft(field, "<>=!")
+		if partialVersionRegexp.MatchString(version) {
+			fields[i] = field + strings.Repeat(".0", 2-strings.Count(version, "."))
+		}
+	}
+
+	parsedRange, err := semver.ParseRange(strings.Join(fields, " "))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse server version range %s", versionRange)
+	}
+
+	return parsedRange, nil
+}
+
+// Compatibility explains why a plugin may not be installed on a server.
+type Compatibility struct {
+	// Reason identifies the constraint the server fails to meet.
+	Reason CompatibilityReason `json:"reason"`
+	// RequiredServerVersion is the minimum server version, or the range of server versions,
+	// required by the plugin.
+	RequiredServerVersion string `json:"required_server_version"`
+}
+
+// CompatibilityReason identifies why a plugin is incompatible with a server.
+type CompatibilityReason string
+
+const (
+	// CompatibilityReasonMinServerVersion signals the server predates the manifest's minimum
+	// server version.
+	CompatibilityReasonMinServerVersion CompatibilityReason = "min_server_version"
+	// CompatibilityReasonServerVersionRange signals the server is outside the plugin's
+	// ServerVersionRange.
+	CompatibilityReasonServerVersionRange CompatibilityReason = "server_version_range"
+)
+
+// MeetsServerVersion reports whether the plugin may be installed on the given server version,
+// checking both the manifest's minimum server version and ServerVersionRange.
+func (p *Plugin) MeetsServerVersion(serverVersion string) (bool, error) {
+	incompatibility, err := p.ServerVersionIncompatibility(serverVersion)
+	if err != nil {
+		return false, err
+	}
+
+	return incompatibility == nil, nil
+}
+
+// ServerVersionIncompatibility explains why the plugin may not be installed on the given server
+// version, returning nil if it may.
+func (p *Plugin) ServerVersionIncompatibility(serverVersion string) (*Compatibility, error) {
+	if p.Manifest.MinServerVersion != "" {
+		meetsMinServerVersion, err := p.Manifest.MeetMinServerVersion(serverVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check minServerVersion for manifest.Id %s", p.Manifest.Id)
+		}
+		if !meetsMinServerVersion {
+			return &Compatibility{Reason: CompatibilityReasonMinServerVersion, RequiredServerVersion: p.Manifest.MinServerVersion}, nil
+		}
+	}
+
+	if p.ServerVersionRange != "" {
+		versionRange, err := ParseServerVersionRange(p.ServerVersionRange)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check server version range for manifest.Id %s", p.Manifest.Id)
+		}
+
+		version, err := semver.Parse(serverVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse server version %s", serverVersion)
+		}
+
+		if !versionRange(version) {
+			return &Compatibility{Reason: CompatibilityReasonServerVersionRange, RequiredServerVersion: p.ServerVersionRange}, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerVersionRange(t *testing.T) {
+	testCases := []struct {
+		Range      string
+		Matches    []string
+		NotMatches []string
+	}{
+		{">=5.26 <7.0", []string{"5.26.0", "6.3.1"}, []string{"5.25.9", "7.0.0"}},
+		{">=5.26.1", []string{"5.26.1", "7.0.0"}, []string{"5.26.0"}},
+		{"<5 || >=6.1", []string{"4.10.0", "6.1.0"}, []string{"5.0.0", "6.0.3"}},
+		{">= 5.26", []string{"5.26.0"}, []string{"5.25.0"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Range, func(t *testing.T) {
+			versionRange, err := ParseServerVersionRange(testCase.Range)
+			require.NoError(t, err)
+
+			for _, version := range testCase.Matches {
+				require.True(t, versionRange(semver.MustParse(version)), version)
+			}
+			for _, version := range testCase.NotMatches {
+				require.False(t, versionRange(semver.MustParse(version)), version)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseServerVersionRange(">=five")
+		require.Error(t, err)
+
+		_, err = ParseServerVersionRange("")
+		require.Error(t, err)
+	})
+}
+
+func TestPluginMeetsServerVersion(t *testing.T) {
+	plugin := &Plugin{
+		ServerVersionRange: ">=5.20 <6.0",
+		Manifest:           &mattermostModel.Manifest{Id: "demo", Version: "0.1.0", MinServerVersion: "5.22.0"},
+	}
+
+	for version, expected := range map[string]bool{
+		"5.20.0": false,
+		"5.22.0": true,
+		"5.99.0": true,
+		"6.0.0":  false,
+	} {
+		meets, err := plugin.MeetsServerVersion(version)
+		require.NoError(t, err)
+		require.Equal(t, expected, meets, version)
+	}
+
+	t.Run("no constraints", func(t *testing.T) {
+		meets, err := (&Plugin{Manifest: &mattermostModel.Manifest{}}).MeetsServerVersion("5.0.0")
+		require.NoError(t, err)
+		require.True(t, meets)
+	})
+
+	t.Run("invalid server version", func(t *testing.T) {
+		_, err := (&Plugin{ServerVersionRange: ">=5.20", Manifest: &mattermostModel.Manifest{}}).MeetsServerVersion("invalid")
+		require.Error(t, err)
+	})
+}
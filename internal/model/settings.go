@@ -0,0 +1,55 @@
+package model
+
+import (
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+)
+
+// settingTypesWithoutInput are the setting types that need no value from admins, booleans
+// defaulting to false and generated settings being filled in on installation.
+var settingTypesWithoutInput = map[string]bool{
+	"bool":      true,
+	"generated": true,
+}
+
+// SettingSummary summarizes a setting of a plugin's settings schema, showing admins what a plugin
+// needs configured before they install it.
+type SettingSummary struct {
+	Key         string `json:"key"`
+	DisplayName string `json:"display_name,omitempty"`
+	// Type is the manifest's setting type, e.g. text, bool or dropdown.
+	Type string `json:"type"`
+	// Required reports whether admins must configure the setting, it having no default value.
+	Required bool `json:"required"`
+	// Options lists the values admins may choose from, for radio and dropdown settings.
+	Options []string `json:"options,omitempty"`
+}
+
+// SummarizeSettings summarizes the settings of the given manifest's settings schema, ignoring
+// those without a key, or returns nil if it has none.
+func SummarizeSettings(manifest *mattermostModel.Manifest) []*SettingSummary {
+	if manifest == nil || manifest.SettingsSchema == nil {
+		return nil
+	}
+
+	var summaries []*SettingSummary
+	for _, setting := range manifest.SettingsSchema.Settings {
+		if setting == nil || setting.Key == "" {
+			continue
+		}
+
+		summary := &SettingSummary{
+			Key:         setting.Key,
+			DisplayName: setting.DisplayName,
+			Type:        setting.Type,
+			Required:    !settingTypesWithoutInput[setting.Type] && (setting.Default == nil || setting.Default == ""),
+		}
+		for _, option := range setting.Options {
+			if option != nil {
+				summary.Options = append(summary.Options, option.Value)
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
+}
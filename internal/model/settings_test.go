@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeSettings(t *testing.T) {
+	t.Run("no schema", func(t *testing.T) {
+		require.Nil(t, SummarizeSettings(nil))
+		require.Nil(t, SummarizeSettings(&mattermostModel.Manifest{Id: "demo"}))
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		manifest := &mattermostModel.Manifest{
+			Id: "demo",
+			SettingsSchema: &mattermostModel.PluginSettingsSchema{
+				Settings: []*mattermostModel.PluginSetting{
+					{Key: "URL", DisplayName: "Server URL", Type: "text"},
+					{Key: "Enabled", Type: "bool"},
+					{Key: "Secret", Type: "generated"},
+					{Key: "Mode", Type: "dropdown", Default: "fast", Options: []*mattermostModel.PluginOption{
+						{DisplayName: "Fast", Value: "fast"},
+						{DisplayName: "Thorough", Value: "thorough"},
+					}},
+					{Type: "custom"},
+				},
+			},
+		}
+
+		require.Equal(t, []*SettingSummary{
+			{Key: "URL", DisplayName: "Server URL", Type: "text", Required: true},
+			{Key: "Enabled", Type: "bool"},
+			{Key: "Secret", Type: "generated"},
+			{Key: "Mode", Type: "dropdown", Options: []string{"fast", "thorough"}},
+		}, SummarizeSettings(manifest))
+	})
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Signature is a detached signature of a plugin bundle, along with the key that produced it.
+type Signature struct {
+	// Signature is the base64-encoded detached signature.
+	Signature string `json:"signature"`
+	// PublicKeyHash identifies the public key able to verify the signature.
+	PublicKeyHash string `json:"public_key_hash"`
+}
+
+// IsValid verifies the signature is well-formed.
+func (s *Signature) IsValid() error {
+	if s.Signature == "" {
+		return errors.New("signature is empty")
+	}
+	if s.PublicKeyHash == "" {
+		return errors.New("signature public key hash is empty")
+	}
+
+	return nil
+}
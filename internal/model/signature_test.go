@@ -0,0 +1,51 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureIsValid(t *testing.T) {
+	require.NoError(t, (&Signature{Signature: "c2lnbmF0dXJl", PublicKeyHash: "hash"}).IsValid())
+	require.EqualError(t, (&Signature{PublicKeyHash: "hash"}).IsValid(), "signature is empty")
+	require.EqualError(t, (&Signature{Signature: "c2lnbmF0dXJl"}).IsValid(), "signature public key hash is empty")
+}
+
+func TestPluginSignatures(t *testing.T) {
+	t.Run("signatures", func(t *testing.T) {
+		plugin, err := PluginFromReader(bytes.NewReader([]byte(
+			`{"signature":"signature1","signatures":[{"signature":"signature1","public_key_hash":"hash1"},{"signature":"signature2","public_key_hash":"hash2"}],"manifest":{}}`,
+		)))
+		require.NoError(t, err)
+		require.Equal(t, "signature1", plugin.Signature)
+		require.Equal(t, []*Signature{
+			{Signature: "signature1", PublicKeyHash: "hash1"},
+			{Signature: "signature2", PublicKeyHash: "hash2"},
+		}, plugin.AllSignatures())
+	})
+
+	t.Run("legacy signature", func(t *testing.T) {
+		plugin, err := PluginFromReader(bytes.NewReader([]byte(
+			`{"signature":"signature1","manifest":{}}`,
+		)))
+		require.NoError(t, err)
+		require.Empty(t, plugin.Signatures)
+		require.Equal(t, []*Signature{{Signature: "signature1"}}, plugin.AllSignatures())
+	})
+
+	t.Run("legacy download signature", func(t *testing.T) {
+		plugin, err := PluginFromReader(bytes.NewReader([]byte(
+			`{"DownloadSignature":"c2lnbmF0dXJl","manifest":{}}`,
+		)))
+		require.NoError(t, err)
+		require.Equal(t, "c2lnbmF0dXJl", plugin.Signature)
+	})
+
+	t.Run("no signatures", func(t *testing.T) {
+		plugin, err := PluginFromReader(bytes.NewReader([]byte(`{"manifest":{}}`)))
+		require.NoError(t, err)
+		require.Empty(t, plugin.AllSignatures())
+	})
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DatabaseIndexName is the name of the index file within the directory of a split database.
+const DatabaseIndexName = "index.json"
+
+// splitPluginsDir is the subdirectory of a split database holding the file of each plugin.
+const splitPluginsDir = "plugins"
+
+// DatabaseIndex lists the files of a split database, which holds every version of each plugin in a
+// file of its own, so that changes can be reviewed, and cached copies invalidated, per plugin.
+type DatabaseIndex struct {
+	Plugins []*DatabaseIndexEntry `json:"plugins"`
+}
+
+// DatabaseIndexEntry describes the file holding the versions of a single plugin.
+type DatabaseIndexEntry struct {
+	ID string `json:"id"`
+	// File is the path of the plugin's file, relative to the directory of the index.
+	File string `json:"file"`
+	// Versions counts the plugin versions in the file.
+	Versions int `json:"versions"`
+	// SHA256 is the hex-encoded digest of the file, changing only when the plugin does.
+	SHA256 string `json:"sha256"`
+}
+
+// DatabaseIndexFromReader decodes a json-encoded DatabaseIndex from the given io.Reader.
+func DatabaseIndexFromReader(reader io.Reader) (*DatabaseIndex, error) {
+	index := DatabaseIndex{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&index)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &index, nil
+}
+
+// WriteSplitDatabase writes the given plugins to the given directory as a split database: the
+// versions of each plugin in the canonical database format, along with an index listing them.
+// Files of plugins listed by an existing index but no longer given are removed.
+func WriteSplitDatabase(dir string, plugins []*Plugin, opts PluginsWriterOptions) (*DatabaseIndex, error) {
+	pluginsByID := map[string][]*Plugin{}
+	for _, plugin := range plugins {
+		if plugin.Manifest == nil {
+			return nil, errors.New("plugin has no manifest")
+		}
+		id := plugin.Manifest.Id
+		if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+			return nil, errors.Errorf("plugin id %q cannot name a file", id)
+		}
+		pluginsByID[id] = append(pluginsByID[id], plugin)
+	}
+
+	ids := make([]string, 0, len(pluginsByID))
+	for id := range pluginsByID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if err := os.MkdirAll(filepath.Join(dir, splitPluginsDir), 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s", dir)
+	}
+
+	index := &DatabaseIndex{Plugins: []*DatabaseIndexEntry{}}
+	written := map[string]bool{}
+	for _, id := range ids {
+		var data bytes.Buffer
+		if err := PluginsToWriter(&data, pluginsByID[id], opts); err != nil {
+			return nil, errors.Wrapf(err, "failed to encode plugin %s", id)
+		}
+
+		file := path.Join(splitPluginsDir, id+".json")
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(file)), data.Bytes(), 0644); err != nil {
+			return nil, errors.Wrapf(err, "failed to write %s", file)
+		}
+		written[file] = true
+
+		sum := sha256.Sum256(data.Bytes())
+		index.Plugins = append(index.Plugins, &DatabaseIndexEntry{
+			ID:       id,
+			File:     file,
+			Versions: len(pluginsByID[id]),
+			SHA256:   hex.EncodeToString(sum[:]),
+		})
+	}
+
+	previous, err := readDatabaseIndex(dir)
+	if err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return nil, err
+	}
+	if previous != nil {
+		for _, entry := range previous.Plugins {
+			if written[entry.File] || !isRelativePath(entry.File) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(dir, filepath.FromSlash(entry.File))); err != nil && !os.IsNotExist(err) {
+				return nil, errors.Wrapf(err, "failed to remove %s", entry.File)
+			}
+		}
+	}
+
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	if opts.Indent {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(index); err != nil {
+		return nil, errors.Wrap(err, "failed to encode index")
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, DatabaseIndexName), data.Bytes(), 0644); err != nil {
+		return nil, errors.Wrapf(err, "failed to write %s", DatabaseIndexName)
+	}
+
+	return index, nil
+}
+
+// ReadSplitDatabase reads every plugin of the split database in the given directory, in the order
+// of its index, verifying each file against the digest recorded by the index.
+func ReadSplitDatabase(dir string) ([]*Plugin, error) {
+	index, err := readDatabaseIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := []*Plugin{}
+	for _, entry := range index.Plugins {
+		if !isRelativePath(entry.File) {
+			return nil, errors.Errorf("plugin %s has file %s outside the database", entry.ID, entry.File)
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(entry.File)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", entry.File)
+		}
+
+		sum := sha256.Sum256(data)
+		if entry.SHA256 != "" && hex.EncodeToString(sum[:]) != entry.SHA256 {
+			return nil, errors.Errorf("%s does not match the digest in the index", entry.File)
+		}
+
+		entryPlugins, err := PluginsFromReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", entry.File)
+		}
+		plugins = append(plugins, entryPlugins...)
+	}
+
+	return plugins, nil
+}
+
+// readDatabaseIndex reads the index of the split database in the given directory.
+func readDatabaseIndex(dir string) (*DatabaseIndex, error) {
+	file, err := os.Open(filepath.Join(dir, DatabaseIndexName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", DatabaseIndexName)
+	}
+	defer file.Close()
+
+	index, err := DatabaseIndexFromReader(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", DatabaseIndexName)
+	}
+
+	return index, nil
+}
+
+// isRelativePath reports whether the given slash-separated path stays within the directory it is
+// relative to.
+func isRelativePath(file string) bool {
+	cleaned := path.Clean(file)
+	return file != "" && !path.IsAbs(cleaned) && cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
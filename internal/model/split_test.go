@@ -0,0 +1,97 @@
+package model
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "split")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	makePlugin := func(id, version string) *Plugin {
+		return &Plugin{
+			DownloadURL:  "https://example.com/" + id + "-" + version + ".tar.gz",
+			ReleaseStage: ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: id, Version: version},
+		}
+	}
+	demo1 := makePlugin("demo", "0.1.0")
+	demo2 := makePlugin("demo", "0.2.0")
+	starter := makePlugin("starter", "1.0.0")
+
+	t.Run("round trip", func(t *testing.T) {
+		index, err := WriteSplitDatabase(dir, []*Plugin{starter, demo1, demo2}, PluginsWriterOptions{})
+		require.NoError(t, err)
+		require.Len(t, index.Plugins, 2)
+		require.Equal(t, "demo", index.Plugins[0].ID)
+		require.Equal(t, "plugins/demo.json", index.Plugins[0].File)
+		require.Equal(t, 2, index.Plugins[0].Versions)
+		require.Equal(t, "starter", index.Plugins[1].ID)
+
+		var expected bytes.Buffer
+		require.NoError(t, PluginsToWriter(&expected, []*Plugin{demo1, demo2}, PluginsWriterOptions{}))
+		data, err := ioutil.ReadFile(filepath.Join(dir, "plugins", "demo.json"))
+		require.NoError(t, err)
+		require.Equal(t, expected.Bytes(), data)
+
+		plugins, err := ReadSplitDatabase(dir)
+		require.NoError(t, err)
+		require.Equal(t, []*Plugin{demo2, demo1, starter}, plugins)
+	})
+
+	t.Run("unchanged plugins keep their digest", func(t *testing.T) {
+		before, err := WriteSplitDatabase(dir, []*Plugin{demo1, starter}, PluginsWriterOptions{})
+		require.NoError(t, err)
+		after, err := WriteSplitDatabase(dir, []*Plugin{demo1, demo2, starter}, PluginsWriterOptions{})
+		require.NoError(t, err)
+
+		require.NotEqual(t, before.Plugins[0].SHA256, after.Plugins[0].SHA256)
+		require.Equal(t, before.Plugins[1].SHA256, after.Plugins[1].SHA256)
+	})
+
+	t.Run("removed plugins", func(t *testing.T) {
+		_, err := WriteSplitDatabase(dir, []*Plugin{demo1, starter}, PluginsWriterOptions{})
+		require.NoError(t, err)
+		_, err = WriteSplitDatabase(dir, []*Plugin{demo1}, PluginsWriterOptions{})
+		require.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(dir, "plugins", "starter.json"))
+		require.True(t, os.IsNotExist(err))
+
+		plugins, err := ReadSplitDatabase(dir)
+		require.NoError(t, err)
+		require.Equal(t, []*Plugin{demo1}, plugins)
+	})
+
+	t.Run("modified file", func(t *testing.T) {
+		_, err := WriteSplitDatabase(dir, []*Plugin{demo1}, PluginsWriterOptions{})
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugins", "demo.json"), []byte("[]"), 0644))
+
+		_, err = ReadSplitDatabase(dir)
+		require.Error(t, err)
+	})
+
+	t.Run("file outside the database", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, DatabaseIndexName), []byte(`{"plugins":[{"id":"demo","file":"../demo.json"}]}`), 0644))
+
+		_, err := ReadSplitDatabase(dir)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid ids", func(t *testing.T) {
+		for _, id := range []string{"", "../demo", ".hidden"} {
+			_, err := WriteSplitDatabase(dir, []*Plugin{makePlugin(id, "0.1.0")}, PluginsWriterOptions{})
+			require.Error(t, err, id)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// StagingReport describes a candidate catalog staged for promotion, and the outcome of validating
+// it.
+type StagingReport struct {
+	// Plugins counts the plugin versions in the candidate.
+	Plugins int `json:"plugins"`
+	// Added, Updated and Removed count the plugin versions the candidate changes relative to the
+	// catalog served when it was staged.
+	Added    int       `json:"added"`
+	Updated  int       `json:"updated"`
+	Removed  int       `json:"removed"`
+	StagedAt time.Time `json:"staged_at"`
+	// ValidatedAt records when the candidate was last validated, if ever.
+	ValidatedAt *time.Time `json:"validated_at,omitempty"`
+	// Problems lists why the candidate failed validation, if it did.
+	Problems []string `json:"problems,omitempty"`
+	// Warnings lists the icons dropped from the candidate's plugins rather than rejecting it.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Passed reports whether the candidate was validated without problems, and may be promoted.
+func (r *StagingReport) Passed() bool {
+	return r.ValidatedAt != nil && len(r.Problems) == 0
+}
+
+// StagingReportFromReader decodes a json-encoded StagingReport from the given io.Reader.
+func StagingReportFromReader(reader io.Reader) (*StagingReport, error) {
+	report := StagingReport{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&report)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &report, nil
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// DailyDownloads counts the downloads recorded on a single UTC day.
+type DailyDownloads struct {
+	// Date is formatted as YYYY-MM-DD.
+	Date      string `json:"date"`
+	Downloads int64  `json:"downloads"`
+}
+
+// VersionAdoption counts the servers reporting installing or upgrading to a single plugin
+// version.
+type VersionAdoption struct {
+	Installs int64 `json:"installs"`
+	Upgrades int64 `json:"upgrades"`
+}
+
+// PluginStats summarizes the download activity of a single plugin, and its adoption as reported
+// by Mattermost servers.
+type PluginStats struct {
+	PluginID       string `json:"plugin_id"`
+	TotalDownloads int64  `json:"total_downloads"`
+	// Versions maps each plugin version to its total downloads.
+	Versions      map[string]int64 `json:"versions"`
+	Daily         []DailyDownloads `json:"daily"`
+	TotalInstalls int64            `json:"total_installs,omitempty"`
+	TotalUpgrades int64            `json:"total_upgrades,omitempty"`
+	// Adoption maps each plugin version reported installed or upgraded to to its reports.
+	Adoption map[string]*VersionAdoption `json:"adoption,omitempty"`
+}
+
+// MarketplaceStats summarizes the download activity across all plugins.
+type MarketplaceStats struct {
+	TotalDownloads int64 `json:"total_downloads"`
+	// Plugins maps each plugin id to its total downloads.
+	Plugins       map[string]int64 `json:"plugins"`
+	Daily         []DailyDownloads `json:"daily"`
+	TotalInstalls int64            `json:"total_installs,omitempty"`
+	TotalUpgrades int64            `json:"total_upgrades,omitempty"`
+}
+
+// PluginStatsFromReader decodes a json-encoded PluginStats from the given io.Reader.
+func PluginStatsFromReader(reader io.Reader) (*PluginStats, error) {
+	stats := PluginStats{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&stats)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// MarketplaceStatsFromReader decodes a json-encoded MarketplaceStats from the given io.Reader.
+func MarketplaceStatsFromReader(reader io.Reader) (*MarketplaceStats, error) {
+	stats := MarketplaceStats{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&stats)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SubmissionStatus describes the progress of a submission through moderation.
+type SubmissionStatus string
+
+const (
+	// SubmissionStatusPending identifies submissions awaiting moderation.
+	SubmissionStatusPending SubmissionStatus = "pending"
+	// SubmissionStatusApproved identifies submissions accepted for inclusion in the marketplace.
+	SubmissionStatusApproved SubmissionStatus = "approved"
+	// SubmissionStatusRejected identifies submissions declined by a moderator.
+	SubmissionStatusRejected SubmissionStatus = "rejected"
+)
+
+// IsValid reports whether the submission status is one of the known values.
+func (s SubmissionStatus) IsValid() bool {
+	switch s {
+	case SubmissionStatusPending, SubmissionStatusApproved, SubmissionStatusRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// gitHubNameRegexp matches the owner and repository names permitted by GitHub.
+var gitHubNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Submission is a community developer's request to include a plugin repository in the
+// marketplace.
+type Submission struct {
+	ID string `json:"id"`
+	// RepositoryURL references the GitHub repository whose releases would be published, e.g.
+	// https://github.com/owner/repository.
+	RepositoryURL string           `json:"repository_url"`
+	SubmitterID   string           `json:"submitter_id"`
+	Status        SubmissionStatus `json:"status"`
+	// ModeratorID identifies the moderator who approved or rejected the submission.
+	ModeratorID string `json:"moderator_id,omitempty"`
+	// Reason optionally explains a moderation decision to the submitter.
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsValid verifies the submission is well-formed.
+func (s *Submission) IsValid() error {
+	if s.ID == "" {
+		return errors.New("submission id is empty")
+	}
+	if _, _, err := ParseGitHubRepository(s.RepositoryURL); err != nil {
+		return err
+	}
+	if s.SubmitterID == "" {
+		return errors.New("submitter id is empty")
+	}
+	if !s.Status.IsValid() {
+		return errors.Errorf("invalid status %s", s.Status)
+	}
+
+	return nil
+}
+
+// ParseGitHubRepository extracts the owner and name of the repository referenced by the given
+// GitHub url, e.g. https://github.com/owner/repository.
+func ParseGitHubRepository(repositoryURL string) (string, string, error) {
+	u, err := url.Parse(repositoryURL)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to parse repository url")
+	}
+	if u.Scheme != "https" || !strings.EqualFold(u.Host, "github.com") {
+		return "", "", errors.Errorf("repository url %s must reference https://github.com", repositoryURL)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 {
+		return "", "", errors.Errorf("repository url %s must reference a repository", repositoryURL)
+	}
+
+	owner, name := parts[0], strings.TrimSuffix(parts[1], ".git")
+	if !gitHubNameRegexp.MatchString(owner) || !gitHubNameRegexp.MatchString(name) {
+		return "", "", errors.Errorf("repository url %s must reference a repository", repositoryURL)
+	}
+
+	return owner, name, nil
+}
+
+// SubmissionFromReader decodes a json-encoded Submission from the given io.Reader.
+func SubmissionFromReader(reader io.Reader) (*Submission, error) {
+	submission := Submission{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&submission)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+// SubmissionsFromReader decodes a json-encoded list of submissions from the given io.Reader.
+func SubmissionsFromReader(reader io.Reader) ([]*Submission, error) {
+	submissions := []*Submission{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&submissions)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return submissions, nil
+}
+
+// SubmissionFilter describes the parameters used to constrain a set of submissions.
+type SubmissionFilter struct {
+	Status      SubmissionStatus
+	SubmitterID string
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitHubRepository(t *testing.T) {
+	testCases := []struct {
+		URL           string
+		ExpectedOwner string
+		ExpectedName  string
+		ExpectedError string
+	}{
+		{"https://github.com/owner/mattermost-plugin-demo", "owner", "mattermost-plugin-demo", ""},
+		{"https://github.com/owner/mattermost-plugin-demo/", "owner", "mattermost-plugin-demo", ""},
+		{"https://github.com/owner/mattermost-plugin-demo.git", "owner", "mattermost-plugin-demo", ""},
+		{"https://GitHub.com/Owner/Demo", "Owner", "Demo", ""},
+		{"", "", "", "repository url  must reference https://github.com"},
+		{"http://github.com/owner/demo", "", "", "repository url http://github.com/owner/demo must reference https://github.com"},
+		{"https://gitlab.com/owner/demo", "", "", "repository url https://gitlab.com/owner/demo must reference https://github.com"},
+		{"https://github.com/owner", "", "", "repository url https://github.com/owner must reference a repository"},
+		{"https://github.com/owner/demo/releases", "", "", "repository url https://github.com/owner/demo/releases must reference a repository"},
+		{"https://github.com/owner/de%20mo", "", "", "repository url https://github.com/owner/de%20mo must reference a repository"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.URL, func(t *testing.T) {
+			owner, name, err := ParseGitHubRepository(testCase.URL)
+			if testCase.ExpectedError != "" {
+				require.EqualError(t, err, testCase.ExpectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, testCase.ExpectedOwner, owner)
+			require.Equal(t, testCase.ExpectedName, name)
+		})
+	}
+}
+
+func TestSubmissionIsValid(t *testing.T) {
+	validSubmission := func() *Submission {
+		return &Submission{
+			ID:            "id",
+			RepositoryURL: "https://github.com/owner/demo",
+			SubmitterID:   "alice",
+			Status:        SubmissionStatusPending,
+		}
+	}
+
+	require.NoError(t, validSubmission().IsValid())
+
+	testCases := []struct {
+		Description   string
+		Modify        func(submission *Submission)
+		ExpectedError string
+	}{
+		{"no id", func(submission *Submission) { submission.ID = "" }, "submission id is empty"},
+		{"invalid repository url", func(submission *Submission) { submission.RepositoryURL = "https://github.com/owner" }, "repository url https://github.com/owner must reference a repository"},
+		{"no submitter", func(submission *Submission) { submission.SubmitterID = "" }, "submitter id is empty"},
+		{"invalid status", func(submission *Submission) { submission.Status = "merged" }, "invalid status merged"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			submission := validSubmission()
+			testCase.Modify(submission)
+			require.EqualError(t, submission.IsValid(), testCase.ExpectedError)
+		})
+	}
+}
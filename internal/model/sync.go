@@ -0,0 +1,125 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// PluginVersionRef identifies a single plugin version.
+type PluginVersionRef struct {
+	PluginID string `json:"plugin_id"`
+	Version  string `json:"version"`
+}
+
+// CatalogSync brings a client's replica of a catalog up to date with the catalog served, listing
+// only the net change to each plugin version since the client's cursor.
+type CatalogSync struct {
+	// Cursor identifies the latest change applied, from which to request the next sync.
+	Cursor int64 `json:"cursor"`
+	// Reset is set if the replica must be discarded before applying the sync, such as on the first
+	// sync, or once the changes after the client's cursor are no longer retained. Added then lists
+	// the whole catalog.
+	Reset bool `json:"reset,omitempty"`
+	// Added lists the plugin versions new to the replica.
+	Added []*Plugin `json:"added"`
+	// Changed lists the plugin versions of the replica whose details changed.
+	Changed []*Plugin `json:"changed"`
+	// Removed lists the plugin versions of the replica withdrawn from the catalog.
+	Removed []*PluginVersionRef `json:"removed"`
+}
+
+// NewCatalogSync compacts the given changes, oldest first, into the net change to each plugin
+// version, ending at the given cursor. A version added then removed is omitted, while one removed
+// then added again is changed.
+func NewCatalogSync(cursor int64, changes []*CatalogChange) *CatalogSync {
+	type netChange struct {
+		existed bool
+		last    *CatalogChange
+	}
+
+	var order []pluginVersionKey
+	net := map[pluginVersionKey]*netChange{}
+	for _, change := range changes {
+		k := pluginVersionKey{change.PluginID, change.Version}
+		if _, ok := net[k]; !ok {
+			net[k] = &netChange{existed: change.Type != ChangeTypeAdded}
+			order = append(order, k)
+		}
+		net[k].last = change
+	}
+
+	sync := &CatalogSync{Cursor: cursor, Added: []*Plugin{}, Changed: []*Plugin{}, Removed: []*PluginVersionRef{}}
+	for _, k := range order {
+		change := net[k]
+		exists := change.last.Type != ChangeTypeRemoved
+		switch {
+		case !change.existed && exists:
+			sync.Added = append(sync.Added, change.last.Plugin)
+		case change.existed && exists:
+			sync.Changed = append(sync.Changed, change.last.Plugin)
+		case change.existed && !exists:
+			sync.Removed = append(sync.Removed, &PluginVersionRef{PluginID: k.id, Version: k.version})
+		}
+	}
+
+	return sync
+}
+
+// IsEmpty determines if the sync changes nothing.
+func (s *CatalogSync) IsEmpty() bool {
+	return !s.Reset && len(s.Added) == 0 && len(s.Changed) == 0 && len(s.Removed) == 0
+}
+
+// Apply returns the given replica of the catalog brought up to date by the sync. Added and
+// changed versions replace any version of the replica they share, so that applying a sync again
+// is harmless.
+func (s *CatalogSync) Apply(replica []*Plugin) []*Plugin {
+	if s.Reset {
+		replica = nil
+	}
+
+	removed := map[pluginVersionKey]bool{}
+	for _, ref := range s.Removed {
+		removed[pluginVersionKey{ref.PluginID, ref.Version}] = true
+	}
+	upserted := map[pluginVersionKey]*Plugin{}
+	for _, plugin := range append(append([]*Plugin(nil), s.Added...), s.Changed...) {
+		upserted[pluginVersionKeyOf(plugin)] = plugin
+	}
+
+	updated := make([]*Plugin, 0, len(replica)+len(s.Added))
+	for _, plugin := range replica {
+		k := pluginVersionKeyOf(plugin)
+		if removed[k] {
+			continue
+		}
+		if upsert, ok := upserted[k]; ok {
+			plugin = upsert
+			delete(upserted, k)
+		}
+		updated = append(updated, plugin)
+	}
+	for _, plugins := range [][]*Plugin{s.Added, s.Changed} {
+		for _, plugin := range plugins {
+			k := pluginVersionKeyOf(plugin)
+			if _, ok := upserted[k]; ok {
+				updated = append(updated, plugin)
+				delete(upserted, k)
+			}
+		}
+	}
+
+	return updated
+}
+
+// CatalogSyncFromReader decodes a json-encoded CatalogSync from the given io.Reader.
+func CatalogSyncFromReader(reader io.Reader) (*CatalogSync, error) {
+	sync := CatalogSync{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&sync)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &sync, nil
+}
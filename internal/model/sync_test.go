@@ -0,0 +1,68 @@
+package model
+
+import (
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogSync(t *testing.T) {
+	makePlugin := func(id, version, downloadURL string) *Plugin {
+		return &Plugin{
+			DownloadURL: downloadURL,
+			Manifest:    &mattermostModel.Manifest{Id: id, Version: version},
+		}
+	}
+	change := func(changeType ChangeType, plugin *Plugin) *CatalogChange {
+		c := &CatalogChange{Type: changeType, PluginID: plugin.Manifest.Id, Version: plugin.Manifest.Version}
+		if changeType != ChangeTypeRemoved {
+			c.Plugin = plugin
+		}
+		return c
+	}
+
+	demo := makePlugin("demo", "0.1.0", "a")
+	rebuiltDemo := makePlugin("demo", "0.1.0", "b")
+	newDemo := makePlugin("demo", "0.2.0", "c")
+	transient := makePlugin("transient", "1.0.0", "d")
+	jira := makePlugin("jira", "3.0.0", "e")
+	zoom := makePlugin("zoom", "1.0.0", "f")
+
+	t.Run("compacted", func(t *testing.T) {
+		sync := NewCatalogSync(42, []*CatalogChange{
+			change(ChangeTypeUpdated, demo),
+			change(ChangeTypeAdded, transient),
+			change(ChangeTypeAdded, newDemo),
+			change(ChangeTypeRemoved, jira),
+			change(ChangeTypeUpdated, rebuiltDemo),
+			change(ChangeTypeRemoved, transient),
+			change(ChangeTypeRemoved, zoom),
+			change(ChangeTypeAdded, zoom),
+		})
+		require.Equal(t, &CatalogSync{
+			Cursor:  42,
+			Added:   []*Plugin{newDemo},
+			Changed: []*Plugin{rebuiltDemo, zoom},
+			Removed: []*PluginVersionRef{{PluginID: "jira", Version: "3.0.0"}},
+		}, sync)
+		require.False(t, sync.IsEmpty())
+
+		replica := sync.Apply([]*Plugin{demo, jira, zoom})
+		require.Equal(t, []*Plugin{rebuiltDemo, zoom, newDemo}, replica)
+		require.Equal(t, replica, sync.Apply(replica))
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		sync := NewCatalogSync(42, nil)
+		require.True(t, sync.IsEmpty())
+		require.Equal(t, []*Plugin{demo}, sync.Apply([]*Plugin{demo}))
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		sync := &CatalogSync{Cursor: 42, Reset: true, Added: []*Plugin{newDemo}}
+		require.False(t, sync.IsEmpty())
+		require.Equal(t, []*Plugin{newDemo}, sync.Apply([]*Plugin{demo, jira}))
+	})
+}
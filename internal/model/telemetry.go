@@ -0,0 +1,57 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// InstallEventType distinguishes the plugin installation events reported by Mattermost servers.
+type InstallEventType string
+
+const (
+	// InstallEventInstall reports a plugin newly installed on a server.
+	InstallEventInstall InstallEventType = "install"
+	// InstallEventUpgrade reports a plugin already installed on a server upgraded to a new version.
+	InstallEventUpgrade InstallEventType = "upgrade"
+)
+
+// InstallEvent is a Mattermost server's report of installing or upgrading a plugin. It is
+// anonymous, identifying neither the server nor its users.
+type InstallEvent struct {
+	Event    InstallEventType `json:"event"`
+	PluginID string           `json:"plugin_id"`
+	// Version is the version installed or upgraded to.
+	Version string `json:"version"`
+	// ServerID optionally carries the server's anonymous telemetry id, used only to count
+	// repeated reports once and never recorded. Reports without one are told apart by address.
+	ServerID string `json:"server_id,omitempty"`
+}
+
+// IsValid verifies the event is well-formed.
+func (e *InstallEvent) IsValid() error {
+	if e.Event != InstallEventInstall && e.Event != InstallEventUpgrade {
+		return errors.Errorf("invalid install event %s", e.Event)
+	}
+	if e.PluginID == "" {
+		return errors.New("plugin id is empty")
+	}
+	if _, err := ParseVersion(e.Version); err != nil {
+		return errors.Wrapf(err, "invalid version %s", e.Version)
+	}
+
+	return nil
+}
+
+// InstallEventFromReader decodes a json-encoded InstallEvent from the given io.Reader.
+func InstallEventFromReader(reader io.Reader) (*InstallEvent, error) {
+	event := InstallEvent{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&event)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallEventIsValid(t *testing.T) {
+	validEvent := func() *InstallEvent {
+		return &InstallEvent{
+			Event:    InstallEventUpgrade,
+			PluginID: "jira",
+			Version:  "3.0.1",
+		}
+	}
+
+	require.NoError(t, validEvent().IsValid())
+
+	testCases := []struct {
+		Description   string
+		Modify        func(event *InstallEvent)
+		ExpectedError string
+	}{
+		{"unknown event", func(event *InstallEvent) { event.Event = "uninstall" }, "invalid install event uninstall"},
+		{"no plugin id", func(event *InstallEvent) { event.PluginID = "" }, "plugin id is empty"},
+		{"invalid version", func(event *InstallEvent) { event.Version = "latest" }, "invalid version latest: No Major.Minor.Patch elements found"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			event := validEvent()
+			testCase.Modify(event)
+			require.EqualError(t, event.IsValid(), testCase.ExpectedError)
+		})
+	}
+}
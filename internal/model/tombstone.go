@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The reasons recorded for plugin versions removed from the catalog, unless a moderator gives
+// their own.
+const (
+	// TombstoneReasonReloaded records a version no longer in the reloaded database.
+	TombstoneReasonReloaded = "removed from the database"
+	// TombstoneReasonImported records a version left out of an imported catalog.
+	TombstoneReasonImported = "removed by a catalog import"
+	// TombstoneReasonPromoted records a version left out of a promoted staging catalog.
+	TombstoneReasonPromoted = "removed by promoting a staged catalog"
+	// TombstoneReasonRolledBack records a version not in the snapshot rolled back to.
+	TombstoneReasonRolledBack = "removed by rolling back the catalog"
+	// TombstoneReasonDelisted records a version delisted by a moderator.
+	TombstoneReasonDelisted = "delisted by a moderator"
+)
+
+// Tombstone records a plugin version removed from the catalog, so that servers that installed it
+// can be warned rather than the version simply vanishing.
+type Tombstone struct {
+	PluginID  string    `json:"plugin_id"`
+	Version   string    `json:"version"`
+	Reason    string    `json:"reason"`
+	RemovedAt time.Time `json:"removed_at"`
+}
+
+// IsValid verifies the tombstone is well-formed.
+func (t *Tombstone) IsValid() error {
+	if t.PluginID == "" {
+		return errors.New("tombstone has no plugin id")
+	}
+	if t.Version == "" {
+		return errors.Errorf("tombstone of %s has no version", t.PluginID)
+	}
+	if t.Reason == "" {
+		return errors.Errorf("tombstone of %s %s has no reason", t.PluginID, t.Version)
+	}
+
+	return nil
+}
+
+// TombstonesFromReader decodes a json-encoded list of tombstones from the given io.Reader.
+func TombstonesFromReader(reader io.Reader) ([]*Tombstone, error) {
+	tombstones := []*Tombstone{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&tombstones)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return tombstones, nil
+}
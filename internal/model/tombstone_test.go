@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTombstoneIsValid(t *testing.T) {
+	validTombstone := func() *Tombstone {
+		return &Tombstone{PluginID: "jira", Version: "2.0.0", Reason: TombstoneReasonDelisted}
+	}
+
+	require.NoError(t, validTombstone().IsValid())
+
+	testCases := []struct {
+		Description   string
+		Modify        func(tombstone *Tombstone)
+		ExpectedError string
+	}{
+		{"no plugin id", func(tombstone *Tombstone) { tombstone.PluginID = "" }, "tombstone has no plugin id"},
+		{"no version", func(tombstone *Tombstone) { tombstone.Version = "" }, "tombstone of jira has no version"},
+		{"no reason", func(tombstone *Tombstone) { tombstone.Reason = "" }, "tombstone of jira 2.0.0 has no reason"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			tombstone := validTombstone()
+			testCase.Modify(tombstone)
+			require.EqualError(t, tombstone.IsValid(), testCase.ExpectedError)
+		})
+	}
+}
+
+func TestTombstonesFromReader(t *testing.T) {
+	tombstones, err := TombstonesFromReader(strings.NewReader(""))
+	require.NoError(t, err)
+	require.Empty(t, tombstones)
+
+	tombstones, err = TombstonesFromReader(strings.NewReader(`[{"plugin_id":"jira","version":"2.0.0","reason":"delisted by a moderator","removed_at":"2020-01-01T00:00:00Z"}]`))
+	require.NoError(t, err)
+	require.Len(t, tombstones, 1)
+	require.Equal(t, "jira", tombstones[0].PluginID)
+	require.Equal(t, 2020, tombstones[0].RemovedAt.Year())
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// PluginUpdate describes a newer version of an installed plugin.
+type PluginUpdate struct {
+	// PluginID is the id under which the plugin is installed, which may be an old id of a renamed
+	// plugin.
+	PluginID         string `json:"plugin_id"`
+	InstalledVersion string `json:"installed_version"`
+	LatestVersion    string `json:"latest_version"`
+	// Plugin is the newer version offered in place of the installed version.
+	Plugin *Plugin `json:"plugin"`
+}
+
+// FindUpdates compares the given installed plugins, mapping plugin ids to installed versions,
+// with the given plugins offered to the installation, returning the installed plugins for which a
+// newer version is offered, sorted by plugin id. Plugins installed under an old id of a renamed
+// plugin are matched with the renamed plugin.
+func FindUpdates(installed map[string]string, offered []*Plugin) ([]*PluginUpdate, error) {
+	ids := make([]string, 0, len(installed))
+	for id := range installed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	updates := []*PluginUpdate{}
+	for _, id := range ids {
+		installedVersion, err := semver.ParseTolerant(installed[id])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version %s of plugin %s", installed[id], id)
+		}
+
+		for _, plugin := range offered {
+			if plugin.Manifest == nil || !plugin.HasID(id) {
+				continue
+			}
+
+			latestVersion, err := semver.ParseTolerant(plugin.Manifest.Version)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid version %s of plugin %s", plugin.Manifest.Version, plugin.Manifest.Id)
+			}
+			if latestVersion.GT(installedVersion) {
+				updates = append(updates, &PluginUpdate{
+					PluginID:         id,
+					InstalledVersion: installed[id],
+					LatestVersion:    plugin.Manifest.Version,
+					Plugin:           plugin,
+				})
+			}
+			break
+		}
+	}
+
+	return updates, nil
+}
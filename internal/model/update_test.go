@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindUpdates(t *testing.T) {
+	jira := &Plugin{Manifest: &mattermostModel.Manifest{Id: "jira", Version: "3.1.0"}}
+	github := &Plugin{Manifest: &mattermostModel.Manifest{Id: "github", Version: "2.0.0"}}
+	todo := &Plugin{Manifest: &mattermostModel.Manifest{Id: "todo", Version: "1.0.0"}, OldIDs: []string{"com.example.todo"}}
+	offered := []*Plugin{jira, github, todo}
+
+	updates, err := FindUpdates(map[string]string{
+		"jira":             "3.0.1",
+		"github":           "2.0.0",
+		"com.example.todo": "0.9.0",
+		"unknown":          "1.0.0",
+		"Jira-Beta":        "0.1.0",
+	}, offered)
+	require.NoError(t, err)
+	require.Equal(t, []*PluginUpdate{
+		{PluginID: "com.example.todo", InstalledVersion: "0.9.0", LatestVersion: "1.0.0", Plugin: todo},
+		{PluginID: "jira", InstalledVersion: "3.0.1", LatestVersion: "3.1.0", Plugin: jira},
+	}, updates)
+
+	t.Run("newer than offered", func(t *testing.T) {
+		updates, err := FindUpdates(map[string]string{"jira": "4.0.0-rc1"}, offered)
+		require.NoError(t, err)
+		require.Empty(t, updates)
+	})
+
+	t.Run("tolerates prefixed versions", func(t *testing.T) {
+		updates, err := FindUpdates(map[string]string{"JIRA": "v3.0"}, offered)
+		require.NoError(t, err)
+		require.Len(t, updates, 1)
+		require.Equal(t, "JIRA", updates[0].PluginID)
+	})
+
+	t.Run("invalid installed version", func(t *testing.T) {
+		_, err := FindUpdates(map[string]string{"jira": "latest"}, offered)
+		require.Error(t, err)
+	})
+}
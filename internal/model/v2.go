@@ -0,0 +1,163 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// DatabaseV2Format identifies a database in the v2 format.
+const DatabaseV2Format = "v2"
+
+// DatabaseV2 is the v2 format of the plugins database. Rather than repeating the metadata of a
+// plugin in each of its versions, as the flat v1 format does, it records the metadata once per
+// plugin with the versions underneath.
+type DatabaseV2 struct {
+	Format  string      `json:"format"`
+	Plugins []*PluginV2 `json:"plugins"`
+}
+
+// PluginV2 records the metadata shared by the versions of a plugin in the v2 database format.
+// Each version omits the shared metadata, recording its own only where it differs.
+type PluginV2 struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	HomepageURL string `json:"homepage_url,omitempty"`
+	IconData    string `json:"icon_data,omitempty"`
+	IconURL     string `json:"icon_url,omitempty"`
+	// Versions lists the versions of the plugin in the canonical order, by descending version.
+	Versions []*Plugin `json:"versions"`
+}
+
+// sharedField accesses a field of a plugin version that the v2 format may share.
+type sharedField struct {
+	shared func(p *PluginV2) *string
+	field  func(p *Plugin) *string
+}
+
+// sharedFields lists the fields the v2 format shares among the versions of a plugin.
+var sharedFields = []sharedField{
+	{func(p *PluginV2) *string { return &p.Name }, func(p *Plugin) *string { return &p.Manifest.Name }},
+	{func(p *PluginV2) *string { return &p.Description }, func(p *Plugin) *string { return &p.Manifest.Description }},
+	{func(p *PluginV2) *string { return &p.HomepageURL }, func(p *Plugin) *string { return &p.HomepageURL }},
+	{func(p *PluginV2) *string { return &p.IconData }, func(p *Plugin) *string { return &p.IconData }},
+	{func(p *PluginV2) *string { return &p.IconURL }, func(p *Plugin) *string { return &p.IconURL }},
+}
+
+// NewDatabaseV2 converts the given plugins from the flat v1 format to the v2 format. The given
+// plugins are not modified.
+func NewDatabaseV2(plugins []*Plugin) (*DatabaseV2, error) {
+	sorted := make([]*Plugin, len(plugins))
+	copy(sorted, plugins)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return pluginLess(sorted[i], sorted[j])
+	})
+
+	database := &DatabaseV2{Format: DatabaseV2Format, Plugins: []*PluginV2{}}
+	var current *PluginV2
+	for _, plugin := range sorted {
+		if plugin.Manifest == nil {
+			return nil, errors.New("plugin has no manifest")
+		}
+		if current == nil || current.ID != plugin.Manifest.Id {
+			current = &PluginV2{ID: plugin.Manifest.Id}
+			database.Plugins = append(database.Plugins, current)
+		}
+
+		version := *plugin
+		manifest := *plugin.Manifest
+		version.Manifest = &manifest
+		current.Versions = append(current.Versions, &version)
+	}
+
+	for _, plugin := range database.Plugins {
+		for _, field := range sharedFields {
+			shareField(plugin, field)
+		}
+	}
+
+	return database, nil
+}
+
+// shareField records the given field of the latest version of the plugin as shared, clearing it
+// from the versions matching it. The field is not shared if any version leaves it empty, as that
+// version would then inherit the shared value.
+func shareField(plugin *PluginV2, field sharedField) {
+	value := *field.field(plugin.Versions[0])
+	if value == "" {
+		return
+	}
+	for _, version := range plugin.Versions {
+		if *field.field(version) == "" {
+			return
+		}
+	}
+
+	*field.shared(plugin) = value
+	for _, version := range plugin.Versions {
+		if *field.field(version) == value {
+			*field.field(version) = ""
+		}
+	}
+}
+
+// FlatPlugins converts the database to the flat v1 format, restoring the shared metadata of each
+// version.
+func (d *DatabaseV2) FlatPlugins() ([]*Plugin, error) {
+	plugins := []*Plugin{}
+	for _, plugin := range d.Plugins {
+		for _, version := range plugin.Versions {
+			if version == nil || version.Manifest == nil {
+				return nil, errors.Errorf("version of plugin %s has no manifest", plugin.ID)
+			}
+			if version.Manifest.Id != plugin.ID {
+				return nil, errors.Errorf("version of plugin %s has id %s", plugin.ID, version.Manifest.Id)
+			}
+
+			for _, field := range sharedFields {
+				if *field.field(version) == "" {
+					*field.field(version) = *field.shared(plugin)
+				}
+			}
+			plugins = append(plugins, version)
+		}
+	}
+
+	return plugins, nil
+}
+
+// isDatabaseV2 reports whether the given encoded database is in the v2 format.
+func isDatabaseV2(data []byte) bool {
+	return databaseFormat(data) == DatabaseV2Format
+}
+
+// expandDatabaseV2 decodes the given v2 database in the flat v1 format.
+func expandDatabaseV2(data []byte) ([]*Plugin, error) {
+	var database DatabaseV2
+	if err := json.Unmarshal(data, &database); err != nil {
+		return nil, err
+	}
+
+	return database.FlatPlugins()
+}
+
+// databaseV2ToWriter encodes the given plugins as a v2 database.
+func databaseV2ToWriter(w io.Writer, plugins []*Plugin, opts PluginsWriterOptions) error {
+	database, err := NewDatabaseV2(plugins)
+	if err != nil {
+		return errors.Wrap(err, "failed to convert plugins")
+	}
+
+	encoder := json.NewEncoder(w)
+	if opts.Indent {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(database); err != nil {
+		return errors.Wrap(err, "failed to encode v2 database")
+	}
+
+	return nil
+}
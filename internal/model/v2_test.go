@@ -0,0 +1,79 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseV2(t *testing.T) {
+	makePlugin := func(id, version, name, description string) *Plugin {
+		return &Plugin{
+			HomepageURL:  "https://example.com/" + id,
+			IconData:     "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=",
+			DownloadURL:  "https://example.com/" + id + "-" + version + ".tar.gz",
+			ReleaseStage: ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: id, Version: version, Name: name, Description: description},
+		}
+	}
+	demo1 := makePlugin("demo", "0.1.0", "Demo Plugin", "")
+	demo2 := makePlugin("demo", "0.2.0", "Demo", "Demonstrates the plugin framework.")
+	demo3 := makePlugin("demo", "0.3.0", "Demo", "Demonstrates the plugin framework.")
+	starter := makePlugin("starter", "1.0.0", "Starter", "Starts plugins.")
+
+	t.Run("shares metadata", func(t *testing.T) {
+		database, err := NewDatabaseV2([]*Plugin{starter, demo1, demo3, demo2})
+		require.NoError(t, err)
+		require.Equal(t, DatabaseV2Format, database.Format)
+		require.Len(t, database.Plugins, 2)
+
+		demo := database.Plugins[0]
+		require.Equal(t, "demo", demo.ID)
+		require.Equal(t, "Demo", demo.Name)
+		require.Equal(t, "https://example.com/demo", demo.HomepageURL)
+		require.Equal(t, demo1.IconData, demo.IconData)
+		// A version without a description keeps the others from sharing theirs.
+		require.Empty(t, demo.Description)
+
+		require.Len(t, demo.Versions, 3)
+		require.Equal(t, "0.3.0", demo.Versions[0].Manifest.Version)
+		require.Empty(t, demo.Versions[0].Manifest.Name)
+		require.Empty(t, demo.Versions[0].IconData)
+		require.Equal(t, "Demonstrates the plugin framework.", demo.Versions[0].Manifest.Description)
+		require.Equal(t, "Demo Plugin", demo.Versions[2].Manifest.Name)
+
+		// The given plugins are unchanged.
+		require.Equal(t, "Demo", demo3.Manifest.Name)
+		require.NotEmpty(t, demo3.IconData)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		var buffer bytes.Buffer
+		require.NoError(t, PluginsToWriter(&buffer, []*Plugin{starter, demo1, demo3, demo2}, PluginsWriterOptions{V2: true}))
+		require.Equal(t, 2, strings.Count(buffer.String(), demo1.IconData))
+
+		var database DatabaseV2
+		require.NoError(t, json.Unmarshal(buffer.Bytes(), &database))
+		require.Equal(t, DatabaseV2Format, database.Format)
+
+		plugins, err := PluginsFromReader(bytes.NewReader(buffer.Bytes()))
+		require.NoError(t, err)
+		require.Equal(t, []*Plugin{demo3, demo2, demo1, starter}, plugins)
+
+		require.NoError(t, ValidateAgainstSchema(bytes.NewReader(buffer.Bytes())))
+	})
+
+	t.Run("mismatched version", func(t *testing.T) {
+		_, err := PluginsFromReader(strings.NewReader(`{"format":"v2","plugins":[{"id":"demo","versions":[{"manifest":{"id":"other"}}]}]}`))
+		require.EqualError(t, err, "version of plugin demo has id other")
+	})
+
+	t.Run("plugin without manifest", func(t *testing.T) {
+		_, err := NewDatabaseV2([]*Plugin{{}})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/blang/semver"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+)
+
+// ParseVersion parses the given semantic version, tolerating surrounding whitespace and a v
+// prefix, as in release tags such as v1.2.3. Build metadata, as in 1.2.3+build5, is retained but,
+// per semantic versioning, ignored when comparing versions.
+func ParseVersion(version string) (semver.Version, error) {
+	version = strings.TrimSpace(version)
+	if strings.HasPrefix(version, "v") || strings.HasPrefix(version, "V") {
+		version = version[1:]
+	}
+
+	return semver.Parse(version)
+}
+
+// NormalizeVersion returns the canonical form of the given semantic version, without any v
+// prefix, or the version unchanged if it cannot be parsed.
+func NormalizeVersion(version string) string {
+	parsed, err := ParseVersion(version)
+	if err != nil {
+		return version
+	}
+
+	return parsed.String()
+}
+
+// NormalizeManifestVersions normalizes the version and minimum server version of the given
+// manifest, returning whether either changed.
+func NormalizeManifestVersions(manifest *mattermostModel.Manifest) bool {
+	version := NormalizeVersion(manifest.Version)
+	minServerVersion := NormalizeVersion(manifest.MinServerVersion)
+	changed := version != manifest.Version || minServerVersion != manifest.MinServerVersion
+
+	manifest.Version = version
+	manifest.MinServerVersion = minServerVersion
+
+	return changed
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// VersionBucket groups the versions of a plugin requiring the same minimum server version.
+type VersionBucket struct {
+	// MinServerVersion is the minimum server version required by the versions in the bucket, or
+	// empty if they run on any server version.
+	MinServerVersion string `json:"min_server_version"`
+	// LatestVersion is the latest version in the bucket.
+	LatestVersion string    `json:"latest_version"`
+	Versions      []*Plugin `json:"versions"`
+}
+
+// NewVersionBuckets groups the given versions of a plugin, sorted by version descending, by their
+// minimum server version, most demanding first. Versions within each bucket keep their order.
+func NewVersionBuckets(versions []*Plugin) []*VersionBucket {
+	buckets := []*VersionBucket{}
+	bucketsByMinServerVersion := map[string]*VersionBucket{}
+	for _, version := range versions {
+		minServerVersion := version.Manifest.MinServerVersion
+		bucket, ok := bucketsByMinServerVersion[minServerVersion]
+		if !ok {
+			bucket = &VersionBucket{
+				MinServerVersion: minServerVersion,
+				LatestVersion:    version.Manifest.Version,
+			}
+			bucketsByMinServerVersion[minServerVersion] = bucket
+			buckets = append(buckets, bucket)
+		}
+		bucket.Versions = append(bucket.Versions, version)
+	}
+
+	sort.SliceStable(buckets, func(i, j int) bool {
+		return lessMinServerVersion(buckets[j].MinServerVersion, buckets[i].MinServerVersion)
+	})
+
+	return buckets
+}
+
+// lessMinServerVersion orders minimum server versions by semantic version, placing the absence of
+// a minimum, and unparseable versions, first.
+func lessMinServerVersion(a, b string) bool {
+	aVersion, aErr := ParseVersion(a)
+	bVersion, bErr := ParseVersion(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return aVersion.LT(bVersion)
+	case aErr == nil:
+		return false
+	case bErr == nil:
+		return true
+	default:
+		return a < b
+	}
+}
+
+// VersionBucketsFromReader decodes a json-encoded list of version buckets from the given
+// io.Reader.
+func VersionBucketsFromReader(reader io.Reader) ([]*VersionBucket, error) {
+	buckets := []*VersionBucket{}
+	decoder := json.NewDecoder(reader)
+	err := decoder.Decode(&buckets)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVersionBuckets(t *testing.T) {
+	makePlugin := func(version, minServerVersion string) *Plugin {
+		return &Plugin{Manifest: &mattermostModel.Manifest{Id: "jira", Version: version, MinServerVersion: minServerVersion}}
+	}
+
+	v4 := makePlugin("4.0.0", "5.30.0")
+	v3_1 := makePlugin("3.1.0", "5.20.0")
+	v3 := makePlugin("3.0.0", "5.20.0")
+	v2_1 := makePlugin("2.1.0", "5.4.0")
+	v2 := makePlugin("2.0.0", "")
+	v1 := makePlugin("1.0.0", "")
+
+	t.Run("no versions", func(t *testing.T) {
+		require.Empty(t, NewVersionBuckets(nil))
+	})
+
+	t.Run("most demanding first", func(t *testing.T) {
+		require.Equal(t, []*VersionBucket{
+			{MinServerVersion: "5.30.0", LatestVersion: "4.0.0", Versions: []*Plugin{v4}},
+			{MinServerVersion: "5.20.0", LatestVersion: "3.1.0", Versions: []*Plugin{v3_1, v3}},
+			{MinServerVersion: "5.4.0", LatestVersion: "2.1.0", Versions: []*Plugin{v2_1}},
+			{MinServerVersion: "", LatestVersion: "2.0.0", Versions: []*Plugin{v2, v1}},
+		}, NewVersionBuckets([]*Plugin{v4, v3_1, v3, v2_1, v2, v1}))
+	})
+}
+
+func TestVersionBucketsFromReader(t *testing.T) {
+	buckets, err := VersionBucketsFromReader(strings.NewReader(""))
+	require.NoError(t, err)
+	require.Empty(t, buckets)
+
+	buckets, err = VersionBucketsFromReader(strings.NewReader(`[{"min_server_version":"5.20.0","latest_version":"3.1.0","versions":[{"manifest":{"id":"jira","version":"3.1.0"}}]}]`))
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+	require.Equal(t, "5.20.0", buckets[0].MinServerVersion)
+	require.Len(t, buckets[0].Versions, 1)
+}
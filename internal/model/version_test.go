@@ -0,0 +1,57 @@
+package model
+
+import (
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		Version  string
+		Expected string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"v1.2.3", "1.2.3"},
+		{"V1.2.3", "1.2.3"},
+		{" v1.2.3 ", "1.2.3"},
+		{"1.2.3+build5", "1.2.3+build5"},
+		{"v1.2.3-rc1+build5", "1.2.3-rc1+build5"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Version, func(t *testing.T) {
+			version, err := ParseVersion(testCase.Version)
+			require.NoError(t, err)
+			require.Equal(t, testCase.Expected, version.String())
+			require.Equal(t, testCase.Expected, NormalizeVersion(testCase.Version))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, version := range []string{"", "v", "vv1.2.3", "1.2", "latest"} {
+			_, err := ParseVersion(version)
+			require.Error(t, err, version)
+			require.Equal(t, version, NormalizeVersion(version))
+		}
+	})
+
+	t.Run("build metadata is ignored when comparing", func(t *testing.T) {
+		a, err := ParseVersion("1.2.3+build5")
+		require.NoError(t, err)
+		b, err := ParseVersion("v1.2.3+build6")
+		require.NoError(t, err)
+		require.True(t, a.EQ(b))
+	})
+}
+
+func TestNormalizeManifestVersions(t *testing.T) {
+	manifest := &mattermostModel.Manifest{Version: "v1.2.3", MinServerVersion: "v5.20.0"}
+	require.True(t, NormalizeManifestVersions(manifest))
+	require.Equal(t, "1.2.3", manifest.Version)
+	require.Equal(t, "5.20.0", manifest.MinServerVersion)
+
+	require.False(t, NormalizeManifestVersions(manifest))
+	require.False(t, NormalizeManifestVersions(&mattermostModel.Manifest{Version: "1.2.3"}))
+}
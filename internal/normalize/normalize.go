@@ -0,0 +1,121 @@
+// Package normalize tidies the display names and descriptions of plugins, so that inconsistent
+// upstream manifests render uniformly in the marketplace.
+package normalize
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// ellipsis marks text truncated to its maximum length.
+const ellipsis = "…"
+
+// Rules describes how names and descriptions are normalized. Whitespace is always trimmed and
+// collapsed.
+type Rules struct {
+	// StripMarkup removes HTML tags and markdown formatting, keeping the text they format.
+	StripMarkup bool
+	// MaxNameLength caps the length of names in characters, with 0 leaving them uncapped.
+	MaxNameLength int
+	// MaxDescriptionLength caps the length of descriptions in characters, with 0 leaving them
+	// uncapped.
+	MaxDescriptionLength int
+}
+
+var (
+	htmlTag            = regexp.MustCompile(`<[^>]*>`)
+	markdownImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink       = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markdownHeading    = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s+`)
+	markdownListItem   = regexp.MustCompile(`(?m)^\s*(?:[-*+]|\d+\.)\s+`)
+	markdownBlockquote = regexp.MustCompile(`(?m)^\s*>\s?`)
+	markdownInlineCode = regexp.MustCompile("`([^`]*)`")
+	// markdownEmphasis matches emphasized text not within a word, so that names such as
+	// snake_case_name are left intact.
+	markdownEmphasis = regexp.MustCompile(`(^|[^\w*~])(\*\*|__|\*|_|~~)(\S(?:.*?\S)?)(\*\*|__|\*|_|~~)($|[^\w*~])`)
+	whitespaceRun    = regexp.MustCompile(`\s+`)
+)
+
+// Apply normalizes the name and description of the given plugin's manifest, returning whether
+// either changed.
+func (r *Rules) Apply(plugin *model.Plugin) bool {
+	if plugin.Manifest == nil {
+		return false
+	}
+
+	name := r.Text(plugin.Manifest.Name, r.MaxNameLength)
+	description := r.Text(plugin.Manifest.Description, r.MaxDescriptionLength)
+	changed := name != plugin.Manifest.Name || description != plugin.Manifest.Description
+
+	plugin.Manifest.Name = name
+	plugin.Manifest.Description = description
+
+	return changed
+}
+
+// Text normalizes the given text, capping it at maxLength characters unless maxLength is 0.
+func (r *Rules) Text(value string, maxLength int) string {
+	if r.StripMarkup {
+		value = stripMarkup(value)
+	}
+	value = strings.TrimSpace(whitespaceRun.ReplaceAllString(value, " "))
+
+	return truncate(value, maxLength)
+}
+
+// stripMarkup removes HTML tags and markdown formatting from the given text.
+func stripMarkup(value string) string {
+	value = htmlTag.ReplaceAllString(value, " ")
+	value = html.UnescapeString(value)
+
+	value = markdownImage.ReplaceAllString(value, "$1")
+	value = markdownLink.ReplaceAllString(value, "$1")
+	value = markdownHeading.ReplaceAllString(value, "")
+	value = markdownListItem.ReplaceAllString(value, "")
+	value = markdownBlockquote.ReplaceAllString(value, "")
+	value = markdownInlineCode.ReplaceAllString(value, "$1")
+
+	// Emphasis may nest, e.g. ***bold italic***, so strip it until none remains.
+	for {
+		stripped := markdownEmphasis.ReplaceAllStringFunc(value, func(match string) string {
+			parts := markdownEmphasis.FindStringSubmatch(match)
+			if parts[2] != parts[4] {
+				return match
+			}
+			return parts[1] + parts[3] + parts[5]
+		})
+		if stripped == value {
+			return value
+		}
+		value = stripped
+	}
+}
+
+// truncate caps the given text at maxLength characters, preferring to break between words and
+// marking the truncation with an ellipsis.
+func truncate(value string, maxLength int) string {
+	runes := []rune(value)
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return value
+	}
+	if maxLength <= len([]rune(ellipsis)) {
+		return string(runes[:maxLength])
+	}
+
+	cut := maxLength - len([]rune(ellipsis))
+	// Break at the last space, unless that would discard most of the allowed text.
+	for i := cut; i > cut/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(",;:.-", r)
+	}) + ellipsis
+}
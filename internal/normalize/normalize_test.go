@@ -0,0 +1,55 @@
+package normalize
+
+import (
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func TestText(t *testing.T) {
+	testCases := []struct {
+		Description string
+		Rules       Rules
+		Value       string
+		MaxLength   int
+		Expected    string
+	}{
+		{"trims and collapses whitespace", Rules{}, "  A  plugin\n\tfor\r\nteams ", 0, "A plugin for teams"},
+		{"keeps markup unless stripping", Rules{}, "A **bold** plugin", 0, "A **bold** plugin"},
+		{"strips html", Rules{StripMarkup: true}, "<p>A <b>bold</b> plugin&nbsp;&amp; more</p>", 0, "A bold plugin & more"},
+		{"strips links and images", Rules{StripMarkup: true}, "![logo](logo.png) See [the docs](https://example.com).", 0, "logo See the docs."},
+		{"strips emphasis", Rules{StripMarkup: true}, "A **bold**, _italic_, ***both*** and ~~struck~~ plugin", 0, "A bold, italic, both and struck plugin"},
+		{"strips headings, lists and code", Rules{StripMarkup: true}, "# Title\n- one\n- `two`\n> quoted", 0, "Title one two quoted"},
+		{"keeps underscores within words", Rules{StripMarkup: true}, "Configure my_plugin_setting and 2*3*4", 0, "Configure my_plugin_setting and 2*3*4"},
+		{"under the cap", Rules{}, "Short", 10, "Short"},
+		{"truncates between words", Rules{}, "A plugin integrating with everything", 24, "A plugin integrating…"},
+		{"truncates long words", Rules{}, "Supercalifragilisticexpialidocious", 10, "Supercali…"},
+		{"trims punctuation before the ellipsis", Rules{}, "Alerts, reminders, and more", 12, "Alerts…"},
+		{"counts characters rather than bytes", Rules{}, "Überwachung für Teams", 12, "Überwachung…"},
+		{"tiny cap", Rules{}, "Plugin", 1, "P"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			require.Equal(t, testCase.Expected, testCase.Rules.Text(testCase.Value, testCase.MaxLength))
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	rules := &Rules{StripMarkup: true, MaxNameLength: 10, MaxDescriptionLength: 20}
+
+	plugin := &model.Plugin{Manifest: &mattermostModel.Manifest{
+		Name:        " <b>Demo</b> ",
+		Description: "A **demo** plugin with a rather long description",
+	}}
+	require.True(t, rules.Apply(plugin))
+	require.Equal(t, "Demo", plugin.Manifest.Name)
+	require.Equal(t, "A demo plugin with…", plugin.Manifest.Description)
+
+	require.False(t, rules.Apply(plugin))
+	require.False(t, rules.Apply(&model.Plugin{}))
+}
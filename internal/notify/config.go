@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"io"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// The types of sink that may be configured.
+const (
+	SinkTypeMattermost = "mattermost"
+	SinkTypeSlack      = "slack"
+	SinkTypeEmail      = "email"
+	SinkTypeHTTP       = "http"
+)
+
+// SinkConfig configures a sink and the events delivered to it.
+type SinkConfig struct {
+	// Name identifies the sink in logs, defaulting to its type.
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Events lists the event types delivered to the sink, or every type if empty.
+	Events []EventType `yaml:"events"`
+
+	// URL is the webhook or endpoint of mattermost, slack and http sinks.
+	URL string `yaml:"url"`
+	// Headers are added to the requests of http sinks.
+	Headers map[string]string `yaml:"headers"`
+	// Username and Channel override those of mattermost webhooks.
+	Username string `yaml:"username"`
+	Channel  string `yaml:"channel"`
+
+	// SMTP is the host and port of the server of email sinks.
+	SMTP         string   `yaml:"smtp"`
+	SMTPUsername string   `yaml:"smtp_username"`
+	SMTPPassword string   `yaml:"smtp_password"`
+	From         string   `yaml:"from"`
+	To           []string `yaml:"to"`
+}
+
+// Config lists the sinks to notify.
+type Config struct {
+	Sinks []*SinkConfig `yaml:"sinks"`
+}
+
+// ConfigFromReader decodes a YAML notifications configuration, validating each sink.
+func ConfigFromReader(reader io.Reader) (*Config, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read notifications configuration")
+	}
+
+	var config Config
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, errors.Wrap(err, "failed to parse notifications configuration")
+	}
+
+	for i, sink := range config.Sinks {
+		if sink == nil {
+			return nil, errors.Errorf("sink %d is empty", i)
+		}
+		if err := sink.IsValid(); err != nil {
+			return nil, errors.Wrapf(err, "invalid sink %d", i)
+		}
+	}
+
+	return &config, nil
+}
+
+// IsValid verifies the sink is fully configured for its type.
+func (c *SinkConfig) IsValid() error {
+	for _, eventType := range c.Events {
+		if !eventType.IsValid() {
+			return errors.Errorf("unknown event type %s", eventType)
+		}
+	}
+
+	switch c.Type {
+	case SinkTypeMattermost, SinkTypeSlack, SinkTypeHTTP:
+		parsed, err := url.Parse(c.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.Errorf("%s sink requires an http or https url", c.Type)
+		}
+	case SinkTypeEmail:
+		if c.SMTP == "" || c.From == "" || len(c.To) == 0 {
+			return errors.New("email sink requires smtp, from and to")
+		}
+	default:
+		return errors.Errorf("unknown sink type %s", c.Type)
+	}
+
+	return nil
+}
+
+// sink creates the configured sink.
+func (c *SinkConfig) sink() Sink {
+	switch c.Type {
+	case SinkTypeMattermost:
+		return &MattermostWebhook{URL: c.URL, Username: c.Username, Channel: c.Channel}
+	case SinkTypeSlack:
+		return &SlackWebhook{URL: c.URL}
+	case SinkTypeEmail:
+		return &Email{Addr: c.SMTP, From: c.From, To: c.To, Username: c.SMTPUsername, Password: c.SMTPPassword}
+	default:
+		return &HTTP{URL: c.URL, Headers: c.Headers}
+	}
+}
+
+// NewFromConfig creates a notifier delivering to the sinks of the given configuration.
+func NewFromConfig(config *Config, logger logrus.FieldLogger) *Notifier {
+	notifier := New(logger)
+	for _, sinkConfig := range config.Sinks {
+		name := sinkConfig.Name
+		if name == "" {
+			name = sinkConfig.Type
+		}
+		notifier.AddSink(name, sinkConfig.sink(), sinkConfig.Events...)
+	}
+
+	return notifier
+}
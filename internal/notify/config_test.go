@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+)
+
+func TestConfigFromReader(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		config, err := ConfigFromReader(strings.NewReader(`
+sinks:
+  - type: mattermost
+    url: https://mattermost.example.com/hooks/abc
+    events: [new_plugin, new_version]
+  - name: security
+    type: email
+    smtp: smtp.example.com:587
+    from: marketplace@example.com
+    to: [security@example.com]
+    events: [advisory, delisting]
+  - type: slack
+    url: https://hooks.slack.com/services/abc
+  - type: http
+    url: https://example.com/events
+    headers:
+      Authorization: Bearer token
+`))
+		require.NoError(t, err)
+		require.Len(t, config.Sinks, 4)
+		require.Equal(t, []EventType{EventAdvisory, EventDelisting}, config.Sinks[1].Events)
+
+		notifier := NewFromConfig(config, testlib.MakeLogger(t))
+		require.Len(t, notifier.routes, 4)
+		require.Equal(t, "mattermost", notifier.routes[0].name)
+		require.Equal(t, "security", notifier.routes[1].name)
+		require.Equal(t, &Email{Addr: "smtp.example.com:587", From: "marketplace@example.com", To: []string{"security@example.com"}}, notifier.routes[1].sink)
+		require.True(t, notifier.routes[1].events[EventAdvisory])
+		require.False(t, notifier.routes[1].events[EventNewPlugin])
+		require.Nil(t, notifier.routes[2].events)
+		require.Equal(t, &HTTP{URL: "https://example.com/events", Headers: map[string]string{"Authorization": "Bearer token"}}, notifier.routes[3].sink)
+	})
+
+	invalid := map[string]string{
+		"unknown field":      "sinks: []\nunknown: true",
+		"unknown type":       "sinks: [{type: pager, url: https://example.com}]",
+		"unknown event":      "sinks: [{type: slack, url: https://example.com, events: [rating]}]",
+		"missing url":        "sinks: [{type: mattermost}]",
+		"invalid url":        "sinks: [{type: http, url: ftp://example.com}]",
+		"incomplete email":   "sinks: [{type: email, smtp: smtp.example.com:25, from: a@example.com}]",
+		"empty sink in list": "sinks: [~]",
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := ConfigFromReader(strings.NewReader(data))
+			require.Error(t, err)
+		})
+	}
+}
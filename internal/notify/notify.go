@@ -0,0 +1,195 @@
+// Package notify delivers notifications of changes to the catalog, such as newly published
+// plugins and security advisories, to pluggable sinks like chat webhooks and email.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// DefaultTimeout bounds the delivery of a notification to a single sink.
+const DefaultTimeout = 30 * time.Second
+
+// EventType identifies the kind of change a notification reports.
+type EventType string
+
+const (
+	// EventNewPlugin reports the first version of a plugin published to the catalog.
+	EventNewPlugin EventType = "new_plugin"
+	// EventNewVersion reports another version of a plugin published to the catalog.
+	EventNewVersion EventType = "new_version"
+	// EventDelisting reports a plugin version removed from the catalog.
+	EventDelisting EventType = "delisting"
+	// EventAdvisory reports a security advisory published for a plugin.
+	EventAdvisory EventType = "advisory"
+	// EventDrift reports a plugin version whose bundle drifted from what the catalog records, as
+	// found by a consistency audit.
+	EventDrift EventType = "drift"
+)
+
+// EventTypes lists every event type.
+var EventTypes = []EventType{EventNewPlugin, EventNewVersion, EventDelisting, EventAdvisory, EventDrift}
+
+// IsValid reports whether the event type is one of the known values.
+func (t EventType) IsValid() bool {
+	for _, eventType := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Event is a change to the catalog of which sinks are notified.
+type Event struct {
+	Type     EventType `json:"type"`
+	PluginID string    `json:"plugin_id"`
+	Version  string    `json:"version,omitempty"`
+	// Plugin is the published plugin version, for new plugins and versions.
+	Plugin *model.Plugin `json:"plugin,omitempty"`
+	// Advisory is the published advisory, for advisories.
+	Advisory *model.Advisory `json:"advisory,omitempty"`
+	// Finding is the drift found, for drift.
+	Finding    *model.AuditFinding `json:"finding,omitempty"`
+	OccurredAt time.Time           `json:"occurred_at"`
+}
+
+// Summary describes the event in a single line of plain text.
+func (e *Event) Summary() string {
+	name := e.PluginID
+	if e.Plugin != nil && e.Plugin.Manifest != nil && e.Plugin.Manifest.Name != "" {
+		name = fmt.Sprintf("%s (%s)", e.Plugin.Manifest.Name, e.PluginID)
+	}
+
+	switch e.Type {
+	case EventNewPlugin:
+		return fmt.Sprintf("New plugin %s %s published to the marketplace", name, e.Version)
+	case EventNewVersion:
+		return fmt.Sprintf("%s %s published to the marketplace", name, e.Version)
+	case EventDelisting:
+		return fmt.Sprintf("%s %s removed from the marketplace", name, e.Version)
+	case EventAdvisory:
+		if e.Advisory == nil {
+			return fmt.Sprintf("Security advisory published for %s", name)
+		}
+		summary := fmt.Sprintf("%s severity security advisory published for %s", e.Advisory.Severity, name)
+		if e.Advisory.FixedIn != "" {
+			summary += fmt.Sprintf(", fixed in %s", e.Advisory.FixedIn)
+		}
+		return summary + ": " + e.Advisory.Description
+	case EventDrift:
+		if e.Finding == nil {
+			return fmt.Sprintf("%s %s drifted from the catalog", name, e.Version)
+		}
+		return fmt.Sprintf("%s %s failed the %s check of the catalog audit: %s", name, e.Version, e.Finding.Check, e.Finding.Error)
+	default:
+		return fmt.Sprintf("%s %s %s", e.Type, name, e.Version)
+	}
+}
+
+// Sink delivers notifications to a destination, such as a chat webhook.
+type Sink interface {
+	Send(ctx context.Context, event *Event) error
+}
+
+// route delivers the events of the given types to a sink.
+type route struct {
+	name   string
+	sink   Sink
+	events map[EventType]bool
+}
+
+// Notifier routes each event to the sinks configured for its type, delivering it in the
+// background so that notifying never delays the change itself.
+type Notifier struct {
+	logger  logrus.FieldLogger
+	timeout time.Duration
+	now     func() time.Time
+
+	routes  []*route
+	pending sync.WaitGroup
+}
+
+// New creates a notifier without sinks.
+func New(logger logrus.FieldLogger) *Notifier {
+	return &Notifier{
+		logger:  logger,
+		timeout: DefaultTimeout,
+		now:     time.Now,
+	}
+}
+
+// AddSink routes the events of the given types to the given sink, identified by the given name in
+// logs, or every event if no type is given. Sinks must be added before notifying.
+func (n *Notifier) AddSink(name string, sink Sink, eventTypes ...EventType) {
+	r := &route{name: name, sink: sink}
+	if len(eventTypes) > 0 {
+		r.events = map[EventType]bool{}
+		for _, eventType := range eventTypes {
+			r.events[eventType] = true
+		}
+	}
+
+	n.routes = append(n.routes, r)
+}
+
+// Notify delivers the given event to each sink configured for its type, logging any failure.
+func (n *Notifier) Notify(event *Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = n.now().UTC()
+	}
+
+	for _, r := range n.routes {
+		if r.events != nil && !r.events[event.Type] {
+			continue
+		}
+
+		n.pending.Add(1)
+		go func(r *route) {
+			defer n.pending.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+			defer cancel()
+
+			if err := r.sink.Send(ctx, event); err != nil {
+				n.logger.WithError(err).WithFields(logrus.Fields{
+					"sink":      r.name,
+					"event":     event.Type,
+					"plugin_id": event.PluginID,
+				}).Error("Failed to deliver notification")
+			}
+		}(r)
+	}
+}
+
+// NotifyAdvisory notifies of the given published advisory.
+func (n *Notifier) NotifyAdvisory(advisory *model.Advisory) {
+	n.Notify(&Event{
+		Type:       EventAdvisory,
+		PluginID:   advisory.PluginID,
+		Advisory:   advisory,
+		OccurredAt: advisory.PublishedAt,
+	})
+}
+
+// NotifyDrift notifies of the given drift found by a consistency audit.
+func (n *Notifier) NotifyDrift(finding *model.AuditFinding) {
+	n.Notify(&Event{
+		Type:     EventDrift,
+		PluginID: finding.PluginID,
+		Version:  finding.Version,
+		Finding:  finding,
+	})
+}
+
+// Wait blocks until every notification in progress is delivered or fails.
+func (n *Notifier) Wait() {
+	n.pending.Wait()
+}
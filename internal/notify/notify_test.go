@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+)
+
+// recordingSink records the events it is sent.
+type recordingSink struct {
+	events chan *Event
+	err    error
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{events: make(chan *Event, 10)}
+}
+
+func (s *recordingSink) Send(ctx context.Context, event *Event) error {
+	s.events <- event
+	return s.err
+}
+
+// next returns the next event sent, failing if none is sent promptly.
+func (s *recordingSink) next(t *testing.T) *Event {
+	t.Helper()
+
+	select {
+	case event := <-s.events:
+		return event
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "expected an event")
+		return nil
+	}
+}
+
+func makePlugin(id, version string) *model.Plugin {
+	return &model.Plugin{
+		HomepageURL: "https://example.com/" + id,
+		Manifest:    &mattermostModel.Manifest{Id: id, Name: "Plugin " + id, Version: version},
+	}
+}
+
+func makeStore(t *testing.T, plugins ...*model.Plugin) *store.Store {
+	t.Helper()
+
+	if plugins == nil {
+		plugins = []*model.Plugin{}
+	}
+	data, err := json.Marshal(plugins)
+	require.NoError(t, err)
+
+	pluginStore, err := store.New(bytes.NewReader(data), testlib.MakeLogger(t))
+	require.NoError(t, err)
+
+	return pluginStore
+}
+
+func TestNotify(t *testing.T) {
+	all := newRecordingSink()
+	advisories := newRecordingSink()
+	failing := newRecordingSink()
+	failing.err = errors.New("unreachable")
+
+	notifier := New(testlib.MakeLogger(t))
+	notifier.AddSink("all", all)
+	notifier.AddSink("advisories", advisories, EventAdvisory)
+	notifier.AddSink("failing", failing, EventNewPlugin)
+
+	notifier.Notify(&Event{Type: EventNewPlugin, PluginID: "demo", Version: "0.1.0"})
+	notifier.NotifyAdvisory(&model.Advisory{ID: "a", PluginID: "demo", Severity: model.AdvisorySeverityHigh, Description: "Leaks secrets.", FixedIn: "0.2.0"})
+	notifier.Wait()
+
+	// Each event is delivered independently, in no particular order.
+	first, second := all.next(t), all.next(t)
+	require.ElementsMatch(t, []EventType{EventNewPlugin, EventAdvisory}, []EventType{first.Type, second.Type})
+	require.False(t, first.OccurredAt.IsZero())
+	require.Equal(t, "high severity security advisory published for demo, fixed in 0.2.0: Leaks secrets.", advisories.next(t).Summary())
+	require.Equal(t, EventNewPlugin, failing.next(t).Type)
+	require.Empty(t, advisories.events)
+	require.Empty(t, failing.events)
+}
+
+func TestSummary(t *testing.T) {
+	plugin := makePlugin("demo", "0.2.0")
+
+	require.Equal(t, "New plugin Plugin demo (demo) 0.2.0 published to the marketplace", (&Event{Type: EventNewPlugin, PluginID: "demo", Version: "0.2.0", Plugin: plugin}).Summary())
+	require.Equal(t, "Plugin demo (demo) 0.2.0 published to the marketplace", (&Event{Type: EventNewVersion, PluginID: "demo", Version: "0.2.0", Plugin: plugin}).Summary())
+	require.Equal(t, "demo 0.1.0 removed from the marketplace", (&Event{Type: EventDelisting, PluginID: "demo", Version: "0.1.0"}).Summary())
+	require.Equal(t, "low severity security advisory published for demo: Minor.", (&Event{Type: EventAdvisory, PluginID: "demo", Advisory: &model.Advisory{Severity: model.AdvisorySeverityLow, Description: "Minor."}}).Summary())
+	require.Equal(t, "demo 0.1.0 failed the checksum check of the catalog audit: checksum mismatch", (&Event{Type: EventDrift, PluginID: "demo", Version: "0.1.0", Finding: &model.AuditFinding{Check: model.AuditCheckChecksum, Error: "checksum mismatch"}}).Summary())
+}
+
+func TestWatch(t *testing.T) {
+	pluginCatalog := catalog.New(makeStore(t, makePlugin("demo", "0.1.0"), makePlugin("starter", "0.1.0")))
+
+	sink := newRecordingSink()
+	notifier := New(logrus.New())
+	notifier.AddSink("sink", sink)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		notifier.Watch(pluginCatalog, done)
+	}()
+
+	// Let the watcher subscribe before changing the catalog.
+	time.Sleep(50 * time.Millisecond)
+	pluginCatalog.Replace(makeStore(t, makePlugin("demo", "0.1.0"), makePlugin("demo", "0.2.0"), makePlugin("jira", "1.0.0")))
+
+	events := map[string]*Event{}
+	for i := 0; i < 3; i++ {
+		event := sink.next(t)
+		events[event.PluginID+" "+event.Version] = event
+	}
+	require.Equal(t, EventNewVersion, events["demo 0.2.0"].Type)
+	require.Equal(t, "https://example.com/demo", events["demo 0.2.0"].Plugin.HomepageURL)
+	require.Equal(t, EventNewPlugin, events["jira 1.0.0"].Type)
+	require.Equal(t, EventDelisting, events["starter 0.1.0"].Type)
+
+	// A plugin delisted entirely is new once published again.
+	pluginCatalog.Replace(makeStore(t, makePlugin("demo", "0.1.0"), makePlugin("demo", "0.2.0"), makePlugin("jira", "1.0.0"), makePlugin("starter", "0.2.0")))
+	event := sink.next(t)
+	require.Equal(t, EventNewPlugin, event.Type)
+	require.Equal(t, "starter", event.PluginID)
+
+	close(done)
+	pluginCatalog.Replace(makeStore(t))
+	<-stopped
+	notifier.Wait()
+}
@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MattermostWebhook posts notifications to a Mattermost incoming webhook.
+type MattermostWebhook struct {
+	URL string
+	// Username and Channel optionally override those configured for the webhook.
+	Username string
+	Channel  string
+	Client   *http.Client
+}
+
+// Send posts the event's summary, linking to the plugin's homepage if any.
+func (s *MattermostWebhook) Send(ctx context.Context, event *Event) error {
+	text := event.Summary()
+	if homepage := homepageURL(event); homepage != "" {
+		text += fmt.Sprintf(" ([homepage](%s))", homepage)
+	}
+
+	return postJSON(ctx, s.Client, s.URL, nil, &mattermostPayload{
+		Text:     text,
+		Username: s.Username,
+		Channel:  s.Channel,
+	})
+}
+
+// mattermostPayload is the body of a post to a Mattermost incoming webhook.
+type mattermostPayload struct {
+	Text     string `json:"text"`
+	Username string `json:"username,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+}
+
+// SlackWebhook posts notifications to a Slack incoming webhook.
+type SlackWebhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// Send posts the event's summary, linking to the plugin's homepage if any.
+func (s *SlackWebhook) Send(ctx context.Context, event *Event) error {
+	text := event.Summary()
+	if homepage := homepageURL(event); homepage != "" {
+		text += fmt.Sprintf(" (<%s|homepage>)", homepage)
+	}
+
+	return postJSON(ctx, s.Client, s.URL, nil, map[string]string{"text": text})
+}
+
+// HTTP posts each event as JSON to an arbitrary url.
+type HTTP struct {
+	URL string
+	// Headers are added to each request, e.g. to authenticate it.
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// Send posts the event as JSON.
+func (s *HTTP) Send(ctx context.Context, event *Event) error {
+	return postJSON(ctx, s.Client, s.URL, s.Headers, event)
+}
+
+// Email sends notifications by email through an SMTP server.
+type Email struct {
+	// Addr is the host and port of the SMTP server, e.g. smtp.example.com:587.
+	Addr string
+	From string
+	To   []string
+	// Username and Password optionally authenticate to the server.
+	Username string
+	Password string
+}
+
+// Send mails the event's summary as both the subject and the body of the message.
+func (s *Email) Send(ctx context.Context, event *Event) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return errors.Wrapf(err, "invalid smtp address %s", s.Addr)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	summary := event.Summary()
+	body := summary + "\r\n"
+	if homepage := homepageURL(event); homepage != "" {
+		body += "\r\n" + homepage + "\r\n"
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", s.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", headerValue(summary))
+	fmt.Fprintf(&message, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	message.WriteString(body)
+
+	// net/smtp offers no cancellation, so the send is abandoned rather than interrupted.
+	result := make(chan error, 1)
+	go func() {
+		result <- smtp.SendMail(s.Addr, auth, s.From, s.To, message.Bytes())
+	}()
+	select {
+	case err := <-result:
+		return errors.Wrap(err, "failed to send email")
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to send email")
+	}
+}
+
+// headerValue flattens the given text onto a single line, for use in a mail header.
+func headerValue(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// homepageURL returns the homepage of the plugin of the given event, if known.
+func homepageURL(event *Event) string {
+	if event.Plugin == nil {
+		return ""
+	}
+
+	return event.Plugin.HomepageURL
+}
+
+// postJSON posts the given value as JSON to the given url, failing unless answered with a 2xx
+// status.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, value interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode notification")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to post notification")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("failed to post notification: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
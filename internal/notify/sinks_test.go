@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordRequests serves the given status, recording the body and headers of each request. The
+// server must be closed.
+func recordRequests(t *testing.T, status int) (*httptest.Server, <-chan *http.Request, <-chan []byte) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests <- r
+		bodies <- body
+		w.WriteHeader(status)
+	}))
+
+	return server, requests, bodies
+}
+
+func TestSinks(t *testing.T) {
+	event := &Event{Type: EventNewVersion, PluginID: "demo", Version: "0.2.0", Plugin: makePlugin("demo", "0.2.0")}
+
+	t.Run("mattermost", func(t *testing.T) {
+		server, _, bodies := recordRequests(t, http.StatusOK)
+		defer server.Close()
+
+		sink := &MattermostWebhook{URL: server.URL, Username: "marketplace"}
+		require.NoError(t, sink.Send(context.Background(), event))
+		require.JSONEq(t, `{"text":"Plugin demo (demo) 0.2.0 published to the marketplace ([homepage](https://example.com/demo))","username":"marketplace"}`, string(<-bodies))
+	})
+
+	t.Run("slack", func(t *testing.T) {
+		server, _, bodies := recordRequests(t, http.StatusOK)
+		defer server.Close()
+
+		sink := &SlackWebhook{URL: server.URL}
+		require.NoError(t, sink.Send(context.Background(), event))
+		require.JSONEq(t, `{"text":"Plugin demo (demo) 0.2.0 published to the marketplace (<https://example.com/demo|homepage>)"}`, string(<-bodies))
+	})
+
+	t.Run("http", func(t *testing.T) {
+		server, requests, bodies := recordRequests(t, http.StatusNoContent)
+		defer server.Close()
+
+		sink := &HTTP{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+		require.NoError(t, sink.Send(context.Background(), event))
+		require.Equal(t, "Bearer token", (<-requests).Header.Get("Authorization"))
+
+		var sent Event
+		require.NoError(t, json.Unmarshal(<-bodies, &sent))
+		require.Equal(t, EventNewVersion, sent.Type)
+		require.Equal(t, "0.2.0", sent.Plugin.Manifest.Version)
+	})
+
+	t.Run("failing status", func(t *testing.T) {
+		server, _, _ := recordRequests(t, http.StatusBadRequest)
+		defer server.Close()
+
+		sink := &SlackWebhook{URL: server.URL}
+		require.EqualError(t, sink.Send(context.Background(), event), "failed to post notification: status 400")
+	})
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Catalog describes the change feed of a catalog, as implemented by catalog.Catalog.
+type Catalog interface {
+	AllPlugins() []*model.Plugin
+	Cursor() int64
+	ChangesSince(cursor int64) ([]*model.CatalogChange, <-chan struct{}, error)
+}
+
+// Watch notifies of the plugin versions published to and removed from the given catalog until
+// done is closed. A version added for a plugin without other versions in the catalog is reported
+// as a new plugin.
+func (n *Notifier) Watch(catalog Catalog, done <-chan struct{}) {
+	versions, cursor := countVersions(catalog)
+	for {
+		changes, updated, err := catalog.ChangesSince(cursor)
+		if err != nil {
+			// The watcher fell behind the retained changes, which are then lost.
+			n.logger.WithError(err).Warn("Resynchronizing notifications with the catalog")
+			versions, cursor = countVersions(catalog)
+			continue
+		}
+
+		for _, change := range changes {
+			cursor = change.Cursor
+			if event := n.changeEvent(change, versions); event != nil {
+				n.Notify(event)
+			}
+		}
+
+		select {
+		case <-done:
+			return
+		case <-updated:
+		}
+	}
+}
+
+// countVersions returns the number of versions of each plugin in the given catalog, along with
+// the cursor of its latest change.
+func countVersions(catalog Catalog) (map[string]int, int64) {
+	cursor := catalog.Cursor()
+
+	versions := map[string]int{}
+	for _, plugin := range catalog.AllPlugins() {
+		versions[plugin.Manifest.Id]++
+	}
+
+	return versions, cursor
+}
+
+// changeEvent returns the event reporting the given change, if any, accounting for it in the given
+// number of versions of each plugin.
+func (n *Notifier) changeEvent(change *model.CatalogChange, versions map[string]int) *Event {
+	event := &Event{
+		PluginID:   change.PluginID,
+		Version:    change.Version,
+		Plugin:     change.Plugin,
+		OccurredAt: change.ChangedAt,
+	}
+
+	switch change.Type {
+	case model.ChangeTypeAdded:
+		event.Type = EventNewVersion
+		if versions[change.PluginID] == 0 {
+			event.Type = EventNewPlugin
+		}
+		versions[change.PluginID]++
+	case model.ChangeTypeRemoved:
+		event.Type = EventDelisting
+		if versions[change.PluginID] > 0 {
+			versions[change.PluginID]--
+		}
+	default:
+		return nil
+	}
+
+	return event
+}
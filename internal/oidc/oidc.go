@@ -0,0 +1,325 @@
+// Package oidc authenticates requests bearing JSON Web Tokens issued by an OpenID Connect
+// provider, letting the marketplace's write path rely on single sign-on rather than shared
+// tokens. Tokens are verified against the signing keys the provider publishes, and their issuer,
+// audience, lifetime and any required claims checked.
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// DefaultUserClaim is the claim identifying the user to whom a token was issued.
+const DefaultUserClaim = "sub"
+
+// bearerPrefix introduces the token in an Authorization header.
+const bearerPrefix = "Bearer "
+
+// leeway tolerates clock skew between the marketplace and the provider when checking lifetimes.
+const leeway = time.Minute
+
+// minKeyRefreshInterval bounds how often the signing keys are fetched again on encountering a
+// token signed by an unknown key, such as after the provider rotated its keys.
+const minKeyRefreshInterval = time.Minute
+
+// supportedAlgorithms are the signing algorithms accepted, excluding symmetric algorithms whose
+// keys a provider never publishes.
+var supportedAlgorithms = map[string]bool{
+	string(jose.RS256): true,
+	string(jose.RS384): true,
+	string(jose.RS512): true,
+	string(jose.ES256): true,
+	string(jose.ES384): true,
+	string(jose.ES512): true,
+}
+
+// Options configures an authenticator.
+type Options struct {
+	// Issuer is the provider's issuer identifier, which tokens must carry in their iss claim.
+	Issuer string
+	// Audience must be among the audiences of each token, such as the marketplace's client id.
+	Audience string
+	// RequiredClaims maps claims to the value each token must carry, or include if the claim is a
+	// list, such as a group granting access to the marketplace.
+	RequiredClaims map[string]string
+	// UserClaim is the claim identifying the user. Defaults to DefaultUserClaim.
+	UserClaim string
+	// JWKSURL serves the provider's signing keys. Defaults to the jwks_uri discovered from the
+	// issuer's OpenID configuration.
+	JWKSURL string
+	// Client fetches the provider's configuration and signing keys. Defaults to a client with a
+	// short timeout.
+	Client *http.Client
+}
+
+// Authenticator authenticates requests bearing tokens issued by an OpenID Connect provider.
+type Authenticator struct {
+	issuer         string
+	audience       string
+	requiredClaims map[string]string
+	userClaim      string
+	client         *http.Client
+	logger         logrus.FieldLogger
+	now            func() time.Time
+
+	lock      sync.Mutex
+	jwksURL   string
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// New creates an authenticator. The provider is only contacted once a token is presented, so that
+// it being unavailable does not prevent the marketplace from starting.
+func New(logger logrus.FieldLogger, options Options) (*Authenticator, error) {
+	if options.Issuer == "" {
+		return nil, errors.New("issuer must not be empty")
+	}
+	if options.Audience == "" {
+		return nil, errors.New("audience must not be empty")
+	}
+
+	a := &Authenticator{
+		issuer:         strings.TrimSuffix(options.Issuer, "/"),
+		audience:       options.Audience,
+		requiredClaims: options.RequiredClaims,
+		userClaim:      options.UserClaim,
+		client:         options.Client,
+		logger:         logger,
+		now:            time.Now,
+		jwksURL:        options.JWKSURL,
+	}
+	if a.userClaim == "" {
+		a.userClaim = DefaultUserClaim
+	}
+	if a.client == nil {
+		a.client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return a, nil
+}
+
+// Authenticate returns the user to whom the request's bearer token was issued, provided it is a
+// valid token from the provider.
+func (a *Authenticator) Authenticate(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+	// Only tokens shaped like a JSON Web Token are for this authenticator to judge.
+	if strings.Count(token, ".") != 2 {
+		return "", false
+	}
+
+	userID, err := a.Verify(token)
+	if err != nil {
+		a.logger.WithError(err).Debug("Rejected bearer token")
+		return "", false
+	}
+
+	return userID, true
+}
+
+// Verify verifies the given token, returning the user to whom it was issued.
+func (a *Authenticator) Verify(token string) (string, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse token")
+	}
+	if len(parsed.Headers) != 1 {
+		return "", errors.New("token must carry exactly one signature")
+	}
+	header := parsed.Headers[0]
+	if !supportedAlgorithms[header.Algorithm] {
+		return "", errors.Errorf("unsupported signing algorithm %q", header.Algorithm)
+	}
+
+	key, err := a.key(header.KeyID, header.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	var standardClaims jwt.Claims
+	var claims map[string]interface{}
+	if err := parsed.Claims(key, &standardClaims, &claims); err != nil {
+		return "", errors.Wrap(err, "failed to verify token")
+	}
+	if err := a.checkClaims(&standardClaims, claims); err != nil {
+		return "", err
+	}
+
+	userID, _ := claims[a.userClaim].(string)
+	if userID == "" {
+		return "", errors.Errorf("token has no %s claim", a.userClaim)
+	}
+
+	return userID, nil
+}
+
+// checkClaims verifies the given claims name the expected issuer and audience, are within their
+// lifetime, and carry the required claims.
+func (a *Authenticator) checkClaims(standardClaims *jwt.Claims, claims map[string]interface{}) error {
+	if strings.TrimSuffix(standardClaims.Issuer, "/") != a.issuer {
+		return errors.Errorf("token issued by %q rather than %q", standardClaims.Issuer, a.issuer)
+	}
+	if standardClaims.Expiry == nil {
+		return errors.New("token has no expiry")
+	}
+	expected := jwt.Expected{Audience: jwt.Audience{a.audience}, Time: a.now()}
+	if err := standardClaims.ValidateWithLeeway(expected, leeway); err != nil {
+		return err
+	}
+
+	for claim, value := range a.requiredClaims {
+		if !claimIncludes(claims[claim], value) {
+			return errors.Errorf("token lacks required claim %s=%s", claim, value)
+		}
+	}
+
+	return nil
+}
+
+// claimIncludes reports whether the given claim is, or is a list including, the given value.
+func claimIncludes(claim interface{}, value string) bool {
+	switch claim := claim.(type) {
+	case nil:
+		return false
+	case []interface{}:
+		for _, element := range claim {
+			if claimIncludes(element, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return fmt.Sprint(claim) == value
+	}
+}
+
+// key returns the provider's signing key with the given id, or its only key usable with the given
+// algorithm if the token names none, fetching the keys if not yet known.
+func (a *Authenticator) key(keyID, algorithm string) (interface{}, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if key := a.findKey(keyID, algorithm); key != nil {
+		return key, nil
+	}
+	if !a.fetchedAt.IsZero() && a.now().Sub(a.fetchedAt) < minKeyRefreshInterval {
+		return nil, errors.Errorf("unknown signing key %q", keyID)
+	}
+
+	if err := a.fetchKeys(); err != nil {
+		return nil, err
+	}
+	if key := a.findKey(keyID, algorithm); key != nil {
+		return key, nil
+	}
+
+	return nil, errors.Errorf("unknown signing key %q", keyID)
+}
+
+// keyMatchesAlgorithm reports whether the given public key is usable with the given algorithm.
+func keyMatchesAlgorithm(key interface{}, algorithm string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(algorithm, "RS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(algorithm, "ES")
+	default:
+		return false
+	}
+}
+
+// findKey returns the known signing key with the given id or, given no id, the only known key
+// usable with the given algorithm.
+func (a *Authenticator) findKey(keyID, algorithm string) interface{} {
+	if keyID != "" {
+		return a.keys[keyID]
+	}
+
+	var found interface{}
+	for _, key := range a.keys {
+		if keyMatchesAlgorithm(key, algorithm) {
+			if found != nil {
+				return nil
+			}
+			found = key
+		}
+	}
+
+	return found
+}
+
+// fetchKeys fetches the provider's signing keys, discovering where they are served if necessary.
+func (a *Authenticator) fetchKeys() error {
+	a.fetchedAt = a.now()
+
+	if a.jwksURL == "" {
+		var configuration struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := a.getJSON(a.issuer+"/.well-known/openid-configuration", &configuration); err != nil {
+			return errors.Wrap(err, "failed to discover openid configuration")
+		}
+		if configuration.JWKSURI == "" {
+			return errors.New("openid configuration has no jwks_uri")
+		}
+		a.jwksURL = configuration.JWKSURI
+	}
+
+	// The keys are decoded one by one, so that a key of an unsupported type is skipped rather than
+	// failing the whole set.
+	var keySet struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := a.getJSON(a.jwksURL, &keySet); err != nil {
+		return errors.Wrap(err, "failed to fetch signing keys")
+	}
+
+	keys := map[string]interface{}{}
+	for i, data := range keySet.Keys {
+		var jwk jose.JSONWebKey
+		if err := jwk.UnmarshalJSON(data); err != nil {
+			a.logger.WithError(err).Warnf("Ignoring signing key %d", i)
+			continue
+		}
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if !jwk.IsPublic() || !jwk.Valid() {
+			a.logger.Warnf("Ignoring signing key %d (kid %q) that is not a valid public key", i, jwk.KeyID)
+			continue
+		}
+		keys[jwk.KeyID] = jwk.Key
+	}
+	a.keys = keys
+
+	return nil
+}
+
+// getJSON decodes the json-encoded response to a GET request of the given url into v.
+func (a *Authenticator) getJSON(u string, v interface{}) error {
+	resp, err := a.client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("%s responded with status %d", u, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
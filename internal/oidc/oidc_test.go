@@ -0,0 +1,219 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+)
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func TestAuthenticator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	hmacKey := []byte("shared secret that must never verify a token")
+
+	keys := []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": encodeBigInt(rsaKey.N), "e": encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encodeBigInt(ecKey.X), "y": encodeBigInt(ecKey.Y)},
+		{"kty": "oct", "kid": "hmac", "k": base64.RawURLEncoding.EncodeToString(hmacKey)},
+		{"kty": "unknown", "kid": "unknown"},
+	}
+	var keyRequests int32
+	var provider *httptest.Server
+	provider = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": provider.URL, "jwks_uri": provider.URL + "/keys"})
+		case "/keys":
+			atomic.AddInt32(&keyRequests, 1)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer provider.Close()
+
+	now := time.Now()
+	sign := func(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+		signed := encodeSegment(t, map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encodeSegment(t, claims)
+		hasher := crypto.SHA256.New()
+		hasher.Write([]byte(signed))
+		digest := hasher.Sum(nil)
+
+		var signature []byte
+		if alg == "HS256" {
+			mac := hmac.New(sha256.New, hmacKey)
+			mac.Write([]byte(signed))
+			signature = mac.Sum(nil)
+		} else if alg == "ES256" {
+			r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest)
+			require.NoError(t, err)
+			signature = make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest)
+			require.NoError(t, err)
+		}
+
+		return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":    provider.URL,
+			"aud":    []string{"marketplace", "other"},
+			"sub":    "alice",
+			"email":  "alice@example.com",
+			"groups": []string{"engineering", "marketplace-admins"},
+			"exp":    now.Add(time.Hour).Unix(),
+			"nbf":    now.Add(-time.Minute).Unix(),
+		}
+	}
+
+	authenticator, err := New(testlib.MakeLogger(t), Options{
+		Issuer:         provider.URL,
+		Audience:       "marketplace",
+		RequiredClaims: map[string]string{"groups": "marketplace-admins"},
+	})
+	require.NoError(t, err)
+
+	authenticate := func(authenticator api.Authenticator, token string) (string, bool) {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/admin/catalog", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return authenticator.Authenticate(r)
+	}
+
+	t.Run("valid tokens", func(t *testing.T) {
+		userID, ok := authenticate(authenticator, sign(t, "RS256", "rsa", validClaims()))
+		require.True(t, ok)
+		require.Equal(t, "alice", userID)
+
+		userID, ok = authenticate(authenticator, sign(t, "ES256", "ec", validClaims()))
+		require.True(t, ok)
+		require.Equal(t, "alice", userID)
+
+		require.EqualValues(t, 1, atomic.LoadInt32(&keyRequests), "keys are fetched once")
+	})
+
+	t.Run("without key id", func(t *testing.T) {
+		userID, ok := authenticate(authenticator, sign(t, "ES256", "", validClaims()))
+		require.True(t, ok)
+		require.Equal(t, "alice", userID)
+	})
+
+	t.Run("invalid tokens", func(t *testing.T) {
+		for description, modify := range map[string]func(claims map[string]interface{}){
+			"other issuer":           func(claims map[string]interface{}) { claims["iss"] = "https://evil.example.com" },
+			"other audience":         func(claims map[string]interface{}) { claims["aud"] = "other" },
+			"expired":                func(claims map[string]interface{}) { claims["exp"] = now.Add(-time.Hour).Unix() },
+			"no expiry":              func(claims map[string]interface{}) { delete(claims, "exp") },
+			"not yet valid":          func(claims map[string]interface{}) { claims["nbf"] = now.Add(time.Hour).Unix() },
+			"missing required claim": func(claims map[string]interface{}) { claims["groups"] = []string{"engineering"} },
+			"no user":                func(claims map[string]interface{}) { delete(claims, "sub") },
+		} {
+			claims := validClaims()
+			modify(claims)
+			_, ok := authenticate(authenticator, sign(t, "RS256", "rsa", claims))
+			require.False(t, ok, description)
+		}
+	})
+
+	t.Run("invalid signatures", func(t *testing.T) {
+		token := sign(t, "RS256", "rsa", validClaims())
+		otherToken := sign(t, "RS256", "rsa", map[string]interface{}{"sub": "mallory"})
+		_, ok := authenticate(authenticator, token[:len(token)-4]+otherToken[len(otherToken)-4:])
+		require.False(t, ok)
+
+		_, ok = authenticate(authenticator, sign(t, "RS256", "ec", validClaims()))
+		require.False(t, ok, "key not usable with the algorithm")
+
+		_, ok = authenticate(authenticator, sign(t, "HS256", "hmac", validClaims()))
+		require.False(t, ok, "symmetric keys are never trusted")
+
+		_, ok = authenticate(authenticator, "not-a-token")
+		require.False(t, ok)
+	})
+
+	t.Run("unknown keys refetched at most once a minute", func(t *testing.T) {
+		before := atomic.LoadInt32(&keyRequests)
+		_, ok := authenticate(authenticator, sign(t, "RS256", "rotated", validClaims()))
+		require.False(t, ok)
+		_, ok = authenticate(authenticator, sign(t, "RS256", "rotated", validClaims()))
+		require.False(t, ok)
+		require.Equal(t, before, atomic.LoadInt32(&keyRequests))
+
+		keys[0]["kid"] = "rotated"
+		authenticator.now = func() time.Time { return now.Add(2 * time.Minute) }
+		defer func() { authenticator.now = time.Now }()
+		userID, ok := authenticate(authenticator, sign(t, "RS256", "rotated", validClaims()))
+		require.True(t, ok)
+		require.Equal(t, "alice", userID)
+		require.Equal(t, before+1, atomic.LoadInt32(&keyRequests))
+	})
+
+	t.Run("user claim", func(t *testing.T) {
+		authenticator, err := New(testlib.MakeLogger(t), Options{
+			Issuer:    provider.URL,
+			Audience:  "marketplace",
+			UserClaim: "email",
+			JWKSURL:   provider.URL + "/keys",
+		})
+		require.NoError(t, err)
+
+		userID, ok := authenticate(authenticator, sign(t, "ES256", "ec", validClaims()))
+		require.True(t, ok)
+		require.Equal(t, "alice@example.com", userID)
+	})
+
+	t.Run("alongside static tokens", func(t *testing.T) {
+		authenticators := api.MultiAuthenticator{api.TokenAuthenticator{"static-token": "bob"}, authenticator}
+
+		userID, ok := authenticate(authenticators, "static-token")
+		require.True(t, ok)
+		require.Equal(t, "bob", userID)
+
+		userID, ok = authenticate(authenticators, sign(t, "ES256", "ec", validClaims()))
+		require.True(t, ok)
+		require.Equal(t, "alice", userID)
+
+		_, ok = authenticate(authenticators, "unknown-token")
+		require.False(t, ok)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		_, err := New(testlib.MakeLogger(t), Options{Audience: "marketplace"})
+		require.EqualError(t, err, "issuer must not be empty")
+
+		_, err = New(testlib.MakeLogger(t), Options{Issuer: provider.URL})
+		require.EqualError(t, err, "audience must not be empty")
+	})
+}
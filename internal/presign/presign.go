@@ -0,0 +1,112 @@
+// Package presign mints short-lived urls granting access to private resources, such as the
+// bundles of private plugins, and verifies them when presented. A url is signed with an HMAC over
+// its path and query string, including its expiry, so that it can neither be altered nor used
+// beyond its lifetime.
+package presign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ExpiresParameter carries the unix time at which a signed url expires.
+	ExpiresParameter = "expires"
+	// SignatureParameter carries the signature of a signed url.
+	SignatureParameter = "signature"
+)
+
+// minKeySize is the least number of bytes of a signing key.
+const minKeySize = 32
+
+var (
+	// ErrUnsigned is returned when verifying a url that was not signed.
+	ErrUnsigned = errors.New("url is not signed")
+	// ErrInvalidSignature is returned when verifying a url whose signature does not match it.
+	ErrInvalidSignature = errors.New("invalid url signature")
+	// ErrExpired is returned when verifying a signed url past its expiry.
+	ErrExpired = errors.New("signed url expired")
+)
+
+// Signer mints and verifies signed urls.
+type Signer struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+// New creates a signer with the given key, minting urls valid for at least the given lifetime.
+func New(key []byte, ttl time.Duration) (*Signer, error) {
+	if len(key) < minKeySize {
+		return nil, errors.Errorf("signing key must be at least %d bytes", minKeySize)
+	}
+	if ttl <= 0 {
+		return nil, errors.New("lifetime must be positive")
+	}
+
+	return &Signer{key: key, ttl: ttl, now: time.Now}, nil
+}
+
+// Expiry returns the expiry of the urls minted now. Expiries are rounded up to the next multiple
+// of the lifetime beyond it, so that the urls minted for a resource only change once per lifetime
+// and responses carrying them may be cached meanwhile. A url is thus valid for at least the
+// lifetime, and at most twice it.
+func (s *Signer) Expiry() time.Time {
+	return s.now().Truncate(s.ttl).Add(2 * s.ttl)
+}
+
+// Sign adds an expiry and a signature to the query string of the given url.
+func (s *Signer) Sign(u *url.URL) {
+	q := u.Query()
+	q.Del(SignatureParameter)
+	q.Set(ExpiresParameter, strconv.FormatInt(s.Expiry().Unix(), 10))
+	q.Set(SignatureParameter, s.signature(u.EscapedPath(), q))
+	u.RawQuery = q.Encode()
+}
+
+// Verify verifies the given url was signed by the signer and has not yet expired.
+func (s *Signer) Verify(u *url.URL) error {
+	q := u.Query()
+	signature := q.Get(SignatureParameter)
+	expires := q.Get(ExpiresParameter)
+	if signature == "" || expires == "" {
+		return ErrUnsigned
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.signature(u.EscapedPath(), q))) {
+		return ErrInvalidSignature
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !s.now().Before(time.Unix(expiresAt, 0)) {
+		return ErrExpired
+	}
+
+	return nil
+}
+
+// signature returns the signature of the given path and query string, excluding any signature.
+func (s *Signer) signature(path string, q url.Values) string {
+	unsigned := url.Values{}
+	for key, values := range q {
+		if key != SignatureParameter {
+			unsigned[key] = values
+		}
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(path))
+	mac.Write([]byte{'?'})
+	mac.Write([]byte(unsigned.Encode()))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,87 @@
+package presign
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Date(2019, 12, 1, 12, 2, 0, 0, time.UTC)
+
+	signer, err := New(key, 5*time.Minute)
+	require.NoError(t, err)
+	signer.now = func() time.Time { return now }
+
+	sign := func(t *testing.T, rawURL string) *url.URL {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		signer.Sign(u)
+		return u
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		u := sign(t, "https://marketplace.example.com/downloads/demo/0.1.0.tar.gz?platform=linux-amd64")
+		require.Equal(t, "linux-amd64", u.Query().Get("platform"))
+		require.Equal(t, "1575202200", u.Query().Get(ExpiresParameter))
+		require.NoError(t, signer.Verify(u))
+	})
+
+	t.Run("stable within a lifetime", func(t *testing.T) {
+		first := sign(t, "https://marketplace.example.com/downloads/demo/0.1.0.tar.gz")
+		now = now.Add(2 * time.Minute)
+		defer func() { now = now.Add(-2 * time.Minute) }()
+		require.Equal(t, first.String(), sign(t, "https://marketplace.example.com/downloads/demo/0.1.0.tar.gz").String())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		u := sign(t, "https://marketplace.example.com/downloads/demo/0.1.0.tar.gz")
+
+		now = now.Add(8 * time.Minute)
+		defer func() { now = now.Add(-8 * time.Minute) }()
+		require.Equal(t, ErrExpired, signer.Verify(u))
+	})
+
+	t.Run("altered", func(t *testing.T) {
+		u := sign(t, "https://marketplace.example.com/downloads/demo/0.1.0.tar.gz?platform=linux-amd64")
+
+		altered := *u
+		altered.Path = "/downloads/demo/0.2.0.tar.gz"
+		require.Equal(t, ErrInvalidSignature, signer.Verify(&altered))
+
+		q := u.Query()
+		q.Set("platform", "windows-amd64")
+		altered = *u
+		altered.RawQuery = q.Encode()
+		require.Equal(t, ErrInvalidSignature, signer.Verify(&altered))
+
+		q = u.Query()
+		q.Set(ExpiresParameter, "4102444800")
+		altered = *u
+		altered.RawQuery = q.Encode()
+		require.Equal(t, ErrInvalidSignature, signer.Verify(&altered))
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		other, err := New([]byte("fedcba9876543210fedcba9876543210"), 5*time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, ErrInvalidSignature, other.Verify(sign(t, "https://marketplace.example.com/downloads/demo/0.1.0.tar.gz")))
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		u, err := url.Parse("https://marketplace.example.com/downloads/demo/0.1.0.tar.gz")
+		require.NoError(t, err)
+		require.Equal(t, ErrUnsigned, signer.Verify(u))
+	})
+
+	t.Run("options", func(t *testing.T) {
+		_, err := New([]byte("short"), time.Minute)
+		require.EqualError(t, err, "signing key must be at least 32 bytes")
+
+		_, err = New(key, 0)
+		require.EqualError(t, err, "lifetime must be positive")
+	})
+}
@@ -0,0 +1,370 @@
+// Package proxy serves the catalog of an upstream marketplace, persisting the last catalog fetched
+// so that it keeps being served, marked stale, while the upstream is unreachable.
+package proxy
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+const (
+	// DefaultRefreshInterval is how long the upstream catalog is served before it is fetched again.
+	DefaultRefreshInterval = 5 * time.Minute
+	// DefaultRetryInterval is how long the cached catalog is served after the upstream fails,
+	// before it is tried again.
+	DefaultRetryInterval = 30 * time.Second
+	// DefaultMaxRetryInterval caps the retry interval, which doubles with each consecutive
+	// failure of the upstream.
+	DefaultMaxRetryInterval = 10 * time.Minute
+	// DefaultTimeout bounds the time taken to fetch the upstream catalog.
+	DefaultTimeout = time.Minute
+	// DefaultRefreshWait bounds how long a query waits on the refresh it triggers.
+	DefaultRefreshWait = 5 * time.Second
+)
+
+// Options configures a proxy store.
+type Options struct {
+	// CacheFile, if set, persists the last catalog fetched from the upstream, served at startup
+	// should the upstream be unreachable.
+	CacheFile string
+	// RefreshInterval is how long the upstream catalog is served before it is fetched again.
+	// Defaults to DefaultRefreshInterval.
+	RefreshInterval time.Duration
+	// RetryInterval is how long the cached catalog is served after the upstream fails, before it
+	// is tried again. Defaults to DefaultRetryInterval.
+	RetryInterval time.Duration
+	// MaxRetryInterval caps the retry interval, which doubles with each consecutive failure of
+	// the upstream so that a struggling upstream is not hammered. Defaults to
+	// DefaultMaxRetryInterval.
+	MaxRetryInterval time.Duration
+	// Timeout bounds the time taken to fetch the upstream catalog. Defaults to DefaultTimeout.
+	Timeout time.Duration
+	// RefreshWait bounds how long a query waits on the refresh it triggers before the current
+	// catalog is served, the refresh completing in the background. Defaults to
+	// DefaultRefreshWait.
+	RefreshWait time.Duration
+	// CatalogOptions configures the catalog serving the upstream plugins, whose store options
+	// validate each catalog fetched.
+	CatalogOptions catalog.Options
+}
+
+// Store serves the plugins of an upstream marketplace from a catalog, fetching the upstream
+// catalog again on the first query once the refresh interval elapses. Should the upstream fail,
+// the catalog last fetched keeps being served, and is reported stale, until the upstream recovers.
+// A slow upstream delays queries by at most the refresh wait, and a failing one is retried less
+// and less often. Apps are not proxied.
+//
+// The optional interfaces of the api package, such as api.Changes, are looked up on the catalog,
+// which Unwrap returns.
+type Store struct {
+	upstream         *api.Client
+	catalog          *catalog.Catalog
+	cache            *catalog.FileBackend
+	refreshInterval  time.Duration
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+	timeout          time.Duration
+	refreshWait      time.Duration
+	storeOptions     store.Options
+	logger           logrus.FieldLogger
+	now              func() time.Time
+
+	lock   sync.Mutex
+	health model.StoreHealth
+	// fetchedAt is when the catalog served was fetched from the upstream.
+	fetchedAt time.Time
+	// refreshAt is when the upstream catalog is next fetched, unless refreshing already.
+	refreshAt  time.Time
+	refreshing bool
+}
+
+// New creates a store serving the catalog fetched from the given upstream, or else the one cached
+// by an earlier store, failing if neither is available.
+func New(upstream *api.Client, logger logrus.FieldLogger, options Options) (*Store, error) {
+	s := &Store{
+		upstream:         upstream,
+		refreshInterval:  options.RefreshInterval,
+		retryInterval:    options.RetryInterval,
+		maxRetryInterval: options.MaxRetryInterval,
+		timeout:          options.Timeout,
+		refreshWait:      options.RefreshWait,
+		storeOptions:     options.CatalogOptions.StoreOptions,
+		logger:           logger,
+		now:              time.Now,
+	}
+	if s.refreshInterval <= 0 {
+		s.refreshInterval = DefaultRefreshInterval
+	}
+	if s.retryInterval <= 0 {
+		s.retryInterval = DefaultRetryInterval
+	}
+	if s.maxRetryInterval <= 0 {
+		s.maxRetryInterval = DefaultMaxRetryInterval
+	}
+	if s.maxRetryInterval < s.retryInterval {
+		s.maxRetryInterval = s.retryInterval
+	}
+	if s.timeout <= 0 {
+		s.timeout = DefaultTimeout
+	}
+	if s.refreshWait <= 0 {
+		s.refreshWait = DefaultRefreshWait
+	}
+	if options.CacheFile != "" {
+		s.cache = &catalog.FileBackend{Path: options.CacheFile}
+	}
+
+	initialStore, err := s.fetch()
+	if err == nil {
+		s.fetchedAt = s.now()
+		s.refreshAt = s.fetchedAt.Add(s.refreshInterval)
+	} else {
+		if s.cache == nil {
+			return nil, err
+		}
+
+		var cacheErr error
+		initialStore, s.fetchedAt, cacheErr = s.loadCache()
+		if cacheErr != nil {
+			return nil, errors.Wrapf(err, "failed to load cached catalog (%v)", cacheErr)
+		}
+		s.recordFailure(err)
+	}
+
+	s.catalog = catalog.NewWithOptions(initialStore, options.CatalogOptions)
+
+	return s, nil
+}
+
+// GetPlugins fetches the given page of plugins.
+func (s *Store) GetPlugins(filter *model.PluginFilter) ([]*model.Plugin, error) {
+	s.query()
+	return s.catalog.GetPlugins(filter)
+}
+
+// GetPluginVersions fetches every version of the given plugin.
+func (s *Store) GetPluginVersions(id string) ([]*model.Plugin, error) {
+	s.query()
+	return s.catalog.GetPluginVersions(id)
+}
+
+// GetApps fetches the given page of apps, of which there are none.
+func (s *Store) GetApps(filter *model.AppFilter) ([]*model.App, error) {
+	s.query()
+	return s.catalog.GetApps(filter)
+}
+
+// Unwrap returns the catalog serving the upstream plugins.
+func (s *Store) Unwrap() api.Store {
+	return s.catalog
+}
+
+// Catalog returns the catalog serving the upstream plugins, whose changes may be watched.
+func (s *Store) Catalog() *catalog.Catalog {
+	return s.catalog
+}
+
+// Health reports whether the cached catalog is served while the upstream fails, and the upstream's
+// failures.
+func (s *Store) Health() *model.StoreHealth {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	health := s.health
+	return &health
+}
+
+// Staleness returns when the catalog served was fetched from the upstream, and whether the
+// upstream failed since.
+func (s *Store) Staleness() (time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.fetchedAt, s.health.Degraded
+}
+
+// Refresh fetches the upstream catalog, serving it in place of the current one.
+func (s *Store) Refresh() error {
+	s.lock.Lock()
+	s.refreshing = true
+	s.lock.Unlock()
+
+	return s.refresh()
+}
+
+// query refreshes the catalog if it is due, before a query is served. Should the refresh take
+// longer than the refresh wait, the current catalog is served while it completes in the
+// background.
+func (s *Store) query() {
+	s.lock.Lock()
+	due := !s.refreshing && !s.now().Before(s.refreshAt)
+	if due {
+		s.refreshing = true
+	}
+	s.lock.Unlock()
+
+	if due {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			// Failures are logged as they are recorded, and the current catalog is served
+			// regardless.
+			_ = s.refresh()
+		}()
+
+		timer := time.NewTimer(s.refreshWait)
+		select {
+		case <-done:
+		case <-timer.C:
+			s.logger.Warn("Upstream is slow, serving the current catalog while refreshing")
+		}
+		timer.Stop()
+	}
+
+	s.lock.Lock()
+	if s.health.Degraded {
+		s.health.Failovers++
+	}
+	s.lock.Unlock()
+}
+
+// refresh fetches the upstream catalog, serving it in place of the current one should it have
+// changed, and clears the refreshing flag set by the caller.
+func (s *Store) refresh() error {
+	fetchedStore, err := s.fetch()
+	if err != nil {
+		s.recordFailure(err)
+		return err
+	}
+
+	// Replacing an unchanged catalog would needlessly retain it as a snapshot.
+	if len(model.DiffPlugins(s.catalog.AllPlugins(), fetchedStore.AllPlugins()).CatalogChanges()) > 0 {
+		changes := s.catalog.Replace(fetchedStore)
+		s.logger.WithField("changes", len(changes)).Info("Refreshed upstream catalog")
+	}
+	s.recordSuccess()
+
+	return nil
+}
+
+// fetch fetches every version of every plugin from the upstream, persisting them to the cache
+// file, if any, once validated.
+func (s *Store) fetch() (*store.Store, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	upstream := s.upstream.WithContext(ctx)
+
+	latest, err := upstream.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch upstream plugins")
+	}
+
+	var plugins []*model.Plugin
+	for _, plugin := range latest {
+		if plugin.Manifest == nil {
+			return nil, errors.New("failed to fetch upstream plugins: plugin without manifest")
+		}
+		versions, err := upstream.GetPluginVersions(plugin.Manifest.Id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch upstream versions of %s", plugin.Manifest.Id)
+		}
+		plugins = append(plugins, versions...)
+	}
+
+	fetchedStore, err := store.NewFromPlugins(plugins, s.logger, s.storeOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to validate upstream plugins")
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Save(fetchedStore.AllPlugins()); err != nil {
+			s.logger.WithError(err).Error("Failed to cache upstream catalog")
+		}
+	}
+
+	return fetchedStore, nil
+}
+
+// loadCache loads the catalog persisted to the cache file, returning when it was fetched.
+func (s *Store) loadCache() (*store.Store, time.Time, error) {
+	file, err := os.Open(s.cache.Path)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "failed to open %s", s.cache.Path)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "failed to stat %s", s.cache.Path)
+	}
+
+	cachedStore, err := store.NewWithOptions(file, s.logger, s.storeOptions)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "failed to load %s", s.cache.Path)
+	}
+
+	return cachedStore, info.ModTime(), nil
+}
+
+func (s *Store) recordSuccess() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	s.fetchedAt = now
+	s.refreshAt = now.Add(s.refreshInterval)
+	s.refreshing = false
+
+	if !s.health.Degraded {
+		return
+	}
+
+	s.health.Degraded = false
+	s.health.ConsecutiveFailures = 0
+	s.health.RecoveredAt = now
+	s.logger.Info("Upstream recovered")
+}
+
+func (s *Store) recordFailure(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	s.health.ConsecutiveFailures++
+	s.health.LastError = err.Error()
+	s.health.LastFailureAt = now
+	s.refreshAt = now.Add(s.backoff(s.health.ConsecutiveFailures))
+	s.refreshing = false
+
+	logger := s.logger.WithError(err).WithField("consecutive_failures", s.health.ConsecutiveFailures)
+	if !s.health.Degraded {
+		logger.Error("Upstream failed, serving the cached catalog")
+	} else {
+		logger.Warn("Upstream failed again")
+	}
+	s.health.Degraded = true
+}
+
+// backoff returns the retry interval after the given number of consecutive failures, doubling
+// with each failure up to the maximum retry interval.
+func (s *Store) backoff(failures int) time.Duration {
+	interval := s.retryInterval
+	for i := 1; i < failures && interval < s.maxRetryInterval; i++ {
+		interval *= 2
+	}
+	if interval > s.maxRetryInterval {
+		interval = s.maxRetryInterval
+	}
+
+	return interval
+}
@@ -0,0 +1,248 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/catalog"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+)
+
+func newPlugin(id, version string) *model.Plugin {
+	return &model.Plugin{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-" + id,
+		DownloadURL:  "https://example.com/" + id + "-" + version + ".tar.gz",
+		ReleaseStage: model.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: id, Name: id, Version: version},
+	}
+}
+
+// fakeUpstream serves the plugins of its catalog through the marketplace API, or fails while down.
+// Requests wait on stall, if set, to simulate a slow upstream.
+type fakeUpstream struct {
+	*httptest.Server
+	catalog *catalog.Catalog
+	down    bool
+	stall   chan struct{}
+}
+
+func newFakeUpstream(t *testing.T, plugins ...*model.Plugin) *fakeUpstream {
+	upstreamStore, err := store.NewFromPlugins(plugins, testlib.MakeLogger(t), store.Options{})
+	require.NoError(t, err)
+
+	upstream := &fakeUpstream{catalog: catalog.New(upstreamStore)}
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{Store: upstream.catalog, Logger: testlib.MakeLogger(t)})
+	upstream.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if upstream.stall != nil {
+			<-upstream.stall
+		}
+		if upstream.down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+
+	return upstream
+}
+
+func (u *fakeUpstream) replace(t *testing.T, plugins ...*model.Plugin) {
+	upstreamStore, err := store.NewFromPlugins(plugins, testlib.MakeLogger(t), store.Options{})
+	require.NoError(t, err)
+	u.catalog.Replace(upstreamStore)
+}
+
+func pluginVersions(t *testing.T, s api.Store, id string) []string {
+	t.Helper()
+
+	plugins, err := s.GetPluginVersions(id)
+	require.NoError(t, err)
+
+	var versions []string
+	for _, plugin := range plugins {
+		versions = append(versions, plugin.Manifest.Version)
+	}
+	return versions
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cacheFile := filepath.Join(dir, "plugins.json")
+
+	upstream := newFakeUpstream(t, newPlugin("demo", "0.1.0"), newPlugin("demo", "0.2.0"), newPlugin("jira", "3.0.0"))
+	defer upstream.Close()
+
+	options := Options{
+		CacheFile:       cacheFile,
+		RefreshInterval: time.Hour,
+		RetryInterval:   time.Minute,
+	}
+	proxyStore, err := New(api.NewClient(upstream.URL), testlib.MakeLogger(t), options)
+	require.NoError(t, err)
+
+	now := time.Now()
+	proxyStore.now = func() time.Time { return now }
+
+	t.Run("serves every upstream version", func(t *testing.T) {
+		require.Equal(t, []string{"0.2.0", "0.1.0"}, pluginVersions(t, proxyStore, "demo"))
+		require.Equal(t, []string{"3.0.0"}, pluginVersions(t, proxyStore, "jira"))
+
+		_, stale := proxyStore.Staleness()
+		require.False(t, stale)
+		require.Equal(t, &model.StoreHealth{}, proxyStore.Health())
+	})
+
+	t.Run("persists the upstream catalog", func(t *testing.T) {
+		file, err := os.Open(cacheFile)
+		require.NoError(t, err)
+		defer file.Close()
+
+		plugins, err := model.PluginsFromReader(file)
+		require.NoError(t, err)
+		require.Len(t, plugins, 3)
+	})
+
+	t.Run("not refreshed before the refresh interval elapses", func(t *testing.T) {
+		upstream.replace(t, newPlugin("demo", "0.3.0"))
+
+		now = now.Add(30 * time.Minute)
+		require.Equal(t, []string{"0.2.0", "0.1.0"}, pluginVersions(t, proxyStore, "demo"))
+	})
+
+	t.Run("refreshed once the refresh interval elapses", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		require.Equal(t, []string{"0.3.0"}, pluginVersions(t, proxyStore, "demo"))
+		require.Empty(t, pluginVersions(t, proxyStore, "jira"))
+
+		fetchedAt, stale := proxyStore.Staleness()
+		require.False(t, stale)
+		require.Equal(t, now, fetchedAt)
+	})
+
+	t.Run("serves the cached catalog while the upstream is down", func(t *testing.T) {
+		upstream.down = true
+		fetchedAt := now
+
+		now = now.Add(2 * time.Hour)
+		require.Equal(t, []string{"0.3.0"}, pluginVersions(t, proxyStore, "demo"))
+
+		staleSince, stale := proxyStore.Staleness()
+		require.True(t, stale)
+		require.Equal(t, fetchedAt, staleSince)
+
+		health := proxyStore.Health()
+		require.True(t, health.Degraded)
+		require.Equal(t, 1, health.ConsecutiveFailures)
+		require.Equal(t, int64(1), health.Failovers)
+		require.Equal(t, now, health.LastFailureAt)
+	})
+
+	t.Run("upstream bypassed until the retry interval elapses", func(t *testing.T) {
+		upstream.down = false
+
+		now = now.Add(30 * time.Second)
+		require.Equal(t, []string{"0.3.0"}, pluginVersions(t, proxyStore, "demo"))
+		require.True(t, proxyStore.Health().Degraded)
+	})
+
+	t.Run("recovered", func(t *testing.T) {
+		upstream.replace(t, newPlugin("demo", "0.4.0"))
+
+		now = now.Add(time.Minute)
+		require.Equal(t, []string{"0.4.0"}, pluginVersions(t, proxyStore, "demo"))
+
+		health := proxyStore.Health()
+		require.False(t, health.Degraded)
+		require.Equal(t, now, health.RecoveredAt)
+	})
+
+	t.Run("starts from the cached catalog while the upstream is down", func(t *testing.T) {
+		upstream.down = true
+
+		restarted, err := New(api.NewClient(upstream.URL), testlib.MakeLogger(t), options)
+		require.NoError(t, err)
+		require.Equal(t, []string{"0.4.0"}, pluginVersions(t, restarted, "demo"))
+
+		_, stale := restarted.Staleness()
+		require.True(t, stale)
+	})
+
+	t.Run("fails to start without a cached catalog while the upstream is down", func(t *testing.T) {
+		options.CacheFile = filepath.Join(dir, "missing.json")
+
+		_, err := New(api.NewClient(upstream.URL), testlib.MakeLogger(t), options)
+		require.Error(t, err)
+	})
+
+	t.Run("unwrap", func(t *testing.T) {
+		var wrapper api.StoreWrapper = proxyStore
+		require.Equal(t, proxyStore.Catalog(), wrapper.Unwrap())
+
+		var reporter api.StalenessReporter = proxyStore
+		_, stale := reporter.Staleness()
+		require.False(t, stale)
+	})
+}
+
+func TestStoreUnreliableUpstream(t *testing.T) {
+	upstream := newFakeUpstream(t, newPlugin("demo", "0.1.0"))
+	defer upstream.Close()
+
+	proxyStore, err := New(api.NewClient(upstream.URL), testlib.MakeLogger(t), Options{
+		RefreshInterval:  time.Hour,
+		RetryInterval:    time.Minute,
+		MaxRetryInterval: 3 * time.Minute,
+		RefreshWait:      10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	proxyStore.now = func() time.Time { return now }
+
+	t.Run("retry interval doubles with each failure", func(t *testing.T) {
+		upstream.down = true
+
+		now = now.Add(time.Hour)
+		pluginVersions(t, proxyStore, "demo")
+		require.Equal(t, now.Add(time.Minute), proxyStore.refreshAt)
+
+		now = now.Add(time.Minute)
+		pluginVersions(t, proxyStore, "demo")
+		require.Equal(t, now.Add(2*time.Minute), proxyStore.refreshAt)
+
+		now = now.Add(2 * time.Minute)
+		pluginVersions(t, proxyStore, "demo")
+		require.Equal(t, now.Add(3*time.Minute), proxyStore.refreshAt)
+		require.Equal(t, 3, proxyStore.Health().ConsecutiveFailures)
+	})
+
+	t.Run("serves the current catalog while the upstream is slow", func(t *testing.T) {
+		upstream.down = false
+		upstream.stall = make(chan struct{})
+		upstream.replace(t, newPlugin("demo", "0.2.0"))
+
+		now = now.Add(3 * time.Minute)
+		require.Equal(t, []string{"0.1.0"}, pluginVersions(t, proxyStore, "demo"))
+
+		close(upstream.stall)
+		require.Eventually(t, func() bool {
+			return !proxyStore.Health().Degraded
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, []string{"0.2.0"}, pluginVersions(t, proxyStore, "demo"))
+	})
+}
@@ -0,0 +1,193 @@
+// Package quality checks plugins against the minimum standards expected of marketplace entries,
+// such as having an icon and release notes, flagging or blocking those falling short.
+package quality
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Severity describes the consequence of a plugin failing a check.
+type Severity string
+
+const (
+	// SeverityOff disables the check.
+	SeverityOff Severity = "off"
+	// SeverityWarn flags plugins failing the check, without otherwise affecting them.
+	SeverityWarn Severity = "warn"
+	// SeverityBlock rejects plugins failing the check.
+	SeverityBlock Severity = "block"
+)
+
+// IsValid determines if the severity is one of the known values.
+func (s Severity) IsValid() bool {
+	switch s {
+	case SeverityOff, SeverityWarn, SeverityBlock:
+		return true
+	default:
+		return false
+	}
+}
+
+// Check identifies one of the quality checks.
+type Check string
+
+const (
+	// CheckIcon requires the plugin to have an icon.
+	CheckIcon Check = "icon"
+	// CheckDescription requires the plugin's description to reach the configured length.
+	CheckDescription Check = "description"
+	// CheckReleaseNotes requires the plugin to link to its release notes.
+	CheckReleaseNotes Check = "release-notes"
+	// CheckSignature requires the plugin bundle to be signed.
+	CheckSignature Check = "signature"
+	// CheckMinServerVersion requires the plugin to declare the minimum server version it supports.
+	CheckMinServerVersion Check = "min-server-version"
+)
+
+// Checks lists every check, in the order evaluated.
+var Checks = []Check{CheckIcon, CheckDescription, CheckReleaseNotes, CheckSignature, CheckMinServerVersion}
+
+// IsValid determines if the check is one of the known checks.
+func (c Check) IsValid() bool {
+	for _, check := range Checks {
+		if c == check {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Config describes which checks apply, and how severely.
+type Config struct {
+	// Severities maps each check to its severity, with unlisted checks being off.
+	Severities map[Check]Severity
+	// MinDescriptionLength is the number of characters a description must reach to pass
+	// CheckDescription.
+	MinDescriptionLength int
+	// Rules applies further checks to the plugins matching each rule, after the built-in checks.
+	Rules []*Rule
+}
+
+// ParseSeverities parses check severities of the form check=severity, such as icon=block.
+func ParseSeverities(values []string) (map[Check]Severity, error) {
+	severities := map[Check]Severity{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("check %s is not of the form check=severity", value)
+		}
+
+		check, severity := Check(parts[0]), Severity(parts[1])
+		if !check.IsValid() {
+			return nil, errors.Errorf("unknown check %s", check)
+		}
+		if !severity.IsValid() {
+			return nil, errors.Errorf("invalid severity %s for check %s", severity, check)
+		}
+		severities[check] = severity
+	}
+
+	return severities, nil
+}
+
+// Finding records a plugin failing a check, or a rule named by Check.
+type Finding struct {
+	Check    Check
+	Severity Severity
+	PluginID string
+	Version  string
+	Message  string
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s %s %s", f.PluginID, f.Version, f.Message)
+}
+
+// Evaluate returns the findings of every enabled check and matching rule the given plugin fails.
+func (c *Config) Evaluate(plugin *model.Plugin) []*Finding {
+	var findings []*Finding
+	for _, check := range Checks {
+		severity := c.Severities[check]
+		if severity == "" || severity == SeverityOff {
+			continue
+		}
+
+		message := c.evaluate(check, plugin)
+		if message == "" {
+			continue
+		}
+
+		findings = append(findings, &Finding{
+			Check:    check,
+			Severity: severity,
+			PluginID: plugin.Manifest.Id,
+			Version:  plugin.Manifest.Version,
+			Message:  message,
+		})
+	}
+
+	for _, rule := range c.Rules {
+		if rule.Severity == SeverityOff || !rule.When.matches(plugin) {
+			continue
+		}
+
+		var messages []string
+		for _, check := range rule.Require {
+			if message := c.evaluate(check, plugin); message != "" {
+				messages = append(messages, message)
+			}
+		}
+		if len(messages) == 0 {
+			continue
+		}
+
+		message := rule.Message
+		if message == "" {
+			message = strings.Join(messages, " and ")
+		}
+		findings = append(findings, &Finding{
+			Check:    Check(rule.Name),
+			Severity: rule.Severity,
+			PluginID: plugin.Manifest.Id,
+			Version:  plugin.Manifest.Version,
+			Message:  message,
+		})
+	}
+
+	return findings
+}
+
+// evaluate returns why the plugin fails the given check, or the empty string if it passes.
+func (c *Config) evaluate(check Check, plugin *model.Plugin) string {
+	switch check {
+	case CheckIcon:
+		if plugin.IconData == "" && plugin.IconURL == "" {
+			return "has no icon"
+		}
+	case CheckDescription:
+		if utf8.RuneCountInString(strings.TrimSpace(plugin.Manifest.Description)) < c.MinDescriptionLength {
+			return fmt.Sprintf("has a description shorter than %d characters", c.MinDescriptionLength)
+		}
+	case CheckReleaseNotes:
+		if plugin.ReleaseNotesURL == "" {
+			return "has no release notes"
+		}
+	case CheckSignature:
+		if len(plugin.AllSignatures()) == 0 {
+			return "is unsigned"
+		}
+	case CheckMinServerVersion:
+		if plugin.Manifest.MinServerVersion == "" {
+			return "declares no minimum server version"
+		}
+	}
+
+	return ""
+}
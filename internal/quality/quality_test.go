@@ -0,0 +1,83 @@
+package quality_test
+
+import (
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/quality"
+)
+
+func TestParseSeverities(t *testing.T) {
+	severities, err := quality.ParseSeverities([]string{"icon=block", "signature=warn", "description=off"})
+	require.NoError(t, err)
+	require.Equal(t, map[quality.Check]quality.Severity{
+		quality.CheckIcon:        quality.SeverityBlock,
+		quality.CheckSignature:   quality.SeverityWarn,
+		quality.CheckDescription: quality.SeverityOff,
+	}, severities)
+
+	for _, value := range []string{"icon", "unknown=warn", "icon=fatal"} {
+		_, err := quality.ParseSeverities([]string{value})
+		require.Error(t, err, value)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	complete := &model.Plugin{
+		IconData:        "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=",
+		ReleaseNotesURL: "https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0",
+		Signatures:      []*model.Signature{{Signature: "c2lnbmF0dXJl", PublicKeyHash: "key"}},
+		Manifest: &mattermostModel.Manifest{
+			Id:               "demo",
+			Version:          "0.1.0",
+			Description:      "Demonstrates the plugin framework.",
+			MinServerVersion: "5.14.0",
+		},
+	}
+	incomplete := &model.Plugin{
+		Manifest: &mattermostModel.Manifest{Id: "starter", Version: "0.2.0", Description: "Short"},
+	}
+
+	config := &quality.Config{
+		Severities: map[quality.Check]quality.Severity{
+			quality.CheckIcon:             quality.SeverityBlock,
+			quality.CheckDescription:      quality.SeverityWarn,
+			quality.CheckReleaseNotes:     quality.SeverityWarn,
+			quality.CheckSignature:        quality.SeverityBlock,
+			quality.CheckMinServerVersion: quality.SeverityOff,
+		},
+		MinDescriptionLength: 20,
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		require.Empty(t, config.Evaluate(complete))
+	})
+
+	t.Run("incomplete", func(t *testing.T) {
+		findings := config.Evaluate(incomplete)
+		require.Len(t, findings, 4)
+
+		require.Equal(t, quality.CheckIcon, findings[0].Check)
+		require.Equal(t, quality.SeverityBlock, findings[0].Severity)
+		require.Equal(t, "starter 0.2.0 has no icon", findings[0].String())
+		require.Equal(t, quality.CheckDescription, findings[1].Check)
+		require.Equal(t, quality.SeverityWarn, findings[1].Severity)
+		require.Equal(t, "has a description shorter than 20 characters", findings[1].Message)
+		require.Equal(t, quality.CheckReleaseNotes, findings[2].Check)
+		require.Equal(t, quality.CheckSignature, findings[3].Check)
+	})
+
+	t.Run("legacy signature", func(t *testing.T) {
+		plugin := *complete
+		plugin.Signatures = nil
+		plugin.Signature = "c2lnbmF0dXJl"
+		require.Empty(t, config.Evaluate(&plugin))
+	})
+
+	t.Run("no checks", func(t *testing.T) {
+		require.Empty(t, (&quality.Config{}).Evaluate(incomplete))
+	})
+}
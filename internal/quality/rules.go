@@ -0,0 +1,167 @@
+package quality
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Rule applies checks to the plugins matching its condition, so that policies such as requiring
+// a signature of plugins labelled Official are configured as data.
+type Rule struct {
+	// Name identifies the rule in its findings.
+	Name     string   `yaml:"name"`
+	Severity Severity `yaml:"severity"`
+	// When limits the rule to matching plugins, applying it to every plugin if empty.
+	When Condition `yaml:"when"`
+	// Require lists the checks a matching plugin must pass.
+	Require []Check `yaml:"require"`
+	// Message optionally replaces the messages of the failed checks in the rule's finding.
+	Message string `yaml:"message"`
+}
+
+// Condition matches plugins by their metadata. A plugin matches if it matches every non-empty
+// field, and matches a field if it has any of the listed values.
+type Condition struct {
+	// Labels matches plugins with a label of one of the given names, ignoring case.
+	Labels        []string             `yaml:"labels"`
+	ReleaseStages []model.ReleaseStage `yaml:"release_stages"`
+	AuthorTypes   []model.AuthorType   `yaml:"author_types"`
+}
+
+// IsValid determines if the rule is complete, naming only known checks and values.
+func (r *Rule) IsValid() error {
+	if r.Name == "" {
+		return errors.New("rule name is required")
+	}
+	if Check(r.Name).IsValid() {
+		return errors.Errorf("rule %s shadows the check of the same name", r.Name)
+	}
+	if r.Severity == "" || !r.Severity.IsValid() {
+		return errors.Errorf("invalid severity %s for rule %s", r.Severity, r.Name)
+	}
+	if len(r.Require) == 0 {
+		return errors.Errorf("rule %s requires no checks", r.Name)
+	}
+	for _, check := range r.Require {
+		if !check.IsValid() {
+			return errors.Errorf("unknown check %s required by rule %s", check, r.Name)
+		}
+	}
+	for _, stage := range r.When.ReleaseStages {
+		if !stage.IsValid() {
+			return errors.Errorf("invalid release stage %s in rule %s", stage, r.Name)
+		}
+	}
+	for _, authorType := range r.When.AuthorTypes {
+		if !authorType.IsValid() {
+			return errors.Errorf("invalid author type %s in rule %s", authorType, r.Name)
+		}
+	}
+
+	return nil
+}
+
+// matches reports whether the given plugin matches the condition.
+func (c *Condition) matches(plugin *model.Plugin) bool {
+	if len(c.Labels) > 0 {
+		labelled := false
+		for _, name := range c.Labels {
+			for _, label := range plugin.Labels {
+				if strings.EqualFold(label.Name, name) {
+					labelled = true
+				}
+			}
+		}
+		if !labelled {
+			return false
+		}
+	}
+
+	if len(c.ReleaseStages) > 0 {
+		staged := false
+		for _, stage := range c.ReleaseStages {
+			if plugin.ReleaseStage == stage {
+				staged = true
+			}
+		}
+		if !staged {
+			return false
+		}
+	}
+
+	if len(c.AuthorTypes) > 0 {
+		authored := false
+		for _, authorType := range c.AuthorTypes {
+			if plugin.AuthorType == authorType {
+				authored = true
+			}
+		}
+		if !authored {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rulesFile is the format of a rules file.
+type rulesFile struct {
+	// Checks maps each built-in check to its severity, as --quality-check does.
+	Checks               map[Check]Severity `yaml:"checks"`
+	MinDescriptionLength int                `yaml:"min_description_length"`
+	Rules                []*Rule            `yaml:"rules"`
+}
+
+// ConfigFromReader decodes a YAML rules file setting the severity of the built-in checks and
+// defining any custom rules.
+func ConfigFromReader(reader io.Reader) (*Config, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read rules")
+	}
+
+	var file rulesFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, errors.Wrap(err, "failed to parse rules")
+	}
+
+	for check, severity := range file.Checks {
+		if !check.IsValid() {
+			return nil, errors.Errorf("unknown check %s", check)
+		}
+		if !severity.IsValid() {
+			return nil, errors.Errorf("invalid severity %s for check %s", severity, check)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, rule := range file.Rules {
+		if rule == nil {
+			return nil, errors.New("rule is empty")
+		}
+		if err := rule.IsValid(); err != nil {
+			return nil, err
+		}
+		if names[rule.Name] {
+			return nil, errors.Errorf("duplicate rule %s", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+
+	severities := file.Checks
+	if severities == nil {
+		severities = map[Check]Severity{}
+	}
+
+	return &Config{
+		Severities:           severities,
+		MinDescriptionLength: file.MinDescriptionLength,
+		Rules:                file.Rules,
+	}, nil
+}
@@ -0,0 +1,136 @@
+package quality_test
+
+import (
+	"strings"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/quality"
+)
+
+func TestConfigFromReader(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		config, err := quality.ConfigFromReader(strings.NewReader(`
+checks:
+  release-notes: warn
+min_description_length: 40
+rules:
+  - name: official-signed
+    severity: block
+    when:
+      labels: [Official]
+    require: [signature]
+  - name: production-icon
+    severity: warn
+    when:
+      release_stages: [production]
+      author_types: [community]
+    require: [icon, min-server-version]
+    message: needs an icon and a minimum server version in production
+`))
+		require.NoError(t, err)
+		require.Equal(t, map[quality.Check]quality.Severity{quality.CheckReleaseNotes: quality.SeverityWarn}, config.Severities)
+		require.Equal(t, 40, config.MinDescriptionLength)
+		require.Len(t, config.Rules, 2)
+		require.Equal(t, []quality.Check{quality.CheckIcon, quality.CheckMinServerVersion}, config.Rules[1].Require)
+		require.Equal(t, []model.AuthorType{model.AuthorTypeCommunity}, config.Rules[1].When.AuthorTypes)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		config, err := quality.ConfigFromReader(strings.NewReader(""))
+		require.NoError(t, err)
+		require.Empty(t, config.Severities)
+		require.Empty(t, config.Rules)
+	})
+
+	invalid := map[string]string{
+		"unknown field":      "checks: {}\nunknown: true",
+		"unknown check":      "checks: {logo: warn}",
+		"invalid severity":   "checks: {icon: fatal}",
+		"unnamed rule":       "rules: [{severity: warn, require: [icon]}]",
+		"shadowing rule":     "rules: [{name: icon, severity: warn, require: [icon]}]",
+		"rule severity":      "rules: [{name: r, require: [icon]}]",
+		"no requirements":    "rules: [{name: r, severity: warn}]",
+		"unknown required":   "rules: [{name: r, severity: warn, require: [logo]}]",
+		"invalid stage":      "rules: [{name: r, severity: warn, require: [icon], when: {release_stages: [alpha]}}]",
+		"invalid author":     "rules: [{name: r, severity: warn, require: [icon], when: {author_types: [anyone]}}]",
+		"duplicate rule":     "rules: [{name: r, severity: warn, require: [icon]}, {name: r, severity: block, require: [signature]}]",
+		"empty rule in list": "rules: [~]",
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := quality.ConfigFromReader(strings.NewReader(data))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	config := &quality.Config{
+		Severities: map[quality.Check]quality.Severity{quality.CheckReleaseNotes: quality.SeverityWarn},
+		Rules: []*quality.Rule{
+			{
+				Name:     "official-signed",
+				Severity: quality.SeverityBlock,
+				When:     quality.Condition{Labels: []string{"Official"}},
+				Require:  []quality.Check{quality.CheckSignature},
+			},
+			{
+				Name:     "production-icon",
+				Severity: quality.SeverityWarn,
+				When:     quality.Condition{ReleaseStages: []model.ReleaseStage{model.ReleaseStageProduction}},
+				Require:  []quality.Check{quality.CheckIcon, quality.CheckMinServerVersion},
+			},
+			{
+				Name:     "community-notes",
+				Severity: quality.SeverityOff,
+				Require:  []quality.Check{quality.CheckReleaseNotes},
+			},
+		},
+	}
+
+	t.Run("matching", func(t *testing.T) {
+		plugin := &model.Plugin{
+			Labels:       []model.Label{{Name: "official"}},
+			ReleaseStage: model.ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: "demo", Version: "0.1.0"},
+		}
+
+		findings := config.Evaluate(plugin)
+		require.Len(t, findings, 3)
+		require.Equal(t, quality.CheckReleaseNotes, findings[0].Check)
+		require.Equal(t, quality.Check("official-signed"), findings[1].Check)
+		require.Equal(t, quality.SeverityBlock, findings[1].Severity)
+		require.Equal(t, "demo 0.1.0 is unsigned", findings[1].String())
+		require.Equal(t, quality.Check("production-icon"), findings[2].Check)
+		require.Equal(t, "has no icon and declares no minimum server version", findings[2].Message)
+	})
+
+	t.Run("not matching", func(t *testing.T) {
+		plugin := &model.Plugin{
+			ReleaseNotesURL: "https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0",
+			ReleaseStage:    model.ReleaseStageBeta,
+			Manifest:        &mattermostModel.Manifest{Id: "demo", Version: "0.1.0"},
+		}
+
+		require.Empty(t, config.Evaluate(plugin))
+	})
+
+	t.Run("custom message", func(t *testing.T) {
+		config := &quality.Config{
+			Rules: []*quality.Rule{{
+				Name:     "signed",
+				Severity: quality.SeverityWarn,
+				Require:  []quality.Check{quality.CheckSignature},
+				Message:  "must be signed",
+			}},
+		}
+
+		findings := config.Evaluate(&model.Plugin{Manifest: &mattermostModel.Manifest{Id: "demo", Version: "0.1.0"}})
+		require.Len(t, findings, 1)
+		require.Equal(t, "demo 0.1.0 must be signed", findings[0].String())
+	})
+}
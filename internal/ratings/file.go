@@ -0,0 +1,62 @@
+package ratings
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// FileBackend persists ratings as JSON to a local file.
+type FileBackend struct {
+	Path string
+}
+
+// Load reads the ratings from the file, returning none if it does not yet exist.
+func (b *FileBackend) Load() ([]*model.Rating, error) {
+	data, err := ioutil.ReadFile(b.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", b.Path)
+	}
+
+	var ratings []*model.Rating
+	if err := json.Unmarshal(data, &ratings); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", b.Path)
+	}
+
+	return ratings, nil
+}
+
+// Save atomically replaces the file with the given ratings.
+func (b *FileBackend) Save(ratings []*model.Rating) error {
+	data, err := json.Marshal(ratings)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal ratings")
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(b.Path), filepath.Base(b.Path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to write %s", file.Name())
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", file.Name())
+	}
+
+	if err := os.Rename(file.Name(), b.Path); err != nil {
+		return errors.Wrapf(err, "failed to replace %s", b.Path)
+	}
+
+	return nil
+}
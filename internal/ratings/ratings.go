@@ -0,0 +1,161 @@
+// Package ratings aggregates the star ratings and reviews submitted for plugins, persisting them
+// to a pluggable backend.
+package ratings
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Backend persists ratings.
+type Backend interface {
+	// Load returns all persisted ratings, or none if nothing was persisted yet.
+	Load() ([]*model.Rating, error)
+	// Save replaces the persisted ratings with the given ratings.
+	Save(ratings []*model.Rating) error
+}
+
+type key struct {
+	pluginID string
+	userID   string
+}
+
+// Aggregator holds the ratings of every plugin, keeping at most one rating per user and plugin.
+type Aggregator struct {
+	backend Backend
+	now     func() time.Time
+
+	lock    sync.Mutex
+	ratings map[key]*model.Rating
+}
+
+// NewAggregator creates an aggregator initialized with the ratings persisted to the given backend.
+func NewAggregator(backend Backend) (*Aggregator, error) {
+	ratings, err := backend.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load ratings")
+	}
+
+	aggregator := &Aggregator{
+		backend: backend,
+		now:     time.Now,
+		ratings: map[key]*model.Rating{},
+	}
+	for _, rating := range ratings {
+		aggregator.ratings[key{rating.PluginID, rating.UserID}] = rating
+	}
+
+	return aggregator, nil
+}
+
+// SubmitRating records the given rating, replacing any earlier rating of the same plugin by the
+// same user, and persists the result to the backend.
+func (a *Aggregator) SubmitRating(rating *model.Rating) error {
+	if err := rating.IsValid(); err != nil {
+		return errors.Wrap(err, "invalid rating")
+	}
+
+	submitted := *rating
+	submitted.UpdatedAt = a.now().UTC()
+	k := key{submitted.PluginID, submitted.UserID}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	previous, hadPrevious := a.ratings[k]
+	a.ratings[k] = &submitted
+
+	if err := a.backend.Save(a.sortedRatings()); err != nil {
+		if hadPrevious {
+			a.ratings[k] = previous
+		} else {
+			delete(a.ratings, k)
+		}
+		return errors.Wrap(err, "failed to save ratings")
+	}
+
+	return nil
+}
+
+// sortedRatings returns every rating ordered by plugin and user. The lock must be held.
+func (a *Aggregator) sortedRatings() []*model.Rating {
+	ratings := make([]*model.Rating, 0, len(a.ratings))
+	for _, rating := range a.ratings {
+		ratings = append(ratings, rating)
+	}
+
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].PluginID != ratings[j].PluginID {
+			return ratings[i].PluginID < ratings[j].PluginID
+		}
+		return ratings[i].UserID < ratings[j].UserID
+	})
+
+	return ratings
+}
+
+// RatingSummaries summarizes the ratings of every rated plugin, keyed by plugin id.
+func (a *Aggregator) RatingSummaries() map[string]*model.RatingSummary {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	totals := map[string]int64{}
+	summaries := map[string]*model.RatingSummary{}
+	for k, rating := range a.ratings {
+		summary, ok := summaries[k.pluginID]
+		if !ok {
+			summary = &model.RatingSummary{}
+			summaries[k.pluginID] = summary
+		}
+
+		summary.Count++
+		totals[k.pluginID] += int64(rating.Stars)
+	}
+	for pluginID, summary := range summaries {
+		summary.Average = float64(totals[pluginID]) / float64(summary.Count)
+	}
+
+	return summaries
+}
+
+// PluginRatings summarizes the ratings of the given plugin along with its reviews.
+func (a *Aggregator) PluginRatings(pluginID string) *model.PluginRatings {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var total int64
+	result := &model.PluginRatings{
+		PluginID: pluginID,
+		Summary:  &model.RatingSummary{},
+		Reviews:  []*model.Rating{},
+	}
+	for k, rating := range a.ratings {
+		if k.pluginID != pluginID {
+			continue
+		}
+
+		result.Summary.Count++
+		total += int64(rating.Stars)
+		if rating.Review != "" {
+			review := *rating
+			result.Reviews = append(result.Reviews, &review)
+		}
+	}
+	if result.Summary.Count > 0 {
+		result.Summary.Average = float64(total) / float64(result.Summary.Count)
+	}
+
+	sort.Slice(result.Reviews, func(i, j int) bool {
+		if !result.Reviews[i].UpdatedAt.Equal(result.Reviews[j].UpdatedAt) {
+			return result.Reviews[i].UpdatedAt.After(result.Reviews[j].UpdatedAt)
+		}
+		return result.Reviews[i].UserID < result.Reviews[j].UserID
+	})
+
+	return result
+}
@@ -0,0 +1,104 @@
+package ratings
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+type failingBackend struct{}
+
+func (b *failingBackend) Load() ([]*model.Rating, error) {
+	return nil, nil
+}
+
+func (b *failingBackend) Save(ratings []*model.Rating) error {
+	return errors.New("unavailable")
+}
+
+func TestAggregator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ratings")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ratings.json")
+
+	now := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+	newAggregator := func(t *testing.T) *Aggregator {
+		aggregator, err := NewAggregator(&FileBackend{Path: path})
+		require.NoError(t, err)
+		aggregator.now = func() time.Time { return now }
+
+		return aggregator
+	}
+
+	aggregator := newAggregator(t)
+
+	t.Run("no ratings", func(t *testing.T) {
+		require.Empty(t, aggregator.RatingSummaries())
+		require.Equal(t, &model.PluginRatings{
+			PluginID: "jira",
+			Summary:  &model.RatingSummary{},
+			Reviews:  []*model.Rating{},
+		}, aggregator.PluginRatings("jira"))
+	})
+
+	t.Run("invalid rating", func(t *testing.T) {
+		err := aggregator.SubmitRating(&model.Rating{PluginID: "jira", UserID: "alice", Stars: 9})
+		require.EqualError(t, err, "invalid rating: stars must be between 1 and 5")
+	})
+
+	require.NoError(t, aggregator.SubmitRating(&model.Rating{PluginID: "jira", UserID: "alice", Stars: 5, Review: "Great."}))
+	now = now.Add(time.Hour)
+	require.NoError(t, aggregator.SubmitRating(&model.Rating{PluginID: "jira", UserID: "bob", Stars: 2}))
+	require.NoError(t, aggregator.SubmitRating(&model.Rating{PluginID: "jira", UserID: "carol", Stars: 4, Review: "Good."}))
+	require.NoError(t, aggregator.SubmitRating(&model.Rating{PluginID: "demo", UserID: "alice", Stars: 3}))
+
+	expectedJiraRatings := &model.PluginRatings{
+		PluginID: "jira",
+		Summary:  &model.RatingSummary{Average: 11.0 / 3, Count: 3},
+		Reviews: []*model.Rating{
+			{PluginID: "jira", UserID: "carol", Stars: 4, Review: "Good.", UpdatedAt: now},
+			{PluginID: "jira", UserID: "alice", Stars: 5, Review: "Great.", UpdatedAt: now.Add(-time.Hour)},
+		},
+	}
+
+	t.Run("aggregates ratings", func(t *testing.T) {
+		require.Equal(t, expectedJiraRatings, aggregator.PluginRatings("jira"))
+		require.Equal(t, map[string]*model.RatingSummary{
+			"jira": {Average: 11.0 / 3, Count: 3},
+			"demo": {Average: 3, Count: 1},
+		}, aggregator.RatingSummaries())
+	})
+
+	t.Run("persists across restarts", func(t *testing.T) {
+		require.Equal(t, expectedJiraRatings, newAggregator(t).PluginRatings("jira"))
+	})
+
+	t.Run("replaces earlier rating by the same user", func(t *testing.T) {
+		require.NoError(t, aggregator.SubmitRating(&model.Rating{PluginID: "jira", UserID: "bob", Stars: 5}))
+		require.Equal(t, &model.RatingSummary{Average: 14.0 / 3, Count: 3}, aggregator.PluginRatings("jira").Summary)
+	})
+
+	t.Run("save failure", func(t *testing.T) {
+		failing, err := NewAggregator(&failingBackend{})
+		require.NoError(t, err)
+
+		err = failing.SubmitRating(&model.Rating{PluginID: "jira", UserID: "alice", Stars: 5})
+		require.EqualError(t, err, "failed to save ratings: unavailable")
+		require.Empty(t, failing.RatingSummaries())
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+
+		_, err := NewAggregator(&FileBackend{Path: path})
+		require.Error(t, err)
+	})
+}
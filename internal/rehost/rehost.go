@@ -0,0 +1,166 @@
+// Package rehost serves plugin bundles from a local directory in place of their original urls,
+// fetching each from its original url the first time it is requested.
+package rehost
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// ErrNotFound is returned when the requested bundle is neither re-hosted nor has a url from which
+// to fetch it.
+var ErrNotFound = errors.New("bundle not found")
+
+// ErrChecksumMismatch is returned when a re-hosted bundle no longer matches the checksums recorded
+// for it, such as when it was corrupted or tampered with on disk.
+var ErrChecksumMismatch = errors.New("bundle does not match its checksums")
+
+// Host serves the bundles re-hosted in its directory, laid out as {id}/{version}.tar.gz, or
+// {id}/{version}-{platform}.tar.gz for the bundles of a given platform.
+type Host struct {
+	directory string
+	client    *http.Client
+
+	// lock serializes fetching bundles, so that a bundle requested concurrently is fetched once.
+	lock sync.Mutex
+}
+
+// New creates a host re-hosting bundles in the given directory, creating it if necessary.
+func New(directory string) (*Host, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s", directory)
+	}
+
+	return &Host{
+		directory: directory,
+		client:    &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// Open opens the re-hosted bundle of the given plugin, the plugin having been selected for a
+// platform if any, fetching it from its download url if not yet re-hosted. The bundle is verified
+// against the plugin's checksums, if any, every time it is opened. The caller must close the file.
+func (h *Host) Open(plugin *model.Plugin, platform string) (*os.File, error) {
+	localPath, err := h.path(plugin.Manifest.Id, plugin.Manifest.Version, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.fetch(plugin, localPath); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", localPath)
+	}
+
+	if plugin.Checksums != nil {
+		checksumsWriter := model.NewChecksumsWriter()
+		if _, err := io.Copy(checksumsWriter, file); err != nil {
+			file.Close()
+			return nil, errors.Wrapf(err, "failed to read %s", localPath)
+		}
+		if err := plugin.Checksums.Verify(checksumsWriter.Checksums()); err != nil {
+			file.Close()
+			return nil, errors.Wrapf(ErrChecksumMismatch, "%s: %s", localPath, err.Error())
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			file.Close()
+			return nil, errors.Wrapf(err, "failed to rewind %s", localPath)
+		}
+	}
+
+	return file, nil
+}
+
+// path returns the local path of the bundle of the given plugin version and platform.
+func (h *Host) path(pluginID, version, platform string) (string, error) {
+	name := version
+	if platform != "" {
+		name += "-" + platform
+	}
+	for _, segment := range []string{pluginID, name} {
+		if !isPathSegment(segment) {
+			return "", errors.Errorf("%s is not usable as a file name", segment)
+		}
+	}
+
+	return filepath.Join(h.directory, pluginID, name+".tar.gz"), nil
+}
+
+// isPathSegment reports whether the given value names a single file or directory, and so may
+// safely be joined to a path.
+func isPathSegment(value string) bool {
+	return value != "" && value != "." && value != ".." && !strings.ContainsAny(value, `/\`)
+}
+
+// fetch downloads the bundle of the given plugin to the given path, unless already re-hosted,
+// verifying it against the plugin's checksums, if any, before moving it into place.
+func (h *Host) fetch(plugin *model.Plugin, localPath string) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if _, err := os.Stat(localPath); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to stat %s", localPath)
+	}
+
+	if plugin.DownloadURL == "" {
+		return ErrNotFound
+	}
+
+	resp, err := h.client.Get(plugin.DownloadURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %s", plugin.DownloadURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to download %s: status code %d", plugin.DownloadURL, resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", localPath)
+	}
+	file, err := ioutil.TempFile(filepath.Dir(localPath), filepath.Base(localPath)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(file.Name())
+
+	checksumsWriter := model.NewChecksumsWriter()
+	if _, err := io.Copy(io.MultiWriter(file, checksumsWriter), resp.Body); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to download %s", plugin.DownloadURL)
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", file.Name())
+	}
+
+	if plugin.Checksums != nil {
+		if err := plugin.Checksums.Verify(checksumsWriter.Checksums()); err != nil {
+			return errors.Wrapf(err, "failed to verify %s", plugin.DownloadURL)
+		}
+	}
+
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return errors.Wrapf(err, "failed to set permissions of %s", file.Name())
+	}
+	if err := os.Rename(file.Name(), localPath); err != nil {
+		return errors.Wrapf(err, "failed to replace %s", localPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package rehost
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func TestHost(t *testing.T) {
+	bundle := []byte("bundle contents")
+	checksumsWriter := model.NewChecksumsWriter()
+	checksumsWriter.Write(bundle)
+	checksums := checksumsWriter.Checksums()
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/missing.tar.gz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(bundle)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "rehost")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	host, err := New(dir)
+	require.NoError(t, err)
+
+	makePlugin := func(id, downloadURL string, checksums *model.Checksums) *model.Plugin {
+		return &model.Plugin{
+			DownloadURL: downloadURL,
+			Checksums:   checksums,
+			Manifest:    &mattermostModel.Manifest{Id: id, Version: "1.0.0"},
+		}
+	}
+
+	readBundle := func(t *testing.T, plugin *model.Plugin, platform string) []byte {
+		file, err := host.Open(plugin, platform)
+		require.NoError(t, err)
+		defer file.Close()
+
+		data, err := ioutil.ReadAll(file)
+		require.NoError(t, err)
+		return data
+	}
+
+	t.Run("fetched once", func(t *testing.T) {
+		plugin := makePlugin("jira", ts.URL+"/jira.tar.gz", checksums)
+		require.Equal(t, bundle, readBundle(t, plugin, ""))
+		require.Equal(t, bundle, readBundle(t, plugin, ""))
+		require.EqualValues(t, 1, atomic.LoadInt32(&requests))
+		require.FileExists(t, filepath.Join(dir, "jira", "1.0.0.tar.gz"))
+	})
+
+	t.Run("platform bundle", func(t *testing.T) {
+		plugin := makePlugin("jira", ts.URL+"/jira-linux-amd64.tar.gz", checksums)
+		require.Equal(t, bundle, readBundle(t, plugin, "linux-amd64"))
+		require.FileExists(t, filepath.Join(dir, "jira", "1.0.0-linux-amd64.tar.gz"))
+	})
+
+	t.Run("checksum mismatch on fetch", func(t *testing.T) {
+		plugin := makePlugin("zoom", ts.URL+"/zoom.tar.gz", &model.Checksums{SHA256: "00"})
+		_, err := host.Open(plugin, "")
+		require.Error(t, err)
+		require.NoFileExists(t, filepath.Join(dir, "zoom", "1.0.0.tar.gz"))
+	})
+
+	t.Run("checksum mismatch on serve", func(t *testing.T) {
+		plugin := makePlugin("demo", ts.URL+"/demo.tar.gz", checksums)
+		require.Equal(t, bundle, readBundle(t, plugin, ""))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "demo", "1.0.0.tar.gz"), []byte("tampered"), 0644))
+
+		_, err := host.Open(plugin, "")
+		require.Equal(t, ErrChecksumMismatch, errors.Cause(err))
+	})
+
+	t.Run("failed download", func(t *testing.T) {
+		_, err := host.Open(makePlugin("missing", ts.URL+"/missing.tar.gz", nil), "")
+		require.Error(t, err)
+	})
+
+	t.Run("no download url", func(t *testing.T) {
+		_, err := host.Open(makePlugin("unhosted", "", nil), "")
+		require.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("unsafe path", func(t *testing.T) {
+		_, err := host.Open(makePlugin("..", ts.URL+"/jira.tar.gz", nil), "")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,61 @@
+// Package reporting forwards errors logged by the marketplace to an external error tracker.
+package reporting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Reporter describes the interface to an error tracker.
+type Reporter interface {
+	// Report records the given error, described by the given message and tagged with the given
+	// fields, such as the id of the request that failed.
+	Report(err error, message string, tags map[string]string)
+	// Flush waits up to the given timeout for reported errors to be delivered, returning false if
+	// any remain undelivered.
+	Flush(timeout time.Duration) bool
+}
+
+// Hook is a logrus hook reporting every error logged at or above the error level.
+//
+// Errors attached with WithError are reported as is, preserving any stack trace recorded by
+// github.com/pkg/errors. The remaining fields of the entry, such as the request id, are reported
+// as tags.
+type Hook struct {
+	reporter Reporter
+}
+
+var _ logrus.Hook = (*Hook)(nil)
+
+// NewHook creates a hook reporting to the given reporter.
+func NewHook(reporter Reporter) *Hook {
+	return &Hook{reporter: reporter}
+}
+
+// Levels returns the levels reported by the hook.
+func (h *Hook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+// Fire reports the given entry.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	err, ok := entry.Data[logrus.ErrorKey].(error)
+	if !ok || err == nil {
+		err = errors.New(entry.Message)
+	}
+
+	tags := map[string]string{}
+	for key, value := range entry.Data {
+		if key == logrus.ErrorKey {
+			continue
+		}
+		tags[key] = fmt.Sprint(value)
+	}
+
+	h.reporter.Report(err, entry.Message, tags)
+
+	return nil
+}
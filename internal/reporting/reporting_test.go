@@ -0,0 +1,75 @@
+package reporting_test
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/reporting"
+)
+
+type report struct {
+	err     error
+	message string
+	tags    map[string]string
+}
+
+type mockReporter struct {
+	reports []report
+}
+
+func (r *mockReporter) Report(err error, message string, tags map[string]string) {
+	r.reports = append(r.reports, report{err, message, tags})
+}
+
+func (r *mockReporter) Flush(timeout time.Duration) bool {
+	return true
+}
+
+func TestHook(t *testing.T) {
+	setup := func() (*logrus.Logger, *mockReporter) {
+		reporter := &mockReporter{}
+		logger := logrus.New()
+		logger.SetOutput(ioutil.Discard)
+		logger.AddHook(reporting.NewHook(reporter))
+
+		return logger, reporter
+	}
+
+	t.Run("ignores lower levels", func(t *testing.T) {
+		logger, reporter := setup()
+
+		logger.Info("info")
+		logger.WithError(errors.New("failure")).Warn("warning")
+		require.Empty(t, reporter.reports)
+	})
+
+	t.Run("reports attached error", func(t *testing.T) {
+		logger, reporter := setup()
+
+		err := errors.Wrap(errors.New("failure"), "wrapped")
+		logger.WithError(err).WithFields(logrus.Fields{
+			"request": "request-id",
+			"status":  500,
+		}).Error("failed to query plugins")
+
+		require.Len(t, reporter.reports, 1)
+		require.Equal(t, err, reporter.reports[0].err)
+		require.Equal(t, "failed to query plugins", reporter.reports[0].message)
+		require.Equal(t, map[string]string{"request": "request-id", "status": "500"}, reporter.reports[0].tags)
+	})
+
+	t.Run("reports message without error", func(t *testing.T) {
+		logger, reporter := setup()
+
+		logger.Error("something went wrong")
+
+		require.Len(t, reporter.reports, 1)
+		require.EqualError(t, reporter.reports[0].err, "something went wrong")
+		require.Empty(t, reporter.reports[0].tags)
+	})
+}
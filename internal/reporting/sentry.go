@@ -0,0 +1,43 @@
+package reporting
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/pkg/errors"
+)
+
+// SentryReporter reports errors to Sentry.
+type SentryReporter struct {
+	client *sentry.Client
+}
+
+var _ Reporter = (*SentryReporter)(nil)
+
+// NewSentryReporter creates a reporter sending errors to the Sentry project with the given DSN.
+func NewSentryReporter(dsn string) (*SentryReporter, error) {
+	return newSentryReporter(sentry.ClientOptions{Dsn: dsn})
+}
+
+func newSentryReporter(options sentry.ClientOptions) (*SentryReporter, error) {
+	client, err := sentry.NewClient(options)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize sentry client")
+	}
+
+	return &SentryReporter{client: client}, nil
+}
+
+// Report sends the given error to Sentry.
+func (r *SentryReporter) Report(err error, message string, tags map[string]string) {
+	scope := sentry.NewScope()
+	scope.SetTags(tags)
+	scope.SetExtra("message", message)
+
+	r.client.CaptureException(err, nil, scope)
+}
+
+// Flush waits up to the given timeout for reported errors to be sent to Sentry.
+func (r *SentryReporter) Flush(timeout time.Duration) bool {
+	return r.client.Flush(timeout)
+}
@@ -0,0 +1,51 @@
+package reporting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTransport struct {
+	events []*sentry.Event
+}
+
+func (t *mockTransport) Configure(options sentry.ClientOptions) {}
+
+func (t *mockTransport) SendEvent(event *sentry.Event) {
+	t.events = append(t.events, event)
+}
+
+func (t *mockTransport) Flush(timeout time.Duration) bool {
+	return true
+}
+
+func TestSentryReporter(t *testing.T) {
+	transport := &mockTransport{}
+	reporter, err := newSentryReporter(sentry.ClientOptions{
+		Dsn:       "https://key@sentry.example.com/1",
+		Transport: transport,
+	})
+	require.NoError(t, err)
+
+	reporter.Report(errors.Wrap(errors.New("failure"), "wrapped"), "failed to query plugins", map[string]string{
+		"request": "request-id",
+	})
+	require.True(t, reporter.Flush(time.Second))
+
+	require.Len(t, transport.events, 1)
+	event := transport.events[0]
+	require.Equal(t, "request-id", event.Tags["request"])
+	require.Equal(t, "failed to query plugins", event.Extra["message"])
+	require.NotEmpty(t, event.Exception)
+	require.Equal(t, "wrapped: failure", event.Exception[len(event.Exception)-1].Value)
+	require.NotNil(t, event.Exception[len(event.Exception)-1].Stacktrace)
+}
+
+func TestNewSentryReporterInvalidDSN(t *testing.T) {
+	_, err := NewSentryReporter("invalid")
+	require.Error(t, err)
+}
@@ -0,0 +1,147 @@
+// Package screening screens the names and descriptions of plugins, e.g. for profanity or
+// trademarks, before they enter the catalog.
+package screening
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Screener screens plugins, returning the reasons for rejecting a plugin, if any.
+type Screener interface {
+	Screen(plugin *model.Plugin) ([]string, error)
+}
+
+// Denylist rejects plugins whose name or description matches any of its patterns.
+type Denylist struct {
+	patterns []*regexp.Regexp
+}
+
+// ParseDenylist reads a denylist with a case-insensitive regular expression on each line. Empty
+// lines and lines starting with # are ignored.
+func ParseDenylist(reader io.Reader) (*Denylist, error) {
+	denylist := &Denylist{}
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		value := strings.TrimSpace(scanner.Text())
+		if value == "" || strings.HasPrefix(value, "#") {
+			continue
+		}
+
+		pattern, err := regexp.Compile("(?i)" + value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern on line %d", line)
+		}
+		denylist.patterns = append(denylist.patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read denylist")
+	}
+
+	return denylist, nil
+}
+
+// Screen rejects the given plugin if its name or description matches any pattern.
+func (d *Denylist) Screen(plugin *model.Plugin) ([]string, error) {
+	if plugin.Manifest == nil {
+		return nil, nil
+	}
+
+	var reasons []string
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"name", plugin.Manifest.Name},
+		{"description", plugin.Manifest.Description},
+	} {
+		for _, pattern := range d.patterns {
+			if match := pattern.FindString(field.value); match != "" {
+				reasons = append(reasons, fmt.Sprintf("%s contains denied term %q", field.name, match))
+			}
+		}
+	}
+
+	return reasons, nil
+}
+
+// CommandInput is written as JSON to the standard input of a screening command.
+type CommandInput struct {
+	ID          string `json:"id"`
+	Version     string `json:"version"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Command screens plugins with an external command. The command receives a CommandInput on its
+// standard input, and accepts the plugin by exiting with status 0, or rejects it by exiting with
+// status 1, giving a reason on each line of its standard output. Any other outcome is an error.
+type Command struct {
+	// Path is the command to run.
+	Path string
+	// Args are passed to the command.
+	Args []string
+	// Timeout bounds each run of the command, with 0 leaving it unbounded.
+	Timeout time.Duration
+}
+
+// Screen runs the command for the given plugin.
+func (c *Command) Screen(plugin *model.Plugin) ([]string, error) {
+	if plugin.Manifest == nil {
+		return nil, nil
+	}
+
+	input, err := json.Marshal(&CommandInput{
+		ID:          plugin.Manifest.Id,
+		Version:     plugin.Manifest.Version,
+		Name:        plugin.Manifest.Name,
+		Description: plugin.Manifest.Description,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode screening input")
+	}
+
+	ctx := context.Background()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Path, c.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		var reasons []string
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				reasons = append(reasons, line)
+			}
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, "rejected by "+c.Path)
+		}
+		return reasons, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to run %s: %s", c.Path, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil, nil
+}
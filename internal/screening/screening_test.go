@@ -0,0 +1,73 @@
+package screening_test
+
+import (
+	"strings"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/screening"
+)
+
+func makePlugin(name, description string) *model.Plugin {
+	return &model.Plugin{
+		Manifest: &mattermostModel.Manifest{Id: "demo", Version: "0.1.0", Name: name, Description: description},
+	}
+}
+
+func TestDenylist(t *testing.T) {
+	denylist, err := screening.ParseDenylist(strings.NewReader("# trademarks\n\nacme\\s*corp\n\\bdarn\\b\n"))
+	require.NoError(t, err)
+
+	t.Run("accepted", func(t *testing.T) {
+		reasons, err := denylist.Screen(makePlugin("Demo", "Demonstrates darning socks."))
+		require.NoError(t, err)
+		require.Empty(t, reasons)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		reasons, err := denylist.Screen(makePlugin("ACME Corp Demo", "Darn useful."))
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`name contains denied term "ACME Corp"`,
+			`description contains denied term "Darn"`,
+		}, reasons)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := screening.ParseDenylist(strings.NewReader("valid\n(invalid\n"))
+		require.EqualError(t, err, "invalid pattern on line 2: error parsing regexp: missing closing ): `(?i)(invalid`")
+	})
+}
+
+func TestCommand(t *testing.T) {
+	t.Run("accepted", func(t *testing.T) {
+		command := &screening.Command{Path: "sh", Args: []string{"-c", `grep -q '"name":"Demo"'`}}
+		reasons, err := command.Screen(makePlugin("Demo", "Demonstrates the plugin framework."))
+		require.NoError(t, err)
+		require.Empty(t, reasons)
+	})
+
+	t.Run("rejected with reasons", func(t *testing.T) {
+		command := &screening.Command{Path: "sh", Args: []string{"-c", "echo 'name is trademarked'; echo; echo 'description is rude'; exit 1"}}
+		reasons, err := command.Screen(makePlugin("Demo", "Demonstrates the plugin framework."))
+		require.NoError(t, err)
+		require.Equal(t, []string{"name is trademarked", "description is rude"}, reasons)
+	})
+
+	t.Run("rejected without reasons", func(t *testing.T) {
+		command := &screening.Command{Path: "sh", Args: []string{"-c", "exit 1"}}
+		reasons, err := command.Screen(makePlugin("Demo", ""))
+		require.NoError(t, err)
+		require.Equal(t, []string{"rejected by sh"}, reasons)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		command := &screening.Command{Path: "sh", Args: []string{"-c", "echo broken >&2; exit 2"}}
+		_, err := command.Screen(makePlugin("Demo", ""))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "broken")
+	})
+}
@@ -0,0 +1,61 @@
+package signing
+
+import (
+	"crypto"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// NewKeyringSigner signs with a private key read from an armored OpenPGP keyring, such as one
+// exported by gpg --export-secret-keys --armor.
+//
+// If keyID is given, the key whose id ends with it is used; otherwise the keyring must hold a
+// single private key. An encrypted key is decrypted with the given passphrase.
+func NewKeyringSigner(keyring io.Reader, keyID string, passphrase []byte) (*OpenPGPSigner, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(keyring)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read keyring")
+	}
+
+	keyID = strings.ToLower(strings.TrimPrefix(keyID, "0x"))
+
+	var candidates []*openpgp.Entity
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			continue
+		}
+		if keyID != "" && !strings.HasSuffix(fmt.Sprintf("%016x", entity.PrimaryKey.KeyId), keyID) {
+			continue
+		}
+		candidates = append(candidates, entity)
+	}
+
+	switch {
+	case len(candidates) == 0 && keyID != "":
+		return nil, errors.Errorf("keyring has no private key %s", keyID)
+	case len(candidates) == 0:
+		return nil, errors.New("keyring has no private keys")
+	case len(candidates) > 1:
+		return nil, errors.Errorf("keyring has %d matching private keys, select one by key id", len(candidates))
+	}
+
+	entity := candidates[0]
+	if entity.PrivateKey.Encrypted {
+		if len(passphrase) == 0 {
+			return nil, errors.New("private key is encrypted, but no passphrase was given")
+		}
+		if err := entity.PrivateKey.Decrypt(passphrase); err != nil {
+			return nil, errors.Wrap(err, "failed to decrypt private key")
+		}
+	}
+
+	return &OpenPGPSigner{
+		entity: entity,
+		config: &packet.Config{DefaultHash: crypto.SHA256},
+	}, nil
+}
@@ -0,0 +1,118 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/pkg/errors"
+)
+
+// KMSSigner is a crypto.Signer backed by an asymmetric AWS KMS key, whose private key never leaves
+// KMS.
+type KMSSigner struct {
+	client       kmsiface.KMSAPI
+	keyID        string
+	publicKey    crypto.PublicKey
+	creationDate time.Time
+}
+
+var _ crypto.Signer = (*KMSSigner)(nil)
+
+// NewKMSSigner creates a signer using the given KMS key, which must have a key usage of
+// SIGN_VERIFY.
+func NewKMSSigner(client kmsiface.KMSAPI, keyID string) (*KMSSigner, error) {
+	describeOutput, err := client.DescribeKey(&kms.DescribeKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to describe key %s", keyID)
+	}
+	if describeOutput.KeyMetadata == nil {
+		return nil, errors.Errorf("key %s has no metadata", keyID)
+	}
+
+	publicKeyOutput, err := client.GetPublicKey(&kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get public key of %s", keyID)
+	}
+	if aws.StringValue(publicKeyOutput.KeyUsage) != kms.KeyUsageTypeSignVerify {
+		return nil, errors.Errorf("key %s is not usable for signing", keyID)
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(publicKeyOutput.PublicKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse public key of %s", keyID)
+	}
+
+	return &KMSSigner{
+		client:       client,
+		keyID:        keyID,
+		publicKey:    publicKey,
+		creationDate: aws.TimeValue(describeOutput.KeyMetadata.CreationDate),
+	}, nil
+}
+
+// CreationDate returns when the KMS key was created, serving as a stable creation time for the
+// OpenPGP key wrapping it.
+func (s *KMSSigner) CreationDate() time.Time {
+	return s.creationDate
+}
+
+// Public returns the public key of the KMS key.
+func (s *KMSSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign asks KMS to sign the given digest, returning a PKCS #1 v1.5 signature for RSA keys or an
+// ASN.1 encoded signature for ECDSA keys, as crypto.Signer requires.
+func (s *KMSSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	algorithm, err := s.signingAlgorithm(opts.HashFunc())
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.client.Sign(&kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(algorithm),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to sign with key %s", s.keyID)
+	}
+
+	return output.Signature, nil
+}
+
+// signingAlgorithm maps the given hash to the KMS signing algorithm for the key's type.
+func (s *KMSSigner) signingAlgorithm(hash crypto.Hash) (string, error) {
+	switch s.publicKey.(type) {
+	case *rsa.PublicKey:
+		switch hash {
+		case crypto.SHA256:
+			return kms.SigningAlgorithmSpecRsassaPkcs1V15Sha256, nil
+		case crypto.SHA384:
+			return kms.SigningAlgorithmSpecRsassaPkcs1V15Sha384, nil
+		case crypto.SHA512:
+			return kms.SigningAlgorithmSpecRsassaPkcs1V15Sha512, nil
+		}
+	case *ecdsa.PublicKey:
+		switch hash {
+		case crypto.SHA256:
+			return kms.SigningAlgorithmSpecEcdsaSha256, nil
+		case crypto.SHA384:
+			return kms.SigningAlgorithmSpecEcdsaSha384, nil
+		case crypto.SHA512:
+			return kms.SigningAlgorithmSpecEcdsaSha512, nil
+		}
+	default:
+		return "", errors.Errorf("unsupported public key type %T", s.publicKey)
+	}
+
+	return "", errors.Errorf("unsupported hash %v", hash)
+}
@@ -0,0 +1,105 @@
+package signing_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/signing"
+)
+
+// mockKMSClient serves a local RSA key as if it were held by KMS.
+type mockKMSClient struct {
+	kmsiface.KMSAPI
+	key          *rsa.PrivateKey
+	keyUsage     string
+	creationDate time.Time
+}
+
+func (m *mockKMSClient) DescribeKey(input *kms.DescribeKeyInput) (*kms.DescribeKeyOutput, error) {
+	return &kms.DescribeKeyOutput{
+		KeyMetadata: &kms.KeyMetadata{
+			KeyId:        input.KeyId,
+			CreationDate: aws.Time(m.creationDate),
+		},
+	}, nil
+}
+
+func (m *mockKMSClient) GetPublicKey(input *kms.GetPublicKeyInput) (*kms.GetPublicKeyOutput, error) {
+	publicKey, err := x509.MarshalPKIXPublicKey(&m.key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kms.GetPublicKeyOutput{
+		KeyId:     input.KeyId,
+		KeyUsage:  aws.String(m.keyUsage),
+		PublicKey: publicKey,
+	}, nil
+}
+
+func (m *mockKMSClient) Sign(input *kms.SignInput) (*kms.SignOutput, error) {
+	if aws.StringValue(input.MessageType) != kms.MessageTypeDigest {
+		return nil, errors.New("expected a digest")
+	}
+	if aws.StringValue(input.SigningAlgorithm) != kms.SigningAlgorithmSpecRsassaPkcs1V15Sha256 {
+		return nil, errors.Errorf("unexpected signing algorithm %s", aws.StringValue(input.SigningAlgorithm))
+	}
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, input.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kms.SignOutput{KeyId: input.KeyId, Signature: signature}, nil
+}
+
+func TestKMSSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	creationDate := time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("signs via kms", func(t *testing.T) {
+		kmsSigner, err := signing.NewKMSSigner(&mockKMSClient{
+			key:          key,
+			keyUsage:     kms.KeyUsageTypeSignVerify,
+			creationDate: creationDate,
+		}, "alias/marketplace")
+		require.NoError(t, err)
+		require.Equal(t, creationDate, kmsSigner.CreationDate())
+		require.Equal(t, &key.PublicKey, kmsSigner.Public())
+
+		signer, err := signing.NewOpenPGPSigner(kmsSigner, kmsSigner.CreationDate(), "Marketplace Test")
+		require.NoError(t, err)
+
+		var publicKey bytes.Buffer
+		require.NoError(t, signer.WritePublicKey(&publicKey))
+		keyring, err := api.ReadPublicKeys(&publicKey)
+		require.NoError(t, err)
+
+		bundle := []byte("plugin bundle contents")
+		signature, err := signer.Sign(bytes.NewReader(bundle))
+		require.NoError(t, err)
+		require.NoError(t, api.VerifyPluginSignature(bytes.NewReader(bundle), signature.Signature, keyring))
+	})
+
+	t.Run("key not usable for signing", func(t *testing.T) {
+		_, err := signing.NewKMSSigner(&mockKMSClient{
+			key:          key,
+			keyUsage:     kms.KeyUsageTypeEncryptDecrypt,
+			creationDate: creationDate,
+		}, "alias/marketplace")
+		require.EqualError(t, err, "key alias/marketplace is not usable for signing")
+	})
+}
@@ -0,0 +1,132 @@
+// Package signing produces the detached OpenPGP signatures recorded for plugin bundles and
+// databases, delegating the private key operation to a crypto.Signer such as a KMS or HSM key.
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Signer produces detached signatures.
+type Signer interface {
+	// Sign returns a detached signature of the given message.
+	Sign(message io.Reader) (*model.Signature, error)
+}
+
+// OpenPGPSigner produces detached OpenPGP signatures without holding the private key, which
+// remains with the underlying crypto.Signer.
+type OpenPGPSigner struct {
+	entity *openpgp.Entity
+	config *packet.Config
+}
+
+var _ Signer = (*OpenPGPSigner)(nil)
+
+// NewOpenPGPSigner wraps the given crypto.Signer, which must hold an RSA or ECDSA key, as an
+// OpenPGP key identified by the given name.
+//
+// The OpenPGP key id depends on the given creation time, which must therefore be stable for each
+// underlying key.
+func NewOpenPGPSigner(signer crypto.Signer, creationTime time.Time, name string) (*OpenPGPSigner, error) {
+	switch signer.Public().(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, errors.Errorf("unsupported public key type %T", signer.Public())
+	}
+
+	config := &packet.Config{DefaultHash: crypto.SHA256}
+	privateKey := packet.NewSignerPrivateKey(creationTime, signer)
+
+	userID := packet.NewUserId(name, "", "")
+	if userID == nil {
+		return nil, errors.Errorf("invalid name %s", name)
+	}
+
+	isPrimaryID := true
+	selfSignature := &packet.Signature{
+		CreationTime: creationTime,
+		SigType:      packet.SigTypePositiveCert,
+		PubKeyAlgo:   privateKey.PubKeyAlgo,
+		Hash:         config.Hash(),
+		IsPrimaryId:  &isPrimaryID,
+		FlagsValid:   true,
+		FlagSign:     true,
+		IssuerKeyId:  &privateKey.KeyId,
+	}
+
+	entity := &openpgp.Entity{
+		PrimaryKey: &privateKey.PublicKey,
+		PrivateKey: privateKey,
+		Identities: map[string]*openpgp.Identity{
+			userID.Id: {
+				Name:          userID.Id,
+				UserId:        userID,
+				SelfSignature: selfSignature,
+			},
+		},
+	}
+	if err := selfSignature.SignUserId(userID.Id, entity.PrimaryKey, entity.PrivateKey, config); err != nil {
+		return nil, errors.Wrap(err, "failed to self-sign key")
+	}
+
+	return &OpenPGPSigner{
+		entity: entity,
+		config: config,
+	}, nil
+}
+
+// PublicKeyHash identifies the key, matching model.Signature.PublicKeyHash.
+func (s *OpenPGPSigner) PublicKeyHash() string {
+	return fmt.Sprintf("%016x", s.entity.PrimaryKey.KeyId)
+}
+
+// SetSignatureTime dates every subsequent signature with the given time rather than the time of
+// signing, so that an RSA key signs the same message identically every time. ECDSA signatures
+// remain randomized.
+func (s *OpenPGPSigner) SetSignatureTime(signatureTime time.Time) {
+	s.config.Time = func() time.Time {
+		return signatureTime
+	}
+}
+
+// Sign returns a base64-encoded detached signature of the given message.
+func (s *OpenPGPSigner) Sign(message io.Reader) (*model.Signature, error) {
+	var signature bytes.Buffer
+	if err := openpgp.DetachSign(&signature, s.entity, message, s.config); err != nil {
+		return nil, errors.Wrap(err, "failed to sign")
+	}
+
+	return &model.Signature{
+		Signature:     base64.StdEncoding.EncodeToString(signature.Bytes()),
+		PublicKeyHash: s.PublicKeyHash(),
+	}, nil
+}
+
+// WritePublicKey writes the armored public key, for distribution to those verifying signatures.
+func (s *OpenPGPSigner) WritePublicKey(w io.Writer) error {
+	armorWriter, err := armor.Encode(w, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to armor public key")
+	}
+	if err := s.entity.Serialize(armorWriter); err != nil {
+		return errors.Wrap(err, "failed to serialize public key")
+	}
+	if err := armorWriter.Close(); err != nil {
+		return errors.Wrap(err, "failed to armor public key")
+	}
+
+	return nil
+}
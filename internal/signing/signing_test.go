@@ -0,0 +1,79 @@
+package signing_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/signing"
+)
+
+func TestOpenPGPSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	creationTime := time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)
+	bundle := []byte("plugin bundle contents")
+
+	for name, key := range map[string]crypto.Signer{"rsa": rsaKey, "ecdsa": ecdsaKey} {
+		t.Run(name, func(t *testing.T) {
+			signer, err := signing.NewOpenPGPSigner(key, creationTime, "Marketplace Test")
+			require.NoError(t, err)
+
+			var publicKey bytes.Buffer
+			require.NoError(t, signer.WritePublicKey(&publicKey))
+			keyring, err := api.ReadPublicKeys(&publicKey)
+			require.NoError(t, err)
+			require.Len(t, keyring, 1)
+
+			signature, err := signer.Sign(bytes.NewReader(bundle))
+			require.NoError(t, err)
+			require.Equal(t, signer.PublicKeyHash(), signature.PublicKeyHash)
+
+			keyID, err := api.SignatureKeyID(signature.Signature)
+			require.NoError(t, err)
+			require.Equal(t, signer.PublicKeyHash(), keyID)
+
+			require.NoError(t, api.VerifyPluginSignature(bytes.NewReader(bundle), signature.Signature, keyring))
+			require.Error(t, api.VerifyPluginSignature(bytes.NewReader([]byte("tampered")), signature.Signature, keyring))
+		})
+	}
+
+	t.Run("key id is stable for a creation time", func(t *testing.T) {
+		signer, err := signing.NewOpenPGPSigner(rsaKey, creationTime, "Marketplace Test")
+		require.NoError(t, err)
+		sameSigner, err := signing.NewOpenPGPSigner(rsaKey, creationTime, "Renamed")
+		require.NoError(t, err)
+		laterSigner, err := signing.NewOpenPGPSigner(rsaKey, creationTime.Add(time.Hour), "Marketplace Test")
+		require.NoError(t, err)
+
+		require.Equal(t, signer.PublicKeyHash(), sameSigner.PublicKeyHash())
+		require.NotEqual(t, signer.PublicKeyHash(), laterSigner.PublicKeyHash())
+	})
+	t.Run("signature time", func(t *testing.T) {
+		signer, err := signing.NewOpenPGPSigner(rsaKey, creationTime, "Marketplace Test")
+		require.NoError(t, err)
+		signer.SetSignatureTime(creationTime.Add(time.Hour))
+
+		signature, err := signer.Sign(bytes.NewReader(bundle))
+		require.NoError(t, err)
+		sameSignature, err := signer.Sign(bytes.NewReader(bundle))
+		require.NoError(t, err)
+		require.Equal(t, signature, sameSignature)
+
+		signer.SetSignatureTime(creationTime.Add(2 * time.Hour))
+		laterSignature, err := signer.Sign(bytes.NewReader(bundle))
+		require.NoError(t, err)
+		require.NotEqual(t, signature.Signature, laterSignature.Signature)
+	})
+}
@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/pkg/errors"
+)
+
+// S3Backend persists download records as JSON to an object in S3.
+type S3Backend struct {
+	Client s3iface.S3API
+	Bucket string
+	Key    string
+}
+
+// Load reads the records from the object, returning none if it does not yet exist.
+func (b *S3Backend) Load() ([]*Record, error) {
+	object, err := b.Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(b.Bucket),
+		Key:    aws.String(b.Key),
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to get s3://%s/%s", b.Bucket, b.Key)
+	}
+	defer object.Body.Close()
+
+	var records []*Record
+	if err := json.NewDecoder(object.Body).Decode(&records); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse s3://%s/%s", b.Bucket, b.Key)
+	}
+
+	return records, nil
+}
+
+// Save replaces the object with the given records.
+func (b *S3Backend) Save(records []*Record) error {
+	data, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal records")
+	}
+
+	_, err = b.Client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(b.Bucket),
+		Key:         aws.String(b.Key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to put s3://%s/%s", b.Bucket, b.Key)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/require"
+)
+
+type mockS3Client struct {
+	s3iface.S3API
+	objects map[string][]byte
+}
+
+func (m *mockS3Client) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	data, ok := m.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)
+	}
+
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (m *mockS3Client) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	data, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)] = data
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestS3Backend(t *testing.T) {
+	backend := &S3Backend{
+		Client: &mockS3Client{objects: map[string][]byte{}},
+		Bucket: "bucket",
+		Key:    "stats.json",
+	}
+
+	records, err := backend.Load()
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	tracker, err := NewTracker(backend)
+	require.NoError(t, err)
+	tracker.RecordDownload("demo", "0.1.0")
+	require.NoError(t, tracker.Flush())
+
+	restarted, err := NewTracker(backend)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, restarted.PluginStats("demo").TotalDownloads)
+}
@@ -0,0 +1,393 @@
+// Package stats aggregates plugin download counters and reported installations per plugin,
+// version and day, persisting them to a pluggable backend.
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// dateFormat is the layout of the UTC day each download is aggregated under.
+const dateFormat = "2006-01-02"
+
+// Record counts the downloads of a single plugin version on a single UTC day, along with the
+// installations of and upgrades to it reported by Mattermost servers.
+type Record struct {
+	PluginID  string `json:"plugin_id"`
+	Version   string `json:"version"`
+	Date      string `json:"date"`
+	Downloads int64  `json:"downloads"`
+	Installs  int64  `json:"installs,omitempty"`
+	Upgrades  int64  `json:"upgrades,omitempty"`
+}
+
+// Backend persists download records.
+type Backend interface {
+	// Load returns all persisted records, or none if nothing was persisted yet.
+	Load() ([]*Record, error)
+	// Save replaces the persisted records with the given records.
+	Save(records []*Record) error
+}
+
+type key struct {
+	pluginID string
+	version  string
+	date     string
+}
+
+// report identifies an installation reported by a single reporter, counted at most once per day.
+type report struct {
+	reporter string
+	pluginID string
+	version  string
+	upgrade  bool
+}
+
+// tally holds the counters recorded under a single key.
+type tally struct {
+	downloads int64
+	installs  int64
+	upgrades  int64
+}
+
+func (t tally) add(other tally) tally {
+	return tally{
+		downloads: t.downloads + other.downloads,
+		installs:  t.installs + other.installs,
+		upgrades:  t.upgrades + other.upgrades,
+	}
+}
+
+// Tracker counts downloads and reported installations in memory, periodically merging them into
+// a backend.
+//
+// Flushing reloads the backend before saving, so that several trackers sharing a backend lose
+// only the events recorded between a concurrent load and save.
+type Tracker struct {
+	backend Backend
+	now     func() time.Time
+
+	lock    sync.Mutex
+	counts  map[key]tally
+	pending map[key]tally
+	// flushing is set while the pending downloads are merged into the backend.
+	flushing bool
+	// reported holds the installations reported on reportedDate, which are only held in memory.
+	reportedDate string
+	reported     map[report]bool
+}
+
+// NewTracker creates a tracker initialized with the records persisted to the given backend.
+func NewTracker(backend Backend) (*Tracker, error) {
+	records, err := backend.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load download records")
+	}
+
+	return &Tracker{
+		backend: backend,
+		now:     time.Now,
+		counts:  countsFromRecords(records),
+		pending: map[key]tally{},
+	}, nil
+}
+
+func countsFromRecords(records []*Record) map[key]tally {
+	counts := map[key]tally{}
+	for _, record := range records {
+		k := key{record.PluginID, record.Version, record.Date}
+		counts[k] = counts[k].add(tally{record.Downloads, record.Installs, record.Upgrades})
+	}
+
+	return counts
+}
+
+// record adds the given counters to those of the given plugin version today.
+func (t *Tracker) record(pluginID, version string, counters tally) {
+	k := key{pluginID, version, t.now().UTC().Format(dateFormat)}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.counts[k] = t.counts[k].add(counters)
+	t.pending[k] = t.pending[k].add(counters)
+}
+
+// RecordDownload counts a download of the given plugin version.
+func (t *Tracker) RecordDownload(pluginID, version string) {
+	t.record(pluginID, version, tally{downloads: 1})
+}
+
+// RecordInstall counts a reported installation of, or upgrade to, the given plugin version,
+// reporting whether it was counted. Repeated reports of the same event by the given reporter, such
+// as a server id or a client address, are only counted once per day.
+func (t *Tracker) RecordInstall(reporter, pluginID, version string, upgrade bool) bool {
+	date := t.now().UTC().Format(dateFormat)
+	r := report{reporter, pluginID, version, upgrade}
+
+	t.lock.Lock()
+	if t.reportedDate != date {
+		t.reportedDate = date
+		t.reported = map[report]bool{}
+	}
+	if t.reported[r] {
+		t.lock.Unlock()
+		return false
+	}
+	t.reported[r] = true
+	t.lock.Unlock()
+
+	if upgrade {
+		t.record(pluginID, version, tally{upgrades: 1})
+	} else {
+		t.record(pluginID, version, tally{installs: 1})
+	}
+
+	return true
+}
+
+// Flush merges the downloads recorded since the last flush into the backend.
+//
+// The backend is accessed without holding the tracker's lock, so that a slow backend never delays
+// recording or reporting downloads. Should the backend fail, the pending downloads are retained
+// for the next flush, and a flush already in progress skips any other.
+func (t *Tracker) Flush() error {
+	t.lock.Lock()
+	if t.flushing || len(t.pending) == 0 {
+		t.lock.Unlock()
+		return nil
+	}
+	flushed := t.pending
+	t.pending = map[key]tally{}
+	t.flushing = true
+	t.lock.Unlock()
+
+	counts, err := t.save(flushed)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.flushing = false
+	if err != nil {
+		for k, pending := range flushed {
+			t.pending[k] = t.pending[k].add(pending)
+		}
+		return err
+	}
+
+	// Downloads recorded while saving remain pending, and are counted on top of those saved.
+	for k, pending := range t.pending {
+		counts[k] = counts[k].add(pending)
+	}
+	t.counts = counts
+
+	return nil
+}
+
+// save merges the given downloads into those persisted to the backend, returning the counts
+// saved.
+func (t *Tracker) save(pending map[key]tally) (map[key]tally, error) {
+	records, err := t.backend.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load download records")
+	}
+
+	counts := countsFromRecords(records)
+	for k, pending := range pending {
+		counts[k] = counts[k].add(pending)
+	}
+
+	if err := t.backend.Save(recordsFromCounts(counts)); err != nil {
+		return nil, errors.Wrap(err, "failed to save download records")
+	}
+
+	return counts, nil
+}
+
+// Run flushes the tracker at the given interval until done is closed, flushing a final time
+// before returning.
+func (t *Tracker) Run(interval time.Duration, done <-chan struct{}, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := t.Flush(); err != nil {
+				onError(err)
+			}
+		case <-done:
+			if err := t.Flush(); err != nil {
+				onError(err)
+			}
+			return
+		}
+	}
+}
+
+func recordsFromCounts(counts map[key]tally) []*Record {
+	records := make([]*Record, 0, len(counts))
+	for k, counters := range counts {
+		records = append(records, &Record{k.pluginID, k.version, k.date, counters.downloads, counters.installs, counters.upgrades})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.PluginID != b.PluginID {
+			return a.PluginID < b.PluginID
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Date < b.Date
+	})
+
+	return records
+}
+
+// dailyDownloads flattens the given per-day totals, ordered by date ascending, omitting days
+// without downloads.
+func dailyDownloads(daily map[string]int64) []model.DailyDownloads {
+	result := make([]model.DailyDownloads, 0, len(daily))
+	for date, downloads := range daily {
+		if downloads == 0 {
+			continue
+		}
+		result = append(result, model.DailyDownloads{Date: date, Downloads: downloads})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date < result[j].Date
+	})
+
+	return result
+}
+
+// PluginStats summarizes the downloads and reported installations of the given plugin.
+func (t *Tracker) PluginStats(pluginID string) *model.PluginStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stats := &model.PluginStats{
+		PluginID: pluginID,
+		Versions: map[string]int64{},
+	}
+	daily := map[string]int64{}
+	for k, counters := range t.counts {
+		if k.pluginID != pluginID {
+			continue
+		}
+
+		if counters.downloads > 0 {
+			stats.TotalDownloads += counters.downloads
+			stats.Versions[k.version] += counters.downloads
+			daily[k.date] += counters.downloads
+		}
+
+		if counters.installs > 0 || counters.upgrades > 0 {
+			stats.TotalInstalls += counters.installs
+			stats.TotalUpgrades += counters.upgrades
+			if stats.Adoption == nil {
+				stats.Adoption = map[string]*model.VersionAdoption{}
+			}
+			adoption, ok := stats.Adoption[k.version]
+			if !ok {
+				adoption = &model.VersionAdoption{}
+				stats.Adoption[k.version] = adoption
+			}
+			adoption.Installs += counters.installs
+			adoption.Upgrades += counters.upgrades
+		}
+	}
+	stats.Daily = dailyDownloads(daily)
+
+	return stats
+}
+
+// MarketplaceStats summarizes the downloads across all plugins.
+func (t *Tracker) MarketplaceStats() *model.MarketplaceStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stats := &model.MarketplaceStats{
+		Plugins: map[string]int64{},
+	}
+	daily := map[string]int64{}
+	for k, counters := range t.counts {
+		stats.TotalInstalls += counters.installs
+		stats.TotalUpgrades += counters.upgrades
+		if counters.downloads == 0 {
+			continue
+		}
+
+		stats.TotalDownloads += counters.downloads
+		stats.Plugins[k.pluginID] += counters.downloads
+		daily[k.date] += counters.downloads
+	}
+	stats.Daily = dailyDownloads(daily)
+
+	return stats
+}
+
+// WriteMetrics writes the total downloads and reported installations of each plugin version in
+// the Prometheus text exposition format.
+func (t *Tracker) WriteMetrics(w io.Writer) error {
+	t.lock.Lock()
+	totals := map[key]tally{}
+	for k, counters := range t.counts {
+		k := key{pluginID: k.pluginID, version: k.version}
+		totals[k] = totals[k].add(counters)
+	}
+	t.lock.Unlock()
+	records := recordsFromCounts(totals)
+
+	if _, err := fmt.Fprint(w, "# HELP marketplace_plugin_downloads_total Plugin downloads served by the marketplace.\n# TYPE marketplace_plugin_downloads_total counter\n"); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if record.Downloads == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "marketplace_plugin_downloads_total{plugin_id=%q,version=%q} %d\n", record.PluginID, record.Version, record.Downloads); err != nil {
+			return err
+		}
+	}
+
+	// Only servers opting in report installations, so omit the counter until any do.
+	reported := false
+	for _, record := range records {
+		reported = reported || record.Installs > 0 || record.Upgrades > 0
+	}
+	if !reported {
+		return nil
+	}
+
+	if _, err := fmt.Fprint(w, "# HELP marketplace_plugin_installs_total Plugin installations and upgrades reported by Mattermost servers.\n# TYPE marketplace_plugin_installs_total counter\n"); err != nil {
+		return err
+	}
+	for _, record := range records {
+		for _, event := range []struct {
+			eventType model.InstallEventType
+			count     int64
+		}{
+			{model.InstallEventInstall, record.Installs},
+			{model.InstallEventUpgrade, record.Upgrades},
+		} {
+			if event.count == 0 {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "marketplace_plugin_installs_total{plugin_id=%q,version=%q,event=%q} %d\n", record.PluginID, record.Version, event.eventType, event.count); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
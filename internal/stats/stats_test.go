@@ -0,0 +1,269 @@
+package stats
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// fixedClock returns a clock reporting the given time until advanced.
+func fixedClock(now *time.Time) func() time.Time {
+	return func() time.Time {
+		return *now
+	}
+}
+
+func newFileTracker(t *testing.T, path string, now *time.Time) *Tracker {
+	tracker, err := NewTracker(&FileBackend{Path: path})
+	require.NoError(t, err)
+	tracker.now = fixedClock(now)
+
+	return tracker
+}
+
+func TestTracker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stats")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "stats.json")
+
+	now := time.Date(2019, 11, 1, 23, 0, 0, 0, time.UTC)
+	tracker := newFileTracker(t, path, &now)
+
+	t.Run("no downloads", func(t *testing.T) {
+		require.Equal(t, &model.PluginStats{
+			PluginID: "demo",
+			Versions: map[string]int64{},
+			Daily:    []model.DailyDownloads{},
+		}, tracker.PluginStats("demo"))
+		require.Equal(t, &model.MarketplaceStats{
+			Plugins: map[string]int64{},
+			Daily:   []model.DailyDownloads{},
+		}, tracker.MarketplaceStats())
+
+		// Nothing is written until something is recorded.
+		require.NoError(t, tracker.Flush())
+		_, err := os.Stat(path)
+		require.True(t, os.IsNotExist(err))
+	})
+
+	tracker.RecordDownload("demo", "0.1.0")
+	tracker.RecordDownload("demo", "0.2.0")
+	now = now.Add(2 * time.Hour)
+	tracker.RecordDownload("demo", "0.2.0")
+	tracker.RecordDownload("starter", "0.1.0")
+
+	expectedDemoStats := &model.PluginStats{
+		PluginID:       "demo",
+		TotalDownloads: 3,
+		Versions:       map[string]int64{"0.1.0": 1, "0.2.0": 2},
+		Daily: []model.DailyDownloads{
+			{Date: "2019-11-01", Downloads: 2},
+			{Date: "2019-11-02", Downloads: 1},
+		},
+	}
+	expectedMarketplaceStats := &model.MarketplaceStats{
+		TotalDownloads: 4,
+		Plugins:        map[string]int64{"demo": 3, "starter": 1},
+		Daily: []model.DailyDownloads{
+			{Date: "2019-11-01", Downloads: 2},
+			{Date: "2019-11-02", Downloads: 2},
+		},
+	}
+
+	t.Run("aggregates per plugin, version and day", func(t *testing.T) {
+		require.Equal(t, expectedDemoStats, tracker.PluginStats("demo"))
+		require.Equal(t, expectedMarketplaceStats, tracker.MarketplaceStats())
+	})
+
+	t.Run("persists across restarts", func(t *testing.T) {
+		require.NoError(t, tracker.Flush())
+
+		restarted := newFileTracker(t, path, &now)
+		require.Equal(t, expectedDemoStats, restarted.PluginStats("demo"))
+		require.Equal(t, expectedMarketplaceStats, restarted.MarketplaceStats())
+	})
+
+	t.Run("merges concurrent trackers", func(t *testing.T) {
+		other := newFileTracker(t, path, &now)
+		other.RecordDownload("starter", "0.1.0")
+		tracker.RecordDownload("starter", "0.1.0")
+
+		require.NoError(t, other.Flush())
+		require.NoError(t, tracker.Flush())
+
+		require.EqualValues(t, 3, tracker.PluginStats("starter").TotalDownloads)
+		require.EqualValues(t, 3, newFileTracker(t, path, &now).PluginStats("starter").TotalDownloads)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, tracker.WriteMetrics(&buf))
+		require.Equal(t, `# HELP marketplace_plugin_downloads_total Plugin downloads served by the marketplace.
+# TYPE marketplace_plugin_downloads_total counter
+marketplace_plugin_downloads_total{plugin_id="demo",version="0.1.0"} 1
+marketplace_plugin_downloads_total{plugin_id="demo",version="0.2.0"} 2
+marketplace_plugin_downloads_total{plugin_id="starter",version="0.1.0"} 3
+`, buf.String())
+	})
+
+	t.Run("run flushes before returning", func(t *testing.T) {
+		tracker.RecordDownload("demo", "0.1.0")
+
+		done := make(chan struct{})
+		close(done)
+		tracker.Run(time.Hour, done, func(err error) {
+			require.NoError(t, err)
+		})
+
+		require.EqualValues(t, 4, newFileTracker(t, path, &now).PluginStats("demo").TotalDownloads)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+
+		_, err := NewTracker(&FileBackend{Path: path})
+		require.Error(t, err)
+	})
+}
+
+func TestTrackerInstalls(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stats")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "stats.json")
+
+	now := time.Date(2019, 11, 1, 23, 0, 0, 0, time.UTC)
+	tracker := newFileTracker(t, path, &now)
+
+	tracker.RecordDownload("demo", "0.2.0")
+	require.True(t, tracker.RecordInstall("server1", "demo", "0.1.0", false))
+	require.True(t, tracker.RecordInstall("server1", "demo", "0.2.0", false))
+	require.False(t, tracker.RecordInstall("server1", "demo", "0.2.0", false))
+	require.True(t, tracker.RecordInstall("server1", "demo", "0.2.0", true))
+	now = now.Add(2 * time.Hour)
+	require.True(t, tracker.RecordInstall("server1", "demo", "0.2.0", true))
+	require.False(t, tracker.RecordInstall("server1", "demo", "0.2.0", true))
+
+	expectedDemoStats := &model.PluginStats{
+		PluginID:       "demo",
+		TotalDownloads: 1,
+		Versions:       map[string]int64{"0.2.0": 1},
+		Daily:          []model.DailyDownloads{{Date: "2019-11-01", Downloads: 1}},
+		TotalInstalls:  2,
+		TotalUpgrades:  2,
+		Adoption: map[string]*model.VersionAdoption{
+			"0.1.0": {Installs: 1},
+			"0.2.0": {Installs: 1, Upgrades: 2},
+		},
+	}
+
+	t.Run("aggregates per version, apart from downloads", func(t *testing.T) {
+		require.Equal(t, expectedDemoStats, tracker.PluginStats("demo"))
+
+		marketplaceStats := tracker.MarketplaceStats()
+		require.EqualValues(t, 1, marketplaceStats.TotalDownloads)
+		require.EqualValues(t, 2, marketplaceStats.TotalInstalls)
+		require.EqualValues(t, 2, marketplaceStats.TotalUpgrades)
+		require.Len(t, marketplaceStats.Daily, 1)
+	})
+
+	t.Run("persists across restarts", func(t *testing.T) {
+		require.NoError(t, tracker.Flush())
+
+		restarted := newFileTracker(t, path, &now)
+		require.Equal(t, expectedDemoStats, restarted.PluginStats("demo"))
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, tracker.WriteMetrics(&buf))
+		require.Equal(t, `# HELP marketplace_plugin_downloads_total Plugin downloads served by the marketplace.
+# TYPE marketplace_plugin_downloads_total counter
+marketplace_plugin_downloads_total{plugin_id="demo",version="0.2.0"} 1
+# HELP marketplace_plugin_installs_total Plugin installations and upgrades reported by Mattermost servers.
+# TYPE marketplace_plugin_installs_total counter
+marketplace_plugin_installs_total{plugin_id="demo",version="0.1.0",event="install"} 1
+marketplace_plugin_installs_total{plugin_id="demo",version="0.2.0",event="install"} 1
+marketplace_plugin_installs_total{plugin_id="demo",version="0.2.0",event="upgrade"} 2
+`, buf.String())
+	})
+}
+
+// slowBackend persists records in memory, blocking each save until released, or failing while
+// down.
+type slowBackend struct {
+	records []*Record
+	saving  chan struct{}
+	release chan struct{}
+	down    bool
+}
+
+func (b *slowBackend) Load() ([]*Record, error) {
+	if b.down {
+		return nil, errors.New("backend down")
+	}
+	return b.records, nil
+}
+
+func (b *slowBackend) Save(records []*Record) error {
+	if b.saving != nil {
+		b.saving <- struct{}{}
+		<-b.release
+	}
+	b.records = records
+	return nil
+}
+
+func TestTrackerSlowBackend(t *testing.T) {
+	backend := &slowBackend{}
+	tracker, err := NewTracker(backend)
+	require.NoError(t, err)
+
+	now := time.Date(2019, 11, 1, 23, 0, 0, 0, time.UTC)
+	tracker.now = fixedClock(&now)
+
+	t.Run("retains pending downloads while the backend fails", func(t *testing.T) {
+		backend.down = true
+		tracker.RecordDownload("demo", "0.1.0")
+		require.Error(t, tracker.Flush())
+		require.EqualValues(t, 1, tracker.PluginStats("demo").TotalDownloads)
+
+		backend.down = false
+		require.NoError(t, tracker.Flush())
+		require.Equal(t, []*Record{{PluginID: "demo", Version: "0.1.0", Date: "2019-11-01", Downloads: 1}}, backend.records)
+	})
+
+	t.Run("records downloads while saving", func(t *testing.T) {
+		backend.saving = make(chan struct{})
+		backend.release = make(chan struct{})
+
+		tracker.RecordDownload("demo", "0.1.0")
+		flushed := make(chan error)
+		go func() {
+			flushed <- tracker.Flush()
+		}()
+		<-backend.saving
+
+		tracker.RecordDownload("demo", "0.1.0")
+		require.EqualValues(t, 3, tracker.PluginStats("demo").TotalDownloads)
+		require.NoError(t, tracker.Flush(), "concurrent flush skipped")
+
+		close(backend.release)
+		require.NoError(t, <-flushed)
+		require.EqualValues(t, 3, tracker.PluginStats("demo").TotalDownloads)
+		require.EqualValues(t, 2, backend.records[0].Downloads)
+
+		backend.saving = nil
+		require.NoError(t, tracker.Flush())
+		require.EqualValues(t, 3, backend.records[0].Downloads)
+	})
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// LoadApps replaces the apps served by the store with those decoded from the given reader.
+//
+// Apps must be loaded before the store begins serving requests.
+func (store *Store) LoadApps(reader io.Reader) error {
+	apps, err := model.AppsFromReader(reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse apps stream")
+	}
+
+	return store.SetApps(apps)
+}
+
+// SetApps replaces the apps served by the store with the given apps, validated as if decoded from
+// a stream. The store takes ownership of the given apps.
+//
+// Apps must be set before the store begins serving requests.
+func (store *Store) SetApps(apps []*model.App) error {
+	ids := map[string]bool{}
+	for _, app := range apps {
+		if err := app.IsValid(); err != nil {
+			return errors.Wrapf(err, "invalid app %s", app.ID)
+		}
+		if ids[app.ID] {
+			return errors.Errorf("duplicate app %s", app.ID)
+		}
+		ids[app.ID] = true
+	}
+
+	sort.SliceStable(apps, func(i, j int) bool {
+		return strings.ToLower(apps[i].DisplayName) < strings.ToLower(apps[j].DisplayName)
+	})
+
+	store.apps = apps
+
+	return nil
+}
+
+// ShareApps serves the apps of the given store, e.g. when replacing only the plugins it serves.
+//
+// Like LoadApps, apps must be shared before the store begins serving requests.
+func (store *Store) ShareApps(other *Store) {
+	store.apps = other.apps
+}
+
+func appMatchesFilter(app *model.App, filter string) bool {
+	filter = strings.ToLower(filter)
+	if strings.ToLower(app.ID) == filter {
+		return true
+	}
+
+	if strings.Contains(strings.ToLower(app.DisplayName), filter) {
+		return true
+	}
+
+	if strings.Contains(strings.ToLower(app.Description), filter) {
+		return true
+	}
+
+	return false
+}
+
+// GetApps fetches the given page of apps, sorted by display name. The first page is 0.
+func (store *Store) GetApps(appFilter *model.AppFilter) ([]*model.App, error) {
+	if appFilter.PerPage == 0 {
+		return nil, nil
+	}
+
+	var apps []*model.App
+	filter := strings.TrimSpace(appFilter.Filter)
+	for _, app := range store.apps {
+		if filter != "" && !appMatchesFilter(app, filter) {
+			continue
+		}
+		if appFilter.HostingType != "" && app.HostingType != appFilter.HostingType {
+			continue
+		}
+
+		apps = append(apps, app)
+	}
+
+	if len(apps) == 0 {
+		return nil, nil
+	}
+	if appFilter.PerPage == model.AllPerPage {
+		return apps, nil
+	}
+
+	start := appFilter.Page * appFilter.PerPage
+	end := (appFilter.Page + 1) * appFilter.PerPage
+	if start >= len(apps) {
+		return nil, nil
+	}
+	if end > len(apps) {
+		end = len(apps)
+	}
+
+	return apps[start:end], nil
+}
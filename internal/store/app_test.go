@@ -0,0 +1,93 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApps(t *testing.T) {
+	zendeskApp := &model.App{
+		ID:          "zendesk",
+		DisplayName: "Zendesk",
+		Description: "Create and manage Zendesk tickets.",
+		ManifestURL: "https://example.com/zendesk/manifest.json",
+		HostingType: model.AppHostingAWSLambda,
+	}
+	helloApp := &model.App{
+		ID:          "hello-world",
+		DisplayName: "Hello World",
+		ManifestURL: "https://example.com/hello/manifest.json",
+		HostingType: model.AppHostingHTTP,
+	}
+
+	newStoreWithApps := func(t *testing.T, apps []*model.App) (*Store, error) {
+		store, err := New(bytes.NewReader([]byte(`[]`)), testlib.MakeLogger(t))
+		require.NoError(t, err)
+
+		data, err := json.Marshal(apps)
+		require.NoError(t, err)
+
+		return store, store.LoadApps(bytes.NewReader(data))
+	}
+
+	t.Run("no apps loaded", func(t *testing.T) {
+		store, err := New(bytes.NewReader([]byte(`[]`)), testlib.MakeLogger(t))
+		require.NoError(t, err)
+
+		apps, err := store.GetApps(&model.AppFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Nil(t, apps)
+	})
+
+	t.Run("invalid stream", func(t *testing.T) {
+		store, err := New(bytes.NewReader([]byte(`[]`)), testlib.MakeLogger(t))
+		require.NoError(t, err)
+		require.Error(t, store.LoadApps(bytes.NewReader([]byte(`{`))))
+	})
+
+	t.Run("invalid app", func(t *testing.T) {
+		_, err := newStoreWithApps(t, []*model.App{{ID: "invalid", DisplayName: "Invalid"}})
+		require.EqualError(t, err, "invalid app invalid: manifest url  must use http or https")
+	})
+
+	t.Run("duplicate app", func(t *testing.T) {
+		_, err := newStoreWithApps(t, []*model.App{zendeskApp, zendeskApp})
+		require.EqualError(t, err, "duplicate app zendesk")
+	})
+
+	store, err := newStoreWithApps(t, []*model.App{zendeskApp, helloApp})
+	require.NoError(t, err)
+
+	t.Run("all apps, sorted by display name", func(t *testing.T) {
+		apps, err := store.GetApps(&model.AppFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.App{helloApp, zendeskApp}, apps)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		apps, err := store.GetApps(&model.AppFilter{Page: 1, PerPage: 1})
+		require.NoError(t, err)
+		require.Equal(t, []*model.App{zendeskApp}, apps)
+
+		apps, err = store.GetApps(&model.AppFilter{Page: 2, PerPage: 1})
+		require.NoError(t, err)
+		require.Nil(t, apps)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		apps, err := store.GetApps(&model.AppFilter{PerPage: model.AllPerPage, Filter: "tickets"})
+		require.NoError(t, err)
+		require.Equal(t, []*model.App{zendeskApp}, apps)
+	})
+
+	t.Run("hosting type", func(t *testing.T) {
+		apps, err := store.GetApps(&model.AppFilter{PerPage: model.AllPerPage, HostingType: model.AppHostingHTTP})
+		require.NoError(t, err)
+		require.Equal(t, []*model.App{helloApp}, apps)
+	})
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"testing"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/sirupsen/logrus"
+)
+
+// benchmarkSizes are the numbers of plugin versions in the databases benchmarked, bracketing the
+// catalog as it grows. The budgets each benchmark is expected to meet are documented in the README.
+var benchmarkSizes = []int{1000, 10000, 100000}
+
+// benchmarkVersions is the number of versions of each plugin in the databases benchmarked.
+const benchmarkVersions = 5
+
+// makeBenchmarkPlugins returns the given number of plugin versions, spread over plugins of
+// benchmarkVersions versions each with a variety of minimum server versions.
+func makeBenchmarkPlugins(count int) []*model.Plugin {
+	plugins := make([]*model.Plugin, 0, count)
+	for i := 0; i < count; i++ {
+		id := i / benchmarkVersions
+		version := i % benchmarkVersions
+		plugins = append(plugins, &model.Plugin{
+			HomepageURL:  fmt.Sprintf("https://example.com/plugin-%d", id),
+			DownloadURL:  fmt.Sprintf("https://example.com/plugin-%d-0.%d.0.tar.gz", id, version),
+			AuthorType:   model.AuthorTypeCommunity,
+			ReleaseStage: model.ReleaseStageProduction,
+			Manifest: &mattermostModel.Manifest{
+				Id:               fmt.Sprintf("com.example.plugin-%d", id),
+				Name:             fmt.Sprintf("Plugin %d", id),
+				Description:      fmt.Sprintf("Plugin number %d of the benchmark.", id),
+				Version:          fmt.Sprintf("0.%d.0", version),
+				MinServerVersion: fmt.Sprintf("5.%d.0", 10+version*5),
+			},
+		})
+	}
+
+	return plugins
+}
+
+func makeBenchmarkLogger() logrus.FieldLogger {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	return logger
+}
+
+func makeBenchmarkStore(b *testing.B, count int) *Store {
+	store, err := NewFromPlugins(makeBenchmarkPlugins(count), makeBenchmarkLogger(), Options{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return store
+}
+
+func BenchmarkNew(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		var database bytes.Buffer
+		if err := model.PluginsToWriter(&database, makeBenchmarkPlugins(size), model.PluginsWriterOptions{}); err != nil {
+			b.Fatal(err)
+		}
+		logger := makeBenchmarkLogger()
+
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(database.Len()))
+			for i := 0; i < b.N; i++ {
+				if _, err := New(bytes.NewReader(database.Bytes()), logger); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetPlugins(b *testing.B) {
+	filters := []struct {
+		name   string
+		filter model.PluginFilter
+	}{
+		{"first page", model.PluginFilter{PerPage: 20}},
+		{"all", model.PluginFilter{PerPage: model.AllPerPage}},
+		{"server version", model.PluginFilter{PerPage: 20, ServerVersion: "5.20.0"}},
+		{"incompatible", model.PluginFilter{PerPage: 20, ServerVersion: "5.20.0", IncludeIncompatible: true}},
+		{"search", model.PluginFilter{PerPage: 20, Filter: "number 12"}},
+		{"author type", model.PluginFilter{PerPage: 20, AuthorType: model.AuthorTypeCommunity}},
+	}
+
+	for _, size := range benchmarkSizes {
+		store := makeBenchmarkStore(b, size)
+		for _, f := range filters {
+			filter := f.filter
+			b.Run(fmt.Sprintf("%s/%d", f.name, size), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := store.GetPlugins(&filter); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkListPlugins measures computing a listing of plugins, as for the first request with
+// a given server version before the listing is cached.
+func BenchmarkListPlugins(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		store := makeBenchmarkStore(b, size)
+
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := store.listPlugins(store.plugins, "5.20.0", true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkResidentMemory measures the heap retained by a store whose plugins each have an icon
+// shared by their versions, as reported by the heap-bytes metric.
+func BenchmarkResidentMemory(b *testing.B) {
+	icon := "data:image/png;base64," + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x89}, 8*1024))
+	for _, size := range benchmarkSizes[:2] {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			var heap uint64
+			for i := 0; i < b.N; i++ {
+				before := heapInUse()
+				plugins := makeBenchmarkPlugins(size)
+				for _, plugin := range plugins {
+					// Copy each icon, as if decoded from a database.
+					plugin.IconData = string([]byte(icon))
+				}
+
+				store, err := NewFromPlugins(plugins, makeBenchmarkLogger(), Options{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				plugins = nil
+				heap += heapInUse() - before
+				runtime.KeepAlive(store)
+			}
+			b.ReportMetric(float64(heap)/float64(b.N), "heap-bytes")
+		})
+	}
+}
+
+// heapInUse returns the bytes of the heap in use once garbage is collected.
+func heapInUse() uint64 {
+	runtime.GC()
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func BenchmarkGetPluginVersions(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		store := makeBenchmarkStore(b, size)
+		id := fmt.Sprintf("COM.EXAMPLE.PLUGIN-%d", size/benchmarkVersions/2)
+
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				versions, err := store.GetPluginVersions(id)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(versions) != benchmarkVersions {
+					b.Fatalf("expected %d versions, found %d", benchmarkVersions, len(versions))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"sort"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Featuring describes the featuring of plugins set at runtime, such as by moderators, overriding
+// the featuring recorded in the database.
+type Featuring interface {
+	// GetFeaturing returns the featuring of the given plugin, or nil if it is not overridden.
+	GetFeaturing(pluginID string) *model.Featuring
+}
+
+// featuringOf returns whether the given plugin is featured, and its weight, honoring any
+// override.
+func (store *Store) featuringOf(plugin *model.Plugin) (bool, int) {
+	if store.featuring != nil {
+		if featuring := store.featuring.GetFeaturing(plugin.Manifest.Id); featuring != nil {
+			return featuring.Featured, featuring.Weight
+		}
+	}
+
+	return plugin.Featured, plugin.FeaturedWeight
+}
+
+// withFeaturedFirst returns the given plugins reordered to list the featured plugins first,
+// heaviest first, otherwise preserving their order. The given plugins are left untouched, as they
+// may be a cached listing, and featuring may change without the catalog changing.
+func (store *Store) withFeaturedFirst(plugins []*model.Plugin) []*model.Plugin {
+	weights := map[*model.Plugin]int{}
+	for _, plugin := range plugins {
+		if featured, weight := store.featuringOf(plugin); featured {
+			weights[plugin] = weight
+		}
+	}
+	if len(weights) == 0 {
+		return plugins
+	}
+
+	sorted := append([]*model.Plugin(nil), plugins...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		iWeight, iFeatured := weights[sorted[i]]
+		jWeight, jFeatured := weights[sorted[j]]
+		if iFeatured != jFeatured {
+			return iFeatured
+		}
+		return iWeight > jWeight
+	})
+
+	return sorted
+}
+
+// withFeaturing returns the given plugins, copying those whose featuring is overridden to record
+// the override.
+func (store *Store) withFeaturing(plugins []*model.Plugin) []*model.Plugin {
+	if store.featuring == nil || plugins == nil {
+		return plugins
+	}
+
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		featured, weight := store.featuringOf(plugin)
+		if featured == plugin.Featured && weight == plugin.FeaturedWeight {
+			result = append(result, plugin)
+			continue
+		}
+
+		annotatedPlugin := *plugin
+		annotatedPlugin.Featured = featured
+		annotatedPlugin.FeaturedWeight = weight
+		result = append(result, &annotatedPlugin)
+	}
+
+	return result
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+)
+
+// storedIcon is the icon of one or more plugin versions, held decoded once rather than as the
+// base64 data URI repeated in each version.
+type storedIcon struct {
+	mimeType string
+	data     []byte
+}
+
+// dataURI re-encodes the icon as the data URI it was decoded from.
+func (icon *storedIcon) dataURI() string {
+	return encodeIconData(icon.mimeType, icon.data)
+}
+
+func encodeIconData(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// internIcons decodes the icons of the given plugins, each already validated, holding each distinct
+// icon once. Plugins with an icon are replaced in the given slice by copies without their icon
+// data, to be restored by withIcons. Icons that would not re-encode to the same data URI, e.g. as
+// they wrap their base64 data, are left in place.
+//
+// Icons are mapped by manifest, which copies of a plugin share, so that the icon of a copy made by
+// the store, e.g. to annotate its compatibility, is restored too.
+func internIcons(plugins []*model.Plugin) map[*mattermostModel.Manifest]*storedIcon {
+	manifests := map[*mattermostModel.Manifest]int{}
+	for _, plugin := range plugins {
+		manifests[plugin.Manifest]++
+	}
+
+	icons := map[*mattermostModel.Manifest]*storedIcon{}
+	byDigest := map[[sha256.Size]byte]*storedIcon{}
+	for i, plugin := range plugins {
+		// A manifest shared by several of the given plugins cannot identify the icon of each.
+		if plugin.IconData == "" || manifests[plugin.Manifest] > 1 {
+			continue
+		}
+
+		digest := sha256.Sum256([]byte(plugin.IconData))
+		icon, ok := byDigest[digest]
+		if !ok {
+			mimeType, data, err := model.DecodeIconData(plugin.IconData)
+			if err == nil && encodeIconData(mimeType, data) == plugin.IconData {
+				icon = &storedIcon{mimeType: mimeType, data: data}
+			}
+			byDigest[digest] = icon
+		}
+		if icon == nil {
+			continue
+		}
+
+		stripped := *plugin
+		stripped.IconData = ""
+		plugins[i] = &stripped
+		icons[stripped.Manifest] = icon
+	}
+
+	return icons
+}
+
+// withIcon returns the given plugin with its icon data restored, copying it if the store holds
+// its icon.
+func (store *Store) withIcon(plugin *model.Plugin) *model.Plugin {
+	icon, ok := store.icons[plugin.Manifest]
+	if !ok {
+		return plugin
+	}
+
+	withIcon := *plugin
+	withIcon.IconData = icon.dataURI()
+	return &withIcon
+}
+
+// withIcons returns the given plugins with their icon data restored, as by withIcon.
+func (store *Store) withIcons(plugins []*model.Plugin) []*model.Plugin {
+	if plugins == nil {
+		return nil
+	}
+
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		result = append(result, store.withIcon(plugin))
+	}
+
+	return result
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIcons(t *testing.T) {
+	icon := "data:image/svg+xml;base64,PHN2Zy8+"
+	makePlugin := func(id, version, iconData string) *model.Plugin {
+		return &model.Plugin{
+			IconData:     iconData,
+			ReleaseStage: model.ReleaseStageProduction,
+			Manifest:     &mattermostModel.Manifest{Id: id, Name: id, Version: version},
+		}
+	}
+
+	t.Run("held once and restored", func(t *testing.T) {
+		demo1 := makePlugin("demo", "0.1.0", icon)
+		demo2 := makePlugin("demo", "0.2.0", icon)
+		starter := makePlugin("starter", "0.1.0", "")
+		store, err := NewFromPlugins([]*model.Plugin{demo1, demo2, starter}, testlib.MakeLogger(t), Options{})
+		require.NoError(t, err)
+
+		require.Len(t, store.icons, 2)
+		require.True(t, store.icons[demo1.Manifest] == store.icons[demo2.Manifest])
+		for _, plugin := range store.plugins {
+			require.Empty(t, plugin.IconData)
+		}
+
+		// The given plugins are left unchanged.
+		require.Equal(t, icon, demo1.IconData)
+
+		versions, err := store.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demo2, demo1}, versions)
+
+		plugins, err := store.GetPlugins(&model.PluginFilter{PerPage: 1})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demo2}, plugins)
+
+		require.Equal(t, []*model.Plugin{demo1, demo2, starter}, store.AllPlugins())
+	})
+
+	t.Run("restored for incompatible plugins", func(t *testing.T) {
+		demo := makePlugin("demo", "0.1.0", icon)
+		demo.Manifest.MinServerVersion = "5.20.0"
+		store, err := NewFromPlugins([]*model.Plugin{demo}, testlib.MakeLogger(t), Options{})
+		require.NoError(t, err)
+
+		plugins, err := store.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, ServerVersion: "5.14.0", IncludeIncompatible: true})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+		require.Equal(t, icon, plugins[0].IconData)
+		require.NotNil(t, plugins[0].Compatibility)
+	})
+
+	t.Run("kept when not re-encoded identically", func(t *testing.T) {
+		wrapped := "data:image/svg+xml;base64,PHN2\nZy8+"
+		demo := makePlugin("demo", "0.1.0", wrapped)
+		store, err := NewFromPlugins([]*model.Plugin{demo}, testlib.MakeLogger(t), Options{})
+		require.NoError(t, err)
+
+		require.Empty(t, store.icons)
+		require.Equal(t, wrapped, store.AllPlugins()[0].IconData)
+	})
+
+	t.Run("kept for plugins sharing a manifest", func(t *testing.T) {
+		demo1 := makePlugin("demo", "0.1.0", icon)
+		demo2 := &model.Plugin{ReleaseStage: model.ReleaseStageProduction, Manifest: demo1.Manifest}
+		store, err := NewFromPlugins([]*model.Plugin{demo1, demo2}, testlib.MakeLogger(t), Options{})
+		require.NoError(t, err)
+
+		require.Empty(t, store.icons)
+		require.Equal(t, []*model.Plugin{demo1, demo2}, store.AllPlugins())
+	})
+}
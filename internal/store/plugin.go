@@ -3,6 +3,7 @@ package store
 import (
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/pkg/errors"
@@ -10,50 +11,190 @@ import (
 	"github.com/mattermost/mattermost-marketplace/internal/model"
 )
 
-func pluginMatchesFilter(plugin *model.Plugin, filter string) bool {
-	filter = strings.ToLower(filter)
-	if strings.ToLower(plugin.Manifest.Id) == filter {
-		return true
+// Relevance of a plugin to a filter, from most to least relevant.
+const (
+	relevanceExactID          = 4
+	relevanceNamePrefix       = 3
+	relevanceNameMatch        = 2
+	relevanceDescriptionMatch = 1
+	relevanceNone             = 0
+)
+
+// maxCachedListings bounds the number of listings cached by getPlugins, each listing the plugins
+// compatible with a server version. The cache is emptied once full, so that requests for arbitrary
+// server versions cannot grow it without bound.
+const maxCachedListings = 64
+
+// listingKey identifies a listing cached by getPlugins.
+type listingKey struct {
+	serverVersion       string
+	includeIncompatible bool
+}
+
+// pluginText holds the lowercased text of a plugin matched by filters.
+type pluginText struct {
+	name        string
+	description string
+}
+
+// pluginIndex indexes the plugins of a store, computed once as the store is constructed so that
+// queries need not scan every version of every plugin.
+type pluginIndex struct {
+	// versions maps each manifest id to its versions, sorted by version descending.
+	versions map[string][]*model.Plugin
+	// ids maps each lowercased manifest id to the first manifest id in the database matching it.
+	ids map[string]string
+	// text holds the lowercased text of each plugin.
+	text map[*model.Plugin]pluginText
+}
+
+// newPluginIndex indexes the given plugins, each already validated.
+func newPluginIndex(plugins []*model.Plugin) pluginIndex {
+	index := pluginIndex{
+		versions: map[string][]*model.Plugin{},
+		ids:      map[string]string{},
+		text:     make(map[*model.Plugin]pluginText, len(plugins)),
 	}
 
-	if strings.Contains(strings.ToLower(plugin.Manifest.Name), filter) {
-		return true
+	parsedVersions := make(map[*model.Plugin]semver.Version, len(plugins))
+	for _, plugin := range plugins {
+		id := plugin.Manifest.Id
+		index.versions[id] = append(index.versions[id], plugin)
+		if _, ok := index.ids[strings.ToLower(id)]; !ok {
+			index.ids[strings.ToLower(id)] = id
+		}
+		index.text[plugin] = pluginText{
+			name:        strings.ToLower(plugin.Manifest.Name),
+			description: strings.ToLower(plugin.Manifest.Description),
+		}
+		parsedVersions[plugin] = semver.MustParse(plugin.Manifest.Version)
 	}
 
-	if strings.Contains(strings.ToLower(plugin.Manifest.Description), filter) {
-		return true
+	for _, versions := range index.versions {
+		sort.SliceStable(versions, func(i, j int) bool {
+			return parsedVersions[versions[i]].GT(parsedVersions[versions[j]])
+		})
 	}
 
-	return false
+	return index
 }
 
-// GetPlugins fetches the given page of plugins. The first page is 0.
+// pluginFilterRelevance ranks how closely the plugin matches the given lowercased filter,
+// returning relevanceNone if it does not match at all.
+func pluginFilterRelevance(plugin *model.Plugin, text pluginText, filter string) int {
+	if plugin.HasID(filter) {
+		return relevanceExactID
+	}
+
+	if strings.HasPrefix(text.name, filter) {
+		return relevanceNamePrefix
+	}
+	if strings.Contains(text.name, filter) {
+		return relevanceNameMatch
+	}
+
+	if strings.Contains(text.description, filter) {
+		return relevanceDescriptionMatch
+	}
+
+	return relevanceNone
+}
+
+// GetPlugins fetches the given page of plugins. The first page is 0. Unless filtered by text, the
+// featured plugins are listed first.
 func (store *Store) GetPlugins(pluginFilter *model.PluginFilter) ([]*model.Plugin, error) {
 	if pluginFilter.PerPage == 0 {
 		return nil, nil
 	}
 
-	plugins, err := store.getPlugins(pluginFilter.ServerVersion)
+	plugins, err := store.getPlugins(pluginFilter.ServerVersion, pluginFilter.IncludeIncompatible)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get plugins")
 	}
 
-	filter := strings.TrimSpace(pluginFilter.Filter)
+	filter := strings.ToLower(strings.TrimSpace(pluginFilter.Filter))
 	if filter != "" {
+		var filteredPlugins []*model.Plugin
+		relevance := map[*model.Plugin]int{}
+		for _, plugin := range plugins {
+			if r := pluginFilterRelevance(plugin, store.pluginText(plugin), filter); r != relevanceNone {
+				filteredPlugins = append(filteredPlugins, plugin)
+				relevance[plugin] = r
+			}
+		}
+
+		// Order the most relevant matches first, otherwise preserving the order by name.
+		sort.SliceStable(filteredPlugins, func(i, j int) bool {
+			return relevance[filteredPlugins[i]] > relevance[filteredPlugins[j]]
+		})
+		plugins = filteredPlugins
+	} else {
+		plugins = store.withFeaturedFirst(plugins)
+	}
+
+	if pluginFilter.AuthorType != "" {
 		var filteredPlugins []*model.Plugin
 		for _, plugin := range plugins {
-			if pluginMatchesFilter(plugin, filter) {
+			if plugin.AuthorType == pluginFilter.AuthorType {
 				filteredPlugins = append(filteredPlugins, plugin)
 			}
 		}
 		plugins = filteredPlugins
 	}
 
+	if pluginFilter.Hosting != "" {
+		var filteredPlugins []*model.Plugin
+		for _, plugin := range plugins {
+			if plugin.HostingRequirement.Supports(pluginFilter.Hosting) {
+				filteredPlugins = append(filteredPlugins, plugin)
+			}
+		}
+		plugins = filteredPlugins
+	}
+
+	if pluginFilter.LicenseTier != "" {
+		var filteredPlugins []*model.Plugin
+		for _, plugin := range plugins {
+			if pluginFilter.LicenseTier.Satisfies(plugin.RequiredLicense) {
+				filteredPlugins = append(filteredPlugins, plugin)
+			}
+		}
+		plugins = filteredPlugins
+	}
+
+	if pluginFilter.Platform != "" || len(pluginFilter.Labels) > 0 {
+		compatibilityFilter := model.CompatibilityFilter{
+			Platform: pluginFilter.Platform,
+			Labels:   pluginFilter.Labels,
+		}
+		var filteredPlugins []*model.Plugin
+		for _, plugin := range plugins {
+			// Only the server version can fail to parse, and it is not constrained here.
+			if compatible, _ := plugin.IsCompatible(compatibilityFilter); compatible {
+				filteredPlugins = append(filteredPlugins, plugin)
+			}
+		}
+		plugins = filteredPlugins
+	}
+
+	switch pluginFilter.Sort {
+	case model.PluginSortName:
+		plugins = append([]*model.Plugin(nil), plugins...)
+		sort.SliceStable(plugins, func(i, j int) bool {
+			return strings.ToLower(plugins[i].Manifest.Name) < strings.ToLower(plugins[j].Manifest.Name)
+		})
+	case model.PluginSortReleased:
+		plugins = append([]*model.Plugin(nil), plugins...)
+		sort.SliceStable(plugins, func(i, j int) bool {
+			return plugins[i].ReleasedAt.After(plugins[j].ReleasedAt)
+		})
+	}
+
 	if len(plugins) == 0 {
 		return nil, nil
 	}
 	if pluginFilter.PerPage == model.AllPerPage {
-		return plugins, nil
+		return store.withIcons(store.withSettings(store.withMaintenance(store.withFeaturing(plugins)))), nil
 	}
 
 	start := (pluginFilter.Page) * pluginFilter.PerPage
@@ -65,53 +206,273 @@ func (store *Store) GetPlugins(pluginFilter *model.PluginFilter) ([]*model.Plugi
 		end = len(plugins)
 	}
 
-	return plugins[start:end], nil
+	return store.withIcons(store.withSettings(store.withMaintenance(store.withFeaturing(plugins[start:end])))), nil
+}
+
+// resolvePluginID returns the manifest id of the plugin identified by the given id, resolving
+// the old ids of renamed plugins before matching manifest ids case-insensitively.
+func (store *Store) resolvePluginID(id string) string {
+	if canonicalID, ok := store.aliases[strings.ToLower(id)]; ok {
+		return canonicalID
+	}
+
+	if _, ok := store.index.versions[id]; ok {
+		return id
+	}
+	if caseInsensitiveID, ok := store.index.ids[strings.ToLower(id)]; ok {
+		return caseInsensitiveID
+	}
+
+	return id
 }
 
-// getPlugins returns all plugins compatible with the given server version, sorted by name ascending.
-func (store *Store) getPlugins(serverVersion string) ([]*model.Plugin, error) {
+// GetPluginVersions fetches every version of the plugin with the given id, sorted by version
+// descending. The id may differ in case from the manifest id, or be an old id of a renamed plugin.
+func (store *Store) GetPluginVersions(id string) ([]*model.Plugin, error) {
+	id = store.resolvePluginID(id)
+
+	versions := store.unexpired(store.index.versions[id], store.now())
+
+	return store.withIcons(store.withSettings(store.withMaintenance(store.withFeaturing(store.withSuccessors(versions))))), nil
+}
+
+// expiredCount returns the number of versions of the store lapsed by the given time.
+func (store *Store) expiredCount(now time.Time) int {
+	return sort.Search(len(store.expiries), func(i int) bool {
+		return now.Before(store.expiries[i])
+	})
+}
+
+// unexpired returns the given plugins less the versions lapsed by the given time, or nil if every
+// version lapsed. Versions lapse as of each request, without the catalog changing.
+func (store *Store) unexpired(plugins []*model.Plugin, now time.Time) []*model.Plugin {
+	if store.expiredCount(now) == 0 {
+		return plugins
+	}
+
 	var result []*model.Plugin
-	plugins := map[string]*model.Plugin{}
+	for _, plugin := range plugins {
+		if !plugin.IsExpired(store.preReleaseTTL, now) {
+			result = append(result, plugin)
+		}
+	}
 
-	for _, storePlugin := range store.plugins {
-		if serverVersion != "" && storePlugin.Manifest.MinServerVersion != "" {
-			meetsMinServerVersion, err := storePlugin.Manifest.MeetMinServerVersion(serverVersion)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to check minServerVersion for manifest.Id %s", storePlugin.Manifest.Id)
-			}
+	return result
+}
 
-			if !meetsMinServerVersion {
-				continue
-			}
+// Epoch counts the changes to the responses of the store due to the passage of time so far, such
+// as plugins growing stale and versions lapsing, for responses cached from the store to be keyed
+// by.
+func (store *Store) Epoch() int {
+	now := store.now()
+
+	return store.staleCount(now) + store.expiredCount(now)
+}
+
+// staleCount returns the number of plugins of the store grown stale by the given time.
+func (store *Store) staleCount(now time.Time) int {
+	return sort.Search(len(store.staleAts), func(i int) bool {
+		return !now.After(store.staleAts[i])
+	})
+}
+
+// withMaintenance returns the given plugins, copying those found unmaintained to flag them with
+// the reason and the unmaintained label. Unmaintained plugins are judged as of each request, as
+// they grow stale without the catalog changing.
+func (store *Store) withMaintenance(plugins []*model.Plugin) []*model.Plugin {
+	if plugins == nil {
+		return plugins
+	}
+
+	now := store.now()
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		reason := store.maintenance[plugin.Manifest.Id].Unmaintained(store.staleAfter, now)
+		if reason == "" {
+			result = append(result, plugin)
+			continue
 		}
 
-		if plugins[storePlugin.Manifest.Id] == nil {
-			plugins[storePlugin.Manifest.Id] = storePlugin
+		annotatedPlugin := *plugin
+		annotatedPlugin.Unmaintained = reason
+		annotatedPlugin.Labels = append(append([]model.Label{}, plugin.Labels...), model.UnmaintainedLabel)
+		result = append(result, &annotatedPlugin)
+	}
+
+	return result
+}
+
+// withSettings returns the given plugins, copying those whose manifest has a settings schema to
+// summarize their settings.
+func (store *Store) withSettings(plugins []*model.Plugin) []*model.Plugin {
+	if plugins == nil {
+		return plugins
+	}
+
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		settings := model.SummarizeSettings(plugin.Manifest)
+		if settings == nil && plugin.Settings == nil {
+			result = append(result, plugin)
 			continue
 		}
 
-		lastSeenPluginVersion, err := semver.Parse(plugins[storePlugin.Manifest.Id].Manifest.Version)
-		if err != nil {
-			return nil, errors.Errorf("failed to parse manifest.Version for manifest.Id %s", storePlugin.Manifest.Id)
+		annotatedPlugin := *plugin
+		annotatedPlugin.Settings = settings
+		result = append(result, &annotatedPlugin)
+	}
+
+	return result
+}
+
+// withSuccessors returns the given plugins annotated with the plugin superseding them, copying
+// those superseded by a plugin of the store without recording their successor.
+func (store *Store) withSuccessors(plugins []*model.Plugin) []*model.Plugin {
+	if len(store.successors) == 0 || plugins == nil {
+		return plugins
+	}
+
+	result := make([]*model.Plugin, 0, len(plugins))
+	for _, plugin := range plugins {
+		successor, ok := store.successors[strings.ToLower(plugin.Manifest.Id)]
+		if !ok || plugin.SupersededBy != "" {
+			result = append(result, plugin)
+			continue
 		}
 
-		storePluginVersion := semver.MustParse(storePlugin.Manifest.Version)
-		if storePluginVersion.GT(lastSeenPluginVersion) {
-			plugins[storePlugin.Manifest.Id] = storePlugin
+		annotatedPlugin := *plugin
+		annotatedPlugin.SupersededBy = successor
+		result = append(result, &annotatedPlugin)
+	}
+
+	return result
+}
+
+// pluginText returns the lowercased text of the given plugin, which may be a copy of a plugin of
+// the store.
+func (store *Store) pluginText(plugin *model.Plugin) pluginText {
+	if text, ok := store.index.text[plugin]; ok {
+		return text
+	}
+
+	return pluginText{
+		name:        strings.ToLower(plugin.Manifest.Name),
+		description: strings.ToLower(plugin.Manifest.Description),
+	}
+}
+
+// getPlugins returns the latest version of all plugins compatible with the given server version,
+// sorted by name ascending. If includeIncompatible is set, plugins with no compatible version are
+// also returned, at their latest version annotated with why they are incompatible.
+//
+// Listings are cached, the plugins of a store never changing, until another version lapses. The
+// returned slice must not be modified.
+func (store *Store) getPlugins(serverVersion string, includeIncompatible bool) ([]*model.Plugin, error) {
+	key := listingKey{serverVersion: serverVersion, includeIncompatible: includeIncompatible}
+	now := store.now()
+	expired := store.expiredCount(now)
+
+	store.listingsLock.Lock()
+	if expired != store.listingsExpired {
+		store.listings = map[listingKey][]*model.Plugin{}
+		store.listingsExpired = expired
+	}
+	listing, ok := store.listings[key]
+	store.listingsLock.Unlock()
+	if ok {
+		return listing, nil
+	}
+
+	listing, err := store.listPlugins(store.unexpired(store.plugins, now), serverVersion, includeIncompatible)
+	if err != nil {
+		return nil, err
+	}
+	// Limit the capacity of the listing, so that appending to it cannot modify the cache.
+	listing = listing[:len(listing):len(listing)]
+
+	store.listingsLock.Lock()
+	if len(store.listings) >= maxCachedListings {
+		store.listings = map[listingKey][]*model.Plugin{}
+	}
+	if expired == store.listingsExpired {
+		store.listings[key] = listing
+	}
+	store.listingsLock.Unlock()
+
+	return listing, nil
+}
+
+// listPlugins computes the listing of the given plugins returned by getPlugins.
+func (store *Store) listPlugins(served []*model.Plugin, serverVersion string, includeIncompatible bool) ([]*model.Plugin, error) {
+	plugins, err := model.FilterPlugins(served, model.CompatibilityFilter{
+		ServerVersion: serverVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if includeIncompatible && serverVersion != "" {
+		incompatible, err := store.incompatiblePlugins(served, serverVersion, plugins)
+		if err != nil {
+			return nil, err
 		}
+		plugins = append(plugins, incompatible...)
 	}
 
+	// Hide the versions published under a renamed plugin's old id, listing the plugin once by its
+	// current id.
+	var current []*model.Plugin
 	for _, plugin := range plugins {
-		result = append(result, plugin)
+		if _, renamed := store.aliases[strings.ToLower(plugin.Manifest.Id)]; !renamed {
+			current = append(current, plugin)
+		}
 	}
 
+	result := store.withSuccessors(model.LatestVersions(current))
+
 	// Sort the final slice by plugin name, ascending
 	sort.SliceStable(
 		result,
 		func(i, j int) bool {
-			return strings.ToLower(result[i].Manifest.Name) < strings.ToLower(result[j].Manifest.Name)
+			return store.pluginText(result[i]).name < store.pluginText(result[j]).name
 		},
 	)
 
 	return result, nil
 }
+
+// incompatiblePlugins returns copies of the latest version of each of the given served plugins
+// absent from the given compatible plugins, annotated with why it is incompatible with the given
+// server version.
+func (store *Store) incompatiblePlugins(served []*model.Plugin, serverVersion string, compatible []*model.Plugin) ([]*model.Plugin, error) {
+	compatibleIDs := map[string]bool{}
+	for _, plugin := range compatible {
+		compatibleIDs[plugin.Manifest.Id] = true
+	}
+
+	var candidates []*model.Plugin
+	for _, plugin := range served {
+		if !compatibleIDs[plugin.Manifest.Id] {
+			candidates = append(candidates, plugin)
+		}
+	}
+
+	var result []*model.Plugin
+	for _, plugin := range model.LatestVersions(candidates) {
+		compatibility, err := plugin.ServerVersionIncompatibility(serverVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		annotatedPlugin := *plugin
+		annotatedPlugin.Compatibility = compatibility
+		result = append(result, &annotatedPlugin)
+	}
+
+	return result, nil
+}
+
+// AllPlugins returns every unexpired version of every plugin in the store, in database order.
+func (store *Store) AllPlugins() []*model.Plugin {
+	return store.withIcons(store.unexpired(store.plugins, store.now()))
+}
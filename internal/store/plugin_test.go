@@ -3,7 +3,9 @@ package store
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/mattermost/mattermost-marketplace/internal/model"
 	"github.com/mattermost/mattermost-marketplace/internal/testlib"
@@ -14,7 +16,7 @@ import (
 func TestPlugins(t *testing.T) {
 	demoPluginV1Min514 := &model.Plugin{
 		HomepageURL: "https://github.com/mattermost/mattermost-plugin-demo",
-		IconData:    "icon-data.svg",
+		IconData:    "data:image/svg+xml;base64,PHN2Zy8+",
 		DownloadURL: "https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz",
 		Manifest: &mattermostModel.Manifest{
 			Id:               "com.mattermost.demo-plugin",
@@ -23,12 +25,13 @@ func TestPlugins(t *testing.T) {
 			Version:          "0.1.0",
 			MinServerVersion: "5.14.0",
 		},
-		Signature: "signature1",
+		ReleaseStage: model.ReleaseStageProduction,
+		Signature:    "signature1",
 	}
 
 	demoPluginV2Min515 := &model.Plugin{
 		HomepageURL: "https://github.com/mattermost/mattermost-plugin-demo",
-		IconData:    "icon-data.svg",
+		IconData:    "data:image/svg+xml;base64,PHN2Zy8+",
 		DownloadURL: "https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.2.0/com.mattermost.demo-plugin-0.2.0.tar.gz",
 		Manifest: &mattermostModel.Manifest{
 			Id:               "com.mattermost.demo-plugin",
@@ -37,12 +40,15 @@ func TestPlugins(t *testing.T) {
 			Version:          "0.2.0",
 			MinServerVersion: "5.15.0",
 		},
-		Signature: "signature1",
+		ReleaseStage:       model.ReleaseStageProduction,
+		Signature:          "signature1",
+		AuthorType:         model.AuthorTypeMattermost,
+		HostingRequirement: model.HostingBoth,
 	}
 
 	starterPluginV1Min515 := &model.Plugin{
 		HomepageURL: "https://github.com/mattermost/mattermost-plugin-starter-template",
-		IconData:    "icon-data2.svg",
+		IconData:    "data:image/png;base64,iVBORw0KGgo=",
 		DownloadURL: "https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz",
 		Manifest: &mattermostModel.Manifest{
 			Id:               "com.mattermost.plugin-starter-template",
@@ -51,7 +57,10 @@ func TestPlugins(t *testing.T) {
 			Version:          "0.1.0",
 			MinServerVersion: "5.15.0",
 		},
-		Signature: "signature2",
+		ReleaseStage:       model.ReleaseStageProduction,
+		Signature:          "signature2",
+		AuthorType:         model.AuthorTypeCommunity,
+		HostingRequirement: model.HostingOnPrem,
 	}
 
 	data, err := json.Marshal([]*model.Plugin{
@@ -187,6 +196,14 @@ func TestPlugins(t *testing.T) {
 		require.Equal(t, []*model.Plugin{demoPluginV2Min515, starterPluginV1Min515}, actualPlugins)
 	})
 
+	t.Run("relevance order", func(t *testing.T) {
+		actualPlugins, err := sqlStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage,
+			Filter: "plugin",
+		})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{starterPluginV1Min515, demoPluginV2Min515}, actualPlugins)
+	})
+
 	t.Run("plugins that satisfy 5.15", func(t *testing.T) {
 		actualPlugins, err := sqlStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage,
 			Filter:        "MATTERMOST",
@@ -212,4 +229,486 @@ func TestPlugins(t *testing.T) {
 		require.NoError(t, err)
 		require.Nil(t, actualPlugins)
 	})
+
+	t.Run("author type", func(t *testing.T) {
+		actualPlugins, err := sqlStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage,
+			AuthorType: model.AuthorTypeCommunity,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{starterPluginV1Min515}, actualPlugins)
+	})
+
+	t.Run("author type with no matches", func(t *testing.T) {
+		actualPlugins, err := sqlStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage,
+			AuthorType: model.AuthorTypePartner,
+		})
+		require.NoError(t, err)
+		require.Nil(t, actualPlugins)
+	})
+
+	t.Run("author type and filter", func(t *testing.T) {
+		actualPlugins, err := sqlStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage,
+			Filter:     "demo",
+			AuthorType: model.AuthorTypeMattermost,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV2Min515}, actualPlugins)
+	})
+
+	t.Run("hosting", func(t *testing.T) {
+		actualPlugins, err := sqlStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage,
+			Hosting: model.HostingOnPrem,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV2Min515, starterPluginV1Min515}, actualPlugins)
+
+		actualPlugins, err = sqlStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage,
+			Hosting: model.HostingCloud,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV2Min515}, actualPlugins)
+	})
+
+	t.Run("server version range", func(t *testing.T) {
+		demoPluginV1 := &model.Plugin{
+			DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+		}
+		demoPluginV2 := &model.Plugin{
+			DownloadURL:        "https://example.com/demo-0.2.0.tar.gz",
+			ServerVersionRange: ">=5.20 <6.0",
+			Manifest:           &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0"},
+			ReleaseStage:       model.ReleaseStageProduction,
+		}
+
+		data, err := json.Marshal([]*model.Plugin{demoPluginV1, demoPluginV2})
+		require.NoError(t, err)
+		rangeStore, err := New(bytes.NewReader(data), testlib.MakeLogger(t))
+		require.NoError(t, err)
+
+		actualPlugins, err := rangeStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, ServerVersion: "5.25.0"})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV2}, actualPlugins)
+
+		actualPlugins, err = rangeStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, ServerVersion: "6.0.0"})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV1}, actualPlugins)
+
+		actualPlugins, err = rangeStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV2}, actualPlugins)
+	})
+
+	t.Run("license tier", func(t *testing.T) {
+		freePlugin := &model.Plugin{
+			DownloadURL:  "https://example.com/free-0.1.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "free", Name: "Free", Version: "0.1.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+		}
+		enterprisePlugin := &model.Plugin{
+			DownloadURL:     "https://example.com/enterprise-0.1.0.tar.gz",
+			Manifest:        &mattermostModel.Manifest{Id: "enterprise", Name: "Enterprise", Version: "0.1.0"},
+			ReleaseStage:    model.ReleaseStageProduction,
+			RequiredLicense: model.LicenseTierEnterprise,
+		}
+
+		data, err := json.Marshal([]*model.Plugin{freePlugin, enterprisePlugin})
+		require.NoError(t, err)
+		tierStore, err := New(bytes.NewReader(data), testlib.MakeLogger(t))
+		require.NoError(t, err)
+
+		actualPlugins, err := tierStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, LicenseTier: model.LicenseTierTeam})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{freePlugin}, actualPlugins)
+
+		actualPlugins, err = tierStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, LicenseTier: model.LicenseTierEnterprise})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{enterprisePlugin, freePlugin}, actualPlugins)
+
+		actualPlugins, err = tierStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{enterprisePlugin, freePlugin}, actualPlugins)
+	})
+
+	t.Run("invalid license tier", func(t *testing.T) {
+		data, err := json.Marshal([]*model.Plugin{{
+			DownloadURL:     "https://example.com/demo-0.1.0.tar.gz",
+			Manifest:        &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+			ReleaseStage:    model.ReleaseStageProduction,
+			RequiredLicense: "platinum",
+		}})
+		require.NoError(t, err)
+		_, err = New(bytes.NewReader(data), testlib.MakeLogger(t))
+		require.Error(t, err)
+	})
+
+	t.Run("superseded plugins", func(t *testing.T) {
+		legacyPlugin := &model.Plugin{
+			DownloadURL:  "https://example.com/legacy-0.1.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "legacy", Name: "Legacy", Version: "0.1.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+		}
+		mergedPlugin := &model.Plugin{
+			DownloadURL:  "https://example.com/merged-0.1.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "merged", Name: "Merged", Version: "0.1.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			SupersededBy: "mattermost-server",
+		}
+		successorPlugin := &model.Plugin{
+			DownloadURL:   "https://example.com/successor-1.0.0.tar.gz",
+			Manifest:      &mattermostModel.Manifest{Id: "successor", Name: "Successor", Version: "1.0.0"},
+			ReleaseStage:  model.ReleaseStageProduction,
+			Supersedes:    []string{"Legacy"},
+			ConflictsWith: []string{"legacy"},
+		}
+
+		data, err := json.Marshal([]*model.Plugin{legacyPlugin, mergedPlugin, successorPlugin})
+		require.NoError(t, err)
+		successorStore, err := New(bytes.NewReader(data), testlib.MakeLogger(t))
+		require.NoError(t, err)
+
+		annotatedLegacyPlugin := *legacyPlugin
+		annotatedLegacyPlugin.SupersededBy = "successor"
+
+		actualPlugins, err := successorStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{&annotatedLegacyPlugin, mergedPlugin, successorPlugin}, actualPlugins)
+
+		actualPlugins, err = successorStore.GetPluginVersions("legacy")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{&annotatedLegacyPlugin}, actualPlugins)
+
+		// The successor is not recorded in the database.
+		require.Equal(t, "", successorStore.AllPlugins()[0].SupersededBy)
+	})
+
+	t.Run("unmaintained plugins", func(t *testing.T) {
+		now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+		activePlugin := &model.Plugin{
+			DownloadURL:  "https://example.com/active-1.0.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "active", Name: "Active", Version: "1.0.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			ReleasedAt:   now.AddDate(0, -1, 0),
+		}
+		archivedPlugin := &model.Plugin{
+			DownloadURL:        "https://example.com/archived-1.0.0.tar.gz",
+			Manifest:           &mattermostModel.Manifest{Id: "archived", Name: "Archived", Version: "1.0.0"},
+			ReleaseStage:       model.ReleaseStageProduction,
+			ReleasedAt:         now.AddDate(0, -1, 0),
+			RepositoryArchived: true,
+		}
+		stalePluginV1 := &model.Plugin{
+			DownloadURL:  "https://example.com/stale-1.0.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "stale", Name: "Stale", Version: "1.0.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			ReleasedAt:   now.AddDate(-3, 0, 0),
+		}
+		stalePluginV2 := &model.Plugin{
+			DownloadURL:  "https://example.com/stale-2.0.0.tar.gz",
+			Labels:       []model.Label{{Name: "Beta"}},
+			Manifest:     &mattermostModel.Manifest{Id: "stale", Name: "Stale", Version: "2.0.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			ReleasedAt:   now.AddDate(-2, 0, 0),
+		}
+
+		data, err := json.Marshal([]*model.Plugin{activePlugin, archivedPlugin, stalePluginV1, stalePluginV2})
+		require.NoError(t, err)
+
+		flagged := func(plugin *model.Plugin, reason model.UnmaintainedReason) *model.Plugin {
+			annotatedPlugin := *plugin
+			annotatedPlugin.Unmaintained = reason
+			annotatedPlugin.Labels = append(append([]model.Label{}, plugin.Labels...), model.UnmaintainedLabel)
+			return &annotatedPlugin
+		}
+
+		t.Run("without a stale age", func(t *testing.T) {
+			maintenanceStore, err := New(bytes.NewReader(data), testlib.MakeLogger(t))
+			require.NoError(t, err)
+			maintenanceStore.now = func() time.Time { return now }
+
+			actualPlugins, err := maintenanceStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{activePlugin, flagged(archivedPlugin, model.UnmaintainedRepositoryArchived), stalePluginV2}, actualPlugins)
+		})
+
+		maintenanceStore, err := NewWithOptions(bytes.NewReader(data), testlib.MakeLogger(t), Options{StaleAfter: 365 * 24 * time.Hour})
+		require.NoError(t, err)
+		maintenanceStore.now = func() time.Time { return now }
+
+		actualPlugins, err := maintenanceStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{
+			activePlugin,
+			flagged(archivedPlugin, model.UnmaintainedRepositoryArchived),
+			flagged(stalePluginV2, model.UnmaintainedStale),
+		}, actualPlugins)
+
+		actualPlugins, err = maintenanceStore.GetPluginVersions("stale")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{
+			flagged(stalePluginV2, model.UnmaintainedStale),
+			flagged(stalePluginV1, model.UnmaintainedStale),
+		}, actualPlugins)
+
+		require.Equal(t, 1, maintenanceStore.Epoch())
+
+		// Plugins are judged as of each request.
+		maintenanceStore.now = func() time.Time { return now.AddDate(-1, -1, 0) }
+		actualPlugins, err = maintenanceStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, stalePluginV2, actualPlugins[2])
+		require.Equal(t, 0, maintenanceStore.Epoch())
+
+		// The flag is not recorded in the database.
+		require.Equal(t, []model.Label{{Name: "Beta"}}, maintenanceStore.AllPlugins()[3].Labels)
+		require.Empty(t, maintenanceStore.AllPlugins()[1].Unmaintained)
+	})
+
+	t.Run("expired pre-releases", func(t *testing.T) {
+		now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		expiresAt := now.AddDate(0, 0, 7)
+
+		stableV1 := &model.Plugin{
+			DownloadURL:  "https://example.com/demo-1.0.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "1.0.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			ReleasedAt:   now.AddDate(-1, 0, 0),
+		}
+		betaV2 := &model.Plugin{
+			DownloadURL:  "https://example.com/demo-2.0.0-rc1.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "2.0.0-rc1"},
+			ReleaseStage: model.ReleaseStageBeta,
+			ReleasedAt:   now.AddDate(0, -1, 0),
+			ExpiresAt:    &expiresAt,
+		}
+		promoted := &model.Plugin{
+			DownloadURL:  "https://example.com/promoted-1.0.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "promoted", Name: "Promoted", Version: "1.0.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+			ReleasedAt:   now.AddDate(-1, 0, 0),
+		}
+		experimental := &model.Plugin{
+			DownloadURL:  "https://example.com/experimental-0.1.0.tar.gz",
+			Manifest:     &mattermostModel.Manifest{Id: "experimental", Name: "Experimental", Version: "0.1.0"},
+			ReleaseStage: model.ReleaseStageExperimental,
+			ReleasedAt:   now.AddDate(0, -2, 0),
+		}
+
+		data, err := json.Marshal([]*model.Plugin{stableV1, betaV2, promoted, experimental})
+		require.NoError(t, err)
+
+		t.Run("without a lifetime", func(t *testing.T) {
+			expiringStore, err := New(bytes.NewReader(data), testlib.MakeLogger(t))
+			require.NoError(t, err)
+			expiringStore.now = func() time.Time { return now }
+
+			actualPlugins, err := expiringStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{betaV2, experimental, promoted}, actualPlugins)
+
+			// Only the recorded expiry applies.
+			expiringStore.now = func() time.Time { return expiresAt }
+			actualPlugins, err = expiringStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{stableV1, experimental, promoted}, actualPlugins)
+		})
+
+		expiringStore, err := NewWithOptions(bytes.NewReader(data), testlib.MakeLogger(t), Options{PreReleaseTTL: 90 * 24 * time.Hour})
+		require.NoError(t, err)
+		expiringStore.now = func() time.Time { return now }
+
+		actualPlugins, err := expiringStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{betaV2, experimental, promoted}, actualPlugins)
+
+		require.Equal(t, 0, expiringStore.Epoch())
+
+		// Listings cached before a version lapses are not served after it.
+		expiringStore.now = func() time.Time { return expiresAt }
+		require.Equal(t, 1, expiringStore.Epoch())
+		actualPlugins, err = expiringStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{stableV1, experimental, promoted}, actualPlugins)
+
+		actualPlugins, err = expiringStore.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{stableV1}, actualPlugins)
+		require.Equal(t, []*model.Plugin{stableV1, promoted, experimental}, expiringStore.AllPlugins())
+
+		// Pre-releases recording no expiry lapse after the configured lifetime.
+		expiringStore.now = func() time.Time { return now.AddDate(0, 1, 0) }
+		actualPlugins, err = expiringStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{stableV1, promoted}, actualPlugins)
+
+		actualPlugins, err = expiringStore.GetPluginVersions("experimental")
+		require.NoError(t, err)
+		require.Nil(t, actualPlugins)
+	})
+
+	t.Run("featured plugins", func(t *testing.T) {
+		makePlugin := func(id, name string) *model.Plugin {
+			return &model.Plugin{
+				DownloadURL:  "https://example.com/" + id + "-1.0.0.tar.gz",
+				Manifest:     &mattermostModel.Manifest{Id: id, Name: name, Version: "1.0.0"},
+				ReleaseStage: model.ReleaseStageProduction,
+			}
+		}
+		alpha := makePlugin("alpha", "Alpha")
+		beta := makePlugin("beta", "Beta")
+		beta.Featured = true
+		gamma := makePlugin("gamma", "Gamma")
+		gamma.Featured = true
+		gamma.FeaturedWeight = 10
+
+		data, err := json.Marshal([]*model.Plugin{alpha, beta, gamma})
+		require.NoError(t, err)
+
+		featuring := fakeFeaturing{}
+		featuredStore, err := NewWithOptions(bytes.NewReader(data), testlib.MakeLogger(t), Options{Featuring: featuring})
+		require.NoError(t, err)
+
+		t.Run("featured first, heaviest first", func(t *testing.T) {
+			actualPlugins, err := featuredStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{gamma, beta, alpha}, actualPlugins)
+
+			actualPlugins, err = featuredStore.GetPlugins(&model.PluginFilter{Page: 1, PerPage: 2})
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{alpha}, actualPlugins)
+		})
+
+		t.Run("relevance order preserved", func(t *testing.T) {
+			actualPlugins, err := featuredStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, Filter: "a"})
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{alpha, beta, gamma}, actualPlugins)
+		})
+
+		t.Run("overridden", func(t *testing.T) {
+			featuring["alpha"] = &model.Featuring{PluginID: "alpha", Featured: true, Weight: 20}
+			featuring["gamma"] = &model.Featuring{PluginID: "gamma"}
+			defer func() {
+				delete(featuring, "alpha")
+				delete(featuring, "gamma")
+			}()
+
+			featuredAlpha := *alpha
+			featuredAlpha.Featured = true
+			featuredAlpha.FeaturedWeight = 20
+			unfeaturedGamma := *gamma
+			unfeaturedGamma.Featured = false
+			unfeaturedGamma.FeaturedWeight = 0
+
+			actualPlugins, err := featuredStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage})
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{&featuredAlpha, beta, &unfeaturedGamma}, actualPlugins)
+
+			actualPlugins, err = featuredStore.GetPluginVersions("gamma")
+			require.NoError(t, err)
+			require.Equal(t, []*model.Plugin{&unfeaturedGamma}, actualPlugins)
+
+			// The override is not recorded in the database.
+			require.True(t, featuredStore.AllPlugins()[2].Featured)
+		})
+	})
+
+	t.Run("platform, labels and sort", func(t *testing.T) {
+		zoom := &model.Plugin{
+			Labels:       []model.Label{{Name: "Official"}},
+			ReleasedAt:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			Platforms:    map[string]*model.PlatformBundle{"linux-amd64": {DownloadURL: "https://example.com/zoom-linux-amd64.tar.gz"}},
+			Manifest:     &mattermostModel.Manifest{Id: "zoom", Name: "Zoom", Version: "1.0.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+		}
+		jira := &model.Plugin{
+			DownloadURL:  "https://example.com/jira.tar.gz",
+			Labels:       []model.Label{{Name: "Official"}, {Name: "Beta"}},
+			ReleasedAt:   time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+			Manifest:     &mattermostModel.Manifest{Id: "jira", Name: "jira", Version: "1.0.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+		}
+		github := &model.Plugin{
+			DownloadURL:  "https://example.com/github.tar.gz",
+			ReleasedAt:   time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+			Manifest:     &mattermostModel.Manifest{Id: "github", Name: "GitHub", Version: "1.0.0"},
+			ReleaseStage: model.ReleaseStageProduction,
+		}
+		queryStore, err := NewFromPlugins([]*model.Plugin{zoom, jira, github}, testlib.MakeLogger(t), Options{})
+		require.NoError(t, err)
+
+		actualPlugins, err := queryStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, Platform: "darwin-amd64"})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{github, jira}, actualPlugins)
+
+		actualPlugins, err = queryStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, Labels: []string{"Official", "Beta"}})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{jira}, actualPlugins)
+
+		actualPlugins, err = queryStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, Sort: model.PluginSortReleased})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{jira, github, zoom}, actualPlugins)
+
+		actualPlugins, err = queryStore.GetPlugins(&model.PluginFilter{PerPage: 1, Page: 1, Sort: model.PluginSortName, Filter: "i"})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{jira}, actualPlugins, "filtered plugins sorted by name")
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		configuredPlugin := &model.Plugin{
+			DownloadURL:  "https://example.com/configured-1.0.0.tar.gz",
+			ReleaseStage: model.ReleaseStageProduction,
+			Manifest: &mattermostModel.Manifest{Id: "configured", Name: "Configured", Version: "1.0.0",
+				SettingsSchema: &mattermostModel.PluginSettingsSchema{
+					Settings: []*mattermostModel.PluginSetting{{Key: "Token", Type: "text"}},
+				},
+			},
+		}
+		settingsStore, err := NewFromPlugins([]*model.Plugin{configuredPlugin}, testlib.MakeLogger(t), Options{})
+		require.NoError(t, err)
+
+		actualPlugins, err := settingsStore.GetPluginVersions("configured")
+		require.NoError(t, err)
+		require.Len(t, actualPlugins, 1)
+		require.Equal(t, []*model.SettingSummary{{Key: "Token", Type: "text", Required: true}}, actualPlugins[0].Settings)
+
+		// The settings are summarized only in responses, leaving the catalog as recorded.
+		require.Nil(t, settingsStore.AllPlugins()[0].Settings)
+	})
+
+	t.Run("plugin versions", func(t *testing.T) {
+		actualPlugins, err := sqlStore.GetPluginVersions("com.mattermost.demo-plugin")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV2Min515, demoPluginV1Min514}, actualPlugins)
+
+		actualPlugins, err = sqlStore.GetPluginVersions("unknown")
+		require.NoError(t, err)
+		require.Nil(t, actualPlugins)
+	})
+
+	t.Run("cached listings", func(t *testing.T) {
+		firstPage, err := sqlStore.GetPlugins(&model.PluginFilter{PerPage: 1, ServerVersion: "5.15.0"})
+		require.NoError(t, err)
+		require.Len(t, firstPage, 1)
+
+		// Appending to a page must not modify the cached listing.
+		_ = append(firstPage, &model.Plugin{})
+		actualPlugins, err := sqlStore.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, ServerVersion: "5.15.0"})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV2Min515, starterPluginV1Min515}, actualPlugins)
+
+		// Listings for arbitrary server versions do not grow the cache without bound.
+		for i := 0; i <= maxCachedListings; i++ {
+			_, err = sqlStore.GetPlugins(&model.PluginFilter{PerPage: 1, ServerVersion: fmt.Sprintf("5.%d.0", i)})
+			require.NoError(t, err)
+		}
+		require.LessOrEqual(t, len(sqlStore.listings), maxCachedListings)
+	})
+}
+
+// fakeFeaturing overrides the featuring of the plugins it maps by id.
+type fakeFeaturing map[string]*model.Featuring
+
+func (f fakeFeaturing) GetFeaturing(pluginID string) *model.Featuring {
+	return f[pluginID]
 }
@@ -1,46 +1,596 @@
 package store
 
 import (
+	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/quality"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
 )
 
 // Store provides access to a store backed by the given reader.
 type Store struct {
 	plugins []*model.Plugin
+	apps    []*model.App
 	logger  logrus.FieldLogger
+	// aliases maps the lowercased old ids of renamed plugins to their current manifest id.
+	aliases map[string]string
+	// successors maps the lowercased manifest ids of superseded plugins to the manifest id of the
+	// plugin superseding them.
+	successors map[string]string
+	// maintenance summarizes the activity of each plugin by manifest id, and staleAfter is the
+	// age of its latest release beyond which a plugin is flagged as unmaintained, if positive.
+	// staleAts lists when each plugin growing stale does so, ascending.
+	maintenance map[string]model.Maintenance
+	staleAfter  time.Duration
+	staleAts    []time.Time
+	now         func() time.Time
+	// preReleaseTTL is the lifetime of pre-releases recording no expiry of their own, and
+	// expiries lists when each lapsing version lapses, ascending.
+	preReleaseTTL time.Duration
+	expiries      []time.Time
+	// featuring overrides the featuring of the plugins recorded in the database, if set.
+	featuring Featuring
+
+	// icons holds the icons of the plugins, decoded once for all the versions sharing them.
+	icons map[*mattermostModel.Manifest]*storedIcon
+
+	index pluginIndex
+
+	// warnings describes the icons dropped from plugins when the store was constructed.
+	warnings []string
+
+	// listings caches the plugins listed by getPlugins, keyed by its arguments, as of the given
+	// number of lapsed versions.
+	listingsLock    sync.Mutex
+	listings        map[listingKey][]*model.Plugin
+	listingsExpired int
+}
+
+// Options configures the validation applied when constructing a Store.
+type Options struct {
+	// MaxIconSize limits the decoded size of plugin icons, defaulting to model.DefaultMaxIconSize.
+	MaxIconSize int
+	// LenientIcons strips icons violating the icon policy, such as oversized icons or icon urls
+	// not using https, instead of rejecting the stream. Icons that fail to decode are always
+	// dropped rather than rejected.
+	LenientIcons bool
+	// StaleAfter, if positive, flags plugins whose latest release is older than it as
+	// unmaintained, as are plugins whose repository was archived.
+	StaleAfter time.Duration
+	// Rules, if set, enables strict mode, rejecting streams with plugins failing its blocking
+	// checks or rules, and logging plugins failing the others.
+	Rules *quality.Config
+	// Featuring, if set, overrides the featuring of the plugins recorded in the database, such as
+	// by moderators, when ordering listings.
+	Featuring Featuring
+	// PreReleaseTTL, if positive, is how long after their release versions not promoted to
+	// production are served, unless they record their own expiry.
+	PreReleaseTTL time.Duration
 }
 
 // New constructs a new instance of Store.
 func New(reader io.Reader, logger logrus.FieldLogger) (*Store, error) {
+	return NewWithOptions(reader, logger, Options{})
+}
+
+// NewWithOptions constructs a new instance of Store using the given options.
+func NewWithOptions(reader io.Reader, logger logrus.FieldLogger, options Options) (*Store, error) {
 	plugins, err := model.PluginsFromReader(reader)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse stream")
 	}
 
+	return NewFromPlugins(plugins, logger, options)
+}
+
+// NewFromPlugins constructs a new instance of Store serving the given plugins, validated as if
+// decoded from a stream. Versions tagged with a v prefix are served without it. The store takes
+// ownership of the given plugins.
+func NewFromPlugins(plugins []*model.Plugin, logger logrus.FieldLogger, options Options) (*Store, error) {
+	for i, plugin := range plugins {
+		if plugin == nil || plugin.Manifest == nil {
+			return nil, errors.Errorf("failed to validate plugins: no manifest for %s", describePlugin(i, plugin))
+		}
+		model.NormalizeManifestVersions(plugin.Manifest)
+	}
+
+	warnings, err := validateIcons(plugins, options, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to validate plugins")
+	}
+
 	if err := validatePlugins(plugins); err != nil {
 		return nil, errors.Wrap(err, "failed to validate plugins")
 	}
 
+	if options.Rules != nil {
+		if err := enforceRules(plugins, options.Rules, logger); err != nil {
+			return nil, errors.Wrap(err, "failed to validate plugins")
+		}
+	}
+
+	aliases, err := pluginAliases(plugins)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to validate plugins")
+	}
+
+	if err := validateDependencies(plugins, aliases); err != nil {
+		return nil, errors.Wrap(err, "failed to validate plugins")
+	}
+
+	successors, err := pluginSuccessors(plugins, aliases)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to validate plugins")
+	}
+
+	icons := internIcons(plugins)
+	index := newPluginIndex(plugins)
+
+	maintenance := map[string]model.Maintenance{}
+	var staleAts []time.Time
+	for id, versions := range index.versions {
+		maintenance[id] = model.NewMaintenance(versions)
+		if options.StaleAfter > 0 && !maintenance[id].RepositoryArchived && !maintenance[id].LastReleasedAt.IsZero() {
+			staleAts = append(staleAts, maintenance[id].LastReleasedAt.Add(options.StaleAfter))
+		}
+	}
+	sort.Slice(staleAts, func(i, j int) bool { return staleAts[i].Before(staleAts[j]) })
+
+	var expiries []time.Time
+	for _, plugin := range plugins {
+		if expiry := plugin.Expiry(options.PreReleaseTTL); !expiry.IsZero() {
+			expiries = append(expiries, expiry)
+		}
+	}
+	sort.Slice(expiries, func(i, j int) bool { return expiries[i].Before(expiries[j]) })
+
 	return &Store{
-		plugins,
-		logger,
+		plugins:       plugins,
+		logger:        logger,
+		aliases:       aliases,
+		successors:    successors,
+		maintenance:   maintenance,
+		staleAfter:    options.StaleAfter,
+		staleAts:      staleAts,
+		now:           time.Now,
+		preReleaseTTL: options.PreReleaseTTL,
+		expiries:      expiries,
+		featuring:     options.Featuring,
+		icons:         icons,
+		index:         index,
+		warnings:      warnings,
+		listings:      map[listingKey][]*model.Plugin{},
 	}, nil
 }
 
-func validatePlugins(plugins []*model.Plugin) error {
+// validateIcons verifies the icon of each plugin, dropping icons that fail to decode and, in
+// lenient mode, those otherwise invalid, and returns a warning describing each icon dropped.
+func validateIcons(plugins []*model.Plugin, options Options, logger logrus.FieldLogger) ([]string, error) {
+	maxIconSize := options.MaxIconSize
+	if maxIconSize <= 0 {
+		maxIconSize = model.DefaultMaxIconSize
+	}
+
+	var warnings []string
+	drop := func(i int, plugin *model.Plugin, kind string, err error) {
+		warning := fmt.Sprintf("dropped %s for %s: %s", kind, describePlugin(i, plugin), err.Error())
+		logger.Warn(warning)
+		warnings = append(warnings, warning)
+	}
+
+	for i, plugin := range plugins {
+		if plugin.Manifest == nil {
+			continue
+		}
+
+		if plugin.IconData != "" {
+			if _, _, err := model.DecodeIconData(plugin.IconData); err != nil {
+				drop(i, plugin, "malformed icon", err)
+				plugin.IconData = ""
+			} else if err := model.ValidateIconData(plugin.IconData, maxIconSize); err != nil && !options.LenientIcons {
+				return nil, errors.Wrapf(err, "invalid icon for %s", describePlugin(i, plugin))
+			} else if err != nil {
+				drop(i, plugin, "invalid icon", err)
+				plugin.IconData = ""
+			}
+		}
+
+		if plugin.IconURL != "" {
+			err := model.ValidateIconURL(plugin.IconURL)
+			if err != nil && !options.LenientIcons {
+				return nil, errors.Wrapf(err, "invalid icon url for %s", describePlugin(i, plugin))
+			} else if err != nil {
+				drop(i, plugin, "invalid icon url", err)
+				plugin.IconURL = ""
+			}
+		}
+	}
+
+	return warnings, nil
+}
+
+// Warnings describes the icons dropped from plugins when the store was constructed, rather than
+// rejecting the stream.
+func (store *Store) Warnings() []string {
+	return store.warnings
+}
+
+// enforceRules evaluates the given rules against each plugin, logging every finding, and fails if
+// any is blocking.
+func enforceRules(plugins []*model.Plugin, rules *quality.Config, logger logrus.FieldLogger) error {
+	var blocked []string
 	for _, plugin := range plugins {
+		for _, finding := range rules.Evaluate(plugin) {
+			if finding.Severity == quality.SeverityBlock {
+				blocked = append(blocked, finding.String())
+				continue
+			}
+			logger.WithField("check", finding.Check).Warn(finding.String())
+		}
+	}
+
+	if len(blocked) > 0 {
+		return errors.Errorf("%d blocking rules failed: %s", len(blocked), strings.Join(blocked, "; "))
+	}
+
+	return nil
+}
+
+func validatePlugins(plugins []*model.Plugin) error {
+	for i, plugin := range plugins {
 		if plugin.Manifest.Id == "" {
-			return errors.Errorf("plugin manifest Id is empty %+v", plugin)
+			return errors.Errorf("manifest Id is empty for %s", describePlugin(i, plugin))
 		}
 		if _, err := semver.Parse(plugin.Manifest.Version); err != nil {
-			return errors.Wrapf(err, "failed to parse manifest version for manifest.Id %s", plugin.Manifest.Id)
+			return errors.Wrapf(err, "failed to parse manifest version for %s", describePlugin(i, plugin))
+		}
+
+		if plugin.Manifest.MinServerVersion != "" {
+			if _, err := semver.Parse(plugin.Manifest.MinServerVersion); err != nil {
+				return errors.Wrapf(err, "failed to parse min server version for %s", describePlugin(i, plugin))
+			}
+		}
+
+		if plugin.AuthorType != "" && !plugin.AuthorType.IsValid() {
+			return errors.Errorf("invalid author type %s for %s", plugin.AuthorType, describePlugin(i, plugin))
+		}
+
+		if plugin.HostingRequirement != "" && !plugin.HostingRequirement.IsValid() {
+			return errors.Errorf("invalid hosting requirement %s for %s", plugin.HostingRequirement, describePlugin(i, plugin))
+		}
+
+		if plugin.RequiredLicense != "" && !plugin.RequiredLicense.IsValid() {
+			return errors.Errorf("invalid required license %s for %s", plugin.RequiredLicense, describePlugin(i, plugin))
+		}
+
+		if plugin.ServerVersionRange != "" {
+			if _, err := model.ParseServerVersionRange(plugin.ServerVersionRange); err != nil {
+				return errors.Wrapf(err, "invalid server version range for %s", describePlugin(i, plugin))
+			}
+		}
+
+		if !plugin.ReleaseStage.IsValid() {
+			return errors.Errorf("invalid release stage %s for %s", plugin.ReleaseStage, describePlugin(i, plugin))
+		}
+
+		for _, screenshot := range plugin.Screenshots {
+			if err := model.ValidateImageReference(screenshot); err != nil {
+				return errors.Wrapf(err, "invalid screenshot for %s", describePlugin(i, plugin))
+			}
+		}
+		if plugin.BannerImageURL != "" {
+			if err := model.ValidateImageReference(plugin.BannerImageURL); err != nil {
+				return errors.Wrapf(err, "invalid banner image for %s", describePlugin(i, plugin))
+			}
+		}
+
+		for _, maintainer := range plugin.Maintainers {
+			if maintainer == nil {
+				return errors.Errorf("empty maintainer for %s", describePlugin(i, plugin))
+			}
+			if err := maintainer.IsValid(); err != nil {
+				return errors.Wrapf(err, "invalid maintainer for %s", describePlugin(i, plugin))
+			}
+		}
+
+		if plugin.Checksums != nil {
+			if err := plugin.Checksums.IsValid(); err != nil {
+				return errors.Wrapf(err, "invalid checksums for %s", describePlugin(i, plugin))
+			}
+		}
+
+		labelNames := map[string]bool{}
+		for _, label := range plugin.Labels {
+			if err := label.IsValid(); err != nil {
+				return errors.Wrapf(err, "invalid label for %s", describePlugin(i, plugin))
+			}
+			if labelNames[label.Name] {
+				return errors.Errorf("duplicate label %s for %s", label.Name, describePlugin(i, plugin))
+			}
+			labelNames[label.Name] = true
+		}
+
+		if err := validateSignatures(plugin.Signatures); err != nil {
+			return errors.Wrapf(err, "invalid signatures for %s", describePlugin(i, plugin))
+		}
+
+		dependencyIDs := map[string]bool{}
+		for _, dependency := range plugin.Dependencies {
+			if dependency == nil {
+				return errors.Errorf("empty dependency for %s", describePlugin(i, plugin))
+			}
+			if err := dependency.IsValid(); err != nil {
+				return errors.Wrapf(err, "invalid dependency for %s", describePlugin(i, plugin))
+			}
+			if plugin.HasID(dependency.PluginID) {
+				return errors.Errorf("%s depends on itself", describePlugin(i, plugin))
+			}
+			if dependencyIDs[strings.ToLower(dependency.PluginID)] {
+				return errors.Errorf("duplicate dependency %s for %s", dependency.PluginID, describePlugin(i, plugin))
+			}
+			dependencyIDs[strings.ToLower(dependency.PluginID)] = true
+		}
+
+		if err := validateRelatedIDs(plugin, "superseded plugin", plugin.Supersedes); err != nil {
+			return errors.Wrapf(err, "invalid supersedes for %s", describePlugin(i, plugin))
+		}
+		if err := validateRelatedIDs(plugin, "conflicting plugin", plugin.ConflictsWith); err != nil {
+			return errors.Wrapf(err, "invalid conflicts_with for %s", describePlugin(i, plugin))
+		}
+		if plugin.SupersededBy != "" && plugin.HasID(plugin.SupersededBy) {
+			return errors.Errorf("%s is superseded by itself", describePlugin(i, plugin))
+		}
+
+		for platform, bundle := range plugin.Platforms {
+			if !model.IsValidPlatform(platform) {
+				return errors.Errorf("invalid platform %s for %s", platform, describePlugin(i, plugin))
+			}
+			if bundle == nil {
+				return errors.Errorf("empty bundle for platform %s for %s", platform, describePlugin(i, plugin))
+			}
+			if err := bundle.IsValid(); err != nil {
+				return errors.Wrapf(err, "invalid bundle for platform %s for %s", platform, describePlugin(i, plugin))
+			}
+			if err := validateSignatures(bundle.Signatures); err != nil {
+				return errors.Wrapf(err, "invalid signatures for platform %s for %s", platform, describePlugin(i, plugin))
+			}
+		}
+	}
+	return nil
+}
+
+// pluginAliases maps the lowercased old ids of the given plugins to their current manifest id,
+// verifying no old id is claimed by more than one plugin.
+func pluginAliases(plugins []*model.Plugin) (map[string]string, error) {
+	aliases := map[string]string{}
+	claimants := map[string]string{}
+	for i, plugin := range plugins {
+		for _, oldID := range plugin.OldIDs {
+			if oldID == "" {
+				return nil, errors.Errorf("empty old id for %s", describePlugin(i, plugin))
+			}
+			if strings.EqualFold(oldID, plugin.Manifest.Id) {
+				return nil, errors.Errorf("old id %s repeats the manifest.Id of %s", oldID, describePlugin(i, plugin))
+			}
+
+			alias := strings.ToLower(oldID)
+			if id, ok := aliases[alias]; ok && id != plugin.Manifest.Id {
+				return nil, errors.Errorf("old id %s is claimed by both %s and %s", oldID, claimants[alias], describePlugin(i, plugin))
+			}
+			aliases[alias] = plugin.Manifest.Id
+			claimants[alias] = describePlugin(i, plugin)
 		}
 	}
+
+	return aliases, nil
+}
+
+// validateDependencies verifies the dependencies among the given plugins form no cycle, which
+// would leave no order in which to install them. A plugin depends on the union of the
+// dependencies of its versions, identified by their current manifest id.
+func validateDependencies(plugins []*model.Plugin, aliases map[string]string) error {
+	canonicalID := func(id string) string {
+		if manifestID, ok := aliases[strings.ToLower(id)]; ok {
+			id = manifestID
+		}
+		return strings.ToLower(id)
+	}
+
+	names := map[string]string{}
+	edges := map[string]map[string]bool{}
+	for _, plugin := range plugins {
+		id := canonicalID(plugin.Manifest.Id)
+		names[id] = plugin.Manifest.Id
+		for _, dependency := range plugin.Dependencies {
+			if edges[id] == nil {
+				edges[id] = map[string]bool{}
+			}
+			edges[id][canonicalID(dependency.PluginID)] = true
+		}
+	}
+
+	ids := make([]string, 0, len(edges))
+	for id := range edges {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visited:
+			return nil
+		case visiting:
+			var cycle []string
+			for i := len(path) - 1; i >= 0; i-- {
+				if path[i] == id {
+					for _, cycleID := range append(path[i:], id) {
+						cycle = append(cycle, names[cycleID])
+					}
+					break
+				}
+			}
+			return errors.Errorf("dependency cycle %s", strings.Join(cycle, " -> "))
+		}
+
+		state[id] = visiting
+		path = append(path, id)
+		dependencies := make([]string, 0, len(edges[id]))
+		for dependency := range edges[id] {
+			dependencies = append(dependencies, dependency)
+		}
+		sort.Strings(dependencies)
+		for _, dependency := range dependencies {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = visited
+
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := visit(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRelatedIDs verifies the ids of the plugins related to the given plugin name neither
+// the plugin itself nor the same plugin twice.
+func validateRelatedIDs(plugin *model.Plugin, relation string, ids []string) error {
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if id == "" {
+			return errors.Errorf("empty %s id", relation)
+		}
+		if plugin.HasID(id) {
+			return errors.Errorf("%s %s is the plugin itself", relation, id)
+		}
+		if seen[strings.ToLower(id)] {
+			return errors.Errorf("duplicate %s %s", relation, id)
+		}
+		seen[strings.ToLower(id)] = true
+	}
+
+	return nil
+}
+
+// pluginSuccessors maps the lowercased manifest ids of the plugins superseded by the given plugins
+// to the manifest id of the plugin superseding them, verifying each plugin has one successor and
+// no plugin succeeds itself through a chain of successors.
+func pluginSuccessors(plugins []*model.Plugin, aliases map[string]string) (map[string]string, error) {
+	canonicalID := func(id string) string {
+		if manifestID, ok := aliases[strings.ToLower(id)]; ok {
+			id = manifestID
+		}
+		return strings.ToLower(id)
+	}
+
+	successors := map[string]string{}
+	claimants := map[string]string{}
+	for i, plugin := range plugins {
+		for _, supersededID := range plugin.Supersedes {
+			superseded := canonicalID(supersededID)
+			if successor, ok := successors[superseded]; ok && successor != plugin.Manifest.Id {
+				return nil, errors.Errorf("plugin %s is superseded by both %s and %s", supersededID, claimants[superseded], describePlugin(i, plugin))
+			}
+			successors[superseded] = plugin.Manifest.Id
+			claimants[superseded] = describePlugin(i, plugin)
+		}
+	}
+
+	for i, plugin := range plugins {
+		successor, ok := successors[strings.ToLower(plugin.Manifest.Id)]
+		if ok && plugin.SupersededBy != "" && canonicalID(plugin.SupersededBy) != strings.ToLower(successor) {
+			return nil, errors.Errorf("%s is superseded by %s, not %s", describePlugin(i, plugin), successor, plugin.SupersededBy)
+		}
+	}
+
+	supersededIDs := make([]string, 0, len(successors))
+	for superseded := range successors {
+		supersededIDs = append(supersededIDs, superseded)
+	}
+	sort.Strings(supersededIDs)
+	for _, superseded := range supersededIDs {
+		seen := map[string]bool{superseded: true}
+		for id := superseded; ; {
+			successor, ok := successors[id]
+			if !ok {
+				break
+			}
+			id = strings.ToLower(successor)
+			if seen[id] {
+				return nil, errors.Errorf("plugin %s supersedes itself through its successors", successor)
+			}
+			seen[id] = true
+		}
+	}
+
+	return successors, nil
+}
+
+// describePlugin identifies the plugin at the given index of a database by the fields most useful
+// for finding it in a large file: its manifest id and version, and where it is downloaded from.
+func describePlugin(index int, plugin *model.Plugin) string {
+	var fields []string
+	if plugin != nil && plugin.Manifest != nil {
+		if plugin.Manifest.Id != "" {
+			fields = append(fields, "manifest.Id "+plugin.Manifest.Id)
+		}
+		if plugin.Manifest.Version != "" {
+			fields = append(fields, "version "+plugin.Manifest.Version)
+		}
+	}
+	if plugin != nil && plugin.DownloadURL != "" {
+		fields = append(fields, "download_url "+plugin.DownloadURL)
+	} else if plugin != nil && plugin.HomepageURL != "" {
+		fields = append(fields, "homepage_url "+plugin.HomepageURL)
+	}
+
+	if len(fields) == 0 {
+		return fmt.Sprintf("plugin %d", index)
+	}
+
+	return fmt.Sprintf("plugin %d (%s)", index, strings.Join(fields, ", "))
+}
+
+// validateSignatures verifies each signature is well-formed and issued by a distinct key.
+func validateSignatures(signatures []*model.Signature) error {
+	publicKeyHashes := map[string]bool{}
+	for i, signature := range signatures {
+		if signature == nil {
+			return errors.Errorf("empty signature %d", i)
+		}
+		if err := signature.IsValid(); err != nil {
+			return err
+		}
+		if publicKeyHashes[signature.PublicKeyHash] {
+			return errors.Errorf("duplicate signature for public key hash %s", signature.PublicKeyHash)
+		}
+		publicKeyHashes[signature.PublicKeyHash] = true
+	}
+
 	return nil
 }
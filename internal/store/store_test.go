@@ -2,9 +2,13 @@ package store
 
 import (
 	"bytes"
+	"io/ioutil"
 	"testing"
 
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/quality"
 	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,29 +30,424 @@ func TestNew(t *testing.T) {
 
 	t.Run("missing manifest id", func(t *testing.T) {
 		logger := testlib.MakeLogger(t)
-		store, err := New(bytes.NewReader([]byte(`[{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-demo","IconData":"icon-data.svg","DownloadURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz","DownloadSignature":"c2lnbmF0dXJl","ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0","Manifest":{}},{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-starter-template","DownloadURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz","Signatures":[{"signature":"signature2","public_key_hash":"hash2"}],"ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/v0.1.0","Manifest":{}}]`)), logger)
-		require.Contains(t, err.Error(), "failed to validate plugins: plugin manifest Id is empty ")
+		store, err := New(bytes.NewReader([]byte(`[{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-demo","IconData":"data:image/svg+xml;base64,PHN2Zy8+","DownloadURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz","DownloadSignature":"c2lnbmF0dXJl","ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0","Manifest":{}},{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-starter-template","DownloadURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz","Signatures":[{"signature":"signature2","public_key_hash":"hash2"}],"ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/v0.1.0","Manifest":{}}]`)), logger)
+		require.Contains(t, err.Error(), "failed to validate plugins: manifest Id is empty for plugin 0")
+		require.Nil(t, store)
+	})
+
+	t.Run("errors locate the offending entry", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id":"test","version":"0.1.0"}},{"download_url":"https://example.com/demo-0.2.0.tar.gz","manifest":{"version":"0.2.0"}}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: manifest Id is empty for plugin 1 (version 0.2.0, download_url https://example.com/demo-0.2.0.tar.gz)")
+		require.Nil(t, store)
+
+		store, err = New(bytes.NewReader([]byte(`[{"manifest":{"id":"test","version":"0.1.0"}},{"homepage_url":"https://example.com/demo"}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: no manifest for plugin 1 (homepage_url https://example.com/demo)")
 		require.Nil(t, store)
 	})
 
 	t.Run("missing manifest version", func(t *testing.T) {
 		logger := testlib.MakeLogger(t)
-		store, err := New(bytes.NewReader([]byte(`[{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-demo","IconData":"icon-data.svg","DownloadURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz","DownloadSignature":"c2lnbmF0dXJl","ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0","Manifest":{"id": "test"}},{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-starter-template","DownloadURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz","Signatures":[{"signature":"signature2","public_key_hash":"hash2"}],"ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/v0.1.0","Manifest":{"id": "test"}}]`)), logger)
-		require.EqualError(t, err, "failed to validate plugins: failed to parse manifest version for manifest.Id test: Version string empty")
+		store, err := New(bytes.NewReader([]byte(`[{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-demo","IconData":"data:image/svg+xml;base64,PHN2Zy8+","DownloadURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz","DownloadSignature":"c2lnbmF0dXJl","ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0","Manifest":{"id": "test"}},{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-starter-template","DownloadURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz","Signatures":[{"signature":"signature2","public_key_hash":"hash2"}],"ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/v0.1.0","Manifest":{"id": "test"}}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: failed to parse manifest version for plugin 0 (manifest.Id test): Version string empty")
+		require.Nil(t, store)
+	})
+
+	t.Run("invalid min_server_version", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id":"test","version":"0.1.0","min_server_version":"5"}}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: failed to parse min server version for plugin 0 (manifest.Id test, version 0.1.0): No Major.Minor.Patch elements found")
 		require.Nil(t, store)
 	})
 
 	t.Run("missing min_server_version version is valid", func(t *testing.T) {
 		logger := testlib.MakeLogger(t)
-		store, err := New(bytes.NewReader([]byte(`[{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-demo","IconData":"icon-data.svg","DownloadURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz","DownloadSignature":"c2lnbmF0dXJl","ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0","Manifest":{"id": "test", "version": "0.1.0"}},{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-starter-template","DownloadURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz","Signatures":[{"signature":"signature2","public_key_hash":"hash2"}],"ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/v0.1.0","Manifest":{"id": "test", "version": "0.1.0"}}]`)), logger)
+		store, err := New(bytes.NewReader([]byte(`[{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-demo","IconData":"data:image/svg+xml;base64,PHN2Zy8+","DownloadURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz","DownloadSignature":"c2lnbmF0dXJl","ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0","Manifest":{"id": "test", "version": "0.1.0"}},{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-starter-template","DownloadURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz","Signatures":[{"signature":"signature2","public_key_hash":"hash2"}],"ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/v0.1.0","Manifest":{"id": "test", "version": "0.1.0"}}]`)), logger)
 		require.NoError(t, err)
 		require.NotNil(t, store)
 	})
 
+	t.Run("v-prefixed versions and build metadata are normalized", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id":"test","version":"v0.2.0+build5","min_server_version":"v5.20.0"}},{"manifest":{"id":"test","version":"0.1.0"}}]`)), logger)
+		require.NoError(t, err)
+
+		plugins, err := store.GetPluginVersions("test")
+		require.NoError(t, err)
+		require.Len(t, plugins, 2)
+		require.Equal(t, "0.2.0+build5", plugins[0].Manifest.Version)
+		require.Equal(t, "5.20.0", plugins[0].Manifest.MinServerVersion)
+		require.Equal(t, "0.1.0", plugins[1].Manifest.Version)
+	})
+
+	t.Run("invalid label", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"labels":[{"name":"Official","color":"blue"}]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid label for plugin 0 (manifest.Id test, version 0.1.0): label Official has invalid color blue")
+		require.Nil(t, store)
+	})
+
+	t.Run("duplicate label", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"labels":[{"name":"Official"},{"name":"Official"}]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: duplicate label Official for plugin 0 (manifest.Id test, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("valid labels", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"labels":[{"name":"Official","color":"#1e325c"},{"name":"Beta"}]}]`)), logger)
+		require.NoError(t, err)
+		require.NotNil(t, store)
+	})
+
+	t.Run("signature missing public key hash", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"signatures":[{"signature":"signature1"}]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid signatures for plugin 0 (manifest.Id test, version 0.1.0): signature public key hash is empty")
+		require.Nil(t, store)
+	})
+
+	t.Run("duplicate signature public key hash", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"signatures":[{"signature":"signature1","public_key_hash":"hash1"},{"signature":"signature2","public_key_hash":"hash1"}]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid signatures for plugin 0 (manifest.Id test, version 0.1.0): duplicate signature for public key hash hash1")
+		require.Nil(t, store)
+	})
+
+	t.Run("null signature", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"signatures":[{"signature":"signature1","public_key_hash":"hash1"},null]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid signatures for plugin 0 (manifest.Id test, version 0.1.0): empty signature 1")
+		require.Nil(t, store)
+	})
+
+	t.Run("invalid checksums", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"checksums":{"sha256":"not-hex"}}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid checksums for plugin 0 (manifest.Id test, version 0.1.0): sha256 checksum is not hex-encoded: encoding/hex: invalid byte: U+006E 'n'")
+		require.Nil(t, store)
+	})
+
+	t.Run("valid checksums", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"checksums":{"sha256":"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}}]`)), logger)
+		require.NoError(t, err)
+		require.NotNil(t, store)
+	})
+
+	t.Run("invalid platform", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"platforms":{"amd64":{"download_url":"https://example.com/test-amd64.tar.gz"}}}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid platform amd64 for plugin 0 (manifest.Id test, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("platform missing download url", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"platforms":{"linux-amd64":{}}}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid bundle for platform linux-amd64 for plugin 0 (manifest.Id test, version 0.1.0): download url is empty")
+		require.Nil(t, store)
+	})
+
+	t.Run("platform with invalid signature", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"platforms":{"linux-amd64":{"download_url":"https://example.com/test-linux-amd64.tar.gz","signatures":[{"public_key_hash":"hash1"}]}}}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid signatures for platform linux-amd64 for plugin 0 (manifest.Id test, version 0.1.0): signature is empty")
+		require.Nil(t, store)
+	})
+
+	t.Run("platform with null signature", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"platforms":{"linux-amd64":{"download_url":"https://example.com/test-linux-amd64.tar.gz","signatures":[null]}}}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid signatures for platform linux-amd64 for plugin 0 (manifest.Id test, version 0.1.0): empty signature 0")
+		require.Nil(t, store)
+	})
+
+	t.Run("valid platforms", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"platforms":{"linux-amd64":{"download_url":"https://example.com/test-linux-amd64.tar.gz","signatures":[{"signature":"signature1","public_key_hash":"hash1"}]},"windows-amd64":{"download_url":"https://example.com/test-windows-amd64.tar.gz"}}}]`)), logger)
+		require.NoError(t, err)
+		require.NotNil(t, store)
+	})
+
+	t.Run("invalid screenshot", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"screenshots":["https://example.com/1.png","ftp://example.com/2.png"]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid screenshot for plugin 0 (manifest.Id test, version 0.1.0): url ftp://example.com/2.png must use http or https")
+		require.Nil(t, store)
+	})
+
+	t.Run("invalid banner image", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"banner_image_url":"data:text/plain;base64,aGVsbG8="}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid banner image for plugin 0 (manifest.Id test, version 0.1.0): data uri has non-image mime type text/plain")
+		require.Nil(t, store)
+	})
+
+	t.Run("valid images", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"screenshots":["https://example.com/1.png","data:image/png;base64,iVBORw0KGgo="],"banner_image_url":"https://example.com/banner.png"}]`)), logger)
+		require.NoError(t, err)
+		require.NotNil(t, store)
+	})
+
+	t.Run("invalid author type", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"author_type":"unknown"}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid author type unknown for plugin 0 (manifest.Id test, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("malformed icons", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"icon_data":"icon.svg"},{"manifest":{"id": "test2", "version": "0.1.0"},"icon_data":"data:image/svg+xml;base64,!!!"},{"manifest":{"id": "test3", "version": "0.1.0"},"icon_data":"data:image/svg+xml;base64,PHN2Zy8+"}]`)), logger)
+		require.NoError(t, err)
+		require.Len(t, store.AllPlugins(), 3)
+		require.Equal(t, "", store.AllPlugins()[0].IconData)
+		require.Equal(t, "", store.AllPlugins()[1].IconData)
+		require.Equal(t, "data:image/svg+xml;base64,PHN2Zy8+", store.AllPlugins()[2].IconData)
+		require.Len(t, store.Warnings(), 2)
+		require.Equal(t, "dropped malformed icon for plugin 0 (manifest.Id test, version 0.1.0): not a data uri", store.Warnings()[0])
+		require.Contains(t, store.Warnings()[1], "dropped malformed icon for plugin 1 (manifest.Id test2, version 0.1.0)")
+	})
+
+	t.Run("invalid icon", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"icon_data":"data:text/html;base64,PHN2Zy8+"}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid icon for plugin 0 (manifest.Id test, version 0.1.0): icon has disallowed mime type text/html")
+		require.Nil(t, store)
+	})
+
+	t.Run("oversized icon", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := NewWithOptions(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"icon_data":"data:image/svg+xml;base64,PHN2Zy8+"}]`)), logger, Options{MaxIconSize: 4})
+		require.EqualError(t, err, "failed to validate plugins: invalid icon for plugin 0 (manifest.Id test, version 0.1.0): icon is 6 bytes, exceeding the maximum of 4")
+		require.Nil(t, store)
+	})
+
+	t.Run("lenient icons", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := NewWithOptions(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"icon_data":"icon.svg"},{"manifest":{"id": "test2", "version": "0.1.0"},"icon_data":"data:image/svg+xml;base64,PHN2Zy8+"}]`)), logger, Options{LenientIcons: true})
+		require.NoError(t, err)
+		require.Equal(t, "", store.AllPlugins()[0].IconData)
+		require.Equal(t, "data:image/svg+xml;base64,PHN2Zy8+", store.AllPlugins()[1].IconData)
+	})
+
+	t.Run("icon url", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"icon_url":"https://cdn.example.com/icons/test.svg"}]`)), logger)
+		require.NoError(t, err)
+		require.Equal(t, "https://cdn.example.com/icons/test.svg", store.AllPlugins()[0].IconURL)
+	})
+
+	t.Run("invalid icon url", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"icon_url":"data:image/svg+xml;base64,PHN2Zy8+"}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid icon url for plugin 0 (manifest.Id test, version 0.1.0): icon url must not be a data uri")
+		require.Nil(t, store)
+	})
+
+	t.Run("lenient icon urls", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := NewWithOptions(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"icon_url":"ftp://example.com/icon.svg"}]`)), logger, Options{LenientIcons: true})
+		require.NoError(t, err)
+		require.Equal(t, "", store.AllPlugins()[0].IconURL)
+		require.Len(t, store.Warnings(), 1)
+		require.Contains(t, store.Warnings()[0], "dropped invalid icon url for plugin 0 (manifest.Id test, version 0.1.0)")
+	})
+
+	t.Run("old id repeating manifest id", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"old_ids":["TEST"]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: old id TEST repeats the manifest.Id of plugin 0 (manifest.Id test, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("old id claimed twice", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"old_ids":["old"]},{"manifest":{"id": "test2", "version": "0.1.0"},"old_ids":["Old"]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: old id Old is claimed by both plugin 0 (manifest.Id test, version 0.1.0) and plugin 1 (manifest.Id test2, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("dependencies", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"dependencies":[{"plugin_id":"Old","min_version":"1.0.0"},{"plugin_id":"prepackaged"}]},{"manifest":{"id": "test2", "version": "1.0.0"},"old_ids":["old"]}]`)), logger)
+		require.NoError(t, err)
+		require.Equal(t, []*model.Dependency{{PluginID: "Old", MinVersion: "1.0.0"}, {PluginID: "prepackaged"}}, store.AllPlugins()[0].Dependencies)
+	})
+
+	t.Run("invalid dependency", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"dependencies":[{"plugin_id":"test2","min_version":"latest"}]}]`)), logger)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to validate plugins: invalid dependency for plugin 0 (manifest.Id test, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("invalid maintainer", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"maintainers":[{"name":"Jane","email":"jane"}]}]`)), logger)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to validate plugins: invalid maintainer for plugin 0 (manifest.Id test, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("dependency on itself", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"old_ids":["old"],"dependencies":[{"plugin_id":"OLD"}]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: plugin 0 (manifest.Id test, version 0.1.0) depends on itself")
+		require.Nil(t, store)
+	})
+
+	t.Run("duplicate dependency", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"dependencies":[{"plugin_id":"test2"},{"plugin_id":"Test2","min_version":"1.0.0"}]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: duplicate dependency Test2 for plugin 0 (manifest.Id test, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("dependency cycle", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "a", "version": "0.1.0"},"dependencies":[{"plugin_id":"b"}]},{"manifest":{"id": "b", "version": "0.1.0"},"dependencies":[{"plugin_id":"old-c"}]},{"manifest":{"id": "c", "version": "0.2.0"},"old_ids":["old-c"]},{"manifest":{"id": "c", "version": "0.1.0"},"dependencies":[{"plugin_id":"B"}]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: dependency cycle b -> c -> b")
+		require.Nil(t, store)
+	})
+
+	t.Run("superseding itself", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"supersedes":["TEST"]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid supersedes for plugin 0 (manifest.Id test, version 0.1.0): superseded plugin TEST is the plugin itself")
+		require.Nil(t, store)
+
+		store, err = New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"superseded_by":"test"}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: plugin 0 (manifest.Id test, version 0.1.0) is superseded by itself")
+		require.Nil(t, store)
+	})
+
+	t.Run("conflicting with itself", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"conflicts_with":["other","other"]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid conflicts_with for plugin 0 (manifest.Id test, version 0.1.0): duplicate conflicting plugin other")
+		require.Nil(t, store)
+	})
+
+	t.Run("superseded twice", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"supersedes":["old"]},{"manifest":{"id": "test2", "version": "0.1.0"},"supersedes":["Old"]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: plugin Old is superseded by both plugin 0 (manifest.Id test, version 0.1.0) and plugin 1 (manifest.Id test2, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("superseded by another", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"supersedes":["old"]},{"manifest":{"id": "old", "version": "0.1.0"},"superseded_by":"test2"}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: plugin 1 (manifest.Id old, version 0.1.0) is superseded by test, not test2")
+		require.Nil(t, store)
+	})
+
+	t.Run("superseding cycle", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "a", "version": "0.1.0"},"supersedes":["b"]},{"manifest":{"id": "b", "version": "0.1.0"},"supersedes":["c"]},{"manifest":{"id": "c", "version": "0.1.0"},"supersedes":["a"]}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: plugin a supersedes itself through its successors")
+		require.Nil(t, store)
+	})
+
+	t.Run("invalid hosting requirement", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"hosting":"hybrid"}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid hosting requirement hybrid for plugin 0 (manifest.Id test, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("invalid server version range", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"server_version_range":">=five"}]`)), logger)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to validate plugins: invalid server version range for plugin 0 (manifest.Id test, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
+	t.Run("invalid release stage", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := New(bytes.NewReader([]byte(`[{"manifest":{"id": "test", "version": "0.1.0"},"release_stage":"alpha"}]`)), logger)
+		require.EqualError(t, err, "failed to validate plugins: invalid release stage alpha for plugin 0 (manifest.Id test, version 0.1.0)")
+		require.Nil(t, store)
+	})
+
 	t.Run("valid stream", func(t *testing.T) {
 		logger := testlib.MakeLogger(t)
-		store, err := New(bytes.NewReader([]byte(`[{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-demo","IconData":"icon-data.svg","DownloadURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz","DownloadSignature":"c2lnbmF0dXJl","ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0","Manifest":{"id": "test", "version": "0.1.0"}},{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-starter-template","DownloadURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz","Signatures":[{"signature":"signature2","public_key_hash":"hash2"}],"ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/v0.1.0","Manifest":{"id": "test", "version": "0.1.0"}}]`)), logger)
+		store, err := New(bytes.NewReader([]byte(`[{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-demo","IconData":"data:image/svg+xml;base64,PHN2Zy8+","DownloadURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/download/v0.1.0/com.mattermost.demo-plugin-0.1.0.tar.gz","DownloadSignature":"c2lnbmF0dXJl","ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-demo/releases/v0.1.0","Manifest":{"id": "test", "version": "0.1.0"}},{"HomepageURL":"https://github.com/mattermost/mattermost-plugin-starter-template","DownloadURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/download/v0.1.0/com.mattermost.plugin-starter-template-0.1.0.tar.gz","Signatures":[{"signature":"signature2","public_key_hash":"hash2"}],"ReleaseNotesURL":"https://github.com/mattermost/mattermost-plugin-starter-template/releases/v0.1.0","Manifest":{"id": "test", "version": "0.1.0"}}]`)), logger)
 		require.NoError(t, err)
 		require.NotNil(t, store)
 	})
 }
+
+func FuzzNew(f *testing.F) {
+	f.Add([]byte(`[{"manifest":{"id":"test","version":"0.1.0","min_server_version":"5.14.0"},"old_ids":["old"],"labels":[{"name":"Official"}]}]`))
+	f.Add([]byte(`[{"manifest":{"id":"test","version":"0.1.0"},"server_version_range":">=5.14.0 <6.0.0","platforms":{"linux-amd64":{"download_url":"https://example.com/test.tar.gz"}}}]`))
+	f.Add([]byte(`[{"manifest":{"id":"test","version":"0.1.0"},"icon_data":"data:image/svg+xml;base64,PHN2Zy8+"},{"manifest":{"id":"TEST","version":"0.2.0-rc1"}}]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		logger := logrus.New()
+		logger.SetOutput(ioutil.Discard)
+
+		store, err := New(bytes.NewReader(data), logger)
+		if err != nil {
+			return
+		}
+
+		// Whatever the store accepts, it must query without failing.
+		for _, serverVersion := range []string{"", "5.14.0"} {
+			plugins, err := store.GetPlugins(&model.PluginFilter{PerPage: model.AllPerPage, ServerVersion: serverVersion, IncludeIncompatible: true, Filter: "test"})
+			if err != nil {
+				t.Fatalf("failed to get plugins: %v", err)
+			}
+			for _, plugin := range plugins {
+				if _, err := store.GetPluginVersions(plugin.Manifest.Id); err != nil {
+					t.Fatalf("failed to get plugin versions: %v", err)
+				}
+			}
+		}
+	})
+}
+
+func TestNewStrict(t *testing.T) {
+	rules := &quality.Config{
+		Rules: []*quality.Rule{
+			{
+				Name:     "official-signed",
+				Severity: quality.SeverityBlock,
+				When:     quality.Condition{Labels: []string{"Official"}},
+				Require:  []quality.Check{quality.CheckSignature},
+			},
+			{
+				Name:     "production-icon",
+				Severity: quality.SeverityWarn,
+				When:     quality.Condition{ReleaseStages: []model.ReleaseStage{model.ReleaseStageProduction}},
+				Require:  []quality.Check{quality.CheckIcon},
+			},
+		},
+	}
+
+	t.Run("passing", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := NewWithOptions(bytes.NewReader([]byte(`[{"release_stage":"production","labels":[{"name":"Community"}],"manifest":{"id":"demo","version":"0.1.0"}}]`)), logger, Options{Rules: rules})
+		require.NoError(t, err)
+		require.Len(t, store.plugins, 1)
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		store, err := NewWithOptions(bytes.NewReader([]byte(`[{"labels":[{"name":"official"}],"manifest":{"id":"demo","version":"0.1.0"}}]`)), logger, Options{Rules: rules})
+		require.EqualError(t, err, "failed to validate plugins: 1 blocking rules failed: demo 0.1.0 is unsigned")
+		require.Nil(t, store)
+	})
+
+	t.Run("lax without rules", func(t *testing.T) {
+		logger := testlib.MakeLogger(t)
+		_, err := NewWithOptions(bytes.NewReader([]byte(`[{"labels":[{"name":"official"}],"manifest":{"id":"demo","version":"0.1.0"}}]`)), logger, Options{})
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,203 @@
+// Package submissions tracks community requests to include plugin repositories in the
+// marketplace through moderation, persisting them to a pluggable backend.
+package submissions
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+var (
+	// ErrNotFound is returned when the requested submission does not exist.
+	ErrNotFound = errors.New("submission not found")
+	// ErrDuplicate is returned when submitting a repository that is already pending or approved.
+	ErrDuplicate = errors.New("repository already submitted")
+	// ErrAlreadyModerated is returned when moderating a submission that is no longer pending.
+	ErrAlreadyModerated = errors.New("submission already moderated")
+)
+
+// Backend persists submissions.
+type Backend interface {
+	// Load returns all persisted submissions, or none if nothing was persisted yet.
+	Load() ([]*model.Submission, error)
+	// Save replaces the persisted submissions with the given submissions.
+	Save(submissions []*model.Submission) error
+}
+
+// Queue holds every submission, ordered by creation.
+type Queue struct {
+	backend Backend
+	now     func() time.Time
+	newID   func() string
+
+	lock        sync.Mutex
+	submissions []*model.Submission
+}
+
+// NewQueue creates a queue initialized with the submissions persisted to the given backend.
+func NewQueue(backend Backend) (*Queue, error) {
+	submissions, err := backend.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load submissions")
+	}
+
+	for _, submission := range submissions {
+		if err := submission.IsValid(); err != nil {
+			return nil, errors.Wrapf(err, "invalid submission %s", submission.ID)
+		}
+	}
+
+	return &Queue{
+		backend:     backend,
+		now:         time.Now,
+		newID:       mattermostModel.NewId,
+		submissions: submissions,
+	}, nil
+}
+
+// Submit queues the given repository for moderation on behalf of the given user.
+func (q *Queue) Submit(repositoryURL, submitterID string) (*model.Submission, error) {
+	now := q.now().UTC()
+	submission := &model.Submission{
+		ID:            q.newID(),
+		RepositoryURL: repositoryURL,
+		SubmitterID:   submitterID,
+		Status:        model.SubmissionStatusPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := submission.IsValid(); err != nil {
+		return nil, errors.Wrap(err, "invalid submission")
+	}
+
+	owner, name, _ := model.ParseGitHubRepository(repositoryURL)
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for _, existing := range q.submissions {
+		if existing.Status == model.SubmissionStatusRejected {
+			continue
+		}
+
+		existingOwner, existingName, _ := model.ParseGitHubRepository(existing.RepositoryURL)
+		if strings.EqualFold(owner, existingOwner) && strings.EqualFold(name, existingName) {
+			return nil, ErrDuplicate
+		}
+	}
+
+	if err := q.save(append(q.submissions, submission)); err != nil {
+		return nil, err
+	}
+
+	result := *submission
+	return &result, nil
+}
+
+// Approve accepts the given pending submission on behalf of the given moderator, annotating the
+// decision with the optional reason.
+func (q *Queue) Approve(id, moderatorID, reason string) (*model.Submission, error) {
+	return q.moderate(id, moderatorID, model.SubmissionStatusApproved, reason)
+}
+
+// Reject declines the given pending submission on behalf of the given moderator, explaining the
+// decision with the optional reason.
+func (q *Queue) Reject(id, moderatorID, reason string) (*model.Submission, error) {
+	return q.moderate(id, moderatorID, model.SubmissionStatusRejected, reason)
+}
+
+func (q *Queue) moderate(id, moderatorID string, status model.SubmissionStatus, reason string) (*model.Submission, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	index := q.find(id)
+	if index < 0 {
+		return nil, ErrNotFound
+	}
+	if q.submissions[index].Status != model.SubmissionStatusPending {
+		return nil, ErrAlreadyModerated
+	}
+
+	moderated := *q.submissions[index]
+	moderated.Status = status
+	moderated.ModeratorID = moderatorID
+	moderated.Reason = reason
+	moderated.UpdatedAt = q.now().UTC()
+
+	submissions := append([]*model.Submission(nil), q.submissions...)
+	submissions[index] = &moderated
+	if err := q.save(submissions); err != nil {
+		return nil, err
+	}
+
+	result := moderated
+	return &result, nil
+}
+
+// save persists the given submissions before adopting them. The lock must be held.
+func (q *Queue) save(submissions []*model.Submission) error {
+	if err := q.backend.Save(submissions); err != nil {
+		return errors.Wrap(err, "failed to save submissions")
+	}
+
+	q.submissions = submissions
+
+	return nil
+}
+
+// find returns the index of the given submission, or -1 if none. The lock must be held.
+func (q *Queue) find(id string) int {
+	for i, submission := range q.submissions {
+		if submission.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// GetSubmission returns the given submission.
+func (q *Queue) GetSubmission(id string) (*model.Submission, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	index := q.find(id)
+	if index < 0 {
+		return nil, ErrNotFound
+	}
+
+	result := *q.submissions[index]
+	return &result, nil
+}
+
+// GetSubmissions returns the submissions matching the given filter, most recent first.
+func (q *Queue) GetSubmissions(filter *model.SubmissionFilter) []*model.Submission {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	result := []*model.Submission{}
+	for _, submission := range q.submissions {
+		if filter.Status != "" && submission.Status != filter.Status {
+			continue
+		}
+		if filter.SubmitterID != "" && submission.SubmitterID != filter.SubmitterID {
+			continue
+		}
+
+		copied := *submission
+		result = append(result, &copied)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result
+}
@@ -0,0 +1,123 @@
+package submissions
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+func TestQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "submissions")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "submissions.json")
+
+	now := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+	nextID := 0
+	newQueue := func(t *testing.T) *Queue {
+		queue, err := NewQueue(&FileBackend{Path: path})
+		require.NoError(t, err)
+		queue.now = func() time.Time { return now }
+		queue.newID = func() string {
+			nextID++
+			return fmt.Sprintf("submission%d", nextID)
+		}
+
+		return queue
+	}
+
+	queue := newQueue(t)
+
+	t.Run("no submissions", func(t *testing.T) {
+		require.Empty(t, queue.GetSubmissions(&model.SubmissionFilter{}))
+
+		_, err := queue.GetSubmission("unknown")
+		require.Equal(t, ErrNotFound, err)
+	})
+
+	demo, err := queue.Submit("https://github.com/alice/demo", "alice")
+	require.NoError(t, err)
+	require.Equal(t, &model.Submission{
+		ID:            "submission1",
+		RepositoryURL: "https://github.com/alice/demo",
+		SubmitterID:   "alice",
+		Status:        model.SubmissionStatusPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}, demo)
+
+	now = now.Add(time.Hour)
+	starter, err := queue.Submit("https://github.com/bob/starter", "bob")
+	require.NoError(t, err)
+
+	t.Run("invalid repository", func(t *testing.T) {
+		_, err := queue.Submit("https://example.com/demo", "alice")
+		require.EqualError(t, err, "invalid submission: repository url https://example.com/demo must reference https://github.com")
+	})
+
+	t.Run("duplicate repository", func(t *testing.T) {
+		_, err := queue.Submit("https://github.com/Alice/demo.git", "bob")
+		require.Equal(t, ErrDuplicate, err)
+	})
+
+	t.Run("filters submissions", func(t *testing.T) {
+		require.Equal(t, []*model.Submission{starter, demo}, queue.GetSubmissions(&model.SubmissionFilter{}))
+		require.Equal(t, []*model.Submission{demo}, queue.GetSubmissions(&model.SubmissionFilter{SubmitterID: "alice"}))
+		require.Empty(t, queue.GetSubmissions(&model.SubmissionFilter{Status: model.SubmissionStatusApproved}))
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		approved, err := queue.Approve(demo.ID, "moderator", "")
+		require.NoError(t, err)
+		require.Equal(t, model.SubmissionStatusApproved, approved.Status)
+		require.Equal(t, "moderator", approved.ModeratorID)
+		require.Equal(t, now, approved.UpdatedAt)
+		require.Equal(t, demo.CreatedAt, approved.CreatedAt)
+
+		_, err = queue.Approve(demo.ID, "moderator", "")
+		require.Equal(t, ErrAlreadyModerated, err)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		rejected, err := queue.Reject(starter.ID, "moderator", "No releases.")
+		require.NoError(t, err)
+		require.Equal(t, model.SubmissionStatusRejected, rejected.Status)
+		require.Equal(t, "No releases.", rejected.Reason)
+
+		_, err = queue.Reject(starter.ID, "moderator", "")
+		require.Equal(t, ErrAlreadyModerated, err)
+
+		// A rejected repository may be submitted again.
+		_, err = queue.Submit("https://github.com/bob/starter", "bob")
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown submission", func(t *testing.T) {
+		_, err := queue.Approve("unknown", "moderator", "")
+		require.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("persists across restarts", func(t *testing.T) {
+		restarted := newQueue(t)
+		require.Equal(t, queue.GetSubmissions(&model.SubmissionFilter{}), restarted.GetSubmissions(&model.SubmissionFilter{}))
+
+		approved := restarted.GetSubmissions(&model.SubmissionFilter{Status: model.SubmissionStatusApproved})
+		require.Len(t, approved, 1)
+		require.Equal(t, demo.ID, approved[0].ID)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(`[{"id": "submission1"}]`), 0600))
+
+		_, err := NewQueue(&FileBackend{Path: path})
+		require.EqualError(t, err, "invalid submission submission1: repository url  must reference https://github.com")
+	})
+}
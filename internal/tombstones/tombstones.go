@@ -0,0 +1,138 @@
+// Package tombstones remembers the plugin versions removed from the catalog, persisting them to a
+// pluggable backend.
+package tombstones
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Backend persists tombstones.
+type Backend interface {
+	// Load returns all persisted tombstones, or none if nothing was persisted yet.
+	Load() ([]*model.Tombstone, error)
+	// Save replaces the persisted tombstones with the given tombstones.
+	Save(tombstones []*model.Tombstone) error
+}
+
+// Registry holds a tombstone for each plugin version removed from the catalog and not since
+// restored.
+type Registry struct {
+	backend Backend
+	now     func() time.Time
+
+	lock       sync.RWMutex
+	tombstones []*model.Tombstone
+}
+
+// New creates a registry initialized with the tombstones persisted to the given backend.
+func New(backend Backend) (*Registry, error) {
+	tombstones, err := backend.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load tombstones")
+	}
+
+	for _, tombstone := range tombstones {
+		if err := tombstone.IsValid(); err != nil {
+			return nil, errors.Wrap(err, "invalid tombstone")
+		}
+	}
+
+	return &Registry{
+		backend:    backend,
+		now:        time.Now,
+		tombstones: tombstones,
+	}, nil
+}
+
+// Record adds a tombstone with the given reason for each plugin version removed by the given
+// catalog changes, replacing any earlier tombstone of the same version. The tombstones of versions
+// added back are discarded.
+func (r *Registry) Record(changes []*model.CatalogChange, reason string) error {
+	type key struct{ pluginID, version string }
+	removed := map[key]*model.Tombstone{}
+	restored := map[key]bool{}
+	removedAt := r.now().UTC()
+	for _, change := range changes {
+		k := key{change.PluginID, change.Version}
+		switch change.Type {
+		case model.ChangeTypeRemoved:
+			removed[k] = &model.Tombstone{
+				PluginID:  change.PluginID,
+				Version:   change.Version,
+				Reason:    reason,
+				RemovedAt: removedAt,
+			}
+			delete(restored, k)
+		case model.ChangeTypeAdded:
+			restored[k] = true
+			delete(removed, k)
+		}
+	}
+	if len(removed) == 0 && len(restored) == 0 {
+		return nil
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	tombstones := []*model.Tombstone{}
+	for _, existing := range r.tombstones {
+		k := key{existing.PluginID, existing.Version}
+		if removed[k] == nil && !restored[k] {
+			tombstones = append(tombstones, existing)
+		}
+	}
+	for _, change := range changes {
+		if tombstone := removed[key{change.PluginID, change.Version}]; tombstone != nil {
+			tombstones = append(tombstones, tombstone)
+		}
+	}
+	if len(tombstones) == len(r.tombstones) && len(removed) == 0 {
+		return nil
+	}
+
+	return r.save(tombstones)
+}
+
+// save persists the given tombstones before adopting them. The lock must be held.
+func (r *Registry) save(tombstones []*model.Tombstone) error {
+	if err := r.backend.Save(tombstones); err != nil {
+		return errors.Wrap(err, "failed to save tombstones")
+	}
+
+	r.tombstones = tombstones
+
+	return nil
+}
+
+// GetTombstones returns the tombstones of the given plugin, or of every plugin if none is given,
+// optionally limited to the given version, most recently removed first.
+func (r *Registry) GetTombstones(pluginID, version string) []*model.Tombstone {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	result := []*model.Tombstone{}
+	for _, tombstone := range r.tombstones {
+		if pluginID != "" && tombstone.PluginID != pluginID {
+			continue
+		}
+		if version != "" && tombstone.Version != version {
+			continue
+		}
+
+		copied := *tombstone
+		result = append(result, &copied)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].RemovedAt.After(result[j].RemovedAt)
+	})
+
+	return result
+}
@@ -0,0 +1,121 @@
+package tombstones
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// failingBackend fails to save, to check that tombstones are only adopted once persisted.
+type failingBackend struct{}
+
+func (b *failingBackend) Load() ([]*model.Tombstone, error) {
+	return nil, nil
+}
+
+func (b *failingBackend) Save(tombstones []*model.Tombstone) error {
+	return errors.New("disk full")
+}
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tombstones")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tombstones.json")
+
+	now := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+	newRegistry := func(t *testing.T) *Registry {
+		registry, err := New(&FileBackend{Path: path})
+		require.NoError(t, err)
+		registry.now = func() time.Time { return now }
+
+		return registry
+	}
+
+	registry := newRegistry(t)
+
+	t.Run("no tombstones", func(t *testing.T) {
+		require.Empty(t, registry.GetTombstones("", ""))
+	})
+
+	t.Run("only removals recorded", func(t *testing.T) {
+		require.NoError(t, registry.Record([]*model.CatalogChange{
+			{Type: model.ChangeTypeAdded, PluginID: "jira", Version: "3.0.0"},
+			{Type: model.ChangeTypeUpdated, PluginID: "demo", Version: "1.0.0"},
+			{Type: model.ChangeTypeRemoved, PluginID: "jira", Version: "2.0.0"},
+		}, model.TombstoneReasonReloaded))
+
+		require.Equal(t, []*model.Tombstone{
+			{PluginID: "jira", Version: "2.0.0", Reason: model.TombstoneReasonReloaded, RemovedAt: now},
+		}, registry.GetTombstones("", ""))
+	})
+
+	now = now.Add(time.Hour)
+
+	t.Run("later removals first", func(t *testing.T) {
+		require.NoError(t, registry.Record([]*model.CatalogChange{
+			{Type: model.ChangeTypeRemoved, PluginID: "demo", Version: "1.0.0"},
+		}, model.TombstoneReasonDelisted))
+
+		tombstones := registry.GetTombstones("", "")
+		require.Len(t, tombstones, 2)
+		require.Equal(t, "demo", tombstones[0].PluginID)
+		require.Equal(t, model.TombstoneReasonDelisted, tombstones[0].Reason)
+		require.Equal(t, "jira", tombstones[1].PluginID)
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		require.Len(t, registry.GetTombstones("jira", ""), 1)
+		require.Len(t, registry.GetTombstones("jira", "2.0.0"), 1)
+		require.Empty(t, registry.GetTombstones("jira", "3.0.0"))
+		require.Empty(t, registry.GetTombstones("unknown", ""))
+	})
+
+	t.Run("persisted", func(t *testing.T) {
+		require.Equal(t, registry.GetTombstones("", ""), newRegistry(t).GetTombstones("", ""))
+	})
+
+	t.Run("restored version discarded", func(t *testing.T) {
+		require.NoError(t, registry.Record([]*model.CatalogChange{
+			{Type: model.ChangeTypeAdded, PluginID: "jira", Version: "2.0.0"},
+		}, model.TombstoneReasonRolledBack))
+
+		require.Empty(t, registry.GetTombstones("jira", ""))
+		require.Empty(t, newRegistry(t).GetTombstones("jira", ""))
+	})
+
+	t.Run("removed again", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		require.NoError(t, registry.Record([]*model.CatalogChange{
+			{Type: model.ChangeTypeRemoved, PluginID: "demo", Version: "1.0.0"},
+		}, model.TombstoneReasonImported))
+
+		require.Equal(t, []*model.Tombstone{
+			{PluginID: "demo", Version: "1.0.0", Reason: model.TombstoneReasonImported, RemovedAt: now},
+		}, registry.GetTombstones("", ""))
+	})
+
+	t.Run("invalid persisted tombstone", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(`[{"plugin_id":"jira"}]`), 0600))
+		_, err := New(&FileBackend{Path: path})
+		require.EqualError(t, err, "invalid tombstone: tombstone of jira has no version")
+	})
+
+	t.Run("failing backend", func(t *testing.T) {
+		registry, err := New(&failingBackend{})
+		require.NoError(t, err)
+
+		err = registry.Record([]*model.CatalogChange{
+			{Type: model.ChangeTypeRemoved, PluginID: "jira", Version: "2.0.0"},
+		}, model.TombstoneReasonReloaded)
+		require.EqualError(t, err, "failed to save tombstones: disk full")
+		require.Empty(t, registry.GetTombstones("", ""))
+	})
+}
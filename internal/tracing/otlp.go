@@ -0,0 +1,265 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// exportTimeout bounds each request to the collector.
+const exportTimeout = 10 * time.Second
+
+// OTLPExporter exports spans to a collector using the JSON encoding of the OTLP/HTTP protocol.
+type OTLPExporter struct {
+	url        string
+	httpClient *http.Client
+}
+
+var _ sdktrace.SpanExporter = (*OTLPExporter)(nil)
+
+// NewOTLPExporter creates an exporter to the OTLP/HTTP receiver at the given base url.
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	return &OTLPExporter{
+		url:        strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		httpClient: &http.Client{Timeout: exportTimeout},
+	}
+}
+
+// ExportSpans sends the given spans to the collector.
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(encodeSpans(spans))
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal spans")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "failed to build request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to export spans")
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("failed to export spans: collector responded with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Shutdown releases the exporter's idle connections.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.httpClient.CloseIdleConnections()
+
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource      `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope   `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Events            []otlpEvent     `json:"events,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpEvent struct {
+	Name         string          `json:"name"`
+	TimeUnixNano string          `json:"timeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpValue `json:"values"`
+}
+
+// OTLP status codes, which differ in order from those of the OpenTelemetry API.
+const (
+	otlpStatusOk    = 1
+	otlpStatusError = 2
+)
+
+// encodeSpans groups the given spans by resource and instrumentation scope, as OTLP requires.
+func encodeSpans(spans []sdktrace.ReadOnlySpan) *otlpRequest {
+	request := &otlpRequest{}
+	resources := map[*resource.Resource]*otlpResourceSpans{}
+	scopes := map[*resource.Resource]map[instrumentation.Scope]*otlpScopeSpans{}
+
+	for _, span := range spans {
+		spanResource := span.Resource()
+		resourceSpans, ok := resources[spanResource]
+		if !ok {
+			resourceSpans = &otlpResourceSpans{}
+			if spanResource != nil {
+				resourceSpans.Resource.Attributes = encodeAttributes(spanResource.Attributes())
+			}
+			resources[spanResource] = resourceSpans
+			scopes[spanResource] = map[instrumentation.Scope]*otlpScopeSpans{}
+			request.ResourceSpans = append(request.ResourceSpans, resourceSpans)
+		}
+
+		scope := span.InstrumentationScope()
+		scopeSpans, ok := scopes[spanResource][scope]
+		if !ok {
+			scopeSpans = &otlpScopeSpans{Scope: otlpScope{Name: scope.Name, Version: scope.Version}}
+			scopes[spanResource][scope] = scopeSpans
+			resourceSpans.ScopeSpans = append(resourceSpans.ScopeSpans, scopeSpans)
+		}
+
+		scopeSpans.Spans = append(scopeSpans.Spans, encodeSpan(span))
+	}
+
+	return request
+}
+
+func encodeSpan(span sdktrace.ReadOnlySpan) *otlpSpan {
+	encoded := &otlpSpan{
+		TraceID:           span.SpanContext().TraceID().String(),
+		SpanID:            span.SpanContext().SpanID().String(),
+		Name:              span.Name(),
+		Kind:              int(span.SpanKind()),
+		StartTimeUnixNano: encodeTime(span.StartTime()),
+		EndTimeUnixNano:   encodeTime(span.EndTime()),
+		Attributes:        encodeAttributes(span.Attributes()),
+	}
+	if span.Parent().HasSpanID() {
+		encoded.ParentSpanID = span.Parent().SpanID().String()
+	}
+
+	for _, event := range span.Events() {
+		encoded.Events = append(encoded.Events, otlpEvent{
+			Name:         event.Name,
+			TimeUnixNano: encodeTime(event.Time),
+			Attributes:   encodeAttributes(event.Attributes),
+		})
+	}
+
+	switch span.Status().Code {
+	case codes.Ok:
+		encoded.Status.Code = otlpStatusOk
+	case codes.Error:
+		encoded.Status.Code = otlpStatusError
+		encoded.Status.Message = span.Status().Description
+	}
+
+	return encoded
+}
+
+func encodeTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func encodeAttributes(attributes []attribute.KeyValue) []otlpAttribute {
+	var encoded []otlpAttribute
+	for _, kv := range attributes {
+		encoded = append(encoded, otlpAttribute{Key: string(kv.Key), Value: encodeValue(kv.Value)})
+	}
+
+	return encoded
+}
+
+func encodeValue(value attribute.Value) otlpValue {
+	switch value.Type() {
+	case attribute.BOOL:
+		v := value.AsBool()
+		return otlpValue{BoolValue: &v}
+	case attribute.INT64:
+		v := strconv.FormatInt(value.AsInt64(), 10)
+		return otlpValue{IntValue: &v}
+	case attribute.FLOAT64:
+		v := value.AsFloat64()
+		return otlpValue{DoubleValue: &v}
+	case attribute.BOOLSLICE:
+		values := []otlpValue{}
+		for _, v := range value.AsBoolSlice() {
+			values = append(values, encodeValue(attribute.BoolValue(v)))
+		}
+		return otlpValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.INT64SLICE:
+		values := []otlpValue{}
+		for _, v := range value.AsInt64Slice() {
+			values = append(values, encodeValue(attribute.Int64Value(v)))
+		}
+		return otlpValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.FLOAT64SLICE:
+		values := []otlpValue{}
+		for _, v := range value.AsFloat64Slice() {
+			values = append(values, encodeValue(attribute.Float64Value(v)))
+		}
+		return otlpValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.STRINGSLICE:
+		values := []otlpValue{}
+		for _, v := range value.AsStringSlice() {
+			values = append(values, encodeValue(attribute.StringValue(v)))
+		}
+		return otlpValue{ArrayValue: &otlpArrayValue{Values: values}}
+	default:
+		v := value.Emit()
+		return otlpValue{StringValue: &v}
+	}
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	var received map[string]interface{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/traces", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(collector.URL + "/")
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "marketplace"))),
+	)
+	tracer := provider.Tracer(InstrumentationName)
+
+	t.Run("exports spans", func(t *testing.T) {
+		ctx, parent := tracer.Start(context.Background(), "GET /api/v1/plugins", trace.WithSpanKind(trace.SpanKindServer))
+		_, child := tracer.Start(ctx, "store.GetPlugins")
+		child.SetAttributes(attribute.Int("plugins", 3), attribute.StringSlice("ids", []string{"demo"}))
+		child.SetStatus(codes.Error, "failed")
+		child.End()
+
+		resourceSpans := received["resourceSpans"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "marketplace"}},
+		}, resourceSpans["resource"].(map[string]interface{})["attributes"])
+
+		scopeSpans := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, map[string]interface{}{"name": InstrumentationName}, scopeSpans["scope"])
+
+		span := scopeSpans["spans"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "store.GetPlugins", span["name"])
+		require.Equal(t, parent.SpanContext().TraceID().String(), span["traceId"])
+		require.Equal(t, parent.SpanContext().SpanID().String(), span["parentSpanId"])
+		require.EqualValues(t, trace.SpanKindInternal, span["kind"])
+		require.Equal(t, map[string]interface{}{"code": float64(otlpStatusError), "message": "failed"}, span["status"])
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"key": "plugins", "value": map[string]interface{}{"intValue": "3"}},
+			map[string]interface{}{"key": "ids", "value": map[string]interface{}{"arrayValue": map[string]interface{}{
+				"values": []interface{}{map[string]interface{}{"stringValue": "demo"}},
+			}}},
+		}, span["attributes"])
+
+		parent.End()
+		span = received["resourceSpans"].([]interface{})[0].(map[string]interface{})["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "GET /api/v1/plugins", span["name"])
+		require.NotContains(t, span, "parentSpanId")
+		require.EqualValues(t, trace.SpanKindServer, span["kind"])
+	})
+
+}
+
+func TestOTLPExporterStatusCode(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	_, span := provider.Tracer(InstrumentationName).Start(context.Background(), "span")
+	span.End()
+
+	err := NewOTLPExporter(collector.URL).ExportSpans(context.Background(), recorder.Ended())
+	require.EqualError(t, err, "failed to export spans: collector responded with status code 503")
+}
@@ -0,0 +1,39 @@
+// Package tracing exports the spans recorded by the marketplace to an OpenTelemetry collector.
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// InstrumentationName identifies the tracer creating the marketplace's spans.
+const InstrumentationName = "github.com/mattermost/mattermost-marketplace"
+
+// Propagator reads and writes the W3C trace context of requests, correlating the marketplace's
+// spans with those of its callers and of the services it calls.
+var Propagator = propagation.TraceContext{}
+
+// Options configures the export of spans.
+type Options struct {
+	// Endpoint is the base url of the collector's OTLP/HTTP receiver, e.g. http://localhost:4318.
+	Endpoint string
+	// ServiceName identifies the marketplace among the services reporting to the collector.
+	ServiceName string
+	// SampleRatio is the fraction of traces started by the marketplace that are recorded. Traces
+	// started by callers are recorded as the callers sampled them.
+	SampleRatio float64
+}
+
+// NewProvider creates a tracer provider exporting batches of recorded spans to the configured
+// collector. The provider must be shut down to flush the spans not yet exported.
+func NewProvider(options Options) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(NewOTLPExporter(options.Endpoint)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", options.ServiceName),
+		)),
+	)
+}
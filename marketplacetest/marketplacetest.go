@@ -0,0 +1,125 @@
+// Package marketplacetest runs the marketplace API server over HTTP against an in-memory catalog,
+// for downstream projects and integration tests exercising real HTTP behaviour without
+// maintaining fixtures of their own:
+//
+//	server := marketplacetest.NewServer(t, marketplacetest.NewPlugin("demo", "0.1.0"))
+//	defer server.Close()
+//
+//	plugins, err := server.Client.GetPlugins(&sdk.GetPluginsRequest{PerPage: sdk.AllPerPage})
+package marketplacetest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+
+	"github.com/mattermost/mattermost-marketplace/internal/testlib"
+	"github.com/mattermost/mattermost-marketplace/memstore"
+	"github.com/mattermost/mattermost-marketplace/sdk"
+)
+
+// bundlesPath prefixes the bundles served by the server itself.
+const bundlesPath = "/bundles/"
+
+// Server serves the marketplace API from an in-memory catalog, along with any plugin bundles
+// added to it. Its output is logged through the test it was created for.
+type Server struct {
+	*httptest.Server
+	// Catalog is the catalog served, which may be modified while it is served.
+	Catalog *memstore.Store
+	// Client is a client to the server.
+	Client *sdk.Client
+
+	lock    sync.RWMutex
+	bundles map[string][]byte
+}
+
+// NewServer starts a server seeded with the given plugins, failing the test should any be
+// invalid. The caller must close the server once done.
+func NewServer(tb testing.TB, plugins ...*sdk.Plugin) *Server {
+	tb.Helper()
+
+	s := &Server{
+		Catalog: memstore.New(),
+		bundles: map[string][]byte{},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", memstore.NewHandler(s.Catalog, testlib.MakeLogger(tb)))
+	mux.HandleFunc(bundlesPath, s.handleBundle)
+	s.Server = httptest.NewServer(mux)
+	s.Client = sdk.NewClient(s.URL)
+
+	s.AddPlugins(tb, plugins...)
+
+	return s
+}
+
+// AddPlugins adds the given plugin versions to the catalog served, failing the test should any be
+// invalid.
+func (s *Server) AddPlugins(tb testing.TB, plugins ...*sdk.Plugin) {
+	tb.Helper()
+
+	for _, plugin := range plugins {
+		if err := s.Catalog.AddPlugin(plugin); err != nil {
+			tb.Fatalf("failed to add plugin: %v", err)
+		}
+	}
+}
+
+// AddBundle serves the given bundle as the download of the given plugin version, adding the
+// version to the catalog with its download url and checksums pointing at the bundle. The plugin
+// is copied rather than modified.
+func (s *Server) AddBundle(tb testing.TB, plugin *sdk.Plugin, bundle []byte) *sdk.Plugin {
+	tb.Helper()
+
+	if plugin.Manifest == nil {
+		tb.Fatal("failed to add bundle: plugin has no manifest")
+	}
+
+	name := fmt.Sprintf("%s-%s.tar.gz", plugin.Manifest.Id, plugin.Manifest.Version)
+	s.lock.Lock()
+	s.bundles[name] = bundle
+	s.lock.Unlock()
+
+	checksums := sdk.NewChecksumsWriter()
+	_, _ = checksums.Write(bundle)
+
+	bundled := *plugin
+	bundled.DownloadURL = s.URL + bundlesPath + name
+	bundled.Checksums = checksums.Checksums()
+	s.AddPlugins(tb, &bundled)
+
+	return &bundled
+}
+
+func (s *Server) handleBundle(w http.ResponseWriter, r *http.Request) {
+	s.lock.RLock()
+	bundle, ok := s.bundles[strings.TrimPrefix(r.URL.Path, bundlesPath)]
+	s.lock.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(bundle))
+}
+
+// NewPlugin returns a valid production release of the given plugin version, named after its id
+// and downloaded from example.com.
+func NewPlugin(id, version string) *sdk.Plugin {
+	return &sdk.Plugin{
+		HomepageURL:  "https://github.com/mattermost/mattermost-plugin-" + id,
+		DownloadURL:  "https://example.com/" + id + "-" + version + ".tar.gz",
+		ReleaseStage: sdk.ReleaseStageProduction,
+		Manifest:     &mattermostModel.Manifest{Id: id, Name: id, Version: version},
+	}
+}
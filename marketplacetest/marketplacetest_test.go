@@ -0,0 +1,41 @@
+package marketplacetest_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/marketplacetest"
+	"github.com/mattermost/mattermost-marketplace/sdk"
+)
+
+func TestServer(t *testing.T) {
+	server := marketplacetest.NewServer(t, marketplacetest.NewPlugin("demo", "0.1.0"))
+	defer server.Close()
+
+	t.Run("serves the seeded plugins", func(t *testing.T) {
+		plugins, err := server.Client.GetPlugins(&sdk.GetPluginsRequest{PerPage: sdk.AllPerPage})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+		require.Equal(t, "demo", plugins[0].Manifest.Id)
+	})
+
+	t.Run("serves plugins added later", func(t *testing.T) {
+		server.AddPlugins(t, marketplacetest.NewPlugin("demo", "0.2.0"), marketplacetest.NewPlugin("jira", "3.0.0"))
+
+		plugins, err := server.Client.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, plugins, 2)
+	})
+
+	t.Run("serves bundles", func(t *testing.T) {
+		bundle := []byte("plugin bundle contents")
+		plugin := server.AddBundle(t, marketplacetest.NewPlugin("demo", "0.3.0"), bundle)
+		require.NotNil(t, plugin.Checksums)
+
+		var downloaded bytes.Buffer
+		require.NoError(t, server.Client.DownloadPluginByID("demo", "0.3.0", &downloaded))
+		require.Equal(t, bundle, downloaded.Bytes())
+	})
+}
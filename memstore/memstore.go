@@ -0,0 +1,233 @@
+// Package memstore provides an in-memory marketplace catalog that may be built and modified
+// programmatically, for projects embedding the marketplace such as test harnesses.
+//
+// A Store serves the same queries, with the same filtering, sorting and validation, as a server
+// backed by plugins.json, and may be served over HTTP by NewHandler:
+//
+//	catalog := memstore.New()
+//	err := catalog.AddPlugin(&memstore.Plugin{
+//		DownloadURL: "https://example.com/demo-0.1.0.tar.gz",
+//		Manifest:    &model.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0"},
+//	})
+//	server := httptest.NewServer(memstore.NewHandler(catalog, nil))
+package memstore
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+)
+
+// Plugin describes a version of a plugin served by the marketplace.
+type Plugin = model.Plugin
+
+// App describes an app served by the marketplace.
+type App = model.App
+
+// AppHostingType describes how an app is deployed.
+type AppHostingType = model.AppHostingType
+
+// PluginFilter describes the parameters used to query plugins.
+type PluginFilter = model.PluginFilter
+
+// AppFilter describes the parameters used to query apps.
+type AppFilter = model.AppFilter
+
+// AuthorType describes who authored a plugin.
+type AuthorType = model.AuthorType
+
+// ReleaseStage describes the maturity of a plugin.
+type ReleaseStage = model.ReleaseStage
+
+// HostingRequirement describes the deployments on which a plugin may be installed.
+type HostingRequirement = model.HostingRequirement
+
+// Label describes a label attached to a plugin.
+type Label = model.Label
+
+// Dependency names a plugin that must be installed for another plugin to work.
+type Dependency = model.Dependency
+
+// AllPerPage requests every result, without pagination.
+const AllPerPage = model.AllPerPage
+
+// Store is a catalog of plugins and apps held in memory. It is safe for concurrent use, with each
+// query observing the catalog as of the latest completed write.
+type Store struct {
+	logger logrus.FieldLogger
+
+	lock    sync.RWMutex
+	plugins []*Plugin
+	apps    []*App
+	current *store.Store
+}
+
+// New creates an empty store.
+func New() *Store {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	current, _ := store.NewFromPlugins(nil, logger, store.Options{})
+
+	return &Store{
+		logger:  logger,
+		current: current,
+	}
+}
+
+// AddPlugin adds the given plugin version to the catalog, replacing any plugin with the same id
+// and version. The plugin is validated as it would be in plugins.json, with its release stage
+// defaulted from its version if unset, and must not be modified once added.
+func (s *Store) AddPlugin(plugin *Plugin) error {
+	if plugin == nil || plugin.Manifest == nil {
+		return errors.New("plugin has no manifest")
+	}
+	if plugin.ReleaseStage == "" {
+		defaulted := *plugin
+		defaulted.ReleaseStage = model.DefaultReleaseStage(plugin.Manifest.Version)
+		plugin = &defaulted
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	plugins := make([]*Plugin, 0, len(s.plugins)+1)
+	for _, existing := range s.plugins {
+		if !isSameVersion(existing, plugin.Manifest.Id, plugin.Manifest.Version) {
+			plugins = append(plugins, existing)
+		}
+	}
+
+	return s.rebuild(append(plugins, plugin), s.apps)
+}
+
+// RemovePlugin removes the given plugin version from the catalog, reporting whether it was found.
+func (s *Store) RemovePlugin(id, version string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	plugins := make([]*Plugin, 0, len(s.plugins))
+	for _, existing := range s.plugins {
+		if !isSameVersion(existing, id, version) {
+			plugins = append(plugins, existing)
+		}
+	}
+	if len(plugins) == len(s.plugins) {
+		return false
+	}
+
+	// Removing a plugin cannot invalidate the remaining ones.
+	_ = s.rebuild(plugins, s.apps)
+
+	return true
+}
+
+// AddApp adds the given app to the catalog, replacing any app with the same id. The app is
+// validated as it would be in the apps database, and must not be modified once added.
+func (s *Store) AddApp(app *App) error {
+	if app == nil {
+		return errors.New("app is nil")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	apps := make([]*App, 0, len(s.apps)+1)
+	for _, existing := range s.apps {
+		if existing.ID != app.ID {
+			apps = append(apps, existing)
+		}
+	}
+
+	return s.rebuild(s.plugins, append(apps, app))
+}
+
+// RemoveApp removes the given app from the catalog, reporting whether it was found.
+func (s *Store) RemoveApp(id string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	apps := make([]*App, 0, len(s.apps))
+	for _, existing := range s.apps {
+		if existing.ID != id {
+			apps = append(apps, existing)
+		}
+	}
+	if len(apps) == len(s.apps) {
+		return false
+	}
+
+	_ = s.rebuild(s.plugins, apps)
+
+	return true
+}
+
+// rebuild validates and adopts the given plugins and apps, leaving the catalog unchanged if they
+// are invalid. The lock must be held.
+func (s *Store) rebuild(plugins []*Plugin, apps []*App) error {
+	rebuilt, err := store.NewFromPlugins(append([]*Plugin(nil), plugins...), s.logger, store.Options{})
+	if err != nil {
+		return err
+	}
+	if err := rebuilt.SetApps(append([]*App(nil), apps...)); err != nil {
+		return err
+	}
+
+	s.plugins = plugins
+	s.apps = apps
+	s.current = rebuilt
+
+	return nil
+}
+
+func (s *Store) currentStore() *store.Store {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.current
+}
+
+// GetPlugins fetches the given page of plugins, returning the latest version of each plugin
+// matching the filter, sorted by name.
+func (s *Store) GetPlugins(filter *PluginFilter) ([]*Plugin, error) {
+	return s.currentStore().GetPlugins(filter)
+}
+
+// GetPluginVersions fetches every version of the given plugin, sorted by version descending.
+func (s *Store) GetPluginVersions(id string) ([]*Plugin, error) {
+	return s.currentStore().GetPluginVersions(id)
+}
+
+// GetApps fetches the given page of apps, sorted by display name.
+func (s *Store) GetApps(filter *AppFilter) ([]*App, error) {
+	return s.currentStore().GetApps(filter)
+}
+
+// NewHandler returns a handler serving the marketplace API from the given store, as the
+// marketplace server does. The logger defaults to discarding all output.
+func NewHandler(s *Store, logger logrus.FieldLogger) http.Handler {
+	if logger == nil {
+		logger = s.logger
+	}
+
+	router := mux.NewRouter()
+	api.Register(router, &api.Context{
+		Store:  s,
+		Logger: logger,
+	})
+
+	return router
+}
+
+// isSameVersion reports whether the given plugin is the given version of the given plugin.
+func isSameVersion(plugin *Plugin, id, version string) bool {
+	return plugin.Manifest.Id == id && plugin.Manifest.Version == version
+}
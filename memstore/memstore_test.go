@@ -0,0 +1,110 @@
+package memstore_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/memstore"
+)
+
+func makePlugin(id, version string) *memstore.Plugin {
+	return &memstore.Plugin{
+		DownloadURL: "https://example.com/" + id + "-" + version + ".tar.gz",
+		Manifest:    &mattermostModel.Manifest{Id: id, Name: id, Version: version},
+	}
+}
+
+func TestStore(t *testing.T) {
+	catalog := memstore.New()
+
+	t.Run("empty", func(t *testing.T) {
+		plugins, err := catalog.GetPlugins(&memstore.PluginFilter{PerPage: memstore.AllPerPage})
+		require.NoError(t, err)
+		require.Empty(t, plugins)
+	})
+
+	t.Run("add plugins", func(t *testing.T) {
+		require.NoError(t, catalog.AddPlugin(makePlugin("demo", "0.1.0")))
+		require.NoError(t, catalog.AddPlugin(makePlugin("demo", "0.2.0")))
+		require.NoError(t, catalog.AddPlugin(makePlugin("starter", "1.0.0")))
+
+		plugins, err := catalog.GetPlugins(&memstore.PluginFilter{PerPage: memstore.AllPerPage})
+		require.NoError(t, err)
+		require.Len(t, plugins, 2)
+		require.Equal(t, "0.2.0", plugins[0].Manifest.Version)
+
+		versions, err := catalog.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+	})
+
+	t.Run("replace plugin version", func(t *testing.T) {
+		replacement := makePlugin("demo", "0.2.0")
+		replacement.DownloadURL = "https://example.com/rebuilt.tar.gz"
+		require.NoError(t, catalog.AddPlugin(replacement))
+
+		versions, err := catalog.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.Equal(t, "https://example.com/rebuilt.tar.gz", versions[0].DownloadURL)
+	})
+
+	t.Run("invalid plugin", func(t *testing.T) {
+		require.Error(t, catalog.AddPlugin(&memstore.Plugin{}))
+		require.Error(t, catalog.AddPlugin(makePlugin("invalid", "not-a-version")))
+
+		versions, err := catalog.GetPluginVersions("invalid")
+		require.NoError(t, err)
+		require.Empty(t, versions)
+	})
+
+	t.Run("remove plugin", func(t *testing.T) {
+		require.True(t, catalog.RemovePlugin("starter", "1.0.0"))
+		require.False(t, catalog.RemovePlugin("starter", "1.0.0"))
+
+		plugins, err := catalog.GetPlugins(&memstore.PluginFilter{PerPage: memstore.AllPerPage})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+	})
+
+	t.Run("apps", func(t *testing.T) {
+		require.Error(t, catalog.AddApp(&memstore.App{}))
+
+		app := &memstore.App{
+			ID:          "jira",
+			DisplayName: "Jira",
+			ManifestURL: "https://example.com/jira/manifest.json",
+			HostingType: "http",
+		}
+		require.NoError(t, catalog.AddApp(app))
+
+		apps, err := catalog.GetApps(&memstore.AppFilter{PerPage: memstore.AllPerPage})
+		require.NoError(t, err)
+		require.Len(t, apps, 1)
+
+		require.True(t, catalog.RemoveApp("jira"))
+		require.False(t, catalog.RemoveApp("jira"))
+	})
+}
+
+func TestNewHandler(t *testing.T) {
+	catalog := memstore.New()
+	require.NoError(t, catalog.AddPlugin(makePlugin("demo", "0.1.0")))
+
+	ts := httptest.NewServer(memstore.NewHandler(catalog, nil))
+	defer ts.Close()
+
+	client := api.NewClient(ts.URL)
+	plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: 10})
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+
+	require.NoError(t, catalog.AddPlugin(makePlugin("starter", "1.0.0")))
+	plugins, err = client.GetPlugins(&api.GetPluginsRequest{PerPage: 10})
+	require.NoError(t, err)
+	require.Len(t, plugins, 2)
+}
@@ -0,0 +1,123 @@
+package sdk
+
+import (
+	"io"
+
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Plugin represents a Mattermost plugin in the marketplace.
+type Plugin = model.Plugin
+
+// App represents a Mattermost App in the marketplace, listed alongside plugins.
+type App = model.App
+
+// AppHostingType describes how a Mattermost App is deployed.
+type AppHostingType = model.AppHostingType
+
+// AuthorType describes who develops and maintains a plugin, indicating its level of trust.
+type AuthorType = model.AuthorType
+
+// ReleaseStage describes the maturity of a plugin release.
+type ReleaseStage = model.ReleaseStage
+
+const (
+	// ReleaseStageProduction identifies releases ready for general use.
+	ReleaseStageProduction = model.ReleaseStageProduction
+	// ReleaseStageBeta identifies releases made available for early feedback.
+	ReleaseStageBeta = model.ReleaseStageBeta
+	// ReleaseStageExperimental identifies releases that may change or break without notice.
+	ReleaseStageExperimental = model.ReleaseStageExperimental
+)
+
+// HostingRequirement describes the kind of Mattermost installation a plugin supports.
+type HostingRequirement = model.HostingRequirement
+
+// LicenseTier describes the license of a Mattermost installation.
+type LicenseTier = model.LicenseTier
+
+// Label represents a badge displayed alongside a plugin, such as "Official" or "Beta".
+type Label = model.Label
+
+// Dependency names a plugin that must be installed for another plugin to work.
+type Dependency = model.Dependency
+
+// AllPerPage requests every result, without pagination.
+const AllPerPage = model.AllPerPage
+
+// PluginUpdate describes a newer version of an installed plugin.
+type PluginUpdate = model.PluginUpdate
+
+// PluginStats summarizes the download activity of a single plugin, and its adoption as reported
+// by Mattermost servers.
+type PluginStats = model.PluginStats
+
+// MarketplaceStats summarizes the download activity across all plugins.
+type MarketplaceStats = model.MarketplaceStats
+
+// InstallEvent is a Mattermost server's report of installing or upgrading a plugin. It is
+// anonymous, identifying neither the server nor its users.
+type InstallEvent = model.InstallEvent
+
+// PluginRatings describes the ratings and reviews of a single plugin.
+type PluginRatings = model.PluginRatings
+
+// RatingSummary aggregates the ratings of a single plugin.
+type RatingSummary = model.RatingSummary
+
+// CompatibilityMatrix describes the version of a plugin served to each of several server
+// versions.
+type CompatibilityMatrix = model.CompatibilityMatrix
+
+// Changelog aggregates the release notes of the versions of a plugin released after FromVersion,
+// up to and including ToVersion.
+type Changelog = model.Changelog
+
+// VersionBucket groups the versions of a plugin requiring the same minimum server version.
+type VersionBucket = model.VersionBucket
+
+// CatalogChanges is a page of the changes to a catalog.
+type CatalogChanges = model.CatalogChanges
+
+// CatalogSync brings a client's replica of a catalog up to date with the catalog served, listing
+// only the net change to each plugin version since the client's cursor.
+type CatalogSync = model.CatalogSync
+
+// CatalogDigest summarizes the catalog served, allowing mirrors and clients to detect divergence
+// from the canonical database.
+type CatalogDigest = model.CatalogDigest
+
+// PublicKey describes a key whose signatures the marketplace publishes, allowing clients to
+// obtain the keys verifying bundle and catalog signatures from the marketplace itself.
+type PublicKey = model.PublicKey
+
+// Advisory discloses a vulnerability in the versions of a plugin preceding the one fixing it.
+type Advisory = model.Advisory
+
+// Tombstone records a plugin version removed from the catalog, so that servers that installed it
+// can be warned rather than the version simply vanishing.
+type Tombstone = model.Tombstone
+
+// Featuring records whether a plugin is featured, overriding the Featured and FeaturedWeight
+// recorded in the database. Featured plugins are listed first by default, heaviest first.
+type Featuring = model.Featuring
+
+// Checksums records hex-encoded digests of a plugin bundle, allowing its download to be verified.
+type Checksums = model.Checksums
+
+// ChecksumsWriter computes the checksums of everything written to it.
+type ChecksumsWriter = model.ChecksumsWriter
+
+// Signature is a detached signature of a plugin bundle, as recorded in Plugin.Signatures.
+type Signature = model.Signature
+
+// NewChecksumsWriter creates a writer computing the checksums of the data written to it.
+func NewChecksumsWriter() *ChecksumsWriter {
+	return model.NewChecksumsWriter()
+}
+
+// PluginsFromReader decodes a json-encoded list of plugins, such as plugins.json, from the given
+// io.Reader.
+func PluginsFromReader(reader io.Reader) ([]*Plugin, error) {
+	return model.PluginsFromReader(reader)
+}
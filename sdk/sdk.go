@@ -0,0 +1,102 @@
+// Package sdk is the public Go SDK of the marketplace, exposing the client, the model types it
+// exchanges and the helpers verifying downloaded plugins under a stable import path, for
+// downstream tools that would otherwise copy the internal packages:
+//
+//	client := sdk.NewClient("https://api.integrations.mattermost.com")
+//	plugins, err := client.GetPlugins(&sdk.GetPluginsRequest{PerPage: sdk.AllPerPage})
+//
+// The SDK is versioned along with the marketplace's releases, following semantic versioning:
+// within a major version, identifiers are only ever added to this package, never removed or
+// changed incompatibly. Identifiers of the internal packages not exposed here carry no such
+// guarantee.
+package sdk
+
+import (
+	"io"
+
+	"github.com/blang/semver"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+)
+
+// Client is the programmatic interface to the marketplace server API.
+type Client = api.Client
+
+// NewClient creates a client to the marketplace server at the given address.
+func NewClient(address string) *Client {
+	return api.NewClient(address)
+}
+
+// PluginClient describes the marketplace operations commonly consumed by downstream tools,
+// allowing them to substitute a fake in tests. It is implemented by Client.
+type PluginClient = api.PluginClient
+
+// GetPluginsRequest describes the parameters used to query plugins.
+type GetPluginsRequest = api.GetPluginsRequest
+
+// GetAppsRequest describes the parameters used to query apps.
+type GetAppsRequest = api.GetAppsRequest
+
+// GetChangesRequest describes the parameters used to query catalog changes.
+type GetChangesRequest = api.GetChangesRequest
+
+// SubmitRatingRequest describes a rating submitted for a plugin.
+type SubmitRatingRequest = api.SubmitRatingRequest
+
+// PluginIcon is the icon of a plugin, as fetched by Client.GetPluginIcon.
+type PluginIcon = api.PluginIcon
+
+// ErrNotFound is returned by the client when the requested resource does not exist.
+var ErrNotFound = api.ErrNotFound
+
+// ErrUnknownCursor is returned by the client when the server no longer retains the changes after
+// the requested cursor. Clients should resynchronize by fetching the plugins afresh.
+var ErrUnknownCursor = api.ErrUnknownCursor
+
+// StatusError is returned by the client for an unexpected status code.
+type StatusError = api.StatusError
+
+// ServerError is returned by the client when the server fails to handle a request.
+type ServerError = api.ServerError
+
+// RateLimitedError is returned by the client when the server throttles its requests.
+type RateLimitedError = api.RateLimitedError
+
+// CheckUpdates reports which of the given installed plugins, mapping plugin ids to installed
+// versions, have a newer version compatible with the given server version using the given client.
+// An empty server version considers the latest version of each plugin.
+func CheckUpdates(client PluginClient, installed map[string]string, serverVersion string) ([]*PluginUpdate, error) {
+	return api.CheckUpdates(client, installed, serverVersion)
+}
+
+// FindPlugin resolves the plugin with the given id and, if non-empty, version using the given
+// client.
+func FindPlugin(client PluginClient, id, version string) (*Plugin, error) {
+	return api.FindPlugin(client, id, version)
+}
+
+// PluginVerification reports the checks of a downloaded plugin bundle against the checksums and
+// signatures recorded by the marketplace, as returned by Client.VerifyPlugin.
+type PluginVerification = api.PluginVerification
+
+// SignatureVerification reports the check of a single signature of a plugin bundle.
+type SignatureVerification = api.SignatureVerification
+
+// ReadPublicKeys parses the given armored or binary OpenPGP public keys into a single keyring.
+func ReadPublicKeys(readers ...io.Reader) (openpgp.EntityList, error) {
+	return api.ReadPublicKeys(readers...)
+}
+
+// VerifyPluginSignature verifies the given bundle against a base64-encoded detached signature, as
+// recorded in Plugin.Signature, using the given keyring.
+func VerifyPluginSignature(bundle io.Reader, signature string, keyring openpgp.EntityList) error {
+	return api.VerifyPluginSignature(bundle, signature, keyring)
+}
+
+// ParseVersion parses the given plugin version, tolerating a leading v and surrounding
+// whitespace.
+func ParseVersion(version string) (semver.Version, error) {
+	return model.ParseVersion(version)
+}
@@ -0,0 +1,57 @@
+package sdk_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-marketplace/marketplacetest"
+	"github.com/mattermost/mattermost-marketplace/sdk"
+)
+
+func TestClient(t *testing.T) {
+	server := marketplacetest.NewServer(t, marketplacetest.NewPlugin("demo", "0.1.0"), marketplacetest.NewPlugin("demo", "0.2.0"))
+	defer server.Close()
+
+	client := sdk.NewClient(server.URL)
+
+	t.Run("get plugins", func(t *testing.T) {
+		plugins, err := client.GetPlugins(&sdk.GetPluginsRequest{PerPage: sdk.AllPerPage})
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+		require.Equal(t, "0.2.0", plugins[0].Manifest.Version)
+	})
+
+	t.Run("find plugin", func(t *testing.T) {
+		plugin, err := sdk.FindPlugin(client, "demo", "0.1.0")
+		require.NoError(t, err)
+		require.Equal(t, "0.1.0", plugin.Manifest.Version)
+
+		_, err = sdk.FindPlugin(client, "unknown", "")
+		require.Equal(t, sdk.ErrNotFound, errors.Cause(err))
+	})
+
+	t.Run("check updates", func(t *testing.T) {
+		updates, err := sdk.CheckUpdates(client, map[string]string{"demo": "0.1.0"}, "")
+		require.NoError(t, err)
+		require.Len(t, updates, 1)
+	})
+}
+
+func TestChecksums(t *testing.T) {
+	bundle := []byte("plugin bundle contents")
+
+	writer := sdk.NewChecksumsWriter()
+	_, err := writer.Write(bundle)
+	require.NoError(t, err)
+	checksums := writer.Checksums()
+
+	other := sdk.NewChecksumsWriter()
+	_, err = other.Write(bytes.ToUpper(bundle))
+	require.NoError(t, err)
+
+	require.NoError(t, checksums.Verify(checksums))
+	require.Error(t, checksums.Verify(other.Checksums()))
+}
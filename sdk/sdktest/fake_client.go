@@ -0,0 +1,175 @@
+// Package sdktest provides helpers for testing code built on the marketplace SDK, such as an
+// in-memory sdk.PluginClient.
+package sdktest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/mattermost/mattermost-marketplace/internal/store"
+	"github.com/mattermost/mattermost-marketplace/sdk"
+)
+
+// FakeClient is an in-memory implementation of sdk.PluginClient.
+//
+// Listings are answered by the same store used by the marketplace server, so filtering, server
+// version compatibility and paging behave exactly as they would over HTTP.
+type FakeClient struct {
+	// Bundles maps a plugin download url to the bundle served for it.
+	Bundles map[string][]byte
+	// PluginStats maps a plugin id to the statistics served for it.
+	PluginStats map[string]*sdk.PluginStats
+	// MarketplaceStats, if set, is served as the marketplace-wide statistics.
+	MarketplaceStats *sdk.MarketplaceStats
+	// Err, if set, is returned from every call instead of a result.
+	Err error
+
+	store *store.Store
+
+	lock     sync.Mutex
+	requests []sdk.GetPluginsRequest
+}
+
+var _ sdk.PluginClient = (*FakeClient)(nil)
+
+// NewFakeClient creates a fake client serving the given plugins.
+func NewFakeClient(plugins []*sdk.Plugin) (*FakeClient, error) {
+	if plugins == nil {
+		plugins = []*sdk.Plugin{}
+	}
+
+	data, err := json.Marshal(plugins)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal plugins")
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	pluginStore, err := store.New(bytes.NewReader(data), logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize store")
+	}
+
+	return &FakeClient{
+		Bundles:     map[string][]byte{},
+		PluginStats: map[string]*sdk.PluginStats{},
+		store:       pluginStore,
+	}, nil
+}
+
+// Requests returns the listing requests received so far, in order.
+func (c *FakeClient) Requests() []sdk.GetPluginsRequest {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]sdk.GetPluginsRequest(nil), c.requests...)
+}
+
+// GetPlugins returns the fake's plugins matching the given request.
+func (c *FakeClient) GetPlugins(request *sdk.GetPluginsRequest) ([]*sdk.Plugin, error) {
+	c.lock.Lock()
+	c.requests = append(c.requests, *request)
+	c.lock.Unlock()
+
+	if c.Err != nil {
+		return nil, c.Err
+	}
+
+	plugins, err := c.store.GetPlugins(request.PluginFilter())
+	if err != nil {
+		return nil, err
+	}
+	if plugins == nil {
+		plugins = []*sdk.Plugin{}
+	}
+
+	return plugins, nil
+}
+
+// GetPluginVersions returns every version of the fake's plugin with the given id.
+func (c *FakeClient) GetPluginVersions(id string) ([]*sdk.Plugin, error) {
+	if c.Err != nil {
+		return nil, c.Err
+	}
+
+	plugins, err := c.store.GetPluginVersions(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(plugins) == 0 {
+		return nil, sdk.ErrNotFound
+	}
+
+	return plugins, nil
+}
+
+// DownloadPlugin writes the registered bundle for the given plugin to w, verifying its checksum
+// as the real client does.
+func (c *FakeClient) DownloadPlugin(plugin *sdk.Plugin, w io.Writer) error {
+	if c.Err != nil {
+		return c.Err
+	}
+
+	bundle, ok := c.Bundles[plugin.DownloadURL]
+	if !ok {
+		return sdk.ErrNotFound
+	}
+
+	if _, err := w.Write(bundle); err != nil {
+		return errors.Wrap(err, "failed to stream plugin bundle")
+	}
+
+	if plugin.Checksums != nil {
+		checksumsWriter := sdk.NewChecksumsWriter()
+		_, _ = checksumsWriter.Write(bundle)
+		if err := plugin.Checksums.Verify(checksumsWriter.Checksums()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DownloadPluginByID resolves the plugin with the given id and writes its bundle to w.
+func (c *FakeClient) DownloadPluginByID(id, version string, w io.Writer) error {
+	plugin, err := sdk.FindPlugin(c, id, version)
+	if err != nil {
+		return err
+	}
+
+	return c.DownloadPlugin(plugin, w)
+}
+
+// GetPluginStats returns the registered statistics for the given plugin.
+func (c *FakeClient) GetPluginStats(id string) (*sdk.PluginStats, error) {
+	if c.Err != nil {
+		return nil, c.Err
+	}
+
+	stats, ok := c.PluginStats[id]
+	if !ok {
+		return nil, sdk.ErrNotFound
+	}
+
+	return stats, nil
+}
+
+// GetMarketplaceStats returns the registered marketplace-wide statistics.
+func (c *FakeClient) GetMarketplaceStats() (*sdk.MarketplaceStats, error) {
+	if c.Err != nil {
+		return nil, c.Err
+	}
+
+	if c.MarketplaceStats == nil {
+		return &sdk.MarketplaceStats{}, nil
+	}
+
+	return c.MarketplaceStats, nil
+}
@@ -0,0 +1,109 @@
+package sdktest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mattermost/mattermost-marketplace/internal/api"
+	"github.com/mattermost/mattermost-marketplace/internal/model"
+	mattermostModel "github.com/mattermost/mattermost-server/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClient(t *testing.T) {
+	bundle := []byte("plugin bundle contents")
+	checksum := sha256.Sum256(bundle)
+
+	demoPluginV1 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.1.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.1.0", MinServerVersion: "5.14.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	demoPluginV2 := &model.Plugin{
+		DownloadURL:  "https://example.com/demo-0.2.0.tar.gz",
+		Checksums:    &model.Checksums{SHA256: hex.EncodeToString(checksum[:])},
+		Manifest:     &mattermostModel.Manifest{Id: "demo", Name: "Demo", Version: "0.2.0", MinServerVersion: "5.16.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+	starterPlugin := &model.Plugin{
+		DownloadURL:  "https://example.com/starter-0.1.0.tar.gz",
+		Manifest:     &mattermostModel.Manifest{Id: "starter", Name: "Starter", Version: "0.1.0"},
+		ReleaseStage: model.ReleaseStageProduction,
+	}
+
+	client, err := NewFakeClient([]*model.Plugin{demoPluginV1, demoPluginV2, starterPlugin})
+	require.NoError(t, err)
+	client.Bundles[demoPluginV2.DownloadURL] = bundle
+
+	t.Run("get plugins", func(t *testing.T) {
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV2, starterPlugin}, plugins)
+	})
+
+	t.Run("get plugins by server version", func(t *testing.T) {
+		plugins, err := client.GetPlugins(&api.GetPluginsRequest{PerPage: model.AllPerPage, ServerVersion: "5.15.0"})
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV1, starterPlugin}, plugins)
+	})
+
+	t.Run("pager", func(t *testing.T) {
+		plugins, err := api.NewPluginsPager(client, &api.GetPluginsRequest{PerPage: 1}).All()
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV2, starterPlugin}, plugins)
+	})
+
+	t.Run("download by id", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, client.DownloadPluginByID("demo", "", &buf))
+		require.Equal(t, bundle, buf.Bytes())
+	})
+
+	t.Run("get plugin versions", func(t *testing.T) {
+		plugins, err := client.GetPluginVersions("demo")
+		require.NoError(t, err)
+		require.Equal(t, []*model.Plugin{demoPluginV2, demoPluginV1}, plugins)
+
+		_, err = client.GetPluginVersions("unknown")
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("download older version by id", func(t *testing.T) {
+		client.Bundles[demoPluginV1.DownloadURL] = []byte("older bundle")
+		defer delete(client.Bundles, demoPluginV1.DownloadURL)
+
+		var buf bytes.Buffer
+		require.NoError(t, client.DownloadPluginByID("demo", "0.1.0", &buf))
+		require.Equal(t, "older bundle", buf.String())
+
+		err := client.DownloadPluginByID("demo", "0.3.0", &bytes.Buffer{})
+		require.EqualError(t, err, "plugin demo with version 0.3.0: not found")
+	})
+
+	t.Run("download missing bundle", func(t *testing.T) {
+		err := client.DownloadPlugin(starterPlugin, &bytes.Buffer{})
+		require.Equal(t, api.ErrNotFound, err)
+	})
+
+	t.Run("download unknown id", func(t *testing.T) {
+		err := client.DownloadPluginByID("unknown", "", &bytes.Buffer{})
+		require.Equal(t, api.ErrNotFound, errors.Cause(err))
+	})
+
+	t.Run("records requests", func(t *testing.T) {
+		require.NotEmpty(t, client.Requests())
+		require.Equal(t, api.GetPluginsRequest{PerPage: model.AllPerPage}, client.Requests()[0])
+	})
+
+	t.Run("injected error", func(t *testing.T) {
+		client, err := NewFakeClient(nil)
+		require.NoError(t, err)
+		client.Err = &api.ServerError{StatusCode: 503}
+
+		_, err = client.GetPlugins(&api.GetPluginsRequest{})
+		require.Equal(t, client.Err, err)
+	})
+}